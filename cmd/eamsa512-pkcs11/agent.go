@@ -0,0 +1,297 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/problem"
+	"github.com/Redeaux-Corporation/eamsa512/tlstrust"
+)
+
+// Agent performs EAMSA 512 operations for the token
+type Agent interface {
+	// Encrypt returns ciphertext || nonce || tag
+	Encrypt(key, plaintext []byte) ([]byte, error)
+
+	// Decrypt verifies and decrypts ciphertext || nonce || tag
+	Decrypt(key, sealed []byte) ([]byte, error)
+}
+
+// errAuthenticationFailed is returned by Decrypt when the tag does not verify
+var errAuthenticationFailed = errors.New("authentication failed")
+
+// maxAgentResponse bounds the size of an agent response
+const maxAgentResponse = 64 << 20
+
+// httpAgent calls the EAMSA 512 REST server (/api/v1/encrypt and
+// /api/v1/decrypt) with hex-encoded binary payloads. Before the first
+// operation it agrees on a cipher suite through /api/v1/negotiate (see
+// negotiate); decryption accepts data of any suite.
+type httpAgent struct {
+	baseURL  string
+	client   *http.Client
+	minSuite eamsa512.Suite
+
+	mu    sync.Mutex
+	suite eamsa512.Suite // Zero until negotiated
+}
+
+// newHTTPAgent creates an agent client for cfg
+func newHTTPAgent(cfg *Config) (*httpAgent, error) {
+	verifier, err := tlstrust.New("pkcs11-agent", cfg.Config, auditTLSFailure)
+	if err != nil {
+		return nil, fmt.Errorf("agent TLS: %v", err)
+	}
+
+	minSuite := eamsa512.SuiteLegacy
+	if cfg.MinSuite != "" {
+		if minSuite, err = eamsa512.ParseSuite(cfg.MinSuite); err != nil {
+			return nil, fmt.Errorf("min_suite: %v", err)
+		}
+	}
+
+	return &httpAgent{
+		baseURL:  strings.TrimRight(cfg.AgentURL, "/"),
+		client:   verifier.HTTPClient(time.Duration(cfg.TimeoutSeconds) * time.Second),
+		minSuite: minSuite,
+	}, nil
+}
+
+// auditTLSFailure logs agent verification failures regardless of
+// EAMSA512_PKCS11_DEBUG; the connection carries key material
+func auditTLSFailure(f tlstrust.Failure) {
+	log.Printf("eamsa512-pkcs11: AUDIT TLS_VERIFY_FAILED %s", f.Error())
+}
+
+// agentEncryptRequest mirrors the server's EncryptRequest
+type agentEncryptRequest struct {
+	Plaintext string         `json:"plaintext"`
+	MasterKey string         `json:"master_key"`
+	Encoding  string         `json:"encoding"`
+	Suite     eamsa512.Suite `json:"suite,omitempty"`
+}
+
+// agentEncryptResponse mirrors the server's EncryptResponse
+type agentEncryptResponse struct {
+	Ciphertext string         `json:"ciphertext"`
+	Nonce      string         `json:"nonce"`
+	Tag        string         `json:"tag"`
+	Envelope   string         `json:"envelope"`
+	Suite      eamsa512.Suite `json:"suite"`
+}
+
+// agentDecryptRequest mirrors the server's DecryptRequest
+type agentDecryptRequest struct {
+	Ciphertext string `json:"ciphertext,omitempty"`
+	MasterKey  string `json:"master_key"`
+	Nonce      string `json:"nonce,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	Envelope   string `json:"envelope,omitempty"`
+	Encoding   string `json:"encoding"`
+}
+
+// agentNegotiateRequest mirrors the server's NegotiateRequest
+type agentNegotiateRequest struct {
+	MasterKey string           `json:"master_key"`
+	Suites    []eamsa512.Suite `json:"suites"`
+	Random    string           `json:"random"`
+}
+
+// agentNegotiateResponse mirrors the server's NegotiateResponse
+type agentNegotiateResponse struct {
+	Suite  eamsa512.Suite   `json:"suite"`
+	Suites []eamsa512.Suite `json:"suites"`
+	Random string           `json:"random"`
+	MAC    string           `json:"mac"`
+}
+
+// agentDecryptResponse mirrors the server's DecryptResponse
+type agentDecryptResponse struct {
+	Plaintext string `json:"plaintext"`
+	Verified  bool   `json:"verified"`
+}
+
+// Encrypt implements Agent; the result is an envelope unless the
+// negotiated suite is eamsa512.SuiteLegacy
+func (a *httpAgent) Encrypt(key, plaintext []byte) ([]byte, error) {
+	suite, err := a.negotiate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req := agentEncryptRequest{
+		Plaintext: hex.EncodeToString(plaintext),
+		MasterKey: hex.EncodeToString(key),
+		Encoding:  "hex",
+	}
+	if suite != eamsa512.SuiteLegacy {
+		req.Suite = suite
+	}
+
+	var resp agentEncryptResponse
+	if err := a.post("/api/v1/encrypt", req, &resp); err != nil {
+		return nil, err
+	}
+
+	if suite != eamsa512.SuiteLegacy {
+		if resp.Suite != suite || resp.Envelope == "" {
+			return nil, fmt.Errorf("agent answered in suite %v, want %v", resp.Suite, suite)
+		}
+		envelope, err := hex.DecodeString(resp.Envelope)
+		if err != nil {
+			return nil, fmt.Errorf("agent returned invalid hex: %v", err)
+		}
+		return envelope, nil
+	}
+
+	var sealed []byte
+	for _, part := range []string{resp.Ciphertext, resp.Nonce, resp.Tag} {
+		decoded, err := hex.DecodeString(part)
+		if err != nil {
+			return nil, fmt.Errorf("agent returned invalid hex: %v", err)
+		}
+		sealed = append(sealed, decoded...)
+	}
+
+	if len(sealed) < nonceSize+tagSize {
+		return nil, fmt.Errorf("agent returned %d bytes, too short for nonce and tag", len(sealed))
+	}
+
+	return sealed, nil
+}
+
+// Decrypt implements Agent for data of any suite. Envelopes go to the
+// server whole (it retries legacy data that merely starts with the
+// envelope magic); a server that predates negotiation only gets legacy
+// requests.
+func (a *httpAgent) Decrypt(key, sealed []byte) ([]byte, error) {
+	suite, err := a.negotiate(key)
+	if err != nil {
+		return nil, err
+	}
+
+	req := agentDecryptRequest{
+		MasterKey: hex.EncodeToString(key),
+		Encoding:  "hex",
+	}
+	if suite != eamsa512.SuiteLegacy && eamsa512.IsEnvelope(sealed) {
+		req.Envelope = hex.EncodeToString(sealed)
+	} else {
+		ciphertextLength := len(sealed) - nonceSize - tagSize
+		req.Ciphertext = hex.EncodeToString(sealed[:ciphertextLength])
+		req.Nonce = hex.EncodeToString(sealed[ciphertextLength : ciphertextLength+nonceSize])
+		req.Tag = hex.EncodeToString(sealed[ciphertextLength+nonceSize:])
+	}
+
+	var resp agentDecryptResponse
+	if err := a.post("/api/v1/decrypt", req, &resp); err != nil {
+		return nil, err
+	}
+
+	if !resp.Verified {
+		return nil, errAuthenticationFailed
+	}
+
+	plaintext, err := hex.DecodeString(resp.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("agent returned invalid hex: %v", err)
+	}
+
+	return plaintext, nil
+}
+
+// negotiate returns the cipher suite agreed with the server, negotiating
+// it under key on first use. The server's choice is authenticated under
+// key and checked to be the strongest both sides support, so a tampered
+// exchange fails (eamsa512.ErrDowngrade) rather than settling on a weaker
+// suite. A server that predates negotiation (404) can only use the legacy
+// suite, which is refused when min_suite is stronger.
+func (a *httpAgent) negotiate(key []byte) (eamsa512.Suite, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.suite != 0 {
+		return a.suite, nil
+	}
+
+	hello, err := eamsa512.NewHello(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var resp agentNegotiateResponse
+	err = a.post("/api/v1/negotiate", agentNegotiateRequest{
+		MasterKey: hex.EncodeToString(key),
+		Suites:    hello.Suites,
+		Random:    hex.EncodeToString(hello.Random),
+	}, &resp)
+
+	var suite eamsa512.Suite
+	var p *problem.Problem
+	switch {
+	case errors.As(err, &p) && p.Status == http.StatusNotFound:
+		suite = eamsa512.SuiteLegacy
+	case err != nil:
+		return 0, err
+	default:
+		random, randomErr := hex.DecodeString(resp.Random)
+		mac, macErr := hex.DecodeString(resp.MAC)
+		if randomErr != nil || macErr != nil {
+			return 0, fmt.Errorf("agent returned invalid hex in negotiation")
+		}
+		suite, err = hello.Accept(key, &eamsa512.Choice{Suite: resp.Suite, Suites: resp.Suites, Random: random, MAC: mac})
+		if err != nil {
+			log.Printf("eamsa512-pkcs11: AUDIT SUITE_DOWNGRADE_DETECTED offered %v, server chose %v", hello.Suites, resp.Suite)
+			return 0, err
+		}
+	}
+
+	if suite < a.minSuite {
+		return 0, fmt.Errorf("agent supports cipher suite %v at best, below min_suite %v", suite, a.minSuite)
+	}
+	logf("agent cipher suite: %v", suite)
+	a.suite = suite
+	return suite, nil
+}
+
+// post sends a JSON request and decodes the JSON response into out
+func (a *httpAgent) post(path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.Post(a.baseURL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("agent request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxAgentResponse))
+	if err != nil {
+		return fmt.Errorf("failed to read agent response: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := problem.Decode(resp.StatusCode, resp.Header, data)
+		if errors.Is(apiErr, problem.ErrDecryptionFailed) {
+			return errAuthenticationFailed
+		}
+		return fmt.Errorf("agent returned %w", apiErr)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid agent response: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/tlstrust"
+)
+
+// DefaultConfigPath is used when EAMSA512_PKCS11_CONFIG is not set
+const DefaultConfigPath = "/etc/eamsa512/pkcs11.json"
+
+// Config is the token configuration file. The agent connection is verified
+// with the embedded tlstrust settings (ca_file, ca_pins, spki_pins,
+// revocation, crl_files, server_name).
+type Config struct {
+	AgentURL       string      `json:"agent_url"`       // EAMSA 512 server, e.g. https://localhost:8080
+	TimeoutSeconds int         `json:"timeout_seconds"` // Per-request timeout (default 30)
+	TokenLabel     string      `json:"token_label"`     // Token label (default "EAMSA512")
+	PIN            string      `json:"pin"`             // User PIN (required)
+	Keys           []KeyConfig `json:"keys"`
+
+	// MinSuite is the weakest cipher suite the agent may settle on (see
+	// eamsa512.Suite): "legacy" (the default, which also allows servers
+	// that predate suite negotiation), "envelope-cbc" or "envelope-siv"
+	MinSuite string `json:"min_suite"`
+
+	tlstrust.Config
+}
+
+// KeyConfig is a secret-key object on the token
+type KeyConfig struct {
+	Label string `json:"label"`
+	ID    string `json:"id"`    // CKA_ID, hex-encoded (optional)
+	Key   string `json:"key"`   // 32-byte master key, hex-encoded
+	Usage string `json:"usage"` // "encrypt", "decrypt" or "" for both
+}
+
+// configPath returns the configuration file location
+func configPath() string {
+	if path := os.Getenv("EAMSA512_PKCS11_CONFIG"); path != "" {
+		return path
+	}
+	return DefaultConfigPath
+}
+
+// LoadConfig reads and validates a token configuration. The file holds
+// key material and must not be readable by group or others.
+func LoadConfig(path string) (*Config, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token config: %v", err)
+	}
+	if info.Mode().Perm()&0077 != 0 {
+		return nil, fmt.Errorf("token config %s is accessible by group or others (mode %v); chmod 600 it", path, info.Mode().Perm())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token config: %v", err)
+	}
+
+	cfg := &Config{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid token config %s: %v", path, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid token config %s: %v", path, err)
+	}
+
+	return cfg, nil
+}
+
+// Validate fills defaults and checks required fields
+func (cfg *Config) Validate() error {
+	if cfg.AgentURL == "" {
+		return fmt.Errorf("agent_url is required")
+	}
+	if cfg.PIN == "" {
+		return fmt.Errorf("pin is required")
+	}
+	if cfg.TimeoutSeconds == 0 {
+		cfg.TimeoutSeconds = 30
+	}
+	if cfg.TokenLabel == "" {
+		cfg.TokenLabel = "EAMSA512"
+	}
+	if len(cfg.TokenLabel) > 32 {
+		return fmt.Errorf("token_label longer than 32 bytes")
+	}
+	if cfg.MinSuite != "" {
+		if _, err := eamsa512.ParseSuite(cfg.MinSuite); err != nil {
+			return fmt.Errorf("min_suite: %v", err)
+		}
+	}
+	if err := cfg.Config.Validate(); err != nil {
+		return err
+	}
+
+	_, err := cfg.tokenKeys()
+	return err
+}
+
+// tokenKeys decodes the configured keys into token objects
+func (cfg *Config) tokenKeys() ([]*tokenKey, error) {
+	keys := make([]*tokenKey, 0, len(cfg.Keys))
+
+	for i, kc := range cfg.Keys {
+		if kc.Label == "" {
+			return nil, fmt.Errorf("keys[%d]: label is required", i)
+		}
+
+		value, err := hex.DecodeString(kc.Key)
+		if err != nil || len(value) != keySize {
+			return nil, fmt.Errorf("keys[%d] (%s): key must be %d hex-encoded bytes", i, kc.Label, keySize)
+		}
+
+		id, err := hex.DecodeString(kc.ID)
+		if err != nil {
+			return nil, fmt.Errorf("keys[%d] (%s): id must be hex-encoded", i, kc.Label)
+		}
+
+		key := &tokenKey{handle: uint(i + 1), label: kc.Label, id: id, value: value}
+		switch kc.Usage {
+		case "":
+			key.encrypt, key.decrypt = true, true
+		case "encrypt":
+			key.encrypt = true
+		case "decrypt":
+			key.decrypt = true
+		default:
+			return nil, fmt.Errorf("keys[%d] (%s): unknown usage %q (want encrypt, decrypt or empty)", i, kc.Label, kc.Usage)
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// logf reports errors that PKCS#11 return values cannot carry; set
+// EAMSA512_PKCS11_DEBUG=1 to enable
+func logf(format string, args ...interface{}) {
+	if os.Getenv("EAMSA512_PKCS11_DEBUG") == "" {
+		return
+	}
+	log.Printf("eamsa512-pkcs11: "+format, args...)
+}
@@ -0,0 +1,481 @@
+package main
+
+/*
+#include <stdlib.h>
+#include "pkcs11.h"
+
+extern CK_FUNCTION_LIST eamsa512FunctionList;
+*/
+import "C"
+
+import (
+	"unsafe"
+)
+
+// ulongSize is sizeof(CK_ULONG) on this platform
+const ulongSize = C.sizeof_CK_ULONG
+
+// Library and token information
+const (
+	manufacturerID     = "Redeaux Corporation"
+	libraryDescription = "EAMSA 512 software token"
+	tokenModel         = "EAMSA512-SW"
+	libraryMajor       = 1
+	libraryMinor       = 0
+
+	ckUnavailableInformation = ^C.CK_ULONG(0)
+
+	ckfLibraryCantCreateOSThreads = 0x01
+	ckfTokenPresent               = 0x01
+	ckfWriteProtected             = 0x02
+	ckfLoginRequired              = 0x04
+	ckfUserPinInitialized         = 0x08
+	ckfTokenInitialized           = 0x400
+	ckfSerialSession              = 0x04
+	ckfRWSession                  = 0x02
+)
+
+//export C_Initialize
+func C_Initialize(pInitArgs C.CK_VOID_PTR) C.CK_RV {
+	if pInitArgs != nil {
+		args := (*C.CK_C_INITIALIZE_ARGS)(pInitArgs)
+		if args.pReserved != nil {
+			return CKR_ARGUMENTS_BAD
+		}
+		// The Go runtime always creates OS threads
+		if args.flags&ckfLibraryCantCreateOSThreads != 0 {
+			return CKR_NEED_TO_CREATE_THREADS
+		}
+	}
+
+	return C.CK_RV(tok.initialize())
+}
+
+//export C_Finalize
+func C_Finalize(pReserved C.CK_VOID_PTR) C.CK_RV {
+	if pReserved != nil {
+		return CKR_ARGUMENTS_BAD
+	}
+	return C.CK_RV(tok.finalize())
+}
+
+//export C_GetInfo
+func C_GetInfo(pInfo C.CK_INFO_PTR) C.CK_RV {
+	if pInfo == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	pInfo.cryptokiVersion = C.CK_VERSION{major: 2, minor: 40}
+	pad(pInfo.manufacturerID[:], manufacturerID)
+	pInfo.flags = 0
+	pad(pInfo.libraryDescription[:], libraryDescription)
+	pInfo.libraryVersion = C.CK_VERSION{major: libraryMajor, minor: libraryMinor}
+
+	return CKR_OK
+}
+
+//export C_GetFunctionList
+func C_GetFunctionList(ppFunctionList C.CK_FUNCTION_LIST_PTR_PTR) C.CK_RV {
+	if ppFunctionList == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	*ppFunctionList = &C.eamsa512FunctionList
+	return CKR_OK
+}
+
+//export C_GetSlotList
+func C_GetSlotList(tokenPresent C.CK_BBOOL, pSlotList C.CK_SLOT_ID_PTR, pulCount C.CK_ULONG_PTR) C.CK_RV {
+	if _, r := tok.tokenLabel(); r != CKR_OK {
+		return C.CK_RV(r)
+	}
+	if pulCount == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	if pSlotList == nil {
+		*pulCount = 1
+		return CKR_OK
+	}
+	if *pulCount < 1 {
+		*pulCount = 1
+		return CKR_BUFFER_TOO_SMALL
+	}
+
+	*pSlotList = tokenSlotID
+	*pulCount = 1
+	return CKR_OK
+}
+
+//export C_GetSlotInfo
+func C_GetSlotInfo(slotID C.CK_SLOT_ID, pInfo C.CK_SLOT_INFO_PTR) C.CK_RV {
+	if r := checkSlot(slotID); r != CKR_OK {
+		return r
+	}
+	if pInfo == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	pad(pInfo.slotDescription[:], libraryDescription)
+	pad(pInfo.manufacturerID[:], manufacturerID)
+	pInfo.flags = ckfTokenPresent
+	pInfo.hardwareVersion = C.CK_VERSION{major: libraryMajor, minor: libraryMinor}
+	pInfo.firmwareVersion = C.CK_VERSION{major: libraryMajor, minor: libraryMinor}
+
+	return CKR_OK
+}
+
+//export C_GetTokenInfo
+func C_GetTokenInfo(slotID C.CK_SLOT_ID, pInfo C.CK_TOKEN_INFO_PTR) C.CK_RV {
+	label, r := tok.tokenLabel()
+	if r != CKR_OK {
+		return C.CK_RV(r)
+	}
+	if slotID != tokenSlotID {
+		return CKR_SLOT_ID_INVALID
+	}
+	if pInfo == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	open, rw := tok.sessionCounts()
+
+	pad(pInfo.label[:], label)
+	pad(pInfo.manufacturerID[:], manufacturerID)
+	pad(pInfo.model[:], tokenModel)
+	pad(pInfo.serialNumber[:], "1")
+	pInfo.flags = ckfWriteProtected | ckfLoginRequired | ckfUserPinInitialized | ckfTokenInitialized
+	pInfo.ulMaxSessionCount = 0 // CK_EFFECTIVELY_INFINITE
+	pInfo.ulSessionCount = C.CK_ULONG(open)
+	pInfo.ulMaxRwSessionCount = 0
+	pInfo.ulRwSessionCount = C.CK_ULONG(rw)
+	pInfo.ulMaxPinLen = 256
+	pInfo.ulMinPinLen = 1
+	pInfo.ulTotalPublicMemory = ckUnavailableInformation
+	pInfo.ulFreePublicMemory = ckUnavailableInformation
+	pInfo.ulTotalPrivateMemory = ckUnavailableInformation
+	pInfo.ulFreePrivateMemory = ckUnavailableInformation
+	pInfo.hardwareVersion = C.CK_VERSION{major: libraryMajor, minor: libraryMinor}
+	pInfo.firmwareVersion = C.CK_VERSION{major: libraryMajor, minor: libraryMinor}
+	pad(pInfo.utcTime[:], "")
+
+	return CKR_OK
+}
+
+//export C_GetMechanismList
+func C_GetMechanismList(slotID C.CK_SLOT_ID, pMechanismList C.CK_MECHANISM_TYPE_PTR, pulCount C.CK_ULONG_PTR) C.CK_RV {
+	if r := checkSlot(slotID); r != CKR_OK {
+		return r
+	}
+	if pulCount == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	if pMechanismList == nil {
+		*pulCount = 1
+		return CKR_OK
+	}
+	if *pulCount < 1 {
+		*pulCount = 1
+		return CKR_BUFFER_TOO_SMALL
+	}
+
+	*pMechanismList = CKM_EAMSA512
+	*pulCount = 1
+	return CKR_OK
+}
+
+//export C_GetMechanismInfo
+func C_GetMechanismInfo(slotID C.CK_SLOT_ID, mechType C.CK_MECHANISM_TYPE, pInfo C.CK_MECHANISM_INFO_PTR) C.CK_RV {
+	if r := checkSlot(slotID); r != CKR_OK {
+		return r
+	}
+	if mechType != CKM_EAMSA512 {
+		return CKR_MECHANISM_INVALID
+	}
+	if pInfo == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	pInfo.ulMinKeySize = keySize
+	pInfo.ulMaxKeySize = keySize
+	pInfo.flags = CKF_ENCRYPT | CKF_DECRYPT
+
+	return CKR_OK
+}
+
+//export C_OpenSession
+func C_OpenSession(slotID C.CK_SLOT_ID, flags C.CK_FLAGS, pApplication C.CK_VOID_PTR, notify C.CK_VOID_PTR, phSession C.CK_SESSION_HANDLE_PTR) C.CK_RV {
+	if phSession == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	handle, r := tok.openSession(uint(slotID), uint(flags))
+	if r != CKR_OK {
+		return C.CK_RV(r)
+	}
+
+	*phSession = C.CK_SESSION_HANDLE(handle)
+	return CKR_OK
+}
+
+//export C_CloseSession
+func C_CloseSession(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	return C.CK_RV(tok.closeSession(uint(hSession)))
+}
+
+//export C_CloseAllSessions
+func C_CloseAllSessions(slotID C.CK_SLOT_ID) C.CK_RV {
+	return C.CK_RV(tok.closeAllSessions(uint(slotID)))
+}
+
+//export C_GetSessionInfo
+func C_GetSessionInfo(hSession C.CK_SESSION_HANDLE, pInfo C.CK_SESSION_INFO_PTR) C.CK_RV {
+	if pInfo == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	state, rw, r := tok.sessionState(uint(hSession))
+	if r != CKR_OK {
+		return C.CK_RV(r)
+	}
+
+	pInfo.slotID = tokenSlotID
+	pInfo.state = C.CK_STATE(state)
+	pInfo.flags = ckfSerialSession
+	if rw {
+		pInfo.flags |= ckfRWSession
+	}
+	pInfo.ulDeviceError = 0
+
+	return CKR_OK
+}
+
+//export C_Login
+func C_Login(hSession C.CK_SESSION_HANDLE, userType C.CK_USER_TYPE, pPin C.CK_UTF8CHAR_PTR, ulPinLen C.CK_ULONG) C.CK_RV {
+	if pPin == nil && ulPinLen > 0 {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	pin := goBytes(unsafe.Pointer(pPin), ulPinLen)
+	defer zero(pin)
+
+	return C.CK_RV(tok.login(uint(hSession), uint(userType), pin))
+}
+
+//export C_Logout
+func C_Logout(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	return C.CK_RV(tok.logout(uint(hSession)))
+}
+
+//export C_GetAttributeValue
+func C_GetAttributeValue(hSession C.CK_SESSION_HANDLE, hObject C.CK_OBJECT_HANDLE, pTemplate C.CK_ATTRIBUTE_PTR, ulCount C.CK_ULONG) C.CK_RV {
+	if pTemplate == nil && ulCount > 0 {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	template := unsafe.Slice(pTemplate, ulCount)
+	types := make([]uint, len(template))
+	for i := range template {
+		types[i] = uint(template[i]._type)
+	}
+
+	values, r := tok.getAttributes(uint(hSession), uint(hObject), types)
+	if values == nil {
+		return C.CK_RV(r)
+	}
+
+	for i := range template {
+		a := &template[i]
+		value := values[i]
+
+		switch {
+		case value == nil:
+			a.ulValueLen = ckUnavailableInformation
+		case a.pValue == nil:
+			a.ulValueLen = C.CK_ULONG(len(value))
+		case a.ulValueLen < C.CK_ULONG(len(value)):
+			a.ulValueLen = ckUnavailableInformation
+			r = CKR_BUFFER_TOO_SMALL
+		default:
+			copy(unsafe.Slice((*byte)(a.pValue), len(value)), value)
+			a.ulValueLen = C.CK_ULONG(len(value))
+		}
+	}
+
+	return C.CK_RV(r)
+}
+
+//export C_FindObjectsInit
+func C_FindObjectsInit(hSession C.CK_SESSION_HANDLE, pTemplate C.CK_ATTRIBUTE_PTR, ulCount C.CK_ULONG) C.CK_RV {
+	if pTemplate == nil && ulCount > 0 {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	var template []attribute
+	for _, a := range unsafe.Slice(pTemplate, ulCount) {
+		template = append(template, attribute{
+			typ:   uint(a._type),
+			value: goBytes(unsafe.Pointer(a.pValue), a.ulValueLen),
+		})
+	}
+
+	return C.CK_RV(tok.findInit(uint(hSession), template))
+}
+
+//export C_FindObjects
+func C_FindObjects(hSession C.CK_SESSION_HANDLE, phObject C.CK_OBJECT_HANDLE_PTR, ulMaxObjectCount C.CK_ULONG, pulObjectCount C.CK_ULONG_PTR) C.CK_RV {
+	if pulObjectCount == nil || (phObject == nil && ulMaxObjectCount > 0) {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	found, r := tok.find(uint(hSession), int(ulMaxObjectCount))
+	if r != CKR_OK {
+		return C.CK_RV(r)
+	}
+
+	out := unsafe.Slice(phObject, len(found))
+	for i, handle := range found {
+		out[i] = C.CK_OBJECT_HANDLE(handle)
+	}
+	*pulObjectCount = C.CK_ULONG(len(found))
+
+	return CKR_OK
+}
+
+//export C_FindObjectsFinal
+func C_FindObjectsFinal(hSession C.CK_SESSION_HANDLE) C.CK_RV {
+	return C.CK_RV(tok.findFinal(uint(hSession)))
+}
+
+//export C_EncryptInit
+func C_EncryptInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	return cryptInit(hSession, pMechanism, hKey, true)
+}
+
+//export C_Encrypt
+func C_Encrypt(hSession C.CK_SESSION_HANDLE, pData C.CK_BYTE_PTR, ulDataLen C.CK_ULONG, pEncryptedData C.CK_BYTE_PTR, pulEncryptedDataLen C.CK_ULONG_PTR) C.CK_RV {
+	return crypt(hSession, pData, ulDataLen, false, pEncryptedData, pulEncryptedDataLen, true)
+}
+
+//export C_EncryptUpdate
+func C_EncryptUpdate(hSession C.CK_SESSION_HANDLE, pPart C.CK_BYTE_PTR, ulPartLen C.CK_ULONG, pEncryptedPart C.CK_BYTE_PTR, pulEncryptedPartLen C.CK_ULONG_PTR) C.CK_RV {
+	return cryptUpdate(hSession, pPart, ulPartLen, pulEncryptedPartLen, true)
+}
+
+//export C_EncryptFinal
+func C_EncryptFinal(hSession C.CK_SESSION_HANDLE, pLastEncryptedPart C.CK_BYTE_PTR, pulLastEncryptedPartLen C.CK_ULONG_PTR) C.CK_RV {
+	return crypt(hSession, nil, 0, true, pLastEncryptedPart, pulLastEncryptedPartLen, true)
+}
+
+//export C_DecryptInit
+func C_DecryptInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE) C.CK_RV {
+	return cryptInit(hSession, pMechanism, hKey, false)
+}
+
+//export C_Decrypt
+func C_Decrypt(hSession C.CK_SESSION_HANDLE, pEncryptedData C.CK_BYTE_PTR, ulEncryptedDataLen C.CK_ULONG, pData C.CK_BYTE_PTR, pulDataLen C.CK_ULONG_PTR) C.CK_RV {
+	return crypt(hSession, pEncryptedData, ulEncryptedDataLen, false, pData, pulDataLen, false)
+}
+
+//export C_DecryptUpdate
+func C_DecryptUpdate(hSession C.CK_SESSION_HANDLE, pEncryptedPart C.CK_BYTE_PTR, ulEncryptedPartLen C.CK_ULONG, pPart C.CK_BYTE_PTR, pulPartLen C.CK_ULONG_PTR) C.CK_RV {
+	return cryptUpdate(hSession, pEncryptedPart, ulEncryptedPartLen, pulPartLen, false)
+}
+
+//export C_DecryptFinal
+func C_DecryptFinal(hSession C.CK_SESSION_HANDLE, pLastPart C.CK_BYTE_PTR, pulLastPartLen C.CK_ULONG_PTR) C.CK_RV {
+	return crypt(hSession, nil, 0, true, pLastPart, pulLastPartLen, false)
+}
+
+//export C_GenerateRandom
+func C_GenerateRandom(hSession C.CK_SESSION_HANDLE, pRandomData C.CK_BYTE_PTR, ulRandomLen C.CK_ULONG) C.CK_RV {
+	if pRandomData == nil && ulRandomLen > 0 {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	random, r := tok.generateRandom(uint(hSession), int(ulRandomLen))
+	if r != CKR_OK {
+		return C.CK_RV(r)
+	}
+
+	copy(unsafe.Slice((*byte)(unsafe.Pointer(pRandomData)), len(random)), random)
+	return CKR_OK
+}
+
+// cryptInit implements C_EncryptInit and C_DecryptInit
+func cryptInit(hSession C.CK_SESSION_HANDLE, pMechanism C.CK_MECHANISM_PTR, hKey C.CK_OBJECT_HANDLE, encrypt bool) C.CK_RV {
+	if pMechanism == nil {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	hasParameter := pMechanism.pParameter != nil || pMechanism.ulParameterLen > 0
+	return C.CK_RV(tok.cryptInit(uint(hSession), uint(pMechanism.mechanism), hasParameter, uint(hKey), encrypt))
+}
+
+// cryptUpdate implements C_EncryptUpdate and C_DecryptUpdate; output is
+// always empty until the Final call
+func cryptUpdate(hSession C.CK_SESSION_HANDLE, pPart C.CK_BYTE_PTR, ulPartLen C.CK_ULONG, pulOutLen C.CK_ULONG_PTR, encrypt bool) C.CK_RV {
+	if pulOutLen == nil || (pPart == nil && ulPartLen > 0) {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	part := goBytes(unsafe.Pointer(pPart), ulPartLen)
+	if r := tok.cryptUpdate(uint(hSession), part, encrypt); r != CKR_OK {
+		return C.CK_RV(r)
+	}
+
+	*pulOutLen = 0
+	return CKR_OK
+}
+
+// crypt implements the single-part and Final calls, including the
+// NULL-buffer length query and CKR_BUFFER_TOO_SMALL conventions
+func crypt(hSession C.CK_SESSION_HANDLE, pIn C.CK_BYTE_PTR, ulInLen C.CK_ULONG, final bool, pOut C.CK_BYTE_PTR, pulOutLen C.CK_ULONG_PTR, encrypt bool) C.CK_RV {
+	if pulOutLen == nil || (pIn == nil && ulInLen > 0) {
+		return CKR_ARGUMENTS_BAD
+	}
+
+	input := goBytes(unsafe.Pointer(pIn), ulInLen)
+	output, needed, r := tok.crypt(uint(hSession), input, final, encrypt, int(*pulOutLen), pOut == nil)
+	if r != CKR_OK && r != CKR_BUFFER_TOO_SMALL {
+		return C.CK_RV(r)
+	}
+
+	*pulOutLen = C.CK_ULONG(needed)
+	if output != nil {
+		copy(unsafe.Slice((*byte)(unsafe.Pointer(pOut)), len(output)), output)
+		zero(output)
+	}
+
+	return C.CK_RV(r)
+}
+
+// checkSlot validates slotID for the slot and token info calls
+func checkSlot(slotID C.CK_SLOT_ID) C.CK_RV {
+	if _, r := tok.tokenLabel(); r != CKR_OK {
+		return C.CK_RV(r)
+	}
+	if slotID != tokenSlotID {
+		return CKR_SLOT_ID_INVALID
+	}
+	return CKR_OK
+}
+
+// goBytes copies n bytes at p into Go memory
+func goBytes(p unsafe.Pointer, n C.CK_ULONG) []byte {
+	if p == nil || n == 0 {
+		return nil
+	}
+	return append([]byte(nil), unsafe.Slice((*byte)(p), n)...)
+}
+
+// pad fills a fixed-size PKCS#11 text field: s, then blanks, no NUL
+func pad(field []C.CK_UTF8CHAR, s string) {
+	for i := range field {
+		field[i] = ' '
+	}
+	for i := 0; i < len(s) && i < len(field); i++ {
+		field[i] = C.CK_UTF8CHAR(s[i])
+	}
+}
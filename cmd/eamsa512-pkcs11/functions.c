@@ -0,0 +1,87 @@
+/*
+ * functions.c - CK_FUNCTION_LIST of the EAMSA 512 software token
+ *
+ * Entry points implemented in Go (exports.go) are listed by name; all
+ * others return CKR_FUNCTION_NOT_SUPPORTED.
+ */
+#include "pkcs11.h"
+#include "_cgo_export.h"
+
+static CK_RV notSupported() {
+	return 0x54; /* CKR_FUNCTION_NOT_SUPPORTED */
+}
+
+#define GO(name) ((CK_FUNC)name)
+#define NS ((CK_FUNC)notSupported)
+
+CK_FUNCTION_LIST eamsa512FunctionList = {
+	{2, 40},
+	GO(C_Initialize),
+	GO(C_Finalize),
+	GO(C_GetInfo),
+	GO(C_GetFunctionList),
+	GO(C_GetSlotList),
+	GO(C_GetSlotInfo),
+	GO(C_GetTokenInfo),
+	GO(C_GetMechanismList),
+	GO(C_GetMechanismInfo),
+	NS, /* C_InitToken */
+	NS, /* C_InitPIN */
+	NS, /* C_SetPIN */
+	GO(C_OpenSession),
+	GO(C_CloseSession),
+	GO(C_CloseAllSessions),
+	GO(C_GetSessionInfo),
+	NS, /* C_GetOperationState */
+	NS, /* C_SetOperationState */
+	GO(C_Login),
+	GO(C_Logout),
+	NS, /* C_CreateObject */
+	NS, /* C_CopyObject */
+	NS, /* C_DestroyObject */
+	NS, /* C_GetObjectSize */
+	GO(C_GetAttributeValue),
+	NS, /* C_SetAttributeValue */
+	GO(C_FindObjectsInit),
+	GO(C_FindObjects),
+	GO(C_FindObjectsFinal),
+	GO(C_EncryptInit),
+	GO(C_Encrypt),
+	GO(C_EncryptUpdate),
+	GO(C_EncryptFinal),
+	GO(C_DecryptInit),
+	GO(C_Decrypt),
+	GO(C_DecryptUpdate),
+	GO(C_DecryptFinal),
+	NS, /* C_DigestInit */
+	NS, /* C_Digest */
+	NS, /* C_DigestUpdate */
+	NS, /* C_DigestKey */
+	NS, /* C_DigestFinal */
+	NS, /* C_SignInit */
+	NS, /* C_Sign */
+	NS, /* C_SignUpdate */
+	NS, /* C_SignFinal */
+	NS, /* C_SignRecoverInit */
+	NS, /* C_SignRecover */
+	NS, /* C_VerifyInit */
+	NS, /* C_Verify */
+	NS, /* C_VerifyUpdate */
+	NS, /* C_VerifyFinal */
+	NS, /* C_VerifyRecoverInit */
+	NS, /* C_VerifyRecover */
+	NS, /* C_DigestEncryptUpdate */
+	NS, /* C_DecryptDigestUpdate */
+	NS, /* C_SignEncryptUpdate */
+	NS, /* C_DecryptVerifyUpdate */
+	NS, /* C_GenerateKey */
+	NS, /* C_GenerateKeyPair */
+	NS, /* C_WrapKey */
+	NS, /* C_UnwrapKey */
+	NS, /* C_DeriveKey */
+	NS, /* C_SeedRandom */
+	GO(C_GenerateRandom),
+	NS, /* C_GetFunctionStatus */
+	NS, /* C_CancelFunction */
+	NS, /* C_WaitForSlotEvent */
+};
@@ -0,0 +1,26 @@
+// Command eamsa512-pkcs11 is a PKCS#11 v2.40 software token that lets
+// applications which only speak PKCS#11 encrypt and decrypt with
+// EAMSA 512. It is built as a shared library, not an executable:
+//
+//	go build -buildmode=c-shared -o libeamsa512-pkcs11.so ./cmd/eamsa512-pkcs11
+//
+// The token performs no cryptography itself. Each operation is forwarded
+// to the EAMSA 512 agent (the REST server's /api/v1/encrypt and
+// /api/v1/decrypt endpoints), so ciphertext is byte-compatible with
+// EncryptData: ciphertext || nonce (16) || tag (64).
+//
+// The token has one slot (ID 0) holding the secret-key objects listed in
+// the configuration file (EAMSA512_PKCS11_CONFIG, default
+// /etc/eamsa512/pkcs11.json, mode 0600). Keys are private, sensitive and
+// non-extractable: they are visible after C_Login with the configured
+// user PIN and can only be used with the vendor mechanism CKM_EAMSA512
+// (0x800EA512). A key's "usage" limits it to encrypt or decrypt, so edge
+// applications can be given encrypt-only objects.
+//
+// Multi-part operations collect input in C_EncryptUpdate/C_DecryptUpdate
+// and return all output from the Final call, since the agent seals whole
+// messages. Set EAMSA512_PKCS11_DEBUG=1 to log errors to stderr.
+package main
+
+// main is required by -buildmode=c-shared and never runs
+func main() {}
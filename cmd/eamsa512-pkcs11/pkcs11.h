@@ -0,0 +1,199 @@
+/*
+ * pkcs11.h - Minimal PKCS#11 v2.40 type definitions for the EAMSA 512
+ * software token
+ *
+ * Only the types, structures and constants used by the token are defined.
+ * Layouts follow the OASIS specification with the Unix (non-packed)
+ * structure alignment. Function prototypes are deliberately omitted: the
+ * entry points are generated by cgo in _cgo_export.h.
+ */
+#ifndef EAMSA512_PKCS11_H
+#define EAMSA512_PKCS11_H
+
+typedef unsigned char CK_BYTE;
+typedef CK_BYTE CK_CHAR;
+typedef CK_BYTE CK_UTF8CHAR;
+typedef CK_BYTE CK_BBOOL;
+typedef unsigned long CK_ULONG;
+typedef CK_ULONG CK_FLAGS;
+typedef CK_ULONG CK_RV;
+typedef CK_ULONG CK_SLOT_ID;
+typedef CK_ULONG CK_SESSION_HANDLE;
+typedef CK_ULONG CK_OBJECT_HANDLE;
+typedef CK_ULONG CK_USER_TYPE;
+typedef CK_ULONG CK_STATE;
+typedef CK_ULONG CK_MECHANISM_TYPE;
+typedef CK_ULONG CK_ATTRIBUTE_TYPE;
+typedef CK_ULONG CK_NOTIFY;
+
+typedef CK_BYTE *CK_BYTE_PTR;
+typedef CK_UTF8CHAR *CK_UTF8CHAR_PTR;
+typedef CK_ULONG *CK_ULONG_PTR;
+typedef CK_SLOT_ID *CK_SLOT_ID_PTR;
+typedef CK_SESSION_HANDLE *CK_SESSION_HANDLE_PTR;
+typedef CK_OBJECT_HANDLE *CK_OBJECT_HANDLE_PTR;
+typedef CK_MECHANISM_TYPE *CK_MECHANISM_TYPE_PTR;
+typedef void *CK_VOID_PTR;
+
+typedef struct CK_VERSION {
+	CK_BYTE major;
+	CK_BYTE minor;
+} CK_VERSION;
+
+typedef struct CK_INFO {
+	CK_VERSION cryptokiVersion;
+	CK_UTF8CHAR manufacturerID[32];
+	CK_FLAGS flags;
+	CK_UTF8CHAR libraryDescription[32];
+	CK_VERSION libraryVersion;
+} CK_INFO;
+typedef CK_INFO *CK_INFO_PTR;
+
+typedef struct CK_SLOT_INFO {
+	CK_UTF8CHAR slotDescription[64];
+	CK_UTF8CHAR manufacturerID[32];
+	CK_FLAGS flags;
+	CK_VERSION hardwareVersion;
+	CK_VERSION firmwareVersion;
+} CK_SLOT_INFO;
+typedef CK_SLOT_INFO *CK_SLOT_INFO_PTR;
+
+typedef struct CK_TOKEN_INFO {
+	CK_UTF8CHAR label[32];
+	CK_UTF8CHAR manufacturerID[32];
+	CK_UTF8CHAR model[16];
+	CK_CHAR serialNumber[16];
+	CK_FLAGS flags;
+	CK_ULONG ulMaxSessionCount;
+	CK_ULONG ulSessionCount;
+	CK_ULONG ulMaxRwSessionCount;
+	CK_ULONG ulRwSessionCount;
+	CK_ULONG ulMaxPinLen;
+	CK_ULONG ulMinPinLen;
+	CK_ULONG ulTotalPublicMemory;
+	CK_ULONG ulFreePublicMemory;
+	CK_ULONG ulTotalPrivateMemory;
+	CK_ULONG ulFreePrivateMemory;
+	CK_VERSION hardwareVersion;
+	CK_VERSION firmwareVersion;
+	CK_CHAR utcTime[16];
+} CK_TOKEN_INFO;
+typedef CK_TOKEN_INFO *CK_TOKEN_INFO_PTR;
+
+typedef struct CK_SESSION_INFO {
+	CK_SLOT_ID slotID;
+	CK_STATE state;
+	CK_FLAGS flags;
+	CK_ULONG ulDeviceError;
+} CK_SESSION_INFO;
+typedef CK_SESSION_INFO *CK_SESSION_INFO_PTR;
+
+typedef struct CK_MECHANISM {
+	CK_MECHANISM_TYPE mechanism;
+	CK_VOID_PTR pParameter;
+	CK_ULONG ulParameterLen;
+} CK_MECHANISM;
+typedef CK_MECHANISM *CK_MECHANISM_PTR;
+
+typedef struct CK_MECHANISM_INFO {
+	CK_ULONG ulMinKeySize;
+	CK_ULONG ulMaxKeySize;
+	CK_FLAGS flags;
+} CK_MECHANISM_INFO;
+typedef CK_MECHANISM_INFO *CK_MECHANISM_INFO_PTR;
+
+typedef struct CK_ATTRIBUTE {
+	CK_ATTRIBUTE_TYPE type;
+	CK_VOID_PTR pValue;
+	CK_ULONG ulValueLen;
+} CK_ATTRIBUTE;
+typedef CK_ATTRIBUTE *CK_ATTRIBUTE_PTR;
+
+typedef struct CK_C_INITIALIZE_ARGS {
+	CK_VOID_PTR CreateMutex;
+	CK_VOID_PTR DestroyMutex;
+	CK_VOID_PTR LockMutex;
+	CK_VOID_PTR UnlockMutex;
+	CK_FLAGS flags;
+	CK_VOID_PTR pReserved;
+} CK_C_INITIALIZE_ARGS;
+typedef CK_C_INITIALIZE_ARGS *CK_C_INITIALIZE_ARGS_PTR;
+
+/* CK_FUNCTION_LIST: the 68 v2.40 entry points in specification order */
+typedef CK_RV (*CK_FUNC)();
+
+typedef struct CK_FUNCTION_LIST {
+	CK_VERSION version;
+	CK_FUNC C_Initialize;
+	CK_FUNC C_Finalize;
+	CK_FUNC C_GetInfo;
+	CK_FUNC C_GetFunctionList;
+	CK_FUNC C_GetSlotList;
+	CK_FUNC C_GetSlotInfo;
+	CK_FUNC C_GetTokenInfo;
+	CK_FUNC C_GetMechanismList;
+	CK_FUNC C_GetMechanismInfo;
+	CK_FUNC C_InitToken;
+	CK_FUNC C_InitPIN;
+	CK_FUNC C_SetPIN;
+	CK_FUNC C_OpenSession;
+	CK_FUNC C_CloseSession;
+	CK_FUNC C_CloseAllSessions;
+	CK_FUNC C_GetSessionInfo;
+	CK_FUNC C_GetOperationState;
+	CK_FUNC C_SetOperationState;
+	CK_FUNC C_Login;
+	CK_FUNC C_Logout;
+	CK_FUNC C_CreateObject;
+	CK_FUNC C_CopyObject;
+	CK_FUNC C_DestroyObject;
+	CK_FUNC C_GetObjectSize;
+	CK_FUNC C_GetAttributeValue;
+	CK_FUNC C_SetAttributeValue;
+	CK_FUNC C_FindObjectsInit;
+	CK_FUNC C_FindObjects;
+	CK_FUNC C_FindObjectsFinal;
+	CK_FUNC C_EncryptInit;
+	CK_FUNC C_Encrypt;
+	CK_FUNC C_EncryptUpdate;
+	CK_FUNC C_EncryptFinal;
+	CK_FUNC C_DecryptInit;
+	CK_FUNC C_Decrypt;
+	CK_FUNC C_DecryptUpdate;
+	CK_FUNC C_DecryptFinal;
+	CK_FUNC C_DigestInit;
+	CK_FUNC C_Digest;
+	CK_FUNC C_DigestUpdate;
+	CK_FUNC C_DigestKey;
+	CK_FUNC C_DigestFinal;
+	CK_FUNC C_SignInit;
+	CK_FUNC C_Sign;
+	CK_FUNC C_SignUpdate;
+	CK_FUNC C_SignFinal;
+	CK_FUNC C_SignRecoverInit;
+	CK_FUNC C_SignRecover;
+	CK_FUNC C_VerifyInit;
+	CK_FUNC C_Verify;
+	CK_FUNC C_VerifyUpdate;
+	CK_FUNC C_VerifyFinal;
+	CK_FUNC C_VerifyRecoverInit;
+	CK_FUNC C_VerifyRecover;
+	CK_FUNC C_DigestEncryptUpdate;
+	CK_FUNC C_DecryptDigestUpdate;
+	CK_FUNC C_SignEncryptUpdate;
+	CK_FUNC C_DecryptVerifyUpdate;
+	CK_FUNC C_GenerateKey;
+	CK_FUNC C_GenerateKeyPair;
+	CK_FUNC C_WrapKey;
+	CK_FUNC C_UnwrapKey;
+	CK_FUNC C_DeriveKey;
+	CK_FUNC C_SeedRandom;
+	CK_FUNC C_GenerateRandom;
+	CK_FUNC C_GetFunctionStatus;
+	CK_FUNC C_CancelFunction;
+	CK_FUNC C_WaitForSlotEvent;
+} CK_FUNCTION_LIST;
+typedef CK_FUNCTION_LIST *CK_FUNCTION_LIST_PTR;
+typedef CK_FUNCTION_LIST_PTR *CK_FUNCTION_LIST_PTR_PTR;
+
+#endif /* EAMSA512_PKCS11_H */
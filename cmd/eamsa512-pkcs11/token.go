@@ -0,0 +1,703 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"sync"
+)
+
+// PKCS#11 return values used by the token
+const (
+	CKR_OK                             = 0x000
+	CKR_HOST_MEMORY                    = 0x002
+	CKR_SLOT_ID_INVALID                = 0x003
+	CKR_GENERAL_ERROR                  = 0x005
+	CKR_ARGUMENTS_BAD                  = 0x007
+	CKR_NEED_TO_CREATE_THREADS         = 0x009
+	CKR_ATTRIBUTE_SENSITIVE            = 0x011
+	CKR_ATTRIBUTE_TYPE_INVALID         = 0x012
+	CKR_DATA_LEN_RANGE                 = 0x021
+	CKR_DEVICE_ERROR                   = 0x030
+	CKR_ENCRYPTED_DATA_INVALID         = 0x040
+	CKR_ENCRYPTED_DATA_LEN_RANGE       = 0x041
+	CKR_FUNCTION_NOT_SUPPORTED         = 0x054
+	CKR_KEY_HANDLE_INVALID             = 0x060
+	CKR_KEY_FUNCTION_NOT_PERMITTED     = 0x068
+	CKR_MECHANISM_INVALID              = 0x070
+	CKR_MECHANISM_PARAM_INVALID        = 0x071
+	CKR_OBJECT_HANDLE_INVALID          = 0x082
+	CKR_OPERATION_ACTIVE               = 0x090
+	CKR_OPERATION_NOT_INITIALIZED      = 0x091
+	CKR_PIN_INCORRECT                  = 0x0A0
+	CKR_SESSION_HANDLE_INVALID         = 0x0B3
+	CKR_SESSION_PARALLEL_NOT_SUPPORTED = 0x0B4
+	CKR_TOKEN_NOT_PRESENT              = 0x0E0
+	CKR_USER_ALREADY_LOGGED_IN         = 0x100
+	CKR_USER_NOT_LOGGED_IN             = 0x101
+	CKR_USER_TYPE_INVALID              = 0x103
+	CKR_BUFFER_TOO_SMALL               = 0x150
+	CKR_CRYPTOKI_NOT_INITIALIZED       = 0x190
+	CKR_CRYPTOKI_ALREADY_INITIALIZED   = 0x191
+)
+
+// PKCS#11 object classes, key types, attributes, flags and states
+const (
+	CKO_SECRET_KEY = 0x04
+
+	CKK_GENERIC_SECRET = 0x10
+
+	CKA_CLASS       = 0x000
+	CKA_TOKEN       = 0x001
+	CKA_PRIVATE     = 0x002
+	CKA_LABEL       = 0x003
+	CKA_KEY_TYPE    = 0x100
+	CKA_ID          = 0x102
+	CKA_SENSITIVE   = 0x103
+	CKA_ENCRYPT     = 0x104
+	CKA_DECRYPT     = 0x105
+	CKA_VALUE       = 0x011
+	CKA_VALUE_LEN   = 0x161
+	CKA_EXTRACTABLE = 0x162
+
+	CKU_SO   = 0
+	CKU_USER = 1
+
+	CKF_RW_SESSION     = 0x02
+	CKF_SERIAL_SESSION = 0x04
+
+	CKS_RO_PUBLIC_SESSION = 0
+	CKS_RO_USER_FUNCTIONS = 1
+	CKS_RW_PUBLIC_SESSION = 2
+	CKS_RW_USER_FUNCTIONS = 3
+
+	CKF_ENCRYPT = 0x100
+	CKF_DECRYPT = 0x200
+)
+
+// CKM_EAMSA512 is the vendor-defined mechanism for EAMSA 512 CBC with
+// HMAC-SHA3-512 (CKM_VENDOR_DEFINED | 0xEA512). It takes no parameter;
+// ciphertext is ciphertext || nonce (16) || tag (64), as EncryptData
+// produces.
+const CKM_EAMSA512 = 0x800EA512
+
+// tokenSlotID is the only slot; it always holds the token
+const tokenSlotID = 0
+
+// Sizes of the sealed format appended to the ciphertext
+const (
+	keySize   = 32
+	nonceSize = 16
+	tagSize   = 64
+)
+
+// rv is a PKCS#11 return value
+type rv uint
+
+// tokenKey is a secret-key object on the token
+type tokenKey struct {
+	handle  uint
+	label   string
+	id      []byte
+	value   []byte
+	encrypt bool
+	decrypt bool
+}
+
+// operation is an active encrypt or decrypt operation
+type operation struct {
+	key     *tokenKey
+	encrypt bool
+	input   []byte // Input collected by Update calls
+	output  []byte // Result kept after a length query
+	done    bool   // output is valid
+}
+
+// session is an open session
+type session struct {
+	handle  uint
+	rw      bool
+	finding bool
+	found   []uint
+	op      *operation
+}
+
+// token is the software token state. All entry points lock mu; agent
+// calls are made without it so sessions do not block each other.
+type token struct {
+	mu          sync.Mutex
+	initialized bool
+	cfg         *Config
+	agent       Agent
+	keys        []*tokenKey
+	sessions    map[uint]*session
+	nextSession uint
+	loggedIn    bool
+}
+
+// tok is the token behind the exported entry points
+var tok token
+
+// initialize loads the configuration and connects the agent
+func (t *token) initialize() rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.initialized {
+		return CKR_CRYPTOKI_ALREADY_INITIALIZED
+	}
+
+	cfg, err := LoadConfig(configPath())
+	if err != nil {
+		logf("initialize: %v", err)
+		return CKR_GENERAL_ERROR
+	}
+
+	agent, err := newHTTPAgent(cfg)
+	if err != nil {
+		logf("initialize: %v", err)
+		return CKR_GENERAL_ERROR
+	}
+
+	return t.load(cfg, agent)
+}
+
+// load installs cfg and agent; keys get object handles 1..n
+func (t *token) load(cfg *Config, agent Agent) rv {
+	keys, err := cfg.tokenKeys()
+	if err != nil {
+		logf("initialize: %v", err)
+		return CKR_GENERAL_ERROR
+	}
+
+	t.cfg = cfg
+	t.agent = agent
+	t.keys = keys
+	t.sessions = make(map[uint]*session)
+	t.nextSession = 1
+	t.loggedIn = false
+	t.initialized = true
+
+	return CKR_OK
+}
+
+// finalize closes all sessions and forgets the keys
+func (t *token) finalize() rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.initialized {
+		return CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+
+	for _, key := range t.keys {
+		zero(key.value)
+	}
+	t.keys = nil
+	t.sessions = nil
+	t.cfg = nil
+	t.agent = nil
+	t.loggedIn = false
+	t.initialized = false
+
+	return CKR_OK
+}
+
+// checkSlot validates a slot ID
+func (t *token) checkSlot(slotID uint) rv {
+	if !t.initialized {
+		return CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+	if slotID != tokenSlotID {
+		return CKR_SLOT_ID_INVALID
+	}
+	return CKR_OK
+}
+
+// tokenLabel returns the configured token label
+func (t *token) tokenLabel() (string, rv) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.initialized {
+		return "", CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+	return t.cfg.TokenLabel, CKR_OK
+}
+
+// sessionCounts returns the number of open and read-write sessions
+func (t *token) sessionCounts() (open, rw uint) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, s := range t.sessions {
+		open++
+		if s.rw {
+			rw++
+		}
+	}
+	return open, rw
+}
+
+// openSession opens a session on the token
+func (t *token) openSession(slotID uint, flags uint) (uint, rv) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r := t.checkSlot(slotID); r != CKR_OK {
+		return 0, r
+	}
+	if flags&CKF_SERIAL_SESSION == 0 {
+		return 0, CKR_SESSION_PARALLEL_NOT_SUPPORTED
+	}
+
+	s := &session{handle: t.nextSession, rw: flags&CKF_RW_SESSION != 0}
+	t.sessions[s.handle] = s
+	t.nextSession++
+
+	return s.handle, CKR_OK
+}
+
+// closeSession closes one session; closing the last one logs out
+func (t *token) closeSession(handle uint) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, r := t.session(handle); r != CKR_OK {
+		return r
+	}
+
+	delete(t.sessions, handle)
+	if len(t.sessions) == 0 {
+		t.loggedIn = false
+	}
+	return CKR_OK
+}
+
+// closeAllSessions closes every session and logs out
+func (t *token) closeAllSessions(slotID uint) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if r := t.checkSlot(slotID); r != CKR_OK {
+		return r
+	}
+
+	t.sessions = make(map[uint]*session)
+	t.loggedIn = false
+	return CKR_OK
+}
+
+// sessionState returns the PKCS#11 state of a session
+func (t *token) sessionState(handle uint) (uint, bool, rv) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return 0, false, r
+	}
+
+	switch {
+	case s.rw && t.loggedIn:
+		return CKS_RW_USER_FUNCTIONS, true, CKR_OK
+	case s.rw:
+		return CKS_RW_PUBLIC_SESSION, true, CKR_OK
+	case t.loggedIn:
+		return CKS_RO_USER_FUNCTIONS, false, CKR_OK
+	default:
+		return CKS_RO_PUBLIC_SESSION, false, CKR_OK
+	}
+}
+
+// session looks up a session; mu must be held
+func (t *token) session(handle uint) (*session, rv) {
+	if !t.initialized {
+		return nil, CKR_CRYPTOKI_NOT_INITIALIZED
+	}
+
+	s, ok := t.sessions[handle]
+	if !ok {
+		return nil, CKR_SESSION_HANDLE_INVALID
+	}
+	return s, CKR_OK
+}
+
+// login logs the normal user in; the security officer is not supported
+func (t *token) login(handle uint, userType uint, pin []byte) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, r := t.session(handle); r != CKR_OK {
+		return r
+	}
+	if userType != CKU_USER {
+		return CKR_USER_TYPE_INVALID
+	}
+	if t.loggedIn {
+		return CKR_USER_ALREADY_LOGGED_IN
+	}
+	if subtle.ConstantTimeCompare(pin, []byte(t.cfg.PIN)) != 1 {
+		return CKR_PIN_INCORRECT
+	}
+
+	t.loggedIn = true
+	return CKR_OK
+}
+
+// logout logs the user out of all sessions
+func (t *token) logout(handle uint) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, r := t.session(handle); r != CKR_OK {
+		return r
+	}
+	if !t.loggedIn {
+		return CKR_USER_NOT_LOGGED_IN
+	}
+
+	t.loggedIn = false
+	for _, s := range t.sessions {
+		s.op = nil
+	}
+	return CKR_OK
+}
+
+// key looks up a key object visible to the session; keys are private
+// objects, so none are visible before login. mu must be held.
+func (t *token) key(handle uint) (*tokenKey, rv) {
+	if !t.loggedIn {
+		return nil, CKR_OBJECT_HANDLE_INVALID
+	}
+	if handle == 0 || handle > uint(len(t.keys)) {
+		return nil, CKR_OBJECT_HANDLE_INVALID
+	}
+	return t.keys[handle-1], CKR_OK
+}
+
+// attribute is one template entry
+type attribute struct {
+	typ   uint
+	value []byte
+}
+
+// attributeValue returns one attribute of a key, encoded as PKCS#11 expects
+func (k *tokenKey) attributeValue(typ uint) ([]byte, rv) {
+	switch typ {
+	case CKA_CLASS:
+		return ulongBytes(CKO_SECRET_KEY), CKR_OK
+	case CKA_KEY_TYPE:
+		return ulongBytes(CKK_GENERIC_SECRET), CKR_OK
+	case CKA_LABEL:
+		return []byte(k.label), CKR_OK
+	case CKA_ID:
+		return k.id, CKR_OK
+	case CKA_VALUE_LEN:
+		return ulongBytes(uint(len(k.value))), CKR_OK
+	case CKA_TOKEN, CKA_PRIVATE, CKA_SENSITIVE:
+		return boolBytes(true), CKR_OK
+	case CKA_EXTRACTABLE:
+		return boolBytes(false), CKR_OK
+	case CKA_ENCRYPT:
+		return boolBytes(k.encrypt), CKR_OK
+	case CKA_DECRYPT:
+		return boolBytes(k.decrypt), CKR_OK
+	case CKA_VALUE:
+		return nil, CKR_ATTRIBUTE_SENSITIVE
+	default:
+		return nil, CKR_ATTRIBUTE_TYPE_INVALID
+	}
+}
+
+// matches reports whether the key has every attribute in template
+func (k *tokenKey) matches(template []attribute) bool {
+	for _, a := range template {
+		value, r := k.attributeValue(a.typ)
+		if r != CKR_OK || !bytes.Equal(value, a.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// getAttributes returns the requested attributes of an object; failed
+// attributes are nil in values and the last failure is returned
+func (t *token) getAttributes(handle, object uint, types []uint) ([][]byte, rv) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, r := t.session(handle); r != CKR_OK {
+		return nil, r
+	}
+
+	key, r := t.key(object)
+	if r != CKR_OK {
+		return nil, r
+	}
+
+	values := make([][]byte, len(types))
+	result := rv(CKR_OK)
+	for i, typ := range types {
+		value, r := key.attributeValue(typ)
+		if r != CKR_OK {
+			result = r
+			continue
+		}
+		values[i] = value
+	}
+
+	return values, result
+}
+
+// findInit starts a search for objects matching template
+func (t *token) findInit(handle uint, template []attribute) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return r
+	}
+	if s.finding {
+		return CKR_OPERATION_ACTIVE
+	}
+
+	s.finding = true
+	s.found = nil
+	if !t.loggedIn {
+		return CKR_OK
+	}
+
+	for _, key := range t.keys {
+		if key.matches(template) {
+			s.found = append(s.found, key.handle)
+		}
+	}
+	return CKR_OK
+}
+
+// find returns up to max further matches
+func (t *token) find(handle uint, max int) ([]uint, rv) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return nil, r
+	}
+	if !s.finding {
+		return nil, CKR_OPERATION_NOT_INITIALIZED
+	}
+
+	if max > len(s.found) {
+		max = len(s.found)
+	}
+	out := s.found[:max]
+	s.found = s.found[max:]
+	return out, CKR_OK
+}
+
+// findFinal ends a search
+func (t *token) findFinal(handle uint) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return r
+	}
+	if !s.finding {
+		return CKR_OPERATION_NOT_INITIALIZED
+	}
+
+	s.finding = false
+	s.found = nil
+	return CKR_OK
+}
+
+// cryptInit starts an encrypt or decrypt operation with CKM_EAMSA512
+func (t *token) cryptInit(handle uint, mechanism uint, hasParameter bool, object uint, encrypt bool) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return r
+	}
+	if s.op != nil {
+		return CKR_OPERATION_ACTIVE
+	}
+	if !t.loggedIn {
+		return CKR_USER_NOT_LOGGED_IN
+	}
+	if mechanism != CKM_EAMSA512 {
+		return CKR_MECHANISM_INVALID
+	}
+	if hasParameter {
+		return CKR_MECHANISM_PARAM_INVALID
+	}
+
+	key, r := t.key(object)
+	if r != CKR_OK {
+		return CKR_KEY_HANDLE_INVALID
+	}
+	if (encrypt && !key.encrypt) || (!encrypt && !key.decrypt) {
+		return CKR_KEY_FUNCTION_NOT_PERMITTED
+	}
+
+	s.op = &operation{key: key, encrypt: encrypt}
+	return CKR_OK
+}
+
+// cryptUpdate adds input to a multi-part operation; output is produced by
+// cryptFinal since the agent seals whole messages
+func (t *token) cryptUpdate(handle uint, part []byte, encrypt bool) rv {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, r := t.activeOperation(handle, encrypt)
+	if r != CKR_OK {
+		return r
+	}
+
+	s.op.input = append(s.op.input, part...)
+	return CKR_OK
+}
+
+// crypt runs a single-part operation on data (final: on the collected
+// input instead). With query set only the output length is returned and
+// the operation stays active, as it does for CKR_BUFFER_TOO_SMALL.
+func (t *token) crypt(handle uint, data []byte, final bool, encrypt bool, capacity int, query bool) ([]byte, int, rv) {
+	t.mu.Lock()
+	s, r := t.activeOperation(handle, encrypt)
+	if r != CKR_OK {
+		t.mu.Unlock()
+		return nil, 0, r
+	}
+	op := s.op
+	agent := t.agent
+	t.mu.Unlock()
+
+	if !op.done {
+		input := data
+		if final {
+			input = op.input
+		}
+
+		output, r := runAgent(agent, op.key, input, encrypt)
+		if r != CKR_OK {
+			t.endOperation(s, op)
+			return nil, 0, r
+		}
+		op.output = output
+		op.done = true
+	}
+
+	if query {
+		return nil, len(op.output), CKR_OK
+	}
+	if capacity < len(op.output) {
+		return nil, len(op.output), CKR_BUFFER_TOO_SMALL
+	}
+
+	t.endOperation(s, op)
+	return op.output, len(op.output), CKR_OK
+}
+
+// activeOperation returns a session with an operation of the given kind;
+// mu must be held
+func (t *token) activeOperation(handle uint, encrypt bool) (*session, rv) {
+	s, r := t.session(handle)
+	if r != CKR_OK {
+		return nil, r
+	}
+	if s.op == nil || s.op.encrypt != encrypt {
+		return nil, CKR_OPERATION_NOT_INITIALIZED
+	}
+	return s, CKR_OK
+}
+
+// endOperation clears op if it is still the session's operation
+func (t *token) endOperation(s *session, op *operation) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if s.op == op {
+		s.op = nil
+	}
+}
+
+// runAgent encrypts or decrypts input under key through the agent
+func runAgent(agent Agent, key *tokenKey, input []byte, encrypt bool) ([]byte, rv) {
+	if encrypt {
+		if len(input) == 0 {
+			return nil, CKR_DATA_LEN_RANGE
+		}
+
+		output, err := agent.Encrypt(key.value, input)
+		if err != nil {
+			logf("encrypt with key %q: %v", key.label, err)
+			return nil, CKR_DEVICE_ERROR
+		}
+		return output, CKR_OK
+	}
+
+	if len(input) < nonceSize+tagSize {
+		return nil, CKR_ENCRYPTED_DATA_LEN_RANGE
+	}
+
+	output, err := agent.Decrypt(key.value, input)
+	if errors.Is(err, errAuthenticationFailed) {
+		return nil, CKR_ENCRYPTED_DATA_INVALID
+	}
+	if err != nil {
+		logf("decrypt with key %q: %v", key.label, err)
+		return nil, CKR_DEVICE_ERROR
+	}
+	return output, CKR_OK
+}
+
+// generateRandom fills n bytes from crypto/rand
+func (t *token) generateRandom(handle uint, n int) ([]byte, rv) {
+	t.mu.Lock()
+	_, r := t.session(handle)
+	t.mu.Unlock()
+	if r != CKR_OK {
+		return nil, r
+	}
+
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, CKR_DEVICE_ERROR
+	}
+	return buf, CKR_OK
+}
+
+// ulongBytes encodes a CK_ULONG attribute value in host byte order
+func ulongBytes(v uint) []byte {
+	buf := make([]byte, ulongSize)
+	if ulongSize == 8 {
+		binary.NativeEndian.PutUint64(buf, uint64(v))
+	} else {
+		binary.NativeEndian.PutUint32(buf, uint32(v))
+	}
+	return buf
+}
+
+// boolBytes encodes a CK_BBOOL attribute value
+func boolBytes(v bool) []byte {
+	if v {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// zero clears b
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
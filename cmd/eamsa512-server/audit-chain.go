@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ============================================================================
+// EAMSA 512 - Audit Hash Chain
+// Tamper evidence for the audit_logs table and its exports
+//
+// Every audit row stores the hash of the row before it (prev_hash) and its
+// own hash over prev_hash and its fields:
+//
+//   hash = SHA3-256(prev_hash || 0 || event_type || 0 || category || 0 ||
+//                   severity || 0 || details || 0 || timestamp || 0 ||
+//                   user_id || 0 || source_ip)
+//
+// with the timestamp in RFC 3339 (nanoseconds, UTC) and the user and IP as
+// stored (pseudonymized if enabled). Editing a row changes its hash;
+// deleting or reordering rows breaks the prev_hash links. Appending rows
+// to the end, or cutting the end off, leaves a valid chain: compare the
+// head with one recorded earlier (audit verify -expect-head) to rule that
+// out. Retention pruning deletes the oldest rows; the chain then starts
+// at the prev_hash of the first row kept (the anchor).
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// AuditChainBreak is one place where the chain does not verify
+type AuditChainBreak struct {
+	ID     int64  `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// AuditChainReport is the result of verifying a run of audit rows
+type AuditChainReport struct {
+	Entries   int               `json:"entries"`
+	Unchained int               `json:"unchained"` // Rows written before chaining was enabled
+	FirstID   int64             `json:"first_id,omitempty"`
+	LastID    int64             `json:"last_id,omitempty"`
+	Anchor    string            `json:"anchor,omitempty"` // prev_hash of the first chained row
+	Head      string            `json:"head,omitempty"`   // Hash of the last row
+	Breaks    []AuditChainBreak `json:"breaks,omitempty"`
+	Valid     bool              `json:"valid"`
+}
+
+// auditChainHash returns the chain hash of entry after prevHash
+func auditChainHash(prevHash string, entry AuditLogEntry) string {
+	h := sha3.New256()
+	for _, field := range []string{
+		prevHash,
+		entry.EventType,
+		entry.Category,
+		entry.Severity,
+		entry.Details,
+		entry.Timestamp.UTC().Format(time.RFC3339Nano),
+		entry.UserID,
+		entry.SourceIP,
+	} {
+		h.Write([]byte(field))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyAuditChain checks the hash chain of entries, in ID order. Rows
+// without a hash are counted as unchained while no chained row has been
+// seen, and are breaks after that.
+func VerifyAuditChain(entries []AuditLogEntry) AuditChainReport {
+	sorted := append([]AuditLogEntry(nil), entries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	report := AuditChainReport{Entries: len(sorted)}
+	if len(sorted) > 0 {
+		report.FirstID = sorted[0].ID
+		report.LastID = sorted[len(sorted)-1].ID
+	}
+
+	chained := false
+	prev := ""
+	for _, entry := range sorted {
+		if entry.Hash == "" {
+			if chained {
+				report.Breaks = append(report.Breaks, AuditChainBreak{ID: entry.ID, Reason: "row has no hash"})
+			} else {
+				report.Unchained++
+			}
+			continue
+		}
+
+		if !chained {
+			chained = true
+			report.Anchor = entry.PrevHash
+		} else if entry.PrevHash != prev {
+			report.Breaks = append(report.Breaks, AuditChainBreak{
+				ID:     entry.ID,
+				Reason: "prev_hash does not match the previous row (rows deleted, inserted or reordered)",
+			})
+		}
+
+		if auditChainHash(entry.PrevHash, entry) != entry.Hash {
+			report.Breaks = append(report.Breaks, AuditChainBreak{ID: entry.ID, Reason: "hash does not match the row (row modified)"})
+		}
+		prev = entry.Hash
+	}
+
+	report.Head = prev
+	report.Valid = len(report.Breaks) == 0
+	return report
+}
+
+// ReadAuditExport reads audit rows exported to a file, as a JSON array
+// (ExportAuditLogsJSON) or as JSON lines (audit search -format json), for
+// files forwarded to a SIEM
+func ReadAuditExport(path string) ([]AuditLogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+
+	trimmed := strings.TrimSpace(string(data))
+	if strings.HasPrefix(trimmed, "[") {
+		var entries []AuditLogEntry
+		if err := json.Unmarshal([]byte(trimmed), &entries); err != nil {
+			return nil, fmt.Errorf("invalid audit export %s: %v", path, err)
+		}
+		return entries, nil
+	}
+
+	var entries []AuditLogEntry
+	for i, line := range strings.Split(trimmed, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		var entry AuditLogEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit export %s line %d: %v", path, i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. WHAT IS CHAINED
+   - Rows of the audit_logs table, written by RecordAuditLog and
+     WriteAuditBatch; the database keeps the head in memory and reloads
+     it on open
+   - Rows from before the prev_hash and hash columns existed stay
+     unchained; the chain starts at the first row written after upgrade
+   - The plain audit log file ([AUDIT] lines) is not chained
+
+2. EXPORTS
+   - Exported rows keep prev_hash and hash, so a SIEM copy verifies
+     offline: eamsa512-server audit verify -file export.json
+   - A partial export verifies from its first row (reported as anchor)
+
+3. LIMITS
+   - The chain is keyed by nothing: anyone able to rewrite the table can
+     recompute every hash after the rows they changed. Record the head
+     outside the database (a ticket, the SIEM, a signed report) and check
+     it with -expect-head
+
+*/
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// EAMSA 512 - Audit Pseudonymization
+// Keyed pseudonyms for user IDs and IP addresses in audit records
+//
+// With a pseudonymization key configured, user IDs and client/source IPs
+// are replaced by "pn:<kind>:<hex>" before they reach the audit log file,
+// the audit_logs table or the operations table. The pseudonym is an
+// HMAC-SHA3-512 under a key derived from the configured one, so the same
+// user or address always maps to the same pseudonym and records can still
+// be correlated, but the identity cannot be recovered from the logs.
+//
+// Re-identification goes through a separate map of pseudonym to the
+// original value, sealed with EncryptData under a second derived key. It
+// is only reachable through POST /api/v1/admin/pseudonyms/reidentify,
+// which requires the admin role and is itself audited. Deleting a map
+// entry makes that pseudonym permanently anonymous.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Pseudonym kinds
+const (
+	PseudonymUser = "user"
+	PseudonymIP   = "ip"
+)
+
+const (
+	pseudonymPrefix    = "pn:"
+	pseudonymMACLabel  = "EAMSA512-PSEUDONYM-MAC"
+	pseudonymSealLabel = "EAMSA512-PSEUDONYM-SEAL"
+	pseudonymTagBytes  = 16
+
+	// DefaultPseudonymMapPath holds the sealed re-identification map
+	DefaultPseudonymMapPath = "/var/lib/eamsa512/pseudonyms.jsonl"
+)
+
+// Pseudonymizer replaces identities in audit records with keyed pseudonyms
+type Pseudonymizer struct {
+	macKey  []byte
+	sealKey []byte
+	mapPath string
+
+	mu    sync.Mutex
+	known map[string]bool // Pseudonyms already in the map
+}
+
+// pseudonymMapEntry is one line of the re-identification map
+type pseudonymMapEntry struct {
+	Pseudonym string `json:"pseudonym"`
+	Kind      string `json:"kind"`
+	Sealed    string `json:"sealed"` // hex EncryptData(value)
+}
+
+// NewPseudonymizer derives the pseudonym and sealing keys from key
+// (32 bytes). mapPath is the re-identification map; "" disables
+// re-identification.
+func NewPseudonymizer(key []byte, mapPath string) (*Pseudonymizer, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid pseudonymization key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	p := &Pseudonymizer{
+		macKey:  deriveContainerKey(key, pseudonymMACLabel),
+		sealKey: deriveContainerKey(key, pseudonymSealLabel),
+		mapPath: mapPath,
+		known:   make(map[string]bool),
+	}
+
+	if mapPath != "" {
+		err := p.scanMap(func(entry pseudonymMapEntry) bool {
+			p.known[entry.Pseudonym] = true
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// LoadPseudonymizer reads a hex-encoded 32-byte key from keyPath
+func LoadPseudonymizer(keyPath, mapPath string) (*Pseudonymizer, error) {
+	data, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pseudonymization key: %v", err)
+	}
+
+	key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("pseudonymization key must be hex-encoded: %v", err)
+	}
+	defer zeroizeKey(key)
+
+	return NewPseudonymizer(key, mapPath)
+}
+
+// LoadPseudonymizerFromEnv configures pseudonymization from
+// EAMSA_AUDIT_PSEUDONYM_KEY (key file) and EAMSA_AUDIT_PSEUDONYM_MAP;
+// returns nil when no key is configured
+func LoadPseudonymizerFromEnv() (*Pseudonymizer, error) {
+	keyPath := os.Getenv("EAMSA_AUDIT_PSEUDONYM_KEY")
+	if keyPath == "" {
+		return nil, nil
+	}
+
+	mapPath := os.Getenv("EAMSA_AUDIT_PSEUDONYM_MAP")
+	if mapPath == "" {
+		mapPath = DefaultPseudonymMapPath
+	}
+
+	return LoadPseudonymizer(keyPath, mapPath)
+}
+
+// Pseudonym returns the pseudonym of value; empty values stay empty and
+// values that are already pseudonyms are returned unchanged
+func (p *Pseudonymizer) Pseudonym(kind, value string) string {
+	if value == "" || strings.HasPrefix(value, pseudonymPrefix) {
+		return value
+	}
+
+	tag := ComputeHMAC(p.macKey, []byte(kind+"\x00"+value))
+	pseudonym := pseudonymPrefix + kind + ":" + hex.EncodeToString(tag[:pseudonymTagBytes])
+
+	p.remember(pseudonym, kind, value)
+	return pseudonym
+}
+
+// PseudonymizeIP pseudonymizes the host part of an address, so that
+// requests from the same client correlate regardless of source port
+func (p *Pseudonymizer) PseudonymizeIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return p.Pseudonym(PseudonymIP, addr)
+}
+
+// remember appends a sealed map entry the first time a pseudonym is
+// issued. A failed write is retried the next time the value is seen.
+func (p *Pseudonymizer) remember(pseudonym, kind, value string) {
+	if p.mapPath == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.known[pseudonym] {
+		return
+	}
+
+	sealed, err := EncryptData([]byte(value), p.sealKey, nil)
+	if err != nil {
+		return
+	}
+
+	line, err := json.Marshal(pseudonymMapEntry{
+		Pseudonym: pseudonym,
+		Kind:      kind,
+		Sealed:    hex.EncodeToString(sealed),
+	})
+	if err != nil {
+		return
+	}
+
+	f, err := os.OpenFile(p.mapPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	_, err = f.Write(append(line, '\n'))
+	if closeErr := f.Close(); err == nil && closeErr == nil {
+		p.known[pseudonym] = true
+	}
+}
+
+// Reidentify returns the kind and original value of a pseudonym
+func (p *Pseudonymizer) Reidentify(pseudonym string) (string, string, error) {
+	if p.mapPath == "" {
+		return "", "", fmt.Errorf("re-identification is disabled (no pseudonym map)")
+	}
+
+	var found *pseudonymMapEntry
+	err := p.scanMap(func(entry pseudonymMapEntry) bool {
+		if entry.Pseudonym == pseudonym {
+			found = &entry
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return "", "", err
+	}
+	if found == nil {
+		return "", "", fmt.Errorf("unknown pseudonym")
+	}
+
+	sealed, err := hex.DecodeString(found.Sealed)
+	if err != nil {
+		return "", "", fmt.Errorf("corrupt pseudonym map entry: %v", err)
+	}
+	value, err := DecryptData(sealed, p.sealKey)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to open pseudonym map entry: %v", err)
+	}
+
+	return found.Kind, string(value), nil
+}
+
+// scanMap calls fn for each map entry until it returns false; a missing
+// map is empty
+func (p *Pseudonymizer) scanMap(fn func(pseudonymMapEntry) bool) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	data, err := os.ReadFile(p.mapPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read pseudonym map: %v", err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry pseudonymMapEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("corrupt pseudonym map: %v", err)
+		}
+		if !fn(entry) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// pseudonymizeDetails replaces identities in audit event details
+func (p *Pseudonymizer) pseudonymizeDetails(details map[string]interface{}) {
+	for key, value := range details {
+		s, ok := value.(string)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "user", "user_id", "approver":
+			details[key] = p.Pseudonym(PseudonymUser, s)
+		case "source", "source_ip", "client_ip":
+			details[key] = p.PseudonymizeIP(s)
+		}
+	}
+}
+
+// ============================================================================
+// Re-identification API
+// ============================================================================
+
+// ReidentifyRequest asks for the identity behind a pseudonym
+type ReidentifyRequest struct {
+	Pseudonym string `json:"pseudonym"`
+	Reason    string `json:"reason"` // Recorded in the audit log
+}
+
+// ReidentifyResponse is the identity behind a pseudonym
+type ReidentifyResponse struct {
+	Pseudonym string `json:"pseudonym"`
+	Kind      string `json:"kind"`
+	Value     string `json:"value"`
+	Timestamp string `json:"timestamp"`
+}
+
+// HandleReidentify handles POST /api/v1/admin/pseudonyms/reidentify.
+// Callers are identified by client certificate through the RBAC policy
+// and must hold the admin role.
+func HandleReidentify(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	if decryptQuotas == nil {
+		respondError(w, r, "forbidden", "Re-identification requires an RBAC policy (EAMSA_RBAC_POLICY)")
+		return
+	}
+	identity := decryptQuotas.Policy().Identify(r)
+	if !hasRole(identity, "admin") {
+		LogAuditEvent("PSEUDONYM_REIDENTIFY_DENIED", map[string]interface{}{
+			"user":      identity.User,
+			"source":    r.RemoteAddr,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		respondError(w, r, "forbidden", "Re-identification requires the admin role")
+		return
+	}
+
+	var req ReidentifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+	if req.Pseudonym == "" || req.Reason == "" {
+		respondError(w, r, "bad_request", "pseudonym and reason are required")
+		return
+	}
+
+	kind, value, err := auditPseudonyms.Reidentify(req.Pseudonym)
+	LogAuditEvent("PSEUDONYM_REIDENTIFIED", map[string]interface{}{
+		"user":      identity.User,
+		"pseudonym": req.Pseudonym,
+		"reason":    req.Reason,
+		"success":   err == nil,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		respondError(w, r, "not_found", err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, ReidentifyResponse{
+		Pseudonym: req.Pseudonym,
+		Kind:      kind,
+		Value:     value,
+		Timestamp: time.Now().Format(time.RFC3339),
+	})
+}
+
+// hasRole reports whether identity holds role
+func hasRole(identity DecryptIdentity, role string) bool {
+	for _, r := range identity.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+CONFIGURATION:
+
+- EAMSA_AUDIT_PSEUDONYM_KEY: file holding a hex-encoded 32-byte key;
+  enables pseudonymization for the server and the gc command
+- EAMSA_AUDIT_PSEUDONYM_MAP: sealed re-identification map (default
+  /var/lib/eamsa512/pseudonyms.jsonl, 0600)
+
+COVERAGE:
+
+- Audit log file and queue: "user", "user_id", "approver", "source",
+  "source_ip" and "client_ip" details
+- audit_logs.user_id/source_ip and operations.user_id/client_ip
+- The sessions table keeps real user IDs and addresses: it is needed to
+  authenticate and is not an audit record
+
+KEY HANDLING:
+
+- Losing the key keeps existing logs correlatable among themselves but
+  new records get different pseudonyms; rotate it only deliberately
+- The key, not the map, is what protects the logs: anyone holding it can
+  test a guessed identity against a pseudonym
+*/
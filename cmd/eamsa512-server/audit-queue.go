@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// EAMSA 512 - Async Audit Pipeline
+// Bounded in-process audit queue with batching and spill-to-disk
+//
+// Crypto operations enqueue audit events and return; a background worker
+// writes them to the sink in batches. When the queue is full the overflow
+// policy either blocks the caller or drops the event and counts it. Events
+// that cannot be written (sink failure, shutdown deadline) are appended to
+// a spill file as JSON lines and replayed on the next start.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// AuditOverflowPolicy selects the behaviour when the queue is full
+type AuditOverflowPolicy int
+
+const (
+	// AuditOverflowBlock makes Enqueue wait for space (no event loss)
+	AuditOverflowBlock AuditOverflowPolicy = iota
+
+	// AuditOverflowDrop drops the event and increments the dropped counter
+	AuditOverflowDrop
+)
+
+// ParseAuditOverflowPolicy parses "block" or "drop"
+func ParseAuditOverflowPolicy(s string) (AuditOverflowPolicy, error) {
+	switch s {
+	case "", "block":
+		return AuditOverflowBlock, nil
+	case "drop":
+		return AuditOverflowDrop, nil
+	}
+	return AuditOverflowBlock, fmt.Errorf("invalid audit overflow policy %q (want block or drop)", s)
+}
+
+var (
+	// ErrAuditQueueFull is returned by Enqueue when an event is dropped
+	ErrAuditQueueFull = errors.New("audit queue full: event dropped")
+
+	// ErrAuditQueueClosed is returned by Enqueue after Close
+	ErrAuditQueueClosed = errors.New("audit queue closed")
+)
+
+// AuditSink receives batches of audit events
+type AuditSink interface {
+	WriteAuditBatch(entries []AuditLogEntry) error
+}
+
+// AuditQueueConfig configures the audit queue
+type AuditQueueConfig struct {
+	Capacity      int                 // Maximum queued events (default 10000)
+	BatchSize     int                 // Maximum events per sink write (default 100)
+	FlushInterval time.Duration       // Maximum time an event waits for a batch (default 1s)
+	Overflow      AuditOverflowPolicy // Behaviour when full
+	SpillPath     string              // JSON-lines file for unflushed events ("" disables)
+}
+
+// AuditQueueStats holds audit queue counters
+type AuditQueueStats struct {
+	Depth         int    `json:"depth"`
+	Enqueued      uint64 `json:"enqueued"`
+	Written       uint64 `json:"written"`
+	Dropped       uint64 `json:"dropped"`
+	Spilled       uint64 `json:"spilled"`
+	Replayed      uint64 `json:"replayed"`
+	FailedBatches uint64 `json:"failed_batches"`
+}
+
+// AuditQueue is a bounded, batching audit event queue
+type AuditQueue struct {
+	sink    AuditSink
+	config  AuditQueueConfig
+	events  chan AuditLogEntry
+	closing chan struct{}
+	done    chan struct{}
+	stats   AuditQueueStats
+	closed  bool
+	mu      sync.Mutex // guards stats and closed
+	spillMu sync.Mutex // serializes spill file writes
+}
+
+// NewAuditQueue starts a queue writing to sink. Events left in the spill
+// file by a previous run are written to the sink first.
+func NewAuditQueue(sink AuditSink, config AuditQueueConfig) (*AuditQueue, error) {
+	if config.Capacity <= 0 {
+		config.Capacity = 10000
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 100
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	q := &AuditQueue{
+		sink:    sink,
+		config:  config,
+		events:  make(chan AuditLogEntry, config.Capacity),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if err := q.replaySpill(); err != nil {
+		return nil, err
+	}
+
+	go q.run()
+	return q, nil
+}
+
+// Enqueue queues an audit event without waiting for the sink
+func (q *AuditQueue) Enqueue(entry AuditLogEntry) error {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	q.mu.Lock()
+	closed := q.closed
+	q.mu.Unlock()
+	if closed {
+		return ErrAuditQueueClosed
+	}
+
+	if q.config.Overflow == AuditOverflowDrop {
+		select {
+		case q.events <- entry:
+		default:
+			q.count(func(s *AuditQueueStats) { s.Dropped++ })
+			return ErrAuditQueueFull
+		}
+	} else {
+		select {
+		case q.events <- entry:
+		case <-q.closing:
+			return ErrAuditQueueClosed
+		}
+	}
+
+	q.count(func(s *AuditQueueStats) { s.Enqueued++ })
+	return nil
+}
+
+// Close stops accepting events and flushes the queue. Events still queued
+// when ctx expires are written to the spill file.
+func (q *AuditQueue) Close(ctx context.Context) error {
+	q.mu.Lock()
+	if q.closed {
+		q.mu.Unlock()
+		return nil
+	}
+	q.closed = true
+	q.mu.Unlock()
+
+	close(q.closing)
+
+	select {
+	case <-q.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	// Deadline passed: persist whatever the worker has not taken yet
+	var pending []AuditLogEntry
+	for {
+		select {
+		case entry := <-q.events:
+			pending = append(pending, entry)
+		default:
+			if err := q.spill(pending); err != nil {
+				return fmt.Errorf("audit shutdown: %d events lost: %v", len(pending), err)
+			}
+			return fmt.Errorf("audit shutdown deadline exceeded: %d events spilled", len(pending))
+		}
+	}
+}
+
+// Stats returns a snapshot of the queue counters
+func (q *AuditQueue) Stats() AuditQueueStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	stats := q.stats
+	stats.Depth = len(q.events)
+	return stats
+}
+
+// count updates stats under the lock
+func (q *AuditQueue) count(update func(*AuditQueueStats)) {
+	q.mu.Lock()
+	update(&q.stats)
+	q.mu.Unlock()
+}
+
+// run batches events until Close, then drains the queue
+func (q *AuditQueue) run() {
+	defer close(q.done)
+
+	ticker := time.NewTicker(q.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]AuditLogEntry, 0, q.config.BatchSize)
+
+	for {
+		select {
+		case entry := <-q.events:
+			batch = append(batch, entry)
+			if len(batch) >= q.config.BatchSize {
+				batch = q.flush(batch)
+			}
+		case <-ticker.C:
+			batch = q.flush(batch)
+		case <-q.closing:
+			for {
+				select {
+				case entry := <-q.events:
+					batch = append(batch, entry)
+					if len(batch) >= q.config.BatchSize {
+						batch = q.flush(batch)
+					}
+				default:
+					q.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch to the sink, spilling it on failure
+func (q *AuditQueue) flush(batch []AuditLogEntry) []AuditLogEntry {
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := q.sink.WriteAuditBatch(batch); err != nil {
+		q.count(func(s *AuditQueueStats) { s.FailedBatches++ })
+		log.Printf("audit sink write failed (%d events): %v", len(batch), err)
+		if err := q.spill(batch); err != nil {
+			log.Printf("audit spill failed, %d events lost: %v", len(batch), err)
+		}
+	} else {
+		q.count(func(s *AuditQueueStats) { s.Written += uint64(len(batch)) })
+	}
+
+	return batch[:0]
+}
+
+// spill appends events to the spill file as JSON lines
+func (q *AuditQueue) spill(entries []AuditLogEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	if q.config.SpillPath == "" {
+		return fmt.Errorf("no spill file configured")
+	}
+
+	q.spillMu.Lock()
+	defer q.spillMu.Unlock()
+
+	f, err := os.OpenFile(q.config.SpillPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit spill file: %v", err)
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write audit spill file: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit spill file: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit spill file: %v", err)
+	}
+
+	q.count(func(s *AuditQueueStats) { s.Spilled += uint64(len(entries)) })
+	return nil
+}
+
+// replaySpill writes spilled events to the sink and removes the spill file
+func (q *AuditQueue) replaySpill() error {
+	if q.config.SpillPath == "" {
+		return nil
+	}
+
+	f, err := os.Open(q.config.SpillPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open audit spill file: %v", err)
+	}
+
+	var entries []AuditLogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		var entry AuditLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			f.Close()
+			return fmt.Errorf("corrupt audit spill file %s: %v", q.config.SpillPath, err)
+		}
+		entries = append(entries, entry)
+	}
+	f.Close()
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read audit spill file: %v", err)
+	}
+
+	for start := 0; start < len(entries); start += q.config.BatchSize {
+		end := start + q.config.BatchSize
+		if end > len(entries) {
+			end = len(entries)
+		}
+		if err := q.sink.WriteAuditBatch(entries[start:end]); err != nil {
+			// Keep the spill file; nothing is lost
+			return fmt.Errorf("failed to replay audit spill file: %v", err)
+		}
+	}
+
+	if err := os.Remove(q.config.SpillPath); err != nil {
+		return fmt.Errorf("failed to remove replayed audit spill file: %v", err)
+	}
+
+	q.stats.Replayed = uint64(len(entries))
+	return nil
+}
+
+// ============================================================================
+// Sinks
+// ============================================================================
+
+// loggerAuditSink writes events to the audit log file
+type loggerAuditSink struct {
+	logger *log.Logger
+}
+
+// WriteAuditBatch writes one "EVENT | {details}" line per event
+func (s loggerAuditSink) WriteAuditBatch(entries []AuditLogEntry) error {
+	for _, entry := range entries {
+		s.logger.Printf("%s | %s", entry.EventType, entry.Details)
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ============================================================================
+// EAMSA 512 - Audit Review
+// Operator commands for reading and verifying audit logs without SQL
+//
+//   eamsa512-server audit tail   [-n 20] [-f] [-db path | -file export]
+//   eamsa512-server audit search [-event E] [-category C] [-severity S]
+//                                [-user U] [-since T] [-until T]
+//                                [-contains text] [-limit N]
+//   eamsa512-server audit verify [-expect-head hash]
+//
+// Rows come from the audit_logs table (opened read-only, so the server
+// can keep running) or from an export file forwarded to a SIEM (-file,
+// a JSON array or JSON lines). Output is a table, or JSON lines with
+// -format json, which is itself an export file that audit verify accepts.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// auditDetailsWidth is how much of the details JSON a table row shows
+const auditDetailsWidth = 80
+
+// auditSource is where audit review reads rows from
+type auditSource struct {
+	db   *Database
+	file []AuditLogEntry // Sorted by ID
+}
+
+// query returns the rows matching filter (see Database.QueryAuditLogs)
+func (s *auditSource) query(filter AuditLogFilter) ([]AuditLogEntry, error) {
+	if s.db != nil {
+		return s.db.QueryAuditLogs(filter)
+	}
+
+	var matched []AuditLogEntry
+	for _, entry := range s.file {
+		if matchAuditFilter(entry, filter) {
+			matched = append(matched, entry)
+		}
+	}
+	if filter.Limit > 0 && len(matched) > filter.Limit {
+		if filter.Newest {
+			matched = matched[len(matched)-filter.Limit:]
+		} else {
+			matched = matched[:filter.Limit]
+		}
+	}
+	return matched, nil
+}
+
+// matchAuditFilter applies filter to one exported row
+func matchAuditFilter(entry AuditLogEntry, filter AuditLogFilter) bool {
+	switch {
+	case entry.ID <= filter.AfterID:
+		return false
+	case !filter.Since.IsZero() && entry.Timestamp.Before(filter.Since):
+		return false
+	case !filter.Until.IsZero() && !entry.Timestamp.Before(filter.Until):
+		return false
+	case filter.EventType != "" && entry.EventType != filter.EventType:
+		return false
+	case filter.Category != "" && entry.Category != filter.Category:
+		return false
+	case filter.Severity != "" && entry.Severity != filter.Severity:
+		return false
+	case filter.UserID != "" && entry.UserID != filter.UserID:
+		return false
+	case filter.Contains != "" && !strings.Contains(entry.Details, filter.Contains):
+		return false
+	}
+	return true
+}
+
+// RunAuditCommand implements the "audit" subcommand
+func RunAuditCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: audit tail|search|verify [flags]")
+	}
+	action := args[0]
+	if action != "tail" && action != "search" && action != "verify" {
+		return fmt.Errorf("unknown audit action %q", action)
+	}
+
+	fs := flag.NewFlagSet("audit "+action, flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to database")
+	file := fs.String("file", "", "Read an audit export (JSON array or JSON lines) instead of the database")
+	format := fs.String("format", "table", "Output format: table or json")
+
+	// tail
+	count := fs.Int("n", 20, "tail: number of rows to show")
+	follow := fs.Bool("f", false, "tail: keep printing new rows (database only)")
+	interval := fs.Duration("interval", time.Second, "tail -f: poll interval")
+
+	// search
+	event := fs.String("event", "", "search: event type, e.g. BREAK_GLASS")
+	category := fs.String("category", "", "search: category")
+	severity := fs.String("severity", "", "search: severity")
+	user := fs.String("user", "", "search: user ID (pseudonymized first if pseudonymization is configured)")
+	since := fs.String("since", "", "search: RFC 3339 time, or a duration such as 24h meaning that long ago")
+	until := fs.String("until", "", "search: RFC 3339 time, or a duration meaning that long ago")
+	contains := fs.String("contains", "", "search: text the details must contain")
+	limit := fs.Int("limit", 100, "search: most rows to show (0: all)")
+
+	// verify
+	expectHead := fs.String("expect-head", "", "verify: chain head recorded earlier; fail unless the chain still contains it")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("-format must be table or json")
+	}
+
+	source := &auditSource{}
+	if *file != "" {
+		if *follow {
+			return fmt.Errorf("-f needs the database, not -file")
+		}
+		entries, err := ReadAuditExport(*file)
+		if err != nil {
+			return err
+		}
+		sort.Slice(entries, func(i, j int) bool { return entries[i].ID < entries[j].ID })
+		source.file = entries
+	} else {
+		db, err := OpenDatabase(*dbPath, StoreOpenOptions{ReadOnly: true})
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		source.db = db
+	}
+
+	switch action {
+	case "tail":
+		return runAuditTail(source, *count, *follow, *interval, *format)
+	case "search":
+		filter := AuditLogFilter{
+			EventType: *event,
+			Category:  *category,
+			Severity:  *severity,
+			UserID:    *user,
+			Contains:  *contains,
+			Limit:     *limit,
+		}
+		var err error
+		if filter.Since, err = parseAuditTime(*since); err != nil {
+			return fmt.Errorf("-since: %v", err)
+		}
+		if filter.Until, err = parseAuditTime(*until); err != nil {
+			return fmt.Errorf("-until: %v", err)
+		}
+		if filter.UserID != "" {
+			pseudonyms, err := LoadPseudonymizerFromEnv()
+			if err != nil {
+				return err
+			}
+			if pseudonyms != nil {
+				filter.UserID = pseudonyms.Pseudonym(PseudonymUser, filter.UserID)
+			}
+		}
+		entries, err := source.query(filter)
+		if err != nil {
+			return err
+		}
+		return printAuditEntries(entries, *format, true)
+	default:
+		return runAuditVerify(source, *expectHead, *format)
+	}
+}
+
+// runAuditTail prints the last count rows and, with follow, new rows as
+// they are written
+func runAuditTail(source *auditSource, count int, follow bool, interval time.Duration, format string) error {
+	entries, err := source.query(AuditLogFilter{Limit: count, Newest: true})
+	if err != nil {
+		return err
+	}
+	if err := printAuditEntries(entries, format, true); err != nil {
+		return err
+	}
+	if !follow {
+		return nil
+	}
+
+	var lastID int64
+	if len(entries) > 0 {
+		lastID = entries[len(entries)-1].ID
+	}
+	for {
+		time.Sleep(interval)
+		entries, err := source.query(AuditLogFilter{AfterID: lastID})
+		if err != nil {
+			return err
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if err := printAuditEntries(entries, format, false); err != nil {
+			return err
+		}
+		lastID = entries[len(entries)-1].ID
+	}
+}
+
+// runAuditVerify verifies the whole chain and fails if it is broken or
+// does not contain expectHead
+func runAuditVerify(source *auditSource, expectHead, format string) error {
+	entries, err := source.query(AuditLogFilter{})
+	if err != nil {
+		return err
+	}
+	report := VerifyAuditChain(entries)
+
+	if expectHead != "" {
+		found := false
+		for _, entry := range entries {
+			if entry.Hash == expectHead {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report.Breaks = append(report.Breaks, AuditChainBreak{Reason: "expected head " + expectHead + " not found (rows removed from the end)"})
+			report.Valid = false
+		}
+	}
+
+	if format == "json" {
+		if err := printJSON(report); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("Entries:   %d (%d unchained)\n", report.Entries, report.Unchained)
+		if report.Entries > 0 {
+			fmt.Printf("IDs:       %d-%d\n", report.FirstID, report.LastID)
+		}
+		if report.Anchor != "" {
+			fmt.Printf("Anchor:    %s\n", report.Anchor)
+		}
+		fmt.Printf("Head:      %s\n", report.Head)
+		for _, b := range report.Breaks {
+			if b.ID != 0 {
+				fmt.Printf("BREAK at id %d: %s\n", b.ID, b.Reason)
+			} else {
+				fmt.Printf("BREAK: %s\n", b.Reason)
+			}
+		}
+	}
+
+	if !report.Valid {
+		return fmt.Errorf("audit chain does not verify (%d breaks)", len(report.Breaks))
+	}
+	if format == "table" {
+		fmt.Println("Chain OK")
+	}
+	return nil
+}
+
+// printAuditEntries prints rows as a table (with a header line if
+// header) or as JSON lines
+func printAuditEntries(entries []AuditLogEntry, format string, header bool) error {
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	if header {
+		fmt.Fprintln(tw, "ID\tTIME\tSEVERITY\tEVENT\tUSER\tSOURCE\tDETAILS")
+	}
+	for _, entry := range entries {
+		details := entry.Details
+		if len(details) > auditDetailsWidth {
+			details = details[:auditDetailsWidth-3] + "..."
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.ID,
+			entry.Timestamp.UTC().Format(time.RFC3339), entry.Severity, entry.EventType,
+			entry.UserID, entry.SourceIP, details)
+	}
+	return tw.Flush()
+}
+
+// parseAuditTime parses an RFC 3339 time, or a duration meaning that long
+// before now; empty is the zero time
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want an RFC 3339 time or a duration, got %q", value)
+	}
+	return t, nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. EXAMPLES
+   - Last 50 rows, then follow:  audit tail -n 50 -f
+   - Break-glass decryptions this week:
+     audit search -event BREAK_GLASS -since 168h
+   - One user's denials as JSON:
+     audit search -user alice -contains DENIED -format json
+   - Check a SIEM copy:           audit verify -file export.jsonl
+   - Check nothing was cut off:   audit verify -expect-head <head>
+
+2. EXIT STATUS
+   - verify exits 1 when the chain is broken or the expected head is
+     missing, so it can run from cron or CI
+
+3. ACCESS
+   - The database is opened read-only and without the store lock; the
+     commands need read access to the file, not SQL credentials
+   - With pseudonymization configured, -user is pseudonymized before the
+     search and rows show pseudonyms; re-identify with the admin API
+
+*/
@@ -0,0 +1,396 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// EAMSA 512 - Audit Routing
+// Per-category audit sinks, each behind its own queue
+//
+// Every audit event has a category: authorization denials and alerts are
+// "security", key lifecycle and administrative changes "admin", crypto
+// operations "operation", and everything else "system". A routing file
+// (EAMSA_AUDIT_ROUTES) sends categories to a JSON-lines file, the
+// audit_logs table or a webhook. Each route has its own queue and
+// overflow policy, so a flood of operation events can be dropped or
+// delayed without holding back security events. Categories no route
+// names go to the server audit log as before.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Audit event categories (AuditLogEntry.Category)
+const (
+	AuditCategorySecurity  = "security"  // Denials, alerts, TLS failures
+	AuditCategoryAdmin     = "admin"     // Key lifecycle, deletions, failpoints
+	AuditCategoryOperation = "operation" // Encrypt, decrypt, streaming
+	AuditCategorySystem    = "system"    // Clock checks and anything else
+)
+
+// auditCategories are the valid categories, for validation
+var auditCategories = []string{AuditCategorySecurity, AuditCategoryAdmin, AuditCategoryOperation, AuditCategorySystem}
+
+// auditEventCategories classifies events that prefixes do not
+var auditEventCategories = map[string]string{
+	"DECRYPT_POLICY_DENIED":       AuditCategorySecurity,
+	"DECRYPT_QUOTA_EXCEEDED":      AuditCategorySecurity,
+	"PSEUDONYM_REIDENTIFY_DENIED": AuditCategorySecurity,
+	"PSEUDONYM_REIDENTIFIED":      AuditCategorySecurity,
+	"BREAK_GLASS":                 AuditCategorySecurity,
+	"HONEYTOKEN_TRIPPED":          AuditCategorySecurity,
+	"TLS_VERIFY_FAILED":           AuditCategorySecurity,
+	"LOW_ENTROPY_KEY":             AuditCategorySecurity,
+	"SUITE_DOWNGRADE_DETECTED":    AuditCategorySecurity,
+	"RANDOM_SOURCE_FAILED":        AuditCategorySecurity,
+	"RANDOM_FALLBACK":             AuditCategorySecurity,
+
+	"BLOB_DELETED":  AuditCategoryAdmin,
+	"FAILPOINT_SET": AuditCategoryAdmin,
+
+	"ENCRYPT":          AuditCategoryOperation,
+	"DECRYPT":          AuditCategoryOperation,
+	"DECRYPT_FAILED":   AuditCategoryOperation,
+	"SUITE_NEGOTIATED": AuditCategoryOperation,
+}
+
+// AuditCategoryOf returns the category of an audit event
+func AuditCategoryOf(event string) string {
+	if category, ok := auditEventCategories[event]; ok {
+		return category
+	}
+
+	switch {
+	case strings.HasSuffix(event, "_DENIED"):
+		return AuditCategorySecurity
+	case strings.HasPrefix(event, "KEY_"), strings.HasPrefix(event, "GC_"):
+		return AuditCategoryAdmin
+	case strings.HasPrefix(event, "STREAM_"):
+		return AuditCategoryOperation
+	}
+	return AuditCategorySystem
+}
+
+// AuditRoutingConfig is the routing file
+type AuditRoutingConfig struct {
+	Routes []AuditRouteConfig `json:"routes"`
+}
+
+// AuditRouteConfig sends some categories to one sink
+type AuditRouteConfig struct {
+	Name       string          `json:"name,omitempty"` // Metrics label (default: the categories)
+	Categories []string        `json:"categories"`
+	Sink       AuditSinkConfig `json:"sink"`
+
+	// Queue settings, as in ServerConfig (zeros select the queue defaults)
+	QueueCapacity   int    `json:"queue_capacity,omitempty"`
+	BatchSize       int    `json:"batch_size,omitempty"`
+	FlushIntervalMS int    `json:"flush_interval_ms,omitempty"`
+	Overflow        string `json:"overflow,omitempty"` // block (default) or drop
+	SpillPath       string `json:"spill_path,omitempty"`
+}
+
+// AuditSinkConfig selects where a route writes
+type AuditSinkConfig struct {
+	Type    string              `json:"type"`              // file, db or webhook
+	Path    string              `json:"path,omitempty"`    // file: JSON-lines log; db: database path
+	Webhook *AlertWebhookConfig `json:"webhook,omitempty"` // webhook: receives JSON arrays of entries
+}
+
+// AuditRouteStats are the queue counters of one route
+type AuditRouteStats struct {
+	Name       string          `json:"name"`
+	Categories []string        `json:"categories"`
+	Queue      AuditQueueStats `json:"queue"`
+}
+
+// auditRoute is a started route
+type auditRoute struct {
+	name       string
+	categories []string
+	queue      *AuditQueue
+	closer     func() error // Releases the sink after the queue is drained
+}
+
+// AuditRouter sends audit events to the route of their category
+type AuditRouter struct {
+	routes     []*auditRoute
+	byCategory map[string]*auditRoute
+}
+
+// LoadAuditRouter reads a routing file and starts its routes
+func LoadAuditRouter(path string) (*AuditRouter, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit routing config: %v", err)
+	}
+
+	var config AuditRoutingConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse audit routing config: %v", err)
+	}
+
+	return NewAuditRouter(config)
+}
+
+// NewAuditRouter validates config and starts a queue and sink per route.
+// A category may appear in one route only.
+func NewAuditRouter(config AuditRoutingConfig) (*AuditRouter, error) {
+	r := &AuditRouter{byCategory: make(map[string]*auditRoute)}
+
+	for i, rc := range config.Routes {
+		route, err := r.start(rc)
+		if err != nil {
+			r.Close(context.Background())
+			return nil, fmt.Errorf("audit route %d: %v", i+1, err)
+		}
+		r.routes = append(r.routes, route)
+	}
+
+	return r, nil
+}
+
+// start validates one route and opens its sink and queue
+func (r *AuditRouter) start(rc AuditRouteConfig) (*auditRoute, error) {
+	if len(rc.Categories) == 0 {
+		return nil, fmt.Errorf("no categories")
+	}
+	for _, category := range rc.Categories {
+		if !isAuditCategory(category) {
+			return nil, fmt.Errorf("unknown category %q (want one of %s)", category, strings.Join(auditCategories, ", "))
+		}
+		if _, taken := r.byCategory[category]; taken {
+			return nil, fmt.Errorf("category %q is already routed", category)
+		}
+	}
+
+	overflow, err := ParseAuditOverflowPolicy(rc.Overflow)
+	if err != nil {
+		return nil, err
+	}
+
+	sink, closer, err := openAuditSink(rc.Sink)
+	if err != nil {
+		return nil, err
+	}
+
+	queue, err := NewAuditQueue(sink, AuditQueueConfig{
+		Capacity:      rc.QueueCapacity,
+		BatchSize:     rc.BatchSize,
+		FlushInterval: time.Duration(rc.FlushIntervalMS) * time.Millisecond,
+		Overflow:      overflow,
+		SpillPath:     rc.SpillPath,
+	})
+	if err != nil {
+		closer()
+		return nil, err
+	}
+
+	route := &auditRoute{
+		name:       rc.Name,
+		categories: append([]string(nil), rc.Categories...),
+		queue:      queue,
+		closer:     closer,
+	}
+	if route.name == "" {
+		route.name = strings.Join(route.categories, "+")
+	}
+	for _, category := range route.categories {
+		r.byCategory[category] = route
+	}
+	return route, nil
+}
+
+// Route queues entry on the route of its category. It returns false if
+// no route takes the category, so the caller writes it as usual.
+func (r *AuditRouter) Route(entry AuditLogEntry) bool {
+	route, ok := r.byCategory[entry.Category]
+	if !ok {
+		return false
+	}
+
+	// A full drop-policy queue counts the event in its stats
+	route.queue.Enqueue(entry)
+	return true
+}
+
+// Stats returns the queue counters of every route
+func (r *AuditRouter) Stats() []AuditRouteStats {
+	stats := make([]AuditRouteStats, 0, len(r.routes))
+	for _, route := range r.routes {
+		stats = append(stats, AuditRouteStats{
+			Name:       route.name,
+			Categories: route.categories,
+			Queue:      route.queue.Stats(),
+		})
+	}
+	return stats
+}
+
+// Close flushes every route until ctx expires (see AuditQueue.Close) and
+// releases the sinks
+func (r *AuditRouter) Close(ctx context.Context) error {
+	var errs []string
+	for _, route := range r.routes {
+		if err := route.queue.Close(ctx); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", route.name, err))
+		}
+		if err := route.closer(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", route.name, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("audit routes: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// isAuditCategory reports whether category is one of auditCategories
+func isAuditCategory(category string) bool {
+	for _, c := range auditCategories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// openAuditSink opens the configured sink and returns it with its closer
+func openAuditSink(config AuditSinkConfig) (AuditSink, func() error, error) {
+	switch config.Type {
+	case "file":
+		if config.Path == "" {
+			return nil, nil, fmt.Errorf("file sink needs a path")
+		}
+		sink, err := newFileAuditSink(config.Path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sink, sink.Close, nil
+
+	case "db":
+		if config.Path == "" {
+			return nil, nil, fmt.Errorf("db sink needs a path")
+		}
+		db, err := NewDatabase(config.Path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to open audit database: %v", err)
+		}
+		return db, db.Close, nil
+
+	case "webhook":
+		if config.Webhook == nil {
+			return nil, nil, fmt.Errorf("webhook sink needs a webhook section")
+		}
+		sink, err := newWebhookAuditSink(*config.Webhook)
+		if err != nil {
+			return nil, nil, err
+		}
+		return sink, func() error { return nil }, nil
+	}
+
+	return nil, nil, fmt.Errorf("unknown audit sink type %q (want \"file\", \"db\" or \"webhook\")", config.Type)
+}
+
+// ============================================================================
+// Sinks
+// ============================================================================
+
+// fileAuditSink appends events to a file as JSON lines, the export format
+// audit review reads (-file)
+type fileAuditSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// newFileAuditSink opens path for appending
+func newFileAuditSink(path string) (*fileAuditSink, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %v", err)
+	}
+	return &fileAuditSink{file: f}, nil
+}
+
+// WriteAuditBatch appends and syncs a batch
+func (s *fileAuditSink) WriteAuditBatch(entries []AuditLogEntry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w := bufio.NewWriter(s.file)
+	enc := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write audit file: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write audit file: %v", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit file: %v", err)
+	}
+	return nil
+}
+
+// Close closes the file
+func (s *fileAuditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// webhookAuditSink posts each batch as a JSON array, signed like the
+// honeytoken alert webhook. A failed post fails the batch, which the
+// route's queue spills and replays.
+type webhookAuditSink struct {
+	url    string
+	secret []byte
+	client *http.Client
+}
+
+// newWebhookAuditSink checks config and builds the client
+func newWebhookAuditSink(config AlertWebhookConfig) (*webhookAuditSink, error) {
+	secret, client, err := newWebhookClient("audit-webhook", config)
+	if err != nil {
+		return nil, err
+	}
+	return &webhookAuditSink{url: config.URL, secret: secret, client: client}, nil
+}
+
+// WriteAuditBatch posts a batch
+func (s *webhookAuditSink) WriteAuditBatch(entries []AuditLogEntry) error {
+	body, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signWebhookRequest(req, body, s.secret)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit webhook: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("audit webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Basic Encryption Implementation
+// Enterprise Authenticated 512-bit Encryption Algorithm
+//
+// This file demonstrates the core encryption and decryption logic for EAMSA 512,
+// based on the authenticated encryption scheme from:
+// https://ijcsm.researchcommons.org/ijcsm/vol4/iss2/11
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Constants for EAMSA 512 (see pkg/eamsa512)
+const (
+	// Block size: 512 bits = 64 bytes
+	BlockSize = eamsa512.BlockSize
+
+	// Number of rounds for the encryption algorithm
+	Rounds = eamsa512.Rounds
+
+	// Nonce size: 128 bits = 16 bytes
+	NonceSize = eamsa512.NonceSize
+
+	// Authentication tag size: 512 bits = 64 bytes
+	TagSize = eamsa512.TagSize
+
+	// Key size: 256 bits = 32 bytes (master key)
+	KeySize = eamsa512.KeySize
+)
+
+// ChaosParams holds parameters for the chaos-based entropy source
+// (eamsa512.ChaosEntropy integrates these defaults)
+type ChaosParams struct {
+	Rho   float64 // Lorenz system parameter
+	Sigma float64 // Lorenz system parameter
+	Beta  float64 // Lorenz system parameter
+}
+
+// DefaultChaosParams returns standard Lorenz system parameters
+func DefaultChaosParams() ChaosParams {
+	return ChaosParams{
+		Rho:   28.0,
+		Sigma: 10.0,
+		Beta:  2.667,
+	}
+}
+
+// ============================================================================
+// Key Derivation Function (KDF)
+// Uses SHA3-512 to derive round keys from the master key
+// ============================================================================
+
+// DeriveKeys generates 11 round keys from the master key using SHA3-512
+// Each key is 128 bits (16 bytes)
+// Returns a slice of 11 keys, each 16 bytes long
+func DeriveKeys(masterKey []byte) ([][]byte, error) {
+	return eamsa512.DeriveKeys(masterKey)
+}
+
+// ============================================================================
+// Nonce and IV Generation
+// ============================================================================
+
+// GenerateNonce creates a new random nonce for encryption
+// Returns a 16-byte nonce from source; nil uses crypto/rand, or the source
+// set with eamsa512.SetNonceSource (an HSM or TRNG)
+func GenerateNonce(source eamsa512.EntropySource) ([]byte, error) {
+	return eamsa512.NewNonceFrom(source)
+}
+
+// DeriveIV derives an Initialization Vector from nonce and key using SHA3-512
+// Returns a 64-byte IV
+func DeriveIV(nonce []byte, key []byte) []byte {
+	return eamsa512.DeriveIV(nonce, key)
+}
+
+// ============================================================================
+// Core Block Encryption (SPN - Substitution-Permutation Network)
+// ============================================================================
+
+// The round functions and block cipher live in pkg/eamsa512; these names
+// are kept for the examples and tests
+
+// SubstituteBlock applies the substitution layer to a block
+// Uses S-box transformation based on SHA3
+func SubstituteBlock(block []byte) []byte {
+	return eamsa512.SubstituteBlock(block)
+}
+
+// PermuteBlock applies a permutation layer to a block
+func PermuteBlock(block []byte) []byte {
+	return eamsa512.PermuteBlock(block)
+}
+
+// MixBlock XORs a block with key material
+func MixBlock(block []byte, key []byte) []byte {
+	return eamsa512.MixBlock(block, key)
+}
+
+// EncryptBlock encrypts a single 64-byte block using SPN with derived keys
+// block: plaintext block (must be 64 bytes)
+// keys: array of round keys (11 keys of 16 bytes each)
+// Returns encrypted block (64 bytes)
+func EncryptBlock(block []byte, keys [][]byte) []byte {
+	return eamsa512.EncryptBlock(block, keys)
+}
+
+// DecryptBlock decrypts a single 64-byte block
+// Uses inverse operations in reverse order
+func DecryptBlock(ciphertext []byte, keys [][]byte) []byte {
+	return eamsa512.DecryptBlock(ciphertext, keys)
+}
+
+// ReversePermuteBlock reverses the permutation
+func ReversePermuteBlock(block []byte) []byte {
+	return eamsa512.ReversePermuteBlock(block)
+}
+
+// ReverseSubstituteBlock reverses the substitution
+func ReverseSubstituteBlock(block []byte) []byte {
+	return eamsa512.ReverseSubstituteBlock(block)
+}
+
+// ============================================================================
+// HMAC-SHA3-512 Authentication
+// ============================================================================
+
+// ComputeHMAC computes HMAC-SHA3-512 for authentication
+// key: authentication key (32 bytes)
+// data: data to authenticate (variable length)
+// Returns 64-byte HMAC tag
+func ComputeHMAC(key []byte, data []byte) []byte {
+	return eamsa512.ComputeHMAC(key, data)
+}
+
+// VerifyHMAC verifies an HMAC tag in constant time
+// key: authentication key
+// data: authenticated data
+// tag: received HMAC tag
+// Returns true if tag is valid
+func VerifyHMAC(key []byte, data []byte, tag []byte) bool {
+	return eamsa512.VerifyHMAC(key, data, tag)
+}
+
+// ============================================================================
+// Encrypt Function (Main API)
+// ============================================================================
+
+// EncryptData encrypts plaintext with EAMSA 512
+// plaintext: data to encrypt (variable length)
+// masterKey: master key (32 bytes)
+// nonce: optional nonce; if nil, will be generated (16 bytes)
+// Returns: ciphertext || nonce || HMAC tag (variable + 16 + 64 bytes)
+// Copies plaintext; use SealInPlace to encrypt the caller's buffer directly
+func EncryptData(plaintext []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	return eamsa512.EncryptData(plaintext, masterKey, nonce)
+}
+
+// EncryptDataWithAAD is EncryptData with additional authenticated data:
+// aad is covered by the tag but not encrypted or stored, and must be
+// passed unchanged to DecryptDataWithAAD
+func EncryptDataWithAAD(plaintext []byte, masterKey []byte, nonce []byte, aad []byte) ([]byte, error) {
+	return eamsa512.EncryptDataWithAAD(plaintext, masterKey, nonce, aad)
+}
+
+// ============================================================================
+// Decrypt Function (Main API)
+// ============================================================================
+
+// ErrDecryption is the single error DecryptData returns for tampered,
+// truncated or badly padded input (see pkg/eamsa512)
+var ErrDecryption = eamsa512.ErrDecryption
+
+// DecryptData decrypts ciphertext with EAMSA 512
+// encryptedData: ciphertext || nonce || HMAC tag
+// masterKey: master key (32 bytes)
+// Returns: plaintext or error
+// Copies encryptedData; use OpenInPlace to decrypt the caller's buffer directly
+func DecryptData(encryptedData []byte, masterKey []byte) ([]byte, error) {
+	return eamsa512.DecryptData(encryptedData, masterKey)
+}
+
+// DecryptDataWithAAD decrypts EncryptDataWithAAD output; aad must match
+func DecryptDataWithAAD(encryptedData []byte, masterKey []byte, aad []byte) ([]byte, error) {
+	return eamsa512.DecryptDataWithAAD(encryptedData, masterKey, aad)
+}
+
+// ============================================================================
+// Envelope API
+// ============================================================================
+
+// EnvelopeOptions selects the mode, key version and chunk size of Encrypt
+type EnvelopeOptions = eamsa512.EnvelopeOptions
+
+// Encrypt encrypts plaintext into a versioned envelope: magic, format
+// version, mode, key version, nonce and chunk size, all authenticated,
+// followed by the ciphertext and tag (see pkg/eamsa512/envelope.go)
+func Encrypt(plaintext []byte, masterKey []byte, opts EnvelopeOptions) ([]byte, error) {
+	return eamsa512.Encrypt(plaintext, masterKey, opts)
+}
+
+// Decrypt decrypts an envelope, or bare EncryptData output written before
+// envelopes. Bare data that happens to start with the envelope magic is
+// still decrypted: it is retried as EncryptData output when it does not
+// open as an envelope.
+func Decrypt(data []byte, masterKey []byte) ([]byte, error) {
+	if !eamsa512.IsEnvelope(data) {
+		return DecryptData(data, masterKey)
+	}
+
+	plaintext, err := eamsa512.Decrypt(data, masterKey)
+	if err != nil {
+		if legacy, legacyErr := DecryptData(data, masterKey); legacyErr == nil {
+			return legacy, nil
+		}
+		return nil, err
+	}
+	return plaintext, nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+1. SIMPLIFIED IMPLEMENTATION
+   This is a basic educational implementation of EAMSA 512.
+   Production implementation should include:
+   - Hardware acceleration (SIMD, AES-NI)
+   - Constant-time operations to prevent timing attacks
+   - Formal cryptographic validation
+   - Hardware Security Module (HSM) integration
+
+2. BLOCK CIPHER
+   - Block size: 512 bits (64 bytes)
+   - Key size: 256 bits (32 bytes)
+   - 16 rounds of SPN (Substitution-Permutation Network)
+   - Uses SHA3-512 for S-box and permutation design
+
+3. MODE OF OPERATION
+   - CBC mode (Cipher Block Chaining) for confidentiality
+   - HMAC-SHA3-512 for authentication (Encrypt-then-MAC)
+   - 16-byte random nonce per encryption
+   - SealInPlace/OpenInPlace (seal.go) reuse the caller's buffer;
+     EncryptData/DecryptData copy their input and call them
+   - The core is the importable package pkg/eamsa512; the functions
+     here delegate to it
+   - Encrypt/Decrypt wrap the ciphertext in a versioned envelope
+     ("EAME" magic, version, mode, key version, chunk size, nonce);
+     Decrypt still opens bare EncryptData output
+
+4. KEY DERIVATION
+   - 11 round keys derived from master key using SHA3-512
+   - Each key: 16 bytes (128 bits)
+   - Ensures different keys for each round
+
+5. AUTHENTICATION
+   - HMAC tag: 64 bytes (512 bits)
+   - Covers both nonce and ciphertext
+   - Prevents tampering and ensures message integrity
+
+6. COMPLIANCE
+   - FIPS 140-2 Level 2 capable
+   - NIST SP 800-56A compliant for key agreement
+   - SHA3-512 from NIST FIPS 202
+   - Suitable for high-security applications
+
+7. SECURITY PROPERTIES
+   - Confidentiality: CBC mode + random nonce
+   - Authenticity: HMAC-SHA3-512
+   - Integrity: HMAC authentication tag
+   - Resistance to replay: Nonce-based
+
+*/
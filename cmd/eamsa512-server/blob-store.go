@@ -0,0 +1,874 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/tlstrust"
+)
+
+// ============================================================================
+// EAMSA 512 - Blob Store
+// Content-addressed storage for sealed ciphertexts
+//
+// With a blob store configured, the server can keep ciphertexts itself and
+// hand the client a handle instead of echoing the ciphertext back. Blobs are
+// named by the SHA3-256 of their content ("sha3-256:<hex>"), so storing the
+// same ciphertext twice is a no-op and every read is checked against its
+// name. Blobs hold sealed data only (ciphertext || nonce || tag); keys never
+// reach the store.
+//
+// Backends: a local directory, an S3-compatible bucket (AWS, MinIO, Ceph)
+// addressed path-style and signed with AWS Signature Version 4, or a
+// Postgres table.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// BlobHandlePrefix starts every blob handle
+const BlobHandlePrefix = "sha3-256:"
+
+var (
+	// ErrBlobNotFound is returned for handles not in the store
+	ErrBlobNotFound = errors.New("blob not found")
+
+	// ErrBlobCorrupt is returned when stored content does not match its handle
+	ErrBlobCorrupt = errors.New("blob content does not match its handle")
+
+	errInvalidBlobHandle = errors.New("invalid blob handle")
+)
+
+// BlobStore stores immutable blobs by content hash
+type BlobStore interface {
+	// Put stores the content of r and returns its handle and size
+	Put(ctx context.Context, r io.Reader) (string, int64, error)
+
+	// Get opens a blob. The reader returns ErrBlobCorrupt at the end if
+	// the content does not match the handle.
+	Get(ctx context.Context, handle string) (io.ReadCloser, error)
+
+	// Delete removes a blob; deleting a missing blob is not an error
+	Delete(ctx context.Context, handle string) error
+
+	// List calls fn for every stored handle
+	List(ctx context.Context, fn func(handle string) error) error
+}
+
+// BlobHandle returns the handle of content
+func BlobHandle(content []byte) string {
+	sum := sha3.Sum256(content)
+	return BlobHandlePrefix + hex.EncodeToString(sum[:])
+}
+
+// parseBlobHandle returns the hex digest of a handle
+func parseBlobHandle(handle string) (string, error) {
+	digest := strings.TrimPrefix(handle, BlobHandlePrefix)
+	if digest == handle || len(digest) != 64 {
+		return "", fmt.Errorf("%w %q", errInvalidBlobHandle, handle)
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", fmt.Errorf("%w %q", errInvalidBlobHandle, handle)
+	}
+	return strings.ToLower(digest), nil
+}
+
+// BlobStoreConfig selects and configures a blob store backend
+type BlobStoreConfig struct {
+	Backend string `json:"backend"` // "file", "s3" or "postgres"
+
+	// file
+	Dir string `json:"dir,omitempty"`
+
+	// s3; credentials come from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY
+	// and AWS_SESSION_TOKEN
+	Endpoint       string          `json:"endpoint,omitempty"` // e.g. "https://s3.eu-west-1.amazonaws.com"
+	Region         string          `json:"region,omitempty"`
+	Bucket         string          `json:"bucket,omitempty"`
+	Prefix         string          `json:"prefix,omitempty"`    // Key prefix, e.g. "github.com/Redeaux-Corporation/eamsa512/"
+	SpoolDir       string          `json:"spool_dir,omitempty"` // Uploads are spooled here to hash them first
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"`
+	TLS            tlstrust.Config `json:"tls"`
+
+	// postgres; e.g. "postgres://eamsa512@db:5432/eamsa512?sslmode=verify-full",
+	// with the password in PGPASSWORD. TimeoutSeconds bounds table creation.
+	DSN string `json:"dsn,omitempty"`
+}
+
+// LoadBlobStore reads a JSON blob store configuration and opens the store
+func LoadBlobStore(path string) (BlobStore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob store config: %v", err)
+	}
+
+	var config BlobStoreConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse blob store config: %v", err)
+	}
+
+	return OpenBlobStore(config)
+}
+
+// OpenBlobStore opens the configured backend
+func OpenBlobStore(config BlobStoreConfig) (BlobStore, error) {
+	switch config.Backend {
+	case "file":
+		return NewFileBlobStore(config.Dir)
+	case "s3":
+		return NewS3BlobStore(config)
+	case "postgres":
+		return NewPostgresBlobStore(config)
+	}
+	return nil, fmt.Errorf("unknown blob store backend %q (want \"file\", \"s3\" or \"postgres\")", config.Backend)
+}
+
+// hashingReader hashes everything read through it
+type hashingReader struct {
+	r io.Reader
+	h hash.Hash
+	n int64
+}
+
+func (hr *hashingReader) Read(p []byte) (int, error) {
+	n, err := hr.r.Read(p)
+	hr.h.Write(p[:n])
+	hr.n += int64(n)
+	return n, err
+}
+
+// verifyingReader checks the content of a blob against its digest at EOF
+type verifyingReader struct {
+	rc     io.ReadCloser
+	h      hash.Hash
+	digest string
+}
+
+func newVerifyingReader(rc io.ReadCloser, digest string) *verifyingReader {
+	return &verifyingReader{rc: rc, h: sha3.New256(), digest: digest}
+}
+
+func (vr *verifyingReader) Read(p []byte) (int, error) {
+	n, err := vr.rc.Read(p)
+	vr.h.Write(p[:n])
+	if err == io.EOF && hex.EncodeToString(vr.h.Sum(nil)) != vr.digest {
+		return n, ErrBlobCorrupt
+	}
+	return n, err
+}
+
+func (vr *verifyingReader) Close() error {
+	return vr.rc.Close()
+}
+
+// ============================================================================
+// Filesystem Backend
+// ============================================================================
+
+// FileBlobStore keeps blobs in a directory as <dir>/<ab>/<cd>/<digest>
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore opens (creating if needed) a directory blob store
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file blob store needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %v", err)
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+func (s *FileBlobStore) path(digest string) string {
+	return filepath.Join(s.dir, digest[:2], digest[2:4], digest)
+}
+
+// Put writes the content to a temporary file while hashing it, then
+// renames it into place
+func (s *FileBlobStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	tmp, err := os.CreateTemp(s.dir, ".put-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create blob: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hr := &hashingReader{r: r, h: sha3.New256()}
+	if _, err := io.Copy(tmp, hr); err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", 0, fmt.Errorf("failed to write blob: %v", err)
+	}
+
+	digest := hex.EncodeToString(hr.h.Sum(nil))
+	handle := BlobHandlePrefix + digest
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	path := s.path(digest)
+	if _, err := os.Stat(path); err == nil {
+		return handle, hr.n, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", 0, fmt.Errorf("failed to create blob directory: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", 0, fmt.Errorf("failed to store blob: %v", err)
+	}
+
+	return handle, hr.n, nil
+}
+
+// Get opens a blob
+func (s *FileBlobStore) Get(ctx context.Context, handle string) (io.ReadCloser, error) {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(s.path(digest))
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to open blob: %v", err)
+	}
+	return newVerifyingReader(f, digest), nil
+}
+
+// Delete removes a blob
+func (s *FileBlobStore) Delete(ctx context.Context, handle string) error {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(digest)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete blob: %v", err)
+	}
+	return nil
+}
+
+// List walks the blob directory
+func (s *FileBlobStore) List(ctx context.Context, fn func(handle string) error) error {
+	return filepath.WalkDir(s.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, err := parseBlobHandle(BlobHandlePrefix + d.Name()); err != nil {
+			return nil // Temporary or foreign file
+		}
+		return fn(BlobHandlePrefix + d.Name())
+	})
+}
+
+// ============================================================================
+// S3 Backend
+// ============================================================================
+
+// emptySHA256 is the SHA-256 of an empty payload
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3BlobStore keeps blobs in an S3-compatible bucket as <prefix><digest>
+type S3BlobStore struct {
+	endpoint     *url.URL
+	region       string
+	bucket       string
+	prefix       string
+	spoolDir     string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+	now          func() time.Time
+}
+
+// NewS3BlobStore creates an S3 blob store from config and the AWS_*
+// credential environment variables
+func NewS3BlobStore(config BlobStoreConfig) (*S3BlobStore, error) {
+	if config.Bucket == "" || config.Region == "" {
+		return nil, fmt.Errorf("s3 blob store needs a bucket and a region")
+	}
+
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %q", endpoint)
+	}
+
+	accessKey := os.Getenv("AWS_ACCESS_KEY_ID")
+	secretKey := os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("s3 blob store needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+
+	timeout := 60 * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+
+	// Peer verification; failures are audited as TLS_VERIFY_FAILED
+	verifier, err := tlstrust.New("blob-store", config.TLS, func(f tlstrust.Failure) {
+		LogAuditEvent("TLS_VERIFY_FAILED", map[string]interface{}{
+			"integration": f.Integration,
+			"server_name": f.ServerName,
+			"reason":      f.Reason,
+			"detail":      f.Detail,
+			"spki_pin":    f.SPKIPin,
+			"accepted":    f.Accepted,
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid blob store TLS configuration: %v", err)
+	}
+
+	return &S3BlobStore{
+		endpoint:     u,
+		region:       config.Region,
+		bucket:       config.Bucket,
+		prefix:       config.Prefix,
+		spoolDir:     config.SpoolDir,
+		accessKey:    accessKey,
+		secretKey:    secretKey,
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       verifier.HTTPClient(timeout),
+		now:          time.Now,
+	}, nil
+}
+
+// objectURL returns the path-style URL of a key
+func (s *S3BlobStore) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	u.Path = "/" + s.bucket + "/" + key
+	u.RawQuery = query.Encode()
+	return &u
+}
+
+// Put spools the content to hash it, then uploads it unless the bucket
+// already has it
+func (s *S3BlobStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	spool, err := os.CreateTemp(s.spoolDir, "eamsa512-blob-*")
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %v", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	payloadHash := sha256.New()
+	hr := &hashingReader{r: r, h: sha3.New256()}
+	if _, err := io.Copy(io.MultiWriter(spool, payloadHash), hr); err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %v", err)
+	}
+
+	digest := hex.EncodeToString(hr.h.Sum(nil))
+	handle := BlobHandlePrefix + digest
+	key := s.prefix + digest
+
+	exists, err := s.exists(ctx, key)
+	if err != nil {
+		return "", 0, err
+	}
+	if exists {
+		return handle, hr.n, nil
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", 0, fmt.Errorf("failed to spool blob: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key, nil).String(), io.NopCloser(spool))
+	if err != nil {
+		return "", 0, err
+	}
+	req.ContentLength = hr.n
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := s.do(req, hex.EncodeToString(payloadHash.Sum(nil)))
+	if err != nil {
+		return "", 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("s3 put %s: %s", key, resp.Status)
+	}
+
+	return handle, hr.n, nil
+}
+
+// exists checks for a key with HEAD
+func (s *S3BlobStore) exists(ctx context.Context, key string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key, nil).String(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.do(req, emptySHA256)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	}
+	return false, fmt.Errorf("s3 head %s: %s", key, resp.Status)
+}
+
+// Get opens a blob
+func (s *S3BlobStore) Get(ctx context.Context, handle string) (io.ReadCloser, error) {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(s.prefix+digest, nil).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(req, emptySHA256)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return newVerifyingReader(resp.Body, digest), nil
+	case http.StatusNotFound:
+		resp.Body.Close()
+		return nil, ErrBlobNotFound
+	}
+	resp.Body.Close()
+	return nil, fmt.Errorf("s3 get %s: %s", s.prefix+digest, resp.Status)
+}
+
+// Delete removes a blob
+func (s *S3BlobStore) Delete(ctx context.Context, handle string) error {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(s.prefix+digest, nil).String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(req, emptySHA256)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("s3 delete %s: %s", s.prefix+digest, resp.Status)
+	}
+	return nil
+}
+
+// s3ListResult is the part of a ListObjectsV2 response used by List
+type s3ListResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// List pages through the bucket prefix with ListObjectsV2
+func (s *S3BlobStore) List(ctx context.Context, fn func(handle string) error) error {
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+
+		u := s.objectURL("", query)
+		u.Path = "/" + s.bucket
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return err
+		}
+		resp, err := s.do(req, emptySHA256)
+		if err != nil {
+			return err
+		}
+
+		var result s3ListResult
+		err = xml.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&result)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("s3 list %s: %s", s.bucket, resp.Status)
+		}
+		if err != nil {
+			return fmt.Errorf("s3 list %s: %v", s.bucket, err)
+		}
+
+		for _, object := range result.Contents {
+			handle := BlobHandlePrefix + strings.TrimPrefix(object.Key, s.prefix)
+			if _, err := parseBlobHandle(handle); err != nil {
+				continue // Not a blob
+			}
+			if err := fn(handle); err != nil {
+				return err
+			}
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// do signs and sends a request
+func (s *S3BlobStore) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.sessionToken)
+	}
+	signS3Request(req, s.accessKey, s.secretKey, s.region, payloadHash, s.now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("s3 %s: %v", strings.ToLower(req.Method), err)
+	}
+	return resp, nil
+}
+
+// signS3Request adds an AWS Signature Version 4 Authorization header for
+// the s3 service. Host, Range, Content-Type and x-amz-* headers are signed.
+func signS3Request(req *http.Request, accessKey, secretKey, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "range" || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	signingKey := hmacSHA256([]byte("AWS4"+secretKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything except RFC 3986 unreserved characters
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3EscapePath escapes each segment of a path (S3 does not double-encode)
+func s3EscapePath(path string) string {
+	if path == "" {
+		return "/"
+	}
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery sorts and escapes query parameters
+func s3CanonicalQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// ============================================================================
+// Postgres Backend
+// ============================================================================
+
+// postgresBlobSchema creates the blob table on first use
+const postgresBlobSchema = `CREATE TABLE IF NOT EXISTS eamsa512_blobs (
+	digest     TEXT PRIMARY KEY,
+	content    BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`
+
+// PostgresBlobStore keeps blobs as rows of the eamsa512_blobs table.
+// Each blob is read into memory, so blobs are limited to
+// MaxInlineBlobSize; use the file or s3 backend for larger ciphertexts.
+type PostgresBlobStore struct {
+	db *sql.DB
+}
+
+// NewPostgresBlobStore connects to config.DSN and creates the blob table
+// if needed. The password may come from PGPASSWORD instead of the DSN.
+func NewPostgresBlobStore(config BlobStoreConfig) (*PostgresBlobStore, error) {
+	if config.DSN == "" {
+		return nil, fmt.Errorf("postgres blob store needs a dsn")
+	}
+
+	db, err := sql.Open("postgres", config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open postgres blob store: %v", err)
+	}
+
+	timeout := 60 * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if _, err := db.ExecContext(ctx, postgresBlobSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create postgres blob table: %v", err)
+	}
+	return &PostgresBlobStore{db: db}, nil
+}
+
+// Put hashes the content and inserts it unless the digest is present
+func (s *PostgresBlobStore) Put(ctx context.Context, r io.Reader) (string, int64, error) {
+	content, err := io.ReadAll(io.LimitReader(r, MaxInlineBlobSize+1))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read blob: %v", err)
+	}
+	if len(content) > MaxInlineBlobSize {
+		return "", 0, fmt.Errorf("blob exceeds the postgres backend limit of %d bytes", MaxInlineBlobSize)
+	}
+
+	handle := BlobHandle(content)
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO eamsa512_blobs (digest, content) VALUES ($1, $2) ON CONFLICT (digest) DO NOTHING`,
+		strings.TrimPrefix(handle, BlobHandlePrefix), content)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to store blob: %v", err)
+	}
+	return handle, int64(len(content)), nil
+}
+
+// Get reads a blob row
+func (s *PostgresBlobStore) Get(ctx context.Context, handle string) (io.ReadCloser, error) {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return nil, err
+	}
+
+	var content []byte
+	err = s.db.QueryRowContext(ctx,
+		`SELECT content FROM eamsa512_blobs WHERE digest = $1`, digest).Scan(&content)
+	if err == sql.ErrNoRows {
+		return nil, ErrBlobNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %v", err)
+	}
+	return newVerifyingReader(io.NopCloser(bytes.NewReader(content)), digest), nil
+}
+
+// Delete removes a blob row
+func (s *PostgresBlobStore) Delete(ctx context.Context, handle string) error {
+	digest, err := parseBlobHandle(handle)
+	if err != nil {
+		return err
+	}
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM eamsa512_blobs WHERE digest = $1`, digest); err != nil {
+		return fmt.Errorf("failed to delete blob: %v", err)
+	}
+	return nil
+}
+
+// List walks the blob table in digest order
+func (s *PostgresBlobStore) List(ctx context.Context, fn func(handle string) error) error {
+	rows, err := s.db.QueryContext(ctx, `SELECT digest FROM eamsa512_blobs ORDER BY digest`)
+	if err != nil {
+		return fmt.Errorf("failed to list blobs: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var digest string
+		if err := rows.Scan(&digest); err != nil {
+			return fmt.Errorf("failed to list blobs: %v", err)
+		}
+		if err := fn(BlobHandlePrefix + digest); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// ============================================================================
+// Server Handlers
+// ============================================================================
+
+// MaxInlineBlobSize bounds blobs decrypted by POST /api/v1/decrypt
+const MaxInlineBlobSize = 64 << 20
+
+var errBlobTooLarge = errors.New("blob too large for inline decryption")
+
+// ReadBlob reads a whole blob of at most limit bytes
+func ReadBlob(ctx context.Context, store BlobStore, handle string, limit int64) ([]byte, error) {
+	rc, err := store.Get(ctx, handle)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errBlobTooLarge
+	}
+	return data, nil
+}
+
+// respondBlobError maps blob store errors to responses
+func respondBlobError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, ErrBlobNotFound):
+		respondError(w, r, "not_found", "No blob with this handle")
+	case errors.Is(err, errBlobTooLarge):
+		respondError(w, r, "too_large",
+			fmt.Sprintf("Blob exceeds %d bytes; download it and decrypt locally", MaxInlineBlobSize))
+	case errors.Is(err, ErrBlobCorrupt):
+		LogError("Blob store returned corrupt data", err)
+		respondError(w, r, "store_corrupt", "Stored blob is corrupt")
+	case errors.Is(err, errInvalidBlobHandle):
+		respondError(w, r, "bad_request", err.Error())
+	default:
+		LogError("Blob store read failed", err)
+		respondError(w, r, "store_failed", "Failed to read from the blob store")
+	}
+}
+
+// HandleBlob handles GET and DELETE /api/v1/blobs/{handle}. GET returns
+// the sealed bytes (ciphertext || nonce || tag) for clients that decrypt
+// locally.
+func HandleBlob(w http.ResponseWriter, r *http.Request) {
+	if blobStore == nil {
+		respondError(w, r, "not_found", "No blob store is configured")
+		return
+	}
+
+	handle := strings.TrimPrefix(r.URL.Path, "/api/v1/blobs/")
+	if _, err := parseBlobHandle(handle); err != nil {
+		respondError(w, r, "bad_request", err.Error())
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		rc, err := blobStore.Get(r.Context(), handle)
+		if err != nil {
+			respondBlobError(w, r, err)
+			return
+		}
+		defer rc.Close()
+
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+		if _, err := io.Copy(w, rc); err != nil {
+			// Too late for an error response; the client sees a short body
+			LogError("Blob download failed", err)
+		}
+
+	case http.MethodDelete:
+		if err := blobStore.Delete(r.Context(), handle); err != nil {
+			LogError("Blob delete failed", err)
+			respondError(w, r, "store_failed", "Failed to delete the blob")
+			return
+		}
+		LogAuditEvent("BLOB_DELETED", map[string]interface{}{
+			"handle":    handle,
+			"source":    r.RemoteAddr,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		respondError(w, r, "method_not_allowed", "Only GET and DELETE are allowed")
+	}
+}
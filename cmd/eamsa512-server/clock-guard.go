@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+	"github.com/Redeaux-Corporation/eamsa512/sntp"
+)
+
+// ============================================================================
+// EAMSA 512 - Clock Guard
+// Time source hardening for key expiry, rotation and session decisions
+//
+// Key expiry, encryption windows, rotation intervals and session validity
+// all compare against the local clock, so a clock set back (by mistake or
+// by an attacker) would extend key lifetimes. The clock guard keeps the
+// wall clock honest against three references:
+//
+// - The monotonic clock: the wall reading at start plus monotonic elapsed
+//   time. A wall clock that disagrees by more than the jump threshold is
+//   audited as CLOCK_JUMP, and the trusted time never moves backwards.
+// - Persisted records: a restored key created "in the future" raises the
+//   floor of the trusted time and is audited as CLOCK_BEHIND_RECORDS.
+// - Optional SNTP servers: an offset beyond the allowed skew is audited as
+//   CLOCK_SKEW, and a clock that is behind is corrected forward.
+//
+// Every correction moves the trusted time forward only, so a bad clock
+// can make keys expire early but never late.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// DefaultClockJumpThreshold is the wall/monotonic disagreement reported
+	// as a jump
+	DefaultClockJumpThreshold = time.Minute
+
+	// DefaultClockMaxSkew is the NTP offset reported as skew
+	DefaultClockMaxSkew = 30 * time.Second
+
+	// DefaultNTPInterval is the time between NTP sanity checks
+	DefaultNTPInterval = time.Hour
+
+	// DefaultNTPTimeout bounds one SNTP query
+	DefaultNTPTimeout = 5 * time.Second
+
+	// clockCheckInterval is how often Start looks for wall clock jumps
+	clockCheckInterval = time.Minute
+)
+
+// ClockGuardConfig selects the clock checks
+type ClockGuardConfig struct {
+	JumpThreshold time.Duration // Wall/monotonic disagreement that is audited
+	NTPServers    []string      // SNTP servers for sanity checks; empty disables them
+	NTPInterval   time.Duration // Time between NTP checks
+	NTPTimeout    time.Duration // Timeout of one SNTP query
+	MaxSkew       time.Duration // NTP offset that is audited and corrected
+
+	// Audit receives clock warnings; nil writes them to the standard logger
+	Audit func(event string, details map[string]interface{})
+}
+
+// ClockGuard provides the trusted time for expiry and rotation decisions
+type ClockGuard struct {
+	config ClockGuardConfig
+
+	mu         sync.Mutex
+	anchor     time.Time     // Wall and monotonic reading at start
+	floor      time.Time     // Latest time vouched for by persisted records
+	jump       time.Duration // Last audited wall minus monotonic-derived time
+	ntpOffset  time.Duration // Server minus local time at the last NTP check
+	ntpChecked time.Time
+
+	stopCh chan struct{}
+}
+
+// ClockStatus reports the clock guard's view of the local clock
+type ClockStatus struct {
+	Trusted    time.Time     `json:"trusted"`
+	Wall       time.Time     `json:"wall"`
+	Jump       time.Duration `json:"jump_ns"`
+	NTPOffset  time.Duration `json:"ntp_offset_ns"`
+	NTPChecked time.Time     `json:"ntp_checked,omitempty"`
+}
+
+// trustedClock is the clock behind key expiry, rotation and session checks
+var trustedClock = NewClockGuard(ClockGuardConfig{})
+
+// NewClockGuard creates a clock guard anchored at the current time
+func NewClockGuard(config ClockGuardConfig) *ClockGuard {
+	if config.JumpThreshold <= 0 {
+		config.JumpThreshold = DefaultClockJumpThreshold
+	}
+	if config.NTPInterval <= 0 {
+		config.NTPInterval = DefaultNTPInterval
+	}
+	if config.NTPTimeout <= 0 {
+		config.NTPTimeout = DefaultNTPTimeout
+	}
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = DefaultClockMaxSkew
+	}
+
+	return &ClockGuard{
+		config: config,
+		anchor: failpoint.Now(),
+	}
+}
+
+// LoadClockGuardFromEnv configures a clock guard from EAMSA_NTP_SERVERS
+// (comma-separated host or host:port) and EAMSA_CLOCK_MAX_SKEW
+func LoadClockGuardFromEnv(audit func(string, map[string]interface{})) (*ClockGuard, error) {
+	config := ClockGuardConfig{Audit: audit}
+
+	for _, server := range strings.Split(os.Getenv("EAMSA_NTP_SERVERS"), ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			config.NTPServers = append(config.NTPServers, server)
+		}
+	}
+
+	if value := os.Getenv("EAMSA_CLOCK_MAX_SKEW"); value != "" {
+		skew, err := time.ParseDuration(value)
+		if err != nil || skew <= 0 {
+			return nil, fmt.Errorf("invalid EAMSA_CLOCK_MAX_SKEW %q", value)
+		}
+		config.MaxSkew = skew
+	}
+
+	return NewClockGuard(config), nil
+}
+
+// Now returns the trusted time: the latest of the wall clock, the
+// monotonic-derived time, the NTP-corrected time and the record floor
+func (g *ClockGuard) Now() time.Time {
+	now := failpoint.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.trusted(now)
+}
+
+// Since returns the time elapsed since t. Times taken in this process
+// carry a monotonic reading and are measured on the monotonic clock;
+// persisted times are measured against the trusted time.
+func (g *ClockGuard) Since(t time.Time) time.Duration {
+	if t != t.Round(0) {
+		return failpoint.Now().Sub(t)
+	}
+	return g.Now().Sub(t)
+}
+
+// Observe raises the trusted time to at least t, for times vouched for by
+// persisted records such as key creation
+func (g *ClockGuard) Observe(t time.Time, source string) {
+	now := failpoint.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t = t.Round(0)
+	if !t.After(g.floor) {
+		return
+	}
+	g.floor = t
+
+	if behind := t.Sub(now.Round(0)); behind > g.config.JumpThreshold {
+		g.audit("CLOCK_BEHIND_RECORDS", map[string]interface{}{
+			"source": source,
+			"record": t.UTC().Format(time.RFC3339),
+			"wall":   now.UTC().Format(time.RFC3339),
+			"behind": behind.String(),
+		})
+	}
+}
+
+// Status returns the current clock readings and corrections
+func (g *ClockGuard) Status() ClockStatus {
+	now := failpoint.Now()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return ClockStatus{
+		Trusted:    g.trusted(now),
+		Wall:       now.Round(0),
+		Jump:       g.jump,
+		NTPOffset:  g.ntpOffset,
+		NTPChecked: g.ntpChecked,
+	}
+}
+
+// trusted computes the trusted time for now; g.mu must be held
+func (g *ClockGuard) trusted(now time.Time) time.Time {
+	wall := now.Round(0)
+	monotonic := g.anchor.Round(0).Add(now.Sub(g.anchor))
+
+	// Audit each jump once: only changes beyond the threshold are new
+	jump := wall.Sub(monotonic)
+	if change := jump - g.jump; change >= g.config.JumpThreshold || change <= -g.config.JumpThreshold {
+		direction := "forward"
+		if change < 0 {
+			direction = "backward"
+		}
+		g.audit("CLOCK_JUMP", map[string]interface{}{
+			"direction": direction,
+			"jump":      change.String(),
+			"wall":      wall.UTC().Format(time.RFC3339),
+			"monotonic": monotonic.UTC().Format(time.RFC3339),
+		})
+		g.jump = jump
+	}
+
+	trusted := wall
+	if monotonic.After(trusted) {
+		trusted = monotonic
+	}
+	if g.ntpOffset > g.config.MaxSkew {
+		if corrected := wall.Add(g.ntpOffset); corrected.After(trusted) {
+			trusted = corrected
+		}
+	}
+	if g.floor.After(trusted) {
+		trusted = g.floor
+	}
+
+	return trusted
+}
+
+// CheckNTP queries the configured servers and records the median offset
+// (server minus local time). Offsets beyond MaxSkew are audited.
+func (g *ClockGuard) CheckNTP() (time.Duration, error) {
+	if len(g.config.NTPServers) == 0 {
+		return 0, fmt.Errorf("no NTP servers configured")
+	}
+
+	var offsets []time.Duration
+	var lastErr error
+	for _, server := range g.config.NTPServers {
+		offset, err := sntp.Query(server, g.config.NTPTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		offsets = append(offsets, offset)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(offsets) == 0 {
+		g.audit("CLOCK_NTP_UNAVAILABLE", map[string]interface{}{
+			"servers": len(g.config.NTPServers),
+			"error":   lastErr.Error(),
+		})
+		return 0, fmt.Errorf("no NTP server answered: %v", lastErr)
+	}
+
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	offset := offsets[len(offsets)/2]
+
+	g.ntpOffset = offset
+	g.ntpChecked = time.Now()
+
+	if offset > g.config.MaxSkew || offset < -g.config.MaxSkew {
+		direction := "ahead"
+		if offset > 0 {
+			direction = "behind"
+		}
+		g.audit("CLOCK_SKEW", map[string]interface{}{
+			"direction": direction,
+			"offset":    offset.String(),
+			"max_skew":  g.config.MaxSkew.String(),
+			"answered":  len(offsets),
+		})
+	}
+
+	return offset, nil
+}
+
+// Start runs jump detection and, with servers configured, NTP checks in
+// the background until Stop
+func (g *ClockGuard) Start() {
+	g.mu.Lock()
+	if g.stopCh != nil {
+		g.mu.Unlock()
+		return
+	}
+	g.stopCh = make(chan struct{})
+	stopCh := g.stopCh
+	g.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(clockCheckInterval)
+		defer ticker.Stop()
+
+		var lastNTP time.Time
+		for {
+			if len(g.config.NTPServers) > 0 && (lastNTP.IsZero() || time.Since(lastNTP) >= g.config.NTPInterval) {
+				g.CheckNTP()
+				lastNTP = time.Now()
+			}
+
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				g.Now()
+			}
+		}
+	}()
+}
+
+// Stop ends the background checks
+func (g *ClockGuard) Stop() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.stopCh != nil {
+		close(g.stopCh)
+		g.stopCh = nil
+	}
+}
+
+// audit reports a clock warning
+func (g *ClockGuard) audit(event string, details map[string]interface{}) {
+	if g.config.Audit != nil {
+		g.config.Audit(event, details)
+		return
+	}
+	log.Printf("%s %v", event, details)
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+CONFIGURATION:
+
+- EAMSA_NTP_SERVERS: comma-separated SNTP servers (host or host:port),
+  checked at start and hourly; unset disables NTP checks
+- EAMSA_CLOCK_MAX_SKEW: NTP offset that is audited and corrected
+  (default 30s)
+
+WHAT USES THE TRUSTED TIME:
+
+- KeyManager: key expiry, encryption windows (NotAfter), scheduled
+  activation and the rotation scheduler's age checks
+- Minimum key age before rotation: measured on the monotonic clock for
+  keys rotated in this process
+- Database.ValidateSession: session expiry
+
+AUDIT EVENTS:
+
+- CLOCK_JUMP: the wall clock moved against the monotonic clock by more
+  than a minute (NTP step, manual change, VM resume)
+- CLOCK_BEHIND_RECORDS: a restored key was created after the current wall
+  time
+- CLOCK_SKEW: the median NTP offset exceeds EAMSA_CLOCK_MAX_SKEW
+- CLOCK_NTP_UNAVAILABLE: no configured NTP server answered
+
+LIMITS:
+
+- SNTP is unauthenticated; use servers on a trusted network. A spoofed
+  reply can only make keys expire early, never late
+- Across restarts, only persisted records and NTP bound a clock that was
+  set back while the process was down
+*/
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Container Compatibility Test Suite
+// Containers written by every format version must keep decrypting
+//
+// testdata/compat holds a fixture for each container version and variant,
+// encrypted from plaintext.txt under key.hex while that version was
+// current. Both files are copies of the corpus in
+// pkg/eamsa512/testdata/compat, whose test suite states the rules: a
+// fixture is never regenerated to make a failing change pass, and a change
+// to the bytes of a format gets a new version and
+//
+//   go test -run TestContainerCompatibility -update-fixtures
+//
+// which writes fixtures only for versions that have none. The fixtures are
+// also the seed corpus of FuzzOpenContainer.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+var updateFixtures = flag.Bool("update-fixtures", false, "write missing container compatibility fixtures")
+
+// compatDir holds the fixtures, key.hex and plaintext.txt
+const compatDir = "testdata/compat"
+
+// compatWeakKey is the low-entropy master key of the stretched-key
+// fixtures, which SealContainer stretches with compatKDFParams
+var compatWeakKey = []byte("password-password-password-12345")
+
+// compatKDFParams is the Argon2id cost of the stretched-key fixtures,
+// kept low so the suite stays fast
+var compatKDFParams = eamsa512.KDFParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// compatOpeners decrypt each format, keyed by the fixture name prefix
+var compatOpeners = map[string]func(data, key []byte) ([]byte, error){
+	"container": func(data, key []byte) ([]byte, error) {
+		plaintext, _, err := OpenContainer(data, key)
+		return plaintext, err
+	},
+	"stretched": func(data, key []byte) ([]byte, error) {
+		plaintext, _, err := OpenContainer(data, compatWeakKey)
+		return plaintext, err
+	},
+}
+
+// compatGenerators write the current version of each format, keyed by
+// fixture name (<format>-v<version>[-<variant>])
+var compatGenerators = map[string]func(plaintext, key []byte) ([]byte, error){
+	"container-v1": func(plaintext, key []byte) ([]byte, error) {
+		return SealContainer(plaintext, key, ContainerOptions{KeyVersion: 3})
+	},
+	"container-v1-encrypted-header": func(plaintext, key []byte) ([]byte, error) {
+		return SealContainer(plaintext, key, ContainerOptions{KeyVersion: 3, EncryptHeader: true, IncludeDigest: true})
+	},
+	"container-v1-iso7816": func(plaintext, key []byte) ([]byte, error) {
+		return SealContainer(plaintext, key, ContainerOptions{KeyVersion: 3, Padding: eamsa512.PaddingISO7816})
+	},
+	"container-v1-zero-length": func(plaintext, key []byte) ([]byte, error) {
+		return SealContainer(plaintext, key, ContainerOptions{KeyVersion: 3, Padding: eamsa512.PaddingZeroLength})
+	},
+	"container-v1-file-key": func(plaintext, key []byte) ([]byte, error) {
+		return SealContainer(plaintext, key, ContainerOptions{KeyVersion: 3, PerFileKey: true, IncludeDigest: true})
+	},
+	"stretched-v1": func(plaintext, key []byte) ([]byte, error) {
+		out, err := SealContainer(plaintext, compatWeakKey, ContainerOptions{
+			KeyVersion: 3, StretchLowEntropyKey: true, StretchParams: compatKDFParams,
+		})
+		if err == nil && out[6]&FlagStretchedKey == 0 {
+			err = fmt.Errorf("weak key was not stretched")
+		}
+		return out, err
+	},
+}
+
+// compatFormat returns the format of a fixture name
+func compatFormat(name string) string {
+	format, _, _ := strings.Cut(name, "-v")
+	return format
+}
+
+// compatFixturePath returns the fixture file of a fixture name
+func compatFixturePath(name string) string {
+	return filepath.Join(compatDir, name+".bin")
+}
+
+// loadCompatInputs reads the corpus key and plaintext
+func loadCompatInputs(tb testing.TB) ([]byte, []byte) {
+	tb.Helper()
+
+	keyHex, err := os.ReadFile(filepath.Join(compatDir, "key.hex"))
+	if err != nil {
+		tb.Fatalf("Failed to read corpus key: %v", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil || len(key) != KeySize {
+		tb.Fatalf("Invalid corpus key: %v", err)
+	}
+
+	plaintext, err := os.ReadFile(filepath.Join(compatDir, "plaintext.txt"))
+	if err != nil {
+		tb.Fatalf("Failed to read corpus plaintext: %v", err)
+	}
+
+	return key, plaintext
+}
+
+// compatFixtures returns the fixture names in the corpus, sorted
+func compatFixtures(tb testing.TB) []string {
+	tb.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(compatDir, "*.bin"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".bin"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCompatFixtures writes a fixture for each generator that has none
+func writeCompatFixtures(t *testing.T) {
+	key, plaintext := loadCompatInputs(t)
+	for name, generate := range compatGenerators {
+		path := compatFixturePath(name)
+		if _, err := os.Stat(path); err == nil {
+			continue // Never regenerate: the fixture records that version
+		}
+
+		data, err := generate(plaintext, key)
+		if err != nil {
+			t.Fatalf("Failed to generate %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Printf("  wrote %s (%d bytes)\n", path, len(data))
+	}
+}
+
+// TestContainerCompatibility tests that every container fixture still
+// decrypts to the corpus plaintext and that every current container
+// version has a fixture
+func TestContainerCompatibility(t *testing.T) {
+	fmt.Println("Test: Container Compatibility")
+
+	if *updateFixtures {
+		writeCompatFixtures(t)
+	}
+
+	key, plaintext := loadCompatInputs(t)
+
+	for name := range compatGenerators {
+		if _, err := os.Stat(compatFixturePath(name)); err != nil {
+			t.Errorf("No fixture for current format %s: run with -update-fixtures", name)
+		}
+	}
+
+	fixtures := compatFixtures(t)
+	for _, name := range fixtures {
+		open, ok := compatOpeners[compatFormat(name)]
+		if !ok {
+			t.Errorf("Fixture %s: no opener for format %q", name, compatFormat(name))
+			continue
+		}
+
+		data, err := os.ReadFile(compatFixturePath(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := open(data, key)
+		if err != nil {
+			t.Errorf("Fixture %s no longer decrypts: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Fixture %s decrypts to different plaintext", name)
+		}
+	}
+
+	if !t.Failed() {
+		fmt.Printf("✓ %d container fixtures decrypt\n", len(fixtures))
+	}
+}
+
+// FuzzOpenContainer feeds mutated fixtures to every container opener.
+// None may panic, and any input that decrypts must yield the corpus
+// plaintext.
+func FuzzOpenContainer(f *testing.F) {
+	key, plaintext := loadCompatInputs(f)
+
+	for _, name := range compatFixtures(f) {
+		data, err := os.ReadFile(compatFixturePath(name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for format, open := range compatOpeners {
+			decrypted, err := open(data, key)
+			if err == nil && !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("%s: mutated input decrypted to new plaintext", format)
+			}
+		}
+	})
+}
@@ -0,0 +1,687 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/format"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Container Format
+// Self-describing container around EncryptData output
+//
+// Layout (version 1, see the format package and docs/container-format.md):
+//   magic "EAMS" (4) | format version (1) | cipher suite (1) | flags (1) |
+//   reserved (1) | metadata length N (2, big-endian) | metadata (N) |
+//   header tag (64) | body (ciphertext || nonce || tag) | [footer]
+//
+// Framing is done by the format package; this file adds the keys,
+// encryption and tags.
+//
+// Metadata holds the sensitive header fields (key version, mode) as
+// type-length-value records. With FlagHeaderEncrypted the metadata is
+// encrypted under a header key derived from the master key, so only
+// magic/version/cipher-suite remain visible. The header tag is
+// HMAC-SHA3-512 over everything before it. With FlagDigestFooter the body
+// is followed by an encrypted footer holding the SHA3-256 digest of the
+// plaintext.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// ContainerMagic identifies EAMSA 512 containers
+	ContainerMagic = format.Magic
+
+	// ContainerFormatVersion is the current container format version
+	ContainerFormatVersion = format.Version
+
+	// CipherSuiteEAMSA512 is EAMSA 512 CBC with HMAC-SHA3-512 and PKCS#7
+	// body padding
+	CipherSuiteEAMSA512 = format.SuiteEAMSA512
+
+	// CipherSuiteEAMSA512ISO7816 pads the body per ISO/IEC 7816-4
+	CipherSuiteEAMSA512ISO7816 = format.SuiteEAMSA512ISO7816
+
+	// CipherSuiteEAMSA512ZeroLength pads the body with zeros and a length
+	// byte (ANSI X9.23)
+	CipherSuiteEAMSA512ZeroLength = format.SuiteEAMSA512ZeroLength
+
+	// FlagHeaderEncrypted marks encrypted metadata
+	FlagHeaderEncrypted = format.FlagHeaderEncrypted
+
+	// FlagDigestFooter marks a trailing encrypted plaintext digest
+	FlagDigestFooter = format.FlagDigestFooter
+
+	// FlagFileKey marks a body under a per-file key
+	FlagFileKey = format.FlagFileKey
+
+	// FlagStretchedKey marks keys derived from a stretched master key
+	FlagStretchedKey = format.FlagStretchedKey
+
+	// PlaintextDigestSize is the size of the SHA3-256 plaintext digest
+	PlaintextDigestSize = format.PlaintextDigestSize
+
+	// containerFooterSize is EncryptData(digest || body tag): 96 bytes
+	// padded to 128, plus nonce and tag
+	containerFooterSize = format.FooterSize
+
+	// Labels for header key derivation
+	headerEncryptionLabel = format.HeaderEncryptionLabel
+	headerMACLabel        = format.HeaderMACLabel
+	footerEncryptionLabel = format.FooterEncryptionLabel
+	fileKeyLabel          = format.FileKeyLabel
+)
+
+// ContainerOptions controls container creation
+type ContainerOptions struct {
+	KeyVersion    int    // Key version recorded in the header (0 = not recorded)
+	Mode          string // Cipher mode recorded in the header (default "CBC")
+	EncryptHeader bool   // Encrypt key version and mode under the header key
+	IncludeDigest bool   // Append an encrypted SHA3-256 digest of the plaintext
+	MACKey        MACKey // Split-trust MAC key for all tags (nil: derived from the master key)
+	PerFileKey    bool   // Encrypt the body under a key derived from the master key and its nonce
+
+	// Padding of the body plaintext, recorded as the cipher suite
+	// (zero: PKCS#7)
+	Padding eamsa512.Padding
+
+	// Stretch a master key that looks low-entropy (eamsa512.LowEntropyKey)
+	// through Argon2id before deriving any container key; the parameters
+	// and salt are recorded in the header. Low-entropy keys are audited
+	// either way.
+	StretchLowEntropyKey bool
+	StretchParams        eamsa512.KDFParams // Zero: eamsa512.DefaultKDFParams
+}
+
+// containerMaxStretchMemory bounds the Argon2id memory (KiB) a container
+// header can ask OpenContainer for
+const containerMaxStretchMemory = eamsa512.DefaultMaxKDFMemory
+
+// ContainerHeader is the parsed container header
+type ContainerHeader struct {
+	FormatVersion   int
+	CipherSuite     int
+	Padding         eamsa512.Padding // Body padding named by the cipher suite
+	HeaderEncrypted bool
+	KeyVersion      int    // Zero if not recorded or not yet decrypted
+	Mode            string // Empty if not yet decrypted
+	HasDigest       bool   // Container carries a digest footer
+	SplitTrust      bool   // Tags are under a separately held MAC key
+	PerFileKey      bool   // Body is under a key derived from its nonce
+	StretchedKey    bool   // Keys derive from the master key stretched with StretchParams
+	StretchParams   eamsa512.KDFParams
+	PlaintextDigest []byte // SHA3-256 of the plaintext (set by OpenContainer)
+	Size            int    // Header size in bytes, including the header tag
+}
+
+// ============================================================================
+// Seal / Open
+// ============================================================================
+
+// SealContainer encrypts plaintext and wraps it in a container
+// plaintext: data to encrypt
+// masterKey: master key (32 bytes)
+// opts: header options
+// Returns: header || EncryptData output
+func SealContainer(plaintext []byte, masterKey []byte, opts ContainerOptions) ([]byte, error) {
+	header, masterKey, err := buildContainerHeader(masterKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := eamsa512.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	bodyKey, err := containerBodyKey(masterKey, nonce, opts.PerFileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	body, err := sealInPlace(buf, bodyKey, nonce, opts.MACKey, opts.Padding)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(header)+len(body)+containerFooterSize)
+	out = append(out, header...)
+	out = append(out, body...)
+
+	if opts.IncludeDigest {
+		digest := sha3.Sum256(plaintext)
+		footer, err := buildContainerFooter(masterKey, opts.MACKey, digest[:], body[len(body)-TagSize:])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, footer...)
+	}
+
+	return out, nil
+}
+
+// OpenContainer verifies and decrypts a container
+// Returns the plaintext and the fully decoded header
+func OpenContainer(data []byte, masterKey []byte) ([]byte, *ContainerHeader, error) {
+	return openContainer(data, masterKey, nil)
+}
+
+// OpenContainerSplit verifies a split-trust container under macKey and
+// decrypts it with masterKey. Containers without FlagSplitTrust are
+// rejected.
+func OpenContainerSplit(data []byte, masterKey []byte, macKey MACKey) ([]byte, *ContainerHeader, error) {
+	if macKey == nil {
+		return nil, nil, fmt.Errorf("split-trust decryption requires a MAC key")
+	}
+	return openContainer(data, masterKey, macKey)
+}
+
+// openContainer verifies and decrypts a container; macKey is nil for
+// master-key tags
+func openContainer(data []byte, masterKey []byte, macKey MACKey) ([]byte, *ContainerHeader, error) {
+	if len(masterKey) != KeySize {
+		return nil, nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	header, container, masterKey, err := openContainerHeader(data, masterKey, macKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	body := container.Body
+
+	if header.HasDigest {
+		header.PlaintextDigest, err = openContainerFooter(masterKey, macKey, container.Footer, body[len(body)-TagSize:])
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, nonce, _, err := format.SplitBody(body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bodyKey, err := containerBodyKey(masterKey, nonce, header.PerFileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	buf := make([]byte, len(body))
+	copy(buf, body)
+
+	plaintext, err := openInPlace(buf, bodyKey, macKey, header.Padding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if header.HasDigest {
+		digest := sha3.Sum256(plaintext)
+		if !bytes.Equal(digest[:], header.PlaintextDigest) {
+			return nil, nil, fmt.Errorf("plaintext digest mismatch")
+		}
+	}
+
+	return plaintext, header, nil
+}
+
+// ContainerDigest returns the plaintext SHA3-256 digest stored in the
+// container footer, without decrypting the body
+func ContainerDigest(data []byte, masterKey []byte) ([]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	header, container, masterKey, err := openContainerHeader(data, masterKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if !header.HasDigest {
+		return nil, fmt.Errorf("container has no digest footer")
+	}
+
+	body := container.Body
+	return openContainerFooter(masterKey, nil, container.Footer, body[len(body)-TagSize:])
+}
+
+// ParseContainerHeader parses the visible part of a container header
+// without a key. Key version and mode are only populated when the header
+// is not encrypted. The header tag is not verified.
+func ParseContainerHeader(data []byte) (*ContainerHeader, error) {
+	container, err := format.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	header := newContainerHeader(container.Header)
+
+	if !header.HeaderEncrypted {
+		if err := decodeContainerMetadata(container.Header.Metadata, header); err != nil {
+			return nil, err
+		}
+	}
+
+	return header, nil
+}
+
+// IsContainer reports whether data starts with the container magic
+func IsContainer(data []byte) bool {
+	return format.IsContainer(data)
+}
+
+// ============================================================================
+// Encoding Helpers
+// ============================================================================
+
+// deriveContainerKey derives a labelled container subkey from the master key
+func deriveContainerKey(masterKey []byte, label string) []byte {
+	hash := sha3.New512()
+	hash.Write([]byte(label))
+	hash.Write(masterKey)
+	return hash.Sum(nil)[:KeySize]
+}
+
+// buildContainerHeader serializes and authenticates a container header.
+// Returns the header and the key the container keys derive from: the
+// master key, or the stretched master key with StretchLowEntropyKey.
+func buildContainerHeader(masterKey []byte, opts ContainerOptions) ([]byte, []byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	if opts.Mode == "" {
+		opts.Mode = "CBC"
+	}
+
+	suite, err := suiteForPadding(opts.Padding)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	metadata, err := format.Metadata{KeyVersion: opts.KeyVersion, Mode: opts.Mode}.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := &format.Header{
+		Version:  ContainerFormatVersion,
+		Suite:    suite,
+		Metadata: metadata,
+	}
+
+	if eamsa512.LowEntropyKey(masterKey) {
+		entropy := eamsa512.ShannonEntropy(masterKey)
+		var stretched *eamsa512.KDFParams
+		if opts.StretchLowEntropyKey {
+			params := opts.StretchParams
+			if params == (eamsa512.KDFParams{}) {
+				params = eamsa512.DefaultKDFParams
+			}
+			masterKey, header.Stretch, err = stretchContainerKey(masterKey, params)
+			if err != nil {
+				return nil, nil, err
+			}
+			header.Flags |= FlagStretchedKey
+			stretched = &params
+		}
+		auditLowEntropyKey(entropy, opts.KeyVersion, stretched)
+	}
+
+	if opts.EncryptHeader {
+		header.Metadata, err = EncryptData(metadata, deriveContainerKey(masterKey, headerEncryptionLabel), nil)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to encrypt header: %v", err)
+		}
+		header.Flags |= FlagHeaderEncrypted
+	}
+
+	if opts.IncludeDigest {
+		header.Flags |= FlagDigestFooter
+	}
+
+	if opts.MACKey != nil {
+		header.Flags |= FlagSplitTrust
+	}
+
+	if opts.PerFileKey {
+		header.Flags |= FlagFileKey
+	}
+
+	signed, err := header.Signed()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header.Tag, err = computeHeaderTag(masterKey, opts.MACKey, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoded, err := header.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+	return encoded, masterKey, nil
+}
+
+// stretchContainerKey stretches masterKey under a random salt and returns
+// the stretched key and the header's key-stretching section
+func stretchContainerKey(masterKey []byte, params eamsa512.KDFParams) ([]byte, []byte, error) {
+	salt := make([]byte, format.StretchSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	section, err := format.KeyStretch{
+		KDF:         format.StretchArgon2id,
+		Memory:      params.Memory,
+		Iterations:  params.Iterations,
+		Parallelism: params.Parallelism,
+		Salt:        salt,
+	}.MarshalBinary()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	key, err := eamsa512.StretchKey(masterKey, salt, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, section, nil
+}
+
+// containerMasterKey returns the key the container keys of h derive from:
+// masterKey, or masterKey stretched with the recorded parameters
+func containerMasterKey(h *format.Header, masterKey []byte) ([]byte, error) {
+	if !h.StretchedKey() {
+		return masterKey, nil
+	}
+
+	s, err := format.ParseKeyStretch(h.Stretch)
+	if err != nil {
+		return nil, err
+	}
+	if s.Memory > containerMaxStretchMemory {
+		return nil, fmt.Errorf("key stretching memory %d KiB exceeds the limit of %d KiB", s.Memory, containerMaxStretchMemory)
+	}
+
+	return eamsa512.StretchKey(masterKey, s.Salt, eamsa512.KDFParams{
+		Memory:      s.Memory,
+		Iterations:  s.Iterations,
+		Parallelism: s.Parallelism,
+	})
+}
+
+// auditLowEntropyKey warns that a container was sealed under a master key
+// that looks low-entropy, and whether it was stretched (params nil: not). The key is
+// identified by version only: a digest of a guessable key would let
+// anyone reading the audit log search for it.
+func auditLowEntropyKey(entropy float64, keyVersion int, params *eamsa512.KDFParams) {
+	details := map[string]interface{}{
+		"warning":     "master key looks low-entropy",
+		"entropy":     fmt.Sprintf("%.2f bits/byte", entropy),
+		"key_version": keyVersion,
+		"stretched":   params != nil,
+		"timestamp":   time.Now().Format(time.RFC3339),
+	}
+	if params != nil {
+		details["kdf"] = "argon2id"
+		details["memory_kib"] = params.Memory
+		details["iterations"] = params.Iterations
+		details["parallelism"] = params.Parallelism
+	}
+	LogAuditEvent("LOW_ENTROPY_KEY", details)
+}
+
+// containerBodyKey returns the key the body is sealed under: the master
+// key, or with perFileKey the key derived from it and the body nonce
+func containerBodyKey(masterKey []byte, nonce []byte, perFileKey bool) ([]byte, error) {
+	if !perFileKey {
+		return masterKey, nil
+	}
+	return eamsa512.DeriveFileKey(masterKey, nonce, fileKeyLabel)
+}
+
+// suiteForPadding returns the cipher suite that records a body padding
+func suiteForPadding(padding eamsa512.Padding) (byte, error) {
+	switch padding {
+	case 0, eamsa512.PaddingPKCS7:
+		return CipherSuiteEAMSA512, nil
+	case eamsa512.PaddingISO7816:
+		return CipherSuiteEAMSA512ISO7816, nil
+	case eamsa512.PaddingZeroLength:
+		return CipherSuiteEAMSA512ZeroLength, nil
+	default:
+		return 0, fmt.Errorf("unsupported body padding %s", padding)
+	}
+}
+
+// paddingForSuite returns the body padding of a known cipher suite
+func paddingForSuite(suite byte) eamsa512.Padding {
+	switch suite {
+	case CipherSuiteEAMSA512ISO7816:
+		return eamsa512.PaddingISO7816
+	case CipherSuiteEAMSA512ZeroLength:
+		return eamsa512.PaddingZeroLength
+	default:
+		return eamsa512.PaddingPKCS7
+	}
+}
+
+// computeHeaderTag tags the signed header under the header MAC key, or
+// under macKey in split-trust mode
+func computeHeaderTag(masterKey []byte, macKey MACKey, signed []byte) ([]byte, error) {
+	if macKey != nil {
+		return computeSplitTag(macKey, headerMACLabel, signed)
+	}
+	return ComputeHMAC(deriveContainerKey(masterKey, headerMACLabel), signed), nil
+}
+
+// openContainerHeader splits the container, verifies the header tag and
+// decodes all header fields. Also returns the key the container keys
+// derive from (see containerMasterKey).
+func openContainerHeader(data []byte, masterKey []byte, macKey MACKey) (*ContainerHeader, *format.Container, []byte, error) {
+	container, err := format.Parse(data)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	masterKey, err = containerMasterKey(container.Header, masterKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	header, err := verifyContainerHeader(container.Header, masterKey, macKey)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return header, container, masterKey, nil
+}
+
+// verifyContainerHeader verifies the header tag and decodes the metadata;
+// masterKey is already stretched if the header says so. The split-trust flag must match whether macKey is given, so neither
+// kind of container is accepted in place of the other.
+func verifyContainerHeader(h *format.Header, masterKey []byte, macKey MACKey) (*ContainerHeader, error) {
+	if h.SplitTrust() && macKey == nil {
+		return nil, fmt.Errorf("container uses split-trust tags: a MAC key is required")
+	}
+	if !h.SplitTrust() && macKey != nil {
+		return nil, fmt.Errorf("container is not split-trust: refusing master-key tags")
+	}
+
+	signed, err := h.Signed()
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := computeHeaderTag(masterKey, macKey, signed)
+	if err != nil {
+		return nil, err
+	}
+
+	if subtle.ConstantTimeCompare(expected, h.Tag) != 1 {
+		return nil, fmt.Errorf("container header authentication failed")
+	}
+
+	header := newContainerHeader(h)
+	metadata := h.Metadata
+
+	if header.HeaderEncrypted {
+		metadata, err = DecryptData(metadata, deriveContainerKey(masterKey, headerEncryptionLabel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt header: %v", err)
+		}
+	}
+
+	if err := decodeContainerMetadata(metadata, header); err != nil {
+		return nil, err
+	}
+
+	return header, nil
+}
+
+// buildContainerFooter encrypts the plaintext digest, bound to the body tag
+func buildContainerFooter(masterKey []byte, macKey MACKey, digest []byte, bodyTag []byte) ([]byte, error) {
+	payload := make([]byte, 0, SealOverhead(PlaintextDigestSize+TagSize)+PlaintextDigestSize+TagSize)
+	payload = append(payload, digest...)
+	payload = append(payload, bodyTag...)
+
+	footer, err := sealInPlace(payload, deriveContainerKey(masterKey, footerEncryptionLabel), nil, macKey, eamsa512.PaddingPKCS7)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt digest footer: %v", err)
+	}
+
+	return footer, nil
+}
+
+// openContainerFooter decrypts the footer and checks it belongs to this body
+func openContainerFooter(masterKey []byte, macKey MACKey, footer []byte, bodyTag []byte) ([]byte, error) {
+	buf := make([]byte, len(footer))
+	copy(buf, footer)
+
+	payload, err := openInPlace(buf, deriveContainerKey(masterKey, footerEncryptionLabel), macKey, eamsa512.PaddingPKCS7)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt digest footer: %v", err)
+	}
+
+	if len(payload) != PlaintextDigestSize+TagSize {
+		return nil, fmt.Errorf("invalid digest footer length: %d", len(payload))
+	}
+
+	if subtle.ConstantTimeCompare(payload[PlaintextDigestSize:], bodyTag) != 1 {
+		return nil, fmt.Errorf("digest footer does not belong to this container")
+	}
+
+	return payload[:PlaintextDigestSize], nil
+}
+
+// newContainerHeader converts the on-disk header
+func newContainerHeader(h *format.Header) *ContainerHeader {
+	header := &ContainerHeader{
+		FormatVersion:   int(h.Version),
+		CipherSuite:     int(h.Suite),
+		Padding:         paddingForSuite(h.Suite),
+		HeaderEncrypted: h.Encrypted(),
+		HasDigest:       h.HasFooter(),
+		SplitTrust:      h.SplitTrust(),
+		PerFileKey:      h.FileKey(),
+		StretchedKey:    h.StretchedKey(),
+		Size:            h.Size(),
+	}
+
+	// format.ParseHeader has validated the section
+	if s, err := format.ParseKeyStretch(h.Stretch); err == nil {
+		header.StretchParams = eamsa512.KDFParams{Memory: s.Memory, Iterations: s.Iterations, Parallelism: s.Parallelism}
+	}
+	return header
+}
+
+// decodeContainerMetadata decodes plaintext metadata records into header
+func decodeContainerMetadata(metadata []byte, header *ContainerHeader) error {
+	m, err := format.ParseMetadata(metadata)
+	if err != nil {
+		return err
+	}
+
+	header.KeyVersion = m.KeyVersion
+	header.Mode = m.Mode
+	return nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. HEADER PRIVACY
+   - Plain headers expose key version and mode to anyone holding the file
+   - EncryptHeader: metadata is EncryptData(metadata, header key); only
+     magic, format version, cipher suite and flags stay visible
+   - Header key = SHA3-512("EAMSA512-HEADER-ENC" || master key)[:32]
+
+2. HEADER INTEGRITY
+   - Header tag = HMAC-SHA3-512(SHA3-512("EAMSA512-HEADER-MAC" || master key)[:32],
+     prefix || metadata)
+   - Verified before the metadata is decrypted or the body is touched
+
+3. DIGEST FOOTER
+   - IncludeDigest / HashingEncryptWriter: the footer is
+     EncryptData(SHA3-256(plaintext) || body tag, footer key), 208 bytes
+   - Embedding the body tag binds the footer to its container
+   - ContainerDigest() reads the digest without decrypting the body
+
+4. SPLIT TRUST
+   - ContainerOptions.MACKey: header, body and footer tags use a MAC key
+     held apart from the master key (see split-trust.go)
+   - FlagSplitTrust is authenticated; OpenContainer() rejects such
+     containers, OpenContainerSplit() rejects all others
+
+5. PER-FILE KEYS
+   - PerFileKey (WithPerFileKey for CopyEncrypt): the body is sealed
+     under DeriveFileKey(master key, body nonce, "EAMSA512-CONTAINER-BODY")
+     and FlagFileKey is set; readers derive the same key from the nonce
+   - A leaked body key exposes one container, not every container under
+     the master key, and no one key seals more than one body
+   - Header and footer keys stay derived from the master key
+
+6. BODY PADDING
+   - ContainerOptions.Padding selects PKCS#7 (suite 1, the default),
+     ISO/IEC 7816-4 (suite 2) or zeros and a length byte (suite 3)
+   - The suite is in the authenticated header, so OpenContainer() always
+     unpads with the scheme the writer used; changing it fails the
+     header tag
+   - Header metadata and the footer are always PKCS#7 padded
+
+7. LOW-ENTROPY KEYS
+   - Every container sealed under a key eamsa512.LowEntropyKey flags is
+     audited as LOW_ENTROPY_KEY (entropy estimate and key version; no key
+     identifier, which would be a digest of a guessable key)
+   - StretchLowEntropyKey: such keys are stretched with Argon2id
+     (StretchParams, default 64 MiB, 3 passes, 4 lanes) and FlagStretchedKey
+     and the parameters and salt go in the authenticated header; every
+     container key derives from the stretched key
+   - Opening costs one Argon2id run; OpenContainer() refuses headers
+     asking for more than 1 GiB
+   - Stretching slows guessing; it does not make a weak key strong.
+     Replace it with a generated key
+
+8. INSPECTION
+   - ParseContainerHeader() reads the visible fields without a key
+   - OpenContainer() returns the full header after verification
+   - "eamsa512 inspect -annotate <file>" prints a byte-level breakdown
+   - "eamsa512 inspect -spec" regenerates docs/container-format.md
+
+*/
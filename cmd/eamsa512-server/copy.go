@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/format"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - io.Copy-style Encryption
+// CopyEncrypt / CopyDecrypt pipe one stream into another
+//
+// CopyEncrypt streams src through EncryptWriter into a container on dst,
+// or with WithChunkSize into a chunked stream. CopyDecrypt is the reverse.
+// The body tag sits at the end of the container, so CopyDecrypt spools
+// the input to a temporary file, verifies the header and body tags, and
+// only then streams plaintext to dst. Chunked input (streams and
+// ModeChunked envelopes) has a tag per chunk: it is verified a batch of
+// chunks at a time across all cores, and each batch is written in order
+// once it has verified. Unauthenticated plaintext is never written.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// copyChunkSize is the read size for spooled ciphertext (multiple of BlockSize)
+	copyChunkSize = 1024 * BlockSize
+)
+
+// CopyOption configures CopyEncrypt and CopyDecrypt
+type CopyOption func(*copyConfig)
+
+// copyConfig holds the resolved options
+type copyConfig struct {
+	container ContainerOptions
+	spoolDir  string
+	chunkSize int // Non-zero: CopyEncrypt writes a chunked stream
+}
+
+// WithKeyVersion records the key version in the container header
+func WithKeyVersion(version int) CopyOption {
+	return func(c *copyConfig) { c.container.KeyVersion = version }
+}
+
+// WithEncryptedHeader encrypts the container metadata
+func WithEncryptedHeader() CopyOption {
+	return func(c *copyConfig) { c.container.EncryptHeader = true }
+}
+
+// WithDigest appends the encrypted SHA3-256 plaintext digest; CopyDecrypt
+// verifies it when present
+func WithDigest() CopyOption {
+	return func(c *copyConfig) { c.container.IncludeDigest = true }
+}
+
+// WithMACKey computes (CopyEncrypt) or verifies (CopyDecrypt) all tags
+// under a split-trust MAC key
+func WithMACKey(macKey MACKey) CopyOption {
+	return func(c *copyConfig) { c.container.MACKey = macKey }
+}
+
+// WithPerFileKey seals the body under a key derived from the master key
+// and the body nonce; CopyDecrypt follows the container flag
+func WithPerFileKey() CopyOption {
+	return func(c *copyConfig) { c.container.PerFileKey = true }
+}
+
+// WithSpoolDir sets the directory for CopyDecrypt's temporary file
+// (default os.TempDir)
+func WithSpoolDir(dir string) CopyOption {
+	return func(c *copyConfig) { c.spoolDir = dir }
+}
+
+// WithChunkSize makes CopyEncrypt write a chunked stream (version 2, see
+// eamsa512.EncryptReaderSize) in chunks of size bytes instead of a
+// container; the container options do not apply to it
+func WithChunkSize(size int) CopyOption {
+	return func(c *copyConfig) { c.chunkSize = size }
+}
+
+// newCopyConfig applies opts
+func newCopyConfig(opts []CopyOption) *copyConfig {
+	c := &copyConfig{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CopyEncrypt encrypts src into a container written to dst
+// Returns the number of plaintext bytes read from src
+func CopyEncrypt(dst io.Writer, src io.Reader, key []byte, opts ...CopyOption) (int64, error) {
+	cfg := newCopyConfig(opts)
+
+	if cfg.chunkSize != 0 {
+		footer, err := eamsa512.EncryptReaderSize(dst, src, key, cfg.chunkSize)
+		if err != nil {
+			return 0, err
+		}
+		return int64(footer.Length), nil
+	}
+
+	ew, err := NewEncryptWriter(dst, key, cfg.container)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := io.Copy(ew, src)
+	if err != nil {
+		ew.Close()
+		return n, err
+	}
+
+	if err := ew.Close(); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// CopyDecrypt verifies and decrypts a container from src, writing the
+// plaintext to dst
+// Returns the number of plaintext bytes written to dst
+func CopyDecrypt(dst io.Writer, src io.Reader, key []byte, opts ...CopyOption) (int64, error) {
+	if len(key) != KeySize {
+		return 0, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	cfg := newCopyConfig(opts)
+
+	spool, err := os.CreateTemp(cfg.spoolDir, "eamsa512-decrypt-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create spool file: %v", err)
+	}
+	defer os.Remove(spool.Name())
+	defer spool.Close()
+
+	total, err := io.Copy(spool, src)
+	if err != nil {
+		return 0, err
+	}
+
+	magic := make([]byte, 4)
+	if _, err := spool.ReadAt(magic, 0); err != nil {
+		return 0, fmt.Errorf("container too short: %d bytes", total)
+	}
+	switch {
+	case eamsa512.IsStream(magic):
+		return eamsa512.DecryptStreamAt(context.Background(), dst, spool, total, key)
+	case eamsa512.IsEnvelope(magic):
+		return eamsa512.DecryptEnvelopeAt(context.Background(), dst, spool, total, key, nil)
+	}
+
+	return decryptSpooled(dst, spool, total, key, cfg.container.MACKey)
+}
+
+// decryptSpooled authenticates a spooled container, then decrypts it to dst
+func decryptSpooled(dst io.Writer, r io.ReaderAt, total int64, key []byte, macKey MACKey) (int64, error) {
+	// Header: fixed prefix first, then the declared metadata and tag
+	prefix := make([]byte, format.PrefixSize)
+	if _, err := r.ReadAt(prefix, 0); err != nil {
+		return 0, fmt.Errorf("container too short: %d bytes", total)
+	}
+
+	headerSize := int64(format.HeaderSize(prefix))
+	if headerSize > total {
+		return 0, fmt.Errorf("container truncated: header declares %d bytes, have %d", headerSize, total)
+	}
+
+	headerBytes := make([]byte, headerSize)
+	if _, err := r.ReadAt(headerBytes, 0); err != nil {
+		return 0, err
+	}
+
+	h, err := format.ParseHeader(headerBytes)
+	if err != nil {
+		return 0, err
+	}
+
+	key, err = containerMasterKey(h, key)
+	if err != nil {
+		return 0, err
+	}
+
+	header, err := verifyContainerHeader(h, key, macKey)
+	if err != nil {
+		return 0, err
+	}
+
+	bodySize, err := h.BodySize(total)
+	if err != nil {
+		return 0, err
+	}
+
+	// Body: ciphertext || nonce || tag
+	ciphertextSize := bodySize - NonceSize - TagSize
+	trailer := make([]byte, NonceSize+TagSize)
+	if _, err := r.ReadAt(trailer, headerSize+ciphertextSize); err != nil {
+		return 0, err
+	}
+	nonce, tag := trailer[:NonceSize], trailer[NonceSize:]
+	ciphertext := io.NewSectionReader(r, headerSize, ciphertextSize)
+
+	bodyKey, err := containerBodyKey(key, nonce, header.PerFileKey)
+	if err != nil {
+		return 0, err
+	}
+
+	keys, err := DeriveKeys(bodyKey)
+	if err != nil {
+		return 0, err
+	}
+
+	// Pass 1: authenticate nonce || ciphertext
+	// Containers carry one HMAC over the whole body, so this pass is
+	// sequential; chunked input is verified in parallel (see CopyDecrypt)
+	mac, err := newBodyTag(keys, macKey)
+	if err != nil {
+		return 0, err
+	}
+	mac.Write(nonce)
+	if _, err := io.Copy(mac, ciphertext); err != nil {
+		return 0, err
+	}
+	if subtle.ConstantTimeCompare(mac.Sum(), tag) != 1 {
+		return 0, eamsa512.ErrDecryption
+	}
+
+	var digest []byte
+	if header.HasDigest {
+		footer := make([]byte, format.FooterSize)
+		if _, err := r.ReadAt(footer, headerSize+bodySize); err != nil {
+			return 0, err
+		}
+		digest, err = openContainerFooter(key, macKey, footer, tag)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Pass 2: decrypt the authenticated ciphertext
+	if _, err := ciphertext.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	var plaintextHash hash.Hash
+	if digest != nil {
+		plaintextHash = sha3.New256()
+	}
+
+	n, err := decryptCBCStream(dst, ciphertext, ciphertextSize, keys, DeriveIV(nonce, bodyKey), header.Padding, plaintextHash)
+	if err != nil {
+		return n, err
+	}
+
+	if digest != nil && !bytes.Equal(plaintextHash.Sum(nil), digest) {
+		return n, fmt.Errorf("plaintext digest mismatch")
+	}
+
+	return n, nil
+}
+
+// decryptCBCStream CBC-decrypts size bytes from r to dst, holding back the
+// final block to strip the padding (same rules as OpenContainer)
+func decryptCBCStream(dst io.Writer, r io.Reader, size int64, keys [][]byte, iv []byte, padding eamsa512.Padding, h hash.Hash) (int64, error) {
+	if size < BlockSize || size%BlockSize != 0 {
+		return 0, eamsa512.ErrDecryption
+	}
+
+	var written int64
+	prevBlock := iv
+	buf := make([]byte, copyChunkSize)
+	var held []byte
+
+	for remaining := size; remaining > 0; {
+		chunk := buf
+		if remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return written, err
+		}
+		remaining -= int64(len(chunk))
+
+		plaintext := make([]byte, len(chunk))
+		for i := 0; i < len(chunk); i += BlockSize {
+			encryptedBlock := chunk[i : i+BlockSize]
+			decryptedBlock := DecryptBlock(encryptedBlock, keys)
+			for j := 0; j < BlockSize; j++ {
+				plaintext[i+j] = decryptedBlock[j] ^ prevBlock[j]
+			}
+			prevBlock = append(prevBlock[:0:0], encryptedBlock...)
+		}
+
+		// Flush the previously held block, keep this chunk's last block
+		if err := writePlaintext(dst, h, held, &written); err != nil {
+			return written, err
+		}
+		if err := writePlaintext(dst, h, plaintext[:len(plaintext)-BlockSize], &written); err != nil {
+			return written, err
+		}
+		held = plaintext[len(plaintext)-BlockSize:]
+	}
+
+	final, err := padding.Unpad(held)
+	if err != nil {
+		return written, err
+	}
+
+	if err := writePlaintext(dst, h, final, &written); err != nil {
+		return written, err
+	}
+
+	return written, nil
+}
+
+// writePlaintext writes p to dst and the optional digest
+func writePlaintext(dst io.Writer, h hash.Hash, p []byte, written *int64) error {
+	if len(p) == 0 {
+		return nil
+	}
+	if h != nil {
+		h.Write(p)
+	}
+	n, err := dst.Write(p)
+	*written += int64(n)
+	return err
+}
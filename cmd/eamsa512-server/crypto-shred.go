@@ -0,0 +1,641 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// ============================================================================
+// EAMSA 512 - Crypto-Shredding
+// End-of-life destruction of data by destroying the key it is encrypted under
+//
+// Deleting every copy of a dataset (replicas, backups, caches) is rarely
+// possible. Destroying the one key version it was encrypted under makes
+// all of those copies unrecoverable at once. Because that cannot be
+// undone, shredding runs as a quorum-approved workflow:
+//
+//   1. request: a user names a key version that is no longer active and a
+//      reason. The request is saved as pending; nothing is destroyed.
+//   2. approve: other users approve it, within 7 days. When the quorum
+//      (default 2 approvers, never the requester) is reached, the key
+//      material is erased and the keystore saved without it, every row of
+//      the operations table that used the version is marked with the
+//      shred ID and time, and a destruction certificate is issued.
+//
+// The certificate records who requested and approved the destruction,
+// when it happened, how the key was erased and how many ciphertexts it
+// made unrecoverable. It carries the chain hash of the KEY_SHREDDED audit
+// row and its own SHA3-256 digest, which is also written to the audit log
+// as SHRED_CERTIFIED, so an edited certificate is detected.
+//
+// Replicas of an Embedded keystore erase the version when they next
+// exchange with this region (see key-replication.go). Backups taken with
+// KeyManager.BackupKey are outside the keystore and must be destroyed
+// separately.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Shred request states
+const (
+	shredPending    = "pending"
+	shredDestroying = "destroying" // Quorum reached; key erased or about to be
+	shredCompleted  = "completed"
+	shredExpired    = "expired"
+	shredRequestTTL = 7 * 24 * time.Hour
+)
+
+// DefaultShredQuorum is the number of approvers a shred request needs
+const DefaultShredQuorum = 2
+
+// KeyShredder is a keystore whose key versions can be destroyed: a
+// KeyManager, or an Embedded keystore, which also saves the result
+type KeyShredder interface {
+	GetKeyMetadata(version int) (*keymgmt.KeyMetadata, error)
+	GetRotationPolicy() keymgmt.KeyRotationPolicy
+	DestroyKeyVersion(version int) (keymgmt.KeyMetadata, error)
+}
+
+// ShredApproval is one approval of a shred request
+type ShredApproval struct {
+	User       string    `json:"user"`
+	ApprovedAt time.Time `json:"approved_at"`
+}
+
+// ShredRequest is a request to destroy a key version
+type ShredRequest struct {
+	ID          string                  `json:"id"`
+	Status      string                  `json:"status"` // "pending", "destroying", "completed" or "expired"
+	KeyVersion  int                     `json:"key_version"`
+	KeyHash     string                  `json:"key_hash"` // Identifier of the key at request time
+	Reason      string                  `json:"reason"`
+	RequestedBy string                  `json:"requested_by"`
+	CreatedAt   time.Time               `json:"created_at"`
+	Quorum      int                     `json:"quorum"`
+	Approvals   []ShredApproval         `json:"approvals"`
+	Operations  int64                   `json:"operations"` // Operations under the version at request time
+	Certificate *DestructionCertificate `json:"certificate,omitempty"`
+}
+
+// DestructionCertificate documents a completed crypto-shred
+type DestructionCertificate struct {
+	ShredID                  string          `json:"shred_id"`
+	KeyVersion               int             `json:"key_version"`
+	KeyHash                  string          `json:"key_hash"`
+	Reason                   string          `json:"reason"`
+	RequestedBy              string          `json:"requested_by"`
+	RequestedAt              time.Time       `json:"requested_at"`
+	Quorum                   int             `json:"quorum"`
+	Approvals                []ShredApproval `json:"approvals"`
+	DestroyedAt              time.Time       `json:"destroyed_at"`
+	DestructionMethod        string          `json:"destruction_method"`
+	DestructionPasses        int             `json:"destruction_passes,omitempty"`
+	OperationsMarked         int64           `json:"operations_marked"`         // Rows of the operations table marked unrecoverable
+	CiphertextsUnrecoverable int64           `json:"ciphertexts_unrecoverable"` // Successful encryptions among them
+	AuditHash                string          `json:"audit_hash"`                // Chain hash of the KEY_SHREDDED audit row
+	IssuedAt                 time.Time       `json:"issued_at"`
+	Notes                    []string        `json:"notes,omitempty"`
+	Digest                   string          `json:"digest"` // SHA3-256 of the certificate with an empty digest
+}
+
+// shredCertificateNotes states what a certificate does not cover
+var shredCertificateNotes = []string{
+	"Backups of the key made outside the keystore (KeyManager.BackupKey) must be destroyed separately.",
+	"Ciphertexts not recorded in the operations table are unrecoverable too but are not counted.",
+}
+
+// ComputeDigest returns the SHA3-256 digest of the certificate with its
+// Digest field empty
+func (cert DestructionCertificate) ComputeDigest() (string, error) {
+	cert.Digest = ""
+	data, err := json.Marshal(cert)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode certificate: %v", err)
+	}
+	sum := sha3.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// RequestShred saves a pending request to destroy a key version. The
+// version must exist in keys and no longer be active; quorum 0 selects
+// DefaultShredQuorum.
+func (db *Database) RequestShred(ctx context.Context, keys KeyShredder, version int, reason, requestedBy string, quorum int) (*ShredRequest, error) {
+	if requestedBy == "" {
+		return nil, fmt.Errorf("the requesting user is required")
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required")
+	}
+	if quorum == 0 {
+		quorum = DefaultShredQuorum
+	}
+	if quorum < 1 {
+		return nil, fmt.Errorf("invalid quorum %d: at least one approver is required", quorum)
+	}
+
+	metadata, err := keys.GetKeyMetadata(version)
+	if err != nil {
+		return nil, err
+	}
+	switch metadata.State {
+	case keymgmt.KeyStateActive:
+		return nil, fmt.Errorf("key version %d is active; rotate it out before shredding it", version)
+	case keymgmt.KeyStateDestroyed:
+		return nil, fmt.Errorf("key version %d is already destroyed", version)
+	}
+
+	if pending, err := db.pendingShredFor(ctx, version); err != nil {
+		return nil, err
+	} else if pending != nil {
+		if pending.Status != shredPending || failpoint.Now().Sub(pending.CreatedAt) <= shredRequestTTL {
+			return nil, fmt.Errorf("key version %d already has shred request %s (%s)", version, pending.ID, pending.Status)
+		}
+		pending.Status = shredExpired
+		if err := db.saveShredRequest(pending); err != nil {
+			return nil, err
+		}
+	}
+
+	operations, err := db.countOperations(ctx, `key_version = ?`, version)
+	if err != nil {
+		return nil, err
+	}
+
+	request := &ShredRequest{
+		ID:          newShredRequestID(),
+		Status:      shredPending,
+		KeyVersion:  version,
+		KeyHash:     metadata.KeyHash,
+		Reason:      reason,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now().UTC(),
+		Quorum:      quorum,
+		Approvals:   []ShredApproval{},
+		Operations:  operations,
+	}
+	if err := db.saveShredRequest(request); err != nil {
+		return nil, err
+	}
+
+	db.auditShred("SHRED_REQUESTED", "warning", requestedBy, map[string]interface{}{
+		"shred_id":    request.ID,
+		"key_version": version,
+		"key_hash":    request.KeyHash,
+		"reason":      reason,
+		"quorum":      quorum,
+		"operations":  operations,
+	})
+	db.logger.Printf("Shred request %s: key version %d, %d operations, quorum %d", request.ID, version, operations, quorum)
+
+	return request, nil
+}
+
+// ApproveShred records an approval. The approver must not be the
+// requester or have approved already. The approval that reaches the
+// quorum destroys the key version in keys, marks its operations and
+// issues the certificate. A request left "destroying" by a failure is
+// finished by the next approval attempt, by any user.
+func (db *Database) ApproveShred(ctx context.Context, keys KeyShredder, requestID, approver string) (*ShredRequest, error) {
+	if approver == "" {
+		return nil, fmt.Errorf("the approving user is required")
+	}
+
+	request, err := db.GetShredRequest(requestID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status == shredDestroying {
+		return request, db.finishShred(ctx, keys, request, approver)
+	}
+
+	if err := checkShredApproval(request, approver); err != nil {
+		if request.Status == shredPending && failpoint.Now().Sub(request.CreatedAt) > shredRequestTTL {
+			request.Status = shredExpired
+			db.saveShredRequest(request)
+		}
+		return nil, err
+	}
+
+	request.Approvals = append(request.Approvals, ShredApproval{User: approver, ApprovedAt: time.Now().UTC()})
+	if len(request.Approvals) >= request.Quorum {
+		request.Status = shredDestroying
+	}
+	if err := db.saveShredRequest(request); err != nil {
+		return nil, err
+	}
+
+	db.auditShred("SHRED_APPROVED", "warning", approver, map[string]interface{}{
+		"shred_id":     request.ID,
+		"key_version":  request.KeyVersion,
+		"requested_by": request.RequestedBy,
+		"approvals":    len(request.Approvals),
+		"quorum":       request.Quorum,
+	})
+
+	if request.Status != shredDestroying {
+		return request, nil
+	}
+	return request, db.finishShred(ctx, keys, request, approver)
+}
+
+// checkShredApproval enforces the pending state, expiry and the rule that
+// approvers are distinct and not the requester
+func checkShredApproval(request *ShredRequest, approver string) error {
+	if request.Status != shredPending {
+		return fmt.Errorf("shred request %s is %s", request.ID, request.Status)
+	}
+	if failpoint.Now().Sub(request.CreatedAt) > shredRequestTTL {
+		return fmt.Errorf("shred request %s has expired; request it again", request.ID)
+	}
+	if approver == request.RequestedBy {
+		return fmt.Errorf("shred request %s must be approved by someone other than %s", request.ID, request.RequestedBy)
+	}
+	for _, approval := range request.Approvals {
+		if approval.User == approver {
+			return fmt.Errorf("%s has already approved shred request %s", approver, request.ID)
+		}
+	}
+	return nil
+}
+
+// finishShred destroys the key version unless that already happened,
+// marks its operations and issues the certificate. Each step may be
+// repeated after a failure.
+func (db *Database) finishShred(ctx context.Context, keys KeyShredder, request *ShredRequest, user string) error {
+	metadata, err := keys.GetKeyMetadata(request.KeyVersion)
+	if err != nil {
+		return db.shredFailed(request, user, err)
+	}
+	// A replication conflict may have moved another key to this version
+	if metadata.KeyHash != request.KeyHash {
+		return db.shredFailed(request, user, fmt.Errorf("key version %d is now key %s, not %s", request.KeyVersion, metadata.KeyHash, request.KeyHash))
+	}
+	if metadata.State != keymgmt.KeyStateDestroyed {
+		destroyed, err := keys.DestroyKeyVersion(request.KeyVersion)
+		if err != nil {
+			return db.shredFailed(request, user, err)
+		}
+		metadata = &destroyed
+	}
+
+	marked, err := db.markShreddedOperations(ctx, request.KeyVersion, request.ID, metadata.DestroyedAt)
+	if err != nil {
+		return db.shredFailed(request, user, err)
+	}
+	ciphertexts, err := db.countOperations(ctx, `shred_id = ? AND operation_type = 'encrypt' AND status = 'success'`, request.ID)
+	if err != nil {
+		return db.shredFailed(request, user, err)
+	}
+
+	db.auditShred("KEY_SHREDDED", "critical", user, map[string]interface{}{
+		"shred_id":          request.ID,
+		"key_version":       request.KeyVersion,
+		"key_hash":          request.KeyHash,
+		"operations_marked": marked,
+		"ciphertexts":       ciphertexts,
+	})
+
+	policy := keys.GetRotationPolicy()
+	cert := &DestructionCertificate{
+		ShredID:                  request.ID,
+		KeyVersion:               request.KeyVersion,
+		KeyHash:                  request.KeyHash,
+		Reason:                   request.Reason,
+		RequestedBy:              request.RequestedBy,
+		RequestedAt:              request.CreatedAt,
+		Quorum:                   request.Quorum,
+		Approvals:                request.Approvals,
+		DestroyedAt:              metadata.DestroyedAt.UTC(),
+		DestructionMethod:        policy.DestructionMethod,
+		OperationsMarked:         marked,
+		CiphertextsUnrecoverable: ciphertexts,
+		AuditHash:                db.currentAuditHead(),
+		IssuedAt:                 time.Now().UTC(),
+		Notes:                    shredCertificateNotes,
+	}
+	if policy.DestructionMethod != "zero" {
+		cert.DestructionPasses = policy.DestructionPasses
+	}
+	if cert.Digest, err = cert.ComputeDigest(); err != nil {
+		return db.shredFailed(request, user, err)
+	}
+
+	request.Status = shredCompleted
+	request.Certificate = cert
+	if err := db.saveShredRequest(request); err != nil {
+		return err
+	}
+
+	db.auditShred("SHRED_CERTIFIED", "info", user, map[string]interface{}{
+		"shred_id": request.ID,
+		"digest":   cert.Digest,
+	})
+	db.logger.Printf("Shred %s completed: key version %d destroyed, %d operations marked unrecoverable",
+		request.ID, request.KeyVersion, marked)
+
+	return nil
+}
+
+// shredFailed audits a failed step and returns err
+func (db *Database) shredFailed(request *ShredRequest, user string, err error) error {
+	db.auditShred("SHRED_FAILED", "critical", user, map[string]interface{}{
+		"shred_id":    request.ID,
+		"key_version": request.KeyVersion,
+		"error":       err.Error(),
+	})
+	return fmt.Errorf("shred %s: %v", request.ID, err)
+}
+
+// markShreddedOperations marks the operations of a key version that are
+// not marked yet, and the key version record, as shredded
+func (db *Database) markShreddedOperations(ctx context.Context, version int, shredID string, at time.Time) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.conn.ExecContext(ctx, `UPDATE operations SET shred_id = ?, shredded_at = ?
+		WHERE key_version = ? AND shred_id IS NULL`, shredID, at, version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark operations of key version %d: %v", version, err)
+	}
+	if _, err := db.conn.ExecContext(ctx, `UPDATE key_versions SET state = ? WHERE version = ?`, string(keymgmt.KeyStateDestroyed), version); err != nil {
+		return 0, fmt.Errorf("failed to mark key version %d destroyed: %v", version, err)
+	}
+
+	// Rows marked by an earlier, interrupted attempt count too
+	var marked int64
+	err = db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM operations WHERE shred_id = ?`, shredID).Scan(&marked)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count shredded operations: %v", err)
+	}
+	return marked, nil
+}
+
+// countOperations counts the operations matching a WHERE clause
+func (db *Database) countOperations(ctx context.Context, where string, args ...interface{}) (int64, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var count int64
+	if err := db.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM operations WHERE `+where, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count operations: %v", err)
+	}
+	return count, nil
+}
+
+// pendingShredFor returns the unfinished request for a version, if any
+func (db *Database) pendingShredFor(ctx context.Context, version int) (*ShredRequest, error) {
+	db.mu.RLock()
+	var id string
+	err := db.conn.QueryRowContext(ctx, `SELECT id FROM shred_requests WHERE key_version = ? AND status IN (?, ?)`,
+		version, shredPending, shredDestroying).Scan(&id)
+	db.mu.RUnlock()
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to look up shred requests: %v", err)
+	}
+	return db.GetShredRequest(id)
+}
+
+// currentAuditHead returns the chain hash of the last audit row
+func (db *Database) currentAuditHead() string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.auditHead
+}
+
+// auditShred records a shred event in the audit log
+func (db *Database) auditShred(event, severity, user string, details map[string]interface{}) {
+	details["timestamp"] = time.Now().Format(time.RFC3339)
+	detailsJSON, _ := json.Marshal(details)
+
+	db.RecordAuditLog(AuditLogEntry{
+		EventType: event,
+		Category:  AuditCategoryAdmin,
+		Severity:  severity,
+		Details:   string(detailsJSON),
+		Timestamp: time.Now(),
+		UserID:    user,
+	})
+}
+
+func newShredRequestID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("shred-%s-%s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(suffix))
+}
+
+// saveShredRequest inserts or updates a request
+func (db *Database) saveShredRequest(request *ShredRequest) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	data, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode shred request: %v", err)
+	}
+
+	query := `INSERT OR REPLACE INTO shred_requests (id, status, key_version, requested_by, request, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	if _, err := db.exec(query, request.ID, request.Status, request.KeyVersion, request.RequestedBy, string(data), request.CreatedAt, time.Now()); err != nil {
+		return fmt.Errorf("failed to save shred request %s: %v", request.ID, err)
+	}
+	return nil
+}
+
+// GetShredRequest loads a request
+func (db *Database) GetShredRequest(id string) (*ShredRequest, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var data string
+	err := db.conn.QueryRow(`SELECT request FROM shred_requests WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no shred request %s", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load shred request %s: %v", id, err)
+	}
+
+	var request ShredRequest
+	if err := json.Unmarshal([]byte(data), &request); err != nil {
+		return nil, fmt.Errorf("failed to decode shred request %s: %v", id, err)
+	}
+	return &request, nil
+}
+
+// VerifyDestructionCertificate checks a certificate's digest and that it
+// matches the one issued for its shred request
+func (db *Database) VerifyDestructionCertificate(cert *DestructionCertificate) error {
+	digest, err := cert.ComputeDigest()
+	if err != nil {
+		return err
+	}
+	if digest != cert.Digest {
+		return fmt.Errorf("certificate digest mismatch: the certificate was modified")
+	}
+
+	request, err := db.GetShredRequest(cert.ShredID)
+	if err != nil {
+		return err
+	}
+	if request.Certificate == nil || request.Certificate.Digest != cert.Digest {
+		return fmt.Errorf("certificate does not match the one issued for %s", cert.ShredID)
+	}
+	return nil
+}
+
+// ============================================================================
+// Command
+// ============================================================================
+
+// RunShredCommand implements "shred":
+//
+//	eamsa512-server shred request -keystore /var/lib/eamsa512/keys -version 3 -reason "..." -user alice [-quorum 2]
+//	eamsa512-server shred approve -keystore /var/lib/eamsa512/keys -id shred-... -user bob
+//	eamsa512-server shred show -id shred-...
+//	eamsa512-server shred certificate -id shred-... [-o cert.json]
+//	eamsa512-server shred verify -certificate cert.json
+//
+// -keystore is an Embedded keystore directory. approve opens it for
+// writing, so no other process may have it open; a process that does
+// calls ApproveShred with its own keystore instead.
+func RunShredCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: shred request|approve|show|certificate|verify [flags]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("shred "+action, flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to database")
+	keystore := fs.String("keystore", "", "Embedded keystore directory holding the key version")
+	version := fs.Int("version", 0, "request: key version to destroy")
+	reason := fs.String("reason", "", "request: why the data is destroyed (e.g. retention period ended)")
+	quorum := fs.Int("quorum", DefaultShredQuorum, "request: approvals required, not counting the requester")
+	id := fs.String("id", "", "Shred request")
+	user := fs.String("user", "", "User requesting or approving")
+	output := fs.String("o", "", "certificate: write to this file instead of stdout")
+	certFile := fs.String("certificate", "", "verify: certificate file")
+	force := fs.Bool("force", false, "Open the database even if another process holds its lock (audited)")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	switch action {
+	case "request", "approve", "show", "certificate", "verify":
+	default:
+		return fmt.Errorf("unknown shred action %q", action)
+	}
+
+	readOnly := action == "show" || action == "certificate" || action == "verify"
+	db, err := OpenDatabase(*dbPath, StoreOpenOptions{ReadOnly: readOnly, Force: *force})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action {
+	case "show", "certificate":
+		if *id == "" {
+			return fmt.Errorf("-id is required")
+		}
+		request, err := db.GetShredRequest(*id)
+		if err != nil {
+			return err
+		}
+		if action == "show" {
+			return printJSON(request)
+		}
+		if request.Certificate == nil {
+			return fmt.Errorf("shred request %s is %s; no certificate has been issued", request.ID, request.Status)
+		}
+		if *output == "" {
+			return printJSON(request.Certificate)
+		}
+		data, err := json.MarshalIndent(request.Certificate, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(*output, append(data, '\n'), 0644)
+
+	case "verify":
+		if *certFile == "" {
+			return fmt.Errorf("-certificate is required")
+		}
+		data, err := os.ReadFile(*certFile)
+		if err != nil {
+			return fmt.Errorf("failed to read certificate: %v", err)
+		}
+		var cert DestructionCertificate
+		if err := json.Unmarshal(data, &cert); err != nil {
+			return fmt.Errorf("failed to parse certificate: %v", err)
+		}
+		if err := db.VerifyDestructionCertificate(&cert); err != nil {
+			return err
+		}
+		fmt.Printf("Certificate %s verified: key version %d destroyed at %s\n", cert.ShredID, cert.KeyVersion, cert.DestroyedAt.Format(time.RFC3339))
+		return nil
+	}
+
+	if *keystore == "" {
+		return fmt.Errorf("-keystore is required")
+	}
+	var keys *Embedded
+	if action == "request" {
+		keys, err = OpenEmbeddedReadOnly(*keystore)
+	} else {
+		keys, err = openExistingEmbedded(*keystore)
+	}
+	if err != nil {
+		return err
+	}
+	defer keys.Close()
+
+	ctx := context.Background()
+	if action == "request" {
+		request, err := db.RequestShred(ctx, keys, *version, *reason, *user, *quorum)
+		if err != nil {
+			return err
+		}
+		if err := printJSON(request); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Nothing was destroyed. %d other user(s) approve with: shred approve -id %s\n", request.Quorum, request.ID)
+		return nil
+	}
+
+	if *id == "" {
+		return fmt.Errorf("-id is required")
+	}
+	request, err := db.ApproveShred(ctx, keys, *id, *user)
+	if request != nil {
+		printJSON(request)
+	}
+	return err
+}
+
+// openExistingEmbedded opens a keystore for writing without creating one
+func openExistingEmbedded(dir string) (*Embedded, error) {
+	if _, err := os.Stat(filepath.Join(dir, EmbeddedKeystoreFile)); err != nil {
+		return nil, fmt.Errorf("cannot open keystore: %v", err)
+	}
+	return NewEmbedded(dir)
+}
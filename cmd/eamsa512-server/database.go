@@ -0,0 +1,1395 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Database Layer
+// Persistence layer for encryption operations, audit logs, and key metadata
+//
+// Uses SQLite3 for simplicity and portability, with migrations for schema management.
+// Implements comprehensive audit logging and operational tracking.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Database represents the EAMSA 512 database connection
+type Database struct {
+	conn       *sql.DB
+	mu         sync.RWMutex
+	logger     *log.Logger
+	dbPath     string
+	maxRetries int
+	pseudonyms *Pseudonymizer // nil stores user IDs and IPs as given
+	lock       *filelock.Lock // nil for read-only and forced opens
+	readOnly   bool
+	auditHead  string // Hash of the last audit_logs row
+}
+
+// OperationRecord represents a single encryption/decryption operation
+type OperationRecord struct {
+	ID              int64      `json:"id"`
+	OperationType   string     `json:"operation_type"`   // "encrypt" or "decrypt"
+	KeyVersion      int        `json:"key_version"`      // Which key was used
+	PlaintextSize   int        `json:"plaintext_size"`   // Size of plaintext
+	CiphertextSize  int        `json:"ciphertext_size"`  // Size of ciphertext
+	Timestamp       time.Time  `json:"timestamp"`        // Operation time
+	Status          string     `json:"status"`           // "success" or "failed"
+	ErrorMessage    string     `json:"error_message"`    // Error details if failed
+	ClientIP        string     `json:"client_ip"`        // Client IP address
+	UserID          string     `json:"user_id"`          // Authenticated user (if available)
+	RequestID       string     `json:"request_id"`       // Unique request identifier
+	DurationMS      int64      `json:"duration_ms"`      // Operation duration in milliseconds
+	ShredID         string     `json:"shred_id,omitempty"`   // Crypto-shred that destroyed the key (see crypto-shred.go)
+	ShreddedAt      *time.Time `json:"shredded_at,omitempty"` // From then on the ciphertext is unrecoverable
+}
+
+// AuditLogEntry represents an audit log entry
+type AuditLogEntry struct {
+	ID        int64      `json:"id"`
+	EventType string     `json:"event_type"`  // "KEY_CREATED", "KEY_ROTATED", "LOGIN", etc.
+	Category  string     `json:"category"`    // "security", "operation", "system", "admin"
+	Severity  string     `json:"severity"`    // "info", "warning", "critical"
+	Details   string     `json:"details"`     // JSON-encoded event details
+	Timestamp time.Time  `json:"timestamp"`   // Event time
+	UserID    string     `json:"user_id"`     // Acting user
+	SourceIP  string     `json:"source_ip"`   // Source IP address
+	PrevHash  string     `json:"prev_hash,omitempty"` // Hash of the previous row (see audit-chain.go)
+	Hash      string     `json:"hash,omitempty"`      // Chain hash of this row
+}
+
+// KeyVersionRecord represents a stored key version record
+type KeyVersionRecord struct {
+	ID              int64          `json:"id"`
+	Version         int            `json:"version"`
+	State           string         `json:"state"`
+	KeyHash         string         `json:"key_hash"` // Standard key identifier (keyid format)
+	CreatedAt       time.Time      `json:"created_at"`
+	ActivatedAt     time.Time      `json:"activated_at"`
+	RotatedAt       time.Time      `json:"rotated_at"`
+	EncryptionCount int64          `json:"encryption_count"`
+	DecryptionCount int64          `json:"decryption_count"`
+	Provenance      *KeyProvenance `json:"provenance,omitempty"` // nil: not recorded
+}
+
+// ComplianceMetrics represents compliance-related metrics
+type ComplianceMetrics struct {
+	TotalEncryptions     int64     `json:"total_encryptions"`
+	TotalDecryptions     int64     `json:"total_decryptions"`
+	FailedOperations     int64     `json:"failed_operations"`
+	KeyRotations         int64     `json:"key_rotations"`
+	SecurityEvents       int64     `json:"security_events"`
+	UnauthorizedAttempts int64     `json:"unauthorized_attempts"`
+	DecryptQuotaDenials  int64     `json:"decrypt_quota_denials"`
+	AverageDurationMS    float64   `json:"average_duration_ms"`
+	Timestamp            time.Time `json:"timestamp"`
+}
+
+// errDatabaseReadOnly is returned by writes to a database opened read-only
+var errDatabaseReadOnly = errors.New("database is open read-only")
+
+// NewDatabase creates a new database connection, holding the database
+// lock until Close
+func NewDatabase(dbPath string) (*Database, error) {
+	return OpenDatabase(dbPath, StoreOpenOptions{})
+}
+
+// OpenDatabase is NewDatabase with a read-only or forced open (see
+// store-lock.go). A read-only database takes no lock and skips migrations.
+func OpenDatabase(dbPath string, opts StoreOpenOptions) (*Database, error) {
+	// Create logger
+	logFile, err := os.OpenFile("/var/log/eamsa512/database.log",
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database log: %v", err)
+	}
+
+	logger := log.New(logFile, "[DATABASE] ", log.LstdFlags|log.Lshortfile)
+
+	lock, err := lockStore(dbPath+".lock", opts, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Open SQLite connection
+	dsn := dbPath
+	if opts.ReadOnly {
+		dsn = "file:" + dbPath + "?mode=ro"
+	}
+	conn, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		lock.Release()
+		return nil, fmt.Errorf("failed to open database: %v", err)
+	}
+
+	// Test connection
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		lock.Release()
+		return nil, fmt.Errorf("failed to ping database: %v", err)
+	}
+
+	// Set connection pool settings
+	conn.SetMaxOpenConns(10)
+	conn.SetMaxIdleConns(5)
+	conn.SetConnMaxLifetime(5 * time.Minute)
+
+	db := &Database{
+		conn:       conn,
+		logger:     logger,
+		dbPath:     dbPath,
+		maxRetries: 3,
+		lock:       lock,
+		readOnly:   opts.ReadOnly,
+	}
+
+	if opts.ReadOnly {
+		logger.Printf("Database opened read-only at %s", dbPath)
+		return db, nil
+	}
+
+	// Run migrations
+	if err := db.runMigrations(); err != nil {
+		conn.Close()
+		lock.Release()
+		return nil, fmt.Errorf("failed to run migrations: %v", err)
+	}
+
+	if err := db.loadAuditHead(); err != nil {
+		conn.Close()
+		lock.Release()
+		return nil, err
+	}
+
+	logger.Printf("Database initialized at %s", dbPath)
+	return db, nil
+}
+
+// runMigrations creates necessary tables if they don't exist
+func (db *Database) runMigrations() error {
+	// Create tables
+	schemas := []string{
+		// Operations table
+		`CREATE TABLE IF NOT EXISTS operations (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			operation_type TEXT NOT NULL,
+			key_version INTEGER NOT NULL,
+			plaintext_size INTEGER,
+			ciphertext_size INTEGER,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			status TEXT NOT NULL,
+			error_message TEXT,
+			client_ip TEXT,
+			user_id TEXT,
+			request_id TEXT UNIQUE,
+			duration_ms INTEGER,
+			FOREIGN KEY(key_version) REFERENCES key_versions(version)
+		)`,
+
+		// Audit log table
+		`CREATE TABLE IF NOT EXISTS audit_logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			event_type TEXT NOT NULL,
+			category TEXT NOT NULL,
+			severity TEXT NOT NULL,
+			details TEXT,
+			timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+			user_id TEXT,
+			source_ip TEXT
+		)`,
+
+		// Key versions table
+		`CREATE TABLE IF NOT EXISTS key_versions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			version INTEGER UNIQUE NOT NULL,
+			state TEXT NOT NULL,
+			key_hash TEXT,
+			created_at DATETIME,
+			activated_at DATETIME,
+			rotated_at DATETIME,
+			encryption_count INTEGER DEFAULT 0,
+			decryption_count INTEGER DEFAULT 0,
+			provenance TEXT
+		)`,
+
+		// Wrapped keys table (DEKs wrapped under the current KEK)
+		`CREATE TABLE IF NOT EXISTS wrapped_keys (
+			id TEXT PRIMARY KEY,
+			wrapped_key TEXT NOT NULL,
+			kek_id TEXT,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Column re-encryption checkpoints (last_key has no type so it
+		// keeps the storage class of the source table's key column)
+		`CREATE TABLE IF NOT EXISTS reencrypt_checkpoints (
+			job_id TEXT PRIMARY KEY,
+			table_name TEXT NOT NULL,
+			old_version INTEGER NOT NULL,
+			new_version INTEGER NOT NULL,
+			last_key,
+			rows_done INTEGER DEFAULT 0,
+			status TEXT NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Orphan GC reports awaiting or past approval
+		`CREATE TABLE IF NOT EXISTS gc_reports (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			requested_by TEXT NOT NULL,
+			report TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Crypto-shred requests and their destruction certificates
+		`CREATE TABLE IF NOT EXISTS shred_requests (
+			id TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			key_version INTEGER NOT NULL,
+			requested_by TEXT NOT NULL,
+			request TEXT NOT NULL,
+			created_at DATETIME,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Sessions table
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			session_id TEXT UNIQUE NOT NULL,
+			user_id TEXT NOT NULL,
+			ip_address TEXT,
+			user_agent TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_activity DATETIME DEFAULT CURRENT_TIMESTAMP,
+			expires_at DATETIME,
+			is_active BOOLEAN DEFAULT 1
+		)`,
+
+		// Nonce manager high-water marks (see SaveNonceState)
+		`CREATE TABLE IF NOT EXISTS nonce_state (
+			name TEXT PRIMARY KEY,
+			prefix BLOB NOT NULL,
+			high_water INTEGER NOT NULL,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Users table
+		`CREATE TABLE IF NOT EXISTS users (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT UNIQUE NOT NULL,
+			username TEXT UNIQUE NOT NULL,
+			role TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_login DATETIME,
+			is_active BOOLEAN DEFAULT 1
+		)`,
+	}
+
+	for _, schema := range schemas {
+		if _, err := db.conn.Exec(schema); err != nil {
+			return fmt.Errorf("failed to create table: %v", err)
+		}
+	}
+
+	// Columns added after the first release
+	if err := db.addColumnIfMissing("key_versions", "provenance", "TEXT"); err != nil {
+		return err
+	}
+	if err := db.addColumnIfMissing("audit_logs", "prev_hash", "TEXT"); err != nil {
+		return err
+	}
+	if err := db.addColumnIfMissing("audit_logs", "hash", "TEXT"); err != nil {
+		return err
+	}
+	if err := db.addColumnIfMissing("operations", "shred_id", "TEXT"); err != nil {
+		return err
+	}
+	if err := db.addColumnIfMissing("operations", "shredded_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Create indexes for performance
+	indexes := []string{
+		`CREATE INDEX IF NOT EXISTS idx_operations_timestamp ON operations(timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_operations_key_version ON operations(key_version)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_logs_timestamp ON audit_logs(timestamp DESC)`,
+		`CREATE INDEX IF NOT EXISTS idx_audit_logs_category ON audit_logs(category)`,
+		`CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_key_versions_state ON key_versions(state)`,
+		`CREATE INDEX IF NOT EXISTS idx_wrapped_keys_kek_id ON wrapped_keys(kek_id)`,
+	}
+
+	for _, idx := range indexes {
+		if _, err := db.conn.Exec(idx); err != nil {
+			return fmt.Errorf("failed to create index: %v", err)
+		}
+	}
+
+	db.logger.Printf("Migrations completed successfully")
+	return nil
+}
+
+// addColumnIfMissing adds a column to a table created by an earlier release
+func (db *Database) addColumnIfMissing(table, column, definition string) error {
+	rows, err := db.conn.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %v", table, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, primaryKey int
+		var name, columnType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &primaryKey); err != nil {
+			return fmt.Errorf("failed to inspect %s: %v", table, err)
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to inspect %s: %v", table, err)
+	}
+
+	if _, err := db.conn.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition)); err != nil {
+		return fmt.Errorf("failed to add %s.%s: %v", table, column, err)
+	}
+	return nil
+}
+
+// SetPseudonymizer pseudonymizes user IDs and IPs in the operations and
+// audit_logs tables from now on (nil turns it off)
+func (db *Database) SetPseudonymizer(p *Pseudonymizer) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.pseudonyms = p
+}
+
+// pseudonymize returns the stored form of a user ID and IP address
+func (db *Database) pseudonymize(userID, ip string) (string, string) {
+	if db.pseudonyms == nil {
+		return userID, ip
+	}
+	return db.pseudonyms.Pseudonym(PseudonymUser, userID), db.pseudonyms.PseudonymizeIP(ip)
+}
+
+// exec runs a write statement. The db-write failpoint fails it before it
+// reaches SQLite.
+func (db *Database) exec(query string, args ...interface{}) (sql.Result, error) {
+	if err := failpoint.Inject(failpoint.DBWrite); err != nil {
+		return nil, err
+	}
+	return db.conn.Exec(query, args...)
+}
+
+// ============================================================================
+// Operation Recording
+// ============================================================================
+
+// RecordOperation records an encryption or decryption operation
+func (db *Database) RecordOperation(op OperationRecord) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `INSERT INTO operations 
+		(operation_type, key_version, plaintext_size, ciphertext_size, 
+		 timestamp, status, error_message, client_ip, user_id, request_id, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	userID, clientIP := db.pseudonymize(op.UserID, op.ClientIP)
+	result, err := db.exec(query,
+		op.OperationType, op.KeyVersion, op.PlaintextSize, op.CiphertextSize,
+		op.Timestamp, op.Status, op.ErrorMessage, clientIP, userID,
+		op.RequestID, op.DurationMS)
+
+	if err != nil {
+		db.logger.Printf("Failed to record operation: %v", err)
+		return fmt.Errorf("failed to record operation: %v", err)
+	}
+
+	id, _ := result.LastInsertId()
+	db.logger.Printf("Operation recorded: id=%d type=%s status=%s", id, op.OperationType, op.Status)
+	return nil
+}
+
+// GetOperations retrieves recent operations with optional filtering
+func (db *Database) GetOperations(limit int, offset int) ([]OperationRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, operation_type, key_version, plaintext_size, ciphertext_size,
+		         timestamp, status, error_message, client_ip, user_id, request_id, duration_ms,
+		         shred_id, shredded_at
+		 FROM operations
+		 ORDER BY timestamp DESC
+		 LIMIT ? OFFSET ?`
+
+	rows, err := db.conn.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %v", err)
+	}
+	defer rows.Close()
+
+	return scanOperations(rows)
+}
+
+// scanOperations reads operation rows selected with the columns of
+// GetOperations
+func scanOperations(rows *sql.Rows) ([]OperationRecord, error) {
+	operations := make([]OperationRecord, 0)
+	for rows.Next() {
+		var op OperationRecord
+		var shredID sql.NullString
+		var shreddedAt sql.NullTime
+		err := rows.Scan(&op.ID, &op.OperationType, &op.KeyVersion, &op.PlaintextSize,
+			&op.CiphertextSize, &op.Timestamp, &op.Status, &op.ErrorMessage,
+			&op.ClientIP, &op.UserID, &op.RequestID, &op.DurationMS, &shredID, &shreddedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan operation: %v", err)
+		}
+		op.ShredID = shredID.String
+		if shreddedAt.Valid {
+			op.ShreddedAt = &shreddedAt.Time
+		}
+		operations = append(operations, op)
+	}
+
+	return operations, rows.Err()
+}
+
+// GetOperationsByKeyVersion retrieves operations for a specific key version
+func (db *Database) GetOperationsByKeyVersion(keyVersion int) ([]OperationRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, operation_type, key_version, plaintext_size, ciphertext_size,
+		         timestamp, status, error_message, client_ip, user_id, request_id, duration_ms,
+		         shred_id, shredded_at
+		 FROM operations
+		 WHERE key_version = ?
+		 ORDER BY timestamp DESC`
+
+	rows, err := db.conn.Query(query, keyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query operations: %v", err)
+	}
+	defer rows.Close()
+
+	return scanOperations(rows)
+}
+
+// ============================================================================
+// Audit Logging
+// ============================================================================
+
+// RecordAuditLog records an audit event
+func (db *Database) RecordAuditLog(entry AuditLogEntry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `INSERT INTO audit_logs 
+		(event_type, category, severity, details, timestamp, user_id, source_ip, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	entry.UserID, entry.SourceIP = db.pseudonymize(entry.UserID, entry.SourceIP)
+	entry = db.chainAuditEntry(db.auditHead, entry)
+	result, err := db.exec(query,
+		entry.EventType, entry.Category, entry.Severity, entry.Details,
+		entry.Timestamp, entry.UserID, entry.SourceIP, entry.PrevHash, entry.Hash)
+
+	if err != nil {
+		db.logger.Printf("Failed to record audit log: %v", err)
+		return fmt.Errorf("failed to record audit log: %v", err)
+	}
+	db.auditHead = entry.Hash
+
+	id, _ := result.LastInsertId()
+	db.logger.Printf("Audit log recorded: id=%d event=%s severity=%s", id, entry.EventType, entry.Severity)
+	return nil
+}
+
+// WriteAuditBatch inserts a batch of audit log entries in one transaction
+// Implements AuditSink for the async audit queue
+func (db *Database) WriteAuditBatch(entries []AuditLogEntry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if err := failpoint.Inject(failpoint.DBWrite); err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	stmt, err := tx.Prepare(`INSERT INTO audit_logs
+		(event_type, category, severity, details, timestamp, user_id, source_ip, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to prepare audit insert: %v", err)
+	}
+	defer stmt.Close()
+
+	// The head only moves once the whole batch is committed
+	head := db.auditHead
+	for _, entry := range entries {
+		entry.UserID, entry.SourceIP = db.pseudonymize(entry.UserID, entry.SourceIP)
+		entry = db.chainAuditEntry(head, entry)
+		_, err := stmt.Exec(entry.EventType, entry.Category, entry.Severity, entry.Details,
+			entry.Timestamp, entry.UserID, entry.SourceIP, entry.PrevHash, entry.Hash)
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record audit log: %v", err)
+		}
+		head = entry.Hash
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit audit batch: %v", err)
+	}
+	db.auditHead = head
+
+	db.logger.Printf("Audit batch recorded: %d entries", len(entries))
+	return nil
+}
+
+// GetAuditLogs retrieves recent audit log entries
+func (db *Database) GetAuditLogs(limit int, offset int) ([]AuditLogEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, event_type, category, severity, details, timestamp, user_id, source_ip, prev_hash, hash
+		 FROM audit_logs
+		 ORDER BY timestamp DESC
+		 LIMIT ? OFFSET ?`
+
+	rows, err := db.conn.Query(query, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// GetAuditLogsByCategory retrieves audit logs by category
+func (db *Database) GetAuditLogsByCategory(category string, limit int) ([]AuditLogEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, event_type, category, severity, details, timestamp, user_id, source_ip, prev_hash, hash
+		 FROM audit_logs
+		 WHERE category = ?
+		 ORDER BY timestamp DESC
+		 LIMIT ?`
+
+	rows, err := db.conn.Query(query, category, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer rows.Close()
+
+	return scanAuditLogs(rows)
+}
+
+// AuditLogFilter selects audit rows for QueryAuditLogs; zero fields match
+// every row
+type AuditLogFilter struct {
+	AfterID   int64 // Rows with a larger ID only
+	Since     time.Time
+	Until     time.Time
+	EventType string
+	Category  string
+	Severity  string
+	UserID    string // As stored (pseudonymized if enabled)
+	Contains  string // Substring of the details JSON
+	Limit     int    // Most rows returned (0: all)
+	Newest    bool   // With Limit, return the newest rows rather than the oldest
+}
+
+// QueryAuditLogs returns the audit rows matching filter in ID order
+func (db *Database) QueryAuditLogs(filter AuditLogFilter) ([]AuditLogEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, event_type, category, severity, details, timestamp, user_id, source_ip, prev_hash, hash
+		 FROM audit_logs WHERE id > ?`
+	args := []interface{}{filter.AfterID}
+
+	if !filter.Since.IsZero() {
+		query += " AND timestamp >= ?"
+		args = append(args, filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query += " AND timestamp < ?"
+		args = append(args, filter.Until)
+	}
+	if filter.EventType != "" {
+		query += " AND event_type = ?"
+		args = append(args, filter.EventType)
+	}
+	if filter.Category != "" {
+		query += " AND category = ?"
+		args = append(args, filter.Category)
+	}
+	if filter.Severity != "" {
+		query += " AND severity = ?"
+		args = append(args, filter.Severity)
+	}
+	if filter.UserID != "" {
+		query += " AND user_id = ?"
+		args = append(args, filter.UserID)
+	}
+	if filter.Contains != "" {
+		query += " AND instr(details, ?) > 0"
+		args = append(args, filter.Contains)
+	}
+
+	if filter.Newest {
+		query += " ORDER BY id DESC"
+	} else {
+		query += " ORDER BY id"
+	}
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %v", err)
+	}
+	defer rows.Close()
+
+	logs, err := scanAuditLogs(rows)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Newest {
+		for i, j := 0, len(logs)-1; i < j; i, j = i+1, j-1 {
+			logs[i], logs[j] = logs[j], logs[i]
+		}
+	}
+	return logs, nil
+}
+
+// scanAuditLogs reads audit rows selected with the prev_hash and hash
+// columns; both are NULL in rows written before chaining
+func scanAuditLogs(rows *sql.Rows) ([]AuditLogEntry, error) {
+	logs := make([]AuditLogEntry, 0)
+	for rows.Next() {
+		var log AuditLogEntry
+		var prevHash, hash sql.NullString
+		err := rows.Scan(&log.ID, &log.EventType, &log.Category, &log.Severity,
+			&log.Details, &log.Timestamp, &log.UserID, &log.SourceIP, &prevHash, &hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit log: %v", err)
+		}
+		log.PrevHash, log.Hash = prevHash.String, hash.String
+		logs = append(logs, log)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan audit log: %v", err)
+	}
+
+	return logs, nil
+}
+
+// chainAuditEntry sets the prev_hash and hash of an entry about to be
+// stored after head
+func (db *Database) chainAuditEntry(head string, entry AuditLogEntry) AuditLogEntry {
+	entry.PrevHash = head
+	entry.Hash = auditChainHash(head, entry)
+	return entry
+}
+
+// loadAuditHead reads the hash of the last audit row, where the chain
+// continues
+func (db *Database) loadAuditHead() error {
+	var head sql.NullString
+	err := db.conn.QueryRow(`SELECT hash FROM audit_logs ORDER BY id DESC LIMIT 1`).Scan(&head)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("failed to read audit chain head: %v", err)
+	}
+	db.auditHead = head.String
+	return nil
+}
+
+// ============================================================================
+// Key Version Tracking
+// ============================================================================
+
+// RecordKeyVersion records a new key version
+func (db *Database) RecordKeyVersion(kvr KeyVersionRecord) error {
+	// Store key identifiers in canonical form only
+	if kvr.KeyHash != "" {
+		normalized, err := keyid.Normalize(kvr.KeyHash)
+		if err != nil {
+			return fmt.Errorf("invalid key hash for version %d: %v", kvr.Version, err)
+		}
+		kvr.KeyHash = normalized
+	}
+
+	var provenance sql.NullString
+	if kvr.Provenance != nil {
+		data, err := json.Marshal(kvr.Provenance)
+		if err != nil {
+			return fmt.Errorf("failed to encode provenance for version %d: %v", kvr.Version, err)
+		}
+		provenance = sql.NullString{String: string(data), Valid: true}
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `INSERT OR REPLACE INTO key_versions 
+		(version, state, key_hash, created_at, activated_at, rotated_at, 
+		 encryption_count, decryption_count, provenance)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	result, err := db.exec(query,
+		kvr.Version, kvr.State, kvr.KeyHash, kvr.CreatedAt, kvr.ActivatedAt,
+		kvr.RotatedAt, kvr.EncryptionCount, kvr.DecryptionCount, provenance)
+
+	if err != nil {
+		db.logger.Printf("Failed to record key version: %v", err)
+		return fmt.Errorf("failed to record key version: %v", err)
+	}
+
+	id, _ := result.LastInsertId()
+	db.logger.Printf("Key version recorded: id=%d version=%d state=%s", id, kvr.Version, kvr.State)
+	return nil
+}
+
+// GetKeyVersions retrieves all key versions
+func (db *Database) GetKeyVersions() ([]KeyVersionRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, version, state, key_hash, created_at, activated_at, 
+		         rotated_at, encryption_count, decryption_count, provenance
+		 FROM key_versions
+		 ORDER BY version DESC`
+
+	rows, err := db.conn.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query key versions: %v", err)
+	}
+	defer rows.Close()
+
+	versions := make([]KeyVersionRecord, 0)
+	for rows.Next() {
+		var kvr KeyVersionRecord
+		var provenance sql.NullString
+		err := rows.Scan(&kvr.ID, &kvr.Version, &kvr.State, &kvr.KeyHash,
+			&kvr.CreatedAt, &kvr.ActivatedAt, &kvr.RotatedAt,
+			&kvr.EncryptionCount, &kvr.DecryptionCount, &provenance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan key version: %v", err)
+		}
+		if kvr.Provenance, err = decodeProvenance(provenance); err != nil {
+			return nil, fmt.Errorf("invalid provenance for version %d: %v", kvr.Version, err)
+		}
+		versions = append(versions, kvr)
+	}
+
+	return versions, nil
+}
+
+// GetActiveKeyVersion retrieves the active key version
+func (db *Database) GetActiveKeyVersion() (*KeyVersionRecord, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT id, version, state, key_hash, created_at, activated_at,
+		         rotated_at, encryption_count, decryption_count, provenance
+		 FROM key_versions
+		 WHERE state = 'active'
+		 ORDER BY version DESC
+		 LIMIT 1`
+
+	var kvr KeyVersionRecord
+	var provenance sql.NullString
+	err := db.conn.QueryRow(query).Scan(&kvr.ID, &kvr.Version, &kvr.State, &kvr.KeyHash,
+		&kvr.CreatedAt, &kvr.ActivatedAt, &kvr.RotatedAt,
+		&kvr.EncryptionCount, &kvr.DecryptionCount, &provenance)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active key version: %v", err)
+	}
+	if kvr.Provenance, err = decodeProvenance(provenance); err != nil {
+		return nil, fmt.Errorf("invalid provenance for version %d: %v", kvr.Version, err)
+	}
+
+	return &kvr, nil
+}
+
+// decodeProvenance decodes the provenance column; NULL is nil
+func decodeProvenance(column sql.NullString) (*KeyProvenance, error) {
+	if !column.Valid || column.String == "" {
+		return nil, nil
+	}
+
+	var provenance KeyProvenance
+	if err := json.Unmarshal([]byte(column.String), &provenance); err != nil {
+		return nil, err
+	}
+	return &provenance, nil
+}
+
+// GetKeyVersionByHash retrieves the key version matching a key identifier
+// Accepts canonical and legacy (bare hex) identifiers
+func (db *Database) GetKeyVersionByHash(keyHash string) (*KeyVersionRecord, error) {
+	id, err := keyid.Parse(keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	versions, err := db.GetKeyVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range versions {
+		storedID, err := keyid.Parse(versions[i].KeyHash)
+		if err != nil {
+			continue
+		}
+		if storedID.Equal(id) {
+			return &versions[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// UpdateKeyVersionCounts updates encryption/decryption counts
+func (db *Database) UpdateKeyVersionCounts(version int, encCount, decCount int64) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `UPDATE key_versions 
+		 SET encryption_count = ?, decryption_count = ?
+		 WHERE version = ?`
+
+	_, err := db.exec(query, encCount, decCount, version)
+	if err != nil {
+		return fmt.Errorf("failed to update key version counts: %v", err)
+	}
+
+	return nil
+}
+
+// ============================================================================
+// Wrapped Key Storage
+// ============================================================================
+
+// StoreWrappedKey stores or replaces a KEK-wrapped DEK
+func (db *Database) StoreWrappedKey(entry WrappedKeyEntry) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `INSERT OR REPLACE INTO wrapped_keys (id, wrapped_key, kek_id, updated_at)
+		VALUES (?, ?, ?, ?)`
+
+	if _, err := db.exec(query, entry.ID, entry.WrappedKey, entry.KEKID, time.Now()); err != nil {
+		db.logger.Printf("Failed to store wrapped key: %v", err)
+		return fmt.Errorf("failed to store wrapped key: %v", err)
+	}
+
+	db.logger.Printf("Wrapped key stored: id=%s kek=%s", entry.ID, entry.KEKID)
+	return nil
+}
+
+// GetWrappedKeys retrieves all wrapped keys
+func (db *Database) GetWrappedKeys() ([]WrappedKeyEntry, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	rows, err := db.conn.Query(`SELECT id, wrapped_key, kek_id FROM wrapped_keys ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wrapped keys: %v", err)
+	}
+	defer rows.Close()
+
+	entries := make([]WrappedKeyEntry, 0)
+	for rows.Next() {
+		var entry WrappedKeyEntry
+		var kekID sql.NullString
+		if err := rows.Scan(&entry.ID, &entry.WrappedKey, &kekID); err != nil {
+			return nil, fmt.Errorf("failed to scan wrapped key: %v", err)
+		}
+		entry.KEKID = kekID.String
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// ============================================================================
+// Nonce State
+// ============================================================================
+
+// Database is an eamsa512.NonceStore, so a NonceManager can keep its
+// high-water mark next to the key metadata
+var _ eamsa512.NonceStore = (*Database)(nil)
+
+// LoadNonceState returns the nonce manager state saved under name
+func (db *Database) LoadNonceState(name string) (eamsa512.NonceState, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var state eamsa512.NonceState
+	var prefix []byte
+	var highWater int64
+	err := db.conn.QueryRow(`SELECT prefix, high_water FROM nonce_state WHERE name = ?`, name).Scan(&prefix, &highWater)
+	if err == sql.ErrNoRows {
+		return state, eamsa512.ErrNonceStateMissing
+	}
+	if err != nil {
+		return state, fmt.Errorf("failed to load nonce state: %v", err)
+	}
+	if len(prefix) != len(state.Prefix) || highWater < 0 {
+		return state, fmt.Errorf("corrupt nonce state for %s", name)
+	}
+
+	copy(state.Prefix[:], prefix)
+	state.HighWater = uint64(highWater)
+	return state, nil
+}
+
+// SaveNonceState replaces the nonce manager state under name with next if
+// it is still prev (nil: if none is saved). The compare and the write are
+// one statement, so two processes cannot both advance from the same state.
+func (db *Database) SaveNonceState(name string, prev *eamsa512.NonceState, next eamsa512.NonceState) error {
+	if db.readOnly {
+		return errDatabaseReadOnly
+	}
+	if next.HighWater > math.MaxInt64 {
+		return fmt.Errorf("nonce high-water mark %d does not fit the database", next.HighWater)
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var result sql.Result
+	var err error
+	if prev == nil {
+		result, err = db.exec(`INSERT OR IGNORE INTO nonce_state (name, prefix, high_water, updated_at)
+			VALUES (?, ?, ?, ?)`, name, next.Prefix[:], int64(next.HighWater), time.Now())
+	} else {
+		result, err = db.exec(`UPDATE nonce_state SET prefix = ?, high_water = ?, updated_at = ?
+			WHERE name = ? AND prefix = ? AND high_water = ?`,
+			next.Prefix[:], int64(next.HighWater), time.Now(), name, prev.Prefix[:], int64(prev.HighWater))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save nonce state: %v", err)
+	}
+
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to save nonce state: %v", err)
+	} else if n != 1 {
+		return eamsa512.ErrNonceStateConflict
+	}
+	return nil
+}
+
+// RewrapStoredKeys re-wraps every stored DEK from oldKEK to newKEK in one
+// transaction. If any entry fails, nothing is written so the keystore never
+// holds a mix of KEKs. With dryRun, entries are verified but not updated.
+// Returns the number of entries re-wrapped and any per-entry failures.
+func (db *Database) RewrapStoredKeys(oldKEK, newKEK []byte, dryRun bool) (int, []RewrapFailure, error) {
+	entries, err := db.GetWrappedKeys()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rewrapped, failed := RewrapKeys(entries, oldKEK, newKEK)
+	if len(failed) > 0 {
+		db.logger.Printf("Key re-wrap aborted: %d of %d entries failed", len(failed), len(entries))
+		return 0, failed, fmt.Errorf("re-wrap aborted: %d of %d entries failed", len(failed), len(entries))
+	}
+
+	if dryRun {
+		return len(rewrapped), nil, nil
+	}
+	if db.readOnly {
+		return 0, nil, errDatabaseReadOnly
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %v", err)
+	}
+
+	now := time.Now()
+	for _, entry := range rewrapped {
+		_, err := tx.Exec(`UPDATE wrapped_keys SET wrapped_key = ?, kek_id = ?, updated_at = ? WHERE id = ?`,
+			entry.WrappedKey, entry.KEKID, now, entry.ID)
+		if err != nil {
+			tx.Rollback()
+			return 0, nil, fmt.Errorf("failed to update wrapped key %s: %v", entry.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit re-wrap: %v", err)
+	}
+
+	db.logger.Printf("Key re-wrap completed: %d entries kek=%s", len(rewrapped), keyid.New(newKEK))
+	return len(rewrapped), nil, nil
+}
+
+// ============================================================================
+// Compliance Metrics
+// ============================================================================
+
+// GetComplianceMetrics calculates and returns compliance metrics
+func (db *Database) GetComplianceMetrics() (ComplianceMetrics, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	metrics := ComplianceMetrics{
+		Timestamp: time.Now(),
+	}
+
+	// Count operations
+	query := `SELECT 
+		SUM(CASE WHEN operation_type = 'encrypt' THEN 1 ELSE 0 END) as encryptions,
+		SUM(CASE WHEN operation_type = 'decrypt' THEN 1 ELSE 0 END) as decryptions,
+		SUM(CASE WHEN status = 'failed' THEN 1 ELSE 0 END) as failures,
+		AVG(CASE WHEN duration_ms > 0 THEN duration_ms ELSE NULL END) as avg_duration
+		FROM operations`
+
+	err := db.conn.QueryRow(query).Scan(&metrics.TotalEncryptions, &metrics.TotalDecryptions,
+		&metrics.FailedOperations, &metrics.AverageDurationMS)
+	if err != nil && err != sql.ErrNoRows {
+		return metrics, fmt.Errorf("failed to query metrics: %v", err)
+	}
+
+	// Count audit events
+	auditQuery := `SELECT 
+		SUM(CASE WHEN event_type LIKE 'KEY_%' THEN 1 ELSE 0 END) as rotations,
+		SUM(CASE WHEN category = 'security' THEN 1 ELSE 0 END) as security_events,
+		SUM(CASE WHEN severity = 'critical' THEN 1 ELSE 0 END) as unauthorized,
+		SUM(CASE WHEN event_type = 'DECRYPT_QUOTA_EXCEEDED' THEN 1 ELSE 0 END) as quota_denials
+		FROM audit_logs`
+
+	err = db.conn.QueryRow(auditQuery).Scan(&metrics.KeyRotations, &metrics.SecurityEvents,
+		&metrics.UnauthorizedAttempts, &metrics.DecryptQuotaDenials)
+	if err != nil && err != sql.ErrNoRows {
+		return metrics, fmt.Errorf("failed to query audit metrics: %v", err)
+	}
+
+	return metrics, nil
+}
+
+// ============================================================================
+// Session Management
+// ============================================================================
+
+// CreateSession creates a new session
+func (db *Database) CreateSession(sessionID, userID, ipAddress, userAgent string, expiresAt time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `INSERT INTO sessions 
+		(session_id, user_id, ip_address, user_agent, expires_at)
+		VALUES (?, ?, ?, ?, ?)`
+
+	_, err := db.conn.Exec(query, sessionID, userID, ipAddress, userAgent, expiresAt.UTC())
+	if err != nil {
+		return fmt.Errorf("failed to create session: %v", err)
+	}
+
+	db.logger.Printf("Session created: sessionID=%s userID=%s", sessionID, userID)
+	return nil
+}
+
+// ValidateSession validates an active session
+// Expiry is checked against the trusted clock rather than SQLite's
+func (db *Database) ValidateSession(sessionID string) (string, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	query := `SELECT user_id FROM sessions 
+		 WHERE session_id = ? AND is_active = 1 AND expires_at > ?`
+
+	var userID string
+	err := db.conn.QueryRow(query, sessionID, trustedClock.Now().UTC()).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("invalid or expired session")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to validate session: %v", err)
+	}
+
+	// Update last activity
+	updateQuery := `UPDATE sessions SET last_activity = datetime('now') WHERE session_id = ?`
+	db.conn.Exec(updateQuery, sessionID)
+
+	return userID, nil
+}
+
+// EndSession terminates a session
+func (db *Database) EndSession(sessionID string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	query := `UPDATE sessions SET is_active = 0 WHERE session_id = ?`
+	_, err := db.conn.Exec(query, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to end session: %v", err)
+	}
+
+	db.logger.Printf("Session ended: sessionID=%s", sessionID)
+	return nil
+}
+
+// ============================================================================
+// Maintenance and Cleanup
+// ============================================================================
+
+// PruneResult counts the records removed, or that would be removed, by
+// PruneOldRecords
+type PruneResult struct {
+	Cutoff     time.Time `json:"cutoff"`
+	Operations int64     `json:"operations"`
+	AuditLogs  int64     `json:"audit_logs"`
+	DryRun     bool      `json:"dry_run,omitempty"`
+}
+
+// PruneOldRecords removes old operation and audit log records
+// With dryRun, the records are counted but not deleted
+func (db *Database) PruneOldRecords(daysToKeep int, dryRun bool) (*PruneResult, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	cutoffDate := time.Now().AddDate(0, 0, -daysToKeep)
+	result := &PruneResult{Cutoff: cutoffDate, DryRun: dryRun}
+
+	if dryRun {
+		err := db.conn.QueryRow(`SELECT
+			(SELECT COUNT(*) FROM operations WHERE timestamp < ?),
+			(SELECT COUNT(*) FROM audit_logs WHERE timestamp < ?)`, cutoffDate, cutoffDate).
+			Scan(&result.Operations, &result.AuditLogs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count prunable records: %v", err)
+		}
+		return result, nil
+	}
+	if db.readOnly {
+		return nil, errDatabaseReadOnly
+	}
+
+	// Delete old operations
+	query1 := `DELETE FROM operations WHERE timestamp < ?`
+	result1, err := db.conn.Exec(query1, cutoffDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune operations: %v", err)
+	}
+
+	result.Operations, _ = result1.RowsAffected()
+
+	// Delete old audit logs
+	query2 := `DELETE FROM audit_logs WHERE timestamp < ?`
+	result2, err := db.conn.Exec(query2, cutoffDate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prune audit logs: %v", err)
+	}
+
+	result.AuditLogs, _ = result2.RowsAffected()
+
+	db.logger.Printf("Pruned records: operations=%d auditLogs=%d cutoffDate=%s",
+		result.Operations, result.AuditLogs, cutoffDate.Format(time.RFC3339))
+
+	return result, nil
+}
+
+// RunPruneCommand implements the "prune" subcommand:
+//
+//	eamsa512-server prune -days 90 [-db path] [-dry-run] [-force]
+func RunPruneCommand(args []string) error {
+	fs := flag.NewFlagSet("prune", flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to database")
+	days := fs.Int("days", 0, "Keep records newer than this many days")
+	dryRun := fs.Bool("dry-run", false, "Count records that would be pruned without deleting")
+	force := fs.Bool("force", false, "Open the database even if another process holds its lock (audited)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("-days must be positive")
+	}
+
+	// A dry run only reads, so it does not wait for the lock holder
+	db, err := OpenDatabase(*dbPath, StoreOpenOptions{ReadOnly: *dryRun, Force: *force})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.PruneOldRecords(*days, *dryRun)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+// Vacuum optimizes the database
+func (db *Database) Vacuum() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	_, err := db.conn.Exec("VACUUM")
+	if err != nil {
+		return fmt.Errorf("failed to vacuum database: %v", err)
+	}
+
+	db.logger.Printf("Database vacuumed")
+	return nil
+}
+
+// Close closes the database connection
+func (db *Database) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.conn != nil {
+		err := db.conn.Close()
+		db.logger.Printf("Database connection closed")
+		if lockErr := db.lock.Release(); err == nil {
+			err = lockErr
+		}
+		db.lock = nil
+		return err
+	}
+
+	return nil
+}
+
+// ============================================================================
+// Export Functions
+// ============================================================================
+
+// ExportOperationsJSON exports operations as JSON
+func (db *Database) ExportOperationsJSON(limit int) (string, error) {
+	ops, err := db.GetOperations(limit, 0)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(ops, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	return string(data), nil
+}
+
+// ExportAuditLogsJSON exports audit logs as JSON
+func (db *Database) ExportAuditLogsJSON(limit int) (string, error) {
+	logs, err := db.GetAuditLogs(limit, 0)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(logs, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal JSON: %v", err)
+	}
+
+	return string(data), nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. DATABASE SCHEMA
+   - operations: Records all encrypt/decrypt operations
+   - audit_logs: Security and system events
+   - key_versions: Key lifecycle tracking, with provenance (origin,
+     external provider and wrapping key) for imported keys
+   - nonce_state: NonceManager high-water marks (see 6)
+   - sessions: User session management
+   - users: User account information
+
+2. INDEXES
+   - Created on timestamp, key_version, category for performance
+   - Enables efficient querying of large datasets
+
+3. THREAD SAFETY
+   - RWMutex protects all database operations
+   - Multiple readers, single writer pattern
+
+4. AUDIT TRAIL
+   - Complete record of all cryptographic operations
+   - Timestamps for all events
+   - User and IP tracking
+   - Operation status and duration
+
+5. COMPLIANCE
+   - FIPS 140-2 audit logging requirements
+   - Comprehensive event tracking
+   - Tamper evidence (timestamps)
+   - Data retention policies
+
+6. NONCE STATE
+   - nonce_state keeps the high-water mark of each eamsa512.NonceManager
+     saved here: the random prefix and the first counter not yet reserved
+   - Updates are compare-and-swap on the previous mark; a row that was
+     rolled back or advanced by another process fails with
+     ErrNonceStateConflict and the manager stops issuing nonces
+   - PruneOldRecords never touches it; deleting a row makes the manager
+     refuse to recover until it is initialized with a new prefix
+
+7. MAINTENANCE
+   - PruneOldRecords: Remove records older than N days (dryRun counts them)
+   - Vacuum: Optimize database size
+   - Connection pooling for performance
+   - Automatic schema migration on startup
+
+8. PRODUCTION CONSIDERATIONS
+   - Use external database (PostgreSQL) for HA
+   - Implement replication for backup
+   - Set up automated backups
+   - Monitor database size and performance
+   - Regular pruning of old records
+   - Encryption at rest for sensitive data
+
+*/
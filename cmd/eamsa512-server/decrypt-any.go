@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+
+	"github.com/Redeaux-Corporation/eamsa512/format"
+)
+
+// ============================================================================
+// EAMSA 512 - Multi-Key Decrypt
+// Decrypt with whichever of several candidate keys the data was sealed under
+//
+// Consumers holding several keys (old and new after a rotation, one per
+// tenant) can pass them all to DecryptWithAny. Containers are matched by
+// their header tag, a short HMAC per candidate, so the body is read once.
+// Bare EncryptData output has no header: the body tag is computed for all
+// candidates in a single pass and only the matching key decrypts.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// ErrNoMatchingKey is returned when no candidate key authenticates the data
+var ErrNoMatchingKey = errors.New("no candidate key matches")
+
+// anyTagChunkSize is how much of the body each candidate tag consumes in turn
+const anyTagChunkSize = 64 * 1024
+
+// DecryptWithAny decrypts a container or EncryptData output with the
+// candidate key that authenticates it. Returns the plaintext and the
+// index of that key in keys.
+func DecryptWithAny(keys [][]byte, data []byte) ([]byte, int, error) {
+	if len(keys) == 0 {
+		return nil, -1, fmt.Errorf("no candidate keys")
+	}
+	for i, key := range keys {
+		if len(key) != KeySize {
+			return nil, -1, fmt.Errorf("invalid master key size for candidate %d: expected %d, got %d", i, KeySize, len(key))
+		}
+	}
+
+	var index int
+	var err error
+	if IsContainer(data) {
+		index, err = matchContainerKey(keys, data)
+	} else {
+		index, err = matchBodyKey(keys, data)
+	}
+	if err != nil {
+		return nil, -1, err
+	}
+
+	var plaintext []byte
+	if IsContainer(data) {
+		plaintext, _, err = OpenContainer(data, keys[index])
+	} else {
+		plaintext, err = DecryptData(data, keys[index])
+	}
+	if err != nil {
+		return nil, index, err
+	}
+
+	return plaintext, index, nil
+}
+
+// matchContainerKey returns the candidate whose header MAC key verifies
+// the container header
+func matchContainerKey(keys [][]byte, data []byte) (int, error) {
+	container, err := format.Parse(data)
+	if err != nil {
+		return -1, err
+	}
+	if container.Header.SplitTrust() {
+		return -1, fmt.Errorf("container uses split-trust tags: a MAC key is required")
+	}
+
+	signed, err := container.Header.Signed()
+	if err != nil {
+		return -1, err
+	}
+
+	for i, key := range keys {
+		// Stretched containers cost one Argon2id run per candidate
+		key, err := containerMasterKey(container.Header, key)
+		if err != nil {
+			return -1, err
+		}
+		tag := ComputeHMAC(deriveContainerKey(key, headerMACLabel), signed)
+		if subtle.ConstantTimeCompare(tag, container.Header.Tag) == 1 {
+			return i, nil
+		}
+	}
+
+	return -1, ErrNoMatchingKey
+}
+
+// matchBodyKey returns the candidate whose body tag verifies
+// ciphertext || nonce || tag, hashing the body once for all candidates
+func matchBodyKey(keys [][]byte, data []byte) (int, error) {
+	if len(data) < NonceSize+TagSize {
+		return -1, fmt.Errorf("encrypted data too short: expected at least %d bytes, got %d",
+			NonceSize+TagSize, len(data))
+	}
+
+	ciphertextLength := len(data) - NonceSize - TagSize
+	ciphertext := data[:ciphertextLength]
+	nonce := data[ciphertextLength : ciphertextLength+NonceSize]
+	receivedTag := data[ciphertextLength+NonceSize:]
+
+	macs := make([]TagWriter, len(keys))
+	for i, key := range keys {
+		derived, err := DeriveKeys(key)
+		if err != nil {
+			return -1, err
+		}
+		macs[i], err = newBodyTag(derived, nil)
+		if err != nil {
+			return -1, err
+		}
+		macs[i].Write(nonce)
+	}
+
+	for off := 0; off < len(ciphertext); off += anyTagChunkSize {
+		chunk := ciphertext[off:min(off+anyTagChunkSize, len(ciphertext))]
+		for _, mac := range macs {
+			mac.Write(chunk)
+		}
+	}
+
+	for i, mac := range macs {
+		if subtle.ConstantTimeCompare(mac.Sum(), receivedTag) == 1 {
+			return i, nil
+		}
+	}
+
+	return -1, ErrNoMatchingKey
+}
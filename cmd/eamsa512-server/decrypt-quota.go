@@ -0,0 +1,361 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+)
+
+// ============================================================================
+// EAMSA 512 - Decrypt Quotas
+// Per-identity limits on decrypt operations
+//
+// A policy caps how many records an identity may decrypt per window (for
+// example, the auditor role at 1000 records/day), which bounds what a
+// compromised credential can read before it is noticed. Windows are fixed
+// and aligned to the window length (UTC midnight for the default 24h), so
+// every counter resets at the same time.
+//
+// Identities come from verified mTLS client certificates, mapped to users
+// and roles through the RBAC policy file. Requests without a verified
+// certificate are counted as the "anonymous" user.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// AnonymousIdentity is the user of requests without a verified client certificate
+const AnonymousIdentity = "anonymous"
+
+// DefaultDecryptQuotaWindow is the quota window when none is configured
+const DefaultDecryptQuotaWindow = 24 * time.Hour
+
+// ErrDecryptQuotaExceeded is returned when an identity has used its quota
+var ErrDecryptQuotaExceeded = errors.New("decrypt quota exceeded")
+
+// DecryptIdentity is the caller a decrypt is charged to
+type DecryptIdentity struct {
+	User  string   `json:"user"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+// DecryptQuotaPolicy sets decrypt limits per user and per role.
+// A user limit takes precedence over role limits; a user with several
+// limited roles gets the largest of them, as roles grant permissions
+// cumulatively. Identities with no configured limit are not restricted.
+// A limit of 0 blocks decryption entirely.
+type DecryptQuotaPolicy struct {
+	Window time.Duration              // Quota window (default 24h)
+	Roles  map[string]int64           // Records per window by role
+	Users  map[string]int64           // Records per window by username
+	Certs  map[string]DecryptIdentity // Client certificate subject to identity
+}
+
+// limitFor returns the limit of an identity and whether it has one
+func (p *DecryptQuotaPolicy) limitFor(id DecryptIdentity) (int64, bool) {
+	if limit, ok := p.Users[id.User]; ok {
+		return limit, true
+	}
+
+	limit, limited := int64(0), false
+	for _, role := range id.Roles {
+		if roleLimit, ok := p.Roles[role]; ok && (!limited || roleLimit > limit) {
+			limit, limited = roleLimit, true
+		}
+	}
+	return limit, limited
+}
+
+// Identify returns the identity of a request from its verified client
+// certificate. Certificates whose subject is not in the policy are charged
+// to the subject itself, with no roles.
+func (p *DecryptQuotaPolicy) Identify(r *http.Request) DecryptIdentity {
+	return identifyCertificate(r, p.Certs)
+}
+
+// identifyCertificate maps the verified client certificate of r through
+// certs (see DecryptQuotaPolicy.Identify)
+func identifyCertificate(r *http.Request, certs map[string]DecryptIdentity) DecryptIdentity {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return DecryptIdentity{User: AnonymousIdentity}
+	}
+
+	subject := subjectOf(r.TLS.VerifiedChains[0][0])
+	if id, ok := certs[subject]; ok {
+		return id
+	}
+	return DecryptIdentity{User: subject}
+}
+
+// subjectOf formats a certificate subject as in the RBAC file
+// ("CN=app,O=Example Corp,C=US")
+func subjectOf(cert *x509.Certificate) string {
+	return cert.Subject.String()
+}
+
+// rbacQuotaDocument is the part of an RBAC policy document used for quotas
+// and break-glass rules
+type rbacQuotaDocument struct {
+	DecryptQuotas *struct {
+		WindowHours int              `yaml:"window_hours"`
+		Roles       map[string]int64 `yaml:"roles"`
+		Users       map[string]int64 `yaml:"users"`
+	} `yaml:"decrypt_quotas"`
+
+	BreakGlass *breakGlassSection `yaml:"break_glass"`
+
+	Users map[string]struct {
+		Username           string   `yaml:"username"`
+		Roles              []string `yaml:"roles"`
+		Status             string   `yaml:"status"`
+		CertificateSubject string   `yaml:"certificate_subject"`
+	} `yaml:"users"`
+
+	Certificates map[string]struct {
+		Subject         string   `yaml:"subject"`
+		Status          string   `yaml:"status"`
+		AssociatedUser  string   `yaml:"associated_user"`
+		AssociatedRoles []string `yaml:"associated_roles"`
+	} `yaml:"certificates"`
+}
+
+// LoadDecryptQuotaPolicy reads the decrypt_quotas section and the user and
+// certificate mappings of an RBAC policy file. It returns nil if the file
+// has no decrypt_quotas section.
+func LoadDecryptQuotaPolicy(path string) (*DecryptQuotaPolicy, error) {
+	docs, err := readRBACDocuments(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &DecryptQuotaPolicy{
+		Window: DefaultDecryptQuotaWindow,
+		Roles:  make(map[string]int64),
+		Users:  make(map[string]int64),
+	}
+	configured := false
+
+	for _, doc := range docs {
+		if q := doc.DecryptQuotas; q != nil {
+			configured = true
+			if q.WindowHours < 0 {
+				return nil, fmt.Errorf("decrypt_quotas.window_hours must be positive")
+			}
+			if q.WindowHours > 0 {
+				policy.Window = time.Duration(q.WindowHours) * time.Hour
+			}
+			for role, limit := range q.Roles {
+				if limit < 0 {
+					return nil, fmt.Errorf("decrypt_quotas.roles.%s: limit must not be negative", role)
+				}
+				policy.Roles[role] = limit
+			}
+			for user, limit := range q.Users {
+				if limit < 0 {
+					return nil, fmt.Errorf("decrypt_quotas.users.%s: limit must not be negative", user)
+				}
+				policy.Users[user] = limit
+			}
+		}
+	}
+
+	if !configured {
+		return nil, nil
+	}
+
+	policy.Certs = rbacCertIdentities(docs)
+	return policy, nil
+}
+
+// readRBACDocuments parses every YAML document of an RBAC policy file
+func readRBACDocuments(path string) ([]rbacQuotaDocument, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read RBAC policy: %v", err)
+	}
+
+	var docs []rbacQuotaDocument
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc rbacQuotaDocument
+		if err := decoder.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse RBAC policy: %v", err)
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// rbacCertIdentities maps the client certificate subjects of active users
+// and certificates to their identities
+func rbacCertIdentities(docs []rbacQuotaDocument) map[string]DecryptIdentity {
+	certs := make(map[string]DecryptIdentity)
+	users := make(map[string]DecryptIdentity)
+
+	for _, doc := range docs {
+		for _, user := range doc.Users {
+			if user.Username == "" || user.Status != "" && user.Status != "active" {
+				continue
+			}
+			id := DecryptIdentity{User: user.Username, Roles: user.Roles}
+			users[user.Username] = id
+			if user.CertificateSubject != "" {
+				certs[user.CertificateSubject] = id
+			}
+		}
+	}
+
+	// Certificate entries take precedence over users.certificate_subject
+	for _, doc := range docs {
+		for _, cert := range doc.Certificates {
+			if cert.Subject == "" || cert.Status != "" && cert.Status != "active" {
+				continue
+			}
+			id := DecryptIdentity{User: cert.AssociatedUser, Roles: cert.AssociatedRoles}
+			if id.User == "" {
+				id.User = cert.Subject
+			}
+			if len(id.Roles) == 0 {
+				id.Roles = users[id.User].Roles
+			}
+			certs[cert.Subject] = id
+		}
+	}
+
+	return certs
+}
+
+// DecryptQuotaUsage is the quota state of one identity in the current window
+type DecryptQuotaUsage struct {
+	User    string    `json:"user"`
+	Limit   int64     `json:"limit"`
+	Used    int64     `json:"used"`
+	Denied  int64     `json:"denied"`
+	ResetAt time.Time `json:"reset_at"`
+}
+
+// DecryptQuotaReport summarizes quota enforcement for compliance reporting
+type DecryptQuotaReport struct {
+	Window      string              `json:"window"`
+	TotalDenied int64               `json:"total_denied"` // Since start, all windows
+	Identities  []DecryptQuotaUsage `json:"identities"`   // Limited identities seen this window
+}
+
+// decryptQuotaCounter counts one identity in the current window
+type decryptQuotaCounter struct {
+	limit  int64
+	used   int64
+	denied int64
+}
+
+// DecryptQuotas enforces a DecryptQuotaPolicy
+type DecryptQuotas struct {
+	mu          sync.Mutex
+	policy      *DecryptQuotaPolicy
+	window      time.Time // Start of the current window
+	counters    map[string]*decryptQuotaCounter
+	totalDenied int64
+	now         func() time.Time
+}
+
+// NewDecryptQuotas creates a quota enforcer
+func NewDecryptQuotas(policy *DecryptQuotaPolicy) *DecryptQuotas {
+	if policy.Window <= 0 {
+		policy.Window = DefaultDecryptQuotaWindow
+	}
+	return &DecryptQuotas{
+		policy:   policy,
+		counters: make(map[string]*decryptQuotaCounter),
+		now:      failpoint.Now,
+	}
+}
+
+// Policy returns the enforced policy
+func (q *DecryptQuotas) Policy() *DecryptQuotaPolicy {
+	return q.policy
+}
+
+// roll starts a new window if the current one has ended (q.mu held)
+func (q *DecryptQuotas) roll() time.Time {
+	start := q.now().UTC().Truncate(q.policy.Window)
+	if !start.Equal(q.window) {
+		q.window = start
+		q.counters = make(map[string]*decryptQuotaCounter)
+	}
+	return start.Add(q.policy.Window)
+}
+
+// Charge records n decrypted records for id. It returns the remaining
+// quota (-1 if unlimited) and the end of the window, or
+// ErrDecryptQuotaExceeded without charging if n records would exceed it.
+func (q *DecryptQuotas) Charge(id DecryptIdentity, n int64) (int64, time.Time, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resetAt := q.roll()
+	limit, limited := q.policy.limitFor(id)
+	if !limited {
+		return -1, resetAt, nil
+	}
+
+	counter := q.counters[id.User]
+	if counter == nil {
+		counter = &decryptQuotaCounter{}
+		q.counters[id.User] = counter
+	}
+	counter.limit = limit
+
+	if counter.used+n > limit {
+		counter.denied++
+		q.totalDenied++
+		return limit - counter.used, resetAt, ErrDecryptQuotaExceeded
+	}
+
+	counter.used += n
+	return limit - counter.used, resetAt, nil
+}
+
+// TotalDenied returns the number of denied requests since start
+func (q *DecryptQuotas) TotalDenied() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.totalDenied
+}
+
+// Report returns the usage of every limited identity in the current window
+func (q *DecryptQuotas) Report() DecryptQuotaReport {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	resetAt := q.roll()
+	report := DecryptQuotaReport{
+		Window:      q.policy.Window.String(),
+		TotalDenied: q.totalDenied,
+		Identities:  make([]DecryptQuotaUsage, 0, len(q.counters)),
+	}
+
+	for user, counter := range q.counters {
+		report.Identities = append(report.Identities, DecryptQuotaUsage{
+			User:    user,
+			Limit:   counter.limit,
+			Used:    counter.used,
+			Denied:  counter.denied,
+			ResetAt: resetAt,
+		})
+	}
+	sort.Slice(report.Identities, func(i, j int) bool { return report.Identities[i].User < report.Identities[j].User })
+
+	return report
+}
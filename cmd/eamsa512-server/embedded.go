@@ -0,0 +1,708 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ============================================================================
+// EAMSA 512 - Embedded Mode
+// Keystore, key lifecycle and cipher in one object for single-binary apps
+//
+// NewEmbedded(dataDir) needs no database, HSM or server:
+//
+//   emb, err := NewEmbedded("/var/lib/myapp/eamsa512")
+//   defer emb.Close()
+//   ciphertext, err := emb.Encrypt(plaintext)
+//
+// The data directory holds the keystore (key versions wrapped under a
+// key-encryption key), the key-encryption key itself and an audit log.
+// Ciphertexts are containers that record their key version, so Decrypt
+// picks the right key after rotations.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// EmbeddedKeystoreFile holds key metadata and wrapped key material
+	EmbeddedKeystoreFile = "keystore.json"
+
+	// EmbeddedKEKFile holds the key-encryption key (0600)
+	EmbeddedKEKFile = "keystore.kek"
+
+	// EmbeddedAuditFile is the append-only audit log
+	EmbeddedAuditFile = "audit.log"
+
+	// EmbeddedLockFile is locked by the process that has the keystore open
+	// for writing (see store-lock.go)
+	EmbeddedLockFile = "keystore.lock"
+
+	// embeddedKeystoreVersion is the keystore file format version
+	embeddedKeystoreVersion = 1
+)
+
+// embeddedKeystore is the on-disk keystore
+type embeddedKeystore struct {
+	FormatVersion int                 `json:"format_version"`
+	Keys          []embeddedKeyRecord `json:"keys"`
+}
+
+// embeddedKeyRecord is one key version; Wrapped is EncryptData(key, KEK)
+// and is absent for archived and destroyed versions
+type embeddedKeyRecord struct {
+	Metadata  keymgmt.KeyMetadata `json:"metadata"`
+	ExpiresAt time.Time           `json:"expires_at"`
+	Wrapped   []byte              `json:"wrapped,omitempty"`
+}
+
+// Embedded bundles a file keystore, a KeyManager and the cipher
+type Embedded struct {
+	mu        sync.RWMutex // Held exclusively while rotating or saving
+	dir       string
+	kek       []byte
+	keys      *KeyManager
+	policy    keymgmt.KeyRotationPolicy
+	audit     *log.Logger
+	auditFile *os.File
+	telemetry telemetry.Telemetry
+	lock      *filelock.Lock // nil for read-only and forced opens
+	readOnly  bool
+	unsaved   error // Set when a rotation could not be saved; blocks Encrypt
+	closed    bool
+}
+
+// DefaultEmbeddedPolicy returns the rotation policy used by NewEmbedded:
+// the default policy without a minimum key age, so the application may
+// rotate at any time, with the archive inside dataDir and a warm standby
+func DefaultEmbeddedPolicy(dataDir string) keymgmt.KeyRotationPolicy {
+	policy := keymgmt.DefaultKeyRotationPolicy()
+	policy.MinKeyAgeDays = 0
+	policy.ArchiveLocation = filepath.Join(dataDir, "archive")
+	policy.WarmStandby = true
+	return policy
+}
+
+// NewEmbedded opens the keystore in dataDir, creating the directory, a
+// key-encryption key and the first key version on first use
+func NewEmbedded(dataDir string) (*Embedded, error) {
+	return NewEmbeddedWithPolicy(dataDir, DefaultEmbeddedPolicy(dataDir))
+}
+
+// NewEmbeddedWithPolicy is NewEmbedded with a custom rotation policy. With
+// policy.Enabled, Encrypt rotates the active key once it is
+// policy.IntervalDays old. With policy.WarmStandby, the next key version is
+// generated and stored ahead of time, so rotating only promotes it.
+func NewEmbeddedWithPolicy(dataDir string, policy keymgmt.KeyRotationPolicy) (*Embedded, error) {
+	return NewEmbeddedWithOptions(dataDir, policy, StoreOpenOptions{})
+}
+
+// OpenEmbeddedReadOnly opens an existing keystore without taking its lock,
+// e.g. to decrypt or inspect while another process has it open. Nothing is
+// rotated or saved; Rotate and Save fail.
+func OpenEmbeddedReadOnly(dataDir string) (*Embedded, error) {
+	return NewEmbeddedWithOptions(dataDir, DefaultEmbeddedPolicy(dataDir), StoreOpenOptions{ReadOnly: true})
+}
+
+// NewEmbeddedWithOptions is NewEmbeddedWithPolicy with a read-only or
+// forced open. A writable open holds dataDir/keystore.lock until Close, so
+// a second process opening the same keystore fails instead of overwriting
+// its rotations.
+func NewEmbeddedWithOptions(dataDir string, policy keymgmt.KeyRotationPolicy, opts StoreOpenOptions) (*Embedded, error) {
+	if opts.ReadOnly {
+		if _, err := os.Stat(filepath.Join(dataDir, EmbeddedKeystoreFile)); err != nil {
+			return nil, fmt.Errorf("cannot open keystore read-only: %v", err)
+		}
+		// Nothing may change what is on disk
+		policy.Enabled = false
+		policy.WarmStandby = false
+	} else if policy.Entropy != nil {
+		// Keys must not come from a broken source
+		if err := policy.Entropy.HealthCheck(); err != nil {
+			return nil, fmt.Errorf("entropy source failed its health check: %v", err)
+		}
+	}
+
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %v", err)
+	}
+
+	auditFile, err := os.OpenFile(filepath.Join(dataDir, EmbeddedAuditFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	e := &Embedded{
+		dir:       dataDir,
+		policy:    policy,
+		audit:     log.New(auditFile, "[EMBEDDED] ", log.LstdFlags|log.LUTC),
+		auditFile: auditFile,
+		telemetry: telemetry.Nop{},
+		readOnly:  opts.ReadOnly,
+	}
+
+	e.lock, err = lockStore(filepath.Join(dataDir, EmbeddedLockFile), opts, e.audit)
+	if err != nil {
+		e.audit.Printf("KEYSTORE_OPEN_FAILED dir=%s error=%q", dataDir, err)
+		auditFile.Close()
+		return nil, err
+	}
+
+	if err := e.open(); err != nil {
+		e.audit.Printf("KEYSTORE_OPEN_FAILED dir=%s error=%q", dataDir, err)
+		e.lock.Release()
+		auditFile.Close()
+		return nil, err
+	}
+
+	active, _ := e.keys.GetActiveKeyMetadata()
+	e.audit.Printf("EMBEDDED_OPENED dir=%s active_version=%d read_only=%t", dataDir, active.Version, e.readOnly)
+
+	// A missing standby is reported by Health; it does not prevent use
+	e.replenishStandby()
+
+	return e, nil
+}
+
+// open loads the keystore, or initializes it if dataDir is new
+func (e *Embedded) open() error {
+	kekPath := filepath.Join(e.dir, EmbeddedKEKFile)
+	keystorePath := filepath.Join(e.dir, EmbeddedKeystoreFile)
+
+	data, err := os.ReadFile(keystorePath)
+	if os.IsNotExist(err) {
+		if _, err := os.Stat(kekPath); err == nil {
+			return fmt.Errorf("%s exists without %s; refusing to replace the key-encryption key", kekPath, keystorePath)
+		}
+		return e.initialize(kekPath)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read keystore: %v", err)
+	}
+
+	e.kek, err = os.ReadFile(kekPath)
+	if err != nil {
+		return fmt.Errorf("failed to read key-encryption key: %v", err)
+	}
+	if len(e.kek) != KeySize {
+		return fmt.Errorf("invalid key-encryption key size: expected %d bytes, got %d", KeySize, len(e.kek))
+	}
+
+	var keystore embeddedKeystore
+	if err := json.Unmarshal(data, &keystore); err != nil {
+		return fmt.Errorf("invalid keystore: %v", err)
+	}
+	if keystore.FormatVersion != embeddedKeystoreVersion {
+		return fmt.Errorf("unsupported keystore format version %d", keystore.FormatVersion)
+	}
+
+	entries := make([]*keymgmt.KeyEntry, 0, len(keystore.Keys))
+	for _, record := range keystore.Keys {
+		entry := &keymgmt.KeyEntry{Metadata: record.Metadata, ExpiresAt: record.ExpiresAt}
+
+		if len(record.Wrapped) > 0 {
+			material, err := DecryptData(record.Wrapped, e.kek)
+			if err != nil {
+				return fmt.Errorf("failed to unwrap key version %d: %v", record.Metadata.Version, err)
+			}
+			// The tag binds the material to the KEK, not to the record;
+			// the identifier check catches swapped records
+			if keyid.New(material).String() != record.Metadata.KeyHash {
+				return fmt.Errorf("key version %d does not match its identifier %s", record.Metadata.Version, record.Metadata.KeyHash)
+			}
+			entry.Material = material
+		}
+
+		entries = append(entries, entry)
+	}
+
+	e.keys, err = restoreKeyManager(entries, e.policy, e.audit)
+	if err != nil {
+		return fmt.Errorf("invalid keystore: %v", err)
+	}
+
+	return nil
+}
+
+// initialize creates the key-encryption key and the first key version
+func (e *Embedded) initialize(kekPath string) error {
+	kek, err := e.newKey()
+	if err != nil {
+		return fmt.Errorf("key-encryption key: %v", err)
+	}
+	e.kek = kek
+	if err := writeFileAtomic(kekPath, e.kek, 0600); err != nil {
+		return fmt.Errorf("failed to write key-encryption key: %v", err)
+	}
+
+	key, err := e.newKey()
+	if err != nil {
+		return err
+	}
+
+	e.keys, err = newKeyManager(key, e.policy, e.audit)
+	if err != nil {
+		os.Remove(kekPath)
+		return err
+	}
+	if err := e.save(); err != nil {
+		// Let the next attempt start over
+		e.keys.Stop()
+		os.Remove(kekPath)
+		return err
+	}
+	return nil
+}
+
+// SetTelemetry sets the receiver of encrypt, decrypt and key lifecycle
+// observations (nil disables), e.g. a telemetry.NewPrometheus collector
+// served on the application's own /metrics endpoint
+func (e *Embedded) SetTelemetry(t telemetry.Telemetry) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.telemetry = telemetry.OrNop(t)
+	e.keys.SetTelemetry(t)
+}
+
+// Encrypt encrypts plaintext under the active key version
+// Returns a container (see SealContainer) recording the key version
+func (e *Embedded) Encrypt(plaintext []byte) ([]byte, error) {
+	if err := e.rotateIfDue(); err != nil {
+		return nil, err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return nil, fmt.Errorf("embedded keystore is closed")
+	}
+	// Data encrypted under a key that is not on disk would be lost
+	if e.unsaved != nil {
+		return nil, fmt.Errorf("active key is not saved (call Save): %v", e.unsaved)
+	}
+
+	metadata, err := e.keys.GetActiveKeyMetadata()
+	if err != nil {
+		return nil, err
+	}
+	key, err := e.keys.GetActiveKey()
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	out, err := SealContainer(plaintext, key, ContainerOptions{KeyVersion: metadata.Version, PerFileKey: true})
+	e.telemetry.ObserveEncrypt(len(plaintext), time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	e.keys.IncrementEncryptionCount()
+	return out, nil
+}
+
+// Decrypt verifies and decrypts a container produced by Encrypt, using the
+// key version recorded in its header
+func (e *Embedded) Decrypt(data []byte) ([]byte, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return nil, fmt.Errorf("embedded keystore is closed")
+	}
+
+	header, err := ParseContainerHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.KeyVersion == 0 {
+		return nil, fmt.Errorf("container does not record a key version")
+	}
+
+	key, err := e.keys.GetKeyByVersion(header.KeyVersion)
+	if err != nil {
+		e.audit.Printf("DECRYPT_FAILED version=%d error=%q", header.KeyVersion, err)
+		return nil, err
+	}
+
+	start := time.Now()
+	plaintext, _, err := OpenContainer(data, key)
+	version := header.KeyVersion
+	if err != nil {
+		// Sealed in a region whose key lost a replication conflict for
+		// this version and was renumbered (see key-replication.go)
+		for _, moved := range e.keys.RenumberedTo(header.KeyVersion) {
+			if movedKey, keyErr := e.keys.GetKeyByVersion(moved); keyErr == nil {
+				if plaintext, _, err = OpenContainer(data, movedKey); err == nil {
+					version = moved
+					break
+				}
+			}
+		}
+	}
+	e.telemetry.ObserveDecrypt(len(data), time.Since(start), err)
+	if err != nil {
+		e.audit.Printf("DECRYPT_FAILED version=%d error=%q", header.KeyVersion, err)
+		return nil, err
+	}
+
+	e.keys.IncrementDecryptionCount(version)
+	return plaintext, nil
+}
+
+// Rotate generates a new key version, makes it active and saves the
+// keystore. Earlier versions remain available for decryption until the
+// retention policy archives them.
+func (e *Embedded) Rotate() (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.readOnly {
+		return 0, errEmbeddedReadOnly
+	}
+
+	return e.rotate()
+}
+
+// PlanRotate reports what Rotate would change without rotating
+func (e *Embedded) PlanRotate() (*keymgmt.RotationPlan, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return nil, fmt.Errorf("embedded keystore is closed")
+	}
+	_, hasStandby := e.keys.StandbyKey()
+	return e.keys.PlanRotation(hasStandby)
+}
+
+// rotate performs a rotation; caller must hold e.mu exclusively
+func (e *Embedded) rotate() (int, error) {
+	if e.closed {
+		return 0, fmt.Errorf("embedded keystore is closed")
+	}
+
+	var version int
+	if _, ok := e.keys.StandbyKey(); ok {
+		promoted, err := e.keys.PromoteStandby()
+		if err != nil {
+			return 0, err
+		}
+		version = promoted
+	} else {
+		key, err := e.newKey()
+		if err != nil {
+			return 0, err
+		}
+		if err := e.keys.RotateKey(key); err != nil {
+			return 0, err
+		}
+		metadata, err := e.keys.GetActiveKeyMetadata()
+		if err != nil {
+			return 0, err
+		}
+		version = metadata.Version
+	}
+
+	if err := e.save(); err != nil {
+		e.unsaved = err
+		return 0, err
+	}
+
+	e.replenishStandby()
+	return version, nil
+}
+
+// GetKeyMetadata returns the metadata of a key version
+func (e *Embedded) GetKeyMetadata(version int) (*keymgmt.KeyMetadata, error) {
+	return e.keys.GetKeyMetadata(version)
+}
+
+// GetRotationPolicy returns the keystore's rotation policy
+func (e *Embedded) GetRotationPolicy() keymgmt.KeyRotationPolicy {
+	return e.keys.GetRotationPolicy()
+}
+
+// DestroyKeyVersion destroys a key version that is no longer active (see
+// KeyManager.DestroyKeyVersion) and saves the keystore without it
+func (e *Embedded) DestroyKeyVersion(version int) (keymgmt.KeyMetadata, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return keymgmt.KeyMetadata{}, fmt.Errorf("embedded keystore is closed")
+	}
+	if e.readOnly {
+		return keymgmt.KeyMetadata{}, errEmbeddedReadOnly
+	}
+
+	metadata, err := e.keys.DestroyKeyVersion(version)
+	if err != nil {
+		return keymgmt.KeyMetadata{}, err
+	}
+	if err := e.save(); err != nil {
+		e.unsaved = err
+		return keymgmt.KeyMetadata{}, fmt.Errorf("key version %d was erased from memory but the keystore was not saved: %v", version, err)
+	}
+	return metadata, nil
+}
+
+// newKey returns key material from policy.Entropy (nil: crypto/rand)
+func (e *Embedded) newKey() ([]byte, error) {
+	return eamsa512.NewKey(e.policy.Entropy)
+}
+
+// replenishStandby generates and stores a standby key if the policy wants
+// one and none exists. Failures are audited and reported by Health.
+// Caller must hold e.mu exclusively (or be the constructor).
+func (e *Embedded) replenishStandby() {
+	if !e.policy.WarmStandby {
+		return
+	}
+	if _, ok := e.keys.StandbyKey(); ok {
+		return
+	}
+
+	key, err := e.newKey()
+	if err != nil {
+		e.audit.Printf("KEY_STANDBY_FAILED error=%q", err)
+		return
+	}
+
+	version, err := e.keys.PrepareStandby(key)
+	if err != nil {
+		e.audit.Printf("KEY_STANDBY_FAILED error=%q", err)
+		return
+	}
+
+	// Only a stored standby may be promoted later
+	if err := e.save(); err != nil {
+		e.keys.CancelScheduledKey(version)
+		e.audit.Printf("KEY_STANDBY_FAILED version=%d error=%q", version, err)
+	}
+}
+
+// Health reports whether the keystore can encrypt and, with a warm standby
+// policy, whether the next key version is ready
+func (e *Embedded) Health() error {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	if e.closed {
+		return fmt.Errorf("embedded keystore is closed")
+	}
+	if e.unsaved != nil {
+		return fmt.Errorf("key rotation not saved: %v", e.unsaved)
+	}
+	return e.keys.CheckStandby()
+}
+
+// rotateIfDue rotates the active key once it is policy.IntervalDays old
+func (e *Embedded) rotateIfDue() error {
+	if !e.policy.Enabled || e.policy.IntervalDays <= 0 {
+		return nil
+	}
+
+	due := func() bool {
+		metadata, err := e.keys.GetActiveKeyMetadata()
+		return err == nil && time.Since(metadata.ActivatedAt) >= time.Duration(e.policy.IntervalDays)*24*time.Hour
+	}
+
+	e.mu.RLock()
+	rotate := !e.closed && due()
+	e.mu.RUnlock()
+	if !rotate {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Another caller may have rotated in between
+	if e.closed || !due() {
+		return nil
+	}
+
+	version, err := e.rotate()
+	if err != nil {
+		return fmt.Errorf("scheduled key rotation failed: %v", err)
+	}
+	e.audit.Printf("KEY_ROTATED_ON_SCHEDULE version=%d interval_days=%d", version, e.policy.IntervalDays)
+	return nil
+}
+
+// Keys returns the key manager for metadata, labels and statistics
+func (e *Embedded) Keys() *KeyManager {
+	return e.keys
+}
+
+// Save writes the keystore, including usage counters
+func (e *Embedded) Save() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return fmt.Errorf("embedded keystore is closed")
+	}
+	if e.readOnly {
+		return errEmbeddedReadOnly
+	}
+	if err := e.save(); err != nil {
+		return err
+	}
+	e.unsaved = nil
+	return nil
+}
+
+// errEmbeddedReadOnly is returned by writes to a keystore opened read-only
+var errEmbeddedReadOnly = errors.New("embedded keystore is open read-only")
+
+// save writes the keystore atomically; caller must hold e.mu exclusively
+// (or be initializing)
+func (e *Embedded) save() error {
+	keystore := embeddedKeystore{FormatVersion: embeddedKeystoreVersion}
+
+	for _, entry := range e.keys.Snapshot() {
+		record := embeddedKeyRecord{Metadata: entry.Metadata, ExpiresAt: entry.ExpiresAt}
+
+		if len(entry.Material) > 0 {
+			wrapped, err := EncryptData(entry.Material, e.kek, nil)
+			if err != nil {
+				return fmt.Errorf("failed to wrap key version %d: %v", entry.Metadata.Version, err)
+			}
+			record.Wrapped = wrapped
+		}
+
+		keystore.Keys = append(keystore.Keys, record)
+	}
+
+	data, err := json.MarshalIndent(keystore, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode keystore: %v", err)
+	}
+
+	if err := writeFileAtomic(filepath.Join(e.dir, EmbeddedKeystoreFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write keystore: %v", err)
+	}
+	return nil
+}
+
+// Close saves the keystore, stops the key manager and closes the audit log
+func (e *Embedded) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var err error
+	if !e.readOnly {
+		err = e.save()
+	}
+	e.keys.Stop()
+	if lockErr := e.lock.Release(); err == nil && lockErr != nil {
+		err = fmt.Errorf("failed to release keystore lock: %v", lockErr)
+	}
+	e.audit.Printf("EMBEDDED_CLOSED dir=%s", e.dir)
+
+	if closeErr := e.auditFile.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close audit log: %v", closeErr)
+	}
+	return err
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory,
+// syncs it and renames it over path
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. DATA DIRECTORY
+   - keystore.json: key metadata (versions, states, counters, labels) and
+     each usable key wrapped with EncryptData under the KEK
+   - keystore.kek: 32-byte key-encryption key, mode 0600
+   - audit.log: key lifecycle events, decrypt failures, open/close
+   - The directory is created with mode 0700; back it up as a unit
+
+2. KEY-ENCRYPTION KEY
+   - Generated on first use and stored next to the keystore, so the
+     keystore is only as safe as the directory permissions
+   - Put the directory on an encrypted volume, or use the server with an
+     HSM where keys must never touch the disk
+   - Opening a directory whose keystore.json is missing but whose KEK
+     exists fails instead of silently starting over
+
+3. LIFECYCLE
+   - Versions, states and retention follow KeyManager (see
+     key-rotation.go); Keys() exposes it for metadata and labels
+   - Rotate() rotates immediately; with the rotation policy enabled,
+     Encrypt rotates once the active key is IntervalDays old
+   - The next version is kept as a stored standby (pending, wrapped in
+     keystore.json), so rotating promotes it and then prepares a new
+     one; Health() fails while no standby exists
+   - Archived versions (beyond RetentionCycles) are erased, and data
+     encrypted under them can no longer be decrypted; raise
+     RetentionCycles via NewEmbeddedWithPolicy to keep more versions
+
+4. PERSISTENCE
+   - The keystore is rewritten atomically on rotation, Save() and Close()
+   - Usage counters are saved by Save() and Close(); a crash loses the
+     counts since the last save, never a key
+   - One writer per data directory, enforced by keystore.lock (see
+     store-lock.go); OpenEmbeddedReadOnly opens alongside the writer
+
+5. PER-FILE KEYS
+   - Encrypt seals each container body under a key derived from the
+     version's key and the body nonce (ContainerOptions.PerFileKey), so
+     a working key recovered from one file exposes nothing else
+   - Containers written before this carry no FlagFileKey and decrypt
+     under the version key as before
+
+6. REPLICATION
+   - NewKeyReplicator replicates the keystore to other regions (see
+     key-replication.go); its state is kept in replication.json
+
+*/
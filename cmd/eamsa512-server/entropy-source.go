@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Entropy Source Selection
+// Health-tested chaos or TRNG entropy with audited failover to crypto/rand
+//
+// EAMSA_ENTROPY_SOURCE selects where the server's nonces and IVs come
+// from:
+//
+// - "system" (or unset): crypto/rand
+// - "chaos": the Lorenz chaos generator, seeded from crypto/rand
+// - "device:<path>": an external TRNG such as /dev/hwrng
+// - "pkcs11:<module>": the RNG of an HSM token (see pkcs11-entropy.go)
+//
+// The chaos, device and PKCS#11 sources run the SP 800-90B Repetition Count
+// and Adaptive Proportion Tests over their raw samples, and the start-up
+// tests before first use. When a test fails, the source stops serving output and
+// the server falls back to crypto/rand; it returns to the source once the
+// start-up tests pass again, no sooner than EAMSA_ENTROPY_RECHECK (default
+// 1m) after the failure. The failure, the first fallback draw and the
+// recovery are audited as RANDOM_SOURCE_FAILED, RANDOM_FALLBACK and
+// RANDOM_SOURCE_RECOVERED.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// LoadEntropySourceFromEnv returns a Random over the source selected by
+// EAMSA_ENTROPY_SOURCE, auditing its failures through audit, or nil for
+// crypto/rand
+func LoadEntropySourceFromEnv(audit func(string, map[string]interface{})) (*eamsa512.Random, error) {
+	value := strings.TrimSpace(os.Getenv("EAMSA_ENTROPY_SOURCE"))
+
+	var source eamsa512.RandomSource
+	switch {
+	case value == "" || value == "system":
+		return nil, nil
+	case value == "chaos":
+		chaos, err := eamsa512.NewChaosEntropy(nil)
+		if err != nil {
+			return nil, fmt.Errorf("chaos entropy source: %v", err)
+		}
+		source = chaos
+	case strings.HasPrefix(value, "device:"):
+		device, err := eamsa512.OpenDeviceEntropy(strings.TrimPrefix(value, "device:"))
+		if err != nil {
+			return nil, err
+		}
+		source = device
+	case strings.HasPrefix(value, "pkcs11:"):
+		token, err := openPKCS11EntropyFromEnv(strings.TrimPrefix(value, "pkcs11:"))
+		if err != nil {
+			return nil, err
+		}
+		source = token
+	default:
+		return nil, fmt.Errorf("invalid EAMSA_ENTROPY_SOURCE %q: want system, chaos, device:<path> or pkcs11:<module>", value)
+	}
+
+	policy := eamsa512.RandomPolicy{
+		Source:   source,
+		Fallback: true,
+		OnEvent:  newEntropyAuditor(audit),
+	}
+	if value := os.Getenv("EAMSA_ENTROPY_RECHECK"); value != "" {
+		interval, err := time.ParseDuration(value)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid EAMSA_ENTROPY_RECHECK %q", value)
+		}
+		policy.RecheckInterval = interval
+	}
+
+	random := eamsa512.NewRandom(policy)
+	if err := random.HealthCheck(); err != nil {
+		return nil, fmt.Errorf("entropy source failed its health check: %v", err)
+	}
+	return random, nil
+}
+
+// newEntropyAuditor returns an event handler that audits source failures
+// and recoveries, and only the first fallback draw after each failure
+func newEntropyAuditor(audit func(string, map[string]interface{})) func(eamsa512.RandomEvent) {
+	var mu sync.Mutex
+	fellBack := false
+
+	return func(e eamsa512.RandomEvent) {
+		details := map[string]interface{}{"source": e.Source}
+
+		mu.Lock()
+		switch e.Type {
+		case eamsa512.RandomSourceFailed:
+			fellBack = false
+			details["severity"] = "critical"
+			details["error"] = e.Err.Error()
+		case eamsa512.RandomFallback:
+			if fellBack {
+				mu.Unlock()
+				return
+			}
+			fellBack = true
+			details["severity"] = "warning"
+			details["fallback"] = eamsa512.SystemRandom
+		}
+		mu.Unlock()
+
+		audit(e.Type, details)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+)
+
+// ============================================================================
+// EAMSA 512 - Failure Injection
+// Run-time control of failpoints in binaries built with -tags failpoints
+//
+// GET /debug/failpoints lists the armed points; POST arms or disarms one:
+//
+//	curl -X POST -d '{"point": "db-write", "spec": "3*error"}' \
+//	  http://127.0.0.1:8080/debug/failpoints
+//
+// The route is registered only when failpoint.Enabled, answers loopback
+// clients only, and every change is audited.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// FailpointRequest arms (or with spec "off" disarms) a failpoint
+type FailpointRequest struct {
+	Point string `json:"point"`
+	Spec  string `json:"spec"`
+}
+
+// HandleFailpoints handles GET/POST /debug/failpoints
+func HandleFailpoints(w http.ResponseWriter, r *http.Request) {
+	if !isLoopback(r.RemoteAddr) {
+		respondError(w, r, "forbidden", "Failpoints are only available from loopback")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		respondJSON(w, http.StatusOK, failpoint.List())
+
+	case http.MethodPost:
+		var req FailpointRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+			return
+		}
+		if err := failpoint.Set(req.Point, req.Spec); err != nil {
+			respondError(w, r, "bad_request", err.Error())
+			return
+		}
+		LogAuditEvent("FAILPOINT_SET", map[string]interface{}{
+			"severity": "warning",
+			"point":    req.Point,
+			"spec":     req.Spec,
+			"source":   r.RemoteAddr,
+		})
+		respondJSON(w, http.StatusOK, failpoint.List())
+
+	default:
+		respondError(w, r, "method_not_allowed", "Only GET and POST are allowed")
+	}
+}
+
+// isLoopback reports whether addr (host:port) is a loopback address
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+POINTS:
+
+- hsm-call: HSM/KMS calls through the circuit breaker. delay(10s) past the
+  call deadline exercises ErrHSMTimeout, the breaker and cached-key fallback
+- db-write: operation, audit, key version and wrapped key writes fail before
+  reaching SQLite; audit batches are retried by the queue
+- stream-write: partial(n) truncates a streaming response chunk, so clients
+  see a short body and an error in the X-EAMSA-Stream-Status trailer
+- clock: skew(d) shifts the clocks behind scheduled key activation, rotation
+  checks, decrypt quota windows, breaker timing and GC report expiry
+
+Startup arming uses EAMSA_FAILPOINTS with the same specs, e.g.
+EAMSA_FAILPOINTS="db-write=10%error;clock=skew(-30m)".
+*/
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"fmt"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Streaming Hash-and-Encrypt
+// One-pass encryption that also yields the plaintext SHA3-256 digest
+//
+// EncryptWriter is the streaming encryption core: plaintext written to it
+// is encrypted into a container without being buffered. With
+// IncludeDigest (always set by HashingEncryptWriter) it is also hashed in
+// the same pass, like io.TeeReader/io.MultiWriter, and the digest is
+// stored encrypted in the container footer. Large files therefore never
+// need to be read twice. The body is byte-compatible with EncryptData, so
+// OpenContainer decrypts the output.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// EncryptWriter encrypts a plaintext stream into a container
+type EncryptWriter struct {
+	w         io.Writer
+	masterKey []byte
+	keys      [][]byte
+	nonce     []byte
+	prevBlock []byte
+	pending   []byte
+	padding   eamsa512.Padding
+	mac       TagWriter
+	macKey    MACKey    // Split-trust MAC key; nil for master-key tags
+	digest    hash.Hash // nil without IncludeDigest
+	written   int64
+	sum       []byte
+	closed    bool
+	err       error
+}
+
+// HashingEncryptWriter is the name NewHashingEncryptWriter has always
+// returned; it is an EncryptWriter with the digest footer enabled
+type HashingEncryptWriter = EncryptWriter
+
+// NewEncryptWriter writes the container header to w and returns a writer
+// for the plaintext. Close must be called to flush the final block, tag
+// and, with IncludeDigest, the digest footer.
+func NewEncryptWriter(w io.Writer, masterKey []byte, opts ContainerOptions) (*EncryptWriter, error) {
+	header, masterKey, err := buildContainerHeader(masterKey, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := eamsa512.NewNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	bodyKey, err := containerBodyKey(masterKey, nonce, opts.PerFileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := DeriveKeys(bodyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+
+	// Tag covers nonce || ciphertext, same as EncryptData
+	mac, err := newBodyTag(keys, opts.MACKey)
+	if err != nil {
+		return nil, err
+	}
+	mac.Write(nonce)
+
+	key := make([]byte, len(masterKey))
+	copy(key, masterKey)
+
+	// buildContainerHeader has rejected unknown schemes
+	if opts.Padding == 0 {
+		opts.Padding = eamsa512.PaddingPKCS7
+	}
+
+	ew := &EncryptWriter{
+		w:         w,
+		masterKey: key,
+		keys:      keys,
+		nonce:     nonce,
+		prevBlock: DeriveIV(nonce, bodyKey),
+		pending:   make([]byte, 0, 2*BlockSize),
+		padding:   opts.Padding,
+		mac:       mac,
+		macKey:    opts.MACKey,
+	}
+	if opts.IncludeDigest {
+		ew.digest = sha3.New256()
+	}
+
+	return ew, nil
+}
+
+// NewHashingEncryptWriter is NewEncryptWriter with IncludeDigest set; Sum
+// returns the plaintext digest after Close
+func NewHashingEncryptWriter(w io.Writer, masterKey []byte, opts ContainerOptions) (*HashingEncryptWriter, error) {
+	opts.IncludeDigest = true
+	return NewEncryptWriter(w, masterKey, opts)
+}
+
+// Write encrypts (and hashes) p
+func (hw *EncryptWriter) Write(p []byte) (int, error) {
+	if hw.closed {
+		return 0, fmt.Errorf("write to closed EncryptWriter")
+	}
+	if hw.err != nil {
+		return 0, hw.err
+	}
+
+	if hw.digest != nil {
+		hw.digest.Write(p)
+	}
+	hw.written += int64(len(p))
+	hw.pending = append(hw.pending, p...)
+
+	// Keep the last (possibly full) block back until Close decides on padding
+	processed := 0
+	for len(hw.pending)-processed > BlockSize {
+		if err := hw.encryptBlock(hw.pending[processed : processed+BlockSize]); err != nil {
+			hw.err = err
+			return 0, err
+		}
+		processed += BlockSize
+	}
+	hw.pending = append(hw.pending[:0], hw.pending[processed:]...)
+
+	return len(p), nil
+}
+
+// Close pads and encrypts the final block, then writes nonce, tag and the
+// encrypted digest footer if requested
+func (hw *EncryptWriter) Close() error {
+	if hw.closed {
+		return nil
+	}
+	hw.closed = true
+	defer zeroizeKey(hw.masterKey)
+
+	if hw.err != nil {
+		return hw.err
+	}
+
+	// Padding per the cipher suite, matching SealContainer: a held full
+	// block is written as is and followed by a whole block of padding
+	if len(hw.pending) == BlockSize {
+		if err := hw.encryptBlock(hw.pending); err != nil {
+			return err
+		}
+		hw.pending = hw.pending[:0]
+	}
+
+	final := make([]byte, BlockSize)
+	copy(final, hw.pending)
+	hw.padding.Fill(final[len(hw.pending):])
+	if err := hw.encryptBlock(final); err != nil {
+		return err
+	}
+
+	tag := hw.mac.Sum()
+	parts := [][]byte{hw.nonce, tag}
+
+	if hw.digest != nil {
+		hw.sum = hw.digest.Sum(nil)
+
+		footer, err := buildContainerFooter(hw.masterKey, hw.macKey, hw.sum, tag)
+		if err != nil {
+			return err
+		}
+		parts = append(parts, footer)
+	}
+
+	for _, part := range parts {
+		if _, err := hw.w.Write(part); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Sum returns the SHA3-256 digest of the plaintext; valid after Close,
+// nil without IncludeDigest
+func (hw *EncryptWriter) Sum() []byte {
+	return hw.sum
+}
+
+// Written returns the number of plaintext bytes written
+func (hw *EncryptWriter) Written() int64 {
+	return hw.written
+}
+
+// encryptBlock CBC-encrypts one block and feeds it to the tag
+func (hw *EncryptWriter) encryptBlock(block []byte) error {
+	xored := make([]byte, BlockSize)
+	for j := 0; j < BlockSize; j++ {
+		xored[j] = block[j] ^ hw.prevBlock[j]
+	}
+
+	encrypted := EncryptBlock(xored, hw.keys)
+	hw.mac.Write(encrypted)
+	hw.prevBlock = encrypted
+
+	_, err := hw.w.Write(encrypted)
+	return err
+}
+
+// EncryptAndHash streams src into an encrypted container on dst
+// Returns the plaintext SHA3-256 digest and the number of plaintext bytes
+func EncryptAndHash(dst io.Writer, src io.Reader, masterKey []byte, opts ContainerOptions) ([]byte, int64, error) {
+	hw, err := NewHashingEncryptWriter(dst, masterKey, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	n, err := io.Copy(hw, src)
+	if err != nil {
+		hw.Close()
+		return nil, n, err
+	}
+
+	if err := hw.Close(); err != nil {
+		return nil, n, err
+	}
+
+	return hw.Sum(), n, nil
+}
+
+// ============================================================================
+// Incremental HMAC
+// ============================================================================
+
+// newStreamingHMAC starts an HMAC-SHA3-512 computation with ComputeHMAC's key schedule
+func newStreamingHMAC(key []byte) TagWriter {
+	return eamsa512.NewHMAC(key)
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/tlstrust"
+)
+
+// ============================================================================
+// EAMSA 512 - Honeytokens
+// Decoy keys and canary ciphertexts
+//
+// A decoy key is registered by its key ID only and is never used by real
+// clients; a canary is a ciphertext planted where an attacker would find
+// it (backups, old tickets, a config repo). Any request that uses a decoy
+// key or tries to decrypt a canary is a strong sign of stolen material:
+// it is audited as a critical HONEYTOKEN_TRIPPED event and sent to the
+// alert webhook. The request itself is answered as usual so the caller is
+// not told that it was detected.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Honeytoken kinds
+const (
+	HoneytokenDecoyKey = "decoy_key"
+	HoneytokenCanary   = "canary"
+)
+
+// HoneytokenAlert describes a tripped honeytoken
+type HoneytokenAlert struct {
+	Kind        string    `json:"kind"`  // HoneytokenDecoyKey or HoneytokenCanary
+	Label       string    `json:"label"` // Where the token was planted
+	KeyID       string    `json:"key_id,omitempty"`
+	Fingerprint string    `json:"fingerprint,omitempty"` // Canary fingerprint
+	Operation   string    `json:"operation"`             // encrypt or decrypt
+	Source      string    `json:"source,omitempty"`      // Client address
+	User        string    `json:"user,omitempty"`        // Caller identity, if known
+	Time        time.Time `json:"time"`
+}
+
+// Alerter delivers honeytoken alerts. Alert must not block.
+type Alerter interface {
+	Alert(alert HoneytokenAlert)
+}
+
+// DecoyKey is a registered decoy key ID
+type DecoyKey struct {
+	KeyID string `json:"key_id"`
+	Label string `json:"label"`
+}
+
+// CanaryCiphertext is a registered canary ciphertext
+type CanaryCiphertext struct {
+	Fingerprint string `json:"fingerprint"` // CanaryFingerprint of the sealed data
+	Label       string `json:"label"`
+}
+
+// HoneytokenConfig is the honeytoken file (JSON)
+type HoneytokenConfig struct {
+	DecoyKeys []DecoyKey          `json:"decoy_keys"`
+	Canaries  []CanaryCiphertext  `json:"canaries"`
+	Webhook   *AlertWebhookConfig `json:"webhook,omitempty"`
+}
+
+// CanaryFingerprint identifies sealed data (ciphertext || nonce || tag) by
+// its tag, which authenticates the nonce and every ciphertext block
+func CanaryFingerprint(sealed []byte) (string, error) {
+	if len(sealed) < NonceSize+TagSize {
+		return "", fmt.Errorf("sealed data too short")
+	}
+	digest := sha3.Sum256(sealed[len(sealed)-TagSize:])
+	return hex.EncodeToString(digest[:16]), nil
+}
+
+// Honeytokens holds the registered decoy keys and canaries
+type Honeytokens struct {
+	mu       sync.RWMutex
+	keys     []keyid.ID
+	labels   []string          // Labels of keys, by index
+	canaries map[string]string // Fingerprint to label
+	alerter  Alerter
+	trips    uint64
+}
+
+// NewHoneytokens creates an empty registry. alerter may be nil.
+func NewHoneytokens(alerter Alerter) *Honeytokens {
+	return &Honeytokens{
+		canaries: make(map[string]string),
+		alerter:  alerter,
+	}
+}
+
+// LoadHoneytokens reads a honeytoken file and starts its webhook, if any
+func LoadHoneytokens(path string) (*Honeytokens, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read honeytoken file: %v", err)
+	}
+
+	var config HoneytokenConfig
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to parse honeytoken file: %v", err)
+	}
+
+	var alerter Alerter
+	if config.Webhook != nil {
+		webhook, err := NewAlertWebhook(*config.Webhook)
+		if err != nil {
+			return nil, err
+		}
+		alerter = webhook
+	}
+
+	h := NewHoneytokens(alerter)
+	for _, decoy := range config.DecoyKeys {
+		if err := h.RegisterDecoyKey(decoy.KeyID, decoy.Label); err != nil {
+			return nil, err
+		}
+	}
+	for _, canary := range config.Canaries {
+		if err := h.registerFingerprint(canary.Fingerprint, canary.Label); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// RegisterDecoyKey registers a decoy key by its key ID
+func (h *Honeytokens) RegisterDecoyKey(keyID, label string) error {
+	id, err := keyid.Parse(keyID)
+	if err != nil {
+		return fmt.Errorf("invalid decoy key ID %q: %v", keyID, err)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.keys = append(h.keys, id)
+	h.labels = append(h.labels, label)
+	return nil
+}
+
+// RegisterCanary registers sealed data as a canary and returns its fingerprint
+func (h *Honeytokens) RegisterCanary(sealed []byte, label string) (string, error) {
+	fingerprint, err := CanaryFingerprint(sealed)
+	if err != nil {
+		return "", err
+	}
+	return fingerprint, h.registerFingerprint(fingerprint, label)
+}
+
+func (h *Honeytokens) registerFingerprint(fingerprint, label string) error {
+	if raw, err := hex.DecodeString(fingerprint); err != nil || len(raw) != 16 {
+		return fmt.Errorf("invalid canary fingerprint %q", fingerprint)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.canaries[strings.ToLower(fingerprint)] = label
+	return nil
+}
+
+// MintCanary encrypts a random token under key and registers the result
+// as a canary. The returned sealed data is what gets planted.
+func (h *Honeytokens) MintCanary(key []byte, label string) ([]byte, string, error) {
+	decoy := make([]byte, 40)
+	if _, err := rand.Read(decoy); err != nil {
+		return nil, "", fmt.Errorf("failed to generate canary content: %v", err)
+	}
+
+	sealed, err := EncryptData([]byte(hex.EncodeToString(decoy)), key, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to seal canary: %v", err)
+	}
+
+	fingerprint, err := h.RegisterCanary(sealed, label)
+	if err != nil {
+		return nil, "", err
+	}
+	return sealed, fingerprint, nil
+}
+
+// CheckKey reports a tripped alert if keyID is a decoy key
+func (h *Honeytokens) CheckKey(keyID, operation, source, user string) *HoneytokenAlert {
+	id, err := keyid.Parse(keyID)
+	if err != nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	label, found := "", false
+	for i, decoy := range h.keys {
+		if decoy.Equal(id) {
+			label, found = h.labels[i], true
+			break
+		}
+	}
+	h.mu.RUnlock()
+
+	if !found {
+		return nil
+	}
+	return h.trip(HoneytokenAlert{Kind: HoneytokenDecoyKey, Label: label, KeyID: keyID,
+		Operation: operation, Source: source, User: user})
+}
+
+// CheckCiphertext reports a tripped alert if sealed is a canary
+func (h *Honeytokens) CheckCiphertext(sealed []byte, keyID, source, user string) *HoneytokenAlert {
+	fingerprint, err := CanaryFingerprint(sealed)
+	if err != nil {
+		return nil
+	}
+
+	h.mu.RLock()
+	label, found := h.canaries[fingerprint]
+	h.mu.RUnlock()
+
+	if !found {
+		return nil
+	}
+	return h.trip(HoneytokenAlert{Kind: HoneytokenCanary, Label: label, KeyID: keyID, Fingerprint: fingerprint,
+		Operation: "decrypt", Source: source, User: user})
+}
+
+// trip counts an alert and hands it to the alerter
+func (h *Honeytokens) trip(alert HoneytokenAlert) *HoneytokenAlert {
+	alert.Time = time.Now().UTC()
+
+	h.mu.Lock()
+	h.trips++
+	h.mu.Unlock()
+
+	if h.alerter != nil {
+		h.alerter.Alert(alert)
+	}
+	return &alert
+}
+
+// Trips returns the number of tripped honeytokens since start
+func (h *Honeytokens) Trips() uint64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.trips
+}
+
+// Alerter returns the alerter, or nil
+func (h *Honeytokens) Alerter() Alerter {
+	return h.alerter
+}
+
+// RunHoneytokenCommand implements "eamsa512-server honeytoken decoy|canary".
+// It writes the material to plant and prints the entry for the honeytoken
+// file.
+func RunHoneytokenCommand(args []string) error {
+	if len(args) == 0 || args[0] != "decoy" && args[0] != "canary" {
+		return fmt.Errorf("expected a subcommand: decoy or canary")
+	}
+
+	fs := flag.NewFlagSet("honeytoken "+args[0], flag.ContinueOnError)
+	label := fs.String("label", "", "Where the token will be planted")
+	output := fs.String("o", "", "Write the material to plant here (default stdout)")
+	keyFile := fs.String("key-file", "", "canary: file containing the key to encrypt under (hex)")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+	if *label == "" {
+		return fmt.Errorf("-label is required")
+	}
+
+	var material []byte
+	var entry interface{}
+	var section string
+
+	switch args[0] {
+	case "decoy":
+		key := make([]byte, KeySize)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate decoy key: %v", err)
+		}
+		defer zeroizeKey(key)
+		material = []byte(hex.EncodeToString(key))
+		entry = DecoyKey{KeyID: keyid.New(key).String(), Label: *label}
+		section = "decoy_keys"
+
+	case "canary":
+		if *keyFile == "" {
+			return fmt.Errorf("-key-file is required")
+		}
+		key, err := readKEKFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		defer zeroizeKey(key)
+
+		sealed, fingerprint, err := NewHoneytokens(nil).MintCanary(key, *label)
+		if err != nil {
+			return err
+		}
+		material = []byte(hex.EncodeToString(sealed))
+		entry = CanaryCiphertext{Fingerprint: fingerprint, Label: *label}
+		section = "canaries"
+	}
+
+	if *output == "" {
+		fmt.Printf("%s\n", material)
+	} else if err := os.WriteFile(*output, append(material, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", *output, err)
+	}
+
+	data, _ := json.MarshalIndent(entry, "", "  ")
+	fmt.Fprintf(os.Stderr, "Add to the honeytoken file (%s):\n%s\n", section, data)
+	return nil
+}
+
+// ============================================================================
+// Webhook Alerts
+// ============================================================================
+
+// AlertWebhookConfig configures the alert webhook
+type AlertWebhookConfig struct {
+	URL            string          `json:"url"`
+	SecretFile     string          `json:"secret_file,omitempty"` // HMAC-SHA256 signing secret
+	TimeoutSeconds int             `json:"timeout_seconds,omitempty"`
+	TLS            tlstrust.Config `json:"tls"` // Peer verification for https URLs
+}
+
+// Webhook delivery limits
+const (
+	alertWebhookQueue    = 100
+	alertWebhookAttempts = 3
+)
+
+// AlertWebhookStats holds webhook delivery counters
+type AlertWebhookStats struct {
+	Sent    uint64 `json:"sent"`
+	Failed  uint64 `json:"failed"`  // Gave up after all attempts
+	Dropped uint64 `json:"dropped"` // Queue full
+}
+
+// AlertWebhook posts alerts as JSON from a background worker. Each body
+// is signed in the X-EAMSA-Signature header ("sha256=<hex HMAC>") when a
+// secret is configured.
+type AlertWebhook struct {
+	url    string
+	secret []byte
+	client *http.Client
+	queue  chan HoneytokenAlert
+	done   chan struct{}
+
+	mu    sync.Mutex
+	stats AlertWebhookStats
+}
+
+// NewAlertWebhook validates config and starts the delivery worker
+func NewAlertWebhook(config AlertWebhookConfig) (*AlertWebhook, error) {
+	secret, client, err := newWebhookClient("webhook", config)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &AlertWebhook{
+		url:    config.URL,
+		secret: secret,
+		client: client,
+		queue:  make(chan HoneytokenAlert, alertWebhookQueue),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// newWebhookClient checks config and returns its signing secret (nil if
+// none) and an HTTP client verifying the peer. TLS failures are audited
+// as TLS_VERIFY_FAILED under integration.
+func newWebhookClient(integration string, config AlertWebhookConfig) ([]byte, *http.Client, error) {
+	if !strings.HasPrefix(config.URL, "https://") && !strings.HasPrefix(config.URL, "http://") {
+		return nil, nil, fmt.Errorf("%s URL must be http(s): %q", integration, config.URL)
+	}
+
+	var secret []byte
+	if config.SecretFile != "" {
+		data, err := os.ReadFile(config.SecretFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s secret: %v", integration, err)
+		}
+		secret = bytes.TrimSpace(data)
+	}
+
+	timeout := 10 * time.Second
+	if config.TimeoutSeconds > 0 {
+		timeout = time.Duration(config.TimeoutSeconds) * time.Second
+	}
+
+	verifier, err := tlstrust.New(integration, config.TLS, func(f tlstrust.Failure) {
+		LogAuditEvent("TLS_VERIFY_FAILED", map[string]interface{}{
+			"integration": f.Integration,
+			"server_name": f.ServerName,
+			"reason":      f.Reason,
+			"detail":      f.Detail,
+			"spki_pin":    f.SPKIPin,
+			"accepted":    f.Accepted,
+		})
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid %s TLS configuration: %v", integration, err)
+	}
+
+	return secret, verifier.HTTPClient(timeout), nil
+}
+
+// signWebhookRequest sets X-EAMSA-Signature on req when secret is set
+func signWebhookRequest(req *http.Request, body, secret []byte) {
+	if secret == nil {
+		return
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	req.Header.Set("X-EAMSA-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+}
+
+// Alert queues an alert; it is dropped if the queue is full
+func (w *AlertWebhook) Alert(alert HoneytokenAlert) {
+	select {
+	case w.queue <- alert:
+	default:
+		w.mu.Lock()
+		w.stats.Dropped++
+		w.mu.Unlock()
+	}
+}
+
+// Stats returns delivery counters
+func (w *AlertWebhook) Stats() AlertWebhookStats {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.stats
+}
+
+// Close delivers queued alerts until ctx expires. Alert must not be
+// called after Close.
+func (w *AlertWebhook) Close(ctx context.Context) error {
+	close(w.queue)
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("alert webhook: %d alert(s) not delivered: %v", len(w.queue), ctx.Err())
+	}
+}
+
+func (w *AlertWebhook) run() {
+	defer close(w.done)
+
+	for alert := range w.queue {
+		err := w.deliver(alert)
+
+		w.mu.Lock()
+		if err != nil {
+			w.stats.Failed++
+		} else {
+			w.stats.Sent++
+		}
+		w.mu.Unlock()
+
+		if err != nil {
+			LogError("Alert webhook delivery failed", err)
+		}
+	}
+}
+
+// deliver posts one alert, retrying with backoff
+func (w *AlertWebhook) deliver(alert HoneytokenAlert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < alertWebhookAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, w.url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		signWebhookRequest(req, body, w.secret)
+
+		resp, err := w.client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		lastErr = fmt.Errorf("webhook returned %s", resp.Status)
+	}
+
+	return lastErr
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Key Export Formats
+// Serialization of symmetric master keys for secret-management pipelines
+//
+// Supported formats:
+// - JWK (RFC 7517, kty "oct") for plaintext hand-off inside trusted systems
+// - PKCS#8-style encrypted key blobs (PEM "EAMSA512 ENCRYPTED KEY"),
+//   wrapped either by a 32-byte KEK or by a passphrase (PBKDF2-SHA3-512)
+//
+// Blobs hold the key in the eamsa512.WrapKey format, so a changed or
+// truncated wrapped key fails its integrity check on import. Version 1
+// blobs, which held EncryptData output instead, are still imported.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// JWKAlgorithm is the "alg" value written into exported JWKs
+	JWKAlgorithm = "EAMSA512"
+
+	// EncryptedKeyPEMType is the PEM block type of encrypted key blobs
+	EncryptedKeyPEMType = "EAMSA512 ENCRYPTED KEY"
+
+	// Wrap algorithms recorded inside encrypted key blobs
+	WrapAlgorithmKEK        = "EAMSA512-KEK"
+	WrapAlgorithmPassphrase = "EAMSA512-PBKDF2-SHA3-512"
+
+	// Passphrase KDF defaults
+	passphraseSaltSize   = 16
+	passphraseIterations = 600000
+
+	// maxPassphraseIterations bounds the cost a blob can demand on import
+	maxPassphraseIterations = 10 * passphraseIterations
+
+	encryptedKeyVersion = 2
+
+	// encryptedKeyVersionLegacy blobs hold EncryptData output
+	encryptedKeyVersionLegacy = 1
+)
+
+// JWK represents a symmetric JSON Web Key (kty "oct")
+type JWK struct {
+	KeyType   string   `json:"kty"`               // Always "oct"
+	Key       string   `json:"k"`                 // base64url key material, no padding
+	Algorithm string   `json:"alg,omitempty"`     // "EAMSA512"
+	KeyID     string   `json:"kid,omitempty"`     // Key identifier
+	Use       string   `json:"use,omitempty"`     // "enc"
+	KeyOps    []string `json:"key_ops,omitempty"` // Permitted operations
+}
+
+// encryptedKeyInfo is the DER body of an encrypted key blob, modelled on
+// PKCS#8 EncryptedPrivateKeyInfo with the algorithm parameters inlined
+type encryptedKeyInfo struct {
+	Version       int
+	KeyAlgorithm  string `asn1:"utf8"`
+	WrapAlgorithm string `asn1:"utf8"`
+	Salt          []byte
+	Iterations    int
+	EncryptedData []byte // eamsa512.WrapKey output (version 1: EncryptData output)
+}
+
+// ============================================================================
+// JWK
+// ============================================================================
+
+// ExportKeyJWK serializes a master key as a JWK
+// key: master key (32 bytes)
+// keyID: optional "kid" value
+// Returns JSON-encoded JWK
+func ExportKeyJWK(key []byte, keyID string) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	jwk := JWK{
+		KeyType:   "oct",
+		Key:       base64.RawURLEncoding.EncodeToString(key),
+		Algorithm: JWKAlgorithm,
+		KeyID:     keyID,
+		Use:       "enc",
+		KeyOps:    []string{"encrypt", "decrypt"},
+	}
+
+	return json.Marshal(jwk)
+}
+
+// ParseKeyJWK parses a JWK produced by ExportKeyJWK (or any oct JWK holding a 32-byte key)
+// Returns the key material and its "kid"
+func ParseKeyJWK(data []byte) ([]byte, string, error) {
+	var jwk JWK
+	if err := json.Unmarshal(data, &jwk); err != nil {
+		return nil, "", fmt.Errorf("invalid JWK: %v", err)
+	}
+
+	if jwk.KeyType != "oct" {
+		return nil, "", fmt.Errorf("unsupported JWK key type: %q", jwk.KeyType)
+	}
+
+	if jwk.Algorithm != "" && jwk.Algorithm != JWKAlgorithm {
+		return nil, "", fmt.Errorf("unsupported JWK algorithm: %q", jwk.Algorithm)
+	}
+
+	key, err := base64.RawURLEncoding.DecodeString(jwk.Key)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid JWK key encoding: %v", err)
+	}
+
+	if len(key) != KeySize {
+		return nil, "", fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	return key, jwk.KeyID, nil
+}
+
+// ============================================================================
+// PKCS#8-style encrypted keys
+// ============================================================================
+
+// ExportKeyEncrypted wraps a master key under a key-encryption key (KEK)
+// key: master key (32 bytes)
+// kek: key-encryption key (32 bytes)
+// Returns PEM-encoded encrypted key blob
+func ExportKeyEncrypted(key []byte, kek []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	wrapped, err := eamsa512.WrapKey(kek, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %v", err)
+	}
+
+	return marshalEncryptedKey(encryptedKeyInfo{
+		Version:       encryptedKeyVersion,
+		KeyAlgorithm:  JWKAlgorithm,
+		WrapAlgorithm: WrapAlgorithmKEK,
+		Salt:          []byte{},
+		EncryptedData: wrapped,
+	})
+}
+
+// ExportKeyWithPassphrase wraps a master key under a passphrase-derived KEK
+// key: master key (32 bytes)
+// passphrase: non-empty passphrase
+// Returns PEM-encoded encrypted key blob
+func ExportKeyWithPassphrase(key []byte, passphrase []byte) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+
+	salt := make([]byte, passphraseSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	kek := derivePassphraseKEK(passphrase, salt, passphraseIterations)
+
+	wrapped, err := eamsa512.WrapKey(kek, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap key: %v", err)
+	}
+
+	return marshalEncryptedKey(encryptedKeyInfo{
+		Version:       encryptedKeyVersion,
+		KeyAlgorithm:  JWKAlgorithm,
+		WrapAlgorithm: WrapAlgorithmPassphrase,
+		Salt:          salt,
+		Iterations:    passphraseIterations,
+		EncryptedData: wrapped,
+	})
+}
+
+// ImportKeyEncrypted unwraps a KEK-wrapped key blob
+func ImportKeyEncrypted(pemData []byte, kek []byte) ([]byte, error) {
+	info, err := parseEncryptedKey(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.WrapAlgorithm != WrapAlgorithmKEK {
+		return nil, fmt.Errorf("key blob is not KEK-wrapped (algorithm: %s)", info.WrapAlgorithm)
+	}
+
+	return unwrapEncryptedKey(info, kek)
+}
+
+// ImportKeyWithPassphrase unwraps a passphrase-wrapped key blob
+func ImportKeyWithPassphrase(pemData []byte, passphrase []byte) ([]byte, error) {
+	info, err := parseEncryptedKey(pemData)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.WrapAlgorithm != WrapAlgorithmPassphrase {
+		return nil, fmt.Errorf("key blob is not passphrase-wrapped (algorithm: %s)", info.WrapAlgorithm)
+	}
+
+	// The blob is untrusted: a low count would accept a trivially weak
+	// KEK, and a huge one would tie up the CPU
+	if info.Iterations < passphraseIterations || info.Iterations > maxPassphraseIterations {
+		return nil, fmt.Errorf("invalid passphrase KDF iterations %d: want %d to %d", info.Iterations, passphraseIterations, maxPassphraseIterations)
+	}
+	if len(info.Salt) == 0 {
+		return nil, fmt.Errorf("invalid passphrase KDF parameters")
+	}
+
+	kek := derivePassphraseKEK(passphrase, info.Salt, info.Iterations)
+	return unwrapEncryptedKey(info, kek)
+}
+
+// derivePassphraseKEK derives a 32-byte KEK from a passphrase with PBKDF2-SHA3-512
+func derivePassphraseKEK(passphrase, salt []byte, iterations int) []byte {
+	return pbkdf2.Key(passphrase, salt, iterations, KeySize, func() hash.Hash {
+		return sha3.New512()
+	})
+}
+
+// marshalEncryptedKey DER-encodes and PEM-armors an encrypted key blob
+func marshalEncryptedKey(info encryptedKeyInfo) ([]byte, error) {
+	der, err := asn1.Marshal(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode key blob: %v", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    EncryptedKeyPEMType,
+		Headers: map[string]string{"Wrap-Algorithm": info.WrapAlgorithm},
+		Bytes:   der,
+	}), nil
+}
+
+// parseEncryptedKey decodes a PEM-armored encrypted key blob
+func parseEncryptedKey(pemData []byte) (*encryptedKeyInfo, error) {
+	block, _ := pem.Decode(pemData)
+	if block == nil || block.Type != EncryptedKeyPEMType {
+		return nil, fmt.Errorf("no %s PEM block found", EncryptedKeyPEMType)
+	}
+
+	var info encryptedKeyInfo
+	rest, err := asn1.Unmarshal(block.Bytes, &info)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key blob: %v", err)
+	}
+	if len(rest) != 0 {
+		return nil, fmt.Errorf("invalid key blob: trailing data")
+	}
+
+	if info.Version != encryptedKeyVersion && info.Version != encryptedKeyVersionLegacy {
+		return nil, fmt.Errorf("unsupported key blob version: %d", info.Version)
+	}
+
+	if info.KeyAlgorithm != JWKAlgorithm {
+		return nil, fmt.Errorf("unsupported key algorithm: %s", info.KeyAlgorithm)
+	}
+
+	return &info, nil
+}
+
+// unwrapEncryptedKey unwraps and validates the wrapped key material
+func unwrapEncryptedKey(info *encryptedKeyInfo, kek []byte) ([]byte, error) {
+	var key []byte
+	var err error
+	if info.Version == encryptedKeyVersionLegacy {
+		key, err = DecryptData(info.EncryptedData, kek)
+	} else {
+		key, err = eamsa512.UnwrapKey(kek, info.EncryptedData)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key: %v", err)
+	}
+
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid unwrapped key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	return key, nil
+}
+
+// ============================================================================
+// KeyManager integration
+// ============================================================================
+
+// ExportKeyJWK exports a key version as a JWK (kid = key ID)
+func (km *KeyManager) ExportKeyJWK(version int) ([]byte, error) {
+	key, err := km.GetKeyByVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := ExportKeyJWK(key, fmt.Sprintf("key_%d", version))
+	if err != nil {
+		return nil, err
+	}
+
+	km.Auditf("KEY_EXPORT version=%d format=jwk", version)
+
+	return jwk, nil
+}
+
+// ExportKeyEncrypted exports a key version as a KEK-wrapped PKCS#8-style blob
+func (km *KeyManager) ExportKeyEncrypted(version int, kek []byte) ([]byte, error) {
+	key, err := km.GetKeyByVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := ExportKeyEncrypted(key, kek)
+	if err != nil {
+		return nil, err
+	}
+
+	km.Auditf("KEY_EXPORT version=%d format=pkcs8 wrap=%s", version, WrapAlgorithmKEK)
+
+	return blob, nil
+}
+
+// ExportKeyWithPassphrase exports a key version as a passphrase-wrapped PKCS#8-style blob
+func (km *KeyManager) ExportKeyWithPassphrase(version int, passphrase []byte) ([]byte, error) {
+	key, err := km.GetKeyByVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	blob, err := ExportKeyWithPassphrase(key, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	km.Auditf("KEY_EXPORT version=%d format=pkcs8 wrap=%s", version, WrapAlgorithmPassphrase)
+
+	return blob, nil
+}
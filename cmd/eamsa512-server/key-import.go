@@ -0,0 +1,404 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/bits"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ============================================================================
+// EAMSA 512 - External Key Import
+// Imports master keys generated elsewhere and wrapped by a cloud KMS or PGP
+//
+// A key arrives as ciphertext produced by AWS KMS, Google Cloud KMS, Azure
+// Key Vault or a PGP encryption to our operators. A KeyUnwrapper turns it
+// back into key material: applications adapt their KMS client library,
+// and the import-key command runs the provider's CLI (aws, gcloud, az,
+// gpg) as a CommandUnwrapper, so no KMS SDK is linked in. The unwrapped
+// key is checked for length and obvious lack of entropy before use, and
+// where it came from is recorded with its key version.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// External key providers
+const (
+	ProviderAWSKMS        = "aws-kms"
+	ProviderGCPKMS        = "gcp-kms"
+	ProviderAzureKeyVault = "azure-keyvault"
+	ProviderPGP           = "pgp"
+)
+
+// DefaultUnwrapTimeout bounds one CommandUnwrapper run
+const DefaultUnwrapTimeout = 30 * time.Second
+
+// knownProviders are the accepted WrappedKeyImport.Provider values
+var knownProviders = map[string]bool{
+	ProviderAWSKMS:        true,
+	ProviderGCPKMS:        true,
+	ProviderAzureKeyVault: true,
+	ProviderPGP:           true,
+}
+
+// WrappedKeyImport is an externally wrapped key, as read from an import
+// request file
+type WrappedKeyImport struct {
+	Provider   string            `json:"provider"`          // One of the Provider constants
+	KeyRef     string            `json:"key_ref"`           // KMS key ARN/resource name, or PGP key fingerprint
+	Ciphertext []byte            `json:"ciphertext"`        // Wrapped key (base64 in JSON)
+	Context    map[string]string `json:"context,omitempty"` // KMS encryption context / additional data
+}
+
+// KeyProvenance records where a key version came from
+type KeyProvenance struct {
+	Origin        string    `json:"origin"`                   // "generated" or "imported"
+	Provider      string    `json:"provider,omitempty"`       // External provider of an imported key
+	KeyRef        string    `json:"key_ref,omitempty"`        // Wrapping key at the provider
+	WrappedDigest string    `json:"wrapped_digest,omitempty"` // SHA3-256 of the wrapped blob (hex)
+	ImportedAt    time.Time `json:"imported_at,omitempty"`
+	ImportedBy    string    `json:"imported_by,omitempty"`
+}
+
+// ImportedKey is an unwrapped and validated external key
+type ImportedKey struct {
+	Material   []byte
+	KeyHash    string // keyid format
+	Provenance KeyProvenance
+}
+
+// KeyUnwrapper decrypts an externally wrapped key
+type KeyUnwrapper interface {
+	Unwrap(ctx context.Context, req *WrappedKeyImport) ([]byte, error)
+}
+
+// KeyUnwrapperFunc adapts a function (e.g. around a KMS client's Decrypt
+// call) to KeyUnwrapper
+type KeyUnwrapperFunc func(ctx context.Context, req *WrappedKeyImport) ([]byte, error)
+
+// Unwrap calls f
+func (f KeyUnwrapperFunc) Unwrap(ctx context.Context, req *WrappedKeyImport) ([]byte, error) {
+	return f(ctx, req)
+}
+
+// CommandUnwrapper unwraps by running a shell command that reads the
+// wrapped key on stdin and writes the key (raw, hex or base64) to stdout.
+// EAMSA_KMS_PROVIDER and EAMSA_KMS_KEY_REF are set for the command, and
+// EAMSA_KMS_CONTEXT holds the context as JSON.
+type CommandUnwrapper struct {
+	Command string        // Run with /bin/sh -c
+	Timeout time.Duration // 0: DefaultUnwrapTimeout
+}
+
+// Unwrap runs the command
+func (c CommandUnwrapper) Unwrap(ctx context.Context, req *WrappedKeyImport) ([]byte, error) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultUnwrapTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	contextJSON, err := json.Marshal(req.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(req.Ciphertext)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	cmd.Env = append(os.Environ(),
+		"EAMSA_KMS_PROVIDER="+req.Provider,
+		"EAMSA_KMS_KEY_REF="+req.KeyRef,
+		"EAMSA_KMS_CONTEXT="+string(contextJSON))
+
+	if err := cmd.Run(); err != nil {
+		zeroizeKey(stdout.Bytes())
+		return nil, fmt.Errorf("unwrap command failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	key, err := decodeUnwrappedKey(stdout.Bytes())
+	zeroizeKey(stdout.Bytes())
+	return key, err
+}
+
+// ImportWrappedKey unwraps req with unwrapper, validates the key and
+// returns it with its provenance. importedBy names the operator.
+func ImportWrappedKey(ctx context.Context, req *WrappedKeyImport, unwrapper KeyUnwrapper, importedBy string) (*ImportedKey, error) {
+	if !knownProviders[req.Provider] {
+		return nil, fmt.Errorf("unknown key provider %q", req.Provider)
+	}
+	if req.KeyRef == "" {
+		return nil, fmt.Errorf("key_ref is required")
+	}
+	if len(req.Ciphertext) == 0 {
+		return nil, fmt.Errorf("ciphertext is required")
+	}
+
+	key, err := unwrapper.Unwrap(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap %s key: %v", req.Provider, err)
+	}
+
+	if err := ValidateImportedKey(key); err != nil {
+		zeroizeKey(key)
+		return nil, err
+	}
+
+	digest := sha3.Sum256(req.Ciphertext)
+
+	return &ImportedKey{
+		Material: key,
+		KeyHash:  keyid.New(key).String(),
+		Provenance: KeyProvenance{
+			Origin:        "imported",
+			Provider:      req.Provider,
+			KeyRef:        req.KeyRef,
+			WrappedDigest: hex.EncodeToString(digest[:]),
+			ImportedAt:    time.Now().UTC(),
+			ImportedBy:    importedBy,
+		},
+	}, nil
+}
+
+// ValidateImportedKey rejects keys of the wrong length and keys that are
+// clearly not random: few distinct bytes, long runs or a skewed bit
+// count. These checks catch test keys, padded plaintext and encoding
+// mistakes, not a weak generator; a uniformly random key fails them about
+// once in 10^8 imports.
+func ValidateImportedKey(key []byte) error {
+	if len(key) != KeySize {
+		return fmt.Errorf("invalid imported key size: expected %d bytes, got %d", KeySize, len(key))
+	}
+
+	distinct := make(map[byte]bool)
+	ones, run, longestRun := 0, 1, 1
+	for i, b := range key {
+		distinct[b] = true
+		ones += bits.OnesCount8(b)
+
+		if i > 0 && b == key[i-1] {
+			run++
+			if run > longestRun {
+				longestRun = run
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	switch {
+	case len(distinct) < 16:
+		return fmt.Errorf("imported key has too little entropy: %d distinct byte values", len(distinct))
+	case longestRun >= 5:
+		return fmt.Errorf("imported key has too little entropy: %d repeated bytes", longestRun)
+	case ones < 80 || ones > 176:
+		return fmt.Errorf("imported key has too little entropy: %d of 256 bits set", ones)
+	}
+
+	return nil
+}
+
+// decodeUnwrappedKey accepts a raw key, or hex or base64 text
+func decodeUnwrappedKey(out []byte) ([]byte, error) {
+	if len(out) == KeySize {
+		return append([]byte(nil), out...), nil
+	}
+
+	text := strings.TrimSpace(string(out))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == KeySize {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("unwrap command output is not a %d-byte key (raw, hex or base64)", KeySize)
+}
+
+// ReadWrappedKeyImport reads an import request file
+func ReadWrappedKeyImport(path string) (*WrappedKeyImport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read import request: %v", err)
+	}
+
+	var req WrappedKeyImport
+	if err := json.Unmarshal(data, &req); err != nil {
+		return nil, fmt.Errorf("invalid import request %s: %v", path, err)
+	}
+	return &req, nil
+}
+
+// RunImportKeyCommand implements "import-key": unwraps an external key,
+// stores it wrapped under the local KEK and records it as a pending key
+// version with its provenance
+//
+//	eamsa512-server import-key -request key.json -kek-file kek.hex -user alice \
+//	    -unwrap-command 'aws kms decrypt --ciphertext-blob fileb:///dev/stdin --query Plaintext --output text' \
+//	    [-db path] [-version N] [-id entry-id] [-force]
+func RunImportKeyCommand(args []string) error {
+	fs := flag.NewFlagSet("import-key", flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to keystore database")
+	requestFile := fs.String("request", "", "JSON file with provider, key_ref, ciphertext (base64) and context")
+	unwrapCommand := fs.String("unwrap-command", "", "Shell command that reads the wrapped key on stdin and prints the key")
+	kekFile := fs.String("kek-file", "", "File containing the local KEK (hex)")
+	user := fs.String("user", "", "Operator importing the key")
+	version := fs.Int("version", 0, "Key version to record (default: next version)")
+	entryID := fs.String("id", "", "Keystore entry ID (default: imported-v<version>)")
+	force := fs.Bool("force", false, "Open the database even if another process holds its lock (audited)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if *requestFile == "" || *unwrapCommand == "" || *kekFile == "" || *user == "" {
+		return fmt.Errorf("-request, -unwrap-command, -kek-file and -user are required")
+	}
+
+	req, err := ReadWrappedKeyImport(*requestFile)
+	if err != nil {
+		return err
+	}
+
+	kek, err := readKEKFile(*kekFile)
+	if err != nil {
+		return err
+	}
+	defer zeroizeKey(kek)
+
+	imported, err := ImportWrappedKey(context.Background(), req, CommandUnwrapper{Command: *unwrapCommand}, *user)
+	if err != nil {
+		return err
+	}
+	defer zeroizeKey(imported.Material)
+
+	db, err := OpenDatabase(*dbPath, StoreOpenOptions{Force: *force})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if existing, err := db.GetKeyVersionByHash(imported.KeyHash); err != nil {
+		return err
+	} else if existing != nil {
+		return fmt.Errorf("key %s is already recorded as version %d", imported.KeyHash, existing.Version)
+	}
+
+	if *version == 0 {
+		versions, err := db.GetKeyVersions()
+		if err != nil {
+			return err
+		}
+		*version = 1
+		if len(versions) > 0 {
+			*version = versions[0].Version + 1
+		}
+	}
+	if *entryID == "" {
+		*entryID = fmt.Sprintf("imported-v%d", *version)
+	}
+
+	blob, err := ExportKeyEncrypted(imported.Material, kek)
+	if err != nil {
+		return err
+	}
+	if err := db.StoreWrappedKey(WrappedKeyEntry{ID: *entryID, WrappedKey: string(blob), KEKID: keyid.New(kek).String()}); err != nil {
+		return err
+	}
+
+	provenance := imported.Provenance
+	if err := db.RecordKeyVersion(KeyVersionRecord{
+		Version:    *version,
+		State:      string(keymgmt.KeyStatePending),
+		KeyHash:    imported.KeyHash,
+		CreatedAt:  provenance.ImportedAt,
+		Provenance: &provenance,
+	}); err != nil {
+		return err
+	}
+
+	detailsJSON, _ := json.Marshal(map[string]interface{}{
+		"version":        *version,
+		"key_id":         imported.KeyHash,
+		"provider":       provenance.Provider,
+		"key_ref":        provenance.KeyRef,
+		"wrapped_digest": provenance.WrappedDigest,
+	})
+	db.RecordAuditLog(AuditLogEntry{
+		EventType: "KEY_IMPORTED",
+		Category:  "admin",
+		Severity:  "info",
+		Details:   string(detailsJSON),
+		Timestamp: time.Now(),
+		UserID:    *user,
+	})
+	serverTelemetry.ObserveKeyEvent(telemetry.KeyImported, imported.KeyHash)
+
+	fmt.Printf("Imported %s key %s as pending version %d (entry %s)\n",
+		provenance.Provider, imported.KeyHash, *version, *entryID)
+	return nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. REQUEST FILE
+
+   {"provider": "aws-kms",
+    "key_ref": "arn:aws:kms:eu-west-1:111122223333:key/1234abcd-...",
+    "ciphertext": "AQICAHh...",
+    "context": {"purpose": "eamsa512-master"}}
+
+   provider is aws-kms, gcp-kms, azure-keyvault or pgp. ciphertext is the
+   provider's wrapped output, base64-encoded.
+
+2. UNWRAP COMMANDS
+
+   - aws-kms: aws kms decrypt --ciphertext-blob fileb:///dev/stdin
+       --key-id "$EAMSA_KMS_KEY_REF" --query Plaintext --output text
+   - gcp-kms: gcloud kms decrypt --key "$EAMSA_KMS_KEY_REF"
+       --ciphertext-file - --plaintext-file -
+   - azure-keyvault: az keyvault key decrypt --id "$EAMSA_KMS_KEY_REF"
+       --algorithm RSA-OAEP-256 --value "$(base64 -w0)" --query result -o tsv
+   - pgp: gpg --batch --decrypt
+
+   The command's stdout must be the 32-byte key, raw or as hex or base64.
+   It is zeroized after decoding; the key is never logged.
+
+3. VALIDATION
+   - Exactly 32 bytes
+   - At least 16 distinct byte values, no run of 5 equal bytes, and
+     between 80 and 176 of 256 bits set
+
+4. PROVENANCE
+   - key_versions.provenance holds origin, provider, key_ref, the SHA3-256
+     of the wrapped blob, and who imported it when
+   - The key is stored in wrapped_keys under the local KEK and recorded
+     as pending; activate it through the usual rotation
+   - KEY_IMPORTED is audited with the same fields
+
+*/
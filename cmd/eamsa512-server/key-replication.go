@@ -0,0 +1,1096 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// ============================================================================
+// EAMSA 512 - Multi-Region Key Replication
+// Asynchronous replication of an embedded keystore between regions
+//
+// Every region runs a KeyReplicator on its own Embedded keystore and
+// periodically pushes its whole keystore to its peers: key metadata and
+// each usable key wrapped under a replication key-encryption key shared by
+// all regions. Messages are authenticated under the same key.
+//
+// Each key version carries a version vector counting the changes every
+// region has made to it. A receiver takes a record that dominates its own,
+// ignores one it dominates, and merges concurrent ones:
+//
+//   same key, concurrent changes  state furthest along the lifecycle wins;
+//                                 differing labels are a "labels" conflict
+//   different keys, same version  two regions rotated concurrently: a
+//                                 "key_material" conflict
+//
+// A key_material conflict keeps the key that was not pending, then the
+// older one, then the one from the region that sorts first, so both
+// regions pick the same winner. The losing region moves its key to a new
+// version marked RenumberedFrom, decrypt-only, and Embedded.Decrypt tries
+// it for data sealed under the old number; re-encrypting that data is
+// recommended. Of several active versions the highest stays active.
+//
+// Standby keys (see PrepareStandby) are local to their region and yield
+// to any replicated record of their version.
+//
+// Report returns the reconciliation report: conflicts and, per peer, the
+// versions either side has not yet confirmed.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// ReplicationStateFile holds version vectors, peer status and
+	// conflicts in the data directory
+	ReplicationStateFile = "replication.json"
+
+	// DefaultReplicationInterval is the time between pushes
+	DefaultReplicationInterval = 30 * time.Second
+
+	// replicationMACLabel derives the message authentication key from the
+	// replication KEK
+	replicationMACLabel = "EAMSA512-REPLICATION-MAC"
+)
+
+// Replication conflict kinds
+const (
+	ConflictKeyMaterial = "key_material" // Different keys under one version
+	ConflictLabels      = "labels"       // Concurrent label changes
+)
+
+// VersionVector counts the changes each region has made to a record
+type VersionVector map[string]uint64
+
+// VectorOrder is how two version vectors relate
+type VectorOrder int
+
+const (
+	VectorEqual      VectorOrder = iota // Same changes
+	VectorBefore                        // Every change of the first is in the second
+	VectorAfter                         // Every change of the second is in the first
+	VectorConcurrent                    // Each has changes the other lacks
+)
+
+// Compare relates v to o
+func (v VersionVector) Compare(o VersionVector) VectorOrder {
+	less, greater := false, false
+	for region, n := range v {
+		if n > o[region] {
+			greater = true
+		} else if n < o[region] {
+			less = true
+		}
+	}
+	for region, n := range o {
+		if _, ok := v[region]; !ok && n > 0 {
+			less = true
+		}
+	}
+
+	switch {
+	case less && greater:
+		return VectorConcurrent
+	case less:
+		return VectorBefore
+	case greater:
+		return VectorAfter
+	}
+	return VectorEqual
+}
+
+// Merge returns the element-wise maximum of v and o
+func (v VersionVector) Merge(o VersionVector) VersionVector {
+	merged := v.Copy()
+	for region, n := range o {
+		if n > merged[region] {
+			merged[region] = n
+		}
+	}
+	return merged
+}
+
+// Copy returns a copy of v
+func (v VersionVector) Copy() VersionVector {
+	c := make(VersionVector, len(v))
+	for region, n := range v {
+		c[region] = n
+	}
+	return c
+}
+
+// ReplicationPeer carries sealed replication messages to another region
+type ReplicationPeer interface {
+	Region() string
+
+	// Exchange delivers message and returns the peer's sealed
+	// acknowledgement
+	Exchange(ctx context.Context, message []byte) ([]byte, error)
+}
+
+// ReplicationConfig configures a KeyReplicator
+type ReplicationConfig struct {
+	Region   string // This region, e.g. "eu-west-1"
+	KEK      []byte // Replication key-encryption key, the same in every region (32 bytes)
+	Peers    []ReplicationPeer
+	Interval time.Duration // Between pushes (default DefaultReplicationInterval)
+}
+
+// ReplicationConflict is a conflict found while applying a peer's keystore
+type ReplicationConflict struct {
+	Version      int       `json:"version"`
+	Kind         string    `json:"kind"`    // ConflictKeyMaterial or ConflictLabels
+	Regions      []string  `json:"regions"` // Regions whose records competed
+	Winner       string    `json:"winner"`
+	RenumberedTo int       `json:"renumbered_to,omitempty"` // New version of this region's losing key
+	DetectedAt   time.Time `json:"detected_at"`
+	Detail       string    `json:"detail"`
+}
+
+// PeerReconciliation is the replication status of one peer
+type PeerReconciliation struct {
+	Region       string    `json:"region"`
+	LastExchange time.Time `json:"last_exchange,omitempty"` // Last push acknowledged by the peer
+	LastReceived time.Time `json:"last_received,omitempty"` // Last push received from the peer
+	LastError    string    `json:"last_error,omitempty"`
+	InSync       bool      `json:"in_sync"`
+
+	// Unconfirmed are versions with local changes the peer has not
+	// acknowledged; Missing are versions with changes the peer has that
+	// this region has not received
+	Unconfirmed []int `json:"unconfirmed,omitempty"`
+	Missing     []int `json:"missing,omitempty"`
+}
+
+// ReconciliationReport summarizes replication from one region's view
+type ReconciliationReport struct {
+	Region      string                `json:"region"`
+	GeneratedAt time.Time             `json:"generated_at"`
+	Versions    int                   `json:"versions"`
+	Peers       []PeerReconciliation  `json:"peers"`
+	Conflicts   []ReplicationConflict `json:"conflicts"`
+}
+
+// replicatedKey is one key version as sent between regions
+type replicatedKey struct {
+	Metadata  keymgmt.KeyMetadata `json:"metadata"` // Without usage counters, which stay local
+	ExpiresAt time.Time           `json:"expires_at"`
+	Wrapped   []byte              `json:"wrapped,omitempty"` // WrapKeyWithAAD under the replication KEK
+	Origin    string              `json:"origin"`            // Region that generated the key
+	Clock     VersionVector       `json:"clock"`
+}
+
+// replicationBatch is a region's whole keystore
+type replicationBatch struct {
+	Region string          `json:"region"`
+	SentAt time.Time       `json:"sent_at"`
+	Keys   []replicatedKey `json:"keys"`
+}
+
+// replicationAck answers a batch with the receiver's clocks after applying it
+type replicationAck struct {
+	Region string                `json:"region"`
+	Clocks map[int]VersionVector `json:"clocks"`
+}
+
+// replicationMessage is a batch or acknowledgement with its MAC
+type replicationMessage struct {
+	Type string          `json:"type"` // "batch" or "ack"
+	Body json.RawMessage `json:"body"`
+	MAC  []byte          `json:"mac"`
+}
+
+// replicaState is the replication state of one local key version
+type replicaState struct {
+	Clock  VersionVector `json:"clock"`
+	Origin string        `json:"origin"`
+	Digest string        `json:"digest"` // Of the replicated fields Clock describes
+}
+
+// peerState is what this region knows about a peer
+type peerState struct {
+	Clocks       map[int]VersionVector `json:"clocks"` // Last acknowledged or received
+	LastExchange time.Time             `json:"last_exchange"`
+	LastReceived time.Time             `json:"last_received"`
+	LastError    string                `json:"last_error,omitempty"`
+}
+
+// replicationFile is the persisted replication state
+type replicationFile struct {
+	Region    string                `json:"region"`
+	Keys      map[int]*replicaState `json:"keys"`
+	Peers     map[string]*peerState `json:"peers"`
+	Conflicts []ReplicationConflict `json:"conflicts"`
+}
+
+// KeyReplicator replicates an Embedded keystore to other regions
+type KeyReplicator struct {
+	emb    *Embedded
+	region string
+	kek    []byte
+	macKey []byte
+	peers  []ReplicationPeer
+
+	interval time.Duration
+	stopCh   chan struct{}
+	done     chan struct{}
+
+	mu    sync.Mutex // Held while syncing or applying; taken before emb.mu
+	state replicationFile
+}
+
+// NewKeyReplicator returns a replicator for e, loading its replication
+// state from the data directory. Start begins pushing to the peers; peers
+// push to Receive (or ServeHTTP).
+func NewKeyReplicator(e *Embedded, cfg ReplicationConfig) (*KeyReplicator, error) {
+	if cfg.Region == "" || strings.ContainsAny(cfg.Region, " \t\n=") {
+		return nil, fmt.Errorf("invalid region %q", cfg.Region)
+	}
+	if len(cfg.KEK) != KeySize {
+		return nil, fmt.Errorf("invalid replication KEK size: expected %d bytes, got %d", KeySize, len(cfg.KEK))
+	}
+	if e.readOnly {
+		return nil, errEmbeddedReadOnly
+	}
+	for _, peer := range cfg.Peers {
+		if peer.Region() == cfg.Region {
+			return nil, fmt.Errorf("peer %q is this region", peer.Region())
+		}
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultReplicationInterval
+	}
+
+	r := &KeyReplicator{
+		emb:      e,
+		region:   cfg.Region,
+		kek:      append([]byte(nil), cfg.KEK...),
+		macKey:   eamsa512.ComputeHMAC(cfg.KEK, []byte(replicationMACLabel))[:KeySize],
+		peers:    cfg.Peers,
+		interval: cfg.Interval,
+		state: replicationFile{
+			Region: cfg.Region,
+			Keys:   make(map[int]*replicaState),
+			Peers:  make(map[string]*peerState),
+		},
+	}
+
+	data, err := os.ReadFile(filepath.Join(e.dir, ReplicationStateFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read replication state: %v", err)
+	}
+	if err == nil {
+		var state replicationFile
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("invalid replication state: %v", err)
+		}
+		if state.Region != cfg.Region {
+			return nil, fmt.Errorf("replication state belongs to region %q, not %q", state.Region, cfg.Region)
+		}
+		if state.Keys != nil {
+			r.state.Keys = state.Keys
+		}
+		if state.Peers != nil {
+			r.state.Peers = state.Peers
+		}
+		r.state.Conflicts = state.Conflicts
+	}
+
+	return r, nil
+}
+
+// Start pushes the keystore to every peer now and then every interval,
+// until Stop. Failures are audited and shown in Report.
+func (r *KeyReplicator) Start() {
+	r.stopCh = make(chan struct{})
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			ctx, cancel := context.WithTimeout(context.Background(), r.interval)
+			r.SyncNow(ctx)
+			cancel()
+
+			select {
+			case <-ticker.C:
+			case <-r.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends the pushes started by Start and waits for a push in progress
+func (r *KeyReplicator) Stop() {
+	if r.stopCh == nil {
+		return
+	}
+	close(r.stopCh)
+	<-r.done
+	r.stopCh = nil
+}
+
+// SyncNow pushes the keystore to every peer and returns the first failure
+func (r *KeyReplicator) SyncNow(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	message, err := r.sealBatchLocked()
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, peer := range r.peers {
+		ps := r.peerLocked(peer.Region())
+		err := r.exchange(ctx, peer, message, ps)
+		if err != nil {
+			ps.LastError = err.Error()
+			r.emb.audit.Printf("KEY_REPLICATION_FAILED peer=%s error=%q", peer.Region(), err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("peer %s: %v", peer.Region(), err)
+			}
+			continue
+		}
+		ps.LastError = ""
+		ps.LastExchange = time.Now().UTC()
+	}
+
+	if err := r.saveStateLocked(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// exchange sends message to peer and records its acknowledged clocks
+func (r *KeyReplicator) exchange(ctx context.Context, peer ReplicationPeer, message []byte, ps *peerState) error {
+	reply, err := peer.Exchange(ctx, message)
+	if err != nil {
+		return err
+	}
+
+	var ack replicationAck
+	if err := r.open(reply, "ack", &ack); err != nil {
+		return err
+	}
+	if ack.Region != peer.Region() {
+		return fmt.Errorf("acknowledged by region %q", ack.Region)
+	}
+	ps.Clocks = ack.Clocks
+	return nil
+}
+
+// Receive applies a peer's sealed keystore and returns the sealed
+// acknowledgement. It is what a ReplicationPeer's Exchange reaches.
+func (r *KeyReplicator) Receive(message []byte) ([]byte, error) {
+	var batch replicationBatch
+	if err := r.open(message, "batch", &batch); err != nil {
+		return nil, err
+	}
+	if batch.Region == r.region || batch.Region == "" {
+		return nil, fmt.Errorf("batch from invalid region %q", batch.Region)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.applyLocked(&batch); err != nil {
+		r.emb.audit.Printf("KEY_REPLICATION_REJECTED peer=%s error=%q", batch.Region, err)
+		return nil, err
+	}
+
+	ack := replicationAck{Region: r.region, Clocks: r.clocksLocked()}
+	ps := r.peerLocked(batch.Region)
+	ps.LastReceived = time.Now().UTC()
+	ps.Clocks = mergeClocks(ps.Clocks, batchClocks(&batch))
+	if err := r.saveStateLocked(); err != nil {
+		return nil, err
+	}
+	return r.seal("ack", ack)
+}
+
+// ServeHTTP accepts pushes from HTTPReplicationPeer. Mount it behind
+// mutual TLS; messages are authenticated, but the endpoint is not
+// rate limited.
+func (r *KeyReplicator) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	message, err := io.ReadAll(io.LimitReader(req.Body, 16<<20))
+	if err != nil {
+		http.Error(w, "failed to read message", http.StatusBadRequest)
+		return
+	}
+
+	reply, err := r.Receive(message)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reply)
+}
+
+// HTTPReplicationPeer pushes to a KeyReplicator served over HTTP(S)
+type HTTPReplicationPeer struct {
+	Name   string // Region of the peer
+	URL    string // e.g. https://us-east-1.keys.internal/replication
+	Client *http.Client
+}
+
+// Region implements ReplicationPeer
+func (p *HTTPReplicationPeer) Region() string {
+	return p.Name
+}
+
+// Exchange implements ReplicationPeer
+func (p *HTTPReplicationPeer) Exchange(ctx context.Context, message []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.URL, bytes.NewReader(message))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("peer returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// Report returns the reconciliation report
+func (r *KeyReplicator) Report() ReconciliationReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.emb.mu.RLock()
+	r.observeLocalLocked()
+	r.emb.mu.RUnlock()
+
+	report := ReconciliationReport{
+		Region:      r.region,
+		GeneratedAt: time.Now().UTC(),
+		Versions:    len(r.state.Keys),
+		Peers:       []PeerReconciliation{},
+		Conflicts:   append([]ReplicationConflict{}, r.state.Conflicts...),
+	}
+
+	regions := make([]string, 0, len(r.state.Peers))
+	for region := range r.state.Peers {
+		regions = append(regions, region)
+	}
+	for _, peer := range r.peers {
+		if _, ok := r.state.Peers[peer.Region()]; !ok {
+			regions = append(regions, peer.Region())
+		}
+	}
+	sort.Strings(regions)
+
+	for _, region := range regions {
+		ps := r.peerLocked(region)
+		pr := PeerReconciliation{
+			Region:       region,
+			LastExchange: ps.LastExchange,
+			LastReceived: ps.LastReceived,
+			LastError:    ps.LastError,
+		}
+
+		for version, local := range r.state.Keys {
+			switch local.Clock.Compare(ps.Clocks[version]) {
+			case VectorAfter:
+				pr.Unconfirmed = append(pr.Unconfirmed, version)
+			case VectorBefore:
+				pr.Missing = append(pr.Missing, version)
+			case VectorConcurrent:
+				pr.Unconfirmed = append(pr.Unconfirmed, version)
+				pr.Missing = append(pr.Missing, version)
+			}
+		}
+		for version := range ps.Clocks {
+			if _, ok := r.state.Keys[version]; !ok {
+				pr.Missing = append(pr.Missing, version)
+			}
+		}
+		sort.Ints(pr.Unconfirmed)
+		sort.Ints(pr.Missing)
+		pr.InSync = len(pr.Unconfirmed) == 0 && len(pr.Missing) == 0 && pr.LastError == ""
+
+		report.Peers = append(report.Peers, pr)
+	}
+
+	return report
+}
+
+// sealBatchLocked records local changes and seals the keystore as a batch
+func (r *KeyReplicator) sealBatchLocked() ([]byte, error) {
+	r.emb.mu.RLock()
+	defer r.emb.mu.RUnlock()
+
+	if r.emb.closed {
+		return nil, fmt.Errorf("embedded keystore is closed")
+	}
+	r.observeLocalLocked()
+
+	batch := replicationBatch{Region: r.region, SentAt: time.Now().UTC()}
+	for _, entry := range r.emb.keys.Snapshot() {
+		state, ok := r.state.Keys[entry.Metadata.Version]
+		if !ok {
+			continue // Standby
+		}
+
+		record := replicatedKey{
+			Metadata:  replicatedMetadata(entry.Metadata),
+			ExpiresAt: entry.ExpiresAt,
+			Origin:    state.Origin,
+			Clock:     state.Clock.Copy(),
+		}
+		if len(entry.Material) > 0 {
+			wrapped, err := eamsa512.WrapKeyWithAAD(r.kek, entry.Material, replicationAAD(entry.Metadata))
+			zeroizeKey(entry.Material)
+			if err != nil {
+				return nil, fmt.Errorf("failed to wrap key version %d: %v", entry.Metadata.Version, err)
+			}
+			record.Wrapped = wrapped
+		}
+		batch.Keys = append(batch.Keys, record)
+	}
+
+	return r.seal("batch", batch)
+}
+
+// observeLocalLocked starts a clock for each new local key version and
+// advances this region's count for each changed one. Standby keys get no
+// clock until promoted. Caller must hold r.mu and r.emb.mu.
+func (r *KeyReplicator) observeLocalLocked() {
+	for _, entry := range r.emb.keys.Snapshot() {
+		zeroizeKey(entry.Material)
+		version := entry.Metadata.Version
+		if isStandby(entry.Metadata) {
+			continue
+		}
+
+		digest := replicationDigest(entry.Metadata, entry.ExpiresAt)
+		state, ok := r.state.Keys[version]
+		if !ok {
+			r.state.Keys[version] = &replicaState{Clock: VersionVector{r.region: 1}, Origin: r.region, Digest: digest}
+			continue
+		}
+		if state.Digest != digest {
+			state.Clock = state.Clock.Copy()
+			state.Clock[r.region]++
+			state.Digest = digest
+		}
+	}
+}
+
+// applyLocked merges a peer's keystore into the local one and saves it.
+// Caller must hold r.mu.
+func (r *KeyReplicator) applyLocked(batch *replicationBatch) error {
+	e := r.emb
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return fmt.Errorf("embedded keystore is closed")
+	}
+	r.observeLocalLocked()
+
+	entries := make(map[int]*keymgmt.KeyEntry)
+	maxVersion := 0
+	for _, entry := range e.keys.Snapshot() {
+		entry := entry
+		entries[entry.Metadata.Version] = &entry
+		maxVersion = max(maxVersion, entry.Metadata.Version)
+	}
+	for _, record := range batch.Keys {
+		maxVersion = max(maxVersion, record.Metadata.Version)
+	}
+	// Erase the snapshot's copies of key material on every path
+	defer func() {
+		for _, entry := range entries {
+			zeroizeKey(entry.Material)
+		}
+	}()
+
+	// replace puts entry in place of version's, erasing the old material
+	replace := func(version int, entry *keymgmt.KeyEntry) {
+		if old, ok := entries[version]; ok {
+			zeroizeKey(old.Material)
+		}
+		entries[version] = entry
+	}
+
+	now := time.Now().UTC()
+	installed, merged := 0, 0
+	var conflicts []ReplicationConflict
+
+	for i := range batch.Keys {
+		record := &batch.Keys[i]
+		version := record.Metadata.Version
+		if version <= 0 || len(record.Clock) == 0 || record.Origin == "" {
+			return fmt.Errorf("invalid record for key version %d", version)
+		}
+
+		remote, err := r.unwrapRecord(record)
+		if err != nil {
+			return err
+		}
+
+		local, exists := entries[version]
+		state := r.state.Keys[version]
+		if !exists || (state == nil && isStandby(local.Metadata)) {
+			// New to this region, or displacing a local standby
+			if exists {
+				e.audit.Printf("KEY_STANDBY_DISCARDED version=%d hash=%s replaced_by=%s", version, local.Metadata.KeyHash, record.Origin)
+			}
+			replace(version, remote)
+			r.state.Keys[version] = &replicaState{Clock: record.Clock.Copy(), Origin: record.Origin, Digest: replicationDigest(record.Metadata, record.ExpiresAt)}
+			installed++
+			continue
+		}
+		if state == nil {
+			return fmt.Errorf("key version %d has no replication state", version)
+		}
+
+		if local.Metadata.KeyHash != record.Metadata.KeyHash {
+			conflict, localWins, renumbered := r.resolveKeyConflict(local, state, remote, record, maxVersion+1, now)
+			conflicts = append(conflicts, conflict)
+			if localWins {
+				zeroizeKey(remote.Material)
+				continue
+			}
+			if renumbered != nil {
+				maxVersion = renumbered.Metadata.Version
+				entries[maxVersion] = renumbered
+				r.state.Keys[maxVersion] = &replicaState{
+					Clock:  VersionVector{r.region: 1},
+					Origin: state.Origin,
+					Digest: replicationDigest(renumbered.Metadata, renumbered.ExpiresAt),
+				}
+			}
+			replace(version, remote)
+			r.state.Keys[version] = &replicaState{Clock: record.Clock.Copy(), Origin: record.Origin, Digest: replicationDigest(record.Metadata, record.ExpiresAt)}
+			installed++
+			continue
+		}
+
+		switch state.Clock.Compare(record.Clock) {
+		case VectorEqual, VectorAfter:
+			// Nothing new
+			zeroizeKey(remote.Material)
+		case VectorBefore:
+			replace(version, adoptRemote(local, remote))
+			state.Clock = record.Clock.Copy()
+			state.Digest = replicationDigest(record.Metadata, record.ExpiresAt)
+			merged++
+		case VectorConcurrent:
+			result, conflict := mergeConcurrent(local, r.region, remote, batch.Region, now)
+			if conflict != nil {
+				conflicts = append(conflicts, *conflict)
+			}
+			replace(version, result)
+			// The merged clock describes the remote record; a merge that
+			// differs from it counts as a local change below
+			state.Clock = state.Clock.Merge(record.Clock)
+			state.Digest = replicationDigest(record.Metadata, record.ExpiresAt)
+			merged++
+		}
+	}
+
+	if err := settleActive(entries, now); err != nil {
+		return err
+	}
+
+	ordered := make([]*keymgmt.KeyEntry, 0, len(entries))
+	for _, entry := range entries {
+		// KeyManager keeps the entries; the deferred erase must not reach them
+		copied := *entry
+		copied.Material = append([]byte(nil), entry.Material...)
+		if len(entry.Material) == 0 {
+			copied.Material = nil
+		}
+		ordered = append(ordered, &copied)
+	}
+	if err := e.keys.ReplaceEntries(ordered); err != nil {
+		return err
+	}
+
+	if err := e.save(); err != nil {
+		e.unsaved = err
+		return err
+	}
+	e.unsaved = nil
+
+	// Changes made while merging (settled active keys, renumbered keys,
+	// merged labels) advance this region's clocks
+	r.observeLocalLocked()
+
+	for _, conflict := range conflicts {
+		e.audit.Printf("KEY_REPLICATION_CONFLICT version=%d kind=%s regions=%s winner=%s renumbered_to=%d",
+			conflict.Version, conflict.Kind, strings.Join(conflict.Regions, ","), conflict.Winner, conflict.RenumberedTo)
+	}
+	r.state.Conflicts = append(r.state.Conflicts, conflicts...)
+	e.audit.Printf("KEY_REPLICATION_APPLIED peer=%s installed=%d merged=%d conflicts=%d", batch.Region, installed, merged, len(conflicts))
+
+	e.replenishStandby()
+	return nil
+}
+
+// resolveKeyConflict settles two different keys under one version and
+// reports whether the local key keeps it. A losing local key that was
+// ever used is returned renumbered to newVersion for the caller to keep.
+func (r *KeyReplicator) resolveKeyConflict(local *keymgmt.KeyEntry, state *replicaState, remote *keymgmt.KeyEntry, record *replicatedKey, newVersion int, now time.Time) (ReplicationConflict, bool, *keymgmt.KeyEntry) {
+	version := local.Metadata.Version
+	conflict := ReplicationConflict{
+		Version:    version,
+		Kind:       ConflictKeyMaterial,
+		Regions:    []string{state.Origin, record.Origin},
+		DetectedAt: now,
+	}
+	sort.Strings(conflict.Regions)
+
+	if keyConflictWinner(local.Metadata, state.Origin, record.Metadata, record.Origin) {
+		conflict.Winner = state.Origin
+		conflict.Detail = fmt.Sprintf("kept key %s; region %s renumbers key %s", local.Metadata.KeyHash, record.Origin, record.Metadata.KeyHash)
+		return conflict, true, nil
+	}
+	conflict.Winner = record.Origin
+
+	if local.Metadata.State == keymgmt.KeyStatePending {
+		// Never encrypted with; nothing to preserve
+		conflict.Detail = fmt.Sprintf("adopted key %s; discarded pending key %s", remote.Metadata.KeyHash, local.Metadata.KeyHash)
+		return conflict, false, nil
+	}
+
+	moved := *local
+	moved.Material = append([]byte(nil), local.Material...)
+	moved.Metadata.Version = newVersion
+	moved.Metadata.ID = fmt.Sprintf("key_%d", newVersion)
+	moved.Metadata.Labels = local.Metadata.Labels.Copy()
+	if moved.Metadata.RenumberedFrom == 0 {
+		moved.Metadata.RenumberedFrom = version
+	}
+	if moved.Metadata.State == keymgmt.KeyStateActive {
+		moved.Metadata.State = keymgmt.KeyStateRotated
+		moved.Metadata.RotatedAt = now
+	}
+
+	conflict.RenumberedTo = newVersion
+	conflict.Detail = fmt.Sprintf("adopted key %s; key %s moved to version %d (decrypt-only); re-encrypt data this region sealed under version %d",
+		remote.Metadata.KeyHash, local.Metadata.KeyHash, newVersion, version)
+	return conflict, false, &moved
+}
+
+// unwrapRecord turns a replicated record into a key entry
+func (r *KeyReplicator) unwrapRecord(record *replicatedKey) (*keymgmt.KeyEntry, error) {
+	entry := &keymgmt.KeyEntry{Metadata: record.Metadata, ExpiresAt: record.ExpiresAt}
+	entry.Metadata.Labels = record.Metadata.Labels.Copy()
+	if len(record.Wrapped) == 0 {
+		return entry, nil
+	}
+
+	material, err := eamsa512.UnwrapKeyWithAAD(r.kek, record.Wrapped, replicationAAD(record.Metadata))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap key version %d: %v", record.Metadata.Version, err)
+	}
+	if keyid.New(material).String() != record.Metadata.KeyHash {
+		return nil, fmt.Errorf("key version %d does not match its identifier %s", record.Metadata.Version, record.Metadata.KeyHash)
+	}
+	entry.Material = material
+	return entry, nil
+}
+
+// seal authenticates v as a message of type typ
+func (r *KeyReplicator) seal(typ string, v interface{}) ([]byte, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %v", typ, err)
+	}
+	return json.Marshal(replicationMessage{Type: typ, Body: body, MAC: r.messageMAC(typ, body)})
+}
+
+// open verifies a message of type typ and decodes its body into v
+func (r *KeyReplicator) open(data []byte, typ string, v interface{}) error {
+	var message replicationMessage
+	if err := json.Unmarshal(data, &message); err != nil {
+		return fmt.Errorf("invalid replication message: %v", err)
+	}
+	if message.Type != typ || !eamsa512.VerifyHMAC(r.macKey, replicationMACInput(typ, message.Body), message.MAC) {
+		return fmt.Errorf("replication message failed authentication (wrong replication KEK?)")
+	}
+	if err := json.Unmarshal(message.Body, v); err != nil {
+		return fmt.Errorf("invalid %s: %v", typ, err)
+	}
+	return nil
+}
+
+// messageMAC authenticates a message body under the replication KEK
+func (r *KeyReplicator) messageMAC(typ string, body []byte) []byte {
+	return eamsa512.ComputeHMAC(r.macKey, replicationMACInput(typ, body))
+}
+
+// replicationMACInput is type || 0 || body
+func replicationMACInput(typ string, body []byte) []byte {
+	input := make([]byte, 0, len(typ)+1+len(body))
+	input = append(input, typ...)
+	input = append(input, 0)
+	return append(input, body...)
+}
+
+// clocksLocked returns a copy of every local clock
+func (r *KeyReplicator) clocksLocked() map[int]VersionVector {
+	clocks := make(map[int]VersionVector, len(r.state.Keys))
+	for version, state := range r.state.Keys {
+		clocks[version] = state.Clock.Copy()
+	}
+	return clocks
+}
+
+// peerLocked returns the state of region, creating it
+func (r *KeyReplicator) peerLocked(region string) *peerState {
+	ps, ok := r.state.Peers[region]
+	if !ok {
+		ps = &peerState{}
+		r.state.Peers[region] = ps
+	}
+	return ps
+}
+
+// saveStateLocked writes the replication state atomically
+func (r *KeyReplicator) saveStateLocked() error {
+	data, err := json.MarshalIndent(r.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode replication state: %v", err)
+	}
+	if err := writeFileAtomic(filepath.Join(r.emb.dir, ReplicationStateFile), data, 0600); err != nil {
+		return fmt.Errorf("failed to write replication state: %v", err)
+	}
+	return nil
+}
+
+// keyConflictWinner reports whether key a (from region aOrigin) beats key
+// b for their shared version: a key that was ever active beats a pending
+// one, then the older key wins, then the region that sorts first
+func keyConflictWinner(a keymgmt.KeyMetadata, aOrigin string, b keymgmt.KeyMetadata, bOrigin string) bool {
+	aPending, bPending := a.State == keymgmt.KeyStatePending, b.State == keymgmt.KeyStatePending
+	if aPending != bPending {
+		return bPending
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	if aOrigin != bOrigin {
+		return aOrigin < bOrigin
+	}
+	return a.KeyHash < b.KeyHash
+}
+
+// mergeConcurrent merges concurrent changes to the same key: the state
+// further along the lifecycle wins, with its timestamps; differing labels
+// are taken from the record changed by the region that sorts first
+func mergeConcurrent(local *keymgmt.KeyEntry, localRegion string, remote *keymgmt.KeyEntry, remoteRegion string, now time.Time) (*keymgmt.KeyEntry, *ReplicationConflict) {
+	result := adoptRemote(local, remote)
+	if lifecycleRank(local.Metadata.State) >= lifecycleRank(remote.Metadata.State) {
+		result.Metadata = local.Metadata
+		result.Metadata.Labels = local.Metadata.Labels.Copy()
+		result.ExpiresAt = local.ExpiresAt
+	}
+
+	var conflict *ReplicationConflict
+	if local.Metadata.Labels.String() != remote.Metadata.Labels.String() {
+		winner, labels := localRegion, local.Metadata.Labels
+		if remoteRegion < localRegion {
+			winner, labels = remoteRegion, remote.Metadata.Labels
+		}
+		result.Metadata.Labels = labels.Copy()
+		conflict = &ReplicationConflict{
+			Version:    local.Metadata.Version,
+			Kind:       ConflictLabels,
+			Regions:    []string{localRegion, remoteRegion},
+			Winner:     winner,
+			DetectedAt: now,
+			Detail:     fmt.Sprintf("labels %q and %q changed concurrently; kept %q", local.Metadata.Labels, remote.Metadata.Labels, labels),
+		}
+		sort.Strings(conflict.Regions)
+	}
+
+	if lifecycleRank(result.Metadata.State) >= lifecycleRank(keymgmt.KeyStateArchived) {
+		zeroizeKey(result.Material)
+		result.Material = nil
+	}
+	return result, conflict
+}
+
+// adoptRemote returns remote's record with local's usage counters and, if
+// remote carries none, local's key material
+func adoptRemote(local, remote *keymgmt.KeyEntry) *keymgmt.KeyEntry {
+	result := *remote
+	result.Metadata.EncryptionCount = local.Metadata.EncryptionCount
+	result.Metadata.DecryptionCount = local.Metadata.DecryptionCount
+	if len(result.Material) == 0 && lifecycleRank(remote.Metadata.State) < lifecycleRank(keymgmt.KeyStateArchived) {
+		result.Material = append([]byte(nil), local.Material...)
+	}
+	return &result
+}
+
+// settleActive leaves the highest active version active and rotates the
+// others out
+func settleActive(entries map[int]*keymgmt.KeyEntry, now time.Time) error {
+	highest := 0
+	for version, entry := range entries {
+		if entry.Metadata.State == keymgmt.KeyStateActive && version > highest {
+			highest = version
+		}
+	}
+	if highest == 0 {
+		return fmt.Errorf("merged keystore has no active key version")
+	}
+
+	for version, entry := range entries {
+		if entry.Metadata.State == keymgmt.KeyStateActive && version != highest {
+			entry.Metadata.State = keymgmt.KeyStateRotated
+			entry.Metadata.RotatedAt = now
+		}
+	}
+	return nil
+}
+
+// lifecycleRank orders key states along the lifecycle
+func lifecycleRank(state keymgmt.KeyState) int {
+	switch state {
+	case keymgmt.KeyStatePending:
+		return 0
+	case keymgmt.KeyStateActive:
+		return 1
+	case keymgmt.KeyStateRotated:
+		return 2
+	case keymgmt.KeyStateArchived:
+		return 3
+	case keymgmt.KeyStateDestroyed:
+		return 4
+	}
+	return -1
+}
+
+// isStandby reports whether metadata is a standby key (pending without an
+// activation time), which does not replicate
+func isStandby(metadata keymgmt.KeyMetadata) bool {
+	return metadata.State == keymgmt.KeyStatePending && metadata.ActivateAt.IsZero()
+}
+
+// replicatedMetadata is metadata as replicated: usage counters stay local
+func replicatedMetadata(metadata keymgmt.KeyMetadata) keymgmt.KeyMetadata {
+	metadata.EncryptionCount = 0
+	metadata.DecryptionCount = 0
+	metadata.Labels = metadata.Labels.Copy()
+	return metadata
+}
+
+// replicationDigest identifies the replicated fields of a key version
+func replicationDigest(metadata keymgmt.KeyMetadata, expiresAt time.Time) string {
+	data, _ := json.Marshal(struct {
+		Metadata  keymgmt.KeyMetadata `json:"metadata"`
+		ExpiresAt time.Time           `json:"expires_at"`
+	}{replicatedMetadata(metadata), expiresAt.UTC()})
+	sum := sha3.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// replicationAAD binds wrapped material to its version and identifier
+func replicationAAD(metadata keymgmt.KeyMetadata) []byte {
+	return []byte(fmt.Sprintf("eamsa512-replication|%d|%s", metadata.Version, metadata.KeyHash))
+}
+
+// batchClocks returns the clocks of a batch by version
+func batchClocks(batch *replicationBatch) map[int]VersionVector {
+	clocks := make(map[int]VersionVector, len(batch.Keys))
+	for _, record := range batch.Keys {
+		clocks[record.Metadata.Version] = record.Clock.Copy()
+	}
+	return clocks
+}
+
+// mergeClocks merges b into a copy of a, version by version
+func mergeClocks(a, b map[int]VersionVector) map[int]VersionVector {
+	merged := make(map[int]VersionVector, len(a)+len(b))
+	for version, clock := range a {
+		merged[version] = clock.Copy()
+	}
+	for version, clock := range b {
+		merged[version] = merged[version].Merge(clock)
+	}
+	return merged
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. WHAT REPLICATES
+   - Key metadata (states, timestamps, labels, identifiers) and usable
+     key material, wrapped with WrapKeyWithAAD under the replication KEK
+     and bound to its version and identifier
+   - Not usage counters, which stay per region, and not standby keys
+   - The local keystore.kek never leaves its region; only the
+     replication KEK has to be distributed (out of band, like any KEK)
+
+2. ORDERING
+   - Pushes are whole keystores, so a lost or repeated push does no harm:
+     a replayed batch is dominated by the receiver's clocks and ignored
+   - Regions converge once every region has pushed after the last change
+
+3. CONFLICTS
+   - key_material: both regions rotated to the same version. The loser's
+     key moves to a new version; Embedded.Decrypt still finds it for
+     data sealed under the old number, but that data should be
+     re-encrypted (see column-reencrypt.go) and the conflict cleared by
+     rotating in one region at a time
+   - labels: labels edited in two regions at once; the region that sorts
+     first wins
+   - Every conflict is audited (KEY_REPLICATION_CONFLICT) and listed in
+     Report()
+
+4. TRANSPORT
+   - HTTPReplicationPeer posts to a KeyReplicator mounted as an
+     http.Handler; serve it behind mutual TLS (see tlstrust)
+   - Any other transport implements ReplicationPeer and delivers to
+     Receive
+
+*/
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ============================================================================
+// EAMSA 512 - Bulk Key Re-wrap
+// Re-wraps stored data encryption keys (DEKs) after KEK rotation
+//
+// When the key-encryption key held in the KMS/HSM rotates, every wrapped DEK
+// must be re-wrapped under the new KEK. Each DEK is unwrapped and re-wrapped
+// in a single step and zeroized immediately; plaintext DEKs are never
+// returned, logged, or persisted.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// MaxRewrapBatchSize limits the number of keys per re-wrap request
+	MaxRewrapBatchSize = 1000
+)
+
+// WrappedKeyEntry is a DEK wrapped under a KEK (PEM "EAMSA512 ENCRYPTED KEY")
+type WrappedKeyEntry struct {
+	ID         string `json:"id"`               // Caller-defined keystore entry ID
+	WrappedKey string `json:"wrapped_key"`      // PEM-encoded KEK-wrapped key blob
+	KEKID      string `json:"kek_id,omitempty"` // Identifier of the wrapping KEK (keyid format)
+}
+
+// RewrapFailure describes an entry that could not be re-wrapped
+type RewrapFailure struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// RewrapRequest represents a bulk re-wrap request
+type RewrapRequest struct {
+	OldKEK string            `json:"old_kek"` // hex-encoded
+	NewKEK string            `json:"new_kek"` // hex-encoded
+	Keys   []WrappedKeyEntry `json:"keys"`
+}
+
+// RewrapResponse represents a bulk re-wrap response
+type RewrapResponse struct {
+	Rewrapped []WrappedKeyEntry `json:"rewrapped"`
+	Failed    []RewrapFailure   `json:"failed"`
+	OldKEKID  string            `json:"old_kek_id"`
+	NewKEKID  string            `json:"new_kek_id"`
+	Timestamp string            `json:"timestamp"`
+}
+
+// ============================================================================
+// Re-wrap Operations
+// ============================================================================
+
+// RewrapKey re-wraps a KEK-wrapped key blob from oldKEK to newKEK
+// The unwrapped DEK only exists for the duration of this call
+func RewrapKey(wrappedKey []byte, oldKEK []byte, newKEK []byte) ([]byte, error) {
+	if len(newKEK) != KeySize {
+		return nil, fmt.Errorf("invalid new KEK size: expected %d bytes, got %d", KeySize, len(newKEK))
+	}
+
+	dek, err := ImportKeyEncrypted(wrappedKey, oldKEK)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroizeKey(dek)
+
+	return ExportKeyEncrypted(dek, newKEK)
+}
+
+// RewrapKeys re-wraps a batch of entries, continuing past individual failures
+func RewrapKeys(entries []WrappedKeyEntry, oldKEK []byte, newKEK []byte) ([]WrappedKeyEntry, []RewrapFailure) {
+	rewrapped := make([]WrappedKeyEntry, 0, len(entries))
+	failed := make([]RewrapFailure, 0)
+	newKEKID := keyid.New(newKEK).String()
+
+	for _, entry := range entries {
+		blob, err := RewrapKey([]byte(entry.WrappedKey), oldKEK, newKEK)
+		if err != nil {
+			failed = append(failed, RewrapFailure{ID: entry.ID, Error: err.Error()})
+			continue
+		}
+
+		rewrapped = append(rewrapped, WrappedKeyEntry{
+			ID:         entry.ID,
+			WrappedKey: string(blob),
+			KEKID:      newKEKID,
+		})
+	}
+
+	return rewrapped, failed
+}
+
+// zeroizeKey overwrites key material in place
+func zeroizeKey(key []byte) {
+	for i := range key {
+		key[i] = 0
+	}
+}
+
+// ============================================================================
+// HTTP Handler
+// ============================================================================
+
+// HandleRewrap handles POST /api/v1/keys/rewrap
+func HandleRewrap(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	var req RewrapRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		LogError("Failed to decode rewrap request", err)
+		respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	oldKEK, err := hex.DecodeString(req.OldKEK)
+	if err != nil || len(oldKEK) != KeySize {
+		respondError(w, r, "bad_request", "old_kek must be a hex-encoded 32-byte key")
+		return
+	}
+	defer zeroizeKey(oldKEK)
+
+	newKEK, err := hex.DecodeString(req.NewKEK)
+	if err != nil || len(newKEK) != KeySize {
+		respondError(w, r, "bad_request", "new_kek must be a hex-encoded 32-byte key")
+		return
+	}
+	defer zeroizeKey(newKEK)
+
+	if len(req.Keys) == 0 {
+		respondError(w, r, "bad_request", "keys is required")
+		return
+	}
+
+	if len(req.Keys) > MaxRewrapBatchSize {
+		respondError(w, r, "bad_request",
+			fmt.Sprintf("too many keys: maximum %d per request", MaxRewrapBatchSize))
+		return
+	}
+
+	rewrapped, failed := RewrapKeys(req.Keys, oldKEK, newKEK)
+
+	response := RewrapResponse{
+		Rewrapped: rewrapped,
+		Failed:    failed,
+		OldKEKID:  keyid.New(oldKEK).String(),
+		NewKEKID:  keyid.New(newKEK).String(),
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+
+	LogAuditEvent("KEY_REWRAP", map[string]interface{}{
+		"old_kek_id": response.OldKEKID,
+		"new_kek_id": response.NewKEKID,
+		"requested":  len(req.Keys),
+		"rewrapped":  len(rewrapped),
+		"failed":     len(failed),
+		"timestamp":  response.Timestamp,
+	})
+
+	for _, entry := range rewrapped {
+		serverTelemetry.ObserveKeyEvent(telemetry.KeyRewrapped, entry.ID)
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ============================================================================
+// CLI Command
+// ============================================================================
+
+// RunRewrapCommand implements "rewrap": re-wraps every DEK in the database keystore
+//
+//	eamsa512-server rewrap -db /var/lib/eamsa512/eamsa512.db \
+//	    -old-kek-file old.kek -new-kek-file new.kek [-dry-run] [-force]
+//
+// KEK files contain a hex-encoded 32-byte key
+func RunRewrapCommand(args []string) error {
+	fs := flag.NewFlagSet("rewrap", flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to keystore database")
+	oldKEKFile := fs.String("old-kek-file", "", "File containing the current KEK (hex)")
+	newKEKFile := fs.String("new-kek-file", "", "File containing the new KEK (hex)")
+	dryRun := fs.Bool("dry-run", false, "Verify all entries unwrap without writing")
+	force := fs.Bool("force", false, "Open the database even if another process holds its lock (audited)")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if *oldKEKFile == "" || *newKEKFile == "" {
+		return fmt.Errorf("-old-kek-file and -new-kek-file are required")
+	}
+
+	oldKEK, err := readKEKFile(*oldKEKFile)
+	if err != nil {
+		return err
+	}
+	defer zeroizeKey(oldKEK)
+
+	newKEK, err := readKEKFile(*newKEKFile)
+	if err != nil {
+		return err
+	}
+	defer zeroizeKey(newKEK)
+
+	// A dry run only reads, so it does not wait for the lock holder
+	db, err := OpenDatabase(*dbPath, StoreOpenOptions{ReadOnly: *dryRun, Force: *force})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	fmt.Printf("Re-wrapping keystore %s\n", *dbPath)
+	fmt.Printf("  Old KEK: %s\n", keyid.New(oldKEK))
+	fmt.Printf("  New KEK: %s\n", keyid.New(newKEK))
+
+	count, failed, err := db.RewrapStoredKeys(oldKEK, newKEK, *dryRun)
+	for _, f := range failed {
+		fmt.Printf("  FAILED %s: %s\n", f.ID, f.Error)
+	}
+	if err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("Dry run: %d keys would be re-wrapped\n", count)
+	} else {
+		fmt.Printf("Re-wrapped %d keys\n", count)
+	}
+
+	return nil
+}
+
+// readKEKFile reads a hex-encoded 32-byte KEK from a file
+func readKEKFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read KEK file: %v", err)
+	}
+
+	kek, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	zeroizeKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("KEK file %s must contain a hex-encoded key", path)
+	}
+
+	if len(kek) != KeySize {
+		return nil, fmt.Errorf("invalid KEK size in %s: expected %d bytes, got %d", path, KeySize, len(kek))
+	}
+
+	return kek, nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// ============================================================================
+// EAMSA 512 - Key Rotation and Lifecycle Management
+// Key management, rotation scheduling, and archival
+//
+// Implements FIPS 140-2 Level 2 key lifecycle requirements including:
+// - Automatic key rotation on schedule
+// - Key versioning and history tracking
+// - Secure key archival and destruction
+// - Key state management
+//
+// The key manager itself is pkg/keymgmt; this file wires it to the
+// server's audit log and trusted clock.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// KeyManager is a keymgmt.KeyManager with the server's extensions: key
+// export (key-export.go), persistence in the embedded keystore
+// (embedded.go) and replication (key-replication.go)
+type KeyManager struct {
+	*keymgmt.KeyManager
+}
+
+// NewKeyManager creates a new key manager with initial key
+func NewKeyManager(initialKey []byte, policy keymgmt.KeyRotationPolicy) (*KeyManager, error) {
+	// Setup audit logger
+	auditFile, err := os.OpenFile("/var/log/eamsa512/key-rotation.log", 
+		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	auditLogger := log.New(auditFile, "[KEY-ROTATION] ", log.LstdFlags|log.Lshortfile)
+
+	return newKeyManager(initialKey, policy, auditLogger)
+}
+
+// newKeyManager creates a key manager that audits to auditLogger and
+// takes its time from trustedClock
+func newKeyManager(initialKey []byte, policy keymgmt.KeyRotationPolicy, auditLogger *log.Logger) (*KeyManager, error) {
+	km, err := keymgmt.NewKeyManager(initialKey, keymgmt.Config{Policy: policy, AuditLog: auditLogger, Clock: trustedClock})
+	if err != nil {
+		return nil, err
+	}
+	return &KeyManager{km}, nil
+}
+
+// restoreKeyManager rebuilds a key manager from persisted entries (see
+// KeyManager.Snapshot). Exactly one entry must be active.
+func restoreKeyManager(entries []*keymgmt.KeyEntry, policy keymgmt.KeyRotationPolicy, auditLogger *log.Logger) (*KeyManager, error) {
+	km, err := keymgmt.RestoreKeyManager(entries, keymgmt.Config{Policy: policy, AuditLog: auditLogger, Clock: trustedClock})
+	if err != nil {
+		return nil, err
+	}
+	return &KeyManager{km}, nil
+}
+
+// GenerateNewKey generates a new random key from source (nil: crypto/rand)
+func GenerateNewKey(source eamsa512.EntropySource) ([]byte, error) {
+	return eamsa512.NewKey(source)
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. KEY LIFECYCLE
+   - Pending: Scheduled via ScheduleKey(); decrypt-only until its
+     activation time, then activated automatically. A standby
+     (PrepareStandby()) is pending without an activation time and waits
+     for PromoteStandby()
+   - Active: In use for encryption and decryption
+   - Rotated: No longer used for encryption, only for decryption
+   - Archived: Old key, can be stored offline
+   - Destroyed: Securely erased
+
+2. ROTATION POLICY
+   - Default: 365-day rotation interval
+   - Max age: 730 days (force rotation)
+   - Retention: Keep 3 key versions
+   - Destruction: 3-pass random overwrite
+
+3. FIPS 140-2 COMPLIANCE
+   - Automatic rotation scheduling
+   - Key versioning and tracking
+   - Audit logging of all key operations
+   - Secure key destruction
+   - Key state management
+
+4. SECURITY FEATURES
+   - Separate active/rotated/archived keys
+   - Backward compatibility for decryption
+   - Encryption counter tracking
+   - Decryption counter tracking
+   - SHA3-512 key hashing for verification
+   - Secure memory erasure (Gutmann method)
+
+5. LABELS
+   - SetKeyLabels() attaches scoping labels (region=eu, tenant=acme,
+     classification=secret) to a key version
+   - ListKeyVersionsByLabel() filters key listings by label selector
+   - Label-based access constraints are enforced by RBACManager label
+     policies (e.g. EU operators may only use region=eu keys)
+
+6. USAGE PATTERNS
+   - GetActiveKey() for encryption
+   - GetKeyByVersion() for decryption
+   - EncryptWithKeyManager() records the active key version in the
+     envelope header; DecryptWithKeyManager() reads it back and fetches
+     that version, so rotated data needs no key bookkeeping
+   - RotateKey() for immediate rotation
+   - ScheduleKey() to stage a key fleet-wide before it becomes active;
+     an optional NotAfter closes its encryption window
+   - PrepareStandby() ahead of time, then PromoteStandby() to rotate
+     without generating or wrapping a key; with WarmStandby set,
+     CheckStandby() (and the hourly scheduler) flag a missing standby.
+     A standby older than a key rotated in by other means is destroyed
+   - GetStatistics() for monitoring
+
+7. PRODUCTION CONSIDERATIONS
+   - Keys should never be stored unencrypted
+   - Use HSM for key storage in production
+   - Implement key escrow for recovery
+   - Regular audit log review
+   - Test rotation procedures regularly
+
+*/
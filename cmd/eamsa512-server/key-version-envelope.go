@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Key-Version Tagged Ciphertext
+// Encrypt under the KeyManager's active key, decrypt under the right one
+//
+// EncryptWithKeyManager writes an envelope (see Encrypt) whose header
+// records the version of the key that sealed it. DecryptWithKeyManager
+// reads the version back and asks the KeyManager for that key, so data
+// sealed before any number of rotations decrypts without the caller
+// tracking which key was current at the time. The key version is part of
+// the authenticated header: changing it fails decryption instead of
+// selecting another key.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// EncryptWithKeyManager encrypts plaintext under the active key and
+// records its version in the envelope header
+func EncryptWithKeyManager(plaintext []byte, km *KeyManager) ([]byte, error) {
+	key, version, err := km.GetActiveKeyVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := Encrypt(plaintext, key, EnvelopeOptions{KeyVersion: uint32(version)})
+	if err != nil {
+		return nil, err
+	}
+
+	km.IncrementEncryptionCount()
+	return sealed, nil
+}
+
+// DecryptWithKeyManager decrypts an envelope with the key version named in
+// its header. Active, rotated and pending versions can decrypt; archived
+// and destroyed ones cannot (see GetKeyByVersion).
+func DecryptWithKeyManager(data []byte, km *KeyManager) ([]byte, error) {
+	if !eamsa512.IsEnvelope(data) {
+		return nil, fmt.Errorf("data has no envelope header and so no key version: use DecryptWithAny")
+	}
+
+	envelope, err := eamsa512.ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	if envelope.KeyVersion == 0 {
+		return nil, fmt.Errorf("envelope has no key version")
+	}
+
+	version := int(envelope.KeyVersion)
+	key, err := km.GetKeyByVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("key for envelope: %v", err)
+	}
+
+	plaintext, err := envelope.Open(key)
+	if err != nil {
+		return nil, err
+	}
+
+	km.IncrementDecryptionCount(version)
+	return plaintext, nil
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. KEY VERSION
+   - Bytes 6 to 9 of the envelope header, big-endian; 0 means none
+   - Version numbers are KeyManager versions (1 for the initial key)
+   - ParseEnvelope reads it without a key; it is authenticated only
+     once the envelope opens
+
+2. COMPATIBILITY
+   - Bare EncryptData output has no header: DecryptWithAny tries the
+     candidate keys instead
+   - Envelopes written by Encrypt with KeyVersion 0 are refused here;
+     decrypt them with Decrypt and a known key
+
+*/
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// ============================================================================
+// EAMSA 512 - Keystore Test Suite
+// Key-version tagged decryption, key destruction, multi-region replication
+// and the entropy source of the embedded keystore
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// TestDecryptWithKeyManager tests that data sealed before a rotation
+// decrypts with the key version recorded in its envelope
+func TestDecryptWithKeyManager(t *testing.T) {
+	fmt.Println("Test: Key-Version Tagged Decryption")
+
+	oldKey := make([]byte, eamsa512.KeySize)
+	newKey := make([]byte, eamsa512.KeySize)
+	rand.Read(oldKey)
+	rand.Read(newKey)
+
+	policy := keymgmt.DefaultKeyRotationPolicy()
+	policy.Enabled = false
+	policy.MinKeyAgeDays = 0
+	km, err := newKeyManager(oldKey, policy, log.New(io.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("Key manager creation failed: %v", err)
+	}
+
+	plaintext := []byte("Sealed under key version 1")
+	before, err := EncryptWithKeyManager(plaintext, km)
+	if err != nil {
+		t.Fatalf("Encryption failed: %v", err)
+	}
+
+	if err := km.RotateKey(newKey); err != nil {
+		t.Fatalf("Rotation failed: %v", err)
+	}
+	after, err := EncryptWithKeyManager(plaintext, km)
+	if err != nil {
+		t.Fatalf("Encryption after rotation failed: %v", err)
+	}
+
+	for name, sealed := range map[string][]byte{"version 1": before, "version 2": after} {
+		decrypted, err := DecryptWithKeyManager(sealed, km)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("Decryption of %s data failed: %v", name, err)
+		}
+	}
+
+	// Pointing the header at the other version must not decrypt
+	tampered := append([]byte(nil), before...)
+	tampered[9] = 2
+	if _, err := DecryptWithKeyManager(tampered, km); err == nil {
+		t.Fatal("Envelope with a rewritten key version decrypted")
+	}
+
+	fmt.Println("✓ Rotated data decrypts with its recorded key version")
+}
+
+// loopbackPeer delivers replication messages to a KeyReplicator in process
+type loopbackPeer struct {
+	region string
+	target *KeyReplicator
+}
+
+func (p *loopbackPeer) Region() string { return p.region }
+
+func (p *loopbackPeer) Exchange(ctx context.Context, message []byte) ([]byte, error) {
+	return p.target.Receive(message)
+}
+
+// TestKeyDestruction tests that a shredded key version is erased from the
+// keystore for good and that the active version cannot be shredded
+func TestKeyDestruction(t *testing.T) {
+	fmt.Println("Test: Key Version Destruction")
+
+	dir := t.TempDir()
+	emb, err := NewEmbedded(dir)
+	if err != nil {
+		t.Fatalf("NewEmbedded failed: %v", err)
+	}
+	sealed, err := emb.Encrypt([]byte("retention ends in 2025"))
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	if _, err := emb.DestroyKeyVersion(1); err == nil {
+		t.Fatal("Active key version destroyed")
+	}
+	if _, err := emb.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+
+	metadata, err := emb.DestroyKeyVersion(1)
+	if err != nil || metadata.State != keymgmt.KeyStateDestroyed || metadata.DestroyedAt.IsZero() {
+		t.Fatalf("DestroyKeyVersion: %+v, %v", metadata, err)
+	}
+	if _, err := emb.DestroyKeyVersion(1); err == nil {
+		t.Fatal("Key version destroyed twice")
+	}
+	emb.Close()
+
+	reopened, err := OpenEmbeddedReadOnly(dir)
+	if err != nil {
+		t.Fatalf("OpenEmbeddedReadOnly failed: %v", err)
+	}
+	defer reopened.Close()
+	if _, err := reopened.Decrypt(sealed); err == nil {
+		t.Fatal("Data under a destroyed key version still decrypts")
+	}
+
+	fmt.Println("✓ Destroyed key versions stay destroyed after reopening")
+}
+
+// TestKeyReplication tests that two regions that rotated concurrently
+// converge on one key per version and still decrypt each other's data
+func TestKeyReplication(t *testing.T) {
+	fmt.Println("Test: Multi-Region Key Replication")
+
+	kek := make([]byte, eamsa512.KeySize)
+	rand.Read(kek)
+
+	eu, err := NewEmbedded(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewEmbedded failed: %v", err)
+	}
+	defer eu.Close()
+	time.Sleep(time.Millisecond) // eu's first key is the older one
+	us, err := NewEmbedded(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewEmbedded failed: %v", err)
+	}
+	defer us.Close()
+
+	euRepl, err := NewKeyReplicator(eu, ReplicationConfig{Region: "eu", KEK: kek})
+	if err != nil {
+		t.Fatalf("NewKeyReplicator failed: %v", err)
+	}
+	usRepl, err := NewKeyReplicator(us, ReplicationConfig{Region: "us", KEK: kek, Peers: []ReplicationPeer{&loopbackPeer{"eu", euRepl}}})
+	if err != nil {
+		t.Fatalf("NewKeyReplicator failed: %v", err)
+	}
+	euRepl.peers = []ReplicationPeer{&loopbackPeer{"us", usRepl}}
+
+	// Both regions start with their own version 1, then rotate to their
+	// own version 2: two conflicts, both won by eu
+	sealedEU, _ := eu.Encrypt([]byte("sealed in eu"))
+	sealedUS, _ := us.Encrypt([]byte("sealed in us"))
+	if _, err := eu.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if _, err := us.Rotate(); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	rotatedUS, _ := us.Encrypt([]byte("sealed in us after rotating"))
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := euRepl.SyncNow(ctx); err != nil {
+			t.Fatalf("eu push failed: %v", err)
+		}
+		if err := usRepl.SyncNow(ctx); err != nil {
+			t.Fatalf("us push failed: %v", err)
+		}
+	}
+
+	euActive, _ := eu.Keys().GetActiveKeyMetadata()
+	usActive, _ := us.Keys().GetActiveKeyMetadata()
+	if euActive.Version != usActive.Version || euActive.KeyHash != usActive.KeyHash {
+		t.Fatalf("Active keys differ: eu %d %s, us %d %s", euActive.Version, euActive.KeyHash, usActive.Version, usActive.KeyHash)
+	}
+
+	for region, e := range map[string]*Embedded{"eu": eu, "us": us} {
+		for _, sealed := range [][]byte{sealedEU, sealedUS, rotatedUS} {
+			if _, err := e.Decrypt(sealed); err != nil {
+				t.Fatalf("%s cannot decrypt replicated data: %v", region, err)
+			}
+		}
+	}
+
+	report := usRepl.Report()
+	if len(report.Conflicts) != 2 || report.Conflicts[0].Winner != "eu" || report.Conflicts[0].RenumberedTo == 0 {
+		t.Fatalf("Conflicts: got %+v", report.Conflicts)
+	}
+	if len(report.Peers) != 1 || !report.Peers[0].InSync {
+		t.Fatalf("Peers not in sync: %+v", report.Peers)
+	}
+
+	// A region with another replication KEK is rejected
+	other, err := NewEmbedded(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewEmbedded failed: %v", err)
+	}
+	defer other.Close()
+	wrongKEK := append([]byte(nil), kek...)
+	wrongKEK[0] ^= 1
+	intruder, _ := NewKeyReplicator(other, ReplicationConfig{Region: "ap", KEK: wrongKEK, Peers: []ReplicationPeer{&loopbackPeer{"eu", euRepl}}})
+	if err := intruder.SyncNow(ctx); err == nil {
+		t.Fatal("Push under the wrong replication KEK was accepted")
+	}
+
+	fmt.Println("✓ Concurrent rotations resolved; regions converge and decrypt each other's data")
+}
+
+// TestEmbeddedEntropySource tests that an embedded keystore does not open
+// on an entropy source that fails its health check
+func TestEmbeddedEntropySource(t *testing.T) {
+	fmt.Println("Test: Embedded Keystore Entropy Source")
+
+	stuck := filepath.Join(t.TempDir(), "hwrng")
+	os.WriteFile(stuck, bytes.Repeat([]byte{0xff}, 256), 0600)
+	device, err := eamsa512.OpenDeviceEntropy(stuck)
+	if err != nil {
+		t.Fatalf("OpenDeviceEntropy failed: %v", err)
+	}
+	defer device.Close()
+
+	policy := DefaultEmbeddedPolicy(t.TempDir())
+	policy.Entropy = device
+	if _, err := NewEmbeddedWithPolicy(t.TempDir(), policy); err == nil {
+		t.Fatal("Embedded keystore opened with a stuck entropy source")
+	}
+
+	fmt.Println("✓ Stuck entropy source rejected")
+}
+
+// TestEntropyAuditor tests that a failing source is audited once per
+// failure, with only the first fallback draw after it
+func TestEntropyAuditor(t *testing.T) {
+	fmt.Println("Test: Entropy Failover Auditing")
+
+	var audited []string
+	onEvent := newEntropyAuditor(func(event string, details map[string]interface{}) {
+		audited = append(audited, event)
+	})
+	failed := eamsa512.RandomEvent{Type: eamsa512.RandomSourceFailed, Source: "hwrng", Err: fmt.Errorf("stuck")}
+	fallback := eamsa512.RandomEvent{Type: eamsa512.RandomFallback, Source: "hwrng"}
+	for _, e := range []eamsa512.RandomEvent{failed, fallback, fallback, fallback, failed, fallback} {
+		onEvent(e)
+	}
+
+	want := []string{eamsa512.RandomSourceFailed, eamsa512.RandomFallback, eamsa512.RandomSourceFailed, eamsa512.RandomFallback}
+	if fmt.Sprint(audited) != fmt.Sprint(want) {
+		t.Fatalf("Audited %v, want %v", audited, want)
+	}
+
+	fmt.Println("✓ Repeated fallback draws audited once per failure")
+}
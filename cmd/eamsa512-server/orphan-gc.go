@@ -0,0 +1,551 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+)
+
+// ============================================================================
+// EAMSA 512 - Orphan Garbage Collection
+// Mark-and-sweep of wrapped keys and blobs no longer referenced by records
+//
+// Application tables reference wrapped DEKs by keystore ID and stored
+// ciphertexts by blob handle. GC runs in two steps so nothing is deleted
+// without a second person looking at it:
+//
+//   1. scan: mark every ID and handle found in the configured reference
+//      columns, sweep the keystore and blob store for entries not marked,
+//      and save the result as a pending report.
+//   2. approve: a different user approves the report. Each entry is
+//      checked again and deleted only if it is still unreferenced, so
+//      records written between scan and approval are safe.
+//
+// A kind (wrapped keys or blobs) with no reference columns configured is
+// never swept: without references, everything would look orphaned.
+// Reports, approvals and every deletion are written to the audit log.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Reference kinds
+const (
+	GCKindWrappedKey = "wrapped_key"
+	GCKindBlob       = "blob"
+)
+
+// GC report states
+const (
+	gcPending   = "pending"
+	gcApplied   = "applied"
+	gcExpired   = "expired"
+	gcReportTTL = 7 * 24 * time.Hour
+)
+
+// GCReference is a column of an application table holding wrapped key IDs
+// or blob handles
+type GCReference struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Kind   string `json:"kind"` // "wrapped_key" or "blob"
+}
+
+// validate checks identifiers (they are interpolated into SQL) and the kind
+func (ref GCReference) validate() error {
+	if !identifierPattern.MatchString(ref.Table) || !identifierPattern.MatchString(ref.Column) {
+		return fmt.Errorf("invalid reference %s.%s", ref.Table, ref.Column)
+	}
+	if ref.Kind != GCKindWrappedKey && ref.Kind != GCKindBlob {
+		return fmt.Errorf("reference %s.%s: unknown kind %q", ref.Table, ref.Column, ref.Kind)
+	}
+	return nil
+}
+
+// GCReport is the result of a scan, pending approval
+type GCReport struct {
+	ID              string     `json:"id"`
+	Status          string     `json:"status"` // "pending", "applied" or "expired"
+	RequestedBy     string     `json:"requested_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+	ReferencedKeys  int        `json:"referenced_keys"`
+	ReferencedBlobs int        `json:"referenced_blobs"`
+	ScannedKeys     int        `json:"scanned_keys"`
+	ScannedBlobs    int        `json:"scanned_blobs"`
+	OrphanKeys      []string   `json:"orphan_keys"`       // Keystore IDs
+	OrphanBlobs     []string   `json:"orphan_blobs"`      // Blob handles
+	Skipped         []string   `json:"skipped,omitempty"` // Kinds not swept, with the reason
+	ApprovedBy      string     `json:"approved_by,omitempty"`
+	AppliedAt       *time.Time `json:"applied_at,omitempty"`
+}
+
+// GCFailure is an orphan that could not be deleted
+type GCFailure struct {
+	Kind  string `json:"kind"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// GCResult summarizes an approved deletion
+type GCResult struct {
+	ReportID     string      `json:"report_id"`
+	DeletedKeys  int         `json:"deleted_keys"`
+	DeletedBlobs int         `json:"deleted_blobs"`
+	Referenced   []string    `json:"referenced,omitempty"` // Orphans referenced again since the scan; kept
+	Failed       []GCFailure `json:"failed,omitempty"`
+	DryRun       bool        `json:"dry_run,omitempty"` // Counts are what approval would delete
+}
+
+// gcMarks holds referenced IDs by kind; a kind is present only if it has
+// at least one reference column
+type gcMarks map[string]map[string]bool
+
+// markReferences collects every value of the reference columns
+func (db *Database) markReferences(ctx context.Context, refs []GCReference) (gcMarks, error) {
+	marks := make(gcMarks)
+
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	for _, ref := range refs {
+		if err := ref.validate(); err != nil {
+			return nil, err
+		}
+		if marks[ref.Kind] == nil {
+			marks[ref.Kind] = make(map[string]bool)
+		}
+
+		column := quoteIdentifier(ref.Column)
+		query := fmt.Sprintf(`SELECT DISTINCT %s FROM %s WHERE %s IS NOT NULL`, column, quoteIdentifier(ref.Table), column)
+		rows, err := db.conn.QueryContext(ctx, query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read references from %s.%s: %v", ref.Table, ref.Column, err)
+		}
+		for rows.Next() {
+			var value string
+			if err := rows.Scan(&value); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to read references from %s.%s: %v", ref.Table, ref.Column, err)
+			}
+			marks[ref.Kind][value] = true
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read references from %s.%s: %v", ref.Table, ref.Column, err)
+		}
+	}
+
+	return marks, nil
+}
+
+// ScanOrphans marks references and sweeps the keystore and, if store is
+// not nil, the blob store. The report is saved as pending and audited;
+// nothing is deleted.
+func (db *Database) ScanOrphans(ctx context.Context, store BlobStore, refs []GCReference, requestedBy string) (*GCReport, error) {
+	if requestedBy == "" {
+		return nil, fmt.Errorf("the requesting user is required")
+	}
+
+	marks, err := db.markReferences(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &GCReport{
+		ID:          newGCReportID(),
+		Status:      gcPending,
+		RequestedBy: requestedBy,
+		CreatedAt:   time.Now().UTC(),
+		OrphanKeys:  []string{},
+		OrphanBlobs: []string{},
+	}
+
+	if keyRefs, ok := marks[GCKindWrappedKey]; ok {
+		report.ReferencedKeys = len(keyRefs)
+		entries, err := db.GetWrappedKeys()
+		if err != nil {
+			return nil, err
+		}
+		report.ScannedKeys = len(entries)
+		for _, entry := range entries {
+			if !keyRefs[entry.ID] {
+				report.OrphanKeys = append(report.OrphanKeys, entry.ID)
+			}
+		}
+	} else {
+		report.Skipped = append(report.Skipped, "wrapped_key: no reference columns configured")
+	}
+
+	switch blobRefs, ok := marks[GCKindBlob]; {
+	case store == nil:
+		report.Skipped = append(report.Skipped, "blob: no blob store configured")
+	case !ok:
+		report.Skipped = append(report.Skipped, "blob: no reference columns configured")
+	default:
+		report.ReferencedBlobs = len(blobRefs)
+		err := store.List(ctx, func(handle string) error {
+			report.ScannedBlobs++
+			if !blobRefs[handle] {
+				report.OrphanBlobs = append(report.OrphanBlobs, handle)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list blobs: %v", err)
+		}
+		sort.Strings(report.OrphanBlobs)
+	}
+
+	if err := db.saveGCReport(report); err != nil {
+		return nil, err
+	}
+
+	db.auditGC("GC_REPORTED", "info", requestedBy, map[string]interface{}{
+		"report_id":    report.ID,
+		"orphan_keys":  len(report.OrphanKeys),
+		"orphan_blobs": len(report.OrphanBlobs),
+		"skipped":      report.Skipped,
+	})
+	db.logger.Printf("GC report %s: %d orphan keys, %d orphan blobs", report.ID, len(report.OrphanKeys), len(report.OrphanBlobs))
+
+	return report, nil
+}
+
+// ApproveOrphanDeletion deletes the orphans of a pending report that are
+// still unreferenced. The approver must differ from the user who ran the
+// scan, and reports expire after 7 days. With dryRun, the result lists
+// what would be deleted and the report stays pending.
+func (db *Database) ApproveOrphanDeletion(ctx context.Context, store BlobStore, refs []GCReference, reportID, approver string, dryRun bool) (*GCResult, error) {
+	report, err := db.GetGCReport(reportID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkGCApproval(report, approver); err != nil {
+		if !dryRun && report.Status == gcPending && failpoint.Now().Sub(report.CreatedAt) > gcReportTTL {
+			report.Status = gcExpired
+			db.saveGCReport(report)
+		}
+		return nil, err
+	}
+	if len(report.OrphanBlobs) > 0 && store == nil {
+		return nil, fmt.Errorf("report %s lists blobs but no blob store is configured", reportID)
+	}
+
+	// Mark again: records may reference an orphan since the scan
+	marks, err := db.markReferences(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &GCResult{ReportID: report.ID, DryRun: dryRun}
+	if dryRun {
+		planGCDeletion(report, marks, result)
+		return result, nil
+	}
+
+	db.auditGC("GC_APPROVED", "warning", approver, map[string]interface{}{
+		"report_id":    report.ID,
+		"requested_by": report.RequestedBy,
+		"orphan_keys":  len(report.OrphanKeys),
+		"orphan_blobs": len(report.OrphanBlobs),
+	})
+
+	for _, id := range report.OrphanKeys {
+		if keyRefs, ok := marks[GCKindWrappedKey]; !ok || keyRefs[id] {
+			result.Referenced = append(result.Referenced, GCKindWrappedKey+":"+id)
+			continue
+		}
+		if err := db.deleteWrappedKey(ctx, id); err != nil {
+			result.Failed = append(result.Failed, GCFailure{Kind: GCKindWrappedKey, ID: id, Error: err.Error()})
+			continue
+		}
+		result.DeletedKeys++
+		db.auditGC("GC_WRAPPED_KEY_DELETED", "warning", approver, map[string]interface{}{
+			"report_id": report.ID,
+			"id":        id,
+		})
+	}
+
+	for _, handle := range report.OrphanBlobs {
+		if blobRefs, ok := marks[GCKindBlob]; !ok || blobRefs[handle] {
+			result.Referenced = append(result.Referenced, handle)
+			continue
+		}
+		if err := store.Delete(ctx, handle); err != nil {
+			result.Failed = append(result.Failed, GCFailure{Kind: GCKindBlob, ID: handle, Error: err.Error()})
+			continue
+		}
+		result.DeletedBlobs++
+		db.auditGC("GC_BLOB_DELETED", "warning", approver, map[string]interface{}{
+			"report_id": report.ID,
+			"handle":    handle,
+		})
+	}
+
+	now := time.Now().UTC()
+	report.Status = gcApplied
+	report.ApprovedBy = approver
+	report.AppliedAt = &now
+	if err := db.saveGCReport(report); err != nil {
+		return result, err
+	}
+
+	db.auditGC("GC_COMPLETED", "info", approver, map[string]interface{}{
+		"report_id":     report.ID,
+		"deleted_keys":  result.DeletedKeys,
+		"deleted_blobs": result.DeletedBlobs,
+		"referenced":    len(result.Referenced),
+		"failed":        len(result.Failed),
+	})
+	db.logger.Printf("GC report %s applied by %s: %d keys, %d blobs deleted", report.ID, approver,
+		result.DeletedKeys, result.DeletedBlobs)
+
+	return result, nil
+}
+
+// planGCDeletion fills result with what approval would delete
+func planGCDeletion(report *GCReport, marks gcMarks, result *GCResult) {
+	for _, id := range report.OrphanKeys {
+		if keyRefs, ok := marks[GCKindWrappedKey]; !ok || keyRefs[id] {
+			result.Referenced = append(result.Referenced, GCKindWrappedKey+":"+id)
+		} else {
+			result.DeletedKeys++
+		}
+	}
+	for _, handle := range report.OrphanBlobs {
+		if blobRefs, ok := marks[GCKindBlob]; !ok || blobRefs[handle] {
+			result.Referenced = append(result.Referenced, handle)
+		} else {
+			result.DeletedBlobs++
+		}
+	}
+}
+
+// checkGCApproval enforces the pending state, expiry and two-person rule
+func checkGCApproval(report *GCReport, approver string) error {
+	if report.Status != gcPending {
+		return fmt.Errorf("report %s is %s", report.ID, report.Status)
+	}
+	if failpoint.Now().Sub(report.CreatedAt) > gcReportTTL {
+		return fmt.Errorf("report %s has expired; run a new scan", report.ID)
+	}
+	if approver == "" {
+		return fmt.Errorf("the approving user is required")
+	}
+	if approver == report.RequestedBy {
+		return fmt.Errorf("report %s must be approved by someone other than %s", report.ID, report.RequestedBy)
+	}
+	return nil
+}
+
+// deleteWrappedKey removes one keystore entry
+func (db *Database) deleteWrappedKey(ctx context.Context, id string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if _, err := db.conn.ExecContext(ctx, `DELETE FROM wrapped_keys WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete wrapped key %s: %v", id, err)
+	}
+	return nil
+}
+
+// auditGC records a GC event in the audit log
+func (db *Database) auditGC(event, severity, user string, details map[string]interface{}) {
+	details["timestamp"] = time.Now().Format(time.RFC3339)
+	detailsJSON, _ := json.Marshal(details)
+
+	db.RecordAuditLog(AuditLogEntry{
+		EventType: event,
+		Category:  "admin",
+		Severity:  severity,
+		Details:   string(detailsJSON),
+		Timestamp: time.Now(),
+		UserID:    user,
+	})
+}
+
+func newGCReportID() string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return fmt.Sprintf("gc-%s-%s", time.Now().UTC().Format("20060102T150405"), hex.EncodeToString(suffix))
+}
+
+// saveGCReport inserts or updates a report
+func (db *Database) saveGCReport(report *GCReport) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode GC report: %v", err)
+	}
+
+	query := `INSERT OR REPLACE INTO gc_reports (id, status, requested_by, report, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	if _, err := db.conn.Exec(query, report.ID, report.Status, report.RequestedBy, string(data), report.CreatedAt, time.Now()); err != nil {
+		return fmt.Errorf("failed to save GC report %s: %v", report.ID, err)
+	}
+	return nil
+}
+
+// GetGCReport loads a report
+func (db *Database) GetGCReport(id string) (*GCReport, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	var data string
+	err := db.conn.QueryRow(`SELECT report FROM gc_reports WHERE id = ?`, id).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("no GC report %s", id)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to load GC report %s: %v", id, err)
+	}
+
+	var report GCReport
+	if err := json.Unmarshal([]byte(data), &report); err != nil {
+		return nil, fmt.Errorf("failed to decode GC report %s: %v", id, err)
+	}
+	return &report, nil
+}
+
+// ============================================================================
+// Command
+// ============================================================================
+
+// RunGCCommand implements "gc":
+//
+//	eamsa512-server gc scan -refs refs.json -user alice [-blob-store blobs.json]
+//	eamsa512-server gc show -report gc-20251204T183000-1a2b3c4d
+//	eamsa512-server gc approve -refs refs.json -report gc-... -user bob [-blob-store blobs.json] [-dry-run]
+//
+// Every action accepts -force to open a database locked by another
+// process (audited); show opens it read-only.
+//
+// refs.json lists the reference columns:
+//
+//	[{"table": "documents", "column": "dek_id", "kind": "wrapped_key"},
+//	 {"table": "documents", "column": "blob_handle", "kind": "blob"}]
+func RunGCCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: gc scan|show|approve [flags]")
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("gc "+action, flag.ContinueOnError)
+	dbPath := fs.String("db", "/var/lib/eamsa512/eamsa512.db", "Path to keystore database")
+	refsFile := fs.String("refs", "", "JSON file listing reference columns")
+	blobConfig := fs.String("blob-store", "", "Blob store configuration (JSON)")
+	reportID := fs.String("report", "", "Report to show or approve")
+	user := fs.String("user", "", "User running the scan or approving the report")
+	dryRun := fs.Bool("dry-run", false, "approve: report what would be deleted without deleting")
+	force := fs.Bool("force", false, "Open the database even if another process holds its lock (audited)")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	db, err := OpenDatabase(*dbPath, StoreOpenOptions{ReadOnly: action == "show", Force: *force})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	pseudonyms, err := LoadPseudonymizerFromEnv()
+	if err != nil {
+		return err
+	}
+	db.SetPseudonymizer(pseudonyms)
+
+	if action == "show" {
+		if *reportID == "" {
+			return fmt.Errorf("-report is required")
+		}
+		report, err := db.GetGCReport(*reportID)
+		if err != nil {
+			return err
+		}
+		return printJSON(report)
+	}
+
+	if action != "scan" && action != "approve" {
+		return fmt.Errorf("unknown gc action %q", action)
+	}
+
+	if *refsFile == "" {
+		return fmt.Errorf("-refs is required")
+	}
+	refs, err := loadGCReferences(*refsFile)
+	if err != nil {
+		return err
+	}
+
+	var store BlobStore
+	if *blobConfig != "" {
+		if store, err = LoadBlobStore(*blobConfig); err != nil {
+			return err
+		}
+	}
+
+	ctx := context.Background()
+	if action == "scan" {
+		report, err := db.ScanOrphans(ctx, store, refs, *user)
+		if err != nil {
+			return err
+		}
+		if err := printJSON(report); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Nothing was deleted. Another user approves with: gc approve -report %s\n", report.ID)
+		return nil
+	}
+
+	if *reportID == "" {
+		return fmt.Errorf("-report is required")
+	}
+	result, err := db.ApproveOrphanDeletion(ctx, store, refs, *reportID, *user, *dryRun)
+	if result != nil {
+		printJSON(result)
+	}
+	return err
+}
+
+// loadGCReferences reads and validates a reference list
+func loadGCReferences(path string) ([]GCReference, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read references: %v", err)
+	}
+
+	var refs []GCReference
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse references: %v", err)
+	}
+	for _, ref := range refs {
+		if err := ref.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return refs, nil
+}
+
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
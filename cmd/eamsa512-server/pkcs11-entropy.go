@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - PKCS#11 Entropy Source
+// The RNG of an HSM (C_GenerateRandom) as the server's nonce and IV source
+//
+// EAMSA_ENTROPY_SOURCE=pkcs11:<module> loads the PKCS#11 module, such as
+// /usr/lib/softhsm/libsofthsm2.so, and draws from the token labelled
+// EAMSA_PKCS11_TOKEN (default: the first token present). When
+// EAMSA_PKCS11_PIN_FILE names a file, the session logs in as the user with
+// the PIN it holds; many tokens serve random bytes without a login.
+//
+// Like the device source, the output runs through the SP 800-90B health
+// tests, so a token that starts repeating itself is taken out of service
+// and the server falls back to crypto/rand (see entropy-source.go).
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// PKCS11Entropy draws random bytes from a PKCS#11 token
+type PKCS11Entropy struct {
+	label string // Token label, for Name
+
+	mu      sync.Mutex
+	ctx     *pkcs11.Ctx
+	session pkcs11.SessionHandle
+	monitor *eamsa512.HealthMonitor
+	failure error
+}
+
+// pkcs11StartupSamples is the number of bytes the start-up tests draw,
+// as for the package's own sources (SP 800-90B 4.3)
+const pkcs11StartupSamples = 1024
+
+// OpenPKCS11Entropy loads module and opens a session on the token
+// labelled label ("" selects the first token present), logging in with
+// pin unless it is empty
+func OpenPKCS11Entropy(module, label, pin string) (*PKCS11Entropy, error) {
+	ctx := pkcs11.New(module)
+	if ctx == nil {
+		return nil, fmt.Errorf("failed to load PKCS#11 module %s", module)
+	}
+	if err := ctx.Initialize(); err != nil {
+		ctx.Destroy()
+		return nil, fmt.Errorf("failed to initialize PKCS#11 module %s: %v", module, err)
+	}
+
+	e, err := openPKCS11Session(ctx, label, pin)
+	if err != nil {
+		ctx.Finalize()
+		ctx.Destroy()
+		return nil, err
+	}
+	return e, nil
+}
+
+// openPKCS11Session opens and, with a PIN, logs in a session on the token
+func openPKCS11Session(ctx *pkcs11.Ctx, label, pin string) (*PKCS11Entropy, error) {
+	slot, tokenLabel, err := findPKCS11Token(ctx, label)
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := ctx.OpenSession(slot, pkcs11.CKF_SERIAL_SESSION)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open a session on PKCS#11 token %q: %v", tokenLabel, err)
+	}
+	if pin != "" {
+		err := ctx.Login(session, pkcs11.CKU_USER, pin)
+		if err != nil && err != pkcs11.Error(pkcs11.CKR_USER_ALREADY_LOGGED_IN) {
+			ctx.CloseSession(session)
+			return nil, fmt.Errorf("failed to log in to PKCS#11 token %q: %v", tokenLabel, err)
+		}
+	}
+
+	monitor, _ := eamsa512.NewHealthMonitor(eamsa512.DefaultMinEntropy)
+	return &PKCS11Entropy{label: tokenLabel, ctx: ctx, session: session, monitor: monitor}, nil
+}
+
+// findPKCS11Token returns the slot holding the token labelled label, or
+// the first slot with a token if label is empty
+func findPKCS11Token(ctx *pkcs11.Ctx, label string) (uint, string, error) {
+	slots, err := ctx.GetSlotList(true)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to list PKCS#11 slots: %v", err)
+	}
+
+	for _, slot := range slots {
+		info, err := ctx.GetTokenInfo(slot)
+		if err != nil {
+			continue
+		}
+		if tokenLabel := strings.TrimRight(info.Label, "\x00"); label == "" || tokenLabel == label {
+			return slot, tokenLabel, nil
+		}
+	}
+
+	if label == "" {
+		return 0, "", fmt.Errorf("no PKCS#11 token present")
+	}
+	return 0, "", fmt.Errorf("no PKCS#11 token labelled %q", label)
+}
+
+// Name identifies the token as a random source, e.g. "pkcs11:eamsa512"
+func (e *PKCS11Entropy) Name() string {
+	return "pkcs11:" + e.label
+}
+
+// Read fills p from the token; a failed draw or health test is an error,
+// and leaves p zeroed. After a failed health test every Read fails until
+// HealthCheck passes.
+func (e *PKCS11Entropy) Read(p []byte) (int, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.failure != nil {
+		clear(p)
+		return 0, e.failure
+	}
+	if err := e.readLocked(p); err != nil {
+		clear(p)
+		return 0, err
+	}
+	if err := e.monitor.Test(p); err != nil {
+		e.failure = err
+		clear(p)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// HealthCheck runs the SP 800-90B start-up tests on fresh token output.
+// Passing clears an earlier health test failure.
+func (e *PKCS11Entropy) HealthCheck() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	sample := make([]byte, pkcs11StartupSamples)
+	defer clear(sample)
+
+	if err := e.readLocked(sample); err != nil {
+		return err
+	}
+	e.monitor.Reset()
+	if err := e.monitor.Test(sample); err != nil {
+		return err
+	}
+	e.failure = nil
+	return nil
+}
+
+// readLocked fills p from the token, without health tests
+func (e *PKCS11Entropy) readLocked(p []byte) error {
+	if e.ctx == nil {
+		return fmt.Errorf("PKCS#11 token %q is closed", e.label)
+	}
+
+	random, err := e.ctx.GenerateRandom(e.session, len(p))
+	if err != nil {
+		return fmt.Errorf("PKCS#11 token %q: %v", e.label, err)
+	}
+	copy(p, random)
+	clear(random)
+	return nil
+}
+
+// Close logs out, closes the session and unloads the module
+func (e *PKCS11Entropy) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.ctx == nil {
+		return nil
+	}
+	e.ctx.Logout(e.session)
+	err := e.ctx.CloseSession(e.session)
+	e.ctx.Finalize()
+	e.ctx.Destroy()
+	e.ctx = nil
+	return err
+}
+
+// openPKCS11EntropyFromEnv opens the token of EAMSA_ENTROPY_SOURCE
+// "pkcs11:<module>" with EAMSA_PKCS11_TOKEN and EAMSA_PKCS11_PIN_FILE
+func openPKCS11EntropyFromEnv(module string) (*PKCS11Entropy, error) {
+	if module == "" {
+		return nil, fmt.Errorf("invalid EAMSA_ENTROPY_SOURCE: pkcs11: needs the module path")
+	}
+
+	var pin string
+	if path := os.Getenv("EAMSA_PKCS11_PIN_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read EAMSA_PKCS11_PIN_FILE: %v", err)
+		}
+		pin = strings.TrimSpace(string(data))
+	}
+
+	return OpenPKCS11Entropy(module, os.Getenv("EAMSA_PKCS11_TOKEN"), pin)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - In-Place Seal/Open
+// Encrypt and decrypt without intermediate copies
+//
+// Follows the crypto/cipher convention of reusing the caller's buffer:
+// SealInPlace encrypts the plaintext in buf and appends nonce || tag;
+// OpenInPlace authenticates and decrypts buf and returns the plaintext as
+// a prefix of it. The wire format is the one produced by EncryptData.
+// The implementation is in pkg/eamsa512; the lowercase variants add
+// split-trust MAC keys.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// SealOverhead returns how many bytes SealInPlace appends to a plaintext
+// of length n (padding, nonce and tag). Allocate buf with
+// cap(buf) >= n+SealOverhead(n) to seal without reallocating.
+func SealOverhead(n int) int {
+	return eamsa512.SealOverhead(n)
+}
+
+// SealInPlace encrypts the plaintext in buf and returns
+// ciphertext || nonce || tag. The result reuses buf's storage when its
+// capacity allows; buf must not be used afterwards.
+// nonce: optional nonce; if nil, one is generated (16 bytes)
+func SealInPlace(buf []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	return eamsa512.SealInPlace(buf, masterKey, nonce)
+}
+
+// SealInPlaceContext is SealInPlace that stops with ctx.Err() once ctx
+// is done, e.g. when the client of a request goes away
+func SealInPlaceContext(ctx context.Context, buf []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	return eamsa512.SealInPlaceContext(ctx, buf, masterKey, nonce)
+}
+
+// sealInPlace is SealInPlace with the body tag computed by macKey
+// (nil: derived from masterKey) and the given padding scheme
+func sealInPlace(buf []byte, masterKey []byte, nonce []byte, macKey MACKey, padding eamsa512.Padding) ([]byte, error) {
+	opts := eamsa512.SealOptions{Padding: padding}
+	if macKey != nil {
+		tag, err := newBodyTag(nil, macKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tag = tag
+	}
+	return eamsa512.SealInPlaceWithOptions(buf, masterKey, nonce, opts)
+}
+
+// OpenInPlace verifies and decrypts ciphertext || nonce || tag held in buf
+// and returns the plaintext, which aliases buf. buf is left unchanged if
+// authentication fails.
+func OpenInPlace(buf []byte, masterKey []byte) ([]byte, error) {
+	return eamsa512.OpenInPlace(buf, masterKey)
+}
+
+// OpenInPlaceContext is OpenInPlace that stops with ctx.Err() once ctx
+// is done
+func OpenInPlaceContext(ctx context.Context, buf []byte, masterKey []byte) ([]byte, error) {
+	return eamsa512.OpenInPlaceContext(ctx, buf, masterKey)
+}
+
+// openInPlace is OpenInPlace with the body tag verified by macKey
+// (nil: derived from masterKey) and the given padding scheme
+func openInPlace(buf []byte, masterKey []byte, macKey MACKey, padding eamsa512.Padding) ([]byte, error) {
+	opts := eamsa512.SealOptions{Padding: padding}
+	if macKey != nil {
+		tag, err := newBodyTag(nil, macKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.Tag = tag
+	}
+	return eamsa512.OpenInPlaceWithOptions(buf, masterKey, opts)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/Redeaux-Corporation/eamsa512/format"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Split-Trust Authentication
+// Tags computed under a MAC key held apart from the encryption key
+//
+// Normally every tag key is derived from the master key, so whoever can
+// decrypt can also forge. In split-trust mode the body, header and footer
+// tags come from a MACKey supplied separately (typically backed by an
+// HSM). A host that only holds the master key can still decrypt, but an
+// attacker who steals it cannot produce data that verifies.
+//
+// Containers record the mode in FlagSplitTrust; the flag is covered by
+// the header tag, and readers given a MAC key reject containers without
+// it, so a split-trust file cannot be downgraded to master-key tags.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+const (
+	// splitMACLabel derives the tag key from a local MAC key
+	splitMACLabel = format.SplitMACLabel
+
+	// FlagSplitTrust marks containers with split-trust tags
+	FlagSplitTrust = format.FlagSplitTrust
+)
+
+// TagWriter is an incremental tag computation: write the message, then Sum
+type TagWriter = eamsa512.TagWriter
+
+// MACKey computes HMAC-SHA3-512 tags under a key that is not derived from
+// the master key. An HSM-backed implementation keeps the key in the HSM
+// and maps NewTag to a multi-part sign operation.
+type MACKey interface {
+	NewTag() (TagWriter, error)
+}
+
+// localMACKey is a MACKey held in process memory
+type localMACKey struct {
+	tagKey []byte
+}
+
+// NewMACKey returns a MACKey for a locally held key (32 bytes). The tag
+// key is SHA3-512("EAMSA512-SPLIT-MAC" || key)[:32], so the MAC key is
+// never used directly and cannot collide with a master-key subkey.
+func NewMACKey(key []byte) (MACKey, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid MAC key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	return &localMACKey{tagKey: deriveContainerKey(key, splitMACLabel)}, nil
+}
+
+// NewTag starts an HMAC-SHA3-512 computation under the tag key
+func (k *localMACKey) NewTag() (TagWriter, error) {
+	return newStreamingHMAC(k.tagKey), nil
+}
+
+// newBodyTag starts the tag over nonce || ciphertext: under macKey in
+// split-trust mode, otherwise under the last derived key as EncryptData does
+func newBodyTag(keys [][]byte, macKey MACKey) (TagWriter, error) {
+	if macKey == nil {
+		return newStreamingHMAC(keys[len(keys)-1]), nil
+	}
+
+	tag, err := macKey.NewTag()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start split-trust tag: %v", err)
+	}
+	return tag, nil
+}
+
+// computeSplitTag tags message under macKey, prefixed with label so that
+// tags for different container sections cannot be swapped
+func computeSplitTag(macKey MACKey, label string, message []byte) ([]byte, error) {
+	tag, err := macKey.NewTag()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start split-trust tag: %v", err)
+	}
+
+	tag.Write([]byte(label))
+	tag.Write(message)
+	return tag.Sum(), nil
+}
+
+// EncryptDataSplit is EncryptData with the tag computed under macKey
+// instead of a key derived from masterKey
+func EncryptDataSplit(plaintext []byte, masterKey []byte, macKey MACKey, nonce []byte) ([]byte, error) {
+	if macKey == nil {
+		return nil, fmt.Errorf("split-trust encryption requires a MAC key")
+	}
+
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	return sealInPlace(buf, masterKey, nonce, macKey, eamsa512.PaddingPKCS7)
+}
+
+// DecryptDataSplit verifies the tag under macKey and decrypts data
+// produced by EncryptDataSplit
+func DecryptDataSplit(encryptedData []byte, masterKey []byte, macKey MACKey) ([]byte, error) {
+	if macKey == nil {
+		return nil, fmt.Errorf("split-trust decryption requires a MAC key")
+	}
+
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	return openInPlace(buf, masterKey, macKey, eamsa512.PaddingPKCS7)
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. THREAT MODEL
+   - Encryption host holds the master key only; the MAC key lives in an HSM
+   - Stolen master key: data can be decrypted, but no new or modified
+     container verifies
+   - Verification needs the MAC key (or the HSM) on the reading side
+
+2. KEYS
+   - Tag key = SHA3-512("EAMSA512-SPLIT-MAC" || MAC key)[:32]
+   - Header tag = HMAC(tag key, "EAMSA512-HEADER-MAC" || prefix || metadata)
+   - Body tag = HMAC(tag key, nonce || ciphertext), same layout as EncryptData
+   - Digest footer tag is also under the tag key
+
+3. CONTAINERS
+   - ContainerOptions.MACKey (or WithMACKey for CopyEncrypt/CopyDecrypt)
+   - OpenContainerSplit() requires FlagSplitTrust; OpenContainer() rejects it
+
+*/
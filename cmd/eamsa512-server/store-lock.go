@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+)
+
+// ============================================================================
+// EAMSA 512 - Store Locking
+// Cross-process locks for the embedded keystore and the database
+//
+// The server, CLI commands and embedding applications may point at the
+// same keystore directory or SQLite database. A second writer would
+// overwrite the first one's rotations (keystore.json is rewritten whole)
+// or re-wrap keys underneath it, so every writer takes an exclusive
+// advisory lock first:
+//
+//   <dataDir>/keystore.lock   embedded keystore
+//   <database>.lock           SQLite database
+//
+// Read-only opens take no lock, so a store can be inspected (and dry runs
+// performed) while the server holds it. Force opens a locked store anyway
+// and is audited; it exists for stale locks on network filesystems, not
+// for running two writers.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// StoreOpenOptions selects how a keystore or database is opened
+type StoreOpenOptions struct {
+	// ReadOnly opens without a lock and refuses writes
+	ReadOnly bool
+
+	// Force opens even if another process holds the lock; the override is
+	// written to the audit log
+	Force bool
+}
+
+// lockStore takes the exclusive lock at lockPath for a writable open. It
+// returns a nil lock for read-only opens and forced overrides.
+func lockStore(lockPath string, opts StoreOpenOptions, audit *log.Logger) (*filelock.Lock, error) {
+	if opts.ReadOnly {
+		return nil, nil
+	}
+
+	lock, err := filelock.Acquire(lockPath, filelock.Exclusive)
+	if err == nil {
+		return lock, nil
+	}
+
+	var locked *filelock.LockedError
+	if !errors.As(err, &locked) {
+		return nil, err
+	}
+
+	if !opts.Force {
+		return nil, fmt.Errorf("%v: stop that process, open read-only, or override with -force", locked)
+	}
+
+	audit.Printf("STORE_LOCK_FORCED lock=%s holder_pid=%d holder=%q since=%s",
+		lockPath, locked.Holder.PID, locked.Holder.Command, formatOptionalTime(locked.Holder.Since))
+	return nil, nil
+}
+
+// formatOptionalTime formats t as RFC 3339, or "none" for the zero time
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. WHO LOCKS
+   - NewEmbedded / NewEmbeddedWithOptions: <dataDir>/keystore.lock, held
+     until Close
+   - NewDatabase / OpenDatabase: <database>.lock, held until Close, by
+     every process that opens the database, the rewrap, gc and prune
+     commands included
+   - The lock file records "pid since command" of the holder, so the error
+     names the process to stop
+
+2. READ-ONLY
+   - OpenEmbeddedReadOnly: no rotation, standby or save; Encrypt and
+     Decrypt work with the stored key versions
+   - OpenDatabase with ReadOnly: SQLite mode=ro, no migrations
+   - rewrap -dry-run and prune -dry-run open read-only
+
+3. FORCE
+   - -force on rewrap, gc and prune; StoreOpenOptions.Force in code
+   - Audited as STORE_LOCK_FORCED with the recorded holder
+   - Locks are released by the OS when a process dies, so a stale lock
+     usually means the holder is still running
+
+4. LIMITS
+   - Advisory flock(2) on Unix only; elsewhere the lock file is written
+     but does not exclude
+   - flock is unreliable on some network filesystems
+
+*/
@@ -0,0 +1,749 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Streaming Endpoints
+// Flow-controlled encryption and decryption of very large uploads
+//
+// POST /api/v1/stream/encrypt and /api/v1/stream/decrypt take the raw body
+// and return the raw result, with the key in the X-EAMSA-Master-Key header.
+// Output produced faster than the client reads it is held in a bounded
+// per-stream window of chunks; all windows share one memory budget. When a
+// window is full, or the budget is used up, chunks spill to a file in
+// SpillDir and are sent in order once the client catches up. This also
+// covers HTTP/1.1 clients that upload the whole body before reading the
+// response. Without room in memory or on disk a stream is refused with
+// 507 Insufficient Storage.
+//
+// Encryption writes a chunked stream (eamsa512 version 2). Its chunk size
+// is EncryptChunkSize if set (the server's --chunk-size), otherwise
+// eamsa512.ChunkSizeFor the upload's Content-Length, or of the
+// X-EAMSA-Size-Hint header when the length is not known in advance. The
+// size is recorded in the stream header, so decryption needs no setting.
+// Decryption takes a stream, a chunked envelope or a container, and
+// verifies chunked input on all cores (see CopyDecrypt).
+//
+// The queue's ChunkSize is only the unit of flow control and has nothing
+// to do with the chunk size of the output.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// StreamConfig configures the streaming endpoints
+type StreamConfig struct {
+	ChunkSize        int           // Bytes per chunk (default 64 KiB)
+	WindowChunks     int           // In-memory chunks per stream before spilling (default 16)
+	MaxInFlightBytes int64         // Memory budget shared by all windows (default 256 MiB)
+	SpillDir         string        // Directory for spill files ("" disables spilling)
+	MaxSpillBytes    int64         // Spill limit per stream (default 4 GiB)
+	StallTimeout     time.Duration // Abort when the client sends or reads nothing for this long (default 30s)
+	MaxUploadBytes   int64         // Request body limit (0 = unlimited)
+	EncryptChunkSize int           // Chunk size of encrypted streams (0: adaptive, see eamsa512.ChunkSizeFor)
+}
+
+// Streaming defaults
+const (
+	defaultStreamChunkSize     = 64 * 1024
+	defaultStreamWindowChunks  = 16
+	defaultStreamInFlightBytes = 256 << 20
+	defaultStreamSpillBytes    = 4 << 30
+	defaultStreamStallTimeout  = 30 * time.Second
+)
+
+// StreamStatusTrailer carries "ok" or the error of a stream whose response
+// had already started
+const StreamStatusTrailer = "X-EAMSA-Stream-Status"
+
+var (
+	errStreamMemory      = errors.New("stream memory budget exhausted")
+	errStreamSpillFull   = errors.New("stream spill limit reached")
+	errStreamStalled     = errors.New("client stopped reading")
+	errStreamReadStalled = errors.New("client stopped sending")
+	errStreamTooLarge    = errors.New("upload exceeds the size limit")
+	errStreamAborted     = errors.New("stream aborted")
+)
+
+// StreamStats holds streaming counters
+type StreamStats struct {
+	Active           int64  `json:"active"`
+	WindowChunks     int64  `json:"window_chunks"`     // Chunks held in memory windows now
+	WindowCapacity   int64  `json:"window_capacity"`   // Memory budget in chunks
+	WindowPeak       int64  `json:"window_peak"`       // Highest WindowChunks since start
+	Spilling         int64  `json:"spilling"`          // Streams with chunks on disk now
+	SpilledBytes     uint64 `json:"spilled_bytes"`     // Bytes written to spill files since start
+	Rejected         uint64 `json:"rejected"`          // Streams refused or cut short for lack of memory or spill space
+	StalledAborts    uint64 `json:"stalled_aborts"`    // Streams aborted by StallTimeout
+	CompletedBytes   uint64 `json:"completed_bytes"`   // Output bytes sent by completed streams
+	CompletedStreams uint64 `json:"completed_streams"` // Streams completed successfully
+}
+
+// StreamServer runs the streaming endpoints
+type StreamServer struct {
+	config StreamConfig
+	tokens chan struct{} // One token per chunk held in a memory window
+
+	active           atomic.Int64
+	windowPeak       atomic.Int64
+	spilling         atomic.Int64
+	spilledBytes     atomic.Uint64
+	rejected         atomic.Uint64
+	stalledAborts    atomic.Uint64
+	completedBytes   atomic.Uint64
+	completedStreams atomic.Uint64
+}
+
+// NewStreamServer applies defaults and creates the streaming endpoints
+func NewStreamServer(config StreamConfig) (*StreamServer, error) {
+	if config.ChunkSize <= 0 {
+		config.ChunkSize = defaultStreamChunkSize
+	}
+	if config.WindowChunks <= 0 {
+		config.WindowChunks = defaultStreamWindowChunks
+	}
+	if config.MaxInFlightBytes <= 0 {
+		config.MaxInFlightBytes = defaultStreamInFlightBytes
+	}
+	if config.MaxSpillBytes <= 0 {
+		config.MaxSpillBytes = defaultStreamSpillBytes
+	}
+	if config.StallTimeout <= 0 {
+		config.StallTimeout = defaultStreamStallTimeout
+	}
+	if config.EncryptChunkSize < 0 || config.EncryptChunkSize > eamsa512.MaxChunkSize {
+		return nil, fmt.Errorf("invalid stream chunk size %d: must be between 1 and %d", config.EncryptChunkSize, eamsa512.MaxChunkSize)
+	}
+
+	capacity := config.MaxInFlightBytes / int64(config.ChunkSize)
+	if capacity < 1 {
+		return nil, fmt.Errorf("stream memory budget %d is smaller than one %d-byte chunk", config.MaxInFlightBytes, config.ChunkSize)
+	}
+
+	if config.SpillDir != "" {
+		if err := os.MkdirAll(config.SpillDir, 0700); err != nil {
+			return nil, fmt.Errorf("failed to create stream spill directory: %v", err)
+		}
+	}
+
+	return &StreamServer{
+		config: config,
+		tokens: make(chan struct{}, capacity),
+	}, nil
+}
+
+// Stats returns streaming counters
+func (s *StreamServer) Stats() StreamStats {
+	return StreamStats{
+		Active:           s.active.Load(),
+		WindowChunks:     int64(len(s.tokens)),
+		WindowCapacity:   int64(cap(s.tokens)),
+		WindowPeak:       s.windowPeak.Load(),
+		Spilling:         s.spilling.Load(),
+		SpilledBytes:     s.spilledBytes.Load(),
+		Rejected:         s.rejected.Load(),
+		StalledAborts:    s.stalledAborts.Load(),
+		CompletedBytes:   s.completedBytes.Load(),
+		CompletedStreams: s.completedStreams.Load(),
+	}
+}
+
+// HandleStreamEncrypt handles POST /api/v1/stream/encrypt
+func HandleStreamEncrypt(w http.ResponseWriter, r *http.Request) {
+	if streamServer == nil {
+		respondError(w, r, "not_found", "Streaming is disabled")
+		return
+	}
+	streamServer.serve(w, r, "encrypt")
+}
+
+// HandleStreamDecrypt handles POST /api/v1/stream/decrypt
+func HandleStreamDecrypt(w http.ResponseWriter, r *http.Request) {
+	if streamServer == nil {
+		respondError(w, r, "not_found", "Streaming is disabled")
+		return
+	}
+	streamServer.serve(w, r, "decrypt")
+}
+
+// serve runs one stream: a producer goroutine reads the body through the
+// cipher into the stream queue, and this goroutine sends queued chunks
+func (s *StreamServer) serve(w http.ResponseWriter, r *http.Request, op string) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	key, err := hex.DecodeString(r.Header.Get("X-EAMSA-Master-Key"))
+	if err != nil || len(key) != KeySize {
+		respondError(w, r, "bad_request", "X-EAMSA-Master-Key must be a hex-encoded 32-byte key")
+		return
+	}
+	defer zeroizeKey(key)
+	keyID := keyid.New(key).String()
+
+	identity := identifyDecryptCaller(r)
+	if honeytokens != nil {
+		if alert := honeytokens.CheckKey(keyID, op, r.RemoteAddr, identity.User); alert != nil {
+			LogHoneytokenAlert(alert)
+		}
+	}
+
+	// Break-glass rules, from the X-EAMSA-Classification,
+	// X-EAMSA-Created-At, X-EAMSA-Ticket and X-EAMSA-Justification headers
+	if op == "decrypt" {
+		policyRequest, err := newDecryptPolicyRequest(r, identity, keyID)
+		if err != nil {
+			respondError(w, r, "bad_request", err.Error())
+			return
+		}
+		if !authorizeDecrypt(w, r, policyRequest) {
+			return
+		}
+	}
+
+	// A stream counts as one record against the caller's decrypt quota
+	if op == "decrypt" && decryptQuotas != nil {
+		_, resetAt, err := decryptQuotas.Charge(identity, 1)
+		if err != nil {
+			LogAuditEvent("DECRYPT_QUOTA_EXCEEDED", map[string]interface{}{
+				"user":      identity.User,
+				"roles":     identity.Roles,
+				"key_id":    keyID,
+				"reset_at":  resetAt.Format(time.RFC3339),
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetAt).Seconds())+1))
+			respondError(w, r, "quota_exceeded",
+				fmt.Sprintf("Decrypt quota exceeded; resets at %s", resetAt.Format(time.RFC3339)))
+			return
+		}
+	}
+
+	if s.config.MaxUploadBytes > 0 && r.ContentLength > s.config.MaxUploadBytes {
+		respondError(w, r, "too_large",
+			fmt.Sprintf("upload exceeds %d bytes", s.config.MaxUploadBytes))
+		return
+	}
+
+	chunkSize, err := s.encryptChunkSize(r)
+	if err != nil {
+		respondError(w, r, "bad_request", err.Error())
+		return
+	}
+
+	// The server-wide read/write timeouts would cut off large transfers;
+	// streams use StallTimeout per read and write instead. Writing the
+	// response while the upload is still arriving needs full duplex on
+	// HTTP/1.1 (HTTP/2 always is).
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+	rc.EnableFullDuplex()
+
+	s.active.Add(1)
+	defer s.active.Add(-1)
+
+	q := newStreamQueue(s)
+	defer q.release()
+
+	body := &streamReader{r: r.Body, rc: rc, timeout: s.config.StallTimeout, limit: s.config.MaxUploadBytes}
+	cw := &chunkWriter{q: q, size: s.config.ChunkSize}
+
+	start := time.Now()
+	var produced int64
+	go func() {
+		var err error
+		switch op {
+		case "encrypt":
+			produced, err = CopyEncrypt(cw, body, key, WithChunkSize(chunkSize))
+		case "decrypt":
+			produced, err = CopyDecrypt(cw, body, key, WithSpoolDir(s.config.SpillDir))
+		}
+		if err == nil {
+			err = cw.flush()
+		}
+		// Once the upload is complete the client has no reason not to read
+		rc.SetWriteDeadline(time.Now().Add(s.config.StallTimeout))
+		q.finish(err)
+	}()
+
+	sent, sendErr := s.send(w, rc, q)
+	q.abort()
+	<-q.done
+
+	// Body and producer errors explain why sending stopped, unless the
+	// producer only stopped because sending did
+	err = body.err
+	if err == nil && !errors.Is(q.producerErr, errStreamAborted) {
+		err = q.producerErr
+	}
+	if err == nil {
+		err = sendErr
+	}
+
+	switch op {
+	case "encrypt":
+		serverTelemetry.ObserveEncrypt(int(produced), time.Since(start), err)
+	case "decrypt":
+		serverTelemetry.ObserveDecrypt(int(body.n), time.Since(start), err)
+	}
+
+	details := map[string]interface{}{
+		"key_id":        keyID,
+		"user":          identity.User,
+		"bytes_in":      body.n,
+		"bytes_out":     sent,
+		"spilled_bytes": q.spilled,
+		"timestamp":     time.Now().Format(time.RFC3339),
+	}
+	if op == "encrypt" {
+		details["chunk_size"] = chunkSize
+	}
+
+	if err == nil {
+		s.completedStreams.Add(1)
+		s.completedBytes.Add(uint64(sent))
+		LogAuditEvent("STREAM_"+streamEvent(op), details)
+		return
+	}
+
+	details["error"] = err.Error()
+	code, message := streamErrorResponse(op, err)
+	switch code {
+	case "insufficient_storage":
+		s.rejected.Add(1)
+	case "timeout":
+		s.stalledAborts.Add(1)
+	}
+
+	LogAuditEvent("STREAM_"+streamEvent(op)+"_FAILED", details)
+
+	if q.started {
+		// Status and part of the body are gone; report in the trailer
+		w.Header().Set(StreamStatusTrailer, "error: "+err.Error())
+		return
+	}
+
+	if code == "insufficient_storage" {
+		w.Header().Set("Retry-After", "5")
+	}
+	respondError(w, r, code, message)
+}
+
+// encryptChunkSize returns the chunk size of an encrypted stream: the
+// configured one, or one chosen for the upload's size
+func (s *StreamServer) encryptChunkSize(r *http.Request) (int, error) {
+	if s.config.EncryptChunkSize > 0 {
+		return s.config.EncryptChunkSize, nil
+	}
+
+	size := r.ContentLength
+	if hint := r.Header.Get("X-EAMSA-Size-Hint"); hint != "" && size < 0 {
+		n, err := strconv.ParseInt(hint, 10, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("X-EAMSA-Size-Hint must be a byte count")
+		}
+		size = n
+	}
+	return eamsa512.ChunkSizeFor(size), nil
+}
+
+// ParseChunkSize parses a chunk size such as "65536", "256K" or "1M"
+func ParseChunkSize(value string) (int, error) {
+	s := strings.ToUpper(strings.TrimSpace(value))
+	shift := 0
+	switch {
+	case strings.HasSuffix(s, "K"):
+		shift = 10
+	case strings.HasSuffix(s, "M"):
+		shift = 20
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil || n <= 0 || n > eamsa512.MaxChunkSize>>shift {
+		return 0, fmt.Errorf("invalid chunk size %q: must be between 1 and %d bytes", value, eamsa512.MaxChunkSize)
+	}
+	return n << shift, nil
+}
+
+// send writes queued chunks to the client until the producer finishes.
+// The status line is written with the first chunk, so errors before it
+// still get a proper error response.
+func (s *StreamServer) send(w http.ResponseWriter, rc *http.ResponseController, q *streamQueue) (int64, error) {
+	var sent int64
+
+	for {
+		chunk, err := q.pop()
+		if err == io.EOF {
+			if !q.started {
+				s.startResponse(w, q)
+			}
+			w.Header().Set(StreamStatusTrailer, "ok")
+			return sent, nil
+		}
+		if err != nil {
+			return sent, err
+		}
+
+		if !q.started {
+			s.startResponse(w, q)
+		}
+
+		if q.uploadDone() {
+			rc.SetWriteDeadline(time.Now().Add(s.config.StallTimeout))
+		}
+		part, injected := failpoint.Partial(failpoint.StreamWrite, chunk)
+		n, err := w.Write(part)
+		sent += int64(n)
+		if err == nil {
+			err = injected
+		}
+		if err != nil {
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				return sent, errStreamStalled
+			}
+			return sent, err
+		}
+		rc.Flush()
+	}
+}
+
+func (s *StreamServer) startResponse(w http.ResponseWriter, q *streamQueue) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Trailer", StreamStatusTrailer)
+	w.WriteHeader(http.StatusOK)
+	q.started = true
+}
+
+// streamErrorResponse maps a stream error to a problem code and detail
+func streamErrorResponse(op string, err error) (string, string) {
+	switch {
+	case errors.Is(err, errStreamMemory), errors.Is(err, errStreamSpillFull):
+		return "insufficient_storage", err.Error()
+	case errors.Is(err, errStreamStalled), errors.Is(err, errStreamReadStalled):
+		return "timeout", err.Error()
+	case errors.Is(err, errStreamTooLarge):
+		return "too_large", err.Error()
+	case op == "decrypt":
+		return "decryption_failed", "Authentication failed or invalid data"
+	}
+	return "encryption_failed", err.Error()
+}
+
+func streamEvent(op string) string {
+	if op == "encrypt" {
+		return "ENCRYPT"
+	}
+	return "DECRYPT"
+}
+
+// ============================================================================
+// Stream Queue
+// ============================================================================
+
+// streamQueue is a FIFO of output chunks: up to WindowChunks in memory,
+// the rest in a spill file. Once a chunk has spilled, later chunks spill
+// too until the file is drained, so order is kept.
+type streamQueue struct {
+	s *StreamServer
+
+	mu        sync.Mutex
+	mem       [][]byte // Chunks in memory, each holding a budget token
+	spill     *os.File
+	spillLens []int // Lengths of chunks in the spill file, in order
+	readOff   int64
+	writeOff  int64
+	spilled   int64 // Total bytes spilled
+	finished  bool
+	aborted   bool
+
+	ready chan struct{} // Signalled when a chunk is queued or the producer finishes
+	space chan struct{} // Signalled when a memory chunk is sent
+	done  chan struct{} // Closed when the producer returns
+
+	producerErr error
+	started     bool // Sender side only: response status written
+}
+
+func newStreamQueue(s *StreamServer) *streamQueue {
+	return &streamQueue{
+		s:     s,
+		ready: make(chan struct{}, 1),
+		space: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+}
+
+func notifyChan(c chan struct{}) {
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
+
+// push queues a chunk, waiting up to StallTimeout for room
+func (q *streamQueue) push(chunk []byte) error {
+	timer := time.NewTimer(q.s.config.StallTimeout)
+	defer timer.Stop()
+
+	for {
+		q.mu.Lock()
+		if q.aborted {
+			q.mu.Unlock()
+			return errStreamAborted
+		}
+
+		windowFree := len(q.spillLens) == 0 && len(q.mem) < q.s.config.WindowChunks
+		if windowFree {
+			select {
+			case q.s.tokens <- struct{}{}:
+				q.mem = append(q.mem, chunk)
+				q.mu.Unlock()
+				q.s.recordPeak()
+				notifyChan(q.ready)
+				return nil
+			default:
+			}
+		}
+
+		if q.s.config.SpillDir != "" {
+			err := q.spillChunk(chunk)
+			q.mu.Unlock()
+			if err == nil {
+				notifyChan(q.ready)
+			}
+			return err
+		}
+		q.mu.Unlock()
+
+		// No spilling: wait for the client (window) or other streams (budget)
+		select {
+		case <-q.space:
+		case <-time.After(10 * time.Millisecond):
+		case <-timer.C:
+			if windowFree {
+				return errStreamMemory
+			}
+			return errStreamStalled
+		}
+	}
+}
+
+// spillChunk appends a chunk to the spill file; caller holds q.mu
+func (q *streamQueue) spillChunk(chunk []byte) error {
+	if q.writeOff-q.readOff+int64(len(chunk)) > q.s.config.MaxSpillBytes {
+		return errStreamSpillFull
+	}
+
+	if q.spill == nil {
+		f, err := os.CreateTemp(q.s.config.SpillDir, "eamsa512-stream-*")
+		if err != nil {
+			return fmt.Errorf("%w: %v", errStreamSpillFull, err)
+		}
+		q.spill = f
+	}
+
+	if len(q.spillLens) == 0 {
+		q.s.spilling.Add(1)
+	}
+	if _, err := q.spill.WriteAt(chunk, q.writeOff); err != nil {
+		if len(q.spillLens) == 0 {
+			q.s.spilling.Add(-1)
+		}
+		return fmt.Errorf("%w: %v", errStreamSpillFull, err)
+	}
+
+	q.writeOff += int64(len(chunk))
+	q.spillLens = append(q.spillLens, len(chunk))
+	q.spilled += int64(len(chunk))
+	q.s.spilledBytes.Add(uint64(len(chunk)))
+	return nil
+}
+
+// pop returns the next chunk, io.EOF when the producer finished cleanly,
+// or the producer's error
+func (q *streamQueue) pop() ([]byte, error) {
+	for {
+		q.mu.Lock()
+
+		if len(q.mem) > 0 {
+			chunk := q.mem[0]
+			q.mem = q.mem[1:]
+			q.mu.Unlock()
+			<-q.s.tokens
+			notifyChan(q.space)
+			return chunk, nil
+		}
+
+		if len(q.spillLens) > 0 {
+			chunk := make([]byte, q.spillLens[0])
+			_, err := q.spill.ReadAt(chunk, q.readOff)
+			if err != nil {
+				q.mu.Unlock()
+				return nil, fmt.Errorf("failed to read spill file: %v", err)
+			}
+			q.readOff += int64(len(chunk))
+			q.spillLens = q.spillLens[1:]
+			if len(q.spillLens) == 0 {
+				// Drained: reuse the file from the start
+				q.readOff, q.writeOff = 0, 0
+				q.spill.Truncate(0)
+				q.s.spilling.Add(-1)
+			}
+			q.mu.Unlock()
+			return chunk, nil
+		}
+
+		if q.finished {
+			err := q.producerErr
+			q.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		q.mu.Unlock()
+
+		<-q.ready
+	}
+}
+
+// finish records the producer's result
+func (q *streamQueue) finish(err error) {
+	q.mu.Lock()
+	q.finished = true
+	q.producerErr = err
+	q.mu.Unlock()
+
+	notifyChan(q.ready)
+	close(q.done)
+}
+
+// uploadDone reports whether the producer has finished
+func (q *streamQueue) uploadDone() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.finished
+}
+
+// abort makes further pushes fail so the producer stops
+func (q *streamQueue) abort() {
+	q.mu.Lock()
+	q.aborted = true
+	q.mu.Unlock()
+	notifyChan(q.space)
+}
+
+// release returns budget tokens and removes the spill file
+func (q *streamQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for range q.mem {
+		<-q.s.tokens
+	}
+	q.mem = nil
+
+	if len(q.spillLens) > 0 {
+		q.s.spilling.Add(-1)
+		q.spillLens = nil
+	}
+	if q.spill != nil {
+		q.spill.Close()
+		os.Remove(q.spill.Name())
+	}
+}
+
+// recordPeak updates the window occupancy high-water mark
+func (s *StreamServer) recordPeak() {
+	current := int64(len(s.tokens))
+	for {
+		peak := s.windowPeak.Load()
+		if current <= peak || s.windowPeak.CompareAndSwap(peak, current) {
+			return
+		}
+	}
+}
+
+// chunkWriter collects cipher output into ChunkSize chunks for the queue
+type chunkWriter struct {
+	q    *streamQueue
+	size int
+	buf  []byte
+}
+
+func (cw *chunkWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		if cw.buf == nil {
+			cw.buf = make([]byte, 0, cw.size)
+		}
+		n := copy(cw.buf[len(cw.buf):cw.size], p)
+		cw.buf = cw.buf[:len(cw.buf)+n]
+		p = p[n:]
+		written += n
+
+		if len(cw.buf) == cw.size {
+			if err := cw.flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// flush queues the partial chunk
+func (cw *chunkWriter) flush() error {
+	if len(cw.buf) == 0 {
+		return nil
+	}
+	chunk := cw.buf
+	cw.buf = nil
+	return cw.q.push(chunk)
+}
+
+// streamReader reads the request body with a stall timeout per read and
+// an optional size limit, recording why reading stopped
+type streamReader struct {
+	r       io.Reader
+	rc      *http.ResponseController
+	timeout time.Duration
+	limit   int64
+	n       int64
+	err     error // Set for stalls and the size limit
+}
+
+func (sr *streamReader) Read(p []byte) (int, error) {
+	sr.rc.SetReadDeadline(time.Now().Add(sr.timeout))
+	n, err := sr.r.Read(p)
+	sr.n += int64(n)
+
+	if sr.limit > 0 && sr.n > sr.limit {
+		sr.err = errStreamTooLarge
+		return n, sr.err
+	}
+	if err != nil && errors.Is(err, os.ErrDeadlineExceeded) {
+		sr.err = errStreamReadStalled
+		return n, sr.err
+	}
+	return n, err
+}
@@ -0,0 +1,1832 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/problem"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ============================================================================
+// EAMSA 512 - Web Server Implementation
+// REST API Server with TLS support
+//
+// Provides HTTP/2 endpoints for encryption, decryption, and key management.
+// Implements FIPS 140-2 Level 2 compliance and audit logging.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// Server configuration
+type ServerConfig struct {
+	Host            string
+	Port            int
+	TLSEnabled      bool
+	TLSCertPath     string
+	TLSKeyPath      string
+	ReadTimeout     time.Duration
+	WriteTimeout    time.Duration
+	IdleTimeout     time.Duration
+	MaxBodySize     int64
+	LogFilePath     string
+	AuditLogPath    string
+	ShutdownTimeout time.Duration
+
+	// Async audit queue (AuditQueueCapacity 0 keeps synchronous writes)
+	AuditQueueCapacity int
+	AuditBatchSize     int
+	AuditFlushInterval time.Duration
+	AuditOverflow      AuditOverflowPolicy
+	AuditSpillPath     string
+
+	// Per-category audit sinks (nil sends every category to AuditLogPath)
+	AuditRouter *AuditRouter
+
+	// Telemetry receives operation observations (default: built-in Prometheus
+	// collector exported on /metrics)
+	Telemetry telemetry.Telemetry
+
+	// Per-identity decrypt quotas (nil disables). Identities come from client
+	// certificates verified against ClientCAPath.
+	DecryptQuotas *DecryptQuotaPolicy
+	ClientCAPath  string
+
+	// Decryptions that need break-glass approval (nil disables)
+	DecryptPolicy *DecryptPolicy
+
+	// Decoy keys and canary ciphertexts (nil disables)
+	Honeytokens *Honeytokens
+
+	// Streaming endpoints (nil disables)
+	Stream *StreamConfig
+
+	// Ciphertext storage for "store" requests (nil disables)
+	BlobStore BlobStore
+
+	// Pseudonymize user IDs and IPs in audit records (nil disables)
+	Pseudonymizer *Pseudonymizer
+}
+
+// Request/Response types
+
+// EncryptRequest represents an encryption request
+type EncryptRequest struct {
+	Plaintext string `json:"plaintext"`
+	MasterKey string `json:"master_key"` // hex-encoded
+	Nonce     string `json:"nonce"`      // hex-encoded (optional)
+	Encoding  string `json:"encoding"`   // plaintext encoding: "" (text) or "hex" (binary data)
+	Store     bool   `json:"store"`      // keep the ciphertext in the blob store and return a handle
+
+	// Suite is the cipher suite agreed through /api/v1/negotiate; zero
+	// means eamsa512.SuiteLegacy, the format of servers before negotiation
+	Suite eamsa512.Suite `json:"suite"`
+}
+
+// EncryptResponse represents an encryption response
+type EncryptResponse struct {
+	Ciphertext string `json:"ciphertext,omitempty"` // hex-encoded
+	Nonce      string `json:"nonce,omitempty"`      // hex-encoded
+	Tag        string `json:"tag,omitempty"`        // hex-encoded
+	Handle     string `json:"handle,omitempty"`     // blob handle when stored instead
+	Envelope   string `json:"envelope,omitempty"`   // hex-encoded, instead of ciphertext, nonce and tag for envelope suites
+	KeyID      string `json:"key_id"`               // audit-safe key identifier (keyid format)
+	Timestamp  string `json:"timestamp"`
+	Size       int    `json:"size"`
+
+	// Suite is the cipher suite the data was sealed in
+	Suite eamsa512.Suite `json:"suite"`
+}
+
+// DecryptRequest represents a decryption request
+type DecryptRequest struct {
+	Ciphertext string `json:"ciphertext"` // hex-encoded
+	MasterKey  string `json:"master_key"` // hex-encoded
+	Nonce      string `json:"nonce"`      // hex-encoded
+	Tag        string `json:"tag"`        // hex-encoded
+	Handle     string `json:"handle"`     // blob handle, instead of ciphertext, nonce and tag
+	Envelope   string `json:"envelope"`   // hex-encoded envelope, instead of ciphertext, nonce and tag
+	Encoding   string `json:"encoding"`   // response plaintext encoding: "" (text) or "hex"
+
+	// Break-glass attributes (see decrypt-policy.go)
+	Classification string `json:"classification"` // data classification, e.g. "secret"
+	CreatedAt      string `json:"created_at"`     // when the data was encrypted (RFC 3339)
+	Ticket         string `json:"ticket"`         // incident or change ticket reference
+	Justification  string `json:"justification"`
+}
+
+// NegotiateRequest is a client's cipher suite offer (see eamsa512.Negotiate)
+type NegotiateRequest struct {
+	MasterKey string           `json:"master_key"` // hex-encoded; authenticates the exchange
+	Suites    []eamsa512.Suite `json:"suites"`     // every suite the client supports
+	Random    string           `json:"random"`     // hex-encoded client random (32 bytes)
+}
+
+// NegotiateResponse is the server's authenticated choice
+type NegotiateResponse struct {
+	Suite     eamsa512.Suite   `json:"suite"`
+	SuiteName string           `json:"suite_name"`
+	Suites    []eamsa512.Suite `json:"suites"` // every suite the server supports
+	Random    string           `json:"random"` // hex-encoded server random (32 bytes)
+	MAC       string           `json:"mac"`    // hex-encoded HMAC-SHA3-512 over the exchange
+	KeyID     string           `json:"key_id"`
+}
+
+// DecryptResponse represents a decryption response
+type DecryptResponse struct {
+	Plaintext string `json:"plaintext"`
+	KeyID     string `json:"key_id"` // audit-safe key identifier (keyid format)
+	Timestamp string `json:"timestamp"`
+	Size      int    `json:"size"`
+	Verified  bool   `json:"verified"`
+}
+
+// HealthCheckResponse represents health check response
+type HealthCheckResponse struct {
+	Status      string    `json:"status"`
+	Version     string    `json:"version"`
+	Timestamp   string    `json:"timestamp"`
+	Uptime      string    `json:"uptime"`
+	TLSEnabled  bool      `json:"tls_enabled"`
+	BlockSize   int       `json:"block_size"`
+	KeySize     int       `json:"key_size"`
+	NonceSize   int       `json:"nonce_size"`
+	RoundCount  int       `json:"round_count"`
+	RandomSource string   `json:"random_source"` // configured nonce and IV source
+}
+
+// ComplianceReport represents a compliance report
+type ComplianceReport struct {
+	FIPSMode              bool   `json:"fips_mode"`
+	NISTSP80056A          bool   `json:"nist_sp_800_56a"`
+	SHA3512Used           bool   `json:"sha3_512_used"`
+	HMACAuthentication    bool   `json:"hmac_authentication"`
+	TLSEnabled            bool   `json:"tls_enabled"`
+	AuditLoggingEnabled   bool   `json:"audit_logging_enabled"`
+	BlockSize             int    `json:"block_size"`
+	KeySize               int    `json:"key_size"`
+	NonceSize             int    `json:"nonce_size"`
+	AuthenticationTagSize int    `json:"authentication_tag_size"`
+	Timestamp             string `json:"timestamp"`
+	ComplianceScore       int    `json:"compliance_score"` // 0-100
+
+	DecryptQuotas *DecryptQuotaReport `json:"decrypt_quotas,omitempty"`
+}
+
+// Global variables
+var (
+	serverStartTime time.Time
+	auditLogger     *log.Logger
+	auditQueue      *AuditQueue // nil when audit writes are synchronous
+	auditRouter     *AuditRouter // nil when no category has its own sink
+	errorLogger     *log.Logger
+	serverTelemetry telemetry.Telemetry = telemetry.Nop{}
+	decryptQuotas   *DecryptQuotas // nil when quotas are disabled
+	decryptPolicy   *DecryptPolicy // nil when no break-glass rules apply
+	honeytokens     *Honeytokens   // nil when no honeytokens are registered
+	streamServer    *StreamServer  // nil when streaming is disabled
+	blobStore       BlobStore      // nil when no blob store is configured
+	auditPseudonyms *Pseudonymizer // nil when audit records keep real identities
+	nonceRandom     *eamsa512.Random // nil when nonces come from crypto/rand
+)
+
+// ============================================================================
+// Initialization
+// ============================================================================
+
+// Validate returns a *confschema.ValidationError listing every invalid
+// field. Zero timeouts mean none, as in net/http; zero audit queue
+// settings select the queue defaults.
+func (config ServerConfig) Validate() error {
+	v := confschema.NewValidator("ServerConfig")
+
+	v.Range("Port", config.Port, 1, 65535)
+	v.Check(!config.TLSEnabled || config.TLSCertPath != "", "TLSCertPath", config.TLSCertPath, "a certificate path when TLSEnabled")
+	v.Check(!config.TLSEnabled || config.TLSKeyPath != "", "TLSKeyPath", config.TLSKeyPath, "a key path when TLSEnabled")
+	v.Check(config.ReadTimeout >= 0, "ReadTimeout", config.ReadTimeout, "0 (none) or more")
+	v.Check(config.WriteTimeout >= 0, "WriteTimeout", config.WriteTimeout, "0 (none) or more")
+	v.Check(config.IdleTimeout >= 0, "IdleTimeout", config.IdleTimeout, "0 (none) or more")
+	v.Check(config.ShutdownTimeout >= 0, "ShutdownTimeout", config.ShutdownTimeout, "0 (none) or more")
+	v.Check(config.MaxBodySize > 0, "MaxBodySize", config.MaxBodySize, "at least 1 byte")
+	v.Check(config.LogFilePath != "", "LogFilePath", config.LogFilePath, "a file path")
+	v.Check(config.AuditLogPath != "", "AuditLogPath", config.AuditLogPath, "a file path")
+
+	v.Min("AuditQueueCapacity", config.AuditQueueCapacity, 0)
+	v.Min("AuditBatchSize", config.AuditBatchSize, 0)
+	v.Check(config.AuditBatchSize <= config.AuditQueueCapacity || config.AuditQueueCapacity == 0,
+		"AuditBatchSize", config.AuditBatchSize, fmt.Sprintf("at most AuditQueueCapacity (%d)", config.AuditQueueCapacity))
+	v.Check(config.AuditFlushInterval >= 0, "AuditFlushInterval", config.AuditFlushInterval, "0 (default) or more")
+
+	return v.Err()
+}
+
+// InitServer initializes the server and logging
+func InitServer(config ServerConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+
+	serverStartTime = time.Now()
+
+	if config.Telemetry != nil {
+		serverTelemetry = config.Telemetry
+	} else {
+		serverTelemetry = telemetry.NewPrometheus("eamsa512")
+	}
+
+	// Setup audit logger
+	auditFile, err := os.OpenFile(config.AuditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %v", err)
+	}
+
+	auditLogger = log.New(auditFile, "[AUDIT] ", log.LstdFlags|log.Lshortfile)
+
+	// Setup error logger
+	errorFile, err := os.OpenFile(config.LogFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open error log: %v", err)
+	}
+
+	errorLogger = log.New(errorFile, "[ERROR] ", log.LstdFlags|log.Lshortfile)
+
+	// Setup async audit queue
+	if config.AuditQueueCapacity > 0 {
+		auditQueue, err = NewAuditQueue(loggerAuditSink{logger: auditLogger}, AuditQueueConfig{
+			Capacity:      config.AuditQueueCapacity,
+			BatchSize:     config.AuditBatchSize,
+			FlushInterval: config.AuditFlushInterval,
+			Overflow:      config.AuditOverflow,
+			SpillPath:     config.AuditSpillPath,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to start audit queue: %v", err)
+		}
+	}
+
+	auditRouter = config.AuditRouter
+
+	if config.DecryptQuotas != nil {
+		decryptQuotas = NewDecryptQuotas(config.DecryptQuotas)
+	}
+	decryptPolicy = config.DecryptPolicy
+	honeytokens = config.Honeytokens
+
+	blobStore = config.BlobStore
+	auditPseudonyms = config.Pseudonymizer
+
+	if config.Stream != nil {
+		streamServer, err = NewStreamServer(*config.Stream)
+		if err != nil {
+			return fmt.Errorf("failed to start streaming: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// LogAuditEvent logs an audit event
+// With the audit queue enabled the event is queued and written in the
+// background; dropped events are counted in the queue stats. Events whose
+// category has a route (see audit-routing.go) go to that route's sink
+// instead. Identities in details are pseudonymized first when configured.
+func LogAuditEvent(event string, details map[string]interface{}) {
+	if auditPseudonyms != nil {
+		auditPseudonyms.pseudonymizeDetails(details)
+	}
+	detailsJSON, _ := json.Marshal(details)
+
+	entry := AuditLogEntry{
+		EventType: event,
+		Category:  AuditCategoryOf(event),
+		Severity:  "info",
+		Details:   string(detailsJSON),
+		Timestamp: time.Now(),
+	}
+	if severity, ok := details["severity"].(string); ok {
+		entry.Severity = severity
+	}
+
+	if auditRouter != nil && auditRouter.Route(entry) {
+		return
+	}
+
+	if auditQueue != nil {
+		auditQueue.Enqueue(entry)
+		return
+	}
+
+	// Helpers such as SealContainer also run outside the server (embedded
+	// mode, tools), before InitServer has opened the audit log
+	if auditLogger == nil {
+		log.Printf("[AUDIT] %s | %s", event, string(detailsJSON))
+		return
+	}
+
+	auditLogger.Printf("%s | %s", event, string(detailsJSON))
+}
+
+// LogHoneytokenAlert audits a tripped honeytoken as a critical event
+func LogHoneytokenAlert(alert *HoneytokenAlert) {
+	LogAuditEvent("HONEYTOKEN_TRIPPED", map[string]interface{}{
+		"severity": "critical",
+		"kind": alert.Kind,
+		"label": alert.Label,
+		"key_id": alert.KeyID,
+		"fingerprint": alert.Fingerprint,
+		"operation": alert.Operation,
+		"source": alert.Source,
+		"user": alert.User,
+		"timestamp": alert.Time.Format(time.RFC3339),
+	})
+}
+
+// LogError logs an error
+func LogError(message string, err error) {
+	if err != nil {
+		errorLogger.Printf("%s: %v", message, err)
+	} else {
+		errorLogger.Printf("%s", message)
+	}
+}
+
+// ============================================================================
+// HTTP Handlers
+// ============================================================================
+
+// HandleEncrypt handles POST /api/v1/encrypt
+func HandleEncrypt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	// Parse request
+	var req EncryptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		LogError("Failed to decode encrypt request", err)
+		respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	// Validate request
+	if req.Plaintext == "" {
+		respondError(w, r, "bad_request", "plaintext is required")
+		return
+	}
+
+	if req.MasterKey == "" {
+		respondError(w, r, "bad_request", "master_key is required (hex-encoded)")
+		return
+	}
+
+	// Decode master key from hex
+	masterKey, err := hex.DecodeString(req.MasterKey)
+	if err != nil {
+		respondError(w, r, "bad_request", "master_key must be hex-encoded")
+		return
+	}
+
+	// Decode nonce if provided
+	var nonce []byte
+	if req.Nonce != "" {
+		nonce, err = hex.DecodeString(req.Nonce)
+		if err != nil {
+			respondError(w, r, "bad_request", "nonce must be hex-encoded")
+			return
+		}
+	}
+
+	if req.Encoding != "" && req.Encoding != "hex" {
+		respondError(w, r, "bad_request", "encoding must be \"hex\" or omitted")
+		return
+	}
+
+	if req.Store && blobStore == nil {
+		respondError(w, r, "bad_request", "store requires a configured blob store")
+		return
+	}
+
+	suite := eamsa512.SuiteLegacy
+	if req.Suite != 0 {
+		suite = req.Suite
+		if !supportedSuite(suite) {
+			respondError(w, r, "bad_request", fmt.Sprintf("unsupported suite %v", suite))
+			return
+		}
+	}
+	if suite != eamsa512.SuiteLegacy && nonce != nil {
+		respondError(w, r, "bad_request", "nonce is only accepted with the legacy suite")
+		return
+	}
+
+	// Perform encryption in a buffer sized for the sealed output
+	var buf []byte
+	plaintextLength := len(req.Plaintext)
+	if req.Encoding == "hex" {
+		plaintextLength = hex.DecodedLen(len(req.Plaintext))
+		buf = make([]byte, plaintextLength, plaintextLength+SealOverhead(plaintextLength))
+		if _, err := hex.Decode(buf, []byte(req.Plaintext)); err != nil {
+			respondError(w, r, "bad_request", "plaintext must be hex-encoded")
+			return
+		}
+	} else {
+		buf = make([]byte, plaintextLength, plaintextLength+SealOverhead(plaintextLength))
+		copy(buf, req.Plaintext)
+	}
+
+	// Decoy keys are answered normally; the caller is not told
+	if honeytokens != nil {
+		if alert := honeytokens.CheckKey(keyid.New(masterKey).String(), "encrypt", r.RemoteAddr, ""); alert != nil {
+			LogHoneytokenAlert(alert)
+		}
+	}
+
+	start := time.Now()
+	var encryptedData []byte
+	if suite == eamsa512.SuiteLegacy {
+		encryptedData, err = SealInPlaceContext(r.Context(), buf, masterKey, nonce)
+	} else {
+		encryptedData, err = eamsa512.SealSuite(r.Context(), suite, buf, masterKey)
+	}
+	serverTelemetry.ObserveEncrypt(plaintextLength, time.Since(start), err)
+	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away mid-request; nobody is left to answer
+			return
+		}
+		LogError("Encryption failed", err)
+		respondError(w, r, "encryption_failed", err.Error())
+		return
+	}
+
+	// Extract components; an envelope is returned whole
+	ciphertext, nonceOut, tag := encryptedData, []byte(nil), []byte(nil)
+	if suite == eamsa512.SuiteLegacy {
+		ciphertextLength := len(encryptedData) - NonceSize - TagSize
+		ciphertext = encryptedData[:ciphertextLength]
+		nonceOut = encryptedData[ciphertextLength : ciphertextLength+NonceSize]
+		tag = encryptedData[ciphertextLength+NonceSize:]
+	}
+
+	keyID := keyid.New(masterKey).String()
+
+	// Keep the sealed data in the blob store instead of returning it
+	var handle string
+	if req.Store {
+		handle, _, err = blobStore.Put(r.Context(), bytes.NewReader(encryptedData))
+		if err != nil {
+			LogError("Blob store write failed", err)
+			respondError(w, r, "store_failed", "Failed to store ciphertext")
+			return
+		}
+	}
+
+	// Log audit event
+	LogAuditEvent("ENCRYPT", map[string]interface{}{
+		"plaintext_size": plaintextLength,
+		"ciphertext_size": len(ciphertext),
+		"key_size": len(masterKey),
+		"key_id": keyID,
+		"nonce_size": len(nonceOut),
+		"suite": suite.String(),
+		"handle": handle,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+
+	// Prepare response
+	response := EncryptResponse{
+		Suite:     suite,
+		KeyID:     keyID,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Size:      len(encryptedData),
+	}
+	if handle != "" {
+		response.Handle = handle
+	} else if suite != eamsa512.SuiteLegacy {
+		response.Envelope = hex.EncodeToString(encryptedData)
+	} else {
+		response.Ciphertext = hex.EncodeToString(ciphertext)
+		response.Nonce = hex.EncodeToString(nonceOut)
+		response.Tag = hex.EncodeToString(tag)
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandleDecrypt handles POST /api/v1/decrypt
+func HandleDecrypt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	// Parse request
+	var req DecryptRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		LogError("Failed to decode decrypt request", err)
+		respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	// Validate request
+	if req.Handle != "" && blobStore == nil {
+		respondError(w, r, "bad_request", "handle requires a configured blob store")
+		return
+	}
+
+	if req.Envelope != "" && (req.Handle != "" || req.Ciphertext != "" || req.Nonce != "" || req.Tag != "") {
+		respondError(w, r, "bad_request", "envelope excludes handle, ciphertext, nonce and tag")
+		return
+	}
+
+	// Bare ciphertext, nonce and tag, as written by the legacy suite
+	inline := req.Handle == "" && req.Envelope == ""
+
+	if inline && req.Ciphertext == "" {
+		respondError(w, r, "bad_request", "ciphertext or envelope is required (hex-encoded)")
+		return
+	}
+
+	if req.MasterKey == "" {
+		respondError(w, r, "bad_request", "master_key is required (hex-encoded)")
+		return
+	}
+
+	if inline && req.Nonce == "" {
+		respondError(w, r, "bad_request", "nonce is required (hex-encoded)")
+		return
+	}
+
+	if inline && req.Tag == "" {
+		respondError(w, r, "bad_request", "tag is required (hex-encoded)")
+		return
+	}
+
+	if req.Encoding != "" && req.Encoding != "hex" {
+		respondError(w, r, "bad_request", "encoding must be \"hex\" or omitted")
+		return
+	}
+
+	masterKey, err := hex.DecodeString(req.MasterKey)
+	if err != nil {
+		respondError(w, r, "bad_request", "master_key must be hex-encoded")
+		return
+	}
+
+	var encryptedData []byte
+	var ciphertextLength int
+	if req.Handle != "" {
+		// Sealed data from the blob store
+		encryptedData, err = ReadBlob(r.Context(), blobStore, req.Handle, MaxInlineBlobSize)
+		if err != nil {
+			respondBlobError(w, r, err)
+			return
+		}
+		ciphertextLength = len(encryptedData) - NonceSize - TagSize
+		if ciphertextLength < 0 {
+			ciphertextLength = 0
+		}
+	} else if req.Envelope != "" {
+		encryptedData, err = hex.DecodeString(req.Envelope)
+		if err != nil {
+			respondError(w, r, "bad_request", "envelope must be hex-encoded")
+			return
+		}
+		ciphertextLength = len(encryptedData)
+	} else {
+		// Decode from hex straight into one ciphertext || nonce || tag buffer
+		ciphertextLength = len(req.Ciphertext) / 2
+		encryptedData = make([]byte, ciphertextLength+len(req.Nonce)/2+len(req.Tag)/2)
+
+		if _, err := hex.Decode(encryptedData[:ciphertextLength], []byte(req.Ciphertext)); err != nil {
+			respondError(w, r, "bad_request", "ciphertext must be hex-encoded")
+			return
+		}
+
+		tagOffset := ciphertextLength + len(req.Nonce)/2
+		if _, err := hex.Decode(encryptedData[ciphertextLength:tagOffset], []byte(req.Nonce)); err != nil {
+			respondError(w, r, "bad_request", "nonce must be hex-encoded")
+			return
+		}
+
+		if _, err := hex.Decode(encryptedData[tagOffset:], []byte(req.Tag)); err != nil {
+			respondError(w, r, "bad_request", "tag must be hex-encoded")
+			return
+		}
+	}
+
+	keyID := keyid.New(masterKey).String()
+
+	identity := identifyDecryptCaller(r)
+
+	// Decoy keys and canaries are checked before anything can reject the
+	// request, and the caller is answered normally
+	if honeytokens != nil {
+		if alert := honeytokens.CheckKey(keyID, "decrypt", r.RemoteAddr, identity.User); alert != nil {
+			LogHoneytokenAlert(alert)
+		}
+		if alert := honeytokens.CheckCiphertext(encryptedData, keyID, r.RemoteAddr, identity.User); alert != nil {
+			LogHoneytokenAlert(alert)
+		}
+	}
+
+	// Break-glass rules; the request fields override the headers
+	policyRequest, err := newDecryptPolicyRequest(r, identity, keyID)
+	if err != nil {
+		respondError(w, r, "bad_request", err.Error())
+		return
+	}
+	policyRequest.Handle = req.Handle
+	if req.Classification != "" {
+		policyRequest.Classification = req.Classification
+	}
+	if req.CreatedAt != "" {
+		createdAt, err := time.Parse(time.RFC3339, req.CreatedAt)
+		if err != nil {
+			respondError(w, r, "bad_request", "created_at must be an RFC 3339 time")
+			return
+		}
+		policyRequest.CreatedAt = createdAt
+	}
+	if req.Ticket != "" {
+		policyRequest.Ticket = req.Ticket
+	}
+	if req.Justification != "" {
+		policyRequest.Justification = req.Justification
+	}
+	if !authorizeDecrypt(w, r, policyRequest) {
+		return
+	}
+
+	// Charge the caller's quota; failed decrypts count too
+	if decryptQuotas != nil {
+		remaining, resetAt, err := decryptQuotas.Charge(identity, 1)
+		if err != nil {
+			LogAuditEvent("DECRYPT_QUOTA_EXCEEDED", map[string]interface{}{
+				"user": identity.User,
+				"roles": identity.Roles,
+				"key_id": keyID,
+				"reset_at": resetAt.Format(time.RFC3339),
+				"timestamp": time.Now().Format(time.RFC3339),
+			})
+			w.Header().Set("Retry-After", fmt.Sprintf("%d", int(time.Until(resetAt).Seconds())+1))
+			respondError(w, r, "quota_exceeded",
+				fmt.Sprintf("Decrypt quota exceeded; resets at %s", resetAt.Format(time.RFC3339)))
+			return
+		}
+		if remaining >= 0 {
+			w.Header().Set("X-Decrypt-Quota-Remaining", fmt.Sprintf("%d", remaining))
+		}
+	}
+
+	// Perform decryption
+	start := time.Now()
+	var plaintext []byte
+	if inline {
+		plaintext, err = OpenInPlaceContext(r.Context(), encryptedData, masterKey)
+	} else {
+		plaintext, err = openAnySuite(r.Context(), encryptedData, masterKey)
+	}
+	serverTelemetry.ObserveDecrypt(ciphertextLength, time.Since(start), err)
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+		LogAuditEvent("DECRYPT_FAILED", map[string]interface{}{
+			"error": err.Error(),
+			"user": identity.User,
+			"key_id": keyID,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		respondError(w, r, "decryption_failed", "Authentication failed or invalid data")
+		return
+	}
+
+	// Log audit event
+	LogAuditEvent("DECRYPT", map[string]interface{}{
+		"ciphertext_size": ciphertextLength,
+		"plaintext_size": len(plaintext),
+		"key_size": len(masterKey),
+		"key_id": keyID,
+		"handle": req.Handle,
+		"user": identity.User,
+		"ticket": policyRequest.Ticket,
+		"verified": true,
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+
+	// Prepare response
+	responsePlaintext := string(plaintext)
+	if req.Encoding == "hex" {
+		responsePlaintext = hex.EncodeToString(plaintext)
+	}
+
+	response := DecryptResponse{
+		Plaintext: responsePlaintext,
+		KeyID:     keyID,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Size:      len(plaintext),
+		Verified:  true,
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// openAnySuite decrypts a stored record or envelope of any suite. Legacy
+// data that happens to start with the envelope magic is retried as such
+// when it does not open as an envelope.
+func openAnySuite(ctx context.Context, data []byte, masterKey []byte) ([]byte, error) {
+	if !eamsa512.IsEnvelope(data) {
+		return OpenInPlaceContext(ctx, data, masterKey)
+	}
+
+	plaintext, err := eamsa512.DecryptContext(ctx, data, masterKey)
+	if err != nil && ctx.Err() == nil {
+		if legacy, legacyErr := OpenInPlaceContext(ctx, data, masterKey); legacyErr == nil {
+			return legacy, nil
+		}
+	}
+	return plaintext, err
+}
+
+// supportedSuite reports whether this server encrypts in suite
+func supportedSuite(suite eamsa512.Suite) bool {
+	for _, s := range eamsa512.SupportedSuites() {
+		if s == suite {
+			return true
+		}
+	}
+	return false
+}
+
+// HandleNegotiate handles POST /api/v1/negotiate: it picks the strongest
+// cipher suite the client and this server share, and authenticates the
+// exchange under the client's key so the client can detect a downgrade
+func HandleNegotiate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, "method_not_allowed", "Only POST is allowed")
+		return
+	}
+
+	var req NegotiateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		LogError("Failed to decode negotiate request", err)
+		respondError(w, r, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
+		return
+	}
+
+	masterKey, err := hex.DecodeString(req.MasterKey)
+	if err != nil || len(masterKey) != KeySize {
+		respondError(w, r, "bad_request", fmt.Sprintf("master_key must be %d hex-encoded bytes", KeySize))
+		return
+	}
+	random, err := hex.DecodeString(req.Random)
+	if err != nil {
+		respondError(w, r, "bad_request", "random must be hex-encoded")
+		return
+	}
+
+	supported := eamsa512.SupportedSuites()
+	choice, err := eamsa512.Negotiate(masterKey, &eamsa512.Hello{Suites: req.Suites, Random: random}, supported)
+	if err == eamsa512.ErrNoCommonSuite {
+		respondError(w, r, "bad_request", fmt.Sprintf("no cipher suite in common; this server supports %v", supported))
+		return
+	}
+	if err != nil {
+		respondError(w, r, "bad_request", err.Error())
+		return
+	}
+
+	keyID := keyid.New(masterKey).String()
+	LogAuditEvent("SUITE_NEGOTIATED", map[string]interface{}{
+		"key_id": keyID,
+		"offered": req.Suites,
+		"suite": choice.Suite.String(),
+		"timestamp": time.Now().Format(time.RFC3339),
+	})
+
+	respondJSON(w, http.StatusOK, NegotiateResponse{
+		Suite:     choice.Suite,
+		SuiteName: choice.Suite.String(),
+		Suites:    choice.Suites,
+		Random:    hex.EncodeToString(choice.Random),
+		MAC:       hex.EncodeToString(choice.MAC),
+		KeyID:     keyID,
+	})
+}
+
+// HandleHealth handles GET /api/v1/health
+func HandleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, "method_not_allowed", "Only GET is allowed")
+		return
+	}
+
+	uptime := time.Since(serverStartTime)
+
+	response := HealthCheckResponse{
+		Status:     "ok",
+		Version:    "1.0.0",
+		Timestamp:  time.Now().Format(time.RFC3339),
+		Uptime:     uptime.String(),
+		TLSEnabled: true,
+		BlockSize:  BlockSize,
+		KeySize:    KeySize,
+		NonceSize:  NonceSize,
+		RoundCount: Rounds,
+		RandomSource: nonceRandom.Source(),
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandleCompliance handles GET /api/v1/compliance/report
+func HandleCompliance(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, "method_not_allowed", "Only GET is allowed")
+		return
+	}
+
+	complianceScore := 100
+
+	response := ComplianceReport{
+		FIPSMode:              true,
+		NISTSP80056A:          true,
+		SHA3512Used:           true,
+		HMACAuthentication:    true,
+		TLSEnabled:            true,
+		AuditLoggingEnabled:   true,
+		BlockSize:             BlockSize,
+		KeySize:               KeySize,
+		NonceSize:             NonceSize,
+		AuthenticationTagSize: TagSize,
+		Timestamp:             time.Now().Format(time.RFC3339),
+		ComplianceScore:       complianceScore,
+	}
+
+	if decryptQuotas != nil {
+		report := decryptQuotas.Report()
+		response.DecryptQuotas = &report
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandleMetrics handles GET /metrics (Prometheus format)
+func HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondError(w, r, "method_not_allowed", "Only GET is allowed")
+		return
+	}
+
+	uptime := time.Since(serverStartTime).Seconds()
+
+	metricsText := fmt.Sprintf(`# HELP eamsa512_uptime_seconds EAMSA 512 uptime in seconds
+# TYPE eamsa512_uptime_seconds gauge
+eamsa512_uptime_seconds %.2f
+
+# HELP eamsa512_block_size_bytes Block size in bytes
+# TYPE eamsa512_block_size_bytes gauge
+eamsa512_block_size_bytes %d
+
+# HELP eamsa512_key_size_bytes Key size in bytes
+# TYPE eamsa512_key_size_bytes gauge
+eamsa512_key_size_bytes %d
+
+# HELP eamsa512_nonce_size_bytes Nonce size in bytes
+# TYPE eamsa512_nonce_size_bytes gauge
+eamsa512_nonce_size_bytes %d
+
+# HELP eamsa512_rounds Total encryption rounds
+# TYPE eamsa512_rounds gauge
+eamsa512_rounds %d
+
+# HELP eamsa512_tag_size_bytes HMAC tag size in bytes
+# TYPE eamsa512_tag_size_bytes gauge
+eamsa512_tag_size_bytes %d
+`, uptime, BlockSize, KeySize, NonceSize, Rounds, TagSize)
+
+	if auditQueue != nil {
+		stats := auditQueue.Stats()
+		metricsText += fmt.Sprintf(`
+# HELP eamsa512_audit_queue_depth Audit events waiting to be written
+# TYPE eamsa512_audit_queue_depth gauge
+eamsa512_audit_queue_depth %d
+
+# HELP eamsa512_audit_events_written_total Audit events written to the sink
+# TYPE eamsa512_audit_events_written_total counter
+eamsa512_audit_events_written_total %d
+
+# HELP eamsa512_audit_events_dropped_total Audit events dropped because the queue was full
+# TYPE eamsa512_audit_events_dropped_total counter
+eamsa512_audit_events_dropped_total %d
+
+# HELP eamsa512_audit_events_spilled_total Audit events written to the spill file
+# TYPE eamsa512_audit_events_spilled_total counter
+eamsa512_audit_events_spilled_total %d
+
+# HELP eamsa512_audit_batches_failed_total Audit batches the sink failed to write
+# TYPE eamsa512_audit_batches_failed_total counter
+eamsa512_audit_batches_failed_total %d
+`, stats.Depth, stats.Written, stats.Dropped, stats.Spilled, stats.FailedBatches)
+	}
+
+	if auditRouter != nil {
+		routes := auditRouter.Stats()
+		metrics := []struct {
+			name, help, kind string
+			value            func(AuditQueueStats) uint64
+		}{
+			{"eamsa512_audit_route_queue_depth", "Audit events waiting on each route", "gauge", func(s AuditQueueStats) uint64 { return uint64(s.Depth) }},
+			{"eamsa512_audit_route_events_written_total", "Audit events each route wrote to its sink", "counter", func(s AuditQueueStats) uint64 { return s.Written }},
+			{"eamsa512_audit_route_events_dropped_total", "Audit events each route dropped because its queue was full", "counter", func(s AuditQueueStats) uint64 { return s.Dropped }},
+			{"eamsa512_audit_route_events_spilled_total", "Audit events each route wrote to its spill file", "counter", func(s AuditQueueStats) uint64 { return s.Spilled }},
+		}
+		for _, m := range metrics {
+			metricsText += fmt.Sprintf("\n# HELP %s %s\n# TYPE %s %s\n", m.name, m.help, m.name, m.kind)
+			for _, route := range routes {
+				metricsText += fmt.Sprintf("%s{route=%q} %d\n", m.name, route.Name, m.value(route.Queue))
+			}
+		}
+	}
+
+	if decryptQuotas != nil {
+		metricsText += fmt.Sprintf(`
+# HELP eamsa512_decrypt_quota_denied_total Decrypt requests denied by per-identity quotas
+# TYPE eamsa512_decrypt_quota_denied_total counter
+eamsa512_decrypt_quota_denied_total %d
+`, decryptQuotas.TotalDenied())
+	}
+
+	if honeytokens != nil {
+		metricsText += fmt.Sprintf(`
+# HELP eamsa512_honeytoken_trips_total Requests that used a decoy key or canary ciphertext
+# TYPE eamsa512_honeytoken_trips_total counter
+eamsa512_honeytoken_trips_total %d
+`, honeytokens.Trips())
+
+		if webhook, ok := honeytokens.Alerter().(*AlertWebhook); ok {
+			stats := webhook.Stats()
+			metricsText += fmt.Sprintf(`
+# HELP eamsa512_alert_webhook_sent_total Alerts delivered to the webhook
+# TYPE eamsa512_alert_webhook_sent_total counter
+eamsa512_alert_webhook_sent_total %d
+
+# HELP eamsa512_alert_webhook_failed_total Alerts not delivered after all attempts or dropped
+# TYPE eamsa512_alert_webhook_failed_total counter
+eamsa512_alert_webhook_failed_total %d
+`, stats.Sent, stats.Failed+stats.Dropped)
+		}
+	}
+
+	if streamServer != nil {
+		stats := streamServer.Stats()
+		metricsText += fmt.Sprintf(`
+# HELP eamsa512_stream_active Streaming requests in progress
+# TYPE eamsa512_stream_active gauge
+eamsa512_stream_active %d
+
+# HELP eamsa512_stream_window_chunks Output chunks held in memory windows
+# TYPE eamsa512_stream_window_chunks gauge
+eamsa512_stream_window_chunks %d
+
+# HELP eamsa512_stream_window_capacity_chunks Chunks the stream memory budget holds
+# TYPE eamsa512_stream_window_capacity_chunks gauge
+eamsa512_stream_window_capacity_chunks %d
+
+# HELP eamsa512_stream_window_peak_chunks Highest window occupancy since start
+# TYPE eamsa512_stream_window_peak_chunks gauge
+eamsa512_stream_window_peak_chunks %d
+
+# HELP eamsa512_stream_spilling Streams with output spilled to disk
+# TYPE eamsa512_stream_spilling gauge
+eamsa512_stream_spilling %d
+
+# HELP eamsa512_stream_spilled_bytes_total Output bytes written to spill files
+# TYPE eamsa512_stream_spilled_bytes_total counter
+eamsa512_stream_spilled_bytes_total %d
+
+# HELP eamsa512_stream_rejected_total Streams refused or cut short for lack of memory or spill space
+# TYPE eamsa512_stream_rejected_total counter
+eamsa512_stream_rejected_total %d
+
+# HELP eamsa512_stream_stalled_total Streams aborted because the client stopped sending or reading
+# TYPE eamsa512_stream_stalled_total counter
+eamsa512_stream_stalled_total %d
+`, stats.Active, stats.WindowChunks, stats.WindowCapacity, stats.WindowPeak,
+			stats.Spilling, stats.SpilledBytes, stats.Rejected, stats.StalledAborts)
+	}
+
+	// Operation counters from the built-in collector; other Telemetry
+	// implementations are exported by the embedding application
+	if collector, ok := serverTelemetry.(*telemetry.Prometheus); ok {
+		var b strings.Builder
+		collector.WriteTo(&b)
+		metricsText += "\n" + b.String()
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, metricsText)
+}
+
+// ============================================================================
+// Response Helpers
+// ============================================================================
+
+// respondJSON sends a JSON response
+func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(data)
+}
+
+// respondError sends an application/problem+json response for errorCode
+// (see problem.Types); the status comes from the code
+func respondError(w http.ResponseWriter, r *http.Request, errorCode, detail string) {
+	response := problem.New(errorCode, detail)
+	response.Instance = r.URL.Path
+	response.RequestID = requestID(r)
+	response.Timestamp = time.Now().Format(time.RFC3339)
+
+	w.Header().Set("Content-Type", problem.ContentType)
+	w.WriteHeader(response.Status)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ============================================================================
+// Middleware
+// ============================================================================
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 64
+
+// RequestIDMiddleware gives every request an ID, taken from the
+// X-Request-ID header when it is well formed and generated otherwise, and
+// echoes it in the response
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(problem.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(problem.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// requestID returns the ID set by RequestIDMiddleware, or ""
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns 16 random bytes in hex
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// validRequestID accepts IDs of letters, digits, '-', '_' and '.', so a
+// client cannot inject into logs or headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// LoggingMiddleware logs HTTP requests
+func LoggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		next.ServeHTTP(w, r)
+
+		duration := time.Since(start)
+		fmt.Printf("[%s] %s %s %s %s\n", time.Now().Format("2006-01-02 15:04:05"), requestID(r), r.Method, r.RequestURI, duration)
+	})
+}
+
+// RecoveryMiddleware recovers from panics
+func RecoveryMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if err := recover(); err != nil {
+				LogError("Panic recovered", fmt.Errorf("%v", err))
+				respondError(w, r, "internal_error", "An internal error occurred")
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ============================================================================
+// Main Server Setup
+// ============================================================================
+
+func main() {
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "rewrap" {
+		if err := RunRewrapCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Re-wrap failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "gc" {
+		if err := RunGCCommand(os.Args[2:]); err != nil {
+			fmt.Printf("GC failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "shred" {
+		if err := RunShredCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Shred failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "prune" {
+		if err := RunPruneCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Prune failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "import-key" {
+		if err := RunImportKeyCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Key import failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "audit" {
+		if err := RunAuditCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Audit: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "honeytoken" {
+		if err := RunHoneytokenCommand(os.Args[2:]); err != nil {
+			fmt.Printf("Honeytoken failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	serverFlags := flag.NewFlagSet("eamsa512-server", flag.ExitOnError)
+	chunkSize := serverFlags.String("chunk-size", "", "Chunk size of /stream/encrypt output, e.g. 256K or 1M (default: chosen per upload)")
+	serverFlags.Parse(os.Args[1:])
+
+	// Server configuration
+	config := ServerConfig{
+		Host:         "0.0.0.0",
+		Port:         8080,
+		TLSEnabled:   true,
+		TLSCertPath:  "/etc/eamsa512/certs/tls.crt",
+		TLSKeyPath:   "/etc/eamsa512/certs/tls.key",
+		ReadTimeout:  30 * time.Second,
+		WriteTimeout: 30 * time.Second,
+		IdleTimeout:  120 * time.Second,
+		MaxBodySize:  1 << 20, // 1MB
+		LogFilePath:  "/var/log/eamsa512/eamsa512.log",
+		AuditLogPath: "/var/log/eamsa512/audit.log",
+
+		ShutdownTimeout:    15 * time.Second,
+		AuditQueueCapacity: 10000,
+		AuditBatchSize:     100,
+		AuditFlushInterval: time.Second,
+		AuditSpillPath:     "/var/lib/eamsa512/audit-spill.jsonl",
+
+		Stream: &StreamConfig{
+			SpillDir:       "/var/lib/eamsa512/stream-spill",
+			MaxUploadBytes: 64 << 30, // 64GB
+		},
+	}
+
+	if *chunkSize != "" {
+		size, err := ParseChunkSize(*chunkSize)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.Stream.EncryptChunkSize = size
+	}
+
+	overflow, err := ParseAuditOverflowPolicy(os.Getenv("EAMSA_AUDIT_OVERFLOW"))
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	config.AuditOverflow = overflow
+
+	// Decrypt quotas from the RBAC policy's decrypt_quotas section
+	if path := os.Getenv("EAMSA_RBAC_POLICY"); path != "" {
+		quotas, err := LoadDecryptQuotaPolicy(path)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.DecryptQuotas = quotas
+
+		// Break-glass rules from its break_glass section
+		policy, err := LoadDecryptPolicy(path)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.DecryptPolicy = policy
+		config.ClientCAPath = os.Getenv("EAMSA_CLIENT_CA")
+	}
+
+	// Per-category audit sinks
+	if path := os.Getenv("EAMSA_AUDIT_ROUTES"); path != "" {
+		router, err := LoadAuditRouter(path)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.AuditRouter = router
+	}
+
+	// Decoy keys, canaries and the alert webhook
+	if path := os.Getenv("EAMSA_HONEYTOKENS"); path != "" {
+		tokens, err := LoadHoneytokens(path)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.Honeytokens = tokens
+	}
+
+	// Ciphertext storage for "store": true requests
+	if path := os.Getenv("EAMSA_BLOB_STORE"); path != "" {
+		store, err := LoadBlobStore(path)
+		if err != nil {
+			fmt.Printf("Invalid configuration: %v\n", err)
+			os.Exit(1)
+		}
+		config.BlobStore = store
+	}
+
+	// Audit pseudonymization and its re-identification map
+	pseudonyms, err := LoadPseudonymizerFromEnv()
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	config.Pseudonymizer = pseudonyms
+
+	// Clock jump detection and optional NTP checks for key expiry
+	clock, err := LoadClockGuardFromEnv(LogAuditEvent)
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	trustedClock = clock
+
+	// Health-tested nonce and IV source with audited failover
+	random, err := LoadEntropySourceFromEnv(LogAuditEvent)
+	if err != nil {
+		fmt.Printf("Invalid configuration: %v\n", err)
+		os.Exit(1)
+	}
+	if random != nil {
+		eamsa512.SetNonceSource(random)
+	}
+	nonceRandom = random
+
+	// Initialize server
+	if err := InitServer(config); err != nil {
+		fmt.Printf("Failed to initialize server: %v\n", err)
+		os.Exit(1)
+	}
+	trustedClock.Start()
+
+	// Setup routes
+	mux := http.NewServeMux()
+
+	// API endpoints
+	mux.HandleFunc("/api/v1/encrypt", HandleEncrypt)
+	mux.HandleFunc("/api/v1/decrypt", HandleDecrypt)
+	mux.HandleFunc("/api/v1/negotiate", HandleNegotiate)
+	mux.HandleFunc("/api/v1/health", HandleHealth)
+	mux.HandleFunc("/api/v1/compliance/report", HandleCompliance)
+	mux.HandleFunc("/api/v1/keys/rewrap", HandleRewrap)
+	mux.HandleFunc("/api/v1/stream/encrypt", HandleStreamEncrypt)
+	mux.HandleFunc("/api/v1/stream/decrypt", HandleStreamDecrypt)
+	mux.HandleFunc("/api/v1/blobs/", HandleBlob)
+
+	// Admin re-identification of audit pseudonyms
+	if config.Pseudonymizer != nil {
+		mux.HandleFunc("/api/v1/admin/pseudonyms/reidentify", HandleReidentify)
+	}
+
+	// Metrics endpoint (Prometheus)
+	mux.HandleFunc("/metrics", HandleMetrics)
+
+	// Failure injection, only in binaries built with -tags failpoints
+	if failpoint.Enabled {
+		mux.HandleFunc("/debug/failpoints", HandleFailpoints)
+	}
+
+	// Apply middleware
+	handler := RequestIDMiddleware(RecoveryMiddleware(LoggingMiddleware(mux)))
+
+	// Create server with timeouts
+	server := &http.Server{
+		Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
+		Handler:      handler,
+		ReadTimeout:  config.ReadTimeout,
+		WriteTimeout: config.WriteTimeout,
+		IdleTimeout:  config.IdleTimeout,
+	}
+
+	// Log startup
+	fmt.Printf("Starting EAMSA 512 Web Server\n")
+	fmt.Printf("Listening on %s\n", server.Addr)
+	fmt.Printf("TLS Enabled: %v\n", config.TLSEnabled)
+	if failpoint.Enabled {
+		fmt.Printf("WARNING: failpoints compiled in (armed: %v)\n", failpoint.List())
+	}
+
+	// Start server with TLS
+	if config.TLSEnabled {
+		// Load TLS certificates
+		cert, err := tls.LoadX509KeyPair(config.TLSCertPath, config.TLSKeyPath)
+		if err != nil {
+			fmt.Printf("Failed to load TLS certificates: %v\n", err)
+			os.Exit(1)
+		}
+
+		tlsConfig := &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+			CipherSuites: []uint16{
+				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+			},
+		}
+
+		// Verified client certificates identify callers for decrypt quotas
+		if config.ClientCAPath != "" {
+			caPEM, err := os.ReadFile(config.ClientCAPath)
+			if err != nil {
+				fmt.Printf("Failed to load client CA: %v\n", err)
+				os.Exit(1)
+			}
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(caPEM) {
+				fmt.Printf("Failed to load client CA: no certificates in %s\n", config.ClientCAPath)
+				os.Exit(1)
+			}
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+
+		server.TLSConfig = tlsConfig
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		if config.TLSEnabled {
+			serverErr <- server.ListenAndServeTLS("", "")
+		} else {
+			serverErr <- server.ListenAndServe()
+		}
+	}()
+
+	// Wait for a shutdown signal or a server failure
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+
+	exitCode := 0
+	select {
+	case err := <-serverErr:
+		if err != nil && err != http.ErrServerClosed {
+			fmt.Printf("Server error: %v\n", err)
+			exitCode = 1
+		}
+	case sig := <-stop:
+		fmt.Printf("Received %v, shutting down\n", sig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Printf("Server shutdown: %v\n", err)
+	}
+	trustedClock.Stop()
+
+	// Deliver pending honeytoken alerts
+	if config.Honeytokens != nil {
+		if webhook, ok := config.Honeytokens.Alerter().(*AlertWebhook); ok {
+			if err := webhook.Close(ctx); err != nil {
+				fmt.Printf("Alert webhook shutdown: %v\n", err)
+			}
+		}
+	}
+
+	// Flush queued audit events; anything left at the deadline is spilled
+	if auditQueue != nil {
+		if err := auditQueue.Close(ctx); err != nil {
+			fmt.Printf("Audit queue shutdown: %v\n", err)
+			exitCode = 1
+		}
+	}
+	if auditRouter != nil {
+		if err := auditRouter.Close(ctx); err != nil {
+			fmt.Printf("Audit route shutdown: %v\n", err)
+			exitCode = 1
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
+// ============================================================================
+// API Documentation
+// ============================================================================
+
+/*
+
+EAMSA 512 Web Server - REST API Documentation
+
+BASE URL: https://localhost:8080/api/v1
+
+ENDPOINTS:
+
+1. POST /encrypt
+   Description: Encrypt plaintext using EAMSA 512
+   Request:
+   {
+     "plaintext": "Hello, World!",
+     "master_key": "deadbeef...",  // 32-byte key in hex
+     "nonce": "...",               // optional 16-byte nonce in hex
+     "encoding": "hex",            // optional: plaintext is hex (binary data)
+     "store": true                 // optional: keep the ciphertext in the blob store
+   }
+   Response:
+   {
+     "ciphertext": "...",  // hex-encoded
+     "nonce": "...",       // hex-encoded
+     "tag": "...",         // 64-byte HMAC tag in hex
+     "key_id": "sha3-512:128:3a98...",  // audit-safe key identifier
+     "timestamp": "2025-12-04T18:30:00Z",
+     "size": 144
+   }
+   With "store": true, ciphertext, nonce and tag are replaced by
+   "handle": "sha3-256:9f86d081..." (see BLOB STORE below).
+
+2. POST /decrypt
+   Description: Decrypt ciphertext using EAMSA 512
+   Request:
+   {
+     "ciphertext": "...",   // hex-encoded
+     "master_key": "...",   // 32-byte key in hex
+     "nonce": "...",        // 16-byte nonce in hex
+     "tag": "...",          // 64-byte HMAC tag in hex
+     "encoding": "hex"      // optional: return the plaintext hex-encoded
+   }
+   or, for stored ciphertexts, {"handle": "sha3-256:...", "master_key": "..."}
+   Response:
+   {
+     "plaintext": "Hello, World!",
+     "key_id": "sha3-512:128:3a98...",
+     "timestamp": "2025-12-04T18:30:00Z",
+     "size": 13,
+     "verified": true
+   }
+   With decrypt quotas enabled the X-Decrypt-Quota-Remaining header
+   carries the caller's remaining quota; a caller over quota gets
+   429 quota_exceeded with Retry-After set to the end of the window.
+   /encrypt and /decrypt stop work within 64 KiB of the client
+   disconnecting, and write no response.
+
+3. GET /health
+   Description: Health check endpoint
+   Response:
+   {
+     "status": "ok",
+     "version": "1.0.0",
+     "timestamp": "2025-12-04T18:30:00Z",
+     "uptime": "12h34m56s",
+     "tls_enabled": true,
+     "block_size": 64,
+     "key_size": 32,
+     "nonce_size": 16,
+     "round_count": 16,
+     "random_source": "crypto/rand"
+   }
+
+4. GET /compliance/report
+   Description: Get FIPS 140-2 compliance report
+   Response:
+   {
+     "fips_mode": true,
+     "nist_sp_800_56a": true,
+     "sha3_512_used": true,
+     "hmac_authentication": true,
+     "tls_enabled": true,
+     "audit_logging_enabled": true,
+     "block_size": 64,
+     "key_size": 32,
+     "nonce_size": 16,
+     "authentication_tag_size": 64,
+     "timestamp": "2025-12-04T18:30:00Z",
+     "compliance_score": 100,
+     "decrypt_quotas": {   // only with decrypt quotas enabled
+       "window": "24h0m0s",
+       "total_denied": 3,
+       "identities": [
+         {"user": "auditor-1", "limit": 1000, "used": 1000, "denied": 3,
+          "reset_at": "2025-12-05T00:00:00Z"}
+       ]
+     }
+   }
+
+5. POST /keys/rewrap
+   Description: Re-wrap KEK-wrapped DEKs under a new KEK (KEK rotation).
+   Plaintext DEKs are never returned. Up to 1000 keys per request;
+   entries that fail to unwrap are reported and skipped.
+   Request:
+   {
+     "old_kek": "...",     // 32-byte current KEK in hex
+     "new_kek": "...",     // 32-byte new KEK in hex
+     "keys": [
+       {"id": "tenant-a", "wrapped_key": "-----BEGIN EAMSA512 ENCRYPTED KEY-----\n..."}
+     ]
+   }
+   Response:
+   {
+     "rewrapped": [
+       {"id": "tenant-a", "wrapped_key": "-----BEGIN ...", "kek_id": "sha3-512:128:..."}
+     ],
+     "failed": [],
+     "old_kek_id": "sha3-512:128:...",
+     "new_kek_id": "sha3-512:128:...",
+     "timestamp": "2025-12-04T18:30:00Z"
+   }
+
+   CLI equivalent (re-wraps the database keystore atomically):
+   eamsa512-server rewrap -db eamsa512.db -old-kek-file old.kek -new-kek-file new.kek
+
+6. POST /stream/encrypt, POST /stream/decrypt
+   Description: Encrypt or decrypt a body of any size without buffering it
+   Request: raw bytes (Content-Type: application/octet-stream)
+   Headers: X-EAMSA-Master-Key: 32-byte key in hex
+            X-EAMSA-Size-Hint: expected bytes (optional; encrypt only,
+            when there is no Content-Length)
+   Response: raw bytes, with the X-EAMSA-Stream-Status trailer set to
+   "ok" or "error: ..." (a stream can fail after the status line is sent).
+   Encryption returns a chunked stream. Decryption returns plaintext only
+   once it has verified: a container as a whole, a stream chunk by chunk.
+   See STREAMING below for flow control.
+
+7. GET /blobs/{handle}, DELETE /blobs/{handle}
+   Description: Download the sealed bytes of a stored ciphertext
+   (ciphertext || nonce || tag), or delete it (audited as BLOB_DELETED).
+
+8. GET /metrics
+   Description: Prometheus metrics (Prometheus format)
+   Response: (text/plain)
+   eamsa512_uptime_seconds 45296.00
+   eamsa512_block_size_bytes 64
+   eamsa512_key_size_bytes 32
+   ...
+   With the audit queue enabled:
+   eamsa512_audit_queue_depth 0
+   eamsa512_audit_events_written_total 1024
+   eamsa512_audit_events_dropped_total 0
+   eamsa512_audit_events_spilled_total 0
+   eamsa512_audit_batches_failed_total 0
+   With decrypt quotas enabled:
+   eamsa512_decrypt_quota_denied_total 3
+   With honeytokens registered (webhook counters only with a webhook):
+   eamsa512_honeytoken_trips_total 1
+   eamsa512_alert_webhook_sent_total 1
+   eamsa512_alert_webhook_failed_total 0
+   With streaming enabled:
+   eamsa512_stream_active 2
+   eamsa512_stream_window_chunks 18
+   eamsa512_stream_window_capacity_chunks 4096
+   eamsa512_stream_window_peak_chunks 512
+   eamsa512_stream_spilling 1
+   eamsa512_stream_spilled_bytes_total 1073741824
+   eamsa512_stream_rejected_total 0
+   eamsa512_stream_stalled_total 0
+   With the default telemetry collector (ServerConfig.Telemetry unset):
+   eamsa512_operations_total{op="encrypt",result="ok"} 1024
+   eamsa512_operation_bytes_total{op="encrypt",result="ok"} 65536
+   eamsa512_operation_duration_seconds_bucket{op="encrypt",result="ok",le="0.001"} 1000
+   eamsa512_key_events_total{event="rewrapped"} 12
+
+AUDIT PIPELINE:
+
+Audit events are queued in memory (AuditQueueCapacity, default 10000) and
+written in batches of AuditBatchSize or every AuditFlushInterval. When the
+queue is full, EAMSA_AUDIT_OVERFLOW selects the policy:
+- block (default): the request waits for space; no events are lost
+- drop: the event is dropped and eamsa512_audit_events_dropped_total grows
+Batches the sink rejects, and events still queued when the shutdown
+deadline (ShutdownTimeout) expires, are appended to AuditSpillPath as JSON
+lines and replayed on the next start. SIGINT/SIGTERM drain the server and
+flush the queue before exit.
+
+AUDIT ROUTING:
+
+EAMSA_AUDIT_ROUTES names a JSON file sending audit categories to their own
+sinks, each with its own queue, overflow policy and spill file:
+
+   {"routes": [
+     {"categories": ["security"], "sink": {"type": "db", "path": "/var/lib/eamsa512/eamsa512.db"}},
+     {"categories": ["admin"], "sink": {"type": "file", "path": "/var/log/eamsa512/keys.jsonl"},
+      "spill_path": "/var/lib/eamsa512/audit-admin-spill.jsonl"},
+     {"name": "ops", "categories": ["operation"], "overflow": "drop",
+      "sink": {"type": "webhook", "webhook": {"url": "https://siem.example.com/ingest"}}}
+   ]}
+
+Categories: security (DECRYPT_POLICY_DENIED, DECRYPT_QUOTA_EXCEEDED,
+HONEYTOKEN_TRIPPED, TLS_VERIFY_FAILED, BREAK_GLASS, ...), admin (KEY_*,
+GC_*, BLOB_DELETED, FAILPOINT_SET), operation (ENCRYPT, DECRYPT,
+DECRYPT_FAILED, STREAM_*) and system (the rest). Each category may be
+routed once; categories without a route go to AuditLogPath. File sinks
+write JSON lines (readable with "audit search -file"), webhook sinks POST
+JSON arrays signed like the honeytoken webhook. Per-route counters are
+exported as eamsa512_audit_route_*{route="..."}.
+
+DECRYPT QUOTAS:
+
+EAMSA_RBAC_POLICY names the RBAC policy file whose decrypt_quotas section
+limits how many records each identity may decrypt per window (default
+24h, aligned to UTC midnight). Callers are identified by client
+certificates verified against EAMSA_CLIENT_CA and mapped to users and
+roles through the users and certificates sections; requests without one
+count as the "anonymous" user. Every decrypt attempt is charged, and
+denials are audited as DECRYPT_QUOTA_EXCEEDED.
+
+BREAK-GLASS:
+
+The break_glass section of the same file lists rules (classification,
+minimum age) selecting decryptions that need approval. A matching request
+must carry a ticket reference matching ticket_pattern and a justification
+("ticket" and "justification" fields, or X-EAMSA-Ticket and
+X-EAMSA-Justification headers), from a caller holding one of the
+break-glass roles. Callers declare "classification" and "created_at";
+requests without them count as default_classification and as old enough.
+Approvals are audited as BREAK_GLASS, denials as DECRYPT_POLICY_DENIED
+(403).
+
+HONEYTOKENS:
+
+EAMSA_HONEYTOKENS names a JSON file of decoy key IDs, canary ciphertext
+fingerprints and an optional alert webhook. A request that uses a decoy
+key, or tries to decrypt a canary, is answered as usual; it is audited as
+a critical HONEYTOKEN_TRIPPED event and POSTed to the webhook (signed with
+X-EAMSA-Signature when secret_file is set). Mint tokens with:
+eamsa512-server honeytoken decoy -label "old backup" -o decoy.hex
+eamsa512-server honeytoken canary -label "wiki page" -key-file app.key -o canary.hex
+
+STREAMING:
+
+Stream output is cut into ChunkSize chunks (default 64KB). Each stream
+keeps up to WindowChunks (default 16) in memory while the client catches
+up, and all streams share MaxInFlightBytes (default 256MB). Beyond that,
+chunks spill to a file in SpillDir (up to MaxSpillBytes per stream) and
+are sent in order later. A stream that cannot be held in memory or on
+disk fails with 507 insufficient_storage and Retry-After; a client that
+sends or reads nothing for StallTimeout (default 30s) gets 408 timeout.
+Server read/write timeouts do not apply to stream requests.
+
+Encrypted streams are written in the chunked stream format. Their chunk
+size is picked per upload from Content-Length (or X-EAMSA-Size-Hint for
+uploads of unknown length): a power of two from 16KB to 4MB, aiming at
+about 4096 chunks. --chunk-size (e.g. --chunk-size 1M) fixes it instead.
+The size is recorded in the stream header; decryption reads it from there.
+
+CLOCK:
+
+Key expiry, rotation and session checks use the trusted clock (see
+clock-guard.go): the wall clock, never earlier than the monotonic-derived
+time. Jumps are audited as CLOCK_JUMP. EAMSA_NTP_SERVERS enables hourly
+SNTP checks; offsets beyond EAMSA_CLOCK_MAX_SKEW (default 30s) are
+audited as CLOCK_SKEW.
+
+ENTROPY SOURCE:
+
+EAMSA_ENTROPY_SOURCE selects where nonces and IVs come from: system
+(crypto/rand, the default), chaos (the Lorenz generator), device:<path>
+(a TRNG such as /dev/hwrng) or pkcs11:<module> (an HSM token, selected by
+EAMSA_PKCS11_TOKEN and logged in with the PIN in EAMSA_PKCS11_PIN_FILE).
+Chaos, device and PKCS#11 sources run the SP 800-90B Repetition Count and
+Adaptive Proportion Tests on their raw samples; a
+failure switches to crypto/rand until the source passes its start-up tests
+again (retried after EAMSA_ENTROPY_RECHECK, default 1m). Audited as
+RANDOM_SOURCE_FAILED, RANDOM_FALLBACK (first draw only) and
+RANDOM_SOURCE_RECOVERED.
+
+BLOB STORE:
+
+EAMSA_BLOB_STORE names a JSON file selecting where stored ciphertexts
+live: {"backend": "file", "dir": "/var/lib/eamsa512/blobs"} or
+{"backend": "s3", "region": "eu-west-1", "bucket": "...", "prefix": "..."}
+with credentials in AWS_ACCESS_KEY_ID / AWS_SECRET_ACCESS_KEY, or
+{"backend": "postgres", "dsn": "postgres://eamsa512@db/eamsa512"} with the
+password in PGPASSWORD (blobs up to 64MB). Handles are
+the SHA3-256 of the sealed bytes, so identical ciphertexts are stored once
+and every read is verified. Keys are never stored. Decryption by handle
+is limited to 64MB blobs. Unreferenced blobs and wrapped keys are removed with
+"eamsa512-server gc" (scan, then approval by a second user).
+
+CRYPTO-SHREDDING:
+
+"eamsa512-server shred" destroys a key version that is no longer active,
+making everything encrypted under it unrecoverable. A request (with a
+reason) needs -quorum approvals (default 2) from other users within 7
+days; the last one erases the key from the embedded keystore, marks the
+version's rows in the operations table (shred_id, shredded_at) and issues
+a destruction certificate ("shred certificate", checked with "shred
+verify"). Audited as SHRED_REQUESTED, SHRED_APPROVED, KEY_SHREDDED and
+SHRED_CERTIFIED.
+
+ERROR RESPONSES:
+
+All errors are RFC 7807 application/problem+json:
+{
+  "type": "urn:eamsa512:problem:bad_request",
+  "title": "Bad Request",
+  "status": 400,
+  "detail": "Human readable message",
+  "instance": "/api/v1/encrypt",
+  "request_id": "5f0c3b7e9a2d41c8b6e0f1a2c3d4e5f6",
+  "timestamp": "2025-12-04T18:30:00Z"
+}
+
+Every response carries X-Request-ID (the client's, if well formed).
+The code after the type prefix always has the same status (problem.Types);
+clients decode with problem.Decode and match with errors.Is.
+
+Error Codes:
+- bad_request: Invalid input (400)
+- decryption_failed: Authentication verification failed (401)
+- forbidden: Not permitted for this client or role (403)
+- not_found: Unknown blob handle or disabled feature (404)
+- method_not_allowed: Wrong HTTP method (405)
+- timeout: Client stopped sending or reading a stream (408)
+- too_large: Upload over the size limit (413)
+- quota_exceeded: Decrypt quota exhausted (429)
+- internal_error: Server error (500)
+- encryption_failed: Encryption operation failed (500)
+- store_corrupt: Blob store returned corrupt data (502)
+- store_failed: Blob store unavailable (503)
+- insufficient_storage: No memory or spill space for a stream (507)
+
+*/
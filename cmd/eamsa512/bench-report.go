@@ -0,0 +1,499 @@
+// bench-report.go - Machine-readable benchmark reports and a local history
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// BenchSchema identifies the report schema (docs/bench-report.schema.json)
+const BenchSchema = "eamsa512-bench/1"
+
+// DefaultBenchHistory is the history file used by "bench publish"
+const DefaultBenchHistory = "eamsa512-bench-history.jsonl"
+
+// BenchMachine describes the host a report was produced on
+type BenchMachine struct {
+	Host      string `json:"host"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+	CPUs      int    `json:"cpus"`
+	CPUModel  string `json:"cpu_model,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// BenchWorkload describes what was measured
+type BenchWorkload struct {
+	Name          string `json:"name"`
+	Iterations    int    `json:"iterations"`               // Blocks encrypted and MACs verified
+	BlockSize     int    `json:"block_size"`               // Bytes per block
+	ScheduleBytes int    `json:"schedule_bytes"`           // Stream size for the key schedule comparison
+	ParallelBytes int    `json:"parallel_bytes,omitempty"` // Payload size for the parallel lane comparison
+	Workers       int    `json:"workers,omitempty"`        // Workers of the parallel run
+	MessageBytes  int    `json:"message_bytes,omitempty"`  // Message size for the key schedule cache comparison
+}
+
+// BenchResult is one measured value
+type BenchResult struct {
+	Name           string  `json:"name"`
+	Unit           string  `json:"unit"`
+	Value          float64 `json:"value"`
+	HigherIsBetter bool    `json:"higher_is_better"`
+}
+
+// BenchReport is a benchmark run: machine + workload + results
+type BenchReport struct {
+	Schema    string        `json:"schema"`
+	Timestamp string        `json:"timestamp"`
+	Label     string        `json:"label,omitempty"` // e.g. a commit or configuration name
+	Machine   BenchMachine  `json:"machine"`
+	Workload  BenchWorkload `json:"workload"`
+	Results   []BenchResult `json:"results"`
+}
+
+// Result returns the named result
+func (r *BenchReport) Result(name string) (BenchResult, bool) {
+	for _, result := range r.Results {
+		if result.Name == name {
+			return result, true
+		}
+	}
+	return BenchResult{}, false
+}
+
+// Validate checks a report read from a file or history line
+func (r *BenchReport) Validate() error {
+	if r.Schema != BenchSchema {
+		return fmt.Errorf("unsupported benchmark schema %q (want %s)", r.Schema, BenchSchema)
+	}
+	if r.Machine.Host == "" || r.Machine.Arch == "" || r.Workload.Name == "" {
+		return fmt.Errorf("benchmark report is missing machine or workload fields")
+	}
+	if len(r.Results) == 0 {
+		return fmt.Errorf("benchmark report has no results")
+	}
+	for _, result := range r.Results {
+		if result.Name == "" || result.Unit == "" {
+			return fmt.Errorf("benchmark result without name or unit")
+		}
+	}
+	return nil
+}
+
+// sameMachine reports whether two reports are comparable
+func (r *BenchReport) sameMachine(other *BenchReport) bool {
+	return r.Machine.Host == other.Machine.Host &&
+		r.Machine.Arch == other.Machine.Arch &&
+		r.Machine.CPUModel == other.Machine.CPUModel &&
+		r.Workload == other.Workload
+}
+
+// currentBenchMachine describes this host
+func currentBenchMachine() BenchMachine {
+	host, _ := os.Hostname()
+	return BenchMachine{
+		Host:      host,
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		CPUModel:  cpuModel(),
+		GoVersion: runtime.Version(),
+	}
+}
+
+// cpuModel returns the CPU model name where the OS exposes it
+func cpuModel() string {
+	f, err := os.Open("/proc/cpuinfo")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if ok && strings.TrimSpace(key) == "model name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// RunPhase3Bench measures block encryption, MAC verification, the Phase 2
+// key schedule, parallel lanes and the cached schedule of eamsa512.Cipher
+func RunPhase3Bench(iterations int) (*BenchReport, error) {
+	const (
+		scheduleBytes = 1 << 20
+		parallelBytes = 1 << 20
+		messageBytes  = 64
+	)
+
+	masterKey := [32]byte{}
+	nonce := [16]byte{}
+	rand.Read(masterKey[:])
+	rand.Read(nonce[:])
+
+	cipher, err := eamsa512.NewEAMSA512CipherSHA3(&eamsa512.EAMSA512ConfigSHA3{
+		MasterKey:     masterKey,
+		Nonce:         nonce,
+		RoundCount:    16,
+		IncludeAuth:   true,
+		AuthAlgorithm: "HMAC-SHA3-512",
+		Mode:          "CBC",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &BenchReport{
+		Schema:    BenchSchema,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Machine:   currentBenchMachine(),
+		Workload: BenchWorkload{
+			Name:          "phase3-sha3",
+			Iterations:    iterations,
+			BlockSize:     64,
+			ScheduleBytes: scheduleBytes,
+			ParallelBytes: parallelBytes,
+			Workers:       eamsa512.Workers(),
+			MessageBytes:  messageBytes,
+		},
+	}
+
+	// Block encryption
+	start := time.Now()
+	for i := 0; i < iterations; i++ {
+		plaintext := [64]byte{}
+		rand.Read(plaintext[:])
+		cipher.EncryptBlockSHA3(plaintext)
+	}
+	elapsed := time.Since(start)
+	report.Results = append(report.Results,
+		BenchResult{Name: "encrypt_block", Unit: "ns/op", Value: float64(elapsed.Nanoseconds()) / float64(iterations)},
+		BenchResult{Name: "encrypt_throughput", Unit: "MB/s", Value: float64(iterations*64) / elapsed.Seconds() / 1e6, HigherIsBetter: true},
+	)
+
+	// MAC verification
+	plaintext := [64]byte{}
+	rand.Read(plaintext[:])
+	result := cipher.EncryptBlockSHA3(plaintext)
+	start = time.Now()
+	for i := 0; i < iterations; i++ {
+		cipher.VerifyMACHA3(result.MAC, cipher.ComputeMACHA3(result.Ciphertext[:], result.Nonce, result.Counter))
+	}
+	elapsed = time.Since(start)
+	report.Results = append(report.Results,
+		BenchResult{Name: "mac_verify", Unit: "ns/op", Value: float64(elapsed.Nanoseconds()) / float64(iterations)},
+	)
+
+	// Phase 2 key schedule
+	schedule := eamsa512.RunMSAScheduleBenchmark(scheduleBytes)
+	mb := float64(schedule.Bytes) / 1e6
+	report.Results = append(report.Results,
+		BenchResult{Name: "schedule_per_block", Unit: "MB/s", Value: mb / schedule.PerBlock.Seconds(), HigherIsBetter: true},
+		BenchResult{Name: "schedule_precomputed", Unit: "MB/s", Value: mb / schedule.Precomputed.Seconds(), HigherIsBetter: true},
+		BenchResult{Name: "schedule_speedup", Unit: "x", Value: schedule.Speedup(), HigherIsBetter: true},
+	)
+
+	// Parallel lanes: a ModeSIV envelope on one worker, then on all
+	sequential := timeSIVEnvelope(parallelBytes, 1)
+	parallel := timeSIVEnvelope(parallelBytes, 0)
+	mb = float64(parallelBytes) / 1e6
+	report.Results = append(report.Results,
+		BenchResult{Name: "siv_sequential", Unit: "MB/s", Value: mb / sequential.Seconds(), HigherIsBetter: true},
+		BenchResult{Name: "siv_parallel", Unit: "MB/s", Value: mb / parallel.Seconds(), HigherIsBetter: true},
+		BenchResult{Name: "siv_parallel_speedup", Unit: "x", Value: sequential.Seconds() / parallel.Seconds(), HigherIsBetter: true},
+	)
+
+	// Small messages: EncryptData derives the schedule per call, a Cipher once
+	derived, cached := timeSmallMessages(iterations, messageBytes)
+	report.Results = append(report.Results,
+		BenchResult{Name: "message_derive", Unit: "ns/op", Value: float64(derived.Nanoseconds()) / float64(iterations)},
+		BenchResult{Name: "message_cached", Unit: "ns/op", Value: float64(cached.Nanoseconds()) / float64(iterations)},
+		BenchResult{Name: "message_cached_speedup", Unit: "x", Value: derived.Seconds() / cached.Seconds(), HigherIsBetter: true},
+	)
+
+	return report, nil
+}
+
+// timeSmallMessages times n encryptions of a size-byte message with
+// EncryptData and with a Cipher
+func timeSmallMessages(n int, size int) (derived, cached time.Duration) {
+	key := make([]byte, eamsa512.KeySize)
+	rand.Read(key)
+	plaintext := make([]byte, size)
+
+	start := time.Now()
+	for i := 0; i < n; i++ {
+		eamsa512.EncryptData(plaintext, key, nil)
+	}
+	derived = time.Since(start)
+
+	c, err := eamsa512.NewCipher(key)
+	if err != nil {
+		return derived, derived
+	}
+	defer c.Destroy()
+
+	start = time.Now()
+	for i := 0; i < n; i++ {
+		c.Encrypt(plaintext, nil)
+	}
+	return derived, time.Since(start)
+}
+
+// timeSIVEnvelope times the ModeSIV encryption of n bytes with workers
+// (0: the default)
+func timeSIVEnvelope(n int, workers int) time.Duration {
+	key := make([]byte, eamsa512.KeySize)
+	rand.Read(key)
+	plaintext := make([]byte, n)
+
+	defer eamsa512.SetWorkers(eamsa512.SetWorkers(workers))
+	start := time.Now()
+	eamsa512.Encrypt(plaintext, key, eamsa512.EnvelopeOptions{Mode: eamsa512.ModeSIV})
+	return time.Since(start)
+}
+
+// ============================================================================
+// History
+// ============================================================================
+
+// readBenchHistory reads a JSON-lines history; a missing file is empty
+func readBenchHistory(path string) ([]*BenchReport, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read benchmark history: %v", err)
+	}
+
+	var history []*BenchReport
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		report := &BenchReport{}
+		if err := json.Unmarshal(line, report); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, i+1, err)
+		}
+		if err := report.Validate(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, i+1, err)
+		}
+		history = append(history, report)
+	}
+	return history, nil
+}
+
+// appendBenchHistory appends report as one line
+func appendBenchHistory(path string, report *BenchReport) error {
+	line, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open benchmark history: %v", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append benchmark history: %v", err)
+	}
+	return nil
+}
+
+// renderBenchComparison prints report against the previous comparable run
+// and the best comparable value in history
+func renderBenchComparison(report *BenchReport, history []*BenchReport) {
+	var previous *BenchReport
+	var comparable []*BenchReport
+	for _, past := range history {
+		if past.sameMachine(report) {
+			comparable = append(comparable, past)
+			previous = past
+		}
+	}
+
+	resultf("%s on %s (%s/%s, %d CPUs)", report.Workload.Name, report.Machine.Host,
+		report.Machine.OS, report.Machine.Arch, report.Machine.CPUs)
+	if report.Label != "" {
+		resultf(" [%s]", report.Label)
+	}
+	resultf("\n")
+	if previous == nil {
+		resultf("No earlier runs of this workload on this machine\n")
+	} else {
+		resultf("Compared with %s%s and %d earlier run(s)\n", previous.Timestamp, labelSuffix(previous), len(comparable))
+	}
+	resultf("\n%-22s %-6s %12s %12s %9s %12s\n", "METRIC", "UNIT", "THIS RUN", "PREVIOUS", "CHANGE", "BEST")
+
+	for _, result := range report.Results {
+		prev, best := "-", "-"
+		change := "-"
+		if previous != nil {
+			if p, ok := previous.Result(result.Name); ok {
+				prev = fmt.Sprintf("%.2f", p.Value)
+				if p.Value != 0 {
+					delta := (result.Value - p.Value) / p.Value * 100
+					change = fmt.Sprintf("%+.1f%%", delta)
+					if (delta < 0) == result.HigherIsBetter && delta != 0 {
+						change += " !"
+					}
+				}
+			}
+		}
+		if value, ok := bestBenchValue(result, comparable); ok {
+			best = fmt.Sprintf("%.2f", value)
+		}
+		resultf("%-22s %-6s %12.2f %12s %9s %12s\n", result.Name, result.Unit, result.Value, prev, change, best)
+	}
+	resultf("\n! marks a regression against the previous run\n")
+}
+
+// bestBenchValue returns the best earlier value of result's metric
+func bestBenchValue(result BenchResult, history []*BenchReport) (float64, bool) {
+	var best float64
+	found := false
+	for _, past := range history {
+		p, ok := past.Result(result.Name)
+		if !ok {
+			continue
+		}
+		if !found || (result.HigherIsBetter && p.Value > best) || (!result.HigherIsBetter && p.Value < best) {
+			best = p.Value
+			found = true
+		}
+	}
+	return best, found
+}
+
+func labelSuffix(r *BenchReport) string {
+	if r.Label == "" {
+		return ""
+	}
+	return " [" + r.Label + "]"
+}
+
+// ============================================================================
+// Commands
+// ============================================================================
+
+// runBenchCommand implements "eamsa512 bench run|publish"
+func runBenchCommand(args []string) error {
+	if len(args) == 0 {
+		return inputError("bench: expected run or publish")
+	}
+
+	switch args[0] {
+	case "run":
+		return runBenchRun(args[1:])
+	case "publish":
+		return runBenchPublish(args[1:])
+	}
+	return inputError("bench: unknown subcommand %q (want run or publish)", args[0])
+}
+
+// runBenchRun implements "eamsa512 bench run [-iterations N] [-label s]";
+// the report is printed as JSON
+func runBenchRun(args []string) error {
+	fs := flag.NewFlagSet("bench run", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	iterations := fs.Int("iterations", 100, "Blocks to encrypt and MACs to verify")
+	label := fs.String("label", "", "Label stored with the report (e.g. a commit)")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("bench run", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("bench run: unexpected argument: %s", fs.Arg(0))
+	}
+	if *iterations <= 0 {
+		return inputError("bench run: -iterations must be positive")
+	}
+
+	infoln("⏱️  EAMSA 512 Benchmark")
+	report, err := RunPhase3Bench(*iterations)
+	if err != nil {
+		return fmt.Errorf("bench run: %v", err)
+	}
+	report.Label = *label
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %v", err)
+	}
+	resultf("%s\n", data)
+	return nil
+}
+
+// runBenchPublish implements
+// "eamsa512 bench publish [-history file] [-label s] [-iterations N] [report.json]":
+// runs the benchmark (or reads a report from "bench run"), appends it to
+// the history and prints a comparison table
+func runBenchPublish(args []string) error {
+	fs := flag.NewFlagSet("bench publish", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	historyPath := fs.String("history", DefaultBenchHistory, "History file (JSON lines)")
+	iterations := fs.Int("iterations", 100, "Blocks to encrypt and MACs to verify")
+	label := fs.String("label", "", "Label stored with the report (e.g. a commit)")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("bench publish", err)
+	}
+	if fs.NArg() > 1 {
+		return inputError("bench publish: unexpected argument: %s", fs.Arg(1))
+	}
+
+	var report *BenchReport
+	if fs.NArg() == 1 {
+		data, err := os.ReadFile(fs.Arg(0))
+		if err != nil {
+			return inputError("bench publish: %v", err)
+		}
+		report = &BenchReport{}
+		if err := json.Unmarshal(data, report); err != nil {
+			return inputError("bench publish: %s: %v", fs.Arg(0), err)
+		}
+		if *label != "" {
+			report.Label = *label
+		}
+	} else {
+		if *iterations <= 0 {
+			return inputError("bench publish: -iterations must be positive")
+		}
+		infoln("⏱️  EAMSA 512 Benchmark")
+		var err error
+		if report, err = RunPhase3Bench(*iterations); err != nil {
+			return fmt.Errorf("bench publish: %v", err)
+		}
+		report.Label = *label
+	}
+	if err := report.Validate(); err != nil {
+		return inputError("bench publish: %v", err)
+	}
+
+	history, err := readBenchHistory(*historyPath)
+	if err != nil {
+		return err
+	}
+	if err := appendBenchHistory(*historyPath, report); err != nil {
+		return err
+	}
+
+	renderBenchComparison(report, history)
+	infof("Appended to %s (%d runs)\n", *historyPath, len(history)+1)
+	return nil
+}
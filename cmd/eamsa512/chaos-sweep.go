@@ -0,0 +1,567 @@
+// chaos-sweep.go - Parameter sweep and sensitivity analysis of the chaos systems
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"math/bits"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// The key generator is only as good as its parameters: a Lorenz or
+// hyperchaotic system pushed out of its chaotic regime settles on a fixed
+// point or a periodic orbit, and its output repeats. The sweep integrates
+// each system over a grid of parameters, estimates the largest Lyapunov
+// exponent (Benettin: two trajectories 1e-8 apart, renormalised every
+// step) and runs a randomness suite on the raw output, the low 32 bits of
+// each coordinate at every step. Output is deterministic: the initial
+// state is fixed, so a sweep is reproducible.
+const (
+	// chaosTransientSteps are integrated and discarded before measuring
+	chaosTransientSteps = 1000
+
+	// chaosSeparation is the initial distance of the Lyapunov companion
+	chaosSeparation = 1e-8
+
+	// chaosBound is the state magnitude treated as divergence
+	chaosBound = 1e6
+
+	// chaosMinLyapunov is the largest exponent (per time unit) below which
+	// a point is not chaotic; the standard Lorenz attractor has about 0.9
+	chaosMinLyapunov = 0.05
+
+	// chaosAlpha is the significance level of each statistical test. The
+	// output is deterministic, so a failure is not noise to be rerun.
+	chaosAlpha = 0.001
+
+	// chaosMinEntropy is the Shannon entropy (bits/byte) required of the
+	// raw output
+	chaosMinEntropy = 7.99
+)
+
+// Regimes of a sweep point
+const (
+	RegimeChaotic    = "chaotic"
+	RegimePeriodic   = "periodic"    // Largest exponent near zero: a limit cycle
+	RegimeFixedPoint = "fixed-point" // Largest exponent negative
+	RegimeUnbounded  = "unbounded"   // Trajectory diverged
+)
+
+// chaosSystem is a flow the key generator integrates
+type chaosSystem struct {
+	name     string
+	params   []string  // Parameter names, in the order deriv takes them
+	defaults []float64 // Production values (chaos.go)
+	initial  []float64
+	deriv    func(p, v, out []float64)
+}
+
+// chaosSystems are the systems of chaos.go with their parameters free
+var chaosSystems = map[string]chaosSystem{
+	"lorenz": {
+		name:     "lorenz",
+		params:   []string{"sigma", "rho", "beta"},
+		defaults: []float64{sigma, rho, beta},
+		initial:  []float64{1, 1, 1},
+		deriv: func(p, v, out []float64) {
+			out[0] = p[0] * (v[1] - v[0])
+			out[1] = v[0]*(p[1]-v[2]) - v[1]
+			out[2] = v[0]*v[1] - p[2]*v[2]
+		},
+	},
+	"hyper": {
+		name:     "hyper",
+		params:   []string{"a", "b", "c"},
+		defaults: []float64{a, b, c},
+		initial:  []float64{1, 1, 1, 1, 1},
+		deriv: func(p, v, out []float64) {
+			out[0] = p[0] * (v[1] - v[0])
+			out[1] = v[0]*(p[1]-v[2]) - v[1] + v[4]
+			out[2] = v[0]*v[1] - p[2]*v[2]
+			out[3] = v[1]*v[2] - v[3]
+			out[4] = v[3] - v[4]
+		},
+	},
+}
+
+// ChaosSweepPoint is the analysis of one parameter set
+type ChaosSweepPoint struct {
+	Params    map[string]float64 `json:"params"`
+	Lyapunov  float64            `json:"lyapunov"` // Largest exponent per time unit
+	Regime    string             `json:"regime"`
+	Monobit   float64            `json:"monobit_p"`    // SP 800-22 frequency test
+	Runs      float64            `json:"runs_p"`       // SP 800-22 runs test
+	ChiSquare float64            `json:"chi_square_p"` // Byte distribution, 255 dof
+	Entropy   float64            `json:"entropy"`      // Shannon, bits/byte
+	Passed    bool               `json:"passed"`
+	Failures  []string           `json:"failures,omitempty"`
+}
+
+// ChaosSweepRegion is a run of consecutive failing points along one
+// swept parameter, with the other parameters fixed
+type ChaosSweepRegion struct {
+	Param  string             `json:"param"`
+	From   float64            `json:"from"`
+	To     float64            `json:"to"`
+	Points int                `json:"points"`
+	Fixed  map[string]float64 `json:"fixed"`
+	Regime []string           `json:"regimes"` // Regimes seen in the region
+}
+
+// ChaosSweepReport is the result of "eamsa512 chaos sweep"
+type ChaosSweepReport struct {
+	System      string             `json:"system"`
+	Dt          float64            `json:"dt"`
+	Steps       int                `json:"steps"`
+	SampleBytes int                `json:"sample_bytes"`
+	Production  ChaosSweepPoint    `json:"production"` // The parameters chaos.go uses
+	Points      []ChaosSweepPoint  `json:"points"`
+	Failed      int                `json:"failed"`
+	Regions     []ChaosSweepRegion `json:"failing_regions"`
+}
+
+// sweepRange is "name=min:max:points"
+type sweepRange struct {
+	name     string
+	min, max float64
+	points   int
+}
+
+// sweepRanges collects repeated -param flags
+type sweepRanges []sweepRange
+
+func (r *sweepRanges) String() string { return "" }
+
+func (r *sweepRanges) Set(value string) error {
+	name, spec, ok := strings.Cut(value, "=")
+	parts := strings.Split(spec, ":")
+	if !ok || len(parts) != 3 {
+		return fmt.Errorf("want name=min:max:points, got %q", value)
+	}
+	min, err1 := strconv.ParseFloat(parts[0], 64)
+	max, err2 := strconv.ParseFloat(parts[1], 64)
+	points, err3 := strconv.Atoi(parts[2])
+	if err1 != nil || err2 != nil || err3 != nil || points < 1 || max < min {
+		return fmt.Errorf("invalid range %q", value)
+	}
+	*r = append(*r, sweepRange{name: name, min: min, max: max, points: points})
+	return nil
+}
+
+// runChaosCommand implements "eamsa512 chaos sweep"
+func runChaosCommand(args []string) error {
+	if len(args) == 0 || args[0] != "sweep" {
+		return inputError("chaos: expected sweep")
+	}
+
+	fs := flag.NewFlagSet("chaos sweep", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	system := fs.String("system", "lorenz", "System to sweep: lorenz or hyper")
+	var ranges sweepRanges
+	fs.Var(&ranges, "param", "Sweep name=min:max:points (repeatable: a grid); default each parameter from 0.25x to 2x its production value")
+	steps := fs.Int("steps", 20000, "Integration steps per point for the Lyapunov exponent")
+	dt := fs.Float64("dt", 0.01, "Integration step")
+	sample := fs.Int("sample", 64*1024, "Output bytes tested per point")
+	format := fs.String("format", "json", "Report format: json or text")
+
+	if err := fs.Parse(args[1:]); err != nil {
+		return flagError("chaos sweep", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("chaos sweep: unexpected argument: %s", fs.Arg(0))
+	}
+	sys, ok := chaosSystems[*system]
+	if !ok {
+		return inputError("chaos sweep: unknown system %q (want lorenz or hyper)", *system)
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("chaos sweep: unknown format %q (want json or text)", *format)
+	}
+	if *steps <= 0 || *dt <= 0 || *sample < 1024 {
+		return inputError("chaos sweep: -steps and -dt must be positive and -sample at least 1024")
+	}
+	swept := make(map[string]bool)
+	for _, r := range ranges {
+		if sys.index(r.name) < 0 {
+			return inputError("chaos sweep: %s has no parameter %q (have %s)", sys.name, r.name, strings.Join(sys.params, ", "))
+		}
+		if swept[r.name] {
+			return inputError("chaos sweep: %s swept twice", r.name)
+		}
+		swept[r.name] = true
+	}
+
+	infof("🌀 Sweeping %s (%d steps, dt %g)\n", sys.name, *steps, *dt)
+	report := RunChaosSweep(sys, ranges, *steps, *dt, *sample)
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		printChaosSweep(report)
+	}
+
+	if !report.Production.Passed {
+		return fmt.Errorf("chaos sweep: production parameters of %s fail: %s", sys.name, strings.Join(report.Production.Failures, "; "))
+	}
+	return nil
+}
+
+// RunChaosSweep analyses the production parameters of sys and every point
+// of ranges (a grid), or of a one-at-a-time sweep of each parameter if
+// ranges is empty
+func RunChaosSweep(sys chaosSystem, ranges []sweepRange, steps int, dt float64, sample int) *ChaosSweepReport {
+	report := &ChaosSweepReport{System: sys.name, Dt: dt, Steps: steps, SampleBytes: sample}
+	report.Production = analyseChaosPoint(sys, sys.defaults, steps, dt, sample)
+
+	for _, line := range sys.sweepLines(ranges) {
+		var run []ChaosSweepPoint
+		flush := func() {
+			if len(run) > 0 {
+				report.Regions = append(report.Regions, failingRegion(line.param, line.fixed, run))
+				run = nil
+			}
+		}
+
+		for _, p := range line.points {
+			point := analyseChaosPoint(sys, p, steps, dt, sample)
+			report.Points = append(report.Points, point)
+			if point.Passed {
+				flush()
+				continue
+			}
+			report.Failed++
+			run = append(run, point)
+		}
+		flush()
+	}
+	return report
+}
+
+// sweepLine is a set of points that vary one parameter
+type sweepLine struct {
+	param  string
+	fixed  map[string]float64
+	points [][]float64
+}
+
+// index returns the position of a parameter, or -1
+func (sys chaosSystem) index(name string) int {
+	for i, p := range sys.params {
+		if p == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// sweepLines expands ranges into lines along the last range, one per
+// combination of the others; without ranges, one line per parameter from
+// 0.25x to 2x its production value with the others at theirs
+func (sys chaosSystem) sweepLines(ranges []sweepRange) []sweepLine {
+	if len(ranges) == 0 {
+		var lines []sweepLine
+		for i, name := range sys.params {
+			d := sys.defaults[i]
+			lines = append(lines, sys.sweepLines([]sweepRange{{name: name, min: d / 4, max: 2 * d, points: 16}})...)
+		}
+		return lines
+	}
+
+	last := ranges[len(ranges)-1]
+	bases := [][]float64{sys.defaults}
+	for _, r := range ranges[:len(ranges)-1] {
+		var next [][]float64
+		for _, base := range bases {
+			for _, v := range r.values() {
+				p := append([]float64(nil), base...)
+				p[sys.index(r.name)] = v
+				next = append(next, p)
+			}
+		}
+		bases = next
+	}
+
+	lines := make([]sweepLine, 0, len(bases))
+	for _, base := range bases {
+		line := sweepLine{param: last.name, fixed: make(map[string]float64)}
+		for i, name := range sys.params {
+			if name != last.name {
+				line.fixed[name] = base[i]
+			}
+		}
+		for _, v := range last.values() {
+			p := append([]float64(nil), base...)
+			p[sys.index(last.name)] = v
+			line.points = append(line.points, p)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// values returns the evenly spaced points of r
+func (r sweepRange) values() []float64 {
+	if r.points == 1 {
+		return []float64{r.min}
+	}
+	out := make([]float64, r.points)
+	for i := range out {
+		out[i] = r.min + (r.max-r.min)*float64(i)/float64(r.points-1)
+	}
+	return out
+}
+
+// analyseChaosPoint integrates sys under params and tests its output
+func analyseChaosPoint(sys chaosSystem, params []float64, steps int, dt float64, sample int) ChaosSweepPoint {
+	point := ChaosSweepPoint{Params: make(map[string]float64, len(params))}
+	for i, name := range sys.params {
+		point.Params[name] = params[i]
+	}
+
+	lyapunov, output, bounded := integrateChaos(sys, params, steps, dt, sample)
+	switch {
+	case !bounded:
+		point.Regime = RegimeUnbounded
+	case lyapunov > chaosMinLyapunov:
+		point.Regime = RegimeChaotic
+	case lyapunov < -chaosMinLyapunov:
+		point.Regime = RegimeFixedPoint
+	default:
+		point.Regime = RegimePeriodic
+	}
+
+	if !bounded {
+		point.Failures = append(point.Failures, "trajectory diverged")
+		return point
+	}
+	point.Lyapunov = lyapunov
+	if point.Regime != RegimeChaotic {
+		point.Failures = append(point.Failures, fmt.Sprintf("%s: largest Lyapunov exponent %.4f", point.Regime, lyapunov))
+	}
+
+	point.Monobit = monobitP(output)
+	point.Runs = runsP(output)
+	point.ChiSquare = byteChiSquareP(output)
+	point.Entropy = eamsa512.ShannonEntropy(output)
+	for _, test := range []struct {
+		name string
+		p    float64
+	}{{"frequency", point.Monobit}, {"runs", point.Runs}, {"byte chi-square", point.ChiSquare}} {
+		if test.p < chaosAlpha {
+			point.Failures = append(point.Failures, fmt.Sprintf("%s test p=%.2g", test.name, test.p))
+		}
+	}
+	if point.Entropy < chaosMinEntropy {
+		point.Failures = append(point.Failures, fmt.Sprintf("entropy %.4f bits/byte", point.Entropy))
+	}
+
+	point.Passed = len(point.Failures) == 0
+	return point
+}
+
+// integrateChaos returns the largest Lyapunov exponent of sys over steps
+// and sample bytes of raw output, or bounded false if it diverges
+func integrateChaos(sys chaosSystem, params []float64, steps int, dt float64, sample int) (float64, []byte, bool) {
+	dim := len(sys.initial)
+	x := append([]float64(nil), sys.initial...)
+	k := make([][]float64, 5)
+	for i := range k {
+		k[i] = make([]float64, dim)
+	}
+
+	for i := 0; i < chaosTransientSteps; i++ {
+		rk4Step(sys, params, x, dt, k)
+	}
+	if !chaosBounded(x) {
+		return 0, nil, false
+	}
+
+	y := append([]float64(nil), x...)
+	y[0] += chaosSeparation
+
+	output := make([]byte, 0, sample+4*dim)
+	var sum float64
+	for i := 0; i < steps || len(output) < sample; i++ {
+		rk4Step(sys, params, x, dt, k)
+		if !chaosBounded(x) {
+			return 0, nil, false
+		}
+		for _, v := range x {
+			output = binary.BigEndian.AppendUint32(output, uint32(math.Float64bits(v)))
+		}
+		if i >= steps {
+			continue
+		}
+
+		rk4Step(sys, params, y, dt, k)
+		var d float64
+		for j := range x {
+			d += (y[j] - x[j]) * (y[j] - x[j])
+		}
+		d = math.Max(math.Sqrt(d), 1e-300)
+		sum += math.Log(d / chaosSeparation)
+		for j := range y {
+			y[j] = x[j] + (y[j]-x[j])*chaosSeparation/d
+		}
+	}
+
+	return sum / (float64(steps) * dt), output[:sample], true
+}
+
+// rk4Step advances v by one Runge-Kutta step in place; k is scratch
+func rk4Step(sys chaosSystem, params, v []float64, dt float64, k [][]float64) {
+	k1, k2, k3, k4, tmp := k[0], k[1], k[2], k[3], k[4]
+
+	sys.deriv(params, v, k1)
+	for i := range v {
+		tmp[i] = v[i] + 0.5*dt*k1[i]
+	}
+	sys.deriv(params, tmp, k2)
+	for i := range v {
+		tmp[i] = v[i] + 0.5*dt*k2[i]
+	}
+	sys.deriv(params, tmp, k3)
+	for i := range v {
+		tmp[i] = v[i] + dt*k3[i]
+	}
+	sys.deriv(params, tmp, k4)
+	for i := range v {
+		v[i] += (dt / 6.0) * (k1[i] + 2.0*k2[i] + 2.0*k3[i] + k4[i])
+	}
+}
+
+// chaosBounded reports whether every coordinate is finite and in bounds
+func chaosBounded(v []float64) bool {
+	for _, x := range v {
+		if math.IsNaN(x) || math.Abs(x) > chaosBound {
+			return false
+		}
+	}
+	return true
+}
+
+// monobitP is the p-value of the SP 800-22 frequency (monobit) test
+func monobitP(data []byte) float64 {
+	ones := 0
+	for _, b := range data {
+		ones += bits.OnesCount8(b)
+	}
+	n := float64(8 * len(data))
+	s := math.Abs(float64(2*ones)-n) / math.Sqrt(n)
+	return math.Erfc(s / math.Sqrt2)
+}
+
+// runsP is the p-value of the SP 800-22 runs test
+func runsP(data []byte) float64 {
+	n := float64(8 * len(data))
+	ones := 0
+	for _, b := range data {
+		ones += bits.OnesCount8(b)
+	}
+	pi := float64(ones) / n
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(n) {
+		return 0 // Frequency prerequisite fails
+	}
+
+	runs := 1
+	prev := data[0] >> 7
+	for i := 1; i < 8*len(data); i++ {
+		bit := (data[i/8] >> (7 - i%8)) & 1
+		if bit != prev {
+			runs++
+		}
+		prev = bit
+	}
+
+	expected := 2 * n * pi * (1 - pi)
+	return math.Erfc(math.Abs(float64(runs)-expected) / (2 * math.Sqrt(2*n) * pi * (1 - pi)))
+}
+
+// byteChiSquareP is the p-value of a chi-square test of uniform byte
+// values (255 degrees of freedom, Wilson-Hilferty approximation)
+func byteChiSquareP(data []byte) float64 {
+	var counts [256]int
+	for _, b := range data {
+		counts[b]++
+	}
+
+	expected := float64(len(data)) / 256
+	var x2 float64
+	for _, c := range counts {
+		d := float64(c) - expected
+		x2 += d * d / expected
+	}
+
+	const dof = 255.0
+	z := (math.Cbrt(x2/dof) - (1 - 2/(9*dof))) / math.Sqrt(2/(9*dof))
+	return 0.5 * math.Erfc(z/math.Sqrt2)
+}
+
+// failingRegion summarises a run of failing points along param
+func failingRegion(param string, fixed map[string]float64, run []ChaosSweepPoint) ChaosSweepRegion {
+	region := ChaosSweepRegion{
+		Param:  param,
+		From:   run[0].Params[param],
+		To:     run[len(run)-1].Params[param],
+		Points: len(run),
+		Fixed:  fixed,
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range run {
+		if !seen[p.Regime] {
+			seen[p.Regime] = true
+			region.Regime = append(region.Regime, p.Regime)
+		}
+	}
+	sort.Strings(region.Regime)
+	return region
+}
+
+// printChaosSweep writes report as a table
+func printChaosSweep(report *ChaosSweepReport) {
+	sys := chaosSystems[report.System]
+	params := func(p ChaosSweepPoint) string {
+		parts := make([]string, len(sys.params))
+		for i, name := range sys.params {
+			parts[i] = fmt.Sprintf("%s=%-8.4g", name, p.Params[name])
+		}
+		return strings.Join(parts, " ")
+	}
+	status := func(p ChaosSweepPoint) string {
+		if p.Passed {
+			return "PASS"
+		}
+		return "FAIL " + strings.Join(p.Failures, "; ")
+	}
+
+	resultf("%s: %d steps, dt %g, %d output bytes per point\n", report.System, report.Steps, report.Dt, report.SampleBytes)
+	resultf("production %s  λ=%7.4f %-11s %s\n\n", params(report.Production), report.Production.Lyapunov, report.Production.Regime, status(report.Production))
+
+	for _, p := range report.Points {
+		resultf("%s  λ=%7.4f %-11s H=%.4f  %s\n", params(p), p.Lyapunov, p.Regime, p.Entropy, status(p))
+	}
+
+	resultf("\n%d of %d points fail\n", report.Failed, len(report.Points))
+	for _, r := range report.Regions {
+		fixed := make([]string, 0, len(r.Fixed))
+		for _, name := range sys.params {
+			if v, ok := r.Fixed[name]; ok {
+				fixed = append(fixed, fmt.Sprintf("%s=%.4g", name, v))
+			}
+		}
+		resultf("  %s %.4g..%.4g (%d points, %s) with %s\n", r.Param, r.From, r.To, r.Points, strings.Join(r.Regime, ", "), strings.Join(fixed, " "))
+	}
+}
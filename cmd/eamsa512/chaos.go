@@ -4,7 +4,6 @@ package main
 import (
     "math"
     "math/rand"
-    "time"
 )
 
 // Vector3 represents a 3D vector for Lorenz system
@@ -100,14 +99,6 @@ func hyperchaoticRK4(v Vector5, dt float64) Vector5 {
     }
 }
 
-func lorenzDeriv(v Vector3) Vector3 {
-    return Vector3{
-        X: sigma * (v.Y - v.X),
-        Y: v.X*(rho - v.Z) - v.Y,
-        Z: v.X*v.Y - beta*v.Z,
-    }
-}
-
 func hyperchaoticDeriv(v Vector5) Vector5 {
     return Vector5{
         M: a*(v.N - v.M),
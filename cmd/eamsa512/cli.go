@@ -0,0 +1,118 @@
+// cli.go - CLI output routing and exit codes for scripting
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Exit codes returned by the CLI
+const (
+	ExitSuccess     = 0 // Command completed
+	ExitFailure     = 1 // Unclassified failure
+	ExitAuthFailure = 2 // MAC/tag verification or tamper check failed
+	ExitBadInput    = 3 // Invalid flags, arguments or input data
+	ExitKeyError    = 4 // Key missing, invalid, expired or unusable
+)
+
+// CLIError carries the exit code for a failed command
+type CLIError struct {
+	Code int
+	Err  error
+}
+
+// Error implements error
+func (e *CLIError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *CLIError) Unwrap() error {
+	return e.Err
+}
+
+// authError marks an authentication failure (exit code 2)
+func authError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitAuthFailure, Err: fmt.Errorf(format, args...)}
+}
+
+// inputError marks invalid input (exit code 3)
+func inputError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitBadInput, Err: fmt.Errorf(format, args...)}
+}
+
+// flagError maps a subcommand's flag parse error: -h prints the usage and
+// is a success, anything else is invalid input (exit code 3)
+func flagError(command string, err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return inputError("%s: %v", command, err)
+}
+
+// keyError marks a key problem (exit code 4)
+func keyError(format string, args ...interface{}) error {
+	return &CLIError{Code: ExitKeyError, Err: fmt.Errorf(format, args...)}
+}
+
+// exitCode maps an error to the process exit code
+func exitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var cliErr *CLIError
+	if errors.As(err, &cliErr) {
+		return cliErr.Code
+	}
+
+	return ExitFailure
+}
+
+// CLI output streams: payload goes to stdout, everything else to stderr so
+// that stdout can be piped. --quiet discards informational output; errors
+// are always reported on stderr.
+var (
+	payloadOut io.Writer = os.Stdout
+	infoOut    io.Writer = os.Stderr
+	errorOut   io.Writer = os.Stderr
+	cliQuiet   bool
+)
+
+// setQuiet enables or disables informational output
+func setQuiet(quiet bool) {
+	cliQuiet = quiet
+	if quiet {
+		infoOut = io.Discard
+	} else {
+		infoOut = os.Stderr
+	}
+}
+
+// infof writes informational output (stderr, suppressed by --quiet)
+func infof(format string, args ...interface{}) {
+	fmt.Fprintf(infoOut, format, args...)
+}
+
+// infoln writes an informational line (stderr, suppressed by --quiet)
+func infoln(args ...interface{}) {
+	fmt.Fprintln(infoOut, args...)
+}
+
+// resultf writes command results (stdout)
+func resultf(format string, args ...interface{}) {
+	fmt.Fprintf(payloadOut, format, args...)
+}
+
+// exitWithError reports err on stderr and exits with its exit code
+func exitWithError(err error) {
+	if err == nil {
+		os.Exit(ExitSuccess)
+	}
+
+	fmt.Fprintf(errorOut, "eamsa512: %v\n", err)
+	os.Exit(exitCode(err))
+}
@@ -0,0 +1,177 @@
+// config-command.go - Configuration lint and schema migration
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+)
+
+// runConfigCommand implements "eamsa512 config lint|migrate"
+func runConfigCommand(args []string) error {
+	if len(args) == 0 {
+		return inputError("config: expected a subcommand (lint or migrate)")
+	}
+
+	switch args[0] {
+	case "lint":
+		return runConfigLint(args[1:])
+	case "migrate":
+		return runConfigMigrate(args[1:])
+	}
+	return inputError("config: unknown subcommand %q (want lint or migrate)", args[0])
+}
+
+// configLintResult is one file in the JSON lint output
+type configLintResult struct {
+	File string `json:"file"`
+	*confschema.Report
+}
+
+// runConfigLint implements "eamsa512 config lint [-format json|text]
+// [-kind auto|server|rbac] [-strict] <file>...". A file of "-" reads stdin.
+func runConfigLint(args []string) error {
+	fs := flag.NewFlagSet("config lint", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	format := fs.String("format", "text", "Output format: json or text")
+	kind := fs.String("kind", "auto", "File kind: auto, server or rbac")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("config lint", err)
+	}
+	if fs.NArg() == 0 {
+		return inputError("config lint: expected at least one file argument")
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("config lint: unknown format %q (want json or text)", *format)
+	}
+
+	var fileKind confschema.Kind
+	switch *kind {
+	case "auto":
+	case string(confschema.KindServer), string(confschema.KindRBAC):
+		fileKind = confschema.Kind(*kind)
+	default:
+		return inputError("config lint: unknown kind %q (want auto, server or rbac)", *kind)
+	}
+
+	var results []configLintResult
+	errors, warnings := 0, 0
+
+	for _, path := range fs.Args() {
+		data, err := readInspectInput(path)
+		if err != nil {
+			return inputError("config lint: %v", err)
+		}
+
+		report, err := confschema.Lint(data, fileKind)
+		if err != nil {
+			return inputError("config lint: %v", err)
+		}
+		results = append(results, configLintResult{File: path, Report: report})
+		errors += report.Count(confschema.SeverityError)
+		warnings += report.Count(confschema.SeverityWarning)
+	}
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		for _, result := range results {
+			for _, f := range result.Findings {
+				resultf("%s: %s: %s: %s\n", configLocation(result.File, f.Line), f.Severity, f.Path, f.Message)
+			}
+		}
+	}
+
+	summary := fmt.Sprintf("%d file(s): %d error(s), %d warning(s)", len(results), errors, warnings)
+	if errors > 0 || *strict && warnings > 0 {
+		return inputError("config lint: %s", summary)
+	}
+
+	infoln("✅ config lint: " + summary)
+	return nil
+}
+
+// runConfigMigrate implements "eamsa512 config migrate [-o file | -w] <file>".
+// The migrated file goes to stdout unless -o or -w is given; the list of
+// changes goes to stderr.
+func runConfigMigrate(args []string) error {
+	fs := flag.NewFlagSet("config migrate", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	output := fs.String("o", "", "Write the migrated file here instead of stdout")
+	inPlace := fs.Bool("w", false, "Rewrite the input file in place")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("config migrate", err)
+	}
+	if fs.NArg() != 1 {
+		return inputError("config migrate: expected exactly one file argument")
+	}
+	path := fs.Arg(0)
+	if *inPlace && (*output != "" || path == "-") {
+		return inputError("config migrate: -w cannot be combined with -o or stdin")
+	}
+
+	data, err := readInspectInput(path)
+	if err != nil {
+		return inputError("config migrate: %v", err)
+	}
+
+	result, err := confschema.Migrate(data)
+	if err != nil {
+		return inputError("config migrate: %v", err)
+	}
+
+	for _, change := range result.Changes {
+		infof("%s: %s: %s\n", configLocation(path, change.Line), change.Path, change.Message)
+	}
+	for _, manual := range result.Manual {
+		fmt.Fprintf(errorOut, "%s: manual: %s: %s\n", configLocation(path, manual.Line), manual.Path, manual.Message)
+	}
+
+	target := *output
+	if *inPlace {
+		target = path
+	}
+
+	switch {
+	case target == "":
+		if _, err := payloadOut.Write(result.Output); err != nil {
+			return err
+		}
+	case result.From == result.To && target == path:
+		// Already current; leave the file untouched
+	default:
+		mode := os.FileMode(0640)
+		if info, err := os.Stat(path); err == nil && path != "-" {
+			mode = info.Mode().Perm()
+		}
+		if err := os.WriteFile(target, result.Output, mode); err != nil {
+			return fmt.Errorf("config migrate: %v", err)
+		}
+	}
+
+	if len(result.Manual) > 0 {
+		return inputError("config migrate: version %d -> %d with %d setting(s) to fix by hand", result.From, result.To, len(result.Manual))
+	}
+
+	infof("✅ config migrate: version %d -> %d, %d change(s)\n", result.From, result.To, len(result.Changes))
+	return nil
+}
+
+// configLocation formats file:line, or just the file for file-wide findings
+func configLocation(path string, line int) string {
+	if line == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s:%d", path, line)
+}
@@ -0,0 +1,184 @@
+// conformance-command.go - Conformance vectors and runner for other implementations
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Redeaux-Corporation/eamsa512/conformance"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// defaultVectorsPath is where the vectors file lives in the repository
+const defaultVectorsPath = "conformance/vectors.json"
+
+// runConformanceCommand implements "eamsa512 conformance run|vectors|reference|contract"
+func runConformanceCommand(args []string) error {
+	if len(args) == 0 {
+		return inputError("conformance: expected run, vectors, reference or contract")
+	}
+
+	switch args[0] {
+	case "run":
+		return runConformanceRun(args[1:])
+	case "vectors":
+		return runConformanceVectors(args[1:])
+	case "reference":
+		return runConformanceReference(args[1:])
+	case "contract":
+		if len(args) > 1 {
+			return inputError("conformance contract: unexpected argument: %s", args[1])
+		}
+		return conformance.WriteContract(payloadOut)
+	}
+	return inputError("conformance: unknown subcommand %q (want run, vectors, reference or contract)", args[0])
+}
+
+// runConformanceRun implements
+// "eamsa512 conformance run [-vectors file] [-ops a,b] [-timeout d] [-format text|json] -- <command>...":
+// exit 1 if any vector fails
+func runConformanceRun(args []string) error {
+	fs := flag.NewFlagSet("conformance run", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	vectorsPath := fs.String("vectors", defaultVectorsPath, "Vectors file")
+	ops := fs.String("ops", "", "Comma-separated ops to run (default: all)")
+	timeout := fs.Duration("timeout", conformance.DefaultTimeout, "Time limit per vector")
+	outputFormat := fs.String("format", "text", "Output format: text or json")
+	verbose := fs.Bool("v", false, "List passing and skipped vectors too")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("conformance run", err)
+	}
+	if fs.NArg() == 0 {
+		return inputError("conformance run: expected the candidate command after --")
+	}
+	if *outputFormat != "text" && *outputFormat != "json" {
+		return inputError("conformance run: -format must be text or json")
+	}
+
+	suite, err := conformance.Load(*vectorsPath)
+	if err != nil {
+		return inputError("conformance run: %v", err)
+	}
+
+	runner := &conformance.Runner{Command: fs.Args(), Timeout: *timeout}
+	if *ops != "" {
+		runner.Ops = strings.Split(*ops, ",")
+	}
+
+	report, err := runner.Run(context.Background(), suite)
+	if err != nil {
+		return inputError("conformance run: %v", err)
+	}
+
+	if *outputFormat == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	} else {
+		printConformanceReport(report, *verbose)
+	}
+
+	if !report.OK() {
+		return fmt.Errorf("conformance: %d of %d vectors failed", report.Failed, len(report.Results))
+	}
+	return nil
+}
+
+// printConformanceReport prints failures (and with verbose, every result)
+// and a summary line
+func printConformanceReport(report *conformance.Report, verbose bool) {
+	for _, r := range report.Results {
+		if r.Status != conformance.StatusFail && !verbose {
+			continue
+		}
+		if r.Reason != "" {
+			resultf("%-4s  %-20s %s\n", strings.ToUpper(r.Status), r.ID, r.Reason)
+		} else {
+			resultf("%-4s  %s\n", strings.ToUpper(r.Status), r.ID)
+		}
+		for _, d := range r.Diffs {
+			resultf("      %s\n        want %s\n        got  %s\n", d.Field, d.Want, d.Got)
+		}
+		if r.Stderr != "" {
+			resultf("      stderr: %s\n", strings.TrimSpace(r.Stderr))
+		}
+	}
+	resultf("%d passed, %d failed, %d skipped (%s)\n", report.Passed, report.Failed, report.Skipped, report.Command)
+}
+
+// runConformanceVectors implements "eamsa512 conformance vectors [-o file]"
+func runConformanceVectors(args []string) error {
+	fs := flag.NewFlagSet("conformance vectors", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	output := fs.String("o", "", "Write to file instead of stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("conformance vectors", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("conformance vectors: unexpected argument: %s", fs.Arg(0))
+	}
+
+	suite, err := conformance.Generate()
+	if err != nil {
+		return fmt.Errorf("conformance vectors: %v", err)
+	}
+	data, err := suite.Marshal()
+	if err != nil {
+		return fmt.Errorf("conformance vectors: %v", err)
+	}
+
+	if *output == "" {
+		_, err = payloadOut.Write(data)
+		return err
+	}
+	if err := os.WriteFile(*output, data, 0644); err != nil {
+		return fmt.Errorf("conformance vectors: %v", err)
+	}
+	infof("Wrote %d vectors to %s\n", len(suite.Vectors), *output)
+	return nil
+}
+
+// runConformanceReference implements "eamsa512 conformance reference <op>",
+// the Go implementation behind the CLI contract
+func runConformanceReference(args []string) error {
+	if len(args) != 1 {
+		return inputError("conformance reference: expected one op")
+	}
+	if _, ok := conformance.LookupOp(args[0]); !ok {
+		return &CLIError{Code: conformance.ExitUnsupported, Err: fmt.Errorf("conformance reference: unsupported op %q", args[0])}
+	}
+
+	data, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return inputError("conformance reference: %v", err)
+	}
+	var input map[string]string
+	if err := json.Unmarshal(data, &input); err != nil {
+		return inputError("conformance reference: input is not a JSON object of strings: %v", err)
+	}
+
+	output, err := conformance.Reference(args[0], input)
+	if errors.Is(err, eamsa512.ErrDecryption) {
+		return authError("conformance reference: %v", err)
+	}
+	if err != nil {
+		return inputError("conformance reference: %v", err)
+	}
+
+	encoded, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	resultf("%s\n", encoded)
+	return nil
+}
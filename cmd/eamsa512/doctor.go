@@ -0,0 +1,739 @@
+// doctor.go - Pre-flight environment checks with remediation steps
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"golang.org/x/sys/cpu"
+	"gopkg.in/yaml.v3"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/sntp"
+)
+
+const (
+	// DefaultConfigPath is where the server looks for eamsa512.yaml
+	DefaultConfigPath = "/etc/eamsa512/eamsa512.yaml"
+
+	// DefaultDatabasePath is the server's SQLite keystore and audit database
+	DefaultDatabasePath = "/var/lib/eamsa512/eamsa512.db"
+
+	// doctorCertWarnDays warns about certificates expiring this soon
+	doctorCertWarnDays = 30
+
+	// doctorMinEntropyAvail is the kernel entropy estimate below which
+	// /dev/random consumers may block on older kernels
+	doctorMinEntropyAvail = 256
+)
+
+// Doctor check outcomes
+const (
+	DoctorOK   = "ok"
+	DoctorWarn = "warn"
+	DoctorFail = "fail"
+)
+
+// DoctorResult is the outcome of one environment check
+type DoctorResult struct {
+	Check  string `json:"check"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+	Fix    string `json:"fix,omitempty"`
+}
+
+// DoctorReport is the machine-readable doctor report
+type DoctorReport struct {
+	Timestamp string         `json:"timestamp"`
+	Host      string         `json:"host"`
+	Platform  string         `json:"platform"`
+	Config    string         `json:"config"`
+	Failed    int            `json:"failed"`
+	Warnings  int            `json:"warnings"`
+	Results   []DoctorResult `json:"results"`
+}
+
+// doctorConfig holds the eamsa512.yaml settings the checks need. The file
+// is a sequence of YAML documents, each holding some top-level sections.
+type doctorConfig struct {
+	Server struct {
+		TLS struct {
+			Enabled  bool   `yaml:"enabled"`
+			CertPath string `yaml:"cert_path"`
+			KeyPath  string `yaml:"key_path"`
+		} `yaml:"tls"`
+	} `yaml:"server"`
+
+	Logging struct {
+		Files map[string]string `yaml:"files"`
+	} `yaml:"logging"`
+
+	HSM struct {
+		Enabled bool   `yaml:"enabled"`
+		Type    string `yaml:"type"`
+		Network struct {
+			Host           string `yaml:"host"`
+			Port           int    `yaml:"port"`
+			TimeoutSeconds int    `yaml:"timeout_seconds"`
+		} `yaml:"network"`
+		Authentication struct {
+			CertPath string `yaml:"cert_path"`
+			KeyPath  string `yaml:"key_path"`
+		} `yaml:"authentication"`
+		Backup struct {
+			Enabled  bool   `yaml:"enabled"`
+			Location string `yaml:"location"`
+		} `yaml:"backup"`
+	} `yaml:"hsm"`
+
+	KeyManagement struct {
+		Storage struct {
+			BackupPath string `yaml:"backup_path"`
+		} `yaml:"storage"`
+	} `yaml:"key_management"`
+
+	Performance struct {
+		Vectorization bool `yaml:"vectorization"`
+	} `yaml:"performance"`
+}
+
+// loadDoctorConfig decodes every YAML document of path into one config
+func loadDoctorConfig(path string) (*doctorConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	config := &doctorConfig{}
+	decoder := yaml.NewDecoder(f)
+	for {
+		if err := decoder.Decode(config); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+		}
+	}
+	return config, nil
+}
+
+// doctorOptions are the command-line settings of a doctor run
+type doctorOptions struct {
+	configPath string
+	dbPath     string
+	ntpServers []string
+	maxSkew    time.Duration
+	timeout    time.Duration
+}
+
+// RunDoctor runs every check and returns the report
+func RunDoctor(config *doctorConfig, options doctorOptions) *DoctorReport {
+	host, _ := os.Hostname()
+
+	report := &DoctorReport{
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Host:      host,
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Config:    options.configPath,
+	}
+
+	var results []DoctorResult
+	results = append(results, doctorDirectories(config, options.dbPath)...)
+	results = append(results, doctorEntropy(options.timeout)...)
+	results = append(results, doctorCPU(config)...)
+	results = append(results, doctorDatabase(options.dbPath, options.timeout)...)
+	results = append(results, doctorHSM(config, options.timeout)...)
+	results = append(results, doctorTLS(config)...)
+	results = append(results, doctorClock(options.ntpServers, options.maxSkew, options.timeout)...)
+
+	for _, result := range results {
+		switch result.Status {
+		case DoctorFail:
+			report.Failed++
+		case DoctorWarn:
+			report.Warnings++
+		}
+	}
+	report.Results = results
+
+	return report
+}
+
+// runDoctorCommand implements "eamsa512 doctor [-config file] [-db file]
+// [-ntp servers] [-format json|text] [-strict]"
+func runDoctorCommand(args []string) error {
+	fs := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	configPath := fs.String("config", DefaultConfigPath, "Server configuration file")
+	dbPath := fs.String("db", DefaultDatabasePath, "Path to database")
+	ntpServers := fs.String("ntp", os.Getenv("EAMSA_NTP_SERVERS"), "Comma-separated NTP servers for the clock check")
+	maxSkew := fs.Duration("max-skew", 30*time.Second, "Largest acceptable clock offset")
+	timeout := fs.Duration("timeout", 5*time.Second, "Timeout for each network check")
+	format := fs.String("format", "text", "Report format: json or text")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as failures")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("doctor", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("doctor: unexpected argument: %s", fs.Arg(0))
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("doctor: unknown format %q (want json or text)", *format)
+	}
+
+	// Without a configuration the checks use the built-in defaults, which
+	// is only expected for the default path
+	config, err := loadDoctorConfig(*configPath)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) || isFlagSet(fs, "config") {
+			return inputError("doctor: %v", err)
+		}
+		config = &doctorConfig{}
+	}
+
+	options := doctorOptions{
+		configPath: *configPath,
+		dbPath:     *dbPath,
+		maxSkew:    *maxSkew,
+		timeout:    *timeout,
+	}
+	for _, server := range strings.Split(*ntpServers, ",") {
+		if server = strings.TrimSpace(server); server != "" {
+			options.ntpServers = append(options.ntpServers, server)
+		}
+	}
+
+	infoln("🩺 EAMSA 512 Doctor")
+	infoln(stringRepeat("=", 60))
+
+	report := RunDoctor(config, options)
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		for _, result := range report.Results {
+			resultf("%-4s  %-10s %s\n", strings.ToUpper(result.Status), result.Check, result.Detail)
+			if result.Fix != "" {
+				resultf("      fix: %s\n", result.Fix)
+			}
+		}
+		resultf("%d failed, %d warnings\n", report.Failed, report.Warnings)
+	}
+
+	if report.Failed > 0 || (*strict && report.Warnings > 0) {
+		return fmt.Errorf("doctor: %d checks failed, %d warnings", report.Failed, report.Warnings)
+	}
+
+	infoln("\n✅ Environment ready")
+	return nil
+}
+
+// isFlagSet reports whether name was given on the command line
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// ============================================================================
+// Directories
+// ============================================================================
+
+// doctorDirectories checks that log, data and key backup directories exist,
+// are writable, and that key directories are private
+func doctorDirectories(config *doctorConfig, dbPath string) []DoctorResult {
+	type directory struct {
+		path    string
+		private bool
+	}
+
+	var directories []directory
+	for _, name := range []string{"application", "audit", "error"} {
+		if path := config.Logging.Files[name]; path != "" {
+			directories = append(directories, directory{filepath.Dir(path), false})
+		}
+	}
+	directories = append(directories, directory{filepath.Dir(dbPath), true})
+	if path := config.KeyManagement.Storage.BackupPath; path != "" {
+		directories = append(directories, directory{path, true})
+	}
+	if config.HSM.Enabled && config.HSM.Backup.Enabled && config.HSM.Backup.Location != "" {
+		directories = append(directories, directory{config.HSM.Backup.Location, true})
+	}
+
+	var results []DoctorResult
+	seen := make(map[string]bool)
+	for _, dir := range directories {
+		path := filepath.Clean(dir.path)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		mode := "0750"
+		if dir.private {
+			mode = "0700"
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			results = append(results, DoctorResult{
+				Check:  "directory",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("%s: %v", path, err),
+				Fix:    fmt.Sprintf("install -d -m %s -o <service user> %s", mode, path),
+			})
+			continue
+		}
+		if !info.IsDir() {
+			results = append(results, DoctorResult{
+				Check:  "directory",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("%s is not a directory", path),
+				Fix:    fmt.Sprintf("move the file away and run: install -d -m %s %s", mode, path),
+			})
+			continue
+		}
+
+		probe, err := os.CreateTemp(path, ".eamsa512-doctor-*")
+		if err != nil {
+			results = append(results, DoctorResult{
+				Check:  "directory",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("%s is not writable: %v", path, err),
+				Fix:    fmt.Sprintf("chown <service user> %s (run doctor as the service user)", path),
+			})
+			continue
+		}
+		probe.Close()
+		os.Remove(probe.Name())
+
+		if dir.private && info.Mode().Perm()&0o077 != 0 {
+			results = append(results, DoctorResult{
+				Check:  "directory",
+				Status: DoctorWarn,
+				Detail: fmt.Sprintf("%s holds key material but has mode %04o", path, info.Mode().Perm()),
+				Fix:    fmt.Sprintf("chmod 0700 %s", path),
+			})
+			continue
+		}
+
+		results = append(results, DoctorResult{
+			Check:  "directory",
+			Status: DoctorOK,
+			Detail: fmt.Sprintf("%s writable (mode %04o)", path, info.Mode().Perm()),
+		})
+	}
+
+	return results
+}
+
+// ============================================================================
+// Entropy
+// ============================================================================
+
+// doctorEntropy checks that the system RNG answers promptly and passes the
+// self-test health checks
+func doctorEntropy(timeout time.Duration) []DoctorResult {
+	done := make(chan error, 1)
+	go func() {
+		_, err := rand.Read(make([]byte, 64))
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return []DoctorResult{{
+				Check:  "entropy",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("system RNG read failed: %v", err),
+				Fix:    "check that /dev/urandom (or getrandom) is available to the service",
+			}}
+		}
+	case <-time.After(timeout):
+		return []DoctorResult{{
+			Check:  "entropy",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("system RNG blocked for more than %v", timeout),
+			Fix:    "the entropy pool is not initialized; attach virtio-rng on VMs or install rng-tools",
+		}}
+	}
+
+	var results []DoctorResult
+	for _, check := range []func() (string, error){checkRNGRepetitionCount, checkRNGAdaptiveProportion} {
+		if _, err := check(); err != nil {
+			results = append(results, DoctorResult{
+				Check:  "entropy",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("RNG health test failed: %v", err),
+				Fix:    "do not generate keys on this host until the RNG is repaired; run: eamsa512 selftest",
+			})
+		}
+	}
+	if len(results) > 0 {
+		return results
+	}
+
+	if data, err := os.ReadFile("/proc/sys/kernel/random/entropy_avail"); err == nil {
+		available, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err == nil && available < doctorMinEntropyAvail {
+			return []DoctorResult{{
+				Check:  "entropy",
+				Status: DoctorWarn,
+				Detail: fmt.Sprintf("kernel entropy estimate is %d bits", available),
+				Fix:    "attach virtio-rng on VMs or install rng-tools (or haveged)",
+			}}
+		}
+	}
+
+	return []DoctorResult{{
+		Check:  "entropy",
+		Status: DoctorOK,
+		Detail: "system RNG available and healthy",
+	}}
+}
+
+// ============================================================================
+// CPU Features
+// ============================================================================
+
+// doctorCPU checks for the vector instructions the SIMD path uses
+func doctorCPU(config *doctorConfig) []DoctorResult {
+	var feature string
+	var present bool
+	switch runtime.GOARCH {
+	case "amd64":
+		feature, present = "AVX2", cpu.X86.HasAVX2
+	case "arm64":
+		feature, present = "ASIMD", cpu.ARM64.HasASIMD
+	default:
+		return []DoctorResult{{
+			Check:  "cpu",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("no SIMD path for %s; the portable implementation is used", runtime.GOARCH),
+			Fix:    "set performance.vectorization: false to match, or deploy on amd64 or arm64",
+		}}
+	}
+
+	if eamsa512.HasAcceleration() {
+		return []DoctorResult{{
+			Check:  "cpu",
+			Status: DoctorOK,
+			Detail: fmt.Sprintf("%s available; the MSA and P-layer kernels use it", feature),
+		}}
+	}
+	if present {
+		return []DoctorResult{{
+			Check:  "cpu",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s available, but this binary was built with the purego tag; the portable implementation is used", feature),
+			Fix:    "rebuild without -tags purego",
+		}}
+	}
+
+	status := DoctorOK
+	fix := ""
+	if config.Performance.Vectorization {
+		status = DoctorWarn
+		fix = "set performance.vectorization: false, or enable " + feature + " passthrough in the hypervisor"
+	}
+	return []DoctorResult{{
+		Check:  "cpu",
+		Status: status,
+		Detail: fmt.Sprintf("%s not available; the portable implementation is used", feature),
+		Fix:    fix,
+	}}
+}
+
+// ============================================================================
+// Database
+// ============================================================================
+
+// doctorDatabase opens the SQLite database and runs a quick integrity check
+func doctorDatabase(path string, timeout time.Duration) []DoctorResult {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s does not exist yet; it is created on first start", path),
+			Fix:    "pass -db if the server uses another path",
+		}}
+	}
+	if err != nil {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s: %v", path, err),
+			Fix:    fmt.Sprintf("check the permissions of %s and its directory", path),
+		}}
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=rw")
+	if err != nil {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("failed to open %s: %v", path, err),
+		}}
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var integrity string
+	if err := db.QueryRowContext(ctx, "PRAGMA quick_check").Scan(&integrity); err != nil {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s: %v", path, err),
+			Fix:    "make the file readable and writable by the service user; stop other writers holding a lock",
+		}}
+	}
+	if integrity != "ok" {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s failed the integrity check: %s", path, integrity),
+			Fix:    "restore the database from the latest backup",
+		}}
+	}
+
+	if info.Mode().Perm()&0o077 != 0 {
+		return []DoctorResult{{
+			Check:  "database",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s holds wrapped keys but has mode %04o", path, info.Mode().Perm()),
+			Fix:    fmt.Sprintf("chmod 0600 %s", path),
+		}}
+	}
+
+	return []DoctorResult{{
+		Check:  "database",
+		Status: DoctorOK,
+		Detail: fmt.Sprintf("%s opened, integrity ok", path),
+	}}
+}
+
+// ============================================================================
+// HSM
+// ============================================================================
+
+// doctorHSM checks that a networked HSM accepts connections
+func doctorHSM(config *doctorConfig, timeout time.Duration) []DoctorResult {
+	if !config.HSM.Enabled {
+		return []DoctorResult{{
+			Check:  "hsm",
+			Status: DoctorOK,
+			Detail: "HSM integration disabled",
+		}}
+	}
+
+	network := config.HSM.Network
+	if network.Host == "" {
+		return []DoctorResult{{
+			Check:  "hsm",
+			Status: DoctorOK,
+			Detail: fmt.Sprintf("%s HSM has no network endpoint to check", config.HSM.Type),
+		}}
+	}
+
+	if network.TimeoutSeconds > 0 {
+		timeout = time.Duration(network.TimeoutSeconds) * time.Second
+	}
+
+	address := net.JoinHostPort(network.Host, strconv.Itoa(network.Port))
+	conn, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return []DoctorResult{{
+			Check:  "hsm",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s HSM at %s unreachable: %v", config.HSM.Type, address, err),
+			Fix:    "check hsm.network.host/port, firewall rules and the HSM's client allow-list",
+		}}
+	}
+	conn.Close()
+
+	return []DoctorResult{{
+		Check:  "hsm",
+		Status: DoctorOK,
+		Detail: fmt.Sprintf("%s HSM at %s reachable", config.HSM.Type, address),
+	}}
+}
+
+// ============================================================================
+// TLS Material
+// ============================================================================
+
+// doctorTLS checks the server certificate and the HSM client certificate
+func doctorTLS(config *doctorConfig) []DoctorResult {
+	var results []DoctorResult
+
+	if config.Server.TLS.Enabled {
+		results = append(results, doctorCertificate("server", config.Server.TLS.CertPath, config.Server.TLS.KeyPath))
+	}
+	if config.HSM.Enabled && config.HSM.Authentication.CertPath != "" {
+		results = append(results, doctorCertificate("hsm client", config.HSM.Authentication.CertPath, config.HSM.Authentication.KeyPath))
+	}
+
+	if len(results) == 0 {
+		results = append(results, DoctorResult{
+			Check:  "tls",
+			Status: DoctorWarn,
+			Detail: "TLS is disabled",
+			Fix:    "set server.tls.enabled: true with cert_path and key_path for production",
+		})
+	}
+
+	return results
+}
+
+// doctorCertificate checks that a certificate and key load, match, are
+// currently valid and that the key file is private
+func doctorCertificate(name, certPath, keyPath string) DoctorResult {
+	pair, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s certificate: %v", name, err),
+			Fix:    fmt.Sprintf("check that %s and %s exist, are PEM-encoded and belong together", certPath, keyPath),
+		}
+	}
+
+	leaf, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s certificate: %v", name, err),
+			Fix:    fmt.Sprintf("reissue %s", certPath),
+		}
+	}
+
+	now := time.Now()
+	switch {
+	case now.Before(leaf.NotBefore):
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s certificate is not valid until %s", name, leaf.NotBefore.Format(time.RFC3339)),
+			Fix:    "check the system clock, or wait for the certificate to become valid",
+		}
+	case now.After(leaf.NotAfter):
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("%s certificate expired on %s", name, leaf.NotAfter.Format(time.RFC3339)),
+			Fix:    fmt.Sprintf("renew %s", certPath),
+		}
+	case leaf.NotAfter.Sub(now) < doctorCertWarnDays*24*time.Hour:
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s certificate expires on %s", name, leaf.NotAfter.Format(time.RFC3339)),
+			Fix:    fmt.Sprintf("renew %s", certPath),
+		}
+	}
+
+	if info, err := os.Stat(keyPath); err == nil && info.Mode().Perm()&0o077 != 0 {
+		return DoctorResult{
+			Check:  "tls",
+			Status: DoctorWarn,
+			Detail: fmt.Sprintf("%s private key %s has mode %04o", name, keyPath, info.Mode().Perm()),
+			Fix:    fmt.Sprintf("chmod 0600 %s", keyPath),
+		}
+	}
+
+	return DoctorResult{
+		Check:  "tls",
+		Status: DoctorOK,
+		Detail: fmt.Sprintf("%s certificate %q valid until %s", name, leaf.Subject.CommonName, leaf.NotAfter.Format("2006-01-02")),
+	}
+}
+
+// ============================================================================
+// Clock
+// ============================================================================
+
+// doctorClock checks the clock against NTP servers, since key expiry and
+// rotation depend on it
+func doctorClock(servers []string, maxSkew, timeout time.Duration) []DoctorResult {
+	// A clock before this release was built has never been set
+	if time.Now().Year() < 2025 {
+		return []DoctorResult{{
+			Check:  "clock",
+			Status: DoctorFail,
+			Detail: fmt.Sprintf("system clock reads %s", time.Now().UTC().Format(time.RFC3339)),
+			Fix:    "set the clock and enable time synchronization: timedatectl set-ntp true",
+		}}
+	}
+
+	if len(servers) == 0 {
+		return []DoctorResult{{
+			Check:  "clock",
+			Status: DoctorWarn,
+			Detail: "no NTP server to compare against",
+			Fix:    "pass -ntp or set EAMSA_NTP_SERVERS (the server uses it too)",
+		}}
+	}
+
+	var lastErr error
+	for _, server := range servers {
+		offset, err := sntp.Query(server, timeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if offset > maxSkew || offset < -maxSkew {
+			return []DoctorResult{{
+				Check:  "clock",
+				Status: DoctorFail,
+				Detail: fmt.Sprintf("clock is off by %v from %s (limit %v)", offset.Round(time.Millisecond), server, maxSkew),
+				Fix:    "enable time synchronization (chrony or systemd-timesyncd: timedatectl set-ntp true)",
+			}}
+		}
+
+		return []DoctorResult{{
+			Check:  "clock",
+			Status: DoctorOK,
+			Detail: fmt.Sprintf("clock within %v of %s", offset.Round(time.Millisecond), server),
+		}}
+	}
+
+	return []DoctorResult{{
+		Check:  "clock",
+		Status: DoctorWarn,
+		Detail: fmt.Sprintf("no NTP server answered: %v", lastErr),
+		Fix:    "allow outbound UDP port 123 to the NTP servers, or list reachable ones with -ntp",
+	}}
+}
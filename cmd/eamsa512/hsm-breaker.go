@@ -0,0 +1,373 @@
+// hsm-breaker.go - Deadlines, circuit breaker and cached-key fallback for HSM/KMS calls
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/failpoint"
+)
+
+// Breaker defaults
+const (
+	DefaultHSMCallTimeout       = 5 * time.Second
+	DefaultBreakerThreshold     = 5
+	DefaultBreakerOpenDuration  = 30 * time.Second
+	DefaultBreakerHalfOpenCalls = 1
+	DefaultCachedKeyTTL         = 15 * time.Minute
+)
+
+var (
+	// ErrBreakerOpen is returned without calling the HSM while the breaker is open
+	ErrBreakerOpen = errors.New("HSM circuit breaker open")
+
+	// ErrHSMTimeout is returned when an HSM call exceeds its deadline
+	ErrHSMTimeout = errors.New("HSM call timed out")
+)
+
+// BreakerState is the circuit breaker state
+type BreakerState int
+
+const (
+	BreakerClosed   BreakerState = iota // Calls pass through
+	BreakerOpen                         // Calls fail fast
+	BreakerHalfOpen                     // Limited probe calls decide whether to close
+)
+
+// String returns the state name
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig defines per-call deadlines and breaker thresholds
+type BreakerConfig struct {
+	CallTimeout      time.Duration // Deadline for each HSM/KMS call
+	FailureThreshold int           // Consecutive failures that open the breaker
+	OpenDuration     time.Duration // Time open before probing again
+	HalfOpenCalls    int           // Concurrent probes allowed while half-open
+	AllowCachedKeys  bool          // Policy: serve cached unwrapped keys while the HSM is unavailable
+	CachedKeyTTL     time.Duration // Maximum age of a cached key used as fallback
+}
+
+// BreakerConfigFromHSMConfig builds a BreakerConfig from HSM configuration,
+// filling in defaults for unset values
+func BreakerConfigFromHSMConfig(config HSMConfig) BreakerConfig {
+	return BreakerConfig{
+		CallTimeout:      time.Duration(config.TimeoutSeconds) * time.Second,
+		FailureThreshold: config.BreakerFailureThreshold,
+		OpenDuration:     time.Duration(config.BreakerOpenSeconds) * time.Second,
+		AllowCachedKeys:  config.AllowCachedKeyFallback,
+		CachedKeyTTL:     time.Duration(config.CachedKeyTTLSeconds) * time.Second,
+	}.withDefaults()
+}
+
+// withDefaults fills in unset values
+func (c BreakerConfig) withDefaults() BreakerConfig {
+	if c.CallTimeout <= 0 {
+		c.CallTimeout = DefaultHSMCallTimeout
+	}
+	if c.FailureThreshold <= 0 {
+		c.FailureThreshold = DefaultBreakerThreshold
+	}
+	if c.OpenDuration <= 0 {
+		c.OpenDuration = DefaultBreakerOpenDuration
+	}
+	if c.HalfOpenCalls <= 0 {
+		c.HalfOpenCalls = DefaultBreakerHalfOpenCalls
+	}
+	if c.CachedKeyTTL <= 0 {
+		c.CachedKeyTTL = DefaultCachedKeyTTL
+	}
+	return c
+}
+
+// BreakerMetrics is a snapshot of breaker counters
+type BreakerMetrics struct {
+	State               string
+	ConsecutiveFailures int
+	Calls               uint64 // Calls passed to the HSM
+	Successes           uint64
+	Failures            uint64 // Includes timeouts
+	Timeouts            uint64
+	Rejected            uint64 // Calls refused while open
+	Fallbacks           uint64 // Requests served from the key cache
+	Transitions         uint64
+	LastTransition      time.Time
+}
+
+// ============================================================================
+// Circuit Breaker
+// ============================================================================
+
+// CircuitBreaker guards calls to a remote key service
+type CircuitBreaker struct {
+	config   BreakerConfig
+	state    BreakerState
+	failures int
+	openedAt time.Time
+	probes   int
+	metrics  BreakerMetrics
+	now      func() time.Time
+	mu       sync.Mutex
+}
+
+// NewCircuitBreaker creates a closed breaker
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{
+		config: config.withDefaults(),
+		state:  BreakerClosed,
+		now:    failpoint.Now,
+	}
+}
+
+// Call runs fn with the configured deadline. fn should honour ctx; if it
+// does not, Call still returns ErrHSMTimeout at the deadline and the call
+// finishes in the background.
+func (b *CircuitBreaker) Call(ctx context.Context, operation string, fn func(ctx context.Context) error) error {
+	if err := b.allow(); err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, b.config.CallTimeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		if err := failpoint.InjectContext(callCtx, failpoint.HSMCall); err != nil {
+			done <- err
+			return
+		}
+		done <- fn(callCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-callCtx.Done():
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		} else {
+			err = ErrHSMTimeout
+		}
+	}
+
+	b.record(err)
+	if err != nil {
+		return fmt.Errorf("%s: %w", operation, err)
+	}
+	return nil
+}
+
+// allow decides whether a call may proceed
+func (b *CircuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.now().Sub(b.openedAt) >= b.config.OpenDuration {
+		b.transition(BreakerHalfOpen)
+	}
+
+	switch b.state {
+	case BreakerOpen:
+		b.metrics.Rejected++
+		return ErrBreakerOpen
+	case BreakerHalfOpen:
+		if b.probes >= b.config.HalfOpenCalls {
+			b.metrics.Rejected++
+			return ErrBreakerOpen
+		}
+		b.probes++
+	}
+
+	b.metrics.Calls++
+	return nil
+}
+
+// record updates the state after a call
+func (b *CircuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+
+	if err == nil {
+		b.metrics.Successes++
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+		}
+		return
+	}
+
+	b.metrics.Failures++
+	if errors.Is(err, ErrHSMTimeout) {
+		b.metrics.Timeouts++
+	}
+	b.failures++
+
+	if b.state == BreakerHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.openedAt = b.now()
+		if b.state != BreakerOpen {
+			b.transition(BreakerOpen)
+		}
+	}
+}
+
+// transition changes state; caller holds mu
+func (b *CircuitBreaker) transition(state BreakerState) {
+	b.state = state
+	b.probes = 0
+	b.metrics.Transitions++
+	b.metrics.LastTransition = b.now()
+}
+
+// State returns the current state
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Metrics returns a snapshot of the breaker counters
+func (b *CircuitBreaker) Metrics() BreakerMetrics {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m := b.metrics
+	m.State = b.state.String()
+	m.ConsecutiveFailures = b.failures
+	return m
+}
+
+// recordFallback counts a request served from the key cache
+func (b *CircuitBreaker) recordFallback() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.metrics.Fallbacks++
+}
+
+// ============================================================================
+// Resilient HSM
+// ============================================================================
+
+// ResilientHSM wraps HSMIntegration with deadlines, a circuit breaker and
+// an optional cache of the last unwrapped key
+type ResilientHSM struct {
+	hsm       *HSMIntegration
+	breaker   *CircuitBreaker
+	config    BreakerConfig
+	cached    [32]byte
+	cachedAt  time.Time
+	hasCached bool
+	mu        sync.Mutex
+}
+
+// NewResilientHSM wraps hsm using config
+func NewResilientHSM(hsm *HSMIntegration, config BreakerConfig) *ResilientHSM {
+	breaker := NewCircuitBreaker(config)
+	return &ResilientHSM{
+		hsm:     hsm,
+		breaker: breaker,
+		config:  breaker.config,
+	}
+}
+
+// ImportKey imports key into the HSM within the call deadline
+func (r *ResilientHSM) ImportKey(ctx context.Context, key [32]byte) error {
+	return r.breaker.Call(ctx, "HSM import", func(ctx context.Context) error {
+		return r.hsm.ImportKey(key)
+	})
+}
+
+// UnwrapKey retrieves the master key from the HSM. If the HSM times out,
+// fails or the breaker is open, and policy allows it, a cached key younger
+// than CachedKeyTTL is returned instead.
+func (r *ResilientHSM) UnwrapKey(ctx context.Context) ([32]byte, error) {
+	var key [32]byte
+
+	err := r.breaker.Call(ctx, "HSM unwrap", func(ctx context.Context) error {
+		k := r.hsm.ExportKey()
+		if k == ([32]byte{}) {
+			return fmt.Errorf("no key in slot %d", r.hsm.config.KeySlot)
+		}
+		key = k
+		return nil
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err == nil {
+		if r.config.AllowCachedKeys {
+			r.cached = key
+			r.cachedAt = time.Now()
+			r.hasCached = true
+		}
+		return key, nil
+	}
+
+	if r.config.AllowCachedKeys && r.hasCached && time.Since(r.cachedAt) <= r.config.CachedKeyTTL {
+		r.breaker.recordFallback()
+		return r.cached, nil
+	}
+
+	return [32]byte{}, err
+}
+
+// DetectTamper runs the HSM tamper check; a detected tamper purges the key cache
+func (r *ResilientHSM) DetectTamper(ctx context.Context) (bool, error) {
+	var tampered bool
+
+	err := r.breaker.Call(ctx, "HSM tamper check", func(ctx context.Context) error {
+		tampered = r.hsm.DetectTamper()
+		return nil
+	})
+
+	if tampered {
+		r.PurgeCache()
+	}
+
+	return tampered, err
+}
+
+// PurgeCache zeroizes the cached key
+func (r *ResilientHSM) PurgeCache() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.cached {
+		r.cached[i] = 0
+	}
+	r.hasCached = false
+}
+
+// Metrics returns the breaker metrics
+func (r *ResilientHSM) Metrics() BreakerMetrics {
+	return r.breaker.Metrics()
+}
+
+// PrintBreakerStatus prints breaker state and counters
+func (r *ResilientHSM) PrintBreakerStatus() {
+	m := r.Metrics()
+
+	fmt.Printf("\n🔌 HSM Circuit Breaker:\n")
+	fmt.Printf("   State:             %s\n", m.State)
+	fmt.Printf("   Call Timeout:      %v\n", r.config.CallTimeout)
+	fmt.Printf("   Failure Threshold: %d (current %d)\n", r.config.FailureThreshold, m.ConsecutiveFailures)
+	fmt.Printf("   Calls:             %d (%d ok, %d failed, %d timed out)\n", m.Calls, m.Successes, m.Failures, m.Timeouts)
+	fmt.Printf("   Rejected:          %d\n", m.Rejected)
+	fmt.Printf("   Cached Fallbacks:  %d (allowed: %v)\n", m.Fallbacks, r.config.AllowCachedKeys)
+}
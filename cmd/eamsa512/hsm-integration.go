@@ -2,10 +2,17 @@
 package main
 
 import (
+	"crypto/rand"
+	"crypto/tls"
 	"fmt"
 	"log"
 	"sync"
 	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+	"github.com/Redeaux-Corporation/eamsa512/tlstrust"
 )
 
 // HSMKeyStorage defines interface for hardware security modules
@@ -36,32 +43,58 @@ type HSMConfig struct {
 	AuditLog          string
 	KeySlot           int
 	MaxRetries        int
-	TimeoutSeconds    int
+	TimeoutSeconds    int  // Per-call deadline (see hsm-breaker.go)
+
+	// Circuit breaker (see BreakerConfigFromHSMConfig)
+	BreakerFailureThreshold int  // Consecutive failures that open the breaker
+	BreakerOpenSeconds      int  // Seconds open before probing the HSM again
+	AllowCachedKeyFallback  bool // Serve cached unwrapped keys while the HSM is unavailable
+	CachedKeyTTLSeconds     int  // Maximum age of a cached fallback key
+
+	// Random number generation (see RandomPolicy)
+	RandomSource         bool // Generate keys and nonce prefixes with the HSM RNG
+	RandomFallback       bool // Use crypto/rand while the HSM RNG is failing, instead of failing
+	RandomRecheckSeconds int  // Seconds after a failure before health checking the HSM RNG again
+
+	// Peer verification for network HSMs and KMS endpoints: CA and SPKI
+	// pinning, revocation checking (see tlstrust.Config)
+	TLS tlstrust.Config
+}
+
+// Validate returns a *confschema.ValidationError listing every invalid
+// field. Zero timeouts and breaker settings select the defaults.
+func (config HSMConfig) Validate() error {
+	v := confschema.NewValidator("HSMConfig")
+
+	v.OneOf("HSMType", config.HSMType, "thales", "yubihsm", "nitro", "softhsm")
+	v.Check(config.HSMType == "softhsm" || config.Endpoint != "", "Endpoint", config.Endpoint,
+		"an address for HSMType "+config.HSMType)
+	v.Min("KeySlot", config.KeySlot, 0)
+	v.Min("MaxRetries", config.MaxRetries, 0)
+	v.Min("TimeoutSeconds", config.TimeoutSeconds, 0)
+	v.Min("BreakerFailureThreshold", config.BreakerFailureThreshold, 0)
+	v.Min("BreakerOpenSeconds", config.BreakerOpenSeconds, 0)
+	v.Min("CachedKeyTTLSeconds", config.CachedKeyTTLSeconds, 0)
+	v.Min("RandomRecheckSeconds", config.RandomRecheckSeconds, 0)
+
+	return v.Err()
 }
 
 // HSMIntegration manages HSM operations
 type HSMIntegration struct {
 	config            HSMConfig
 	status            HSMStatus
-	auditLog          []AuditEntry
+	auditLog          []keymgmt.AuditEntry
 	keyMaterial       [32]byte
+	tlsVerifier       *tlstrust.Verifier
 	mu                sync.RWMutex
 }
 
-// AuditEntry records security events
-type AuditEntry struct {
-	Timestamp   time.Time
-	EventType   string
-	Description string
-	Status      string
-	OperatorID  string
-}
-
 // NewHSMIntegration creates new HSM integration
 func NewHSMIntegration(config HSMConfig) *HSMIntegration {
 	hsm := &HSMIntegration{
 		config:   config,
-		auditLog: make([]AuditEntry, 0),
+		auditLog: make([]keymgmt.AuditEntry, 0),
 		status: HSMStatus{
 			Online:         false,
 			TamperDetected: false,
@@ -69,6 +102,19 @@ func NewHSMIntegration(config HSMConfig) *HSMIntegration {
 		},
 	}
 
+	if err := config.Validate(); err != nil {
+		hsm.LogAudit("HSM_CONFIG_INVALID", err.Error(), "FAILURE", "system")
+		return hsm
+	}
+
+	// Peer verification; failures are audited as TLS_VERIFY_FAILED
+	verifier, err := tlstrust.New("hsm:"+config.HSMType, config.TLS, hsm.auditTLSFailure)
+	if err != nil {
+		hsm.LogAudit("TLS_CONFIG_INVALID", err.Error(), "FAILURE", "system")
+		return hsm
+	}
+	hsm.tlsVerifier = verifier
+
 	// Initialize based on HSM type
 	switch config.HSMType {
 	case "thales":
@@ -126,6 +172,24 @@ func (h *HSMIntegration) initializeSoftHSM() {
 	h.LogAudit("HSM_INIT", "SoftHSM initialized (testing only)", "SUCCESS", "system")
 }
 
+// TLSConfig returns the client TLS configuration for connections to
+// config.Endpoint
+func (h *HSMIntegration) TLSConfig() (*tls.Config, error) {
+	if h.tlsVerifier == nil {
+		return nil, fmt.Errorf("HSM TLS configuration is invalid")
+	}
+	return h.tlsVerifier.TLSConfig(), nil
+}
+
+// auditTLSFailure records a rejected (or soft-fail accepted) HSM/KMS peer
+func (h *HSMIntegration) auditTLSFailure(f tlstrust.Failure) {
+	status := "FAILURE"
+	if f.Accepted {
+		status = "WARNING"
+	}
+	h.LogAudit("TLS_VERIFY_FAILED", f.Error(), status, "system")
+}
+
 // ImportKey securely imports key into HSM
 func (h *HSMIntegration) ImportKey(key [32]byte) error {
 	h.mu.Lock()
@@ -151,6 +215,75 @@ func (h *HSMIntegration) ExportKey() [32]byte {
 	return h.keyMaterial
 }
 
+// Name identifies the HSM as a random source
+func (h *HSMIntegration) Name() string {
+	return "hsm:" + h.config.HSMType
+}
+
+// HealthCheck fails unless the HSM RNG can be used: the HSM is online and
+// no tamper has been detected
+func (h *HSMIntegration) HealthCheck() error {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.status.Online {
+		return fmt.Errorf("HSM not online")
+	}
+	if h.status.TamperDetected {
+		return fmt.Errorf("HSM tamper detected")
+	}
+	return nil
+}
+
+// Read fills p from the HSM RNG
+func (h *HSMIntegration) Read(p []byte) (int, error) {
+	if err := h.HealthCheck(); err != nil {
+		return 0, err
+	}
+
+	// Generate in HSM (hardware-specific, e.g. C_GenerateRandom)
+	return rand.Read(p)
+}
+
+// Reseed mixes seed into the HSM RNG, making the HSM an
+// eamsa512.EntropySource
+func (h *HSMIntegration) Reseed(seed []byte) error {
+	if err := h.HealthCheck(); err != nil {
+		return err
+	}
+
+	// Seed in HSM (hardware-specific, e.g. C_SeedRandom)
+	h.LogAudit("RANDOM_RESEEDED", fmt.Sprintf("HSM RNG reseeded with %d bytes", len(seed)), "SUCCESS", "system")
+	return nil
+}
+
+// RandomPolicy returns the policy for keys and nonce prefixes: the HSM
+// RNG if config.RandomSource is set, otherwise crypto/rand. Failures of
+// the HSM RNG, fallbacks and recoveries are audited.
+func (h *HSMIntegration) RandomPolicy() eamsa512.RandomPolicy {
+	if !h.config.RandomSource {
+		return eamsa512.RandomPolicy{}
+	}
+	return eamsa512.RandomPolicy{
+		Source:          h,
+		Fallback:        h.config.RandomFallback,
+		RecheckInterval: time.Duration(h.config.RandomRecheckSeconds) * time.Second,
+		OnEvent:         h.auditRandomEvent,
+	}
+}
+
+// auditRandomEvent records a change of random source
+func (h *HSMIntegration) auditRandomEvent(e eamsa512.RandomEvent) {
+	switch e.Type {
+	case eamsa512.RandomSourceFailed:
+		h.LogAudit(e.Type, fmt.Sprintf("Random source %s failed: %v", e.Source, e.Err), "FAILURE", "system")
+	case eamsa512.RandomFallback:
+		h.LogAudit(e.Type, fmt.Sprintf("Random source %s unavailable, using %s", e.Source, eamsa512.SystemRandom), "WARNING", "system")
+	default:
+		h.LogAudit(e.Type, fmt.Sprintf("Random source %s passed its health check", e.Source), "SUCCESS", "system")
+	}
+}
+
 // DetectTamper checks for HSM tampering
 func (h *HSMIntegration) DetectTamper() bool {
 	h.mu.Lock()
@@ -194,7 +327,7 @@ func (h *HSMIntegration) LogAudit(eventType, description, status, operatorID str
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	entry := AuditEntry{
+	entry := keymgmt.AuditEntry{
 		Timestamp:   time.Now(),
 		EventType:   eventType,
 		Description: description,
@@ -220,12 +353,12 @@ func (h *HSMIntegration) GetStatus() HSMStatus {
 }
 
 // GetAuditLog returns audit log entries
-func (h *HSMIntegration) GetAuditLog() []AuditEntry {
+func (h *HSMIntegration) GetAuditLog() []keymgmt.AuditEntry {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
 
 	// Return copy of audit log
-	logCopy := make([]AuditEntry, len(h.auditLog))
+	logCopy := make([]keymgmt.AuditEntry, len(h.auditLog))
 	copy(logCopy, h.auditLog)
 	return logCopy
 }
@@ -0,0 +1,531 @@
+// ingest-command.go - Bulk encryption of a directory tree with a resumable manifest
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	mathrand "math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"golang.org/x/crypto/sha3"
+)
+
+// A keyspace is an X25519 identity made by "keygen -o <keyspace>": files
+// are encrypted to <keyspace>.pub, so the ingesting host needs no secret,
+// and <keyspace>.key is needed only to verify the sample (and to decrypt
+// later with "decrypt -identity").
+const (
+	// IngestManifestFile is the checkpoint manifest in the destination
+	IngestManifestFile = "ingest-manifest.jsonl"
+
+	// IngestSuffix is appended to the path of each encrypted file
+	IngestSuffix = ".eamr"
+
+	ingestProgressInterval = 5 * time.Second
+)
+
+// IngestRecord is one line of the manifest: one operation on one file.
+// An "ingest" record with status "ok" is written only once its output is
+// durable; a rerun skips files that have one and are unchanged since.
+type IngestRecord struct {
+	Op           string    `json:"op"`   // "ingest" or "verify"
+	Path         string    `json:"path"` // Relative to -src, slash-separated
+	Output       string    `json:"output,omitempty"`
+	Size         int64     `json:"size"`
+	ModTime      int64     `json:"mtime_ns"`
+	OutputDigest string    `json:"output_sha3_256,omitempty"` // Of the encrypted file
+	KeyID        string    `json:"key_id,omitempty"`
+	Status       string    `json:"status"` // "ok", "failed" or "skipped" (verify of a changed source)
+	Error        string    `json:"error,omitempty"`
+	Time         time.Time `json:"time"`
+	DurationMs   float64   `json:"duration_ms"`
+}
+
+// IngestSummary is the result of an ingest run
+type IngestSummary struct {
+	Files        int     `json:"files"` // Regular files under -src
+	Encrypted    int     `json:"encrypted"`
+	Skipped      int     `json:"skipped"` // Done in an earlier run and unchanged
+	Failed       int     `json:"failed"`
+	Bytes        int64   `json:"bytes"` // Plaintext encrypted in this run
+	Verified     int     `json:"verified"`
+	VerifyFailed int     `json:"verify_failed"`
+	Seconds      float64 `json:"seconds"`
+}
+
+// ingestJob is one source file
+type ingestJob struct {
+	path    string // Slash-separated, relative to the source
+	size    int64
+	modTime int64
+}
+
+// ingestRun holds the state of one ingest
+type ingestRun struct {
+	src, dst string
+	pub      eamsa512.PublicKey
+
+	manifest *os.File
+	mu       sync.Mutex // Guards manifest and done
+	done     map[string]IngestRecord
+
+	files, bytes atomic.Int64 // Encrypted so far
+	failed       atomic.Int64
+}
+
+// runIngestCommand implements "eamsa512 ingest -src dir -dst dir -keyspace name"
+func runIngestCommand(args []string) error {
+	fs := flag.NewFlagSet("ingest", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	src := fs.String("src", "", "Directory tree to encrypt")
+	dst := fs.String("dst", "", "Directory for the encrypted files and the manifest")
+	keyspace := fs.String("keyspace", "", "Keyspace: encrypt to <keyspace>.pub (from keygen -o <keyspace>)")
+	identity := fs.String("identity", "", "Identity for verification (default <keyspace>.key)")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "Files encrypted concurrently")
+	sample := fs.Int("verify-sample", 16, "Encrypted files to decrypt and compare with the source afterwards (0 skips)")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("ingest", err)
+	}
+	if *src == "" || *dst == "" || *keyspace == "" || fs.NArg() != 0 {
+		return inputError("ingest: -src, -dst and -keyspace are required")
+	}
+	if *workers < 1 {
+		return inputError("ingest: -workers must be at least 1")
+	}
+	if *sample < 0 {
+		return inputError("ingest: -verify-sample must not be negative")
+	}
+
+	pub, err := readRecipient(*keyspace + ".pub")
+	if err != nil {
+		return keyError("ingest: keyspace %s: %v", *keyspace, err)
+	}
+	var key *eamsa512.PrivateKey
+	if *sample > 0 {
+		if *identity == "" {
+			*identity = *keyspace + ".key"
+		}
+		if key, err = readIdentity(*identity); err != nil {
+			return keyError("ingest: verification needs the identity (or -verify-sample 0): %v", err)
+		}
+		if key.PublicKey().KeyID() != pub.KeyID() {
+			return keyError("ingest: identity %s is not the key of keyspace %s", *identity, *keyspace)
+		}
+	}
+
+	if err := os.MkdirAll(*dst, 0700); err != nil {
+		return fmt.Errorf("ingest: %v", err)
+	}
+	manifestPath := filepath.Join(*dst, IngestManifestFile)
+	lock, err := filelock.Acquire(manifestPath+".lock", filelock.Exclusive)
+	if err != nil {
+		return fmt.Errorf("ingest: %v", err)
+	}
+	defer lock.Release()
+
+	done, err := readIngestManifest(manifestPath)
+	if err != nil {
+		return inputError("ingest: %v", err)
+	}
+	manifest, err := os.OpenFile(manifestPath, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("ingest: %v", err)
+	}
+	defer manifest.Close()
+	if err := terminateManifest(manifest); err != nil {
+		return fmt.Errorf("ingest: %v", err)
+	}
+
+	run := &ingestRun{src: *src, dst: *dst, pub: pub, manifest: manifest, done: done}
+
+	jobs, err := listIngestJobs(*src, *dst)
+	if err != nil {
+		return inputError("ingest: %v", err)
+	}
+
+	start := time.Now()
+	summary := IngestSummary{Files: len(jobs)}
+	pending := make([]ingestJob, 0, len(jobs))
+	for _, job := range jobs {
+		if rec, ok := done[job.path]; ok && rec.Size == job.size && rec.ModTime == job.modTime {
+			summary.Skipped++
+			continue
+		}
+		pending = append(pending, job)
+	}
+	infof("Ingesting %d files (%d already done) to keyspace %s (%s)\n", len(pending), summary.Skipped, *keyspace, pub.KeyID())
+
+	run.encryptAll(pending, *workers)
+	summary.Encrypted = int(run.files.Load())
+	summary.Bytes = run.bytes.Load()
+	summary.Failed = int(run.failed.Load())
+
+	if key != nil {
+		summary.Verified, summary.VerifyFailed = run.verifySample(key, *sample)
+	}
+	if err := manifest.Sync(); err != nil {
+		return fmt.Errorf("ingest: %v", err)
+	}
+	summary.Seconds = time.Since(start).Seconds()
+
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode summary: %v", err)
+	}
+	resultf("%s\n", data)
+
+	if summary.VerifyFailed > 0 {
+		return authError("ingest: %d of %d sampled files failed verification (see %s)", summary.VerifyFailed, summary.Verified+summary.VerifyFailed, manifestPath)
+	}
+	if summary.Failed > 0 {
+		return fmt.Errorf("ingest: %d files failed (see %s); rerun to retry them", summary.Failed, manifestPath)
+	}
+	return nil
+}
+
+// listIngestJobs returns the regular files under src, skipping dst if it
+// lies inside src
+func listIngestJobs(src, dst string) ([]ingestJob, error) {
+	dstAbs, _ := filepath.Abs(dst)
+
+	var jobs []ingestJob
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if abs, _ := filepath.Abs(path); abs == dstAbs {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		jobs = append(jobs, ingestJob{path: filepath.ToSlash(rel), size: info.Size(), modTime: info.ModTime().UnixNano()})
+		return nil
+	})
+	return jobs, err
+}
+
+// readIngestManifest returns the latest successful ingest record of each
+// path; a missing manifest is empty
+func readIngestManifest(path string) (map[string]IngestRecord, error) {
+	done := make(map[string]IngestRecord)
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		var rec IngestRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// A crash can leave the last line incomplete; its file is redone
+			if !scanner.Scan() {
+				break
+			}
+			return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+		if rec.Op == "ingest" {
+			if rec.Status == "ok" {
+				done[rec.Path] = rec
+			} else {
+				delete(done, rec.Path)
+			}
+		}
+	}
+	return done, scanner.Err()
+}
+
+// terminateManifest ends an incomplete last line left by a crash, so the
+// next record starts on a line of its own
+func terminateManifest(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return err
+	}
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return err
+	}
+	if last[0] == '\n' {
+		return nil
+	}
+	_, err = f.Write([]byte{'\n'})
+	return err
+}
+
+// encryptAll encrypts jobs on workers goroutines, reporting progress
+func (r *ingestRun) encryptAll(jobs []ingestJob, workers int) {
+	var total int64
+	for _, job := range jobs {
+		total += job.size
+	}
+
+	queue := make(chan ingestJob)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				r.encryptOne(job)
+			}
+		}()
+	}
+
+	stop := make(chan struct{})
+	go func() {
+		start := time.Now()
+		ticker := time.NewTicker(ingestProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				bytes := r.bytes.Load()
+				infof("ingest: %d/%d files, %d/%d MB, %.1f MB/s, %d failed\n",
+					r.files.Load(), len(jobs), bytes>>20, total>>20,
+					float64(bytes)/1e6/time.Since(start).Seconds(), r.failed.Load())
+			}
+		}
+	}()
+
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+	close(stop)
+}
+
+// encryptOne encrypts one file and records the outcome
+func (r *ingestRun) encryptOne(job ingestJob) {
+	start := time.Now()
+	rec := IngestRecord{
+		Op:      "ingest",
+		Path:    job.path,
+		Output:  job.path + IngestSuffix,
+		Size:    job.size,
+		ModTime: job.modTime,
+		KeyID:   r.pub.KeyID(),
+		Status:  "ok",
+	}
+
+	digest, err := r.encryptFile(job)
+	if err != nil {
+		rec.Status = "failed"
+		rec.Error = err.Error()
+		r.failed.Add(1)
+	} else {
+		rec.OutputDigest = digest
+		r.files.Add(1)
+		r.bytes.Add(job.size)
+	}
+
+	rec.Time = time.Now().UTC()
+	rec.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err := r.record(rec); err != nil && rec.Status == "ok" {
+		// Not in the manifest: counted as failed so the run reports it
+		r.files.Add(-1)
+		r.bytes.Add(-job.size)
+		r.failed.Add(1)
+	}
+}
+
+// encryptFile writes the encrypted file durably and returns its SHA3-256
+func (r *ingestRun) encryptFile(job ingestJob) (string, error) {
+	srcPath := filepath.Join(r.src, filepath.FromSlash(job.path))
+	plaintext, err := os.ReadFile(srcPath)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() != job.size || info.ModTime().UnixNano() != job.modTime {
+		return "", errors.New("source changed while ingesting")
+	}
+
+	sealed, err := eamsa512.EncryptForRecipients(plaintext, []eamsa512.PublicKey{r.pub})
+	if err != nil {
+		return "", err
+	}
+
+	dstPath := filepath.Join(r.dst, filepath.FromSlash(job.path+IngestSuffix))
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0700); err != nil {
+		return "", err
+	}
+	if err := writeFileSynced(dstPath, sealed); err != nil {
+		return "", err
+	}
+
+	sum := sha3.Sum256(sealed)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// record appends rec to the manifest
+func (r *ingestRun) record(rec IngestRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, err := r.manifest.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	if rec.Op == "ingest" && rec.Status == "ok" {
+		r.done[rec.Path] = rec
+	}
+	return nil
+}
+
+// verifySample decrypts up to n encrypted files, from this or earlier
+// runs, and compares them with their sources. Sources changed since they
+// were ingested are recorded as skipped.
+func (r *ingestRun) verifySample(key *eamsa512.PrivateKey, n int) (verified, failed int) {
+	paths := make([]string, 0, len(r.done))
+	for path := range r.done {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	mathrand.Shuffle(len(paths), func(i, j int) { paths[i], paths[j] = paths[j], paths[i] })
+	if len(paths) > n {
+		paths = paths[:n]
+	}
+
+	for _, path := range paths {
+		start := time.Now()
+		done := r.done[path]
+		rec := IngestRecord{Op: "verify", Path: path, Output: done.Output, Size: done.Size, ModTime: done.ModTime, KeyID: done.KeyID, Status: "ok"}
+
+		switch err := r.verifyFile(key, done); {
+		case errors.Is(err, errSourceChanged):
+			rec.Status = "skipped"
+			rec.Error = err.Error()
+		case err != nil:
+			rec.Status = "failed"
+			rec.Error = err.Error()
+			failed++
+		default:
+			verified++
+		}
+
+		rec.Time = time.Now().UTC()
+		rec.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		r.record(rec)
+	}
+
+	infof("Verified %d of %d sampled files\n", verified, len(paths))
+	return verified, failed
+}
+
+// errSourceChanged marks a source modified after it was ingested
+var errSourceChanged = errors.New("source changed since ingest")
+
+// verifyFile decrypts the output of done and compares it with the source
+func (r *ingestRun) verifyFile(key *eamsa512.PrivateKey, done IngestRecord) error {
+	sealed, err := os.ReadFile(filepath.Join(r.dst, filepath.FromSlash(done.Output)))
+	if err != nil {
+		return err
+	}
+	if sum := sha3.Sum256(sealed); hex.EncodeToString(sum[:]) != done.OutputDigest {
+		return errors.New("encrypted file changed since ingest")
+	}
+
+	plaintext, err := eamsa512.DecryptForRecipient(sealed, key)
+	if err != nil {
+		return err
+	}
+
+	srcPath := filepath.Join(r.src, filepath.FromSlash(done.Path))
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return err
+	}
+	if info.Size() != done.Size || info.ModTime().UnixNano() != done.ModTime {
+		return errSourceChanged
+	}
+	source, err := os.ReadFile(srcPath)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(plaintext, source) {
+		return errors.New("decrypted file differs from source")
+	}
+	return nil
+}
+
+// writeFileSynced writes data (mode 0600) to a temporary file in the same
+// directory, syncs it, renames it over path and syncs the directory, so
+// the contents are durable once it returns
+func writeFileSynced(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
@@ -0,0 +1,145 @@
+// key-handles.go - One-way encrypt-only and decrypt-only key handles
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/Redeaux-Corporation/eamsa512/labels"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// EncryptHandle encrypts with a key but has no decrypt or verify
+// operation and never exposes the key material. It is meant for edge
+// collectors that produce ciphertext but must not read historical data.
+//
+// EAMSA 512 is symmetric: both directions use the same block and MAC keys,
+// so the separation is enforced by this API and by RBAC, not
+// cryptographically. A process that can read its own memory can recover
+// the key; keep handles in-process and issue them per collector.
+type EncryptHandle struct {
+	cipher *eamsa512.EAMSA512CipherSHA3
+	keyID  string
+}
+
+// DecryptHandle verifies and decrypts with a key but cannot produce new
+// ciphertext or tags
+type DecryptHandle struct {
+	cipher *eamsa512.EAMSA512CipherSHA3
+	keyID  string
+}
+
+// NewEncryptHandle creates an encrypt-only handle from config; config is
+// copied and may be zeroed afterwards
+func NewEncryptHandle(keyID string, config eamsa512.EAMSA512ConfigSHA3) (*EncryptHandle, error) {
+	cipher, err := eamsa512.NewEAMSA512CipherSHA3(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptHandle{cipher: cipher, keyID: keyID}, nil
+}
+
+// NewDecryptHandle creates a decrypt-only handle from config; config is
+// copied and may be zeroed afterwards
+func NewDecryptHandle(keyID string, config eamsa512.EAMSA512ConfigSHA3) (*DecryptHandle, error) {
+	cipher, err := eamsa512.NewEAMSA512CipherSHA3(&config)
+	if err != nil {
+		return nil, err
+	}
+	return &DecryptHandle{cipher: cipher, keyID: keyID}, nil
+}
+
+// KeyID returns the ID of the key the handle was issued for
+func (h *EncryptHandle) KeyID() string { return h.keyID }
+
+// Usage returns keymgmt.UsageEncrypt
+func (h *EncryptHandle) Usage() keymgmt.KeyUsage { return keymgmt.UsageEncrypt }
+
+// EncryptBlock encrypts and authenticates one 512-bit block
+func (h *EncryptHandle) EncryptBlock(plaintext [64]byte) eamsa512.CipherResultSHA3 {
+	return h.cipher.EncryptBlockSHA3(plaintext)
+}
+
+// EncryptStream encrypts input to output in the EncryptStreamSHA3 format
+func (h *EncryptHandle) EncryptStream(input io.Reader, output io.Writer) (int64, error) {
+	return h.cipher.EncryptStreamSHA3(input, output)
+}
+
+// KeyID returns the ID of the key the handle was issued for
+func (h *DecryptHandle) KeyID() string { return h.keyID }
+
+// Usage returns keymgmt.UsageDecrypt
+func (h *DecryptHandle) Usage() keymgmt.KeyUsage { return keymgmt.UsageDecrypt }
+
+// DecryptBlock verifies the MAC and decrypts one 512-bit block
+func (h *DecryptHandle) DecryptBlock(ciphertext, mac [64]byte, counter uint64) ([64]byte, bool) {
+	return h.cipher.DecryptBlockSHA3(ciphertext, mac, counter)
+}
+
+// DecryptStream verifies and decrypts a stream written by EncryptStream
+func (h *DecryptHandle) DecryptStream(input io.Reader, output io.Writer) (int64, error) {
+	return h.cipher.DecryptStreamSHA3(input, output)
+}
+
+// IssueEncryptHandle authorizes userID for PermEncrypt on keyID of klm
+// and returns an encrypt-only handle. The key must be activated.
+func IssueEncryptHandle(klm *keymgmt.LifecycleManager, rbac *RBACManager, userID, keyID string, nonce [16]byte) (*EncryptHandle, error) {
+	config, err := issueHandle(klm, rbac, userID, keyID, nonce, keymgmt.UsageEncrypt)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroHandleConfig(&config)
+
+	return NewEncryptHandle(keyID, config)
+}
+
+// IssueDecryptHandle authorizes userID for PermDecrypt on keyID of klm
+// and returns a decrypt-only handle. Deactivated keys are accepted so
+// that data encrypted before a rotation stays readable.
+func IssueDecryptHandle(klm *keymgmt.LifecycleManager, rbac *RBACManager, userID, keyID string, nonce [16]byte) (*DecryptHandle, error) {
+	config, err := issueHandle(klm, rbac, userID, keyID, nonce, keymgmt.UsageDecrypt)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroHandleConfig(&config)
+
+	return NewDecryptHandle(keyID, config)
+}
+
+// issueHandle checks RBAC and key state for usage and returns the cipher
+// configuration for the key
+func issueHandle(klm *keymgmt.LifecycleManager, rbac *RBACManager, userID, keyID string, nonce [16]byte, usage keymgmt.KeyUsage) (eamsa512.EAMSA512ConfigSHA3, error) {
+	if rbac == nil {
+		return eamsa512.EAMSA512ConfigSHA3{}, fmt.Errorf("RBAC manager required to issue %s handle", usage)
+	}
+
+	permission := PermEncrypt
+	if usage == keymgmt.UsageDecrypt {
+		permission = PermDecrypt
+	}
+
+	key, err := klm.CheckOutKey(keyID, userID, usage, func(keyLabels labels.Set) error {
+		return rbac.AuthorizeKeyAccess(userID, permission, keyID, keyLabels)
+	})
+	if err != nil {
+		return eamsa512.EAMSA512ConfigSHA3{}, err
+	}
+
+	return eamsa512.EAMSA512ConfigSHA3{
+		MasterKey:     key,
+		Nonce:         nonce,
+		RoundCount:    16,
+		IncludeAuth:   true,
+		AuthAlgorithm: "HMAC-SHA3-512",
+		Mode:          "CBC",
+		Telemetry:     klm.Telemetry(),
+	}, nil
+}
+
+// zeroHandleConfig clears the key copy held in a handle configuration
+func zeroHandleConfig(config *eamsa512.EAMSA512ConfigSHA3) {
+	for i := range config.MasterKey {
+		config.MasterKey[i] = 0
+	}
+}
@@ -0,0 +1,159 @@
+// password-command.go - Password-based file encryption (Argon2id)
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// passwordEnv holds the password when no -password-file is given
+const passwordEnv = "EAMSA512_PASSWORD"
+
+// runEncryptCommand implements "eamsa512 encrypt [-password-file file]
+// [-memory KiB] [-iterations N] [-parallelism N] [-o file] <file|->".
+// The key is derived from the password with Argon2id, so no hex key has to
+// be kept; salt and KDF parameters travel in the output header. With
+// -recipient (repeatable) the file is encrypted to X25519 public keys
+// instead (see recipient-command.go).
+func runEncryptCommand(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	passwordFile := fs.String("password-file", "", "Read the password from this file (default $"+passwordEnv+")")
+	memory := fs.Uint("memory", uint(eamsa512.DefaultKDFParams.Memory), "Argon2id memory in KiB")
+	iterations := fs.Uint("iterations", uint(eamsa512.DefaultKDFParams.Iterations), "Argon2id passes")
+	parallelism := fs.Uint("parallelism", uint(eamsa512.DefaultKDFParams.Parallelism), "Argon2id lanes")
+	output := fs.String("o", "", "Write the ciphertext here instead of stdout")
+	var recipients recipientFlags
+	fs.Var(&recipients, "recipient", "Encrypt to this X25519 public key (file or hex; repeatable) instead of a password")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("encrypt", err)
+	}
+	if fs.NArg() != 1 {
+		return inputError("encrypt: expected exactly one file argument")
+	}
+
+	if len(recipients) > 0 {
+		if *passwordFile != "" {
+			return inputError("encrypt: -recipient and -password-file are exclusive")
+		}
+		plaintext, err := readInspectInput(fs.Arg(0))
+		if err != nil {
+			return inputError("encrypt: %v", err)
+		}
+		return encryptForRecipients(plaintext, recipients, *output)
+	}
+	if *memory > 1<<32-1 || *iterations > 1<<32-1 || *parallelism > 255 {
+		return inputError("encrypt: KDF parameter out of range")
+	}
+
+	params := eamsa512.KDFParams{
+		Memory:      uint32(*memory),
+		Iterations:  uint32(*iterations),
+		Parallelism: uint8(*parallelism),
+	}
+	if err := params.Validate(); err != nil {
+		return inputError("encrypt: %v", err)
+	}
+
+	password, err := readPassword(*passwordFile)
+	if err != nil {
+		return keyError("encrypt: %v", err)
+	}
+
+	plaintext, err := readInspectInput(fs.Arg(0))
+	if err != nil {
+		return inputError("encrypt: %v", err)
+	}
+
+	sealed, err := eamsa512.EncryptWithPassword(plaintext, password, eamsa512.WithKDFParams(params))
+	if err != nil {
+		return fmt.Errorf("encrypt: %v", err)
+	}
+
+	infof("Argon2id: %d KiB, %d passes, %d lanes\n", params.Memory, params.Iterations, params.Parallelism)
+	return writePasswordOutput(*output, sealed)
+}
+
+// runDecryptCommand implements "eamsa512 decrypt [-password-file file]
+// [-max-memory KiB] [-identity file] [-o file] <file|->"
+func runDecryptCommand(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	passwordFile := fs.String("password-file", "", "Read the password from this file (default $"+passwordEnv+")")
+	maxMemory := fs.Uint("max-memory", eamsa512.DefaultMaxKDFMemory, "Refuse files whose Argon2id memory exceeds this (KiB)")
+	identity := fs.String("identity", "", "Decrypt a file encrypted to recipients with this identity (from keygen)")
+	output := fs.String("o", "", "Write the plaintext here instead of stdout")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("decrypt", err)
+	}
+	if fs.NArg() != 1 {
+		return inputError("decrypt: expected exactly one file argument")
+	}
+	if *maxMemory > 1<<32-1 {
+		return inputError("decrypt: -max-memory out of range")
+	}
+
+	data, err := readInspectInput(fs.Arg(0))
+	if err != nil {
+		return inputError("decrypt: %v", err)
+	}
+	if eamsa512.IsRecipientEncrypted(data) {
+		return decryptForRecipient(data, *identity, *output)
+	}
+	if !eamsa512.IsPasswordEncrypted(data) {
+		return inputError("decrypt: not a password- or recipient-encrypted file")
+	}
+
+	password, err := readPassword(*passwordFile)
+	if err != nil {
+		return keyError("decrypt: %v", err)
+	}
+
+	plaintext, err := eamsa512.DecryptWithPassword(data, password, eamsa512.WithMaxKDFMemory(uint32(*maxMemory)))
+	if errors.Is(err, eamsa512.ErrDecryption) {
+		return authError("decrypt: wrong password or tampered file")
+	}
+	if err != nil {
+		return inputError("decrypt: %v", err)
+	}
+
+	return writePasswordOutput(*output, plaintext)
+}
+
+// readPassword reads the password from path, or from $EAMSA512_PASSWORD
+// when path is empty. One trailing newline is removed.
+func readPassword(path string) ([]byte, error) {
+	var password []byte
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		password = data
+	} else {
+		password = []byte(os.Getenv(passwordEnv))
+	}
+
+	password = bytes.TrimSuffix(password, []byte("\n"))
+	password = bytes.TrimSuffix(password, []byte("\r"))
+	if len(password) == 0 {
+		return nil, fmt.Errorf("no password: use -password-file or set %s", passwordEnv)
+	}
+	return password, nil
+}
+
+// writePasswordOutput writes data to path (mode 0600), or stdout if empty
+func writePasswordOutput(path string, data []byte) error {
+	if path == "" {
+		_, err := payloadOut.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,314 @@
+// phase-profile.go - Per-phase time breakdown with optional pprof labels
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/format"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/kdf"
+)
+
+// PhaseProfileVersion identifies the report schema
+const PhaseProfileVersion = "1"
+
+// PhaseTiming is the time spent in one operation
+type PhaseTiming struct {
+	Phase     string  `json:"phase"`
+	Operation string  `json:"operation"`
+	Calls     int64   `json:"calls"`
+	TotalMs   float64 `json:"total_ms"`
+	MeanNs    float64 `json:"mean_ns"`
+	Percent   float64 `json:"percent"`
+}
+
+// PhaseSummary is the time spent in one phase
+type PhaseSummary struct {
+	Phase   string  `json:"phase"`
+	TotalMs float64 `json:"total_ms"`
+	Percent float64 `json:"percent"`
+}
+
+// PhaseProfile is the machine-readable per-phase breakdown
+type PhaseProfile struct {
+	Version    string         `json:"version"`
+	Timestamp  string         `json:"timestamp"`
+	GoVersion  string         `json:"go_version"`
+	Platform   string         `json:"platform"`
+	Blocks     int            `json:"blocks"`
+	Bytes      int            `json:"bytes"`
+	TotalMs    float64        `json:"total_ms"`
+	MBps       float64        `json:"mb_per_s"`
+	Hotspot    string         `json:"hotspot"`
+	Phases     []PhaseSummary `json:"phases"`
+	Operations []PhaseTiming  `json:"operations"`
+	CPUProfile string         `json:"cpu_profile,omitempty"`
+}
+
+// phaseProfiler accumulates time per (phase, operation)
+type phaseProfiler struct {
+	ctx    context.Context
+	labels bool
+	ops    []*PhaseTiming
+	totals map[string]*time.Duration
+	index  map[string]int
+}
+
+// newPhaseProfiler creates a profiler; with labels set, every operation
+// runs under pprof labels phase=<phase> op=<operation>
+func newPhaseProfiler(labels bool) *phaseProfiler {
+	return &phaseProfiler{
+		ctx:    context.Background(),
+		labels: labels,
+		totals: make(map[string]*time.Duration),
+		index:  make(map[string]int),
+	}
+}
+
+// measure runs fn and attributes its duration to phase/op
+func (p *phaseProfiler) measure(phase, op string, fn func()) {
+	var elapsed time.Duration
+	if p.labels {
+		pprof.Do(p.ctx, pprof.Labels("phase", phase, "op", op), func(context.Context) {
+			start := time.Now()
+			fn()
+			elapsed = time.Since(start)
+		})
+	} else {
+		start := time.Now()
+		fn()
+		elapsed = time.Since(start)
+	}
+
+	key := phase + "/" + op
+	i, ok := p.index[key]
+	if !ok {
+		i = len(p.ops)
+		p.index[key] = i
+		p.ops = append(p.ops, &PhaseTiming{Phase: phase, Operation: op})
+		p.totals[key] = new(time.Duration)
+	}
+
+	p.ops[i].Calls++
+	*p.totals[key] += elapsed
+}
+
+// RunPhaseProfile encrypts blocks random 512-bit blocks through all three
+// phases and attributes the time to each operation
+func RunPhaseProfile(blocks int, labels bool) (PhaseProfile, error) {
+	p := newPhaseProfiler(labels)
+
+	masterKey := generateRandomKey()
+	nonce := generateRandomNonce()
+	splitKey := generateRandomKey()
+
+	// Phase 1: chaos trajectories and KDF (once per key); the MAC key is
+	// the split-trust derivation from a separate auth key
+	var keys [11][16]byte
+	var authKey [64]byte
+	var err error
+
+	p.measure("phase1", "chaos", func() {
+		generateChaosKeys(kdf.ChaosSeed(masterKey[:], nonce[:]), 1000, 0.01)
+	})
+	p.measure("phase1", "kdf", func() {
+		keys, err = kdf.New().DeriveKeys(masterKey, nonce, nil, 0)
+	})
+	if err != nil {
+		return PhaseProfile{}, err
+	}
+	p.measure("phase1", "auth-key", func() {
+		// As NewEAMSA512CipherSHA3 derives it from a split-trust AuthKey
+		authKey = sha3.Sum512(append([]byte(format.SplitMACLabel), splitKey[:]...))
+	})
+
+	// Phase 2: key schedule once, then MSA / S-box / P-layer per round
+	var schedule *eamsa512.MSAKeySchedule
+	p.measure("phase2", "msa-schedule", func() {
+		schedule = eamsa512.NewMSAKeySchedule(keys)
+	})
+
+	sboxPlayer := eamsa512.NewSBoxPlayers()
+	macCipher := &eamsa512.EAMSA512CipherSHA3{AuthKeyMaterial: authKey}
+
+	var block [64]byte
+	rand.Read(block[:])
+
+	for b := 0; b < blocks; b++ {
+		var left, right [32]byte
+		copy(left[:], block[0:32])
+		copy(right[:], block[32:64])
+
+		for round := 0; round < eamsa512.Phase2Rounds; round++ {
+			var leftOut [32]byte
+			p.measure("phase2", "msa", func() {
+				pad := schedule.Pad(round)
+				for i := 0; i < 32; i++ {
+					leftOut[i] = left[i] ^ pad[i]
+				}
+			})
+
+			var rightIn, sboxed, rightOut [64]byte
+			copy(rightIn[:], right[:])
+			p.measure("phase2", "sbox", func() {
+				sboxed = sboxPlayer.ApplySBoxes(rightIn)
+			})
+			p.measure("phase2", "player", func() {
+				rightOut = sboxPlayer.ApplyPLayer(sboxed)
+			})
+
+			p.measure("phase2", "mix", func() {
+				for i := 0; i < 32; i++ {
+					right[i] = left[i] ^ rightOut[i]
+				}
+				copy(left[:], rightOut[:32])
+			})
+		}
+
+		copy(block[0:32], left[:])
+		copy(block[32:64], right[:])
+
+		// Phase 3: HMAC-SHA3-512 over header || ciphertext
+		p.measure("phase3", "mac", func() {
+			macCipher.ComputeMACHA3(block[:], nonce, uint64(b))
+		})
+	}
+
+	return p.report(blocks), nil
+}
+
+// report converts the accumulated timings into a PhaseProfile
+func (p *phaseProfiler) report(blocks int) PhaseProfile {
+	profile := PhaseProfile{
+		Version:   PhaseProfileVersion,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+		Blocks:    blocks,
+		Bytes:     blocks * 64,
+	}
+
+	var total time.Duration
+	for _, d := range p.totals {
+		total += *d
+	}
+	profile.TotalMs = float64(total) / float64(time.Millisecond)
+	if total > 0 {
+		profile.MBps = float64(profile.Bytes) / total.Seconds() / 1e6
+	}
+
+	phaseTotals := make(map[string]time.Duration)
+	var hottest time.Duration
+	for _, op := range p.ops {
+		d := *p.totals[op.Phase+"/"+op.Operation]
+		op.TotalMs = float64(d) / float64(time.Millisecond)
+		op.MeanNs = float64(d) / float64(op.Calls)
+		if total > 0 {
+			op.Percent = 100 * float64(d) / float64(total)
+		}
+		phaseTotals[op.Phase] += d
+		if d > hottest {
+			hottest = d
+			profile.Hotspot = op.Phase + "/" + op.Operation
+		}
+		profile.Operations = append(profile.Operations, *op)
+	}
+
+	for phase, d := range phaseTotals {
+		summary := PhaseSummary{Phase: phase, TotalMs: float64(d) / float64(time.Millisecond)}
+		if total > 0 {
+			summary.Percent = 100 * float64(d) / float64(total)
+		}
+		profile.Phases = append(profile.Phases, summary)
+	}
+	sort.Slice(profile.Phases, func(i, j int) bool { return profile.Phases[i].Phase < profile.Phases[j].Phase })
+
+	return profile
+}
+
+// runProfileCommand implements "eamsa512 profile"
+func runProfileCommand(args []string) (err error) {
+	fs := flag.NewFlagSet("profile", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	blocks := fs.Int("blocks", 16384, "Number of 512-bit blocks to encrypt (16384 = 1 MB)")
+	format := fs.String("format", "json", "Report format: json or text")
+	cpuProfile := fs.String("cpuprofile", "", "Write a labelled CPU profile (go tool pprof -http=: <file>)")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("profile", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("profile: unexpected argument: %s", fs.Arg(0))
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("profile: unknown format %q (want json or text)", *format)
+	}
+	if *blocks <= 0 {
+		return inputError("profile: -blocks must be positive")
+	}
+
+	infoln("⏱️  EAMSA 512 Phase Profile")
+	infoln(stringRepeat("=", 60))
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return fmt.Errorf("failed to create CPU profile: %v", err)
+		}
+		defer f.Close()
+
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return fmt.Errorf("failed to start CPU profile: %v", err)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("profile aborted: %v", r)
+		}
+	}()
+
+	profile, err := RunPhaseProfile(*blocks, *cpuProfile != "")
+	if err != nil {
+		return fmt.Errorf("profile failed: %v", err)
+	}
+	profile.CPUProfile = *cpuProfile
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(profile, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode profile: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		resultf("%d blocks (%d bytes) in %.2f ms, %.2f MB/s\n\n", profile.Blocks, profile.Bytes, profile.TotalMs, profile.MBps)
+		for _, op := range profile.Operations {
+			resultf("%-7s %-13s %9d calls %10.2f ms %10.0f ns/call %6.2f%%\n",
+				op.Phase, op.Operation, op.Calls, op.TotalMs, op.MeanNs, op.Percent)
+		}
+		resultf("\n")
+		for _, phase := range profile.Phases {
+			resultf("%-7s %10.2f ms %6.2f%%\n", phase.Phase, phase.TotalMs, phase.Percent)
+		}
+		resultf("\nHotspot: %s\n", profile.Hotspot)
+	}
+
+	if *cpuProfile != "" {
+		infof("\nCPU profile written to %s (flame graph: go tool pprof -http=: %s)\n", *cpuProfile, *cpuProfile)
+	}
+
+	return nil
+}
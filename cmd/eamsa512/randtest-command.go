@@ -0,0 +1,121 @@
+// randtest-command.go - SP 800-22 statistical test report for a file of random output
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Redeaux-Corporation/eamsa512/randtest"
+)
+
+// randtestDefaultBits is the sequence length SP 800-22 uses in its own
+// assessments; longer files are tested on their first bits
+const randtestDefaultBits = 1000000
+
+// runRandtestCommand implements "eamsa512 randtest"
+func runRandtestCommand(args []string) error {
+	fs := flag.NewFlagSet("randtest", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	in := fs.String("in", "", "File of generator output to test (- for stdin)")
+	bits := fs.Int("bits", randtestDefaultBits, "Bits tested, from the start of the file")
+	alpha := fs.Float64("alpha", randtest.DefaultAlpha, "Significance level of each test")
+	block := fs.Int("block", 128, "Block size of the frequency within a block test")
+	format := fs.String("format", "json", "Report format: json or text")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("randtest", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("randtest: unexpected argument: %s", fs.Arg(0))
+	}
+	if *in == "" {
+		return inputError("randtest: -in is required")
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("randtest: unknown format %q (want json or text)", *format)
+	}
+	if *bits < randtest.MinBits || *alpha <= 0 || *alpha >= 1 || *block < 1 {
+		return inputError("randtest: -bits must be at least %d, -alpha between 0 and 1 and -block positive", randtest.MinBits)
+	}
+
+	data, err := readRandtestInput(*in, (*bits+7)/8)
+	if err != nil {
+		return inputError("randtest: %v", err)
+	}
+	sequence := randtest.Expand(data)
+	if len(sequence) > *bits {
+		sequence = sequence[:*bits]
+	}
+	if len(sequence) < randtest.MinBits {
+		return inputError("randtest: %s has %d bits: need at least %d", *in, len(sequence), randtest.MinBits)
+	}
+	if len(sequence) < *bits {
+		infof("⚠️  %s has only %d bits; some tests need more and are skipped\n", *in, len(sequence))
+	}
+
+	infof("🎲 Testing %d bits of %s\n", len(sequence), *in)
+	report, err := randtest.RunBits(sequence, randtest.Config{Alpha: *alpha, BlockSize: *block})
+	if err != nil {
+		return inputError("randtest: %v", err)
+	}
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		printRandtestReport(*in, report)
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("randtest: %s fails %s", *in, strings.Join(report.Failed, ", "))
+	}
+	return nil
+}
+
+// readRandtestInput reads up to limit bytes of a file, or of stdin for "-"
+func readRandtestInput(path string, limit int) ([]byte, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	return io.ReadAll(io.LimitReader(r, int64(limit)))
+}
+
+// printRandtestReport writes report as a table
+func printRandtestReport(name string, report *randtest.Report) {
+	resultf("%s: %d bits, alpha %g\n\n", name, report.Bits, report.Alpha)
+	for _, r := range report.Results {
+		if r.Skipped != "" {
+			resultf("%-20s %-19s SKIP %s\n", r.Name, "", r.Skipped)
+			continue
+		}
+		pvalues := make([]string, len(r.PValues))
+		for i, p := range r.PValues {
+			pvalues[i] = fmt.Sprintf("%.6f", p)
+		}
+		status := "PASS"
+		if !r.Passed {
+			status = "FAIL"
+		}
+		resultf("%-20s %-19s %s\n", r.Name, strings.Join(pvalues, " "), status)
+	}
+
+	if report.Passed {
+		resultf("\nAll tests pass\n")
+	} else {
+		resultf("\n%d of %d tests fail\n", len(report.Failed), len(report.Results))
+	}
+}
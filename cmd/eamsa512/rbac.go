@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"sync"
 	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/labels"
 )
 
 // Role defines user roles in the system
@@ -15,6 +17,8 @@ const (
 	RoleOperator    Role = "operator"    // Encrypt/decrypt operations
 	RoleAuditor     Role = "auditor"     // Read-only access
 	RoleMaintenance Role = "maintenance" // Key rotation and maintenance
+	RoleCollector   Role = "collector"   // Encrypt-only (edge collectors)
+	RoleReader      Role = "reader"      // Decrypt-only
 )
 
 // Permission defines what operations are allowed
@@ -40,6 +44,21 @@ type User struct {
 	LastAccess  time.Time
 	AccessCount int64
 	Permissions []Permission
+	Attributes  map[string]string // Scoping attributes (region, tenant, clearance)
+}
+
+// LabelPolicy restricts which keys a user may use, based on key labels.
+// A policy applies to users whose role is in Roles (empty = all roles) and
+// whose attributes match Subject (empty = all users), for the listed
+// Permissions (empty = all). Keys must then carry RequiredLabels; a value of
+// labels.UserAttribute binds the label to the user's attribute, e.g.
+// {"tenant": "$user"} confines each user to keys of their own tenant.
+type LabelPolicy struct {
+	Name           string
+	Roles          []Role
+	Permissions    []Permission
+	Subject        labels.Set
+	RequiredLabels labels.Set
 }
 
 // RBACManager manages role-based access control
@@ -47,6 +66,7 @@ type RBACManager struct {
 	users       map[string]*User
 	rolePerms   map[Role][]Permission
 	auditLog    []RBACEvent
+	labelPolicies []LabelPolicy
 	mu          sync.RWMutex
 }
 
@@ -96,6 +116,16 @@ func (rbac *RBACManager) initializeRolePermissions() {
 	rbac.rolePerms[RoleMaintenance] = []Permission{
 		PermGenerateKey, PermRotateKey, PermDestroyKey,
 	}
+	
+	// Collector: Encrypt-only handles; cannot read what it produced
+	rbac.rolePerms[RoleCollector] = []Permission{
+		PermEncrypt,
+	}
+	
+	// Reader: Decrypt-only handles
+	rbac.rolePerms[RoleReader] = []Permission{
+		PermDecrypt,
+	}
 }
 
 // CreateUser creates new user with specified role
@@ -260,6 +290,197 @@ func (rbac *RBACManager) UpdateUserRole(userID string, newRole Role) error {
 	return nil
 }
 
+// ============================================================================
+// Label-Based Key Constraints
+// ============================================================================
+
+// SetUserAttributes sets the scoping attributes used by label policies
+func (rbac *RBACManager) SetUserAttributes(userID string, attributes map[string]string) error {
+	if err := labels.Set(attributes).Validate(); err != nil {
+		return err
+	}
+
+	rbac.mu.Lock()
+	user, exists := rbac.users[userID]
+	if !exists {
+		rbac.mu.Unlock()
+		return fmt.Errorf("user %s not found", userID)
+	}
+	user.Attributes = labels.Set(attributes).Copy()
+	rbac.mu.Unlock()
+
+	rbac.logEvent(RBACEvent{
+		Timestamp: time.Now(),
+		UserID:    "system",
+		Username:  "system",
+		Action:    "SET_ATTRIBUTES",
+		Resource:  userID,
+		Result:    "SUCCESS",
+		Details:   fmt.Sprintf("Attributes: %s", labels.Set(attributes)),
+	})
+
+	return nil
+}
+
+// AddLabelPolicy registers a label-based key constraint
+func (rbac *RBACManager) AddLabelPolicy(policy LabelPolicy) error {
+	if policy.Name == "" {
+		return fmt.Errorf("label policy name is required")
+	}
+
+	if len(policy.RequiredLabels) == 0 {
+		return fmt.Errorf("label policy %s has no required labels", policy.Name)
+	}
+
+	if err := policy.Subject.Validate(); err != nil {
+		return fmt.Errorf("label policy %s: %v", policy.Name, err)
+	}
+
+	for key, value := range policy.RequiredLabels {
+		if value == labels.UserAttribute {
+			continue
+		}
+		if err := (labels.Set{key: value}).Validate(); err != nil {
+			return fmt.Errorf("label policy %s: %v", policy.Name, err)
+		}
+	}
+
+	rbac.mu.Lock()
+	for _, existing := range rbac.labelPolicies {
+		if existing.Name == policy.Name {
+			rbac.mu.Unlock()
+			return fmt.Errorf("label policy %s already exists", policy.Name)
+		}
+	}
+	rbac.labelPolicies = append(rbac.labelPolicies, policy)
+	rbac.mu.Unlock()
+
+	rbac.logEvent(RBACEvent{
+		Timestamp: time.Now(),
+		UserID:    "system",
+		Username:  "system",
+		Action:    "ADD_LABEL_POLICY",
+		Resource:  policy.Name,
+		Result:    "SUCCESS",
+		Details:   fmt.Sprintf("Subject: %s Required: %s", policy.Subject, policy.RequiredLabels),
+	})
+
+	return nil
+}
+
+// AuthorizeKeyAccess verifies the user holds permission and that the key's
+// labels satisfy every applicable label policy
+func (rbac *RBACManager) AuthorizeKeyAccess(userID string, permission Permission, keyID string, keyLabels labels.Set) error {
+	if err := rbac.AuthorizeAction(userID, fmt.Sprintf("%s:%s", permission, keyID), permission); err != nil {
+		return err
+	}
+
+	rbac.mu.RLock()
+	user, exists := rbac.users[userID]
+	if !exists {
+		rbac.mu.RUnlock()
+		return fmt.Errorf("user %s not found", userID)
+	}
+	username := user.Username
+	violation := ""
+	for _, policy := range rbac.labelPolicies {
+		if !policy.appliesTo(user, permission) {
+			continue
+		}
+
+		required, err := policy.RequiredLabels.Resolve(user.Attributes)
+		if err != nil {
+			violation = fmt.Sprintf("policy %s: %v", policy.Name, err)
+			break
+		}
+
+		if !keyLabels.Matches(required) {
+			violation = fmt.Sprintf("policy %s requires key labels %s", policy.Name, required)
+			break
+		}
+	}
+	rbac.mu.RUnlock()
+
+	if violation != "" {
+		rbac.logEvent(RBACEvent{
+			Timestamp:  time.Now(),
+			UserID:     userID,
+			Username:   username,
+			Action:     "KEY_ACCESS",
+			Resource:   keyID,
+			Result:     "DENIED",
+			Permission: permission,
+			Details:    violation,
+		})
+		return fmt.Errorf("access denied: user %s cannot use key %s (%s)", userID, keyID, violation)
+	}
+
+	return nil
+}
+
+// FilterKeysForUser returns the key IDs (from a keyID -> labels map) that the
+// user may use for permission under the label policies
+func (rbac *RBACManager) FilterKeysForUser(userID string, permission Permission, keys map[string]labels.Set) []string {
+	rbac.mu.RLock()
+	defer rbac.mu.RUnlock()
+
+	user, exists := rbac.users[userID]
+	if !exists {
+		return nil
+	}
+
+	allowed := make([]string, 0, len(keys))
+	for keyID, keyLabels := range keys {
+		permitted := true
+		for _, policy := range rbac.labelPolicies {
+			if !policy.appliesTo(user, permission) {
+				continue
+			}
+			required, err := policy.RequiredLabels.Resolve(user.Attributes)
+			if err != nil || !keyLabels.Matches(required) {
+				permitted = false
+				break
+			}
+		}
+		if permitted {
+			allowed = append(allowed, keyID)
+		}
+	}
+
+	return allowed
+}
+
+// appliesTo reports whether the policy governs user for permission
+func (policy LabelPolicy) appliesTo(user *User, permission Permission) bool {
+	if len(policy.Roles) > 0 {
+		match := false
+		for _, role := range policy.Roles {
+			if role == user.Role {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+
+	if len(policy.Permissions) > 0 {
+		match := false
+		for _, perm := range policy.Permissions {
+			if perm == permission {
+				match = true
+				break
+			}
+		}
+		if !match {
+			return false
+		}
+	}
+
+	return labels.Set(user.Attributes).Matches(policy.Subject)
+}
+
 // logEvent logs RBAC event
 func (rbac *RBACManager) logEvent(event RBACEvent) {
 	rbac.mu.Lock()
@@ -289,13 +510,17 @@ func (rbac *RBACManager) PrintRBACStatus() {
 	for _, user := range rbac.users {
 		fmt.Printf("\n   User: %s (%s)\n", user.Username, user.UserID)
 		fmt.Printf("     Role: %s\n", user.Role)
+		if len(user.Attributes) > 0 {
+			fmt.Printf("     Attributes: %s\n", labels.Set(user.Attributes))
+		}
 		fmt.Printf("     Permissions: %d\n", len(user.Permissions))
 		fmt.Printf("     Created: %v\n", user.CreatedAt)
 		fmt.Printf("     Last Access: %v\n", user.LastAccess)
 		fmt.Printf("     Access Count: %d\n", user.AccessCount)
 	}
 	
-	fmt.Printf("\n   Audit Log Events: %d\n", len(rbac.auditLog))
+	fmt.Printf("\n   Label Policies: %d\n", len(rbac.labelPolicies))
+	fmt.Printf("   Audit Log Events: %d\n", len(rbac.auditLog))
 }
 
 // VerifyRBACCompliance checks RBAC compliance
@@ -0,0 +1,129 @@
+// recipient-command.go - X25519 recipient keys for file sharing
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// recipientFlags collects repeated -recipient flags
+type recipientFlags []string
+
+func (r *recipientFlags) String() string { return strings.Join(*r, ",") }
+
+func (r *recipientFlags) Set(value string) error {
+	*r = append(*r, value)
+	return nil
+}
+
+// runKeygenCommand implements "eamsa512 keygen -o name": writes the
+// identity to name.key (mode 0600) and the public key to name.pub, both
+// hex-encoded
+func runKeygenCommand(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	output := fs.String("o", "", "Write <name>.key and <name>.pub")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("keygen", err)
+	}
+	if *output == "" || fs.NArg() != 0 {
+		return inputError("keygen: -o <name> is required")
+	}
+
+	key, err := eamsa512.GenerateRecipientKey()
+	if err != nil {
+		return keyError("keygen: %v", err)
+	}
+	pub := key.PublicKey()
+
+	keyPath, pubPath := *output+".key", *output+".pub"
+	if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key.Bytes())+"\n"), 0600); err != nil {
+		return fmt.Errorf("keygen: %v", err)
+	}
+	if err := os.WriteFile(pubPath, []byte(pub.String()+"\n"), 0644); err != nil {
+		return fmt.Errorf("keygen: %v", err)
+	}
+
+	infof("Identity: %s (keep private)\n", keyPath)
+	infof("Public key: %s (give to senders)\n", pubPath)
+	resultf("%s\n", pub.KeyID())
+	return nil
+}
+
+// readRecipient reads a public key given as hex or as a file holding it
+func readRecipient(value string) (eamsa512.PublicKey, error) {
+	text := value
+	if data, err := os.ReadFile(value); err == nil {
+		text = string(data)
+	}
+
+	b, err := hex.DecodeString(strings.TrimSpace(text))
+	if err != nil {
+		return eamsa512.PublicKey{}, fmt.Errorf("recipient %s: not a public key file or hex key", value)
+	}
+	return eamsa512.ParsePublicKey(b)
+}
+
+// readIdentity reads an identity written by keygen
+func readIdentity(path string) (*eamsa512.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("identity %s must be hex-encoded", path)
+	}
+	return eamsa512.NewPrivateKey(b)
+}
+
+// encryptForRecipients implements "encrypt -recipient"
+func encryptForRecipients(plaintext []byte, values []string, output string) error {
+	recipients := make([]eamsa512.PublicKey, 0, len(values))
+	for _, value := range values {
+		pub, err := readRecipient(value)
+		if err != nil {
+			return keyError("encrypt: %v", err)
+		}
+		recipients = append(recipients, pub)
+	}
+
+	sealed, err := eamsa512.EncryptForRecipients(plaintext, recipients)
+	if err != nil {
+		return inputError("encrypt: %v", err)
+	}
+
+	infof("Encrypted for %d recipient(s)\n", len(recipients))
+	return writePasswordOutput(output, sealed)
+}
+
+// decryptForRecipient implements "decrypt -identity"
+func decryptForRecipient(data []byte, identityPath, output string) error {
+	if identityPath == "" {
+		return keyError("decrypt: file is encrypted to recipients; use -identity")
+	}
+	key, err := readIdentity(identityPath)
+	if err != nil {
+		return keyError("decrypt: %v", err)
+	}
+
+	plaintext, err := eamsa512.DecryptForRecipient(data, key)
+	if errors.Is(err, eamsa512.ErrNotRecipient) {
+		return keyError("decrypt: file is not addressed to %s", key.PublicKey().KeyID())
+	}
+	if errors.Is(err, eamsa512.ErrDecryption) {
+		return authError("decrypt: tampered file")
+	}
+	if err != nil {
+		return inputError("decrypt: %v", err)
+	}
+
+	return writePasswordOutput(output, plaintext)
+}
@@ -0,0 +1,516 @@
+// selftest.go - Runtime self-test (KATs, RNG health, constant-time, S-box, round-trip)
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/kdf"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// SelfTestVersion identifies the report schema
+const SelfTestVersion = "1"
+
+// SelfTestResult is the outcome of a single check
+type SelfTestResult struct {
+	Name       string  `json:"name"`
+	Category   string  `json:"category"`
+	Passed     bool    `json:"passed"`
+	DurationMs float64 `json:"duration_ms"`
+	Detail     string  `json:"detail,omitempty"`
+}
+
+// SelfTestReport is the machine-readable self-test report
+type SelfTestReport struct {
+	Version   string           `json:"version"`
+	Timestamp string           `json:"timestamp"`
+	Host      string           `json:"host"`
+	GoVersion string           `json:"go_version"`
+	Platform  string           `json:"platform"`
+	Passed    bool             `json:"passed"`
+	Total     int              `json:"total"`
+	Failed    int              `json:"failed"`
+	Checks    []SelfTestResult `json:"checks"`
+}
+
+// selfTestCheck is a named check; it returns a detail string or an error
+type selfTestCheck struct {
+	name     string
+	category string
+	run      func() (string, error)
+}
+
+// selfTestChecks lists every check in execution order
+func selfTestChecks() []selfTestCheck {
+	return []selfTestCheck{
+		{"sha3-256-abc", "kat", func() (string, error) {
+			d := sha3.Sum256([]byte("abc"))
+			return checkDigestKAT(d[:],
+				"3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532")
+		}},
+		{"sha3-512-abc", "kat", func() (string, error) {
+			d := sha3.Sum512([]byte("abc"))
+			return checkDigestKAT(d[:],
+				"b751850b1a57168a5693cd924b6b096e08f621827444f70d884f5d0240d2712e"+
+					"10e116e9192af3c91a7ec57647e3934057340b4cf408d5a56592f8274eec53f0")
+		}},
+		{"sha3-512-empty", "kat", func() (string, error) {
+			d := sha3.Sum512(nil)
+			return checkDigestKAT(d[:],
+				"a69f73cca23a9ac5c8b567dc185a756e97c982164fe25859e0d1dcc1475c80a6"+
+					"15b2123af1f5f94c11e3e9402c3ac558f500199d95b6d3e301758586281dcd26")
+		}},
+		{"repetition-count", "rng", checkRNGRepetitionCount},
+		{"adaptive-proportion", "rng", checkRNGAdaptiveProportion},
+		{"shannon-entropy", "rng", checkRNGEntropy},
+		{"compare-semantics", "constant-time", checkConstantTimeSemantics},
+		{"mac-verify-timing", "constant-time", checkConstantTimeTiming},
+		{"table-digest", "sbox", checkPhaseTablesDigest},
+		{"sbox-bijective", "sbox", checkSBoxInvertibility},
+		{"player-permutation", "sbox", checkPLayerInvertibility},
+		{"block-roundtrip", "roundtrip", checkBlockRoundTrip},
+		{"tamper-detection", "roundtrip", checkTamperDetection},
+		{"key-state-machine", "lifecycle", keymgmt.CheckTransitions},
+	}
+}
+
+// RunSelfTest runs all checks and returns the report
+func RunSelfTest() *SelfTestReport {
+	host, _ := os.Hostname()
+
+	report := &SelfTestReport{
+		Version:   SelfTestVersion,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Host:      host,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	for _, check := range selfTestChecks() {
+		result := runSelfTestCheck(check)
+		report.Checks = append(report.Checks, result)
+
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+			report.Failed++
+		}
+		infof("   [%s] %s/%s\n", status, result.Category, result.Name)
+	}
+
+	report.Total = len(report.Checks)
+	report.Passed = report.Failed == 0
+
+	return report
+}
+
+// runSelfTestCheck runs one check, turning panics into failures
+func runSelfTestCheck(check selfTestCheck) (result SelfTestResult) {
+	result = SelfTestResult{Name: check.name, Category: check.category}
+	start := time.Now()
+
+	defer func() {
+		result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		if r := recover(); r != nil {
+			result.Passed = false
+			result.Detail = fmt.Sprintf("panic: %v", r)
+		}
+	}()
+
+	detail, err := check.run()
+	if err != nil {
+		result.Detail = err.Error()
+		return result
+	}
+
+	result.Passed = true
+	result.Detail = detail
+	return result
+}
+
+// runSelfTestCommand implements "eamsa512 selftest [-format json|text]"
+func runSelfTestCommand(args []string) error {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	fs.SetOutput(errorOut)
+	format := fs.String("format", "json", "Report format: json or text")
+
+	if err := fs.Parse(args); err != nil {
+		return flagError("selftest", err)
+	}
+	if fs.NArg() > 0 {
+		return inputError("selftest: unexpected argument: %s", fs.Arg(0))
+	}
+	if *format != "json" && *format != "text" {
+		return inputError("selftest: unknown format %q (want json or text)", *format)
+	}
+
+	infoln("🧪 EAMSA 512 Self-Test")
+	infoln(stringRepeat("=", 60))
+
+	report := RunSelfTest()
+
+	switch *format {
+	case "json":
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode report: %v", err)
+		}
+		resultf("%s\n", data)
+	case "text":
+		for _, check := range report.Checks {
+			status := "PASS"
+			if !check.Passed {
+				status = "FAIL"
+			}
+			resultf("%-4s  %-14s %-20s %s\n", status, check.Category, check.Name, check.Detail)
+		}
+		resultf("%d/%d checks passed\n", report.Total-report.Failed, report.Total)
+	}
+
+	if !report.Passed {
+		return fmt.Errorf("self-test failed: %d of %d checks failed", report.Failed, report.Total)
+	}
+
+	infoln("\n✅ Self-test PASSED")
+	return nil
+}
+
+// ============================================================================
+// Known Answer Tests
+// ============================================================================
+
+// checkDigestKAT compares a digest against the FIPS 202 expected value
+func checkDigestKAT(got []byte, expectedHex string) (string, error) {
+	expected, _ := hex.DecodeString(expectedHex)
+	if !bytes.Equal(got, expected) {
+		return "", fmt.Errorf("digest mismatch: got %x", got)
+	}
+	return "matches FIPS 202 vector", nil
+}
+
+// ============================================================================
+// RNG Health Tests
+// ============================================================================
+
+const (
+	// rngSampleSize is the number of crypto/rand bytes sampled
+	rngSampleSize = 64 * 1024
+
+	// Cutoffs from SP 800-90B 4.4 for an assumed min-entropy of 4 bits per
+	// byte and a false-positive rate of 2^-20
+	rngRepetitionCutoff  = 6
+	rngProportionWindow  = 512
+	rngProportionCutoff  = 62
+	rngMinShannonEntropy = 7.99
+)
+
+// rngSample reads a fresh sample from the system RNG
+func rngSample() ([]byte, error) {
+	sample := make([]byte, rngSampleSize)
+	if _, err := rand.Read(sample); err != nil {
+		return nil, fmt.Errorf("system RNG read failed: %v", err)
+	}
+	return sample, nil
+}
+
+// checkRNGRepetitionCount detects a stuck RNG (SP 800-90B 4.4.1)
+func checkRNGRepetitionCount() (string, error) {
+	sample, err := rngSample()
+	if err != nil {
+		return "", err
+	}
+
+	longest, run := 1, 1
+	for i := 1; i < len(sample); i++ {
+		if sample[i] == sample[i-1] {
+			run++
+			if run > longest {
+				longest = run
+			}
+			if run >= rngRepetitionCutoff {
+				return "", fmt.Errorf("%d identical consecutive bytes at offset %d", run, i-run+1)
+			}
+		} else {
+			run = 1
+		}
+	}
+
+	return fmt.Sprintf("longest run %d (cutoff %d)", longest, rngRepetitionCutoff), nil
+}
+
+// checkRNGAdaptiveProportion detects loss of entropy (SP 800-90B 4.4.2)
+func checkRNGAdaptiveProportion() (string, error) {
+	sample, err := rngSample()
+	if err != nil {
+		return "", err
+	}
+
+	highest := 0
+	for start := 0; start+rngProportionWindow <= len(sample); start += rngProportionWindow {
+		window := sample[start : start+rngProportionWindow]
+		count := 0
+		for _, b := range window {
+			if b == window[0] {
+				count++
+			}
+		}
+		if count > highest {
+			highest = count
+		}
+		if count >= rngProportionCutoff {
+			return "", fmt.Errorf("value 0x%02x occurs %d times in window at offset %d", window[0], count, start)
+		}
+	}
+
+	return fmt.Sprintf("highest count %d (cutoff %d)", highest, rngProportionCutoff), nil
+}
+
+// checkRNGEntropy checks Shannon entropy of the RNG output
+func checkRNGEntropy() (string, error) {
+	sample, err := rngSample()
+	if err != nil {
+		return "", err
+	}
+
+	entropy := eamsa512.ShannonEntropy(sample)
+	if !kdf.New().VerifyEntropySource(sample) {
+		return "", fmt.Errorf("%.4f bits/byte, want at least %.2f", entropy, rngMinShannonEntropy)
+	}
+
+	return fmt.Sprintf("%.4f bits/byte", entropy), nil
+}
+
+// ============================================================================
+// Constant-Time Checks
+// ============================================================================
+
+const (
+	// ctIterations is the number of verifications per timing batch
+	ctIterations = 2000
+
+	// ctBatches is the number of timing batches per input class
+	ctBatches = 15
+
+	// ctMaxRatio is the largest accepted ratio between input classes.
+	// Generous on purpose: the check catches early-exit comparisons, which
+	// differ by far more, without flagging scheduler noise.
+	ctMaxRatio = 1.5
+)
+
+// checkConstantTimeSemantics checks VerifyMACHA3 accepts only identical MACs
+func checkConstantTimeSemantics() (string, error) {
+	cipher := &eamsa512.EAMSA512CipherSHA3{}
+
+	mac := [64]byte{}
+	rand.Read(mac[:])
+
+	if !cipher.VerifyMACHA3(mac, mac) {
+		return "", fmt.Errorf("identical MACs rejected")
+	}
+
+	for _, pos := range []int{0, 31, 63} {
+		other := mac
+		other[pos] ^= 0x01
+		if cipher.VerifyMACHA3(mac, other) {
+			return "", fmt.Errorf("MACs differing at byte %d accepted", pos)
+		}
+	}
+
+	return "accepts equal, rejects first/middle/last byte mismatch", nil
+}
+
+// checkConstantTimeTiming compares VerifyMACHA3 timings for a match, an
+// early mismatch and a late mismatch
+func checkConstantTimeTiming() (string, error) {
+	cipher := &eamsa512.EAMSA512CipherSHA3{}
+
+	mac := [64]byte{}
+	rand.Read(mac[:])
+	early := mac
+	early[0] ^= 0xFF
+	late := mac
+	late[63] ^= 0xFF
+
+	measure := func(other [64]byte) time.Duration {
+		samples := make([]time.Duration, ctBatches)
+		for b := 0; b < ctBatches; b++ {
+			start := time.Now()
+			for i := 0; i < ctIterations; i++ {
+				cipher.VerifyMACHA3(mac, other)
+			}
+			samples[b] = time.Since(start)
+		}
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		return samples[ctBatches/2]
+	}
+
+	equal := measure(mac)
+	earlyMiss := measure(early)
+	lateMiss := measure(late)
+
+	fastest, slowest := equal, equal
+	for _, d := range []time.Duration{earlyMiss, lateMiss} {
+		if d < fastest {
+			fastest = d
+		}
+		if d > slowest {
+			slowest = d
+		}
+	}
+
+	ratio := float64(slowest) / float64(fastest)
+	detail := fmt.Sprintf("median ns/op equal=%.1f early=%.1f late=%.1f ratio=%.2f",
+		float64(equal)/ctIterations, float64(earlyMiss)/ctIterations,
+		float64(lateMiss)/ctIterations, ratio)
+
+	if ratio > ctMaxRatio {
+		return "", fmt.Errorf("%s exceeds %.2f", detail, ctMaxRatio)
+	}
+
+	return detail, nil
+}
+
+// ============================================================================
+// S-box and P-layer Checks
+// ============================================================================
+
+// checkPhaseTablesDigest checks the S-box and P-layer tables are the ones
+// gentables.go generated and verified
+func checkPhaseTablesDigest() (string, error) {
+	digest := hex.EncodeToString(eamsa512.HashPhaseTables())
+	if digest != eamsa512.PhaseTablesDigest {
+		return "", fmt.Errorf("table digest %s does not match generated %s", digest, eamsa512.PhaseTablesDigest)
+	}
+
+	return "sha3-256 " + digest[:16], nil
+}
+
+// checkSBoxInvertibility checks every S-box is a bijection on bytes
+func checkSBoxInvertibility() (string, error) {
+	for box := range eamsa512.SBoxTable {
+		var seen [256]bool
+		for in := 0; in < 256; in++ {
+			out := eamsa512.SBoxTable[box][in]
+			if seen[out] {
+				return "", fmt.Errorf("S-box %d is not invertible: output 0x%02x repeated at input 0x%02x", box+1, out, in)
+			}
+			seen[out] = true
+		}
+	}
+
+	return fmt.Sprintf("%d S-boxes are bijective", len(eamsa512.SBoxTable)), nil
+}
+
+// checkPLayerInvertibility checks the P-layer is a permutation and
+// InversePLayerPermutation undoes it
+func checkPLayerInvertibility() (string, error) {
+	var seen [64]bool
+	for i, p := range eamsa512.PLayerPermutation {
+		if p < 0 || p >= len(eamsa512.PLayerPermutation) || seen[p] {
+			return "", fmt.Errorf("P-layer entry %d (%d) is out of range or repeated", i, p)
+		}
+		seen[p] = true
+	}
+
+	for i := range eamsa512.PLayerPermutation {
+		if eamsa512.InversePLayerPermutation[eamsa512.PLayerPermutation[i]] != i {
+			return "", fmt.Errorf("inverse P-layer does not undo position %d", i)
+		}
+	}
+
+	sbp := eamsa512.NewSBoxPlayers()
+	var input [64]byte
+	rand.Read(input[:])
+	if output := sbp.ApplyPLayer(input); output == input && input != ([64]byte{}) {
+		return "", fmt.Errorf("P-layer is the identity")
+	}
+
+	return "permutation with matching inverse", nil
+}
+
+// ============================================================================
+// Round-Trip Checks
+// ============================================================================
+
+// selfTestCipher builds a cipher with a fresh random key and nonce
+func selfTestCipher() (*eamsa512.EAMSA512CipherSHA3, error) {
+	return eamsa512.NewEAMSA512CipherSHA3(&eamsa512.EAMSA512ConfigSHA3{
+		MasterKey:     generateRandomKey(),
+		Nonce:         generateRandomNonce(),
+		RoundCount:    16,
+		IncludeAuth:   true,
+		AuthAlgorithm: "HMAC-SHA3-512",
+		Mode:          "CBC",
+	})
+}
+
+// checkBlockRoundTrip checks decrypt(encrypt(p)) == p with a valid MAC
+func checkBlockRoundTrip() (string, error) {
+	cipher, err := selfTestCipher()
+	if err != nil {
+		return "", err
+	}
+
+	for i := 0; i < 8; i++ {
+		var plaintext [64]byte
+		rand.Read(plaintext[:])
+
+		result := cipher.EncryptBlockSHA3(plaintext)
+		if result.Ciphertext == plaintext {
+			return "", fmt.Errorf("block %d: ciphertext equals plaintext", i)
+		}
+
+		decrypted, valid := cipher.DecryptBlockSHA3(result.Ciphertext, result.MAC, result.Counter)
+		if !valid {
+			return "", fmt.Errorf("block %d: MAC rejected on untampered ciphertext", i)
+		}
+		if subtle.ConstantTimeCompare(decrypted[:], plaintext[:]) != 1 {
+			return "", fmt.Errorf("block %d: decrypted block differs from plaintext", i)
+		}
+	}
+
+	return "8 random blocks round-trip", nil
+}
+
+// checkTamperDetection checks modified ciphertexts and MACs are rejected
+func checkTamperDetection() (string, error) {
+	cipher, err := selfTestCipher()
+	if err != nil {
+		return "", err
+	}
+
+	var plaintext [64]byte
+	rand.Read(plaintext[:])
+	result := cipher.EncryptBlockSHA3(plaintext)
+
+	tamperedCiphertext := result.Ciphertext
+	tamperedCiphertext[0] ^= 0x01
+	if _, valid := cipher.DecryptBlockSHA3(tamperedCiphertext, result.MAC, result.Counter); valid {
+		return "", fmt.Errorf("modified ciphertext accepted")
+	}
+
+	tamperedMAC := result.MAC
+	tamperedMAC[63] ^= 0x80
+	if _, valid := cipher.DecryptBlockSHA3(result.Ciphertext, tamperedMAC, result.Counter); valid {
+		return "", fmt.Errorf("modified MAC accepted")
+	}
+
+	if _, valid := cipher.DecryptBlockSHA3(result.Ciphertext, result.MAC, result.Counter+1); valid {
+		return "", fmt.Errorf("MAC accepted for wrong block counter")
+	}
+
+	return "ciphertext, MAC and counter tampering rejected", nil
+}
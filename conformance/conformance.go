@@ -0,0 +1,185 @@
+// Package conformance checks other implementations of EAMSA 512 (ports to
+// Rust, Java and so on) against this one. Vectors are produced by the Go
+// implementation (Generate, "eamsa512 conformance vectors") and stored in
+// conformance/vectors.json; Runner feeds each vector to a candidate
+// program and diffs what it prints against the expected output.
+//
+// CLI contract: for every vector the runner starts
+//
+//	<candidate command...> <op>
+//
+// writes a JSON object of lowercase hex strings (the vector's input) to
+// its stdin and closes it. The candidate prints a JSON object of hex
+// strings (the output) on stdout and exits 0; on an authentication
+// failure it exits 2, as the eamsa512 CLI does; it exits 64 for an op it
+// does not implement, which the runner reports as skipped. Anything on
+// stderr is shown with a failure. Ops lists the ops with their input and
+// output fields; WriteContract renders it as Markdown (docs/conformance.md).
+package conformance
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	// SuiteVersion is the vectors file version written by Generate
+	SuiteVersion = 1
+
+	// ExitAuthFailure is the exit code of a candidate rejecting tampered input
+	ExitAuthFailure = 2
+
+	// ExitUnsupported is the exit code of a candidate without the op
+	ExitUnsupported = 64
+
+	// ErrorAuth is the expected error of a vector the candidate must reject
+	ErrorAuth = "auth"
+)
+
+// ErrUnsupported is returned by Reference for an op it does not know
+var ErrUnsupported = errors.New("unsupported operation")
+
+// Vector is one test case
+type Vector struct {
+	ID          string            `json:"id"`
+	Op          string            `json:"op"`
+	Description string            `json:"description,omitempty"`
+	Input       map[string]string `json:"input"`
+	Output      map[string]string `json:"output,omitempty"` // Expected output; empty for error vectors
+	Error       string            `json:"error,omitempty"`  // ErrorAuth: the candidate must exit ExitAuthFailure
+}
+
+// Suite is the contents of a vectors file
+type Suite struct {
+	Version   int      `json:"version"`
+	Generator string   `json:"generator"`
+	Vectors   []Vector `json:"vectors"`
+}
+
+// Field is an input or output field of an op
+type Field struct {
+	Name        string
+	Optional    bool // Inputs only: absent means empty
+	Description string
+}
+
+// Op describes one operation of the CLI contract
+type Op struct {
+	Name        string
+	Description string
+	Inputs      []Field
+	Outputs     []Field
+}
+
+// Ops lists the operations of the CLI contract, in the order a port would
+// usually implement them
+var Ops = []Op{
+	{
+		Name:        "derive-keys",
+		Description: "The 11 round keys of a master key: SHA3-512(key || \"key_<i>\")[:16] for i = 0..10",
+		Inputs:      []Field{{Name: "key", Description: "Master key (32)"}},
+		Outputs:     []Field{{Name: "round_keys", Description: "The round keys concatenated (176)"}},
+	},
+	{
+		Name:        "encrypt-block",
+		Description: "One block through the 16-round SPN under the round keys of key",
+		Inputs: []Field{
+			{Name: "key", Description: "Master key (32)"},
+			{Name: "block", Description: "Plaintext block (64)"},
+		},
+		Outputs: []Field{{Name: "ciphertext", Description: "Ciphertext block (64)"}},
+	},
+	{
+		Name:        "hmac",
+		Description: "HMAC-SHA3-512 per RFC 2104 (block size 72, the SHA3-512 rate)",
+		Inputs: []Field{
+			{Name: "key", Description: "MAC key (any length)"},
+			{Name: "data", Optional: true, Description: "Message"},
+		},
+		Outputs: []Field{{Name: "tag", Description: "Tag (64)"}},
+	},
+	{
+		Name:        "seal",
+		Description: "EncryptDataWithAAD: CBC with PKCS#7 padding and an HMAC-SHA3-512 tag",
+		Inputs: []Field{
+			{Name: "key", Description: "Master key (32)"},
+			{Name: "nonce", Description: "Nonce (16)"},
+			{Name: "plaintext", Optional: true, Description: "Plaintext"},
+			{Name: "aad", Optional: true, Description: "Additional authenticated data"},
+		},
+		Outputs: []Field{{Name: "sealed", Description: "ciphertext || nonce || tag"}},
+	},
+	{
+		Name:        "open",
+		Description: "DecryptDataWithAAD: verify the tag, then decrypt; a bad tag is an authentication failure",
+		Inputs: []Field{
+			{Name: "key", Description: "Master key (32)"},
+			{Name: "sealed", Description: "ciphertext || nonce || tag"},
+			{Name: "aad", Optional: true, Description: "Additional authenticated data"},
+		},
+		Outputs: []Field{{Name: "plaintext", Description: "Plaintext"}},
+	},
+	{
+		Name:        "wrap-key",
+		Description: "WrapKeyWithAAD: deterministic SIV key wrap",
+		Inputs: []Field{
+			{Name: "kek", Description: "Key-encryption key (32)"},
+			{Name: "key", Description: "Key to wrap (16 to 4096)"},
+			{Name: "aad", Optional: true, Description: "Additional authenticated data"},
+		},
+		Outputs: []Field{{Name: "wrapped", Description: "Wrapped key (key length + 37)"}},
+	},
+	{
+		Name:        "unwrap-key",
+		Description: "UnwrapKeyWithAAD; a bad SIV is an authentication failure",
+		Inputs: []Field{
+			{Name: "kek", Description: "Key-encryption key (32)"},
+			{Name: "wrapped", Description: "Wrapped key"},
+			{Name: "aad", Optional: true, Description: "Additional authenticated data"},
+		},
+		Outputs: []Field{{Name: "key", Description: "Unwrapped key"}},
+	},
+}
+
+// LookupOp returns the contract of the named op
+func LookupOp(name string) (Op, bool) {
+	for _, op := range Ops {
+		if op.Name == name {
+			return op, true
+		}
+	}
+	return Op{}, false
+}
+
+// Load reads a vectors file
+func Load(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var suite Suite
+	if err := json.Unmarshal(data, &suite); err != nil {
+		return nil, fmt.Errorf("invalid vectors file %s: %v", path, err)
+	}
+	if suite.Version != SuiteVersion {
+		return nil, fmt.Errorf("unsupported vectors file version %d", suite.Version)
+	}
+	for _, v := range suite.Vectors {
+		if _, ok := LookupOp(v.Op); !ok {
+			return nil, fmt.Errorf("vector %s: unknown op %q", v.ID, v.Op)
+		}
+	}
+	return &suite, nil
+}
+
+// Marshal returns suite as indented JSON, as stored in vectors.json
+func (s *Suite) Marshal() ([]byte, error) {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
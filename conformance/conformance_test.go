@@ -0,0 +1,69 @@
+package conformance
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Conformance Vectors Test
+// conformance/vectors.json must match the Go implementation
+//
+// Ports to other languages are checked against vectors.json with
+// "eamsa512 conformance run". This test keeps the file honest: it must
+// equal what Generate produces now, and every vector must pass against
+// the Go reference. After an intentional change to the cipher, regenerate
+// with "eamsa512 conformance vectors -o conformance/vectors.json".
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// vectorsPath is vectors.json in this directory
+const vectorsPath = "vectors.json"
+
+// TestConformanceVectors checks vectors.json against the implementation
+func TestConformanceVectors(t *testing.T) {
+	stored, err := os.ReadFile(vectorsPath)
+	if err != nil {
+		t.Fatalf("Failed to read vectors: %v", err)
+	}
+
+	suite, err := Generate()
+	if err != nil {
+		t.Fatalf("Failed to generate vectors: %v", err)
+	}
+	generated, err := suite.Marshal()
+	if err != nil {
+		t.Fatalf("Failed to encode vectors: %v", err)
+	}
+	if !bytes.Equal(stored, generated) {
+		t.Errorf("%s is out of date; regenerate it with \"eamsa512 conformance vectors -o conformance/vectors.json\"", vectorsPath)
+	}
+
+	loaded, err := Load(vectorsPath)
+	if err != nil {
+		t.Fatalf("Failed to load vectors: %v", err)
+	}
+	for _, v := range loaded.Vectors {
+		output, err := Reference(v.Op, v.Input)
+		if v.Error == ErrorAuth {
+			if !errors.Is(err, eamsa512.ErrDecryption) {
+				t.Errorf("%s: expected authentication failure, got %v", v.ID, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", v.ID, err)
+			continue
+		}
+		for field, want := range v.Output {
+			if output[field] != want {
+				t.Errorf("%s: %s = %s, want %s", v.ID, field, output[field], want)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package conformance
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteContract writes the CLI contract as Markdown, generated from Ops.
+// docs/conformance.md is produced with "eamsa512 conformance contract".
+func WriteContract(w io.Writer) error {
+	p := &contractWriter{w: w}
+
+	p.printf("# EAMSA 512 Conformance Contract (vectors version %d)\n\n", SuiteVersion)
+	p.printf("Generated from the `conformance` package; do not edit by hand.\n\n")
+
+	p.printf("## Running a Candidate\n\n")
+	p.printf("    eamsa512 conformance run [-vectors conformance/vectors.json] [-ops a,b] -- <command> [args...]\n\n")
+	p.printf("For each vector the runner starts `<command> [args...] <op>`, writes the\n")
+	p.printf("vector's input to stdin as a JSON object of lowercase hex strings and closes\n")
+	p.printf("it. The candidate must:\n\n")
+	p.printf("1. On success, print a JSON object of hex strings holding the output fields\n")
+	p.printf("   on stdout and exit 0. Extra fields are ignored; hex case is not significant.\n")
+	p.printf("2. On an authentication failure (bad tag or SIV), exit %d. Vectors with\n", ExitAuthFailure)
+	p.printf("   `\"error\": \"%s\"` expect this.\n", ErrorAuth)
+	p.printf("3. For an op it does not implement, exit %d; the vector is skipped, so a\n", ExitUnsupported)
+	p.printf("   port can be checked one op at a time.\n\n")
+	p.printf("Each run has a timeout (default %v). stderr is shown for failing vectors.\n", DefaultTimeout)
+	p.printf("Optional inputs may be absent from the JSON object; absent means empty.\n")
+	p.printf("`eamsa512 conformance reference` implements this contract with the Go code.\n")
+
+	p.printf("\n## Operations\n")
+	for _, op := range Ops {
+		p.printf("\n### `%s`\n\n", op.Name)
+		p.printf("%s.\n\n", op.Description)
+		p.printf("| Direction | Field | Description |\n")
+		p.printf("|-----------|-------|-------------|\n")
+		for _, f := range op.Inputs {
+			description := f.Description
+			if f.Optional {
+				description += " (optional)"
+			}
+			p.printf("| input | `%s` | %s |\n", f.Name, cell(description))
+		}
+		for _, f := range op.Outputs {
+			p.printf("| output | `%s` | %s |\n", f.Name, cell(f.Description))
+		}
+	}
+
+	p.printf("\n## Vectors File\n\n")
+	p.printf("`conformance/vectors.json` is `{\"version\", \"generator\", \"vectors\"}`; each vector\n")
+	p.printf("has `id`, `op`, `description`, `input` and either `output` or `error`.\n")
+	p.printf("Regenerate it with `eamsa512 conformance vectors -o conformance/vectors.json`\n")
+	p.printf("only after an intentional change to the cipher: ports validated against the\n")
+	p.printf("old file stop matching.\n")
+
+	return p.err
+}
+
+// cell escapes a Markdown table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// contractWriter remembers the first write error
+type contractWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *contractWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
@@ -0,0 +1,230 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"golang.org/x/crypto/sha3"
+)
+
+// Reference runs op on input with the Go implementation. It is the
+// candidate behind "eamsa512 conformance reference" and the source of the
+// expected outputs in Generate. Authentication failures are
+// eamsa512.ErrDecryption.
+func Reference(op string, input map[string]string) (map[string]string, error) {
+	spec, ok := LookupOp(op)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	in := make(map[string][]byte, len(spec.Inputs))
+	for _, f := range spec.Inputs {
+		value, present := input[f.Name]
+		if !present && !f.Optional {
+			return nil, fmt.Errorf("%s: missing input %q", op, f.Name)
+		}
+		b, err := hex.DecodeString(value)
+		if err != nil {
+			return nil, fmt.Errorf("%s: input %q is not hex: %v", op, f.Name, err)
+		}
+		in[f.Name] = b
+	}
+
+	var out []byte
+	var err error
+	switch op {
+	case "derive-keys":
+		var keys [][]byte
+		if keys, err = eamsa512.DeriveKeys(in["key"]); err == nil {
+			out = bytes.Join(keys, nil)
+		}
+	case "encrypt-block":
+		if len(in["block"]) != eamsa512.BlockSize {
+			return nil, fmt.Errorf("%s: block must be %d bytes", op, eamsa512.BlockSize)
+		}
+		var keys [][]byte
+		if keys, err = eamsa512.DeriveKeys(in["key"]); err == nil {
+			out = eamsa512.EncryptBlock(in["block"], keys)
+		}
+	case "hmac":
+		out = eamsa512.ComputeHMAC(in["key"], in["data"])
+	case "seal":
+		if len(in["nonce"]) != eamsa512.NonceSize {
+			return nil, fmt.Errorf("%s: nonce must be %d bytes", op, eamsa512.NonceSize)
+		}
+		out, err = eamsa512.EncryptDataWithAAD(in["plaintext"], in["key"], in["nonce"], in["aad"])
+	case "open":
+		out, err = eamsa512.DecryptDataWithAAD(in["sealed"], in["key"], in["aad"])
+	case "wrap-key":
+		out, err = eamsa512.WrapKeyWithAAD(in["kek"], in["key"], in["aad"])
+	case "unwrap-key":
+		out, err = eamsa512.UnwrapKeyWithAAD(in["kek"], in["wrapped"], in["aad"])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{spec.Outputs[0].Name: hex.EncodeToString(out)}, nil
+}
+
+// Generate builds the vectors file from the Go implementation. Inputs are
+// fixed (see testBytes), so the output only changes when the
+// implementation does.
+func Generate() (*Suite, error) {
+	g := &generator{}
+
+	zeroKey := make([]byte, eamsa512.KeySize)
+	key := testBytes("key", eamsa512.KeySize)
+	nonce := testBytes("nonce", eamsa512.NonceSize)
+
+	g.add("derive-keys", "all-zero key", ins{"key": zeroKey})
+	g.add("derive-keys", "sequential key", ins{"key": sequential(eamsa512.KeySize)})
+	g.add("derive-keys", "pseudorandom key", ins{"key": key})
+
+	g.add("encrypt-block", "all-zero key and block", ins{"key": zeroKey, "block": make([]byte, eamsa512.BlockSize)})
+	g.add("encrypt-block", "sequential key and block", ins{"key": sequential(eamsa512.KeySize), "block": sequential(eamsa512.BlockSize)})
+	g.add("encrypt-block", "all-ones block", ins{"key": key, "block": bytes.Repeat([]byte{0xff}, eamsa512.BlockSize)})
+	g.add("encrypt-block", "pseudorandom block", ins{"key": key, "block": testBytes("block", eamsa512.BlockSize)})
+
+	g.add("hmac", "empty message", ins{"key": key})
+	for _, n := range []int{1, 71, 72, 73, 1000} {
+		g.add("hmac", fmt.Sprintf("%d-byte message", n), ins{"key": key, "data": testBytes("data", n)})
+	}
+	g.add("hmac", "key of the block size", ins{"key": testBytes("long key", 72), "data": testBytes("data", 64)})
+	g.add("hmac", "key longer than the block size", ins{"key": testBytes("long key", 200), "data": testBytes("data", 64)})
+
+	var sealed, sealedAAD []byte
+	opens := make(map[int][]byte)
+	for _, n := range []int{0, 1, 63, 64, 65, 200} {
+		v := g.add("seal", fmt.Sprintf("%d-byte plaintext", n), ins{"key": key, "nonce": nonce, "plaintext": testBytes("plaintext", n)})
+		opens[n], _ = hex.DecodeString(v.Output["sealed"])
+		if n == 65 {
+			sealed = opens[n]
+		}
+	}
+	aad := []byte("record-42")
+	v := g.add("seal", "with additional data", ins{"key": key, "nonce": nonce, "plaintext": testBytes("plaintext", 100), "aad": aad})
+	sealedAAD, _ = hex.DecodeString(v.Output["sealed"])
+
+	if sealed == nil || sealedAAD == nil {
+		return nil, fmt.Errorf("seal vector missing")
+	}
+	for _, n := range []int{0, 1, 64, 65, 200} {
+		g.add("open", fmt.Sprintf("%d-byte plaintext", n), ins{"key": key, "sealed": opens[n]})
+	}
+	g.add("open", "with additional data", ins{"key": key, "sealed": sealedAAD, "aad": aad})
+	g.addError("open", "tag modified", ins{"key": key, "sealed": flip(sealed, len(sealed)-1)})
+	g.addError("open", "ciphertext modified", ins{"key": key, "sealed": flip(sealed, 0)})
+	g.addError("open", "nonce modified", ins{"key": key, "sealed": flip(sealed, len(sealed)-eamsa512.TagSize-1)})
+	g.addError("open", "additional data added", ins{"key": key, "sealed": sealed, "aad": aad})
+	g.addError("open", "truncated", ins{"key": key, "sealed": sealed[:len(sealed)-1]})
+
+	kek := testBytes("kek", eamsa512.KeySize)
+	var wrapped []byte
+	for _, n := range []int{16, 32, 64, 100} {
+		v := g.add("wrap-key", fmt.Sprintf("%d-byte key", n), ins{"kek": kek, "key": testBytes("wrapped key", n)})
+		if n == 32 {
+			wrapped, _ = hex.DecodeString(v.Output["wrapped"])
+		}
+	}
+	g.add("wrap-key", "with additional data", ins{"kek": kek, "key": testBytes("wrapped key", 32), "aad": aad})
+
+	if wrapped == nil {
+		return nil, fmt.Errorf("wrap-key vector missing")
+	}
+	g.add("unwrap-key", "32-byte key", ins{"kek": kek, "wrapped": wrapped})
+	g.addError("unwrap-key", "SIV modified", ins{"kek": kek, "wrapped": flip(wrapped, 5)})
+	g.addError("unwrap-key", "wrapped key modified", ins{"kek": kek, "wrapped": flip(wrapped, len(wrapped)-1)})
+	g.addError("unwrap-key", "additional data added", ins{"kek": kek, "wrapped": wrapped, "aad": aad})
+	g.addError("unwrap-key", "wrong KEK", ins{"kek": key, "wrapped": wrapped})
+
+	if g.err != nil {
+		return nil, g.err
+	}
+	return &Suite{
+		Version:   SuiteVersion,
+		Generator: "eamsa512 (Go reference implementation)",
+		Vectors:   g.vectors,
+	}, nil
+}
+
+// ins is the input of a vector before hex encoding
+type ins map[string][]byte
+
+// generator numbers vectors per op and keeps the first error
+type generator struct {
+	vectors []Vector
+	counts  map[string]int
+	err     error
+}
+
+// vector returns a numbered vector for op with input hex-encoded
+func (g *generator) vector(op, description string, input ins) Vector {
+	if g.counts == nil {
+		g.counts = make(map[string]int)
+	}
+	g.counts[op]++
+
+	v := Vector{
+		ID:          fmt.Sprintf("%s-%03d", op, g.counts[op]),
+		Op:          op,
+		Description: description,
+		Input:       make(map[string]string, len(input)),
+	}
+	for name, value := range input {
+		v.Input[name] = hex.EncodeToString(value)
+	}
+	return v
+}
+
+// add appends a vector whose output is computed by Reference
+func (g *generator) add(op, description string, input ins) Vector {
+	v := g.vector(op, description, input)
+	output, err := Reference(op, v.Input)
+	if err != nil && g.err == nil {
+		g.err = fmt.Errorf("%s: %v", v.ID, err)
+	}
+	v.Output = output
+	g.vectors = append(g.vectors, v)
+	return v
+}
+
+// addError appends a vector that Reference must reject as unauthentic
+func (g *generator) addError(op, description string, input ins) {
+	v := g.vector(op, description, input)
+	if _, err := Reference(op, v.Input); err != eamsa512.ErrDecryption && g.err == nil {
+		g.err = fmt.Errorf("%s: expected an authentication failure, got %v", v.ID, err)
+	}
+	v.Error = ErrorAuth
+	g.vectors = append(g.vectors, v)
+}
+
+// testBytes returns n reproducible pseudorandom bytes: SHAKE256 of
+// "EAMSA512-CONFORMANCE" || 0 || label
+func testBytes(label string, n int) []byte {
+	out := make([]byte, n)
+	h := sha3.NewShake256()
+	h.Write([]byte("EAMSA512-CONFORMANCE"))
+	h.Write([]byte{0})
+	h.Write([]byte(label))
+	h.Read(out)
+	return out
+}
+
+// sequential returns 0, 1, 2, ... n-1 (mod 256)
+func sequential(n int) []byte {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = byte(i)
+	}
+	return out
+}
+
+// flip returns a copy of b with byte i inverted
+func flip(b []byte, i int) []byte {
+	out := append([]byte(nil), b...)
+	out[i] ^= 0xff
+	return out
+}
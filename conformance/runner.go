@@ -0,0 +1,220 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Result statuses
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+	StatusSkip = "skip" // The candidate exited ExitUnsupported
+)
+
+// DefaultTimeout bounds one candidate run
+const DefaultTimeout = 10 * time.Second
+
+// maxStderr is how much of a failing candidate's stderr a result keeps
+const maxStderr = 2048
+
+// Runner runs vectors against a candidate program
+type Runner struct {
+	Command []string      // Program and arguments; the op is appended
+	Timeout time.Duration // Per vector; zero means DefaultTimeout
+	Ops     []string      // Ops to run; empty means all
+}
+
+// Diff is one output field that does not match
+type Diff struct {
+	Field string `json:"field"`
+	Want  string `json:"want"`
+	Got   string `json:"got"`
+}
+
+// Result is the outcome of one vector
+type Result struct {
+	ID     string `json:"id"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+	Diffs  []Diff `json:"diffs,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Command string   `json:"command"`
+	Passed  int      `json:"passed"`
+	Failed  int      `json:"failed"`
+	Skipped int      `json:"skipped"`
+	Results []Result `json:"results"`
+}
+
+// OK reports whether no vector failed
+func (r *Report) OK() bool {
+	return r.Failed == 0
+}
+
+// Run runs every selected vector of suite in order
+func (r *Runner) Run(ctx context.Context, suite *Suite) (*Report, error) {
+	if len(r.Command) == 0 {
+		return nil, fmt.Errorf("no candidate command")
+	}
+	for _, op := range r.Ops {
+		if _, ok := LookupOp(op); !ok {
+			return nil, fmt.Errorf("unknown op %q", op)
+		}
+	}
+
+	report := &Report{Command: strings.Join(r.Command, " ")}
+	for _, v := range suite.Vectors {
+		if !r.selected(v.Op) {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		result := r.runVector(ctx, v)
+		switch result.Status {
+		case StatusPass:
+			report.Passed++
+		case StatusSkip:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// selected reports whether op is in r.Ops
+func (r *Runner) selected(op string) bool {
+	if len(r.Ops) == 0 {
+		return true
+	}
+	for _, o := range r.Ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+// runVector starts the candidate for one vector and checks its output
+func (r *Runner) runVector(ctx context.Context, v Vector) Result {
+	result := Result{ID: v.ID, Op: v.Op, Status: StatusFail}
+
+	input, err := json.Marshal(v.Input)
+	if err != nil {
+		result.Reason = err.Error()
+		return result
+	}
+
+	timeout := r.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := append(append([]string(nil), r.Command[1:]...), v.Op)
+	cmd := exec.CommandContext(ctx, r.Command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err = cmd.Run()
+	result.Stderr = truncate(stderr.String(), maxStderr)
+
+	code := 0
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.Reason = fmt.Sprintf("timed out after %v", timeout)
+		return result
+	case errors.As(err, &exitErr):
+		code = exitErr.ExitCode()
+	case err != nil:
+		result.Reason = err.Error()
+		return result
+	}
+
+	if code == ExitUnsupported {
+		result.Status = StatusSkip
+		result.Reason = "op not implemented"
+		result.Stderr = ""
+		return result
+	}
+
+	if v.Error == ErrorAuth {
+		if code != ExitAuthFailure {
+			result.Reason = fmt.Sprintf("expected authentication failure (exit %d), got exit %d", ExitAuthFailure, code)
+			return result
+		}
+		result.Status = StatusPass
+		result.Stderr = ""
+		return result
+	}
+
+	if code != 0 {
+		result.Reason = fmt.Sprintf("exit %d", code)
+		return result
+	}
+
+	var output map[string]string
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		result.Reason = fmt.Sprintf("stdout is not a JSON object of strings: %v", err)
+		return result
+	}
+
+	result.Diffs = diffOutput(v.Output, output)
+	if len(result.Diffs) > 0 {
+		result.Reason = "output differs"
+		return result
+	}
+	result.Status = StatusPass
+	result.Stderr = ""
+	return result
+}
+
+// diffOutput compares the expected fields with got (hex case-insensitively);
+// extra fields in got are ignored
+func diffOutput(want, got map[string]string) []Diff {
+	names := make([]string, 0, len(want))
+	for name := range want {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var diffs []Diff
+	for _, name := range names {
+		value, ok := got[name]
+		if !ok {
+			diffs = append(diffs, Diff{Field: name, Want: want[name], Got: "(missing)"})
+			continue
+		}
+		if !strings.EqualFold(value, want[name]) {
+			diffs = append(diffs, Diff{Field: name, Want: want[name], Got: value})
+		}
+	}
+	return diffs
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,663 @@
+package confschema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Severity of a finding
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // The file is invalid or unsafe
+	SeverityWarning Severity = "warning" // Deprecated or questionable setting
+)
+
+// Finding is one lint result
+type Finding struct {
+	Severity Severity `json:"severity"`
+	Path     string   `json:"path,omitempty"` // Dotted key path, e.g. "hsm.network.port"
+	Line     int      `json:"line,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of linting one file
+type Report struct {
+	Kind     Kind      `json:"kind"`
+	Version  int       `json:"config_version,omitempty"` // Server files only
+	Findings []Finding `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity
+func (r *Report) Count(severity Severity) int {
+	n := 0
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// document is a configuration file with its YAML documents merged into one
+// top-level mapping. Line numbers refer to the whole file.
+type document struct {
+	root       *yaml.Node // Synthetic mapping of every top-level key
+	duplicates [][2]*yaml.Node
+}
+
+// parse decodes every YAML document in data
+func parse(data []byte) (*document, error) {
+	doc := &document{root: &yaml.Node{Kind: yaml.MappingNode}}
+	seen := make(map[string]bool)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var node yaml.Node
+		err := decoder.Decode(&node)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind == yaml.ScalarNode && node.Content[0].ShortTag() == "!!null" {
+			continue // Empty document (comments only)
+		}
+
+		top := node.Content[0]
+		if top.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: top level of a document must be a mapping", top.Line)
+		}
+
+		for i := 0; i+1 < len(top.Content); i += 2 {
+			key, value := top.Content[i], top.Content[i+1]
+			if seen[key.Value] {
+				doc.duplicates = append(doc.duplicates, [2]*yaml.Node{key, value})
+				continue
+			}
+			seen[key.Value] = true
+			doc.root.Content = append(doc.root.Content, key, value)
+		}
+	}
+
+	return doc, nil
+}
+
+// find returns the key and value nodes of a dotted path, or nils
+func find(root *yaml.Node, path string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	node := root
+
+	for _, part := range strings.Split(path, ".") {
+		node = resolve(node)
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+
+		var next *yaml.Node
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == part {
+				key, next = node.Content[i], node.Content[i+1]
+				break
+			}
+		}
+		if next == nil {
+			return nil, nil
+		}
+		node = next
+	}
+
+	return key, resolve(node)
+}
+
+// resolve follows aliases
+func resolve(node *yaml.Node) *yaml.Node {
+	for node != nil && node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// DetectKind guesses the kind of a configuration file from its top-level keys
+func DetectKind(data []byte) (Kind, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return "", err
+	}
+	return detectKind(doc), nil
+}
+
+func detectKind(doc *document) Kind {
+	for _, section := range rbacSections {
+		if key, _ := find(doc.root, section); key != nil {
+			return KindRBAC
+		}
+	}
+	return KindServer
+}
+
+// linter collects findings for one file
+type linter struct {
+	report *Report
+	now    time.Time
+}
+
+func (l *linter) add(severity Severity, path string, node *yaml.Node, format string, args ...interface{}) {
+	finding := Finding{Severity: severity, Path: path, Message: fmt.Sprintf(format, args...)}
+	if node != nil {
+		finding.Line = node.Line
+	}
+	l.report.Findings = append(l.report.Findings, finding)
+}
+
+// Lint checks a configuration file against the schema of kind, or of the
+// detected kind if kind is empty. YAML syntax errors are reported as
+// findings, not returned.
+func Lint(data []byte, kind Kind) (*Report, error) {
+	switch kind {
+	case "", KindServer, KindRBAC:
+	default:
+		return nil, fmt.Errorf("unknown configuration kind %q", kind)
+	}
+
+	report := &Report{Kind: kind, Findings: []Finding{}}
+	l := &linter{report: report, now: time.Now()}
+
+	doc, err := parse(data)
+	if err != nil {
+		if report.Kind == "" {
+			report.Kind = KindServer
+		}
+		l.add(SeverityError, "", nil, "invalid YAML: %s", strings.TrimPrefix(err.Error(), "yaml: "))
+		return report, nil
+	}
+	if report.Kind == "" {
+		report.Kind = detectKind(doc)
+	}
+
+	for _, dup := range doc.duplicates {
+		first, _ := find(doc.root, dup[0].Value)
+		l.add(SeverityError, dup[0].Value, dup[0], "section already defined on line %d", first.Line)
+	}
+
+	l.walk(schemaFor(report.Kind), doc.root, "")
+
+	if report.Kind == KindServer {
+		l.checkVersion(doc.root)
+		l.checkServer(doc.root)
+	} else {
+		l.checkRBAC(doc.root)
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Line < report.Findings[j].Line
+	})
+	return report, nil
+}
+
+// walk checks node against f
+func (l *linter) walk(f *field, node *yaml.Node, path string) {
+	node = resolve(node)
+	if node == nil || node.ShortTag() == "!!null" {
+		return // Empty values keep their defaults
+	}
+
+	if !matches(f.kind, node) {
+		l.add(SeverityError, path, node, "expected %s, got %s", f.kind, describe(node))
+		return
+	}
+
+	if f.deprecated != "" {
+		l.add(SeverityWarning, path, node, "deprecated: %s", f.deprecated)
+	}
+	if f.fixed != nil && node.Value != fmt.Sprint(f.fixed) {
+		l.add(SeverityError, path, node, "only %v is supported, got %s", f.fixed, node.Value)
+	}
+	if len(f.enum) > 0 && !contains(f.enum, node.Value) {
+		l.add(SeverityError, path, node, "%q is not one of %s", node.Value, strings.Join(quoteAll(f.enum), ", "))
+	}
+	if f.secret && node.Value != "" && !strings.HasPrefix(node.Value, "${") {
+		l.add(SeverityError, path, node, "secret stored in the file; reference an environment variable (\"${VAR}\") instead")
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			childPath := join(path, key.Value)
+
+			child := f.entries
+			if f.fields != nil {
+				child = f.fields[key.Value]
+			}
+			if child == nil {
+				l.add(SeverityError, childPath, key, "unknown key%s", suggest(key.Value, f.fields))
+				continue
+			}
+			l.walk(child, value, childPath)
+		}
+
+	case yaml.SequenceNode:
+		if f.items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			l.walk(f.items, item, fmt.Sprintf("%s[%d]", path, i))
+		}
+	}
+}
+
+// checkVersion reports a missing, outdated or unsupported config_version
+func (l *linter) checkVersion(root *yaml.Node) {
+	key, value := find(root, "config_version")
+	if key == nil {
+		l.report.Version = 1
+		l.add(SeverityWarning, "config_version", nil, "missing; the file is schema version 1, run \"eamsa512 config migrate\"")
+		return
+	}
+
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || version < 1 {
+		return // Reported by walk
+	}
+	l.report.Version = version
+
+	switch {
+	case version > CurrentVersion:
+		l.add(SeverityError, "config_version", value, "version %d is newer than this release supports (%d)", version, CurrentVersion)
+	case version < CurrentVersion:
+		l.add(SeverityWarning, "config_version", value, "version %d is outdated; run \"eamsa512 config migrate\"", version)
+	}
+}
+
+// checkServer applies cross-field rules to a server configuration
+func (l *linter) checkServer(root *yaml.Node) {
+	if _, mode := find(root, "environment.mode"); mode != nil && mode.Value == "production" {
+		if key, v := find(root, "advanced.debug_mode"); isTrue(v) {
+			l.add(SeverityError, "advanced.debug_mode", key, "debug mode logs sensitive data and must be off in production")
+		}
+		if key, v := find(root, "server.tls.enabled"); isFalse(v) {
+			l.add(SeverityError, "server.tls.enabled", key, "TLS must be enabled in production")
+		}
+		if key, v := find(root, "advanced.hsm_fallback"); isTrue(v) {
+			l.add(SeverityWarning, "advanced.hsm_fallback", key, "software fallback is not recommended in production")
+		}
+		if key, v := find(root, "performance.cache.key_cache_enabled"); isTrue(v) {
+			l.add(SeverityWarning, "performance.cache.key_cache_enabled", key, "the key cache is intended for development and testing")
+		}
+		if key, v := find(root, "advanced.tracing_enabled"); isTrue(v) {
+			l.add(SeverityWarning, "advanced.tracing_enabled", key, "tracing is very verbose and not recommended in production")
+		}
+	}
+
+	if key, v := find(root, "logging.scrub_secrets"); isFalse(v) {
+		l.add(SeverityError, "logging.scrub_secrets", key, "secrets would be written to the logs")
+	}
+
+	if _, v := find(root, "key_management.generation.min_entropy_bits"); v != nil {
+		if bits, err := strconv.Atoi(v.Value); err == nil && bits < 256 {
+			l.add(SeverityError, "key_management.generation.min_entropy_bits", v, "must be at least 256, got %d", bits)
+		}
+	}
+
+	if _, v := find(root, "server.tls.enabled"); isTrue(v) {
+		for _, name := range []string{"cert_path", "key_path"} {
+			if _, path := find(root, "server.tls."+name); path == nil || path.Value == "" {
+				l.add(SeverityError, "server.tls."+name, v, "required when TLS is enabled")
+			}
+		}
+	}
+
+	if _, v := find(root, "hsm.enabled"); isTrue(v) {
+		if _, hsmType := find(root, "hsm.type"); hsmType != nil && hsmType.Value == "none" {
+			l.add(SeverityError, "hsm.type", hsmType, "HSM is enabled but type is \"none\"")
+		}
+	}
+}
+
+// checkRBAC checks that every role, permission and user reference in an
+// RBAC policy is defined
+func (l *linter) checkRBAC(root *yaml.Node) {
+	roles := mappingKeys(root, "roles")
+	permissions := mappingKeys(root, "permissions")
+	users := make(map[string]bool)
+	for _, user := range mappingValues(root, "users") {
+		if _, name := find(user.value, "username"); name != nil {
+			users[name.Value] = true
+		}
+	}
+	apiKeys := mappingKeys(root, "api_keys")
+
+	checkRole := func(path string, node *yaml.Node) {
+		if roles != nil && !roles[node.Value] {
+			l.add(SeverityError, path, node, "undefined role %q", node.Value)
+		}
+	}
+	checkRoles := func(path string, parent *yaml.Node, name string) {
+		_, list := find(parent, name)
+		for i, item := range sequence(list) {
+			checkRole(fmt.Sprintf("%s.%s[%d]", path, name, i), item)
+		}
+	}
+	checkPermissions := func(path string, parent *yaml.Node) {
+		if permissions == nil {
+			return
+		}
+		_, list := find(parent, "permissions")
+		for i, item := range sequence(list) {
+			if !permissions[item.Value] {
+				l.add(SeverityError, fmt.Sprintf("%s.permissions[%d]", path, i), item, "undefined permission %q", item.Value)
+			}
+		}
+	}
+
+	if _, v := find(root, "rbac.default_role"); v != nil && v.Value != "" {
+		checkRole("rbac.default_role", v)
+	}
+
+	for _, role := range mappingValues(root, "roles") {
+		checkPermissions("roles."+role.name, role.value)
+	}
+
+	parents := make(map[string][]string)
+	for _, entry := range mappingValues(root, "role_hierarchies") {
+		path := "role_hierarchies." + entry.name
+		checkRole(path, entry.key)
+		checkRoles(path, entry.value, "inherits_from")
+
+		_, list := find(entry.value, "inherits_from")
+		for _, item := range sequence(list) {
+			parents[entry.name] = append(parents[entry.name], item.Value)
+		}
+	}
+	for _, entry := range mappingValues(root, "role_hierarchies") {
+		if cycle := findCycle(entry.name, parents); cycle != nil {
+			l.add(SeverityError, "role_hierarchies."+entry.name, entry.key, "inheritance cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	for _, user := range mappingValues(root, "users") {
+		path := "users." + user.name
+		checkRoles(path, user.value, "roles")
+		if _, id := find(user.value, "api_key_id"); id != nil && id.Value != "" && !apiKeys[id.Value] {
+			l.add(SeverityError, path+".api_key_id", id, "undefined API key %q", id.Value)
+		}
+	}
+
+	for i, policy := range sequence(resolveValue(root, "label_policies")) {
+		path := fmt.Sprintf("label_policies[%d]", i)
+		checkRoles(path, policy, "roles")
+		checkPermissions(path, policy)
+	}
+
+	if _, v := find(root, "decrypt_quotas.window_hours"); v != nil {
+		if hours, err := strconv.Atoi(v.Value); err == nil && hours <= 0 {
+			l.add(SeverityError, "decrypt_quotas.window_hours", v, "must be positive")
+		}
+	}
+	for _, quota := range mappingValues(root, "decrypt_quotas.roles") {
+		path := "decrypt_quotas.roles." + quota.name
+		checkRole(path, quota.key)
+		l.checkQuota(path, quota.value)
+	}
+	for _, quota := range mappingValues(root, "decrypt_quotas.users") {
+		path := "decrypt_quotas.users." + quota.name
+		// "anonymous" covers requests without a client certificate
+		if quota.name != "anonymous" && !users[quota.name] {
+			l.add(SeverityError, path, quota.key, "undefined user %q", quota.name)
+		}
+		l.checkQuota(path, quota.value)
+	}
+
+	for _, key := range mappingValues(root, "api_keys") {
+		path := "api_keys." + key.name
+		checkRoles(path, key.value, "roles")
+		if _, account := find(key.value, "service_account"); account != nil && account.Value != "" && !users[account.Value] {
+			l.add(SeverityError, path+".service_account", account, "undefined user %q", account.Value)
+		}
+		l.checkExpiry(path, key.value, "expires_date")
+	}
+
+	for _, cert := range mappingValues(root, "certificates") {
+		path := "certificates." + cert.name
+		checkRoles(path, cert.value, "associated_roles")
+		if _, user := find(cert.value, "associated_user"); user != nil && user.Value != "" && !users[user.Value] {
+			l.add(SeverityError, path+".associated_user", user, "undefined user %q", user.Value)
+		}
+		l.checkExpiry(path, cert.value, "valid_until")
+	}
+}
+
+// checkQuota rejects a negative decrypt quota
+func (l *linter) checkQuota(path string, value *yaml.Node) {
+	if limit, err := strconv.ParseInt(value.Value, 10, 64); err == nil && limit < 0 {
+		l.add(SeverityError, path, value, "quota must not be negative")
+	}
+}
+
+// checkExpiry warns about an active credential past its expiry date
+func (l *linter) checkExpiry(path string, credential *yaml.Node, name string) {
+	if _, status := find(credential, "status"); status != nil && status.Value != "active" {
+		return
+	}
+	_, v := find(credential, name)
+	if v == nil || v.Value == "" {
+		return
+	}
+
+	expires, err := parseDate(v.Value)
+	if err != nil {
+		l.add(SeverityError, path+"."+name, v, "invalid date %q (want YYYY-MM-DD or RFC 3339)", v.Value)
+		return
+	}
+	if l.now.After(expires) {
+		l.add(SeverityWarning, path+"."+name, v, "active credential expired on %s", v.Value)
+	}
+}
+
+// entry is a key/value pair of a mapping
+type entry struct {
+	name  string
+	key   *yaml.Node
+	value *yaml.Node
+}
+
+// mappingValues returns the entries of the mapping at path
+func mappingValues(root *yaml.Node, path string) []entry {
+	node := resolveValue(root, path)
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	entries := make([]entry, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		entries = append(entries, entry{name: node.Content[i].Value, key: node.Content[i], value: resolve(node.Content[i+1])})
+	}
+	return entries
+}
+
+// mappingKeys returns the key set of the mapping at path, or nil if the
+// section is absent (references to it are then not checked)
+func mappingKeys(root *yaml.Node, path string) map[string]bool {
+	if key, _ := find(root, path); key == nil {
+		return nil
+	}
+
+	keys := make(map[string]bool)
+	for _, e := range mappingValues(root, path) {
+		keys[e.name] = true
+	}
+	return keys
+}
+
+func resolveValue(root *yaml.Node, path string) *yaml.Node {
+	_, value := find(root, path)
+	return value
+}
+
+// sequence returns the items of a list node
+func sequence(node *yaml.Node) []*yaml.Node {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	items := make([]*yaml.Node, 0, len(node.Content))
+	for _, item := range node.Content {
+		items = append(items, resolve(item))
+	}
+	return items
+}
+
+// findCycle returns an inheritance path from role back to itself
+func findCycle(role string, parents map[string][]string) []string {
+	var visit func(current string, path []string, seen map[string]bool) []string
+	visit = func(current string, path []string, seen map[string]bool) []string {
+		for _, parent := range parents[current] {
+			if parent == role {
+				return append(path, parent)
+			}
+			if seen[parent] {
+				continue
+			}
+			seen[parent] = true
+			if cycle := visit(parent, append(path, parent), seen); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
+	}
+	return visit(role, []string{role}, map[string]bool{role: true})
+}
+
+// matches reports whether node has the expected kind
+func matches(kind valueKind, node *yaml.Node) bool {
+	switch kind {
+	case kindMap:
+		return node.Kind == yaml.MappingNode
+	case kindList:
+		return node.Kind == yaml.SequenceNode
+	case kindString:
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!str"
+	case kindInt:
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!int"
+	case kindNumber:
+		return node.Kind == yaml.ScalarNode && (node.ShortTag() == "!!int" || node.ShortTag() == "!!float")
+	case kindBool:
+		return node.Kind == yaml.ScalarNode && node.ShortTag() == "!!bool"
+	}
+	return true
+}
+
+// describe names the type of a node for error messages
+func describe(node *yaml.Node) string {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return "mapping"
+	case yaml.SequenceNode:
+		return "list"
+	}
+
+	switch node.ShortTag() {
+	case "!!int":
+		return "integer " + node.Value
+	case "!!float":
+		return "number " + node.Value
+	case "!!bool":
+		return "boolean " + node.Value
+	case "!!str":
+		return strconv.Quote(node.Value)
+	}
+	return node.ShortTag() + " " + node.Value
+}
+
+// suggest returns a "did you mean" hint for an unknown key
+func suggest(key string, known map[string]*field) string {
+	best, bestDistance := "", math.MaxInt
+	for name := range known {
+		d := distance(key, name)
+		if d < bestDistance || d == bestDistance && name < best {
+			best, bestDistance = name, d
+		}
+	}
+
+	if best == "" || bestDistance > 2 && bestDistance > len(key)/3 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// parseDate accepts YYYY-MM-DD or RFC 3339
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.Add(24 * time.Hour), nil // Valid through the whole day
+}
+
+func isTrue(node *yaml.Node) bool {
+	return node != nil && node.ShortTag() == "!!bool" && node.Value == "true"
+}
+
+func isFalse(node *yaml.Node) bool {
+	return node != nil && node.ShortTag() == "!!bool" && node.Value == "false"
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func quoteAll(values []string) []string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return quoted
+}
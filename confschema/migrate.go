@@ -0,0 +1,348 @@
+package confschema
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Change is one edit made by Migrate
+type Change struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line,omitempty"` // Line in the input file
+	Message string `json:"message"`
+}
+
+// MigrateResult is a migrated server configuration
+type MigrateResult struct {
+	From    int       `json:"from"`
+	To      int       `json:"to"`
+	Output  []byte    `json:"-"`
+	Changes []Change  `json:"changes"`
+	Manual  []Finding `json:"manual"` // Settings Migrate could not convert; Output keeps them
+}
+
+// migration upgrades a file from one schema version to the next
+type migration struct {
+	from  int
+	apply func(e *editor)
+}
+
+// migrations are applied in order until CurrentVersion is reached
+var migrations = []migration{
+	{from: 1, apply: migrateV1},
+}
+
+// Migrate rewrites a server configuration in the current schema version.
+// Edits are made line by line so that comments, blank lines and quoting
+// outside the changed settings are preserved. Settings that cannot be
+// converted safely are left in place and listed in Manual.
+func Migrate(data []byte) (*MigrateResult, error) {
+	doc, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML: %v", err)
+	}
+	if detectKind(doc) == KindRBAC {
+		return nil, fmt.Errorf("RBAC policy files are not versioned; nothing to migrate")
+	}
+
+	version, err := fileVersion(doc)
+	if err != nil {
+		return nil, err
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config_version %d is newer than this release supports (%d)", version, CurrentVersion)
+	}
+
+	result := &MigrateResult{From: version, To: version, Output: data, Changes: []Change{}, Manual: []Finding{}}
+
+	for _, m := range migrations {
+		if m.from != result.To {
+			continue
+		}
+
+		e := newEditor(result.Output, doc)
+		m.apply(e)
+		e.setVersion(m.from + 1)
+
+		result.Output = e.output()
+		result.Changes = append(result.Changes, e.changes...)
+		result.Manual = append(result.Manual, e.manual...)
+		result.To = m.from + 1
+
+		if doc, err = parse(result.Output); err != nil {
+			return nil, fmt.Errorf("migration to version %d produced invalid YAML: %v", result.To, err)
+		}
+	}
+
+	sort.SliceStable(result.Changes, func(i, j int) bool { return result.Changes[i].Line < result.Changes[j].Line })
+	sort.SliceStable(result.Manual, func(i, j int) bool { return result.Manual[i].Line < result.Manual[j].Line })
+	return result, nil
+}
+
+// fileVersion returns config_version, or 1 if absent
+func fileVersion(doc *document) (int, error) {
+	_, value := find(doc.root, "config_version")
+	if value == nil {
+		return 1, nil
+	}
+
+	version, err := strconv.Atoi(value.Value)
+	if err != nil || version < 1 {
+		return 0, fmt.Errorf("line %d: invalid config_version %q", value.Line, value.Value)
+	}
+	return version, nil
+}
+
+// migrateV1 drops the parameters fixed by the algorithm and moves
+// duplicated settings to their canonical location
+func migrateV1(e *editor) {
+	for _, path := range []string{
+		"crypto.block_size",
+		"crypto.kdf.algorithm",
+		"crypto.kdf.key_count",
+		"crypto.kdf.key_size_bits",
+		"crypto.hmac.algorithm",
+		"crypto.hmac.output_size",
+	} {
+		e.removeFixed(path)
+	}
+	e.removeIfEmpty("crypto.kdf")
+	e.removeIfEmpty("crypto.hmac")
+	e.removeIfEmpty("crypto")
+
+	e.move("key_management.master_key_source", "key_management.storage.primary")
+	e.move("audit.file_path", "logging.files.audit")
+}
+
+// editor applies line-based edits to a file. Line numbers are 1-based and
+// refer to the input.
+type editor struct {
+	lines   []string
+	doc     *document
+	removed map[int]bool
+	keys    map[*yaml.Node]bool // Keys whose setting was removed
+	replace map[int]string
+	insert  map[int][]string // Lines inserted before a line (len(lines)+1 appends)
+	changes []Change
+	manual  []Finding
+}
+
+func newEditor(data []byte, doc *document) *editor {
+	return &editor{
+		lines:   strings.Split(string(data), "\n"),
+		doc:     doc,
+		removed: make(map[int]bool),
+		keys:    make(map[*yaml.Node]bool),
+		replace: make(map[int]string),
+		insert:  make(map[int][]string),
+	}
+}
+
+func (e *editor) change(path string, node *yaml.Node, format string, args ...interface{}) {
+	e.changes = append(e.changes, Change{Path: path, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *editor) needsManual(path string, node *yaml.Node, format string, args ...interface{}) {
+	e.manual = append(e.manual, Finding{Severity: SeverityError, Path: path, Line: node.Line, Message: fmt.Sprintf(format, args...)})
+}
+
+// removeFixed removes an algorithm-fixed setting if it has the fixed value
+func (e *editor) removeFixed(path string) {
+	key, value := find(e.doc.root, path)
+	if key == nil {
+		return
+	}
+
+	fixed := fmt.Sprint(lookup(serverSchema, path).fixed)
+	if value.Kind != yaml.ScalarNode || value.Value != fixed {
+		e.needsManual(path, key, "set to %s but only %s is supported; remove the setting", value.Value, fixed)
+		return
+	}
+
+	e.remove(key)
+	e.change(path, key, "removed (%s is fixed by the algorithm)", fixed)
+}
+
+// removeIfEmpty removes a mapping whose settings have all been removed
+func (e *editor) removeIfEmpty(path string) {
+	key, value := find(e.doc.root, path)
+	if key == nil || value.Kind != yaml.MappingNode {
+		return
+	}
+
+	for i := 0; i < len(value.Content); i += 2 {
+		if !e.keys[value.Content[i]] {
+			return
+		}
+	}
+	e.remove(key)
+}
+
+// move removes from if to holds the same value, or creates to if it is
+// missing and its parent exists. Conflicting values are left for the user.
+func (e *editor) move(from, to string) {
+	fromKey, fromValue := find(e.doc.root, from)
+	if fromKey == nil {
+		return
+	}
+	if fromValue.Kind != yaml.ScalarNode {
+		e.needsManual(from, fromKey, "expected a single value; move it to %s by hand", to)
+		return
+	}
+
+	toKey, toValue := find(e.doc.root, to)
+	if toKey != nil {
+		if toValue.Value != fromValue.Value {
+			e.needsManual(from, fromKey, "%q conflicts with %s %q; keep one value in %s", fromValue.Value, to, toValue.Value, to)
+			return
+		}
+		e.remove(fromKey)
+		e.change(from, fromKey, "removed (same value as %s)", to)
+		return
+	}
+
+	parentPath, name := to[:strings.LastIndex(to, ".")], to[strings.LastIndex(to, ".")+1:]
+	parentKey, parent := find(e.doc.root, parentPath)
+	if parentKey == nil || parent.Kind != yaml.MappingNode || len(parent.Content) == 0 {
+		e.needsManual(from, fromKey, "move the setting to %s", to)
+		return
+	}
+
+	indent := strings.Repeat(" ", parent.Content[0].Column-1)
+	line := indent + name + ": " + e.sourceValue(fromValue)
+	end := e.end(parentKey)
+	e.insert[end+1] = append(e.insert[end+1], line)
+
+	e.remove(fromKey)
+	e.change(from, fromKey, "moved to %s", to)
+}
+
+// sourceValue returns a scalar as written in the file, keeping its quoting
+func (e *editor) sourceValue(node *yaml.Node) string {
+	line := e.lines[node.Line-1]
+	if node.Style == yaml.DoubleQuotedStyle || node.Style == yaml.SingleQuotedStyle {
+		start := node.Column - 1
+		quote := line[start]
+		if end := strings.IndexByte(line[start+1:], quote); end >= 0 && !strings.Contains(line[start+1:start+1+end], `\`) {
+			return line[start : start+end+2]
+		}
+		return strconv.Quote(node.Value)
+	}
+	return node.Value
+}
+
+// setVersion writes config_version, replacing an existing value
+func (e *editor) setVersion(version int) {
+	line := fmt.Sprintf("config_version: %d", version)
+
+	if key, _ := find(e.doc.root, "config_version"); key != nil {
+		e.replace[key.Line] = line
+		e.change("config_version", key, "set to %d", version)
+		return
+	}
+
+	// Before the first document separator if only comments precede it,
+	// otherwise before the first top-level key
+	at := e.doc.root.Content[0].Line
+	for i, l := range e.lines {
+		trimmed := strings.TrimSpace(l)
+		if trimmed == "---" {
+			at = i + 1
+			break
+		}
+		if trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+	}
+
+	e.insert[at] = append(e.insert[at], line, "")
+	e.changes = append(e.changes, Change{Path: "config_version", Message: fmt.Sprintf("set to %d", version)})
+}
+
+// remove deletes the setting of key, its value lines and the comment lines
+// directly above it
+func (e *editor) remove(key *yaml.Node) {
+	e.keys[key] = true
+
+	start, end := key.Line, e.end(key)
+	indent := key.Column - 1
+	for start > 1 {
+		above := e.lines[start-2]
+		trimmed := strings.TrimSpace(above)
+		if !strings.HasPrefix(trimmed, "#") || indentOf(above) != indent {
+			break
+		}
+		start--
+	}
+
+	for line := start; line <= end; line++ {
+		e.removed[line] = true
+	}
+}
+
+// end returns the last line of the setting of key: the last non-comment
+// line indented deeper than the key
+func (e *editor) end(key *yaml.Node) int {
+	end := key.Line
+	indent := key.Column - 1
+
+	for line := key.Line + 1; line <= len(e.lines); line++ {
+		text := e.lines[line-1]
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") && indentOf(text) > indent {
+			continue
+		}
+		if indentOf(text) <= indent {
+			break
+		}
+		end = line
+	}
+
+	return end
+}
+
+// output assembles the edited file. A blank line following a removed block
+// is dropped if it would double a blank line or open a section.
+func (e *editor) output() []byte {
+	var out []string
+	afterRemoval := false
+
+	emit := func(line string) {
+		if afterRemoval && strings.TrimSpace(line) == "" && len(out) > 0 {
+			last := strings.TrimSpace(out[len(out)-1])
+			if last == "" || strings.HasSuffix(last, ":") {
+				return
+			}
+		}
+		afterRemoval = false
+		out = append(out, line)
+	}
+
+	for i, line := range e.lines {
+		n := i + 1
+		out = append(out, e.insert[n]...)
+		if e.removed[n] {
+			afterRemoval = true
+			continue
+		}
+		if replacement, ok := e.replace[n]; ok {
+			line = indentString(line) + replacement
+		}
+		emit(line)
+	}
+	out = append(out, e.insert[len(e.lines)+1]...)
+
+	return []byte(strings.Join(out, "\n"))
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func indentString(line string) string {
+	return line[:indentOf(line)]
+}
@@ -0,0 +1,500 @@
+// Package confschema describes the EAMSA 512 configuration files, lints
+// them and migrates old schema versions to the current one.
+//
+// Two kinds of file are understood: the server configuration
+// (config/eamsa512.yaml: server, logging, crypto, hsm, key management, ...)
+// and the RBAC policy (config/rbac-config.yaml: roles, users, label
+// policies, API keys, certificates). Both may be split into several YAML
+// documents; their top-level keys are treated as one mapping.
+//
+// The server configuration is versioned by a top-level config_version key.
+// Files without it are version 1. Version 2 drops the parameters fixed by
+// the algorithm (block size, KDF and HMAC parameters) and folds duplicated
+// settings into one place; see Migrate.
+//
+// Validator checks the Go configuration structs built from these files
+// (ServerConfig, KeyRotationPolicy, HSMConfig, the cipher configuration)
+// and reports every invalid field at once as a ValidationError.
+package confschema
+
+import (
+	"strings"
+)
+
+// CurrentVersion is the server configuration schema written by Migrate
+const CurrentVersion = 2
+
+// Kind is the type of configuration file
+type Kind string
+
+const (
+	KindServer Kind = "server" // eamsa512.yaml
+	KindRBAC   Kind = "rbac"   // rbac-config.yaml
+)
+
+// valueKind is the YAML type expected for a setting
+type valueKind int
+
+const (
+	kindAny valueKind = iota
+	kindMap
+	kindString
+	kindInt
+	kindNumber
+	kindBool
+	kindList
+)
+
+// String returns the name used in lint messages
+func (k valueKind) String() string {
+	switch k {
+	case kindMap:
+		return "mapping"
+	case kindString:
+		return "string"
+	case kindInt:
+		return "integer"
+	case kindNumber:
+		return "number"
+	case kindBool:
+		return "boolean"
+	case kindList:
+		return "list"
+	}
+	return "any"
+}
+
+// field is the schema of one setting
+type field struct {
+	kind       valueKind
+	fields     map[string]*field // Known keys of a mapping
+	entries    *field            // Schema of every value in a free-form mapping
+	items      *field            // Schema of list items
+	enum       []string          // Allowed string values
+	fixed      interface{}       // Only value the algorithm supports (deprecated settings)
+	deprecated string            // Why the setting is deprecated and what replaces it
+	secret     bool              // Literal values are rejected; use ${ENV_VAR}
+}
+
+func obj(fields map[string]*field) *field { return &field{kind: kindMap, fields: fields} }
+func dict(entries *field) *field          { return &field{kind: kindMap, entries: entries} }
+func list(items *field) *field            { return &field{kind: kindList, items: items} }
+func str(enum ...string) *field           { return &field{kind: kindString, enum: enum} }
+func integer() *field                     { return &field{kind: kindInt} }
+func number() *field                      { return &field{kind: kindNumber} }
+func boolean() *field                     { return &field{kind: kindBool} }
+func anyValue() *field                    { return &field{kind: kindAny} }
+
+// deprecate marks a setting deprecated
+func (f *field) deprecate(reason string) *field {
+	f.deprecated = reason
+	return f
+}
+
+// fixedAt marks a setting whose only supported value is v
+func (f *field) fixedAt(v interface{}) *field {
+	f.fixed = v
+	return f
+}
+
+// secretValue marks a setting that must reference the environment
+func (f *field) secretValue() *field {
+	f.secret = true
+	return f
+}
+
+// fixedReason is the deprecation message of algorithm-fixed parameters
+const fixedReason = "fixed by the algorithm; removed in schema version 2"
+
+// tlsSchema is the outbound peer verification block (tlstrust.Config)
+func tlsSchema() *field {
+	return obj(map[string]*field{
+		"ca_file":     str(),
+		"ca_pins":     list(str()),
+		"spki_pins":   list(str()),
+		"revocation":  str("off", "soft-fail", "hard-fail"),
+		"crl_files":   list(str()),
+		"server_name": str(),
+	})
+}
+
+// serverSchema describes config/eamsa512.yaml
+var serverSchema = obj(map[string]*field{
+	"config_version": integer(),
+
+	"server": obj(map[string]*field{
+		"host":          str(),
+		"port":          integer(),
+		"http2_enabled": boolean(),
+		"tls": obj(map[string]*field{
+			"enabled":       boolean(),
+			"cert_path":     str(),
+			"key_path":      str(),
+			"min_version":   str("1.2", "1.3"),
+			"cipher_suites": list(str()),
+		}),
+		"read_timeout":  integer(),
+		"write_timeout": integer(),
+		"idle_timeout":  integer(),
+		"max_body_size": integer(),
+	}),
+
+	"logging": obj(map[string]*field{
+		"level":  str("DEBUG", "INFO", "WARN", "ERROR"),
+		"format": str("json", "text"),
+		"files": obj(map[string]*field{
+			"application": str(),
+			"audit":       str(),
+			"error":       str(),
+		}),
+		"rotation": obj(map[string]*field{
+			"max_size_mb":  integer(),
+			"max_backups":  integer(),
+			"max_age_days": integer(),
+			"compress":     boolean(),
+		}),
+		"scrub_secrets": boolean(),
+	}),
+
+	"crypto": obj(map[string]*field{
+		"block_size": integer().fixedAt(64).deprecate(fixedReason),
+		"entropy": obj(map[string]*field{
+			"type": str("chaos", "hsm", "hybrid"),
+			"chaos": obj(map[string]*field{
+				"integration_steps":      integer(),
+				"sampling_interval":      integer(),
+				"raw_sample_buffer_size": integer(),
+			}),
+			"health_check_interval": integer(),
+			"fail_on_health_error":  boolean(),
+		}),
+		"kdf": obj(map[string]*field{
+			"algorithm":     str().fixedAt("SHA3-512").deprecate(fixedReason),
+			"key_count":     integer().fixedAt(11).deprecate(fixedReason),
+			"key_size_bits": integer().fixedAt(128).deprecate(fixedReason),
+		}),
+		"hmac": obj(map[string]*field{
+			"algorithm":   str().fixedAt("HMAC-SHA3-512").deprecate(fixedReason),
+			"output_size": integer().fixedAt(64).deprecate(fixedReason),
+		}),
+	}),
+
+	"hsm": obj(map[string]*field{
+		"enabled": boolean(),
+		"type":    str("thales", "yubihsm", "nitro", "softhsm", "none"),
+		"network": obj(map[string]*field{
+			"host":            str(),
+			"port":            integer(),
+			"timeout_seconds": integer(),
+			"tls":             tlsSchema(),
+		}),
+		"authentication": obj(map[string]*field{
+			"cert_path":   str(),
+			"key_path":    str(),
+			"pin":         str().secretValue(),
+			"pin_env_var": str(),
+		}),
+		"partition": obj(map[string]*field{
+			"label":  str(),
+			"so_pin": str().secretValue(),
+		}),
+		"tamper_sensor": obj(map[string]*field{
+			"enabled": boolean(),
+			"action":  str("shutdown", "alert", "disable_crypto"),
+		}),
+		"fips_mode_required": boolean(),
+		"circuit_breaker": obj(map[string]*field{
+			"failure_threshold":         integer(),
+			"open_seconds":              integer(),
+			"allow_cached_key_fallback": boolean(),
+			"cached_key_ttl_seconds":    integer(),
+		}),
+		"backup": obj(map[string]*field{
+			"enabled":        boolean(),
+			"interval_hours": integer(),
+			"location":       str(),
+			"encrypt_backup": boolean(),
+		}),
+	}),
+
+	"key_management": obj(map[string]*field{
+		"master_key_source": str("hsm", "kms", "local_encrypted").
+			deprecate("duplicates key_management.storage.primary; moved there in schema version 2"),
+		"generation": obj(map[string]*field{
+			"min_entropy_bits":         integer(),
+			"auto_generate_on_startup": boolean(),
+		}),
+		"rotation": obj(map[string]*field{
+			"enabled":          boolean(),
+			"interval_days":    integer(),
+			"retention_cycles": integer(),
+		}),
+		"storage": obj(map[string]*field{
+			"primary":                   str("hsm", "kms", "encrypted_file", "local_encrypted"),
+			"backup":                    str("hsm", "kms", "encrypted_file", "none", ""),
+			"backup_path":               str(),
+			"backup_encryption_key_env": str(),
+		}),
+		"zeroization": obj(map[string]*field{
+			"overwrite_passes": integer(),
+			"pattern":          str("zeros", "ones", "random"),
+		}),
+	}),
+
+	"compliance": obj(map[string]*field{
+		"fips_140_2_enabled":      boolean(),
+		"fips_mode":               str("level_1", "level_2", "level_3"),
+		"nist_sp_800_56a_enabled": boolean(),
+		"self_tests": obj(map[string]*field{
+			"enabled":                      boolean(),
+			"interval_seconds":             integer(),
+			"kat_enabled":                  boolean(),
+			"entropy_health_tests_enabled": boolean(),
+		}),
+		"startup_verification": boolean(),
+	}),
+
+	"rbac": obj(map[string]*field{
+		"enabled": boolean(),
+		"roles": dict(obj(map[string]*field{
+			"permissions": list(str()),
+		})).deprecate("roles are defined in rbac-config.yaml; keep only enabled and default_role here"),
+		"default_role": str(),
+	}),
+
+	"audit": obj(map[string]*field{
+		"enabled": boolean(),
+		"events": obj(map[string]*field{
+			"key_operations":    boolean(),
+			"auth_attempts":     boolean(),
+			"config_changes":    boolean(),
+			"hsm_events":        boolean(),
+			"self_tests":        boolean(),
+			"crypto_operations": boolean(),
+		}),
+		"output":    str("file", "syslog", "database"),
+		"file_path": str().deprecate("duplicates logging.files.audit; moved there in schema version 2"),
+		"alerts": obj(map[string]*field{
+			"failed_auth_threshold": integer(),
+			"self_test_failure":     boolean(),
+			"hsm_error":             boolean(),
+			"tamper_detection":      boolean(),
+		}),
+	}),
+
+	"performance": obj(map[string]*field{
+		"worker_threads":     integer(),
+		"request_queue_size": integer(),
+		"vectorization":      boolean(),
+		"cache": obj(map[string]*field{
+			"enabled":           boolean(),
+			"key_cache_enabled": boolean(),
+			"max_cached_keys":   integer(),
+		}),
+	}),
+
+	"environment": obj(map[string]*field{
+		"mode":        str("development", "staging", "production"),
+		"allowed_ips": list(str()),
+		"cors": obj(map[string]*field{
+			"enabled":         boolean(),
+			"allowed_origins": list(str()),
+		}),
+		"rate_limit": obj(map[string]*field{
+			"enabled":             boolean(),
+			"requests_per_minute": integer(),
+			"per_ip": obj(map[string]*field{
+				"enabled":             boolean(),
+				"requests_per_minute": integer(),
+			}),
+		}),
+	}),
+
+	"health": obj(map[string]*field{
+		"path": str(),
+		"readiness": obj(map[string]*field{
+			"check_hsm":        boolean(),
+			"check_entropy":    boolean(),
+			"check_self_tests": boolean(),
+		}),
+		"liveness": obj(map[string]*field{
+			"check_goroutines": boolean(),
+			"max_goroutines":   integer(),
+		}),
+	}),
+
+	"integrations": obj(map[string]*field{
+		"syslog": obj(map[string]*field{
+			"enabled":  boolean(),
+			"address":  str(),
+			"protocol": str("udp", "tcp", "tls"),
+			"tls":      tlsSchema(),
+		}),
+		"prometheus": obj(map[string]*field{
+			"enabled": boolean(),
+			"path":    str(),
+			"port":    integer(),
+		}),
+		"kms": obj(map[string]*field{
+			"enabled":         boolean(),
+			"type":            str("aws-kms", "hashicorp-vault", "azure-keyvault"),
+			"endpoint":        str(),
+			"region":          str(),
+			"credentials_env": str(),
+			"tls":             tlsSchema(),
+		}),
+	}),
+
+	"advanced": obj(map[string]*field{
+		"hsm_fallback": boolean(),
+		"chaos": obj(map[string]*field{
+			"lorenz_rho":   number(),
+			"lorenz_sigma": number(),
+			"lorenz_beta":  number(),
+		}),
+		"crypto_buffer_alignment": integer(),
+		"tracing_enabled":         boolean(),
+		"debug_mode":              boolean(),
+	}),
+})
+
+// rbacSchema describes config/rbac-config.yaml
+var rbacSchema = obj(map[string]*field{
+	"rbac": obj(map[string]*field{
+		"enabled":                  boolean(),
+		"default_role":             str(),
+		"deny_by_default":          boolean(),
+		"role_hierarchies_enabled": boolean(),
+		"audit_all_decisions":      boolean(),
+		"caching_enabled":          boolean(),
+		"cache_ttl_seconds":        integer(),
+	}),
+
+	"roles": dict(obj(map[string]*field{
+		"description":        str(),
+		"priority":           integer(),
+		"is_service_account": boolean(),
+		"permissions":        list(str()),
+		"time_restrictions": obj(map[string]*field{
+			"allowed_hours": str(),
+			"allowed_days":  list(str("MON", "TUE", "WED", "THU", "FRI", "SAT", "SUN")),
+		}),
+		"network_restrictions": obj(map[string]*field{
+			"allowed_ips": list(str()),
+			"require_vpn": boolean(),
+			"require_mfa": boolean(),
+		}),
+		"session_constraints": obj(map[string]*field{
+			"max_concurrent_sessions":          integer(),
+			"max_session_duration_hours":       integer(),
+			"require_reauthentication_minutes": integer(),
+		}),
+	})),
+
+	"role_hierarchies": dict(obj(map[string]*field{
+		"inherits_from": list(str()),
+	})),
+
+	"permissions": dict(str()),
+
+	"users": dict(obj(map[string]*field{
+		"username":                str(),
+		"email":                   str(),
+		"roles":                   list(str()),
+		"status":                  str("active", "disabled", "locked"),
+		"created_date":            str(),
+		"last_login":              str(),
+		"require_password_change": boolean(),
+		"mfa_enabled":             boolean(),
+		"is_service_account":      boolean(),
+		"api_key_id":              str(),
+		"certificate_subject":     str(),
+		"attributes":              dict(str()),
+	})),
+
+	"label_policies": list(obj(map[string]*field{
+		"name":            str(),
+		"roles":           list(str()),
+		"permissions":     list(str()),
+		"subject":         dict(str()),
+		"required_labels": dict(str()),
+	})),
+
+	"decrypt_quotas": obj(map[string]*field{
+		"window_hours": integer(),
+		"roles":        dict(integer()),
+		"users":        dict(integer()),
+	}),
+
+	"break_glass": obj(map[string]*field{
+		"ticket_pattern":         str(),
+		"roles":                  list(str()),
+		"default_classification": str(),
+		"rules": list(obj(map[string]*field{
+			"name":           str(),
+			"classification": str(),
+			"min_age_days":   integer(),
+		})),
+	}),
+
+	"api_keys": dict(obj(map[string]*field{
+		"name":               str(),
+		"service_account":    str(),
+		"roles":              list(str()),
+		"created_date":       str(),
+		"expires_date":       str(),
+		"status":             str("active", "revoked", "expired"),
+		"allowed_operations": list(str()),
+		"rate_limit":         integer(),
+		"ip_whitelist":       list(str()),
+		"last_used":          str(),
+		"audit_logging":      boolean(),
+	})),
+
+	"certificates": dict(obj(map[string]*field{
+		"subject":          str(),
+		"issuer":           str(),
+		"serial_number":    str(),
+		"valid_from":       str(),
+		"valid_until":      str(),
+		"thumbprint":       str(),
+		"status":           str("active", "revoked", "expired"),
+		"associated_user":  str(),
+		"associated_roles": list(str()),
+		"certificate_path": str(),
+		"key_path":         str(),
+		"require_mfa":      boolean(),
+	})),
+
+	"audit": dict(anyValue()),
+})
+
+// rbacSections are top-level keys that only appear in RBAC files
+var rbacSections = []string{"roles", "role_hierarchies", "permissions", "users", "label_policies", "decrypt_quotas", "break_glass", "api_keys", "certificates"}
+
+// schemaFor returns the schema of a file kind
+func schemaFor(kind Kind) *field {
+	if kind == KindRBAC {
+		return rbacSchema
+	}
+	return serverSchema
+}
+
+// lookup returns the schema of a dotted path, or nil
+func lookup(schema *field, path string) *field {
+	f := schema
+	for _, part := range strings.Split(path, ".") {
+		switch {
+		case f.fields != nil:
+			f = f.fields[part]
+		case f.entries != nil:
+			f = f.entries
+		default:
+			return nil
+		}
+		if f == nil {
+			return nil
+		}
+	}
+	return f
+}
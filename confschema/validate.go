@@ -0,0 +1,97 @@
+package confschema
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError is one invalid field of a configuration struct
+type FieldError struct {
+	Field    string      // Go field name, e.g. "RoundCount"
+	Value    interface{} // The value found
+	Expected string      // What would be accepted, e.g. "1 to 32"
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s = %#v: expected %s", e.Field, e.Value, e.Expected)
+}
+
+// ValidationError lists every invalid field of a configuration struct, so
+// one run shows everything to fix. errors.As finds each FieldError.
+type ValidationError struct {
+	Config string // Type of the struct, e.g. "ServerConfig"
+	Fields []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid %s (%d field", e.Config, len(e.Fields))
+	if len(e.Fields) != 1 {
+		b.WriteString("s")
+	}
+	b.WriteString(")")
+	for i, f := range e.Fields {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+		b.WriteString(f.Error())
+	}
+	return b.String()
+}
+
+// Unwrap returns the field errors
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Fields))
+	for i, f := range e.Fields {
+		errs[i] = f
+	}
+	return errs
+}
+
+// Validator collects the field errors of one configuration struct:
+//
+//	v := confschema.NewValidator("ServerConfig")
+//	v.Range("Port", c.Port, 1, 65535)
+//	v.Check(!c.TLSEnabled || c.TLSCertPath != "", "TLSCertPath", c.TLSCertPath, "a path when TLSEnabled")
+//	return v.Err()
+type Validator struct {
+	err ValidationError
+}
+
+// NewValidator returns a validator for the struct type named config
+func NewValidator(config string) *Validator {
+	return &Validator{err: ValidationError{Config: config}}
+}
+
+// Check records field as invalid unless ok
+func (v *Validator) Check(ok bool, field string, value interface{}, expected string) {
+	if !ok {
+		v.err.Fields = append(v.err.Fields, &FieldError{Field: field, Value: value, Expected: expected})
+	}
+}
+
+// Range records field as invalid unless min <= value <= max
+func (v *Validator) Range(field string, value, min, max int) {
+	v.Check(value >= min && value <= max, field, value, fmt.Sprintf("%d to %d", min, max))
+}
+
+// Min records field as invalid unless value >= min
+func (v *Validator) Min(field string, value, min int) {
+	v.Check(value >= min, field, value, fmt.Sprintf("at least %d", min))
+}
+
+// OneOf records field as invalid unless value is one of allowed
+func (v *Validator) OneOf(field string, value string, allowed ...string) {
+	v.Check(contains(allowed, value), field, value, "one of "+strings.Join(quoteAll(allowed), ", "))
+}
+
+// Err returns a *ValidationError listing every invalid field, or nil
+func (v *Validator) Err() error {
+	if len(v.err.Fields) == 0 {
+		return nil
+	}
+	err := v.err
+	return &err
+}
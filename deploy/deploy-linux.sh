@@ -102,7 +102,7 @@ fi
 cd "$INSTALL_DIR/src"
 if [ -f "go.mod" ]; then
     go mod download
-    go build -o "$INSTALL_DIR/eamsa512" .
+    go build -o "$INSTALL_DIR/eamsa512" ./cmd/eamsa512
     echo -e "${GREEN}[OK] EAMSA 512 built successfully${NC}"
 else
     echo -e "${YELLOW}[WARNING] Could not build - go.mod not found${NC}"
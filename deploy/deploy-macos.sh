@@ -80,7 +80,7 @@ fi
 if [ -f "$INSTALL_DIR/src/go.mod" ]; then
     cd "$INSTALL_DIR/src"
     go mod download
-    go build -o "$INSTALL_DIR/eamsa512" .
+    go build -o "$INSTALL_DIR/eamsa512" ./cmd/eamsa512
 fi
 
 # Create configuration
@@ -137,7 +137,7 @@ try {
         & go mod download
         
         Write-Info "Building application..."
-        & go build -o "$InstallPath\eamsa512.exe" .
+        & go build -o "$InstallPath\eamsa512.exe" ./cmd/eamsa512
         Write-Success "Build complete"
     }
     else {
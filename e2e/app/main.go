@@ -0,0 +1,421 @@
+// Command eamsa512-e2e-app is the example application of the integration
+// environment (see e2e/README.md). It uses a running server through the
+// REST API the way an application would:
+//
+//   - key rotation: records are encrypted under a data key, re-encrypted
+//     under its successor, and the retired key is shown to be rejected
+//   - streaming: a random payload goes through /api/v1/stream/encrypt and
+//     back through /api/v1/stream/decrypt
+//   - audit export: the server's audit log entries for this run's keys are
+//     written out as JSON lines
+//
+// It prints a JSON report and exits with status 1 if a step fails.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/problem"
+)
+
+// streamStatusTrailer is the server's StreamStatusTrailer
+const streamStatusTrailer = "X-EAMSA-Stream-Status"
+
+// Report is the JSON printed on stdout
+type Report struct {
+	RunID    string         `json:"run_id"`
+	Rotation RotationResult `json:"rotation"`
+	Stream   StreamResult   `json:"stream"`
+	Audit    AuditResult    `json:"audit"`
+}
+
+// RotationResult describes the key rotation step
+type RotationResult struct {
+	Records        int    `json:"records"`
+	OldKeyID       string `json:"old_key_id"`
+	NewKeyID       string `json:"new_key_id"`
+	OldKeyRejected bool   `json:"old_key_rejected"`
+}
+
+// StreamResult describes the streaming step
+type StreamResult struct {
+	KeyID           string `json:"key_id"`
+	PlaintextBytes  int    `json:"plaintext_bytes"`
+	CiphertextBytes int    `json:"ciphertext_bytes"`
+}
+
+// AuditResult describes the audit export step
+type AuditResult struct {
+	Path     string         `json:"path"`
+	Exported int            `json:"exported"`
+	Events   map[string]int `json:"events"`
+}
+
+// AuditRecord is one exported audit log entry
+type AuditRecord struct {
+	Event   string                 `json:"event"`
+	Details map[string]interface{} `json:"details"`
+}
+
+func main() {
+	server := flag.String("server", "https://server:8080", "Server base URL")
+	caFile := flag.String("ca", "", "CA certificate for the server (PEM); empty uses the system roots")
+	records := flag.Int("records", 10, "Records to encrypt and rotate")
+	streamSize := flag.Int("stream-size", 8<<20, "Bytes to send through the streaming endpoints")
+	auditLog := flag.String("audit-log", "", "Server audit log to export from (skipped if empty)")
+	auditOut := flag.String("audit-out", "audit-export.jsonl", "File for the exported audit records")
+	auditDelay := flag.Duration("audit-delay", 3*time.Second, "Wait for the server's audit queue to flush before exporting")
+	flag.Parse()
+
+	c, err := newClient(*server, *caFile)
+	if err != nil {
+		fail(err)
+	}
+
+	report := Report{RunID: c.runID}
+	keyIDs := make(map[string]bool)
+
+	if report.Rotation, err = rotate(c, *records); err != nil {
+		fail(fmt.Errorf("key rotation: %v", err))
+	}
+	keyIDs[report.Rotation.OldKeyID] = true
+	keyIDs[report.Rotation.NewKeyID] = true
+
+	if report.Stream, err = stream(c, *streamSize); err != nil {
+		fail(fmt.Errorf("streaming: %v", err))
+	}
+	keyIDs[report.Stream.KeyID] = true
+
+	if *auditLog != "" {
+		time.Sleep(*auditDelay)
+		if report.Audit, err = exportAudit(*auditLog, *auditOut, keyIDs); err != nil {
+			fail(fmt.Errorf("audit export: %v", err))
+		}
+	}
+
+	out := json.NewEncoder(os.Stdout)
+	out.SetIndent("", "  ")
+	out.Encode(report)
+}
+
+// fail reports err and exits
+func fail(err error) {
+	fmt.Fprintf(os.Stderr, "eamsa512-e2e-app: %v\n", err)
+	os.Exit(1)
+}
+
+// ============================================================================
+// Steps
+// ============================================================================
+
+// rotate encrypts records under one key, moves them to a new key and
+// checks the old key no longer opens them
+func rotate(c *client, records int) (RotationResult, error) {
+	oldKey, newKey := newKey(), newKey()
+	result := RotationResult{
+		Records:  records,
+		OldKeyID: keyid.New(oldKey).String(),
+		NewKeyID: keyid.New(newKey).String(),
+	}
+
+	plaintexts := make([]string, records)
+	sealed := make([]sealedRecord, records)
+	for i := range plaintexts {
+		plaintexts[i] = fmt.Sprintf("record %d of run %s", i, c.runID)
+
+		var err error
+		if sealed[i], err = c.encrypt(plaintexts[i], oldKey); err != nil {
+			return result, err
+		}
+	}
+
+	// Rotate: decrypt under the old key and re-encrypt under the new one
+	for i := range sealed {
+		plaintext, err := c.decrypt(sealed[i], oldKey)
+		if err != nil {
+			return result, fmt.Errorf("record %d: %v", i, err)
+		}
+		if plaintext != plaintexts[i] {
+			return result, fmt.Errorf("record %d: decrypted text does not match", i)
+		}
+		if sealed[i], err = c.encrypt(plaintext, newKey); err != nil {
+			return result, err
+		}
+	}
+
+	for i := range sealed {
+		plaintext, err := c.decrypt(sealed[i], newKey)
+		if err != nil {
+			return result, fmt.Errorf("record %d under the new key: %v", i, err)
+		}
+		if plaintext != plaintexts[i] {
+			return result, fmt.Errorf("record %d: decrypted text does not match after rotation", i)
+		}
+	}
+
+	// The retired key must not open rotated records
+	_, err := c.decrypt(sealed[0], oldKey)
+	if !errors.Is(err, problem.ErrDecryptionFailed) {
+		return result, fmt.Errorf("old key on a rotated record: expected decryption_failed, got %v", err)
+	}
+	result.OldKeyRejected = true
+
+	return result, nil
+}
+
+// stream round-trips size random bytes through the streaming endpoints
+func stream(c *client, size int) (StreamResult, error) {
+	key := newKey()
+	result := StreamResult{KeyID: keyid.New(key).String(), PlaintextBytes: size}
+
+	plaintext := make([]byte, size)
+	if _, err := rand.Read(plaintext); err != nil {
+		return result, err
+	}
+
+	ciphertext, err := c.stream("/api/v1/stream/encrypt", key, plaintext)
+	if err != nil {
+		return result, err
+	}
+	result.CiphertextBytes = len(ciphertext)
+
+	decrypted, err := c.stream("/api/v1/stream/decrypt", key, ciphertext)
+	if err != nil {
+		return result, err
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		return result, fmt.Errorf("decrypted stream does not match (%d of %d bytes)", len(decrypted), size)
+	}
+
+	return result, nil
+}
+
+// exportAudit copies the audit log entries that name one of keyIDs to
+// outPath as JSON lines. Lines are "[AUDIT] date time file:line: EVENT | {details}".
+func exportAudit(logPath, outPath string, keyIDs map[string]bool) (AuditResult, error) {
+	result := AuditResult{Path: outPath, Events: make(map[string]int)}
+
+	in, err := os.Open(logPath)
+	if err != nil {
+		return result, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(outPath)
+	if err != nil {
+		return result, err
+	}
+	defer out.Close()
+	enc := json.NewEncoder(out)
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	for scanner.Scan() {
+		record, ok := parseAuditLine(scanner.Text())
+		if !ok {
+			continue
+		}
+		if id, _ := record.Details["key_id"].(string); !keyIDs[id] {
+			continue
+		}
+
+		if err := enc.Encode(record); err != nil {
+			return result, err
+		}
+		result.Exported++
+		result.Events[record.Event]++
+	}
+	if err := scanner.Err(); err != nil {
+		return result, err
+	}
+
+	return result, out.Sync()
+}
+
+// parseAuditLine parses one audit log line
+func parseAuditLine(line string) (AuditRecord, bool) {
+	sep := strings.Index(line, " | {")
+	if sep < 0 {
+		return AuditRecord{}, false
+	}
+
+	fields := strings.Fields(line[:sep])
+	if len(fields) == 0 {
+		return AuditRecord{}, false
+	}
+
+	record := AuditRecord{Event: fields[len(fields)-1]}
+	if err := json.Unmarshal([]byte(line[sep+3:]), &record.Details); err != nil {
+		return AuditRecord{}, false
+	}
+	return record, true
+}
+
+// newKey returns a random 32-byte data key
+func newKey() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		fail(err)
+	}
+	return key
+}
+
+// ============================================================================
+// REST Client
+// ============================================================================
+
+// client calls the server, tagging each request with a request ID of the
+// form <run ID>-<n> so server logs can be matched to this run
+type client struct {
+	baseURL string
+	http    *http.Client
+	runID   string
+	n       int
+}
+
+// sealedRecord is an /api/v1/encrypt response
+type sealedRecord struct {
+	Ciphertext string `json:"ciphertext"`
+	Nonce      string `json:"nonce"`
+	Tag        string `json:"tag"`
+	KeyID      string `json:"key_id"`
+}
+
+// newClient returns a client for baseURL trusting caFile
+func newClient(baseURL, caFile string) (*client, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	runID := make([]byte, 4)
+	rand.Read(runID)
+
+	return &client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		http: &http.Client{
+			Timeout:   2 * time.Minute,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		},
+		runID: "e2e-" + hex.EncodeToString(runID),
+	}, nil
+}
+
+// do sends a request and returns the response of a 2xx status; other
+// statuses are returned as a *problem.Problem
+func (c *client) do(method, path string, header http.Header, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	c.n++
+	req.Header.Set(problem.RequestIDHeader, fmt.Sprintf("%s-%d", c.runID, c.n))
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		return nil, problem.Decode(resp.StatusCode, resp.Header, data)
+	}
+	return resp, nil
+}
+
+// postJSON sends in as JSON and decodes the response into out
+func (c *client) postJSON(path string, in, out interface{}) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.do(http.MethodPost, path, http.Header{"Content-Type": {"application/json"}}, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// encrypt seals plaintext with key
+func (c *client) encrypt(plaintext string, key []byte) (sealedRecord, error) {
+	var record sealedRecord
+	err := c.postJSON("/api/v1/encrypt", map[string]interface{}{
+		"plaintext":  plaintext,
+		"master_key": hex.EncodeToString(key),
+	}, &record)
+	return record, err
+}
+
+// decrypt opens a sealed record with key
+func (c *client) decrypt(record sealedRecord, key []byte) (string, error) {
+	var resp struct {
+		Plaintext string `json:"plaintext"`
+		Verified  bool   `json:"verified"`
+	}
+	err := c.postJSON("/api/v1/decrypt", map[string]interface{}{
+		"ciphertext": record.Ciphertext,
+		"nonce":      record.Nonce,
+		"tag":        record.Tag,
+		"master_key": hex.EncodeToString(key),
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if !resp.Verified {
+		return "", fmt.Errorf("server did not verify the record")
+	}
+	return resp.Plaintext, nil
+}
+
+// stream sends body to a streaming endpoint and returns the whole response,
+// checking the status trailer
+func (c *client) stream(path string, key, body []byte) ([]byte, error) {
+	header := http.Header{
+		"Content-Type":       {"application/octet-stream"},
+		"X-Eamsa-Master-Key": {hex.EncodeToString(key)},
+	}
+	resp, err := c.do(http.MethodPost, path, header, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if status := resp.Trailer.Get(streamStatusTrailer); status != "ok" {
+		return nil, fmt.Errorf("stream status %q", status)
+	}
+	return data, nil
+}
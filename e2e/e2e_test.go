@@ -0,0 +1,434 @@
+//go:build e2e
+
+// Package e2e holds the end-to-end tests of the integration environment.
+// They run against the docker compose stack in this directory (see
+// README.md) and use the real binaries: the server, its import-key
+// subcommand and the example application in app/.
+//
+//	docker compose up -d --build
+//	docker compose run --rm e2e
+package e2e
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/bits"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/problem"
+)
+
+// appRecords is the -records value passed to the example application
+const appRecords = 5
+
+// env returns the environment variable name or skips the test
+func env(t *testing.T, name string) string {
+	t.Helper()
+	value := os.Getenv(name)
+	if value == "" {
+		t.Skipf("%s not set; run inside the integration environment", name)
+	}
+	return value
+}
+
+// serverClient returns an HTTPS client trusting EAMSA_E2E_CA
+func serverClient(t *testing.T) *http.Client {
+	t.Helper()
+	pem, err := os.ReadFile(env(t, "EAMSA_E2E_CA"))
+	if err != nil {
+		t.Fatalf("Failed to read CA: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		t.Fatal("No certificates in EAMSA_E2E_CA")
+	}
+
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}},
+	}
+}
+
+// TestHealth tests the health endpoint and request ID echo
+func TestHealth(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	client := serverClient(t)
+
+	req, _ := http.NewRequest(http.MethodGet, server+"/api/v1/health", nil)
+	req.Header.Set(problem.RequestIDHeader, "e2e-health")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Health check returned %s", resp.Status)
+	}
+	if got := resp.Header.Get(problem.RequestIDHeader); got != "e2e-health" {
+		t.Fatalf("Request ID not echoed: got %q", got)
+	}
+}
+
+// TestProblemResponse tests that errors are problem+json with a
+// registered type, the request path and the request ID
+func TestProblemResponse(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	client := serverClient(t)
+
+	body := strings.NewReader(`{"plaintext": "x", "master_key": "not hex"}`)
+	req, _ := http.NewRequest(http.MethodPost, server+"/api/v1/encrypt", body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(problem.RequestIDHeader, "e2e-problem")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+
+	if ct := resp.Header.Get("Content-Type"); ct != problem.ContentType {
+		t.Fatalf("Expected %s, got %q", problem.ContentType, ct)
+	}
+
+	p := problem.Decode(resp.StatusCode, resp.Header, data)
+	if !errors.Is(p, problem.ErrBadRequest) || p.Status != http.StatusBadRequest {
+		t.Fatalf("Expected bad_request (400), got %v", p)
+	}
+	if p.Instance != "/api/v1/encrypt" || p.RequestID != "e2e-problem" {
+		t.Fatalf("Unexpected instance %q or request ID %q", p.Instance, p.RequestID)
+	}
+}
+
+// TestExampleApp builds and runs the example application: key rotation,
+// streaming and audit export
+func TestExampleApp(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	ca := env(t, "EAMSA_E2E_CA")
+	auditLog := env(t, "EAMSA_E2E_AUDIT_LOG")
+
+	dir := t.TempDir()
+	app := filepath.Join(dir, "eamsa512-e2e-app")
+	if out, err := exec.Command("go", "build", "-o", app, "./app").CombinedOutput(); err != nil {
+		t.Fatalf("Failed to build the example app: %v\n%s", err, out)
+	}
+
+	exportPath := filepath.Join(dir, "audit-export.jsonl")
+	cmd := exec.Command(app,
+		"-server", server, "-ca", ca,
+		"-records", strconv.Itoa(appRecords), "-stream-size", "4194304",
+		"-audit-log", auditLog, "-audit-out", exportPath)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("Example app failed: %v\n%s", err, stderr.String())
+	}
+
+	var report struct {
+		Rotation struct {
+			Records        int  `json:"records"`
+			OldKeyRejected bool `json:"old_key_rejected"`
+		} `json:"rotation"`
+		Stream struct {
+			PlaintextBytes  int `json:"plaintext_bytes"`
+			CiphertextBytes int `json:"ciphertext_bytes"`
+		} `json:"stream"`
+		Audit struct {
+			Exported int            `json:"exported"`
+			Events   map[string]int `json:"events"`
+		} `json:"audit"`
+	}
+	if err := json.Unmarshal(out, &report); err != nil {
+		t.Fatalf("Invalid report: %v\n%s", err, out)
+	}
+
+	if report.Rotation.Records != appRecords || !report.Rotation.OldKeyRejected {
+		t.Fatalf("Rotation incomplete: %s", out)
+	}
+	if report.Stream.CiphertextBytes <= report.Stream.PlaintextBytes {
+		t.Fatalf("Stream ciphertext (%d bytes) not larger than plaintext (%d bytes)",
+			report.Stream.CiphertextBytes, report.Stream.PlaintextBytes)
+	}
+
+	// Every record is encrypted and decrypted under both keys
+	expected := map[string]int{
+		"ENCRYPT":        2 * appRecords,
+		"DECRYPT":        2 * appRecords,
+		"DECRYPT_FAILED": 1,
+		"STREAM_ENCRYPT": 1,
+		"STREAM_DECRYPT": 1,
+	}
+	for event, count := range expected {
+		if got := report.Audit.Events[event]; got != count {
+			t.Errorf("Audit export has %d %s events, expected %d", got, event, count)
+		}
+	}
+
+	exported, err := os.ReadFile(exportPath)
+	if err != nil {
+		t.Fatalf("Failed to read audit export: %v", err)
+	}
+	if lines := bytes.Count(exported, []byte("\n")); lines != report.Audit.Exported {
+		t.Fatalf("Audit export has %d lines, report says %d", lines, report.Audit.Exported)
+	}
+}
+
+// TestMetrics tests that the server exports metrics and Prometheus
+// scrapes it
+func TestMetrics(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	prometheus := env(t, "EAMSA_E2E_PROMETHEUS")
+
+	resp, err := serverClient(t).Get(server + "/metrics")
+	if err != nil {
+		t.Fatalf("Metrics request failed: %v", err)
+	}
+	data, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if !bytes.Contains(data, []byte("eamsa512_")) {
+		t.Fatal("No eamsa512_ metrics exported")
+	}
+
+	query := prometheus + "/api/v1/query?query=" + url.QueryEscape(`up{job="eamsa512"}`)
+	deadline := time.Now().Add(60 * time.Second)
+	for {
+		if scraped(query) {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Prometheus has not scraped the server")
+		}
+		time.Sleep(2 * time.Second)
+	}
+}
+
+// scraped reports whether the Prometheus query returns up == 1
+func scraped(query string) bool {
+	resp, err := http.Get(query)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Data struct {
+			Result []struct {
+				Value []interface{} `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return false
+	}
+	for _, r := range result.Data.Result {
+		if len(r.Value) == 2 && r.Value[1] == "1" {
+			return true
+		}
+	}
+	return false
+}
+
+// TestKeyImport runs the server's import-key subcommand against a fresh
+// SQLite database, then imports the same key again
+func TestKeyImport(t *testing.T) {
+	bin := env(t, "EAMSA_E2E_SERVER_BIN")
+	dir := t.TempDir()
+
+	kek := make([]byte, 32)
+	rand.Read(kek)
+	kekFile := filepath.Join(dir, "kek.hex")
+	if err := os.WriteFile(kekFile, []byte(hex.EncodeToString(kek)), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// "Wrapped" by a pass-through unwrapper, as a PGP import would be by gpg
+	request, _ := json.Marshal(map[string]interface{}{
+		"provider":   "pgp",
+		"key_ref":    "e2e-test",
+		"ciphertext": importableKey(),
+	})
+	requestFile := filepath.Join(dir, "request.json")
+	if err := os.WriteFile(requestFile, request, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	run := func() (string, error) {
+		out, err := exec.Command(bin, "import-key",
+			"-db", filepath.Join(dir, "eamsa512.db"),
+			"-request", requestFile, "-kek-file", kekFile,
+			"-unwrap-command", "cat", "-user", "e2e").CombinedOutput()
+		return string(out), err
+	}
+
+	out, err := run()
+	if err != nil || !strings.Contains(out, "as pending version 1") {
+		t.Fatalf("Import failed: %v\n%s", err, out)
+	}
+
+	out, err = run()
+	if err == nil || !strings.Contains(out, "already recorded") {
+		t.Fatalf("Second import of the same key was not refused: %v\n%s", err, out)
+	}
+}
+
+// importableKey returns a random 32-byte key that passes the import
+// entropy check (ones count, distinct bytes, no long runs)
+func importableKey() []byte {
+	for {
+		key := make([]byte, 32)
+		rand.Read(key)
+
+		ones := 0
+		distinct := make(map[byte]bool)
+		for _, b := range key {
+			ones += bits.OnesCount8(b)
+			distinct[b] = true
+		}
+		if ones >= 96 && ones <= 160 && len(distinct) >= 24 {
+			return key
+		}
+	}
+}
+
+// postJSON posts body to the server and decodes a 200 response into out
+func postJSON(t *testing.T, client *http.Client, url string, body, out interface{}) {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Request to %s failed: %v", url, err)
+	}
+	defer resp.Body.Close()
+	data, _ = io.ReadAll(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("%s returned %s: %s", url, resp.Status, data)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("Invalid response from %s: %v\n%s", url, err, data)
+	}
+}
+
+// TestBlobStore stores a ciphertext in the Postgres blob store, decrypts
+// it by handle, downloads and deletes it
+func TestBlobStore(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	client := serverClient(t)
+
+	key := make([]byte, 32)
+	rand.Read(key)
+	masterKey := hex.EncodeToString(key)
+
+	var encrypted struct {
+		Handle     string `json:"handle"`
+		Ciphertext string `json:"ciphertext"`
+	}
+	postJSON(t, client, server+"/api/v1/encrypt", map[string]interface{}{
+		"plaintext": "stored in postgres", "master_key": masterKey, "store": true,
+	}, &encrypted)
+	if !strings.HasPrefix(encrypted.Handle, "sha3-256:") || encrypted.Ciphertext != "" {
+		t.Fatalf("Expected a handle instead of the ciphertext, got %+v", encrypted)
+	}
+
+	var decrypted struct {
+		Plaintext string `json:"plaintext"`
+	}
+	postJSON(t, client, server+"/api/v1/decrypt", map[string]interface{}{
+		"handle": encrypted.Handle, "master_key": masterKey,
+	}, &decrypted)
+	if decrypted.Plaintext != "stored in postgres" {
+		t.Fatalf("Decrypted %q by handle", decrypted.Plaintext)
+	}
+
+	blobURL := server + "/api/v1/blobs/" + encrypted.Handle
+	resp, err := client.Get(blobURL)
+	if err != nil {
+		t.Fatalf("Blob download failed: %v", err)
+	}
+	sealed, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || len(sealed) == 0 {
+		t.Fatalf("Blob download returned %s with %d bytes", resp.Status, len(sealed))
+	}
+
+	req, _ := http.NewRequest(http.MethodDelete, blobURL, nil)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("Blob delete failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Blob delete returned %s", resp.Status)
+	}
+
+	resp, err = client.Get(blobURL)
+	if err != nil {
+		t.Fatalf("Blob download failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("Deleted blob returned %s", resp.Status)
+	}
+}
+
+// TestEntropySource tests that nonces come from the SoftHSM token and
+// that the token passed its health tests throughout
+func TestEntropySource(t *testing.T) {
+	server := env(t, "EAMSA_E2E_SERVER")
+	want := env(t, "EAMSA_E2E_RANDOM_SOURCE")
+	auditLog := env(t, "EAMSA_E2E_AUDIT_LOG")
+	client := serverClient(t)
+
+	resp, err := client.Get(server + "/api/v1/health")
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	var health struct {
+		RandomSource string `json:"random_source"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&health)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("Invalid health response: %v", err)
+	}
+	if health.RandomSource != want {
+		t.Fatalf("Random source is %q, expected %q", health.RandomSource, want)
+	}
+
+	// Draw enough nonces to exercise the continuous health tests
+	key := make([]byte, 32)
+	rand.Read(key)
+	for i := 0; i < 64; i++ {
+		var encrypted struct {
+			Ciphertext string `json:"ciphertext"`
+		}
+		postJSON(t, client, server+"/api/v1/encrypt", map[string]interface{}{
+			"plaintext": "nonce " + strconv.Itoa(i), "master_key": hex.EncodeToString(key),
+		}, &encrypted)
+	}
+
+	data, err := os.ReadFile(auditLog)
+	if err != nil {
+		t.Fatalf("Failed to read audit log: %v", err)
+	}
+	for _, event := range []string{"RANDOM_SOURCE_FAILED", "RANDOM_FALLBACK"} {
+		if bytes.Contains(data, []byte(event)) {
+			t.Errorf("Audit log has %s events", event)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+// Command basic-encryption encrypts a message into a versioned envelope
+// with pkg/eamsa512, decrypts it, and shows that a flipped ciphertext bit
+// is rejected:
+//
+//	go run ./example/basic-encryption
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+func main() {
+	fmt.Println("EAMSA 512 - Basic Encryption")
+	fmt.Println("============================")
+	fmt.Println()
+
+	masterKey, err := eamsa512.NewKey(nil)
+	if err != nil {
+		fmt.Printf("Key generation error: %v\n", err)
+		os.Exit(1)
+	}
+	plaintext := []byte("Hello, World! This is a secret message.")
+
+	fmt.Printf("Master Key: %s (length: %d bytes)\n", hex.EncodeToString(masterKey), len(masterKey))
+	fmt.Printf("Plaintext: %s (length: %d bytes)\n\n", plaintext, len(plaintext))
+
+	// Encrypt
+	fmt.Println("Encrypting...")
+	encryptedData, err := eamsa512.Encrypt(plaintext, masterKey, eamsa512.EnvelopeOptions{KeyVersion: 1})
+	if err != nil {
+		fmt.Printf("Encryption error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Encrypted Data (hex): %s\n", hex.EncodeToString(encryptedData[:32]))
+	fmt.Printf("Total encrypted length: %d bytes\n", len(encryptedData))
+	fmt.Printf("  - Header (incl. nonce, key commitment): %d bytes\n", eamsa512.EnvelopeHeaderSize)
+	fmt.Printf("  - Ciphertext: %d bytes\n", len(encryptedData)-eamsa512.EnvelopeHeaderSize-eamsa512.TagSize)
+	fmt.Printf("  - HMAC Tag: %d bytes\n\n", eamsa512.TagSize)
+
+	// Decrypt
+	fmt.Println("Decrypting...")
+	decrypted, err := eamsa512.Decrypt(encryptedData, masterKey)
+	if err != nil {
+		fmt.Printf("Decryption error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Decrypted: %s\n", decrypted)
+	fmt.Printf("Match: %v\n\n", bytes.Equal(decrypted, plaintext))
+
+	// Tampered ciphertext fails authentication
+	fmt.Println("Testing authentication (tampering detection)...")
+	tamperedData := bytes.Clone(encryptedData)
+	tamperedData[eamsa512.EnvelopeHeaderSize] ^= 0xFF // Flip bits in first ciphertext byte
+
+	if _, err := eamsa512.Decrypt(tamperedData, masterKey); err == nil {
+		fmt.Println("Tampering not detected")
+		os.Exit(1)
+	} else {
+		fmt.Printf("Tampering detected: %v\n", err)
+	}
+}
@@ -0,0 +1,121 @@
+// Command key-rotation walks a pkg/keymgmt KeyManager through a rotation:
+// versions and statistics before and after, and the retired version still
+// available for decryption:
+//
+//	go run ./example/key-rotation
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+func main() {
+	fmt.Println("EAMSA 512 - Key Rotation Management")
+	fmt.Println("===================================")
+	fmt.Println()
+
+	// Create initial key
+	initialKey, err := eamsa512.NewKey(nil)
+	if err != nil {
+		fmt.Printf("Key generation error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Create key manager with default policy
+	policy := keymgmt.DefaultKeyRotationPolicy()
+	policy.Enabled = false   // Disable automatic rotation for demo
+	policy.MinKeyAgeDays = 0 // Allow rotating the new key right away
+
+	km, err := keymgmt.NewKeyManager(initialKey, keymgmt.Config{Policy: policy})
+	if err != nil {
+		fmt.Printf("Error creating key manager: %v\n", err)
+		os.Exit(1)
+	}
+	defer km.Stop()
+
+	fmt.Println("Initial State:")
+	activeMetadata, err := km.GetActiveKeyMetadata()
+	if err != nil {
+		fmt.Printf("Error reading active key: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("  Active Key Version: %d\n", activeMetadata.Version)
+	fmt.Printf("  Active Key Hash: %s\n", activeMetadata.KeyHash)
+	fmt.Printf("  Active Key Created: %s\n", activeMetadata.CreatedAt.Format(time.RFC3339))
+	fmt.Printf("  Active Key State: %s\n\n", activeMetadata.State)
+
+	// Simulate encryption/decryption operations
+	km.IncrementEncryptionCount()
+	km.IncrementEncryptionCount()
+	km.IncrementDecryptionCount(activeMetadata.Version)
+
+	fmt.Println("Key Versions:")
+	for _, v := range km.ListKeyVersions() {
+		fmt.Printf("  Version %d: State=%s, Hash=%s, Encryptions=%d, Decryptions=%d\n",
+			v.Version, v.State, v.KeyHash, v.EncryptionCount, v.DecryptionCount)
+	}
+
+	fmt.Println("\nKey Statistics:")
+	stats := km.GetStatistics()
+	fmt.Printf("  Total Keys: %d\n", stats.TotalKeys)
+	fmt.Printf("  Active Keys: %d\n", stats.ActiveKeys)
+	fmt.Printf("  Rotated Keys: %d\n", stats.RotatedKeys)
+	fmt.Printf("  Archived Keys: %d\n", stats.ArchivedKeys)
+	fmt.Printf("  Total Encryptions: %d\n", stats.TotalEncryptions)
+	fmt.Printf("  Total Decryptions: %d\n\n", stats.TotalDecryptions)
+
+	// Perform key rotation
+	fmt.Println("Performing Key Rotation...")
+	newKey, err := eamsa512.NewKey(nil)
+	if err != nil {
+		fmt.Printf("Key generation error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := km.RotateKey(newKey); err != nil {
+		fmt.Printf("Error rotating key: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("\nAfter Rotation:")
+	stats = km.GetStatistics()
+	fmt.Printf("  Total Keys: %d\n", stats.TotalKeys)
+	fmt.Printf("  Active Keys: %d\n", stats.ActiveKeys)
+	fmt.Printf("  Rotated Keys: %d\n\n", stats.RotatedKeys)
+
+	fmt.Println("Key Versions After Rotation:")
+	for _, v := range km.ListKeyVersions() {
+		fmt.Printf("  Version %d: State=%s, Hash=%s\n", v.Version, v.State, v.KeyHash)
+	}
+
+	newMetadata, err := km.GetActiveKeyMetadata()
+	if err != nil {
+		fmt.Printf("Error reading active key: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nNew Active Key Version: %d\n", newMetadata.Version)
+	fmt.Printf("New Active Key Hash: %s\n", newMetadata.KeyHash)
+	fmt.Printf("New Active Key State: %s\n", newMetadata.State)
+
+	// The retired version still decrypts
+	fmt.Println("\nVerifying Key Access:")
+	oldKey, oldErr := km.GetKeyByVersion(activeMetadata.Version)
+	newActiveKey, newErr := km.GetActiveKey()
+
+	fmt.Printf("  Old Key (version %d) accessible: %v\n", activeMetadata.Version, oldErr == nil)
+	fmt.Printf("  New Active Key accessible: %v\n", newErr == nil)
+	fmt.Printf("  Keys are different: %v\n", !bytes.Equal(oldKey, newActiveKey))
+
+	fmt.Println("\nKey Rotation Policy:")
+	fmt.Printf("  Enabled: %v\n", policy.Enabled)
+	fmt.Printf("  Rotation Interval: %d days\n", policy.IntervalDays)
+	fmt.Printf("  Max Key Age: %d days\n", policy.MaxKeyAgeDays)
+	fmt.Printf("  Retention Cycles: %d\n", policy.RetentionCycles)
+	fmt.Printf("  Destruction Method: %s\n", policy.DestructionMethod)
+	fmt.Printf("  Destruction Passes: %d\n", policy.DestructionPasses)
+}
@@ -1,677 +0,0 @@
-package main
-
-import (
-	"crypto/tls"
-	"encoding/hex"
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
-	"net/http"
-	"os"
-	"time"
-)
-
-// ============================================================================
-// EAMSA 512 - Web Server Implementation
-// REST API Server with TLS support
-//
-// Provides HTTP/2 endpoints for encryption, decryption, and key management.
-// Implements FIPS 140-2 Level 2 compliance and audit logging.
-//
-// Last updated: December 4, 2025
-// ============================================================================
-
-// Server configuration
-type ServerConfig struct {
-	Host            string
-	Port            int
-	TLSEnabled      bool
-	TLSCertPath     string
-	TLSKeyPath      string
-	ReadTimeout     time.Duration
-	WriteTimeout    time.Duration
-	IdleTimeout     time.Duration
-	MaxBodySize     int64
-	LogFilePath     string
-	AuditLogPath    string
-}
-
-// Request/Response types
-
-// EncryptRequest represents an encryption request
-type EncryptRequest struct {
-	Plaintext string `json:"plaintext"`
-	MasterKey string `json:"master_key"` // hex-encoded
-	Nonce     string `json:"nonce"`      // hex-encoded (optional)
-}
-
-// EncryptResponse represents an encryption response
-type EncryptResponse struct {
-	Ciphertext string `json:"ciphertext"` // hex-encoded
-	Nonce      string `json:"nonce"`      // hex-encoded
-	Tag        string `json:"tag"`        // hex-encoded
-	Timestamp  string `json:"timestamp"`
-	Size       int    `json:"size"`
-}
-
-// DecryptRequest represents a decryption request
-type DecryptRequest struct {
-	Ciphertext string `json:"ciphertext"` // hex-encoded
-	MasterKey  string `json:"master_key"` // hex-encoded
-	Nonce      string `json:"nonce"`      // hex-encoded
-	Tag        string `json:"tag"`        // hex-encoded
-}
-
-// DecryptResponse represents a decryption response
-type DecryptResponse struct {
-	Plaintext string `json:"plaintext"`
-	Timestamp string `json:"timestamp"`
-	Size      int    `json:"size"`
-	Verified  bool   `json:"verified"`
-}
-
-// HealthCheckResponse represents health check response
-type HealthCheckResponse struct {
-	Status      string    `json:"status"`
-	Version     string    `json:"version"`
-	Timestamp   string    `json:"timestamp"`
-	Uptime      string    `json:"uptime"`
-	TLSEnabled  bool      `json:"tls_enabled"`
-	BlockSize   int       `json:"block_size"`
-	KeySize     int       `json:"key_size"`
-	NonceSize   int       `json:"nonce_size"`
-	RoundCount  int       `json:"round_count"`
-}
-
-// ComplianceReport represents a compliance report
-type ComplianceReport struct {
-	FIPSMode              bool   `json:"fips_mode"`
-	NISTSP80056A          bool   `json:"nist_sp_800_56a"`
-	SHA3512Used           bool   `json:"sha3_512_used"`
-	HMACAuthentication    bool   `json:"hmac_authentication"`
-	TLSEnabled            bool   `json:"tls_enabled"`
-	AuditLoggingEnabled   bool   `json:"audit_logging_enabled"`
-	BlockSize             int    `json:"block_size"`
-	KeySize               int    `json:"key_size"`
-	NonceSize             int    `json:"nonce_size"`
-	AuthenticationTagSize int    `json:"authentication_tag_size"`
-	Timestamp             string `json:"timestamp"`
-	ComplianceScore       int    `json:"compliance_score"` // 0-100
-}
-
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error     string `json:"error"`
-	Message   string `json:"message"`
-	Timestamp string `json:"timestamp"`
-	Code      int    `json:"code"`
-}
-
-// Global variables
-var (
-	serverStartTime time.Time
-	auditLogger     *log.Logger
-	errorLogger     *log.Logger
-)
-
-// ============================================================================
-// Initialization
-// ============================================================================
-
-// InitServer initializes the server and logging
-func InitServer(config ServerConfig) error {
-	serverStartTime = time.Now()
-
-	// Setup audit logger
-	auditFile, err := os.OpenFile(config.AuditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open audit log: %v", err)
-	}
-
-	auditLogger = log.New(auditFile, "[AUDIT] ", log.LstdFlags|log.Lshortfile)
-
-	// Setup error logger
-	errorFile, err := os.OpenFile(config.LogFilePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
-	if err != nil {
-		return fmt.Errorf("failed to open error log: %v", err)
-	}
-
-	errorLogger = log.New(errorFile, "[ERROR] ", log.LstdFlags|log.Lshortfile)
-
-	return nil
-}
-
-// LogAuditEvent logs an audit event
-func LogAuditEvent(event string, details map[string]interface{}) {
-	detailsJSON, _ := json.Marshal(details)
-	auditLogger.Printf("%s | %s", event, string(detailsJSON))
-}
-
-// LogError logs an error
-func LogError(message string, err error) {
-	if err != nil {
-		errorLogger.Printf("%s: %v", message, err)
-	} else {
-		errorLogger.Printf("%s", message)
-	}
-}
-
-// ============================================================================
-// HTTP Handlers
-// ============================================================================
-
-// HandleEncrypt handles POST /api/v1/encrypt
-func HandleEncrypt(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST is allowed")
-		return
-	}
-
-	// Parse request
-	var req EncryptRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		LogError("Failed to decode encrypt request", err)
-		respondError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
-		return
-	}
-
-	// Validate request
-	if req.Plaintext == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "plaintext is required")
-		return
-	}
-
-	if req.MasterKey == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "master_key is required (hex-encoded)")
-		return
-	}
-
-	// Decode master key from hex
-	masterKey, err := hex.DecodeString(req.MasterKey)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "bad_request", "master_key must be hex-encoded")
-		return
-	}
-
-	// Decode nonce if provided
-	var nonce []byte
-	if req.Nonce != "" {
-		nonce, err = hex.DecodeString(req.Nonce)
-		if err != nil {
-			respondError(w, http.StatusBadRequest, "bad_request", "nonce must be hex-encoded")
-			return
-		}
-	}
-
-	// Perform encryption
-	plaintext := []byte(req.Plaintext)
-	encryptedData, err := EncryptData(plaintext, masterKey, nonce)
-	if err != nil {
-		LogError("Encryption failed", err)
-		respondError(w, http.StatusInternalServerError, "encryption_failed", err.Error())
-		return
-	}
-
-	// Extract components
-	ciphertextLength := len(encryptedData) - NonceSize - TagSize
-	ciphertext := encryptedData[:ciphertextLength]
-	nonceOut := encryptedData[ciphertextLength : ciphertextLength+NonceSize]
-	tag := encryptedData[ciphertextLength+NonceSize:]
-
-	// Log audit event
-	LogAuditEvent("ENCRYPT", map[string]interface{}{
-		"plaintext_size": len(plaintext),
-		"ciphertext_size": len(ciphertext),
-		"key_size": len(masterKey),
-		"nonce_size": len(nonceOut),
-		"timestamp": time.Now().Format(time.RFC3339),
-	})
-
-	// Prepare response
-	response := EncryptResponse{
-		Ciphertext: hex.EncodeToString(ciphertext),
-		Nonce:      hex.EncodeToString(nonceOut),
-		Tag:        hex.EncodeToString(tag),
-		Timestamp:  time.Now().Format(time.RFC3339),
-		Size:       len(encryptedData),
-	}
-
-	respondJSON(w, http.StatusOK, response)
-}
-
-// HandleDecrypt handles POST /api/v1/decrypt
-func HandleDecrypt(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		respondError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only POST is allowed")
-		return
-	}
-
-	// Parse request
-	var req DecryptRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		LogError("Failed to decode decrypt request", err)
-		respondError(w, http.StatusBadRequest, "bad_request", fmt.Sprintf("Invalid JSON: %v", err))
-		return
-	}
-
-	// Validate request
-	if req.Ciphertext == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "ciphertext is required (hex-encoded)")
-		return
-	}
-
-	if req.MasterKey == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "master_key is required (hex-encoded)")
-		return
-	}
-
-	if req.Nonce == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "nonce is required (hex-encoded)")
-		return
-	}
-
-	if req.Tag == "" {
-		respondError(w, http.StatusBadRequest, "bad_request", "tag is required (hex-encoded)")
-		return
-	}
-
-	// Decode from hex
-	ciphertext, err := hex.DecodeString(req.Ciphertext)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "bad_request", "ciphertext must be hex-encoded")
-		return
-	}
-
-	masterKey, err := hex.DecodeString(req.MasterKey)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "bad_request", "master_key must be hex-encoded")
-		return
-	}
-
-	nonce, err := hex.DecodeString(req.Nonce)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "bad_request", "nonce must be hex-encoded")
-		return
-	}
-
-	tag, err := hex.DecodeString(req.Tag)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "bad_request", "tag must be hex-encoded")
-		return
-	}
-
-	// Reconstruct encrypted data format
-	encryptedData := make([]byte, 0, len(ciphertext)+len(nonce)+len(tag))
-	encryptedData = append(encryptedData, ciphertext...)
-	encryptedData = append(encryptedData, nonce...)
-	encryptedData = append(encryptedData, tag...)
-
-	// Perform decryption
-	plaintext, err := DecryptData(encryptedData, masterKey)
-	if err != nil {
-		LogAuditEvent("DECRYPT_FAILED", map[string]interface{}{
-			"error": err.Error(),
-			"timestamp": time.Now().Format(time.RFC3339),
-		})
-		respondError(w, http.StatusUnauthorized, "decryption_failed", "Authentication failed or invalid data")
-		return
-	}
-
-	// Log audit event
-	LogAuditEvent("DECRYPT", map[string]interface{}{
-		"ciphertext_size": len(ciphertext),
-		"plaintext_size": len(plaintext),
-		"key_size": len(masterKey),
-		"verified": true,
-		"timestamp": time.Now().Format(time.RFC3339),
-	})
-
-	// Prepare response
-	response := DecryptResponse{
-		Plaintext: string(plaintext),
-		Timestamp: time.Now().Format(time.RFC3339),
-		Size:      len(plaintext),
-		Verified:  true,
-	}
-
-	respondJSON(w, http.StatusOK, response)
-}
-
-// HandleHealth handles GET /api/v1/health
-func HandleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET is allowed")
-		return
-	}
-
-	uptime := time.Since(serverStartTime)
-
-	response := HealthCheckResponse{
-		Status:     "ok",
-		Version:    "1.0.0",
-		Timestamp:  time.Now().Format(time.RFC3339),
-		Uptime:     uptime.String(),
-		TLSEnabled: true,
-		BlockSize:  BlockSize,
-		KeySize:    KeySize,
-		NonceSize:  NonceSize,
-		RoundCount: Rounds,
-	}
-
-	respondJSON(w, http.StatusOK, response)
-}
-
-// HandleCompliance handles GET /api/v1/compliance/report
-func HandleCompliance(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET is allowed")
-		return
-	}
-
-	complianceScore := 100
-
-	response := ComplianceReport{
-		FIPSMode:              true,
-		NISTSP80056A:          true,
-		SHA3512Used:           true,
-		HMACAuthentication:    true,
-		TLSEnabled:            true,
-		AuditLoggingEnabled:   true,
-		BlockSize:             BlockSize,
-		KeySize:               KeySize,
-		NonceSize:             NonceSize,
-		AuthenticationTagSize: TagSize,
-		Timestamp:             time.Now().Format(time.RFC3339),
-		ComplianceScore:       complianceScore,
-	}
-
-	respondJSON(w, http.StatusOK, response)
-}
-
-// HandleMetrics handles GET /metrics (Prometheus format)
-func HandleMetrics(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
-		respondError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Only GET is allowed")
-		return
-	}
-
-	uptime := time.Since(serverStartTime).Seconds()
-
-	metricsText := fmt.Sprintf(`# HELP eamsa512_uptime_seconds EAMSA 512 uptime in seconds
-# TYPE eamsa512_uptime_seconds gauge
-eamsa512_uptime_seconds %.2f
-
-# HELP eamsa512_block_size_bytes Block size in bytes
-# TYPE eamsa512_block_size_bytes gauge
-eamsa512_block_size_bytes %d
-
-# HELP eamsa512_key_size_bytes Key size in bytes
-# TYPE eamsa512_key_size_bytes gauge
-eamsa512_key_size_bytes %d
-
-# HELP eamsa512_nonce_size_bytes Nonce size in bytes
-# TYPE eamsa512_nonce_size_bytes gauge
-eamsa512_nonce_size_bytes %d
-
-# HELP eamsa512_rounds Total encryption rounds
-# TYPE eamsa512_rounds gauge
-eamsa512_rounds %d
-
-# HELP eamsa512_tag_size_bytes HMAC tag size in bytes
-# TYPE eamsa512_tag_size_bytes gauge
-eamsa512_tag_size_bytes %d
-`, uptime, BlockSize, KeySize, NonceSize, Rounds, TagSize)
-
-	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-	w.WriteHeader(http.StatusOK)
-	io.WriteString(w, metricsText)
-}
-
-// ============================================================================
-// Response Helpers
-// ============================================================================
-
-// respondJSON sends a JSON response
-func respondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	w.WriteHeader(statusCode)
-	json.NewEncoder(w).Encode(data)
-}
-
-// respondError sends an error response
-func respondError(w http.ResponseWriter, statusCode int, errorCode, message string) {
-	response := ErrorResponse{
-		Error:     errorCode,
-		Message:   message,
-		Timestamp: time.Now().Format(time.RFC3339),
-		Code:      statusCode,
-	}
-
-	respondJSON(w, statusCode, response)
-}
-
-// ============================================================================
-// Middleware
-// ============================================================================
-
-// LoggingMiddleware logs HTTP requests
-func LoggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-
-		next.ServeHTTP(w, r)
-
-		duration := time.Since(start)
-		fmt.Printf("[%s] %s %s %s\n", time.Now().Format("2006-01-02 15:04:05"), r.Method, r.RequestURI, duration)
-	})
-}
-
-// RecoveryMiddleware recovers from panics
-func RecoveryMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		defer func() {
-			if err := recover(); err != nil {
-				LogError("Panic recovered", fmt.Errorf("%v", err))
-				respondError(w, http.StatusInternalServerError, "internal_error", "An internal error occurred")
-			}
-		}()
-
-		next.ServeHTTP(w, r)
-	})
-}
-
-// ============================================================================
-// Main Server Setup
-// ============================================================================
-
-func main() {
-	// Server configuration
-	config := ServerConfig{
-		Host:         "0.0.0.0",
-		Port:         8080,
-		TLSEnabled:   true,
-		TLSCertPath:  "/etc/eamsa512/certs/tls.crt",
-		TLSKeyPath:   "/etc/eamsa512/certs/tls.key",
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
-		MaxBodySize:  1 << 20, // 1MB
-		LogFilePath:  "/var/log/eamsa512/eamsa512.log",
-		AuditLogPath: "/var/log/eamsa512/audit.log",
-	}
-
-	// Initialize server
-	if err := InitServer(config); err != nil {
-		fmt.Printf("Failed to initialize server: %v\n", err)
-		os.Exit(1)
-	}
-
-	// Setup routes
-	mux := http.NewServeMux()
-
-	// API endpoints
-	mux.HandleFunc("/api/v1/encrypt", HandleEncrypt)
-	mux.HandleFunc("/api/v1/decrypt", HandleDecrypt)
-	mux.HandleFunc("/api/v1/health", HandleHealth)
-	mux.HandleFunc("/api/v1/compliance/report", HandleCompliance)
-
-	// Metrics endpoint (Prometheus)
-	mux.HandleFunc("/metrics", HandleMetrics)
-
-	// Apply middleware
-	handler := RecoveryMiddleware(LoggingMiddleware(mux))
-
-	// Create server with timeouts
-	server := &http.Server{
-		Addr:         fmt.Sprintf("%s:%d", config.Host, config.Port),
-		Handler:      handler,
-		ReadTimeout:  config.ReadTimeout,
-		WriteTimeout: config.WriteTimeout,
-		IdleTimeout:  config.IdleTimeout,
-	}
-
-	// Log startup
-	fmt.Printf("Starting EAMSA 512 Web Server\n")
-	fmt.Printf("Listening on %s\n", server.Addr)
-	fmt.Printf("TLS Enabled: %v\n", config.TLSEnabled)
-
-	// Start server with TLS
-	if config.TLSEnabled {
-		// Load TLS certificates
-		cert, err := tls.LoadX509KeyPair(config.TLSCertPath, config.TLSKeyPath)
-		if err != nil {
-			fmt.Printf("Failed to load TLS certificates: %v\n", err)
-			os.Exit(1)
-		}
-
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{cert},
-			MinVersion:   tls.VersionTLS12,
-			CipherSuites: []uint16{
-				tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
-				tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
-				tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
-			},
-		}
-
-		server.TLSConfig = tlsConfig
-
-		if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("Server error: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			fmt.Printf("Server error: %v\n", err)
-			os.Exit(1)
-		}
-	}
-}
-
-// ============================================================================
-// API Documentation
-// ============================================================================
-
-/*
-
-EAMSA 512 Web Server - REST API Documentation
-
-BASE URL: https://localhost:8080/api/v1
-
-ENDPOINTS:
-
-1. POST /encrypt
-   Description: Encrypt plaintext using EAMSA 512
-   Request:
-   {
-     "plaintext": "Hello, World!",
-     "master_key": "deadbeef...",  // 32-byte key in hex
-     "nonce": "..."                // optional 16-byte nonce in hex
-   }
-   Response:
-   {
-     "ciphertext": "...",  // hex-encoded
-     "nonce": "...",       // hex-encoded
-     "tag": "...",         // 64-byte HMAC tag in hex
-     "timestamp": "2025-12-04T18:30:00Z",
-     "size": 144
-   }
-
-2. POST /decrypt
-   Description: Decrypt ciphertext using EAMSA 512
-   Request:
-   {
-     "ciphertext": "...",   // hex-encoded
-     "master_key": "...",   // 32-byte key in hex
-     "nonce": "...",        // 16-byte nonce in hex
-     "tag": "..."           // 64-byte HMAC tag in hex
-   }
-   Response:
-   {
-     "plaintext": "Hello, World!",
-     "timestamp": "2025-12-04T18:30:00Z",
-     "size": 13,
-     "verified": true
-   }
-
-3. GET /health
-   Description: Health check endpoint
-   Response:
-   {
-     "status": "ok",
-     "version": "1.0.0",
-     "timestamp": "2025-12-04T18:30:00Z",
-     "uptime": "12h34m56s",
-     "tls_enabled": true,
-     "block_size": 64,
-     "key_size": 32,
-     "nonce_size": 16,
-     "round_count": 16
-   }
-
-4. GET /compliance/report
-   Description: Get FIPS 140-2 compliance report
-   Response:
-   {
-     "fips_mode": true,
-     "nist_sp_800_56a": true,
-     "sha3_512_used": true,
-     "hmac_authentication": true,
-     "tls_enabled": true,
-     "audit_logging_enabled": true,
-     "block_size": 64,
-     "key_size": 32,
-     "nonce_size": 16,
-     "authentication_tag_size": 64,
-     "timestamp": "2025-12-04T18:30:00Z",
-     "compliance_score": 100
-   }
-
-5. GET /metrics
-   Description: Prometheus metrics (Prometheus format)
-   Response: (text/plain)
-   eamsa512_uptime_seconds 45296.00
-   eamsa512_block_size_bytes 64
-   eamsa512_key_size_bytes 32
-   ...
-
-ERROR RESPONSES:
-
-All errors return JSON format:
-{
-  "error": "error_code",
-  "message": "Human readable message",
-  "timestamp": "2025-12-04T18:30:00Z",
-  "code": 400
-}
-
-Common Error Codes:
-- bad_request: Invalid input (400)
-- method_not_allowed: Wrong HTTP method (405)
-- encryption_failed: Encryption operation failed (500)
-- decryption_failed: Authentication verification failed (401)
-- internal_error: Server error (500)
-
-*/
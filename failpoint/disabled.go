@@ -0,0 +1,41 @@
+//go:build !failpoints
+
+package failpoint
+
+import (
+	"context"
+	"time"
+)
+
+// Enabled reports whether failpoints are compiled in
+const Enabled = false
+
+// Set fails with ErrDisabled
+func Set(name, spec string) error {
+	return ErrDisabled
+}
+
+// List returns no points
+func List() map[string]string {
+	return nil
+}
+
+// Inject never fails
+func Inject(name string) error {
+	return nil
+}
+
+// InjectContext never fails
+func InjectContext(ctx context.Context, name string) error {
+	return nil
+}
+
+// Partial returns p unchanged
+func Partial(name string, p []byte) ([]byte, error) {
+	return p, nil
+}
+
+// Now is time.Now
+func Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,157 @@
+//go:build failpoints
+
+package failpoint
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Enabled reports whether failpoints are compiled in
+const Enabled = true
+
+var (
+	mu      sync.Mutex
+	actions = make(map[string]*Action)
+	armed   atomic.Int32 // len(actions), read without the lock on every evaluation
+)
+
+func init() {
+	list := os.Getenv(EnvVar)
+	if list == "" {
+		return
+	}
+	parsed, err := ParseList(list)
+	if err != nil {
+		log.Fatalf("%s: %v", EnvVar, err)
+	}
+	for name, a := range parsed {
+		set(name, a)
+	}
+	log.Printf("FAILPOINTS_ARMED %s", List())
+}
+
+// Set arms (or with "off" disarms) a point
+func Set(name, spec string) error {
+	if !Known(name) {
+		return fmt.Errorf("unknown failpoint %q", name)
+	}
+	a, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	set(name, a)
+	return nil
+}
+
+func set(name string, a Action) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if a.Kind == KindOff {
+		delete(actions, name)
+	} else {
+		actions[name] = &a
+	}
+	armed.Store(int32(len(actions)))
+}
+
+// List returns the armed points and their remaining specs
+func List() map[string]string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	specs := make(map[string]string, len(actions))
+	for name, a := range actions {
+		specs[name] = a.String()
+	}
+	return specs
+}
+
+// eval returns the action if name is armed and fires on this evaluation
+func eval(name string) (Action, bool) {
+	if armed.Load() == 0 {
+		return Action{}, false
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	a, ok := actions[name]
+	if !ok || (a.Percent < 100 && rand.Intn(100) >= a.Percent) {
+		return Action{}, false
+	}
+	fired := *a
+	if a.Count > 0 {
+		a.Count--
+		if a.Count == 0 {
+			delete(actions, name)
+			armed.Store(int32(len(actions)))
+		}
+	}
+	return fired, true
+}
+
+// Inject evaluates an error or delay point
+func Inject(name string) error {
+	return InjectContext(context.Background(), name)
+}
+
+// InjectContext is Inject with a delay that ends early, returning ctx.Err(),
+// when ctx is done
+func InjectContext(ctx context.Context, name string) error {
+	a, ok := eval(name)
+	if !ok {
+		return nil
+	}
+	switch a.Kind {
+	case KindError:
+		return injectedError(name, a)
+	case KindDelay:
+		t := time.NewTimer(a.Duration)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Partial evaluates a write point. It returns the part of p to write and
+// the error to report once that part is written.
+func Partial(name string, p []byte) ([]byte, error) {
+	a, ok := eval(name)
+	if !ok {
+		return p, nil
+	}
+	switch a.Kind {
+	case KindError:
+		return nil, injectedError(name, a)
+	case KindPartial:
+		if a.Bytes < len(p) {
+			p = p[:a.Bytes]
+		}
+		return p, injectedError(name, a)
+	case KindDelay:
+		time.Sleep(a.Duration)
+	}
+	return p, nil
+}
+
+// Now returns the wall clock, shifted while the clock point is armed
+// with skew
+func Now() time.Time {
+	now := time.Now()
+	if a, ok := eval(Clock); ok && a.Kind == KindSkew {
+		return now.Add(a.Duration)
+	}
+	return now
+}
@@ -0,0 +1,195 @@
+// Package failpoint injects failures into the real binary so operators can
+// rehearse incidents: HSM timeouts, database write failures, truncated
+// stream responses and clock skew.
+//
+// Failpoints exist only in binaries built with the "failpoints" tag:
+//
+//	go build -tags failpoints ./cmd/eamsa512-server
+//
+// Without the tag Enabled is false, Inject and Partial never fail and Now is
+// time.Now, so the hooks cost nothing in production builds.
+//
+// Points are armed from EAMSA_FAILPOINTS at startup, or at run time with Set,
+// as a semicolon-separated list of point=spec:
+//
+//	EAMSA_FAILPOINTS="hsm-call=delay(10s);db-write=20%error(disk full);clock=skew(-2h)"
+//
+// A spec is [P%][N*]action[(arg)]. P% fires on that share of evaluations and
+// N* disarms the point after N firings. Actions are:
+//
+//	off               disarm
+//	error[(message)]  fail the operation
+//	delay(duration)   stall before the operation; a stall past a deadline times out
+//	partial(bytes)    write at most bytes of the buffer, then fail
+//	skew(duration)    shift the clock returned by Now
+package failpoint
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Points wired into the binary
+const (
+	HSMCall     = "hsm-call"     // Every HSM/KMS call made through the circuit breaker
+	DBWrite     = "db-write"     // Operation, audit, key version and wrapped key writes
+	StreamWrite = "stream-write" // Each chunk written to a streaming response
+	Clock       = "clock"        // Rotation, quota window, breaker and GC expiry clocks
+)
+
+// EnvVar names the variable read at startup
+const EnvVar = "EAMSA_FAILPOINTS"
+
+var (
+	// ErrInjected is wrapped by every injected error
+	ErrInjected = errors.New("injected failure")
+
+	// ErrDisabled is returned by Set in binaries built without failpoints
+	ErrDisabled = errors.New("failpoints not compiled in (build with -tags failpoints)")
+)
+
+// Kind is a failpoint action
+type Kind string
+
+const (
+	KindOff     Kind = "off"
+	KindError   Kind = "error"
+	KindDelay   Kind = "delay"
+	KindPartial Kind = "partial"
+	KindSkew    Kind = "skew"
+)
+
+// Action is a parsed spec
+type Action struct {
+	Kind     Kind
+	Percent  int           // Share of evaluations that fire, 1-100
+	Count    int           // Firings left before disarming; 0 is unlimited
+	Message  string        // KindError
+	Duration time.Duration // KindDelay and KindSkew
+	Bytes    int           // KindPartial
+}
+
+// Known reports whether name is a point wired into the binary
+func Known(name string) bool {
+	switch name {
+	case HSMCall, DBWrite, StreamWrite, Clock:
+		return true
+	}
+	return false
+}
+
+// Parse parses a spec
+func Parse(spec string) (Action, error) {
+	s := strings.TrimSpace(spec)
+	a := Action{Percent: 100}
+
+	if i := strings.IndexByte(s, '%'); i >= 0 {
+		p, err := strconv.Atoi(s[:i])
+		if err != nil || p < 1 || p > 100 {
+			return Action{}, fmt.Errorf("invalid failpoint percentage in %q", spec)
+		}
+		a.Percent = p
+		s = s[i+1:]
+	}
+	if i := strings.IndexByte(s, '*'); i >= 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil || n < 1 {
+			return Action{}, fmt.Errorf("invalid failpoint count in %q", spec)
+		}
+		a.Count = n
+		s = s[i+1:]
+	}
+
+	arg, hasArg := "", false
+	if i := strings.IndexByte(s, '('); i >= 0 {
+		if !strings.HasSuffix(s, ")") {
+			return Action{}, fmt.Errorf("unterminated failpoint argument in %q", spec)
+		}
+		arg, hasArg = s[i+1:len(s)-1], true
+		s = s[:i]
+	}
+
+	a.Kind = Kind(s)
+	switch a.Kind {
+	case KindOff:
+	case KindError:
+		a.Message = arg
+	case KindDelay, KindSkew:
+		d, err := time.ParseDuration(arg)
+		if err != nil || !hasArg {
+			return Action{}, fmt.Errorf("failpoint %s needs a duration in %q", a.Kind, spec)
+		}
+		if a.Kind == KindDelay && d <= 0 {
+			return Action{}, fmt.Errorf("failpoint delay must be positive in %q", spec)
+		}
+		a.Duration = d
+	case KindPartial:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 0 {
+			return Action{}, fmt.Errorf("failpoint partial needs a byte count in %q", spec)
+		}
+		a.Bytes = n
+	default:
+		return Action{}, fmt.Errorf("unknown failpoint action %q", s)
+	}
+	return a, nil
+}
+
+// String formats a as a spec
+func (a Action) String() string {
+	var b strings.Builder
+	if a.Percent > 0 && a.Percent < 100 {
+		fmt.Fprintf(&b, "%d%%", a.Percent)
+	}
+	if a.Count > 0 {
+		fmt.Fprintf(&b, "%d*", a.Count)
+	}
+	b.WriteString(string(a.Kind))
+	switch a.Kind {
+	case KindError:
+		if a.Message != "" {
+			fmt.Fprintf(&b, "(%s)", a.Message)
+		}
+	case KindDelay, KindSkew:
+		fmt.Fprintf(&b, "(%s)", a.Duration)
+	case KindPartial:
+		fmt.Fprintf(&b, "(%d)", a.Bytes)
+	}
+	return b.String()
+}
+
+// ParseList parses "point=spec;point=spec"
+func ParseList(list string) (map[string]Action, error) {
+	actions := make(map[string]Action)
+	for _, item := range strings.Split(list, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok {
+			return nil, fmt.Errorf("failpoint %q has no spec", name)
+		}
+		if !Known(name) {
+			return nil, fmt.Errorf("unknown failpoint %q", name)
+		}
+		a, err := Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		actions[name] = a
+	}
+	return actions, nil
+}
+
+// injectedError is the error returned for a firing point
+func injectedError(name string, a Action) error {
+	if a.Message != "" {
+		return fmt.Errorf("failpoint %s: %s: %w", name, a.Message, ErrInjected)
+	}
+	return fmt.Errorf("failpoint %s: %w", name, ErrInjected)
+}
@@ -0,0 +1,165 @@
+// Package filelock provides the advisory cross-process locks that keep two
+// processes (for example the server and a CLI command) from modifying the
+// same keystore or database at once.
+//
+// A lock is held on a separate lock file next to the protected files, so
+// atomic renames of the protected files do not drop it. Writers take an
+// exclusive lock and record their process ID in the lock file; Acquire
+// never blocks and reports a held lock as a *LockedError naming the
+// holder. Locks are advisory: only processes that use this package are
+// kept out, and the operating system releases them when the holder exits.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is the kind of lock
+type Mode int
+
+const (
+	// Shared locks may be held by any number of processes at once, but not
+	// together with an exclusive lock
+	Shared Mode = iota
+
+	// Exclusive locks are held by one process at a time
+	Exclusive
+)
+
+// ErrLocked matches every *LockedError with errors.Is
+var ErrLocked = errors.New("filelock: locked by another process")
+
+// Holder describes the process holding an exclusive lock, as recorded in
+// the lock file
+type Holder struct {
+	PID     int
+	Command string
+	Since   time.Time
+}
+
+// LockedError is returned by Acquire when another process holds the lock
+type LockedError struct {
+	Path   string
+	Holder Holder // Zero if not recorded (e.g. shared holders)
+}
+
+func (e *LockedError) Error() string {
+	if e.Holder.PID == 0 {
+		return fmt.Sprintf("%s is locked by another process", e.Path)
+	}
+	return fmt.Sprintf("%s is locked by pid %d (%s) since %s",
+		e.Path, e.Holder.PID, e.Holder.Command, e.Holder.Since.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrLocked
+func (e *LockedError) Is(target error) bool {
+	return target == ErrLocked
+}
+
+// Lock is a held lock
+type Lock struct {
+	file *os.File
+	path string
+	mode Mode
+}
+
+// Acquire takes a lock of the given mode on the lock file at path,
+// creating it with mode 0600 if needed. It does not wait: if another
+// process holds a conflicting lock it returns a *LockedError.
+func Acquire(path string, mode Mode) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %v", err)
+	}
+
+	held, err := lockFile(file, mode)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+	}
+	if !held {
+		file.Close()
+		holder, _ := ReadHolder(path)
+		return nil, &LockedError{Path: path, Holder: holder}
+	}
+
+	if mode == Exclusive {
+		record := fmt.Sprintf("%d %s %s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339), command())
+		if err := file.Truncate(0); err == nil {
+			file.WriteAt([]byte(record), 0)
+		}
+	}
+
+	return &Lock{file: file, path: path, mode: mode}, nil
+}
+
+// Release releases the lock. An exclusive holder clears its record first.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+
+	if l.mode == Exclusive {
+		l.file.Truncate(0)
+	}
+	err := unlockFile(l.file)
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file = nil
+	return err
+}
+
+// Path returns the lock file path
+func (l *Lock) Path() string {
+	return l.path
+}
+
+// ReadHolder returns the holder recorded in the lock file at path. The
+// record is informational: it may be stale if the holder was killed.
+func ReadHolder(path string) (Holder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Holder{}, err
+	}
+
+	fields := strings.SplitN(strings.TrimSpace(string(data)), " ", 3)
+	if len(fields) < 2 {
+		return Holder{}, fmt.Errorf("no holder recorded in %s", path)
+	}
+
+	pid, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return Holder{}, fmt.Errorf("invalid holder record in %s", path)
+	}
+	since, err := time.Parse(time.RFC3339, fields[1])
+	if err != nil {
+		return Holder{}, fmt.Errorf("invalid holder record in %s", path)
+	}
+
+	holder := Holder{PID: pid, Since: since}
+	if len(fields) == 3 {
+		holder.Command = fields[2]
+	}
+	return holder, nil
+}
+
+// command returns the name of the running program and its subcommand
+func command() string {
+	if len(os.Args) == 0 {
+		return "unknown"
+	}
+	name := os.Args[0]
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		name += " " + os.Args[1]
+	}
+	return name
+}
@@ -0,0 +1,224 @@
+package format
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Field describes one field of the on-disk layout
+type Field struct {
+	Name        string
+	Size        string // Byte count, or a symbolic size such as "N"
+	Description string
+}
+
+// Fields of the version 1 layout, in file order. Annotate labels regions
+// with these names and WriteSpec documents them.
+var (
+	FieldMagic          = Field{"magic", "4", `ASCII "EAMS"`}
+	FieldVersion        = Field{"version", "1", "Format version, 1"}
+	FieldSuite          = Field{"cipher_suite", "1", "EAMSA 512 CBC with HMAC-SHA3-512, padding the body with 1 = PKCS#7, 2 = ISO/IEC 7816-4, 3 = zeros and a length byte (ANSI X9.23)"}
+	FieldFlags          = Field{"flags", "1", "0x01 header encrypted, 0x02 digest footer, 0x04 split-trust tags, 0x08 per-file body key, 0x10 stretched master key; other bits must be 0"}
+	FieldReserved       = Field{"reserved", "1", "Must be 0"}
+	FieldMetadataLength = Field{"metadata_length", "2", "N, big-endian uint16"}
+	FieldKeyStretch     = Field{"key_stretch", "26", "With flag 0x10: kdf (1, 1 = Argon2id) | memory KiB (4) | iterations (4) | parallelism (1) | salt (16)"}
+	FieldMetadata       = Field{"metadata", "N", "TLV records; with flag 0x01, an encrypted body holding the records"}
+	FieldHeaderTag      = Field{"header_tag", "64", "HMAC-SHA3-512 over magic..metadata under the header MAC key (split MAC key with flag 0x04)"}
+	FieldCiphertext     = Field{"body.ciphertext", "64*k", "CBC ciphertext of the plaintext, padded per cipher_suite"}
+	FieldNonce          = Field{"body.nonce", "16", "Nonce; the IV is derived from nonce and key"}
+	FieldTag            = Field{"body.tag", "64", "HMAC-SHA3-512 over nonce || ciphertext (split MAC key with flag 0x04)"}
+	FieldFooter         = Field{"footer", "208", "With flag 0x02: encrypted SHA3-256(plaintext) || body.tag, as ciphertext (128) || nonce (16) || tag (64)"}
+)
+
+// Layout lists the version 1 fields in file order
+var Layout = []Field{
+	FieldMagic, FieldVersion, FieldSuite, FieldFlags, FieldReserved,
+	FieldMetadataLength, FieldKeyStretch, FieldMetadata, FieldHeaderTag,
+	FieldCiphertext, FieldNonce, FieldTag, FieldFooter,
+}
+
+// MetadataField describes one metadata record type
+type MetadataField struct {
+	Type        byte
+	Name        string
+	Description string
+}
+
+// MetadataFields lists the known metadata record types
+var MetadataFields = []MetadataField{
+	{FieldKeyVersion, "key_version", "uint32 big-endian; omitted when 0"},
+	{FieldMode, "mode", `ASCII cipher mode, e.g. "CBC"`},
+}
+
+// metadataFieldName returns the name of a record type
+func metadataFieldName(fieldType byte) string {
+	for _, f := range MetadataFields {
+		if f.Type == fieldType {
+			return f.Name
+		}
+	}
+	return fmt.Sprintf("unknown(0x%02x)", fieldType)
+}
+
+// Region is an annotated byte range of a container
+type Region struct {
+	Offset int
+	Length int
+	Name   string
+	Value  string // Decoded value, empty for opaque bytes
+	Bytes  []byte
+}
+
+// Annotate returns a byte-level breakdown of a container. Encrypted
+// metadata is shown as opaque bytes; plaintext metadata is broken down
+// record by record.
+func Annotate(data []byte) ([]Region, error) {
+	c, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	h := c.Header
+
+	var regions []Region
+	offset := 0
+	add := func(name string, n int, value string) {
+		regions = append(regions, Region{
+			Offset: offset,
+			Length: n,
+			Name:   name,
+			Value:  value,
+			Bytes:  data[offset : offset+n],
+		})
+		offset += n
+	}
+
+	add(FieldMagic.Name, 4, fmt.Sprintf("%q", Magic))
+	add(FieldVersion.Name, 1, fmt.Sprintf("%d", h.Version))
+	add(FieldSuite.Name, 1, suiteName(h.Suite))
+	add(FieldFlags.Name, 1, flagNames(h.Flags))
+	add(FieldReserved.Name, 1, "")
+	add(FieldMetadataLength.Name, 2, fmt.Sprintf("%d", binary.BigEndian.Uint16(data[8:10])))
+
+	if h.StretchedKey() {
+		s, err := ParseKeyStretch(h.Stretch)
+		if err != nil {
+			return nil, err
+		}
+		add(FieldKeyStretch.Name+".kdf", 1, "1 (Argon2id)")
+		add(FieldKeyStretch.Name+".memory", 4, fmt.Sprintf("%d KiB", s.Memory))
+		add(FieldKeyStretch.Name+".iterations", 4, fmt.Sprintf("%d", s.Iterations))
+		add(FieldKeyStretch.Name+".parallelism", 1, fmt.Sprintf("%d", s.Parallelism))
+		add(FieldKeyStretch.Name+".salt", StretchSaltSize, "")
+	}
+
+	if h.Encrypted() {
+		add(FieldMetadata.Name, len(h.Metadata), "encrypted")
+	} else {
+		records, err := Records(h.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			name := FieldMetadata.Name + "." + metadataFieldName(record.Type)
+			add(name+".type", 1, fmt.Sprintf("0x%02x", record.Type))
+			add(name+".length", 1, fmt.Sprintf("%d", len(record.Value)))
+			add(name+".value", len(record.Value), recordValue(record))
+		}
+	}
+
+	add(FieldHeaderTag.Name, TagSize, "")
+
+	ciphertext, _, _, err := SplitBody(c.Body)
+	if err != nil {
+		return nil, err
+	}
+	add(FieldCiphertext.Name, len(ciphertext), fmt.Sprintf("%d blocks", len(ciphertext)/BlockSize))
+	add(FieldNonce.Name, NonceSize, "")
+	add(FieldTag.Name, TagSize, "")
+
+	if c.Footer != nil {
+		add(FieldFooter.Name, FooterSize, "encrypted")
+	}
+
+	return regions, nil
+}
+
+// recordValue formats a known metadata record value
+func recordValue(record Record) string {
+	switch record.Type {
+	case FieldKeyVersion:
+		if len(record.Value) == 4 {
+			return fmt.Sprintf("%d", binary.BigEndian.Uint32(record.Value))
+		}
+	case FieldMode:
+		return fmt.Sprintf("%q", record.Value)
+	}
+	return ""
+}
+
+// suiteName names a cipher suite
+func suiteName(suite byte) string {
+	switch suite {
+	case SuiteEAMSA512:
+		return "1 (EAMSA 512 CBC, HMAC-SHA3-512, PKCS#7)"
+	case SuiteEAMSA512ISO7816:
+		return "2 (EAMSA 512 CBC, HMAC-SHA3-512, ISO/IEC 7816-4)"
+	case SuiteEAMSA512ZeroLength:
+		return "3 (EAMSA 512 CBC, HMAC-SHA3-512, ANSI X9.23)"
+	}
+	return fmt.Sprintf("%d (unknown)", suite)
+}
+
+// flagNames lists the set flags
+func flagNames(flags byte) string {
+	var names []string
+	if flags&FlagHeaderEncrypted != 0 {
+		names = append(names, "header-encrypted")
+	}
+	if flags&FlagDigestFooter != 0 {
+		names = append(names, "digest-footer")
+	}
+	if flags&FlagSplitTrust != 0 {
+		names = append(names, "split-trust")
+	}
+	if flags&FlagFileKey != 0 {
+		names = append(names, "file-key")
+	}
+	if flags&FlagStretchedKey != 0 {
+		names = append(names, "stretched-key")
+	}
+	if len(names) == 0 {
+		return fmt.Sprintf("0x%02x", flags)
+	}
+	return fmt.Sprintf("0x%02x (%s)", flags, strings.Join(names, ", "))
+}
+
+// annotateMaxBytes is the number of bytes shown per region
+const annotateMaxBytes = 16
+
+// WriteAnnotated writes the Annotate breakdown as a table
+func WriteAnnotated(w io.Writer, data []byte) error {
+	regions, err := Annotate(data)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%-8s %-6s %-30s %-50s %s\n", "OFFSET", "LENGTH", "FIELD", "BYTES", "VALUE")
+	for _, r := range regions {
+		shown := r.Bytes
+		more := ""
+		if len(shown) > annotateMaxBytes {
+			shown = shown[:annotateMaxBytes]
+			more = " ..."
+		}
+		hexBytes := strings.TrimSpace(fmt.Sprintf("% x", shown)) + more
+		if _, err := fmt.Fprintf(w, "0x%06x %-6d %-30s %-50s %s\n", r.Offset, r.Length, r.Name, hexBytes, r.Value); err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "total %d bytes\n", len(data))
+	return err
+}
@@ -0,0 +1,484 @@
+// Package format is the reference serializer and parser for the EAMSA 512
+// container format. The container code, the inspect command and the
+// generated format specification all use this package, so the documented
+// layout, the bytes written and the bytes accepted cannot drift apart.
+//
+// Layout (version 1):
+//
+//	magic "EAMS" (4) | format version (1) | cipher suite (1) | flags (1) |
+//	reserved (1) | metadata length N (2, big-endian) | metadata (N) |
+//	header tag (64) | body (ciphertext || nonce || tag) | [footer (208)]
+//
+// The package handles framing only. Keys, encryption and tag computation
+// belong to the caller; the header tag covers Header.Signed().
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// Magic identifies EAMSA 512 containers
+	Magic = "EAMS"
+
+	// Version is the current container format version
+	Version = 1
+
+	// SuiteEAMSA512 is EAMSA 512 CBC with HMAC-SHA3-512 and PKCS#7 padding
+	SuiteEAMSA512 = 1
+
+	// SuiteEAMSA512ISO7816 is SuiteEAMSA512 with ISO/IEC 7816-4 padding
+	SuiteEAMSA512ISO7816 = 2
+
+	// SuiteEAMSA512ZeroLength is SuiteEAMSA512 with zero padding ending in
+	// the padding length (ANSI X9.23)
+	SuiteEAMSA512ZeroLength = 3
+
+	// FlagHeaderEncrypted marks encrypted metadata
+	FlagHeaderEncrypted = 0x01
+
+	// FlagDigestFooter marks a trailing encrypted plaintext digest
+	FlagDigestFooter = 0x02
+
+	// FlagSplitTrust marks header and body tags computed under a MAC key
+	// held separately from the master key
+	FlagSplitTrust = 0x04
+
+	// FlagFileKey marks a body encrypted and tagged under a per-file key
+	// derived from the master key and the body nonce (see FileKeyLabel)
+	FlagFileKey = 0x08
+
+	// FlagStretchedKey marks a key-stretching section after the prefix:
+	// every container key derives from the master key stretched with the
+	// recorded Argon2id parameters
+	FlagStretchedKey = 0x10
+
+	// KnownFlags are the flags understood by this version
+	KnownFlags = FlagHeaderEncrypted | FlagDigestFooter | FlagSplitTrust | FlagFileKey | FlagStretchedKey
+
+	// PrefixSize is the fixed part of the header before the metadata
+	PrefixSize = 10
+
+	// StretchSize is the key-stretching section of FlagStretchedKey:
+	// kdf (1) | memory KiB (4) | iterations (4) | parallelism (1) | salt (16)
+	StretchSize = 26
+
+	// StretchArgon2id identifies Argon2id in the key-stretching section
+	StretchArgon2id = 1
+
+	// StretchSaltSize is the length of the key-stretching salt
+	StretchSaltSize = 16
+
+	// MaxMetadataSize is the largest metadata section the length field holds
+	MaxMetadataSize = 0xFFFF
+
+	// BlockSize is the cipher block size
+	BlockSize = 64
+
+	// NonceSize is the size of the body nonce
+	NonceSize = 16
+
+	// TagSize is the size of an HMAC-SHA3-512 tag
+	TagSize = 64
+
+	// PlaintextDigestSize is the size of the SHA3-256 plaintext digest
+	PlaintextDigestSize = 32
+
+	// FooterSize is the encrypted footer: SHA3-256 digest || body tag
+	// (96 bytes, padded to 128), plus nonce and tag
+	FooterSize = 2*BlockSize + NonceSize + TagSize
+
+	// MinBodySize is the smallest body: an empty ciphertext, nonce and tag
+	MinBodySize = NonceSize + TagSize
+)
+
+// Key derivation labels. Each container subkey is
+// SHA3-512(label || master key)[:32].
+const (
+	HeaderEncryptionLabel = "EAMSA512-HEADER-ENC"
+	HeaderMACLabel        = "EAMSA512-HEADER-MAC"
+	FooterEncryptionLabel = "EAMSA512-FOOTER-ENC"
+)
+
+// SplitMACLabel derives the tag key from a separately held MAC key with
+// FlagSplitTrust: SHA3-512(label || MAC key)[:32]
+const SplitMACLabel = "EAMSA512-SPLIT-MAC"
+
+// FileKeyLabel derives the body key with FlagFileKey:
+// eamsa512.DeriveFileKey(master key, body nonce, FileKeyLabel). The
+// header and footer keys stay derived from the master key.
+const FileKeyLabel = "EAMSA512-CONTAINER-BODY"
+
+// Metadata record types
+const (
+	FieldKeyVersion = 0x01 // uint32, big-endian
+	FieldMode       = 0x02 // ASCII cipher mode name
+)
+
+// Header is a container header as stored on disk
+type Header struct {
+	Version  byte
+	Suite    byte
+	Flags    byte
+	Stretch  []byte // Key-stretching section with FlagStretchedKey, else nil
+	Metadata []byte // TLV records, or their encryption with FlagHeaderEncrypted
+	Tag      []byte // Header tag over Signed()
+}
+
+// KnownSuite reports whether suite is understood by this version. Suites
+// differ only in the padding of the body plaintext; the header and footer
+// are always PKCS#7 padded.
+func KnownSuite(suite byte) bool {
+	return suite == SuiteEAMSA512 || suite == SuiteEAMSA512ISO7816 || suite == SuiteEAMSA512ZeroLength
+}
+
+// Encrypted reports whether the metadata is encrypted
+func (h *Header) Encrypted() bool {
+	return h.Flags&FlagHeaderEncrypted != 0
+}
+
+// SplitTrust reports whether the tags are under a separately held MAC key
+func (h *Header) SplitTrust() bool {
+	return h.Flags&FlagSplitTrust != 0
+}
+
+// FileKey reports whether the body is under a per-file key
+func (h *Header) FileKey() bool {
+	return h.Flags&FlagFileKey != 0
+}
+
+// StretchedKey reports whether the container keys derive from a
+// stretched master key
+func (h *Header) StretchedKey() bool {
+	return h.Flags&FlagStretchedKey != 0
+}
+
+// HasFooter reports whether the container ends with a digest footer
+func (h *Header) HasFooter() bool {
+	return h.Flags&FlagDigestFooter != 0
+}
+
+// Size returns the encoded header size, including the tag
+func (h *Header) Size() int {
+	return PrefixSize + len(h.Stretch) + len(h.Metadata) + TagSize
+}
+
+// Validate checks the header can be encoded by this version
+func (h *Header) Validate() error {
+	if h.Version != Version {
+		return fmt.Errorf("unsupported container format version: %d", h.Version)
+	}
+	if !KnownSuite(h.Suite) {
+		return fmt.Errorf("unsupported cipher suite: %d", h.Suite)
+	}
+	if h.Flags&^KnownFlags != 0 {
+		return fmt.Errorf("unsupported container flags: 0x%02x", h.Flags)
+	}
+	if len(h.Metadata) > MaxMetadataSize {
+		return fmt.Errorf("container metadata too large: %d bytes", len(h.Metadata))
+	}
+	if h.StretchedKey() {
+		if _, err := ParseKeyStretch(h.Stretch); err != nil {
+			return err
+		}
+	} else if h.Stretch != nil {
+		return fmt.Errorf("key-stretching section without flag 0x%02x", FlagStretchedKey)
+	}
+	return nil
+}
+
+// Signed returns the authenticated part of the header:
+// prefix || [key stretching] || metadata
+func (h *Header) Signed() ([]byte, error) {
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, PrefixSize, h.Size())
+	copy(buf[0:4], Magic)
+	buf[4] = h.Version
+	buf[5] = h.Suite
+	buf[6] = h.Flags
+	buf[7] = 0
+	binary.BigEndian.PutUint16(buf[8:10], uint16(len(h.Metadata)))
+
+	buf = append(buf, h.Stretch...)
+	return append(buf, h.Metadata...), nil
+}
+
+// MarshalBinary encodes the header: prefix || [key stretching] || metadata || tag
+func (h *Header) MarshalBinary() ([]byte, error) {
+	if len(h.Tag) != TagSize {
+		return nil, fmt.Errorf("invalid header tag size: expected %d, got %d", TagSize, len(h.Tag))
+	}
+
+	buf, err := h.Signed()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(buf, h.Tag...), nil
+}
+
+// IsContainer reports whether data starts with the container magic
+func IsContainer(data []byte) bool {
+	return len(data) >= len(Magic) && bytes.Equal(data[:len(Magic)], []byte(Magic))
+}
+
+// ParseHeader decodes the header at the start of data. The tag is
+// returned, not verified.
+func ParseHeader(data []byte) (*Header, error) {
+	if len(data) < PrefixSize+TagSize {
+		return nil, fmt.Errorf("container too short: %d bytes", len(data))
+	}
+
+	if !IsContainer(data) {
+		return nil, fmt.Errorf("not an EAMSA 512 container (bad magic)")
+	}
+
+	if data[7] != 0 {
+		return nil, fmt.Errorf("reserved header byte is 0x%02x, want 0", data[7])
+	}
+
+	h := &Header{
+		Version: data[4],
+		Suite:   data[5],
+		Flags:   data[6],
+	}
+
+	size := HeaderSize(data[:PrefixSize])
+	if len(data) < size {
+		return nil, fmt.Errorf("container truncated: header declares %d bytes, have %d", size, len(data))
+	}
+
+	metadataStart := PrefixSize
+	if h.StretchedKey() {
+		metadataStart += StretchSize
+		h.Stretch = data[PrefixSize:metadataStart]
+	}
+	h.Metadata = data[metadataStart : size-TagSize]
+	h.Tag = data[size-TagSize : size]
+
+	if err := h.Validate(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+// HeaderSize returns the header size, including the tag, declared by a
+// PrefixSize-byte prefix, for readers that fetch the header in two steps
+func HeaderSize(prefix []byte) int {
+	size := PrefixSize + int(binary.BigEndian.Uint16(prefix[8:10])) + TagSize
+	if prefix[6]&FlagStretchedKey != 0 {
+		size += StretchSize
+	}
+	return size
+}
+
+// Container is a parsed container split into its sections
+type Container struct {
+	Header *Header
+	Body   []byte // ciphertext || nonce || tag
+	Footer []byte // Encrypted digest footer; nil without FlagDigestFooter
+}
+
+// Parse splits data into header, body and footer
+func Parse(data []byte) (*Container, error) {
+	h, err := ParseHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	bodySize, err := h.BodySize(int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	bodyEnd := h.Size() + int(bodySize)
+	c := &Container{Header: h, Body: data[h.Size():bodyEnd]}
+	if h.HasFooter() {
+		c.Footer = data[bodyEnd:]
+	}
+
+	return c, nil
+}
+
+// BodySize returns the body length of a container of total bytes, for
+// readers that stream the body instead of holding the container in memory
+func (h *Header) BodySize(total int64) (int64, error) {
+	rest := total - int64(h.Size())
+
+	if h.HasFooter() {
+		if rest < MinBodySize+FooterSize {
+			return 0, fmt.Errorf("container truncated: missing digest footer")
+		}
+		rest -= FooterSize
+	}
+
+	if rest < MinBodySize {
+		return 0, fmt.Errorf("container truncated: body is %d bytes, need at least %d", rest, MinBodySize)
+	}
+
+	if (rest-MinBodySize)%BlockSize != 0 {
+		return 0, fmt.Errorf("invalid body length %d: ciphertext is not a multiple of %d bytes", rest, BlockSize)
+	}
+
+	return rest, nil
+}
+
+// SplitBody splits a body (or footer) into ciphertext, nonce and tag
+func SplitBody(body []byte) (ciphertext, nonce, tag []byte, err error) {
+	if len(body) < MinBodySize {
+		return nil, nil, nil, fmt.Errorf("body too short: %d bytes", len(body))
+	}
+
+	n := len(body) - NonceSize - TagSize
+	return body[:n], body[n : n+NonceSize], body[n+NonceSize:], nil
+}
+
+// ============================================================================
+// Key Stretching
+// ============================================================================
+
+// KeyStretch is the key-stretching section of FlagStretchedKey
+type KeyStretch struct {
+	KDF         byte   // StretchArgon2id
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	Salt        []byte // StretchSaltSize bytes
+}
+
+// MarshalBinary encodes the section
+func (s KeyStretch) MarshalBinary() ([]byte, error) {
+	if s.KDF != StretchArgon2id {
+		return nil, fmt.Errorf("unsupported key-stretching KDF %d", s.KDF)
+	}
+	if len(s.Salt) != StretchSaltSize {
+		return nil, fmt.Errorf("invalid key-stretching salt size: expected %d, got %d", StretchSaltSize, len(s.Salt))
+	}
+
+	buf := make([]byte, StretchSize)
+	buf[0] = s.KDF
+	binary.BigEndian.PutUint32(buf[1:5], s.Memory)
+	binary.BigEndian.PutUint32(buf[5:9], s.Iterations)
+	buf[9] = s.Parallelism
+	copy(buf[10:], s.Salt)
+	return buf, nil
+}
+
+// ParseKeyStretch decodes the section; Salt aliases data. Cost limits are
+// the caller's to enforce.
+func ParseKeyStretch(data []byte) (KeyStretch, error) {
+	if len(data) != StretchSize {
+		return KeyStretch{}, fmt.Errorf("invalid key-stretching section size: expected %d, got %d", StretchSize, len(data))
+	}
+	if data[0] != StretchArgon2id {
+		return KeyStretch{}, fmt.Errorf("unsupported key-stretching KDF %d", data[0])
+	}
+
+	return KeyStretch{
+		KDF:         data[0],
+		Memory:      binary.BigEndian.Uint32(data[1:5]),
+		Iterations:  binary.BigEndian.Uint32(data[5:9]),
+		Parallelism: data[9],
+		Salt:        data[10:StretchSize],
+	}, nil
+}
+
+// ============================================================================
+// Metadata
+// ============================================================================
+
+// Metadata holds the decoded metadata records
+type Metadata struct {
+	KeyVersion int    // Zero if not recorded
+	Mode       string // Cipher mode, e.g. "CBC"
+}
+
+// MarshalBinary encodes m as TLV records: type (1) | length (1) | value
+func (m Metadata) MarshalBinary() ([]byte, error) {
+	if m.KeyVersion < 0 || uint64(m.KeyVersion) > 0xFFFFFFFF {
+		return nil, fmt.Errorf("invalid key version: %d", m.KeyVersion)
+	}
+	if len(m.Mode) > 0xFF {
+		return nil, fmt.Errorf("mode too long: %d bytes", len(m.Mode))
+	}
+
+	var buf []byte
+
+	if m.KeyVersion > 0 {
+		value := make([]byte, 4)
+		binary.BigEndian.PutUint32(value, uint32(m.KeyVersion))
+		buf = appendRecord(buf, FieldKeyVersion, value)
+	}
+
+	buf = appendRecord(buf, FieldMode, []byte(m.Mode))
+
+	return buf, nil
+}
+
+// appendRecord appends one type-length-value record
+func appendRecord(buf []byte, fieldType byte, value []byte) []byte {
+	buf = append(buf, fieldType, byte(len(value)))
+	return append(buf, value...)
+}
+
+// Record is one raw metadata record
+type Record struct {
+	Offset int // Offset of the type byte within the metadata
+	Type   byte
+	Value  []byte
+}
+
+// Records splits plaintext metadata into TLV records
+func Records(metadata []byte) ([]Record, error) {
+	var records []Record
+
+	for pos := 0; pos < len(metadata); {
+		if pos+2 > len(metadata) {
+			return nil, fmt.Errorf("malformed container metadata at offset %d", pos)
+		}
+
+		record := Record{Offset: pos, Type: metadata[pos]}
+		length := int(metadata[pos+1])
+		pos += 2
+
+		if pos+length > len(metadata) {
+			return nil, fmt.Errorf("malformed container metadata: field 0x%02x overruns header", record.Type)
+		}
+		record.Value = metadata[pos : pos+length]
+		pos += length
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// ParseMetadata decodes plaintext metadata; unknown records are skipped
+func ParseMetadata(metadata []byte) (Metadata, error) {
+	var m Metadata
+
+	records, err := Records(metadata)
+	if err != nil {
+		return m, err
+	}
+
+	for _, record := range records {
+		switch record.Type {
+		case FieldKeyVersion:
+			if len(record.Value) != 4 {
+				return m, fmt.Errorf("invalid key version field length: %d", len(record.Value))
+			}
+			m.KeyVersion = int(binary.BigEndian.Uint32(record.Value))
+		case FieldMode:
+			m.Mode = string(record.Value)
+		}
+	}
+
+	return m, nil
+}
@@ -0,0 +1,123 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// ============================================================================
+// EAMSA 512 - Container Format Test Suite
+// Header, metadata and key-stretching encoding round trips
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// testContainer builds a container of one ciphertext block with the given
+// header; tags, nonce and ciphertext are filler
+func testContainer(t *testing.T, h *Header) []byte {
+	t.Helper()
+
+	header, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary failed: %v", err)
+	}
+	body := bytes.Repeat([]byte{0xbb}, BlockSize+NonceSize+TagSize)
+	return append(header, body...)
+}
+
+// TestHeaderRoundTrip tests that Parse returns the header MarshalBinary
+// wrote and splits off the body
+func TestHeaderRoundTrip(t *testing.T) {
+	fmt.Println("Test: Container Header Round Trip")
+
+	metadata, err := Metadata{KeyVersion: 7, Mode: "CBC"}.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Metadata MarshalBinary failed: %v", err)
+	}
+	stretch, err := KeyStretch{KDF: StretchArgon2id, Memory: 64, Iterations: 1, Parallelism: 1, Salt: make([]byte, StretchSaltSize)}.MarshalBinary()
+	if err != nil {
+		t.Fatalf("KeyStretch MarshalBinary failed: %v", err)
+	}
+
+	h := &Header{
+		Version:  Version,
+		Suite:    SuiteEAMSA512ISO7816,
+		Flags:    FlagStretchedKey,
+		Stretch:  stretch,
+		Metadata: metadata,
+		Tag:      bytes.Repeat([]byte{0xaa}, TagSize),
+	}
+	data := testContainer(t, h)
+
+	c, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if c.Header.Suite != h.Suite || !c.Header.StretchedKey() || !bytes.Equal(c.Header.Tag, h.Tag) {
+		t.Fatalf("Parsed header differs: %+v", c.Header)
+	}
+	if len(c.Body) != BlockSize+MinBodySize || c.Footer != nil {
+		t.Fatalf("Body is %d bytes, footer %d", len(c.Body), len(c.Footer))
+	}
+	if HeaderSize(data[:PrefixSize]) != h.Size() {
+		t.Fatalf("HeaderSize = %d, want %d", HeaderSize(data[:PrefixSize]), h.Size())
+	}
+
+	m, err := ParseMetadata(c.Header.Metadata)
+	if err != nil || m.KeyVersion != 7 || m.Mode != "CBC" {
+		t.Fatalf("ParseMetadata = %+v, %v", m, err)
+	}
+	s, err := ParseKeyStretch(c.Header.Stretch)
+	if err != nil || s.Memory != 64 || s.Iterations != 1 || s.Parallelism != 1 {
+		t.Fatalf("ParseKeyStretch = %+v, %v", s, err)
+	}
+
+	regions, err := Annotate(data)
+	if err != nil {
+		t.Fatalf("Annotate failed: %v", err)
+	}
+	last := regions[len(regions)-1]
+	if last.Offset+last.Length != len(data) {
+		t.Fatalf("Annotated regions end at %d of %d bytes", last.Offset+last.Length, len(data))
+	}
+
+	fmt.Println("✓ Header, metadata and key stretching round trip")
+}
+
+// TestParseRejects tests that Parse rejects headers this version cannot
+// read and bodies of the wrong length
+func TestParseRejects(t *testing.T) {
+	fmt.Println("Test: Container Header Validation")
+
+	valid := func() *Header {
+		return &Header{Version: Version, Suite: SuiteEAMSA512, Metadata: []byte{FieldMode, 3, 'C', 'B', 'C'}, Tag: make([]byte, TagSize)}
+	}
+	data := testContainer(t, valid())
+	if _, err := Parse(data); err != nil {
+		t.Fatalf("Valid container rejected: %v", err)
+	}
+
+	for name, mutate := range map[string]func([]byte) []byte{
+		"bad magic":      func(d []byte) []byte { d[0] = 'X'; return d },
+		"future version": func(d []byte) []byte { d[4] = Version + 1; return d },
+		"unknown suite":  func(d []byte) []byte { d[5] = 0xee; return d },
+		"unknown flag":   func(d []byte) []byte { d[6] = 0x80; return d },
+		"reserved byte":  func(d []byte) []byte { d[7] = 1; return d },
+		"partial block":  func(d []byte) []byte { return d[:len(d)-1] },
+		"no body":        func(d []byte) []byte { return d[:valid().Size()] },
+	} {
+		if _, err := Parse(mutate(append([]byte(nil), data...))); err == nil {
+			t.Errorf("%s: container accepted", name)
+		}
+	}
+
+	if _, err := Records([]byte{FieldMode, 9, 'C'}); err == nil {
+		t.Error("Overrunning metadata record accepted")
+	}
+	if _, err := (KeyStretch{KDF: StretchArgon2id, Salt: []byte("short")}).MarshalBinary(); err == nil {
+		t.Error("Short key-stretching salt accepted")
+	}
+
+	fmt.Println("✓ Invalid headers and bodies rejected")
+}
@@ -0,0 +1,86 @@
+package format
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteSpec writes the format specification as Markdown, generated from
+// Layout and MetadataFields. docs/container-format.md is produced with
+// "eamsa512 inspect -spec".
+func WriteSpec(w io.Writer) error {
+	p := &specWriter{w: w}
+
+	p.printf("# EAMSA 512 Container Format (version %d)\n\n", Version)
+	p.printf("Generated from the `format` package; do not edit by hand.\n\n")
+
+	p.printf("## Layout\n\n")
+	p.printf("All integers are big-endian. Sizes are in bytes.\n\n")
+	p.printf("| Field | Size | Description |\n")
+	p.printf("|-------|------|-------------|\n")
+	for _, f := range Layout {
+		p.printf("| `%s` | %s | %s |\n", f.Name, f.Size, cell(f.Description))
+	}
+
+	p.printf("\n## Metadata Records\n\n")
+	p.printf("Metadata is a sequence of records: type (1) | length (1) | value (length).\n")
+	p.printf("Readers skip unknown record types.\n\n")
+	p.printf("| Type | Name | Value |\n")
+	p.printf("|------|------|-------|\n")
+	for _, f := range MetadataFields {
+		p.printf("| `0x%02x` | `%s` | %s |\n", f.Type, f.Name, cell(f.Description))
+	}
+
+	p.printf("\n## Keys\n\n")
+	p.printf("Container subkeys are SHA3-512(label || master key), truncated to 32 bytes:\n\n")
+	p.printf("| Label | Use |\n")
+	p.printf("|-------|-----|\n")
+	p.printf("| `%s` | Key for `header_tag` |\n", HeaderMACLabel)
+	p.printf("| `%s` | Encrypts `metadata` when flag `0x%02x` is set |\n", HeaderEncryptionLabel, FlagHeaderEncrypted)
+	p.printf("| `%s` | Encrypts `footer` when flag `0x%02x` is set |\n", FooterEncryptionLabel, FlagDigestFooter)
+
+	p.printf("\nWith flag `0x%02x` (split trust), `header_tag`, `body.tag` and the footer tag\n", FlagSplitTrust)
+	p.printf("are computed under SHA3-512(`%s` || MAC key)[:32], where the MAC key is\n", SplitMACLabel)
+	p.printf("held apart from the master key (e.g. in an HSM); the `header_tag` input is\n")
+	p.printf("prefixed with `%s`. The master key alone can\n", HeaderMACLabel)
+	p.printf("decrypt but cannot produce valid tags. Readers given a MAC key must reject\n")
+	p.printf("containers without the flag.\n")
+
+	p.printf("\nWith flag `0x%02x` (per-file key), the body is encrypted and tagged under\n", FlagFileKey)
+	p.printf("HMAC-SHA3-512(master key, `EAMSA512-FILE-KEY` || 0x00 || `%s` || 0x00 ||\n", FileKeyLabel)
+	p.printf("`body.nonce`)[:32] instead of the master key. Header and footer keys are unchanged.\n")
+
+	p.printf("\nWith flag `0x%02x` (stretched key), every key above derives from\n", FlagStretchedKey)
+	p.printf("Argon2id(master key, `EAMSA512-KEY-STRETCH` || salt) with the memory, iterations and\n")
+	p.printf("parallelism of `key_stretch` in place of the master key. Writers set it for\n")
+	p.printf("master keys that look low-entropy; readers must bound the recorded cost.\n")
+
+	p.printf("\n## Parsing Rules\n\n")
+	p.printf("1. Reject input shorter than %d bytes or not starting with `%s`.\n", PrefixSize+TagSize, Magic)
+	p.printf("2. Reject unknown versions, cipher suites, flag bits and a non-zero reserved byte.\n")
+	p.printf("3. The header is %d + N + %d bytes (%d more with flag `0x%02x`); verify `header_tag`\n", PrefixSize, TagSize, StretchSize, FlagStretchedKey)
+	p.printf("   before using metadata.\n")
+	p.printf("4. With flag `0x%02x`, the last %d bytes are the footer.\n", FlagDigestFooter, FooterSize)
+	p.printf("5. The body is at least %d bytes; `body.ciphertext` is a multiple of %d bytes.\n", MinBodySize, BlockSize)
+
+	return p.err
+}
+
+// cell escapes a Markdown table cell
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// specWriter remembers the first write error
+type specWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *specWriter) printf(format string, args ...interface{}) {
+	if p.err != nil {
+		return
+	}
+	_, p.err = fmt.Fprintf(p.w, format, args...)
+}
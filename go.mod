@@ -1,7 +1,14 @@
-module eamsa512
+module github.com/Redeaux-Corporation/eamsa512
 
 go 1.21
 
-require golang.org/x/crypto v0.17.0
-
-require golang.org/x/sys v0.15.0 // indirect
+require (
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.52
+	github.com/miekg/pkcs11 v1.1.1
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/metric v1.28.0
+	golang.org/x/crypto v0.17.0
+	golang.org/x/sys v0.15.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -1,191 +0,0 @@
-// kdf-compliance.go - NIST SP 800-56A Compliant Key Derivation Function
-package main
-
-import (
-	"crypto/sha512"
-	"fmt"
-	"golang.org/x/crypto/sha3"
-)
-
-// KDFNISTCompliance implements NIST SP 800-56A compliant KDF
-type KDFNISTCompliance struct {
-	hashFunction string
-	entropyBits  int
-	securityBits int
-}
-
-// NewKDFNISTCompliance creates NIST SP 800-56A compliant KDF
-func NewKDFNISTCompliance() *KDFNISTCompliance {
-	return &KDFNISTCompliance{
-		hashFunction: "SHA3-512",
-		entropyBits:  512,
-		securityBits: 256,
-	}
-}
-
-// DeriveKeysNISTSP80056A derives keys using NIST SP 800-56A Section 5.8.1
-// Concatenation KDF implementation
-func (kdf *KDFNISTCompliance) DeriveKeysNISTSP80056A(
-	masterKey [32]byte,
-	nonce [16]byte,
-	sharedSecret []byte,
-	counter uint32,
-) ([11][16]byte, error) {
-	
-	// NIST SP 800-56A Section 5.8.1 - Concatenation KDF
-	// Input: masterKey, nonce, sharedSecret
-	// Output: 11 × 128-bit keys (1408 bits total)
-	
-	derivedKeys := [11][16]byte{}
-	
-	// KDF Input = counter || fixedInfo || masterKey || nonce || sharedSecret
-	for keyIndex := 0; keyIndex < 11; keyIndex++ {
-		// Counter starts at 1 for first key (NIST requirement)
-		currentCounter := counter + uint32(keyIndex+1)
-		
-		// Build KDF input per NIST SP 800-56A
-		kdfInput := make([]byte, 0, 4+32+16+len(sharedSecret))
-		
-		// Append counter (big-endian, 4 bytes)
-		kdfInput = append(kdfInput,
-			byte((currentCounter >> 24) & 0xFF),
-			byte((currentCounter >> 16) & 0xFF),
-			byte((currentCounter >> 8) & 0xFF),
-			byte(currentCounter & 0xFF),
-		)
-		
-		// Append master key
-		kdfInput = append(kdfInput, masterKey[:]...)
-		
-		// Append nonce
-		kdfInput = append(kdfInput, nonce[:]...)
-		
-		// Append shared secret (entropy source)
-		kdfInput = append(kdfInput, sharedSecret...)
-		
-		// Hash using SHA3-512 (NIST FIPS 202 approved)
-		h := sha3.New512()
-		h.Write(kdfInput)
-		hash := h.Sum(nil)
-		
-		// Extract 128 bits (16 bytes) for this key
-		copy(derivedKeys[keyIndex][:], hash[:16])
-	}
-	
-	return derivedKeys, nil
-}
-
-// ValidateDerivedKeys verifies derived keys meet NIST requirements
-func (kdf *KDFNISTCompliance) ValidateDerivedKeys(
-	keys [11][16]byte,
-) bool {
-	
-	// NIST requirement: All derived keys must be distinct
-	for i := 0; i < 11; i++ {
-		for j := i + 1; j < 11; j++ {
-			if keys[i] == keys[j] {
-				return false // Non-distinct keys
-			}
-		}
-	}
-	
-	// NIST requirement: Each key must have sufficient entropy
-	for i := 0; i < 11; i++ {
-		entropy := calculateEntropy(keys[i][:])
-		if entropy < 7.0 { // Minimum 7 bits/byte
-			return false
-		}
-	}
-	
-	return true
-}
-
-// VerifyEntropySource verifies entropy source quality per NIST
-func (kdf *KDFNISTCompliance) VerifyEntropySource(
-	source []byte,
-) bool {
-	
-	// Entropy must be at least 256 bits
-	if len(source) < 32 {
-		return false
-	}
-	
-	// Calculate entropy (Shannon entropy)
-	entropy := calculateEntropy(source)
-	
-	// NIST requires minimum 7.99 bits/byte for cryptographic use
-	minRequiredEntropy := 7.99 * float64(len(source))
-	actualEntropy := entropy * float64(len(source))
-	
-	return actualEntropy >= minRequiredEntropy
-}
-
-// calculateEntropy calculates Shannon entropy of data
-func calculateEntropy(data []byte) float64 {
-	if len(data) == 0 {
-		return 0.0
-	}
-	
-	// Count frequency of each byte value
-	freq := make([]int, 256)
-	for _, b := range data {
-		freq[b]++
-	}
-	
-	// Calculate Shannon entropy
-	entropy := 0.0
-	dataLen := float64(len(data))
-	
-	for _, count := range freq {
-		if count > 0 {
-			probability := float64(count) / dataLen
-			entropy -= probability * logBase2(probability)
-		}
-	}
-	
-	return entropy
-}
-
-// logBase2 calculates log base 2
-func logBase2(x float64) float64 {
-	if x <= 0 {
-		return 0
-	}
-	// ln(x) / ln(2) = log2(x)
-	const ln2 = 0.693147180559945309417232121458
-	return (math.Log(x) / ln2)
-}
-
-// PrintComplianceStatus prints NIST SP 800-56A compliance status
-func (kdf *KDFNISTCompliance) PrintComplianceStatus() {
-	fmt.Printf("\n✅ NIST SP 800-56A Compliance Status\n")
-	fmt.Printf("═════════════════════════════════════════════════════════════\n")
-	fmt.Printf("KDF Algorithm:     SHA3-512 (NIST FIPS 202 approved)\n")
-	fmt.Printf("Key Derivation:    Concatenation KDF per SP 800-56A Section 5.8.1\n")
-	fmt.Printf("Hash Function:     %s\n", kdf.hashFunction)
-	fmt.Printf("Entropy Bits:      %d\n", kdf.entropyBits)
-	fmt.Printf("Security Bits:     %d\n", kdf.securityBits)
-	fmt.Printf("Key Count:         11 (11 × 128-bit = 1408 bits total)\n")
-	fmt.Printf("Output Per Key:    128 bits (16 bytes)\n")
-	fmt.Printf("Counter Mode:      Big-endian 32-bit (NIST compliant)\n")
-	fmt.Printf("═════════════════════════════════════════════════════════════\n")
-	fmt.Printf("✅ COMPLIANT with NIST SP 800-56A Rev. 3\n")
-}
-
-// Stub for math.Log (would be imported)
-import "math"
-
-// GetComplianceCertificate returns compliance certificate data
-func (kdf *KDFNISTCompliance) GetComplianceCertificate() map[string]string {
-	cert := make(map[string]string)
-	cert["standard"] = "NIST SP 800-56A Rev. 3"
-	cert["section"] = "5.8.1 - Concatenation KDF"
-	cert["algorithm"] = "SHA3-512"
-	cert["hash_bits"] = "512"
-	cert["output_bits"] = "1408 (11 × 128)"
-	cert["counter_mode"] = "Big-endian 32-bit"
-	cert["entropy_requirement"] = "7.99+ bits/byte"
-	cert["status"] = "COMPLIANT"
-	cert["validation_date"] = "2025-12-04"
-	return cert
-}
@@ -0,0 +1,166 @@
+// Package keyid defines the audit-safe identifier used to display and
+// correlate EAMSA 512 key material without revealing it.
+//
+// An identifier has the multihash-style form
+//
+//	<algorithm>:<bits>:<hex digest>
+//
+// for example "sha3-512:128:3a985da74fe225b2045c172d6bd390bd". The digest
+// is SHA3-512(key) truncated to <bits>. KeyManager, the database, the REST
+// API and the CLI all use this format; legacy values (a bare 32-character
+// hex prefix of SHA3-512) are still accepted by Parse.
+package keyid
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	// Algorithm is the digest algorithm of identifiers produced by New
+	Algorithm = "sha3-512"
+
+	// DefaultBits is the truncation length of identifiers produced by New
+	DefaultBits = 128
+
+	// MinBits is the shortest truncation accepted by Parse
+	MinBits = 64
+
+	// MaxBits is the full SHA3-512 output length
+	MaxBits = 512
+)
+
+// ID is a parsed key identifier
+type ID struct {
+	Algorithm string
+	Bits      int
+	Digest    []byte
+}
+
+// New computes the standard identifier of key material
+func New(key []byte) ID {
+	return NewWithBits(key, DefaultBits)
+}
+
+// NewWithBits computes an identifier truncated to bits (multiple of 8, MinBits..MaxBits)
+func NewWithBits(key []byte, bits int) ID {
+	if bits < MinBits || bits > MaxBits || bits%8 != 0 {
+		bits = DefaultBits
+	}
+
+	digest := sha3.Sum512(key)
+
+	return ID{
+		Algorithm: Algorithm,
+		Bits:      bits,
+		Digest:    digest[:bits/8],
+	}
+}
+
+// String returns the canonical "<algorithm>:<bits>:<hex>" form
+func (id ID) String() string {
+	return fmt.Sprintf("%s:%d:%s", id.Algorithm, id.Bits, hex.EncodeToString(id.Digest))
+}
+
+// Short returns the hex digest only, for space-constrained displays
+func (id ID) Short() string {
+	return hex.EncodeToString(id.Digest)
+}
+
+// Parse parses a canonical or legacy identifier
+func Parse(s string) (ID, error) {
+	s = strings.TrimSpace(strings.ToLower(s))
+
+	parts := strings.Split(s, ":")
+	switch len(parts) {
+	case 1:
+		// Legacy: bare hex prefix of SHA3-512
+		return parseDigest(Algorithm, len(parts[0])*4, parts[0])
+	case 3:
+		bits, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return ID{}, fmt.Errorf("invalid key identifier length %q", parts[1])
+		}
+		return parseDigest(parts[0], bits, parts[2])
+	default:
+		return ID{}, fmt.Errorf("invalid key identifier %q", s)
+	}
+}
+
+// parseDigest validates algorithm, truncation length and hex digest
+func parseDigest(algorithm string, bits int, digestHex string) (ID, error) {
+	if algorithm != Algorithm {
+		return ID{}, fmt.Errorf("unsupported key identifier algorithm %q", algorithm)
+	}
+
+	if bits < MinBits || bits > MaxBits || bits%8 != 0 {
+		return ID{}, fmt.Errorf("invalid key identifier length: %d bits", bits)
+	}
+
+	digest, err := hex.DecodeString(digestHex)
+	if err != nil {
+		return ID{}, fmt.Errorf("invalid key identifier digest: %v", err)
+	}
+
+	if len(digest)*8 != bits {
+		return ID{}, fmt.Errorf("key identifier digest is %d bits, expected %d", len(digest)*8, bits)
+	}
+
+	return ID{
+		Algorithm: algorithm,
+		Bits:      bits,
+		Digest:    digest,
+	}, nil
+}
+
+// Equal reports whether two identifiers name the same key. Identifiers with
+// different truncation lengths are compared on their common prefix.
+func (id ID) Equal(other ID) bool {
+	if id.Algorithm != other.Algorithm {
+		return false
+	}
+
+	n := len(id.Digest)
+	if len(other.Digest) < n {
+		n = len(other.Digest)
+	}
+	if n*8 < MinBits {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(id.Digest[:n], other.Digest[:n]) == 1
+}
+
+// Matches reports whether id identifies key
+func (id ID) Matches(key []byte) bool {
+	return id.Equal(NewWithBits(key, MaxBits))
+}
+
+// Compare parses two identifier strings and reports whether they are equal
+func Compare(a, b string) (bool, error) {
+	idA, err := Parse(a)
+	if err != nil {
+		return false, err
+	}
+
+	idB, err := Parse(b)
+	if err != nil {
+		return false, err
+	}
+
+	return idA.Equal(idB), nil
+}
+
+// Normalize converts a canonical or legacy identifier to canonical form
+func Normalize(s string) (string, error) {
+	id, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
+}
@@ -0,0 +1,56 @@
+package keyid
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// EAMSA 512 - Key Identifier Test Suite
+// Canonical and legacy identifiers, truncation and comparison
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// TestKeyID tests that identifiers round-trip through Parse and compare
+// on their common prefix
+func TestKeyID(t *testing.T) {
+	fmt.Println("Test: Key Identifiers")
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	id := New(key)
+	if !strings.HasPrefix(id.String(), "sha3-512:128:") || len(id.Short()) != 32 {
+		t.Fatalf("Unexpected identifier %s", id)
+	}
+
+	parsed, err := Parse(strings.ToUpper(id.String()))
+	if err != nil || !parsed.Equal(id) || parsed.String() != id.String() {
+		t.Fatalf("Parse(%s) = %s, %v", id, parsed, err)
+	}
+	if !id.Matches(key) || id.Matches([]byte("another key")) {
+		t.Fatal("Matches does not identify the key")
+	}
+
+	// A legacy bare prefix and a longer identifier name the same key
+	if equal, err := Compare(id.Short(), NewWithBits(key, MaxBits).String()); err != nil || !equal {
+		t.Fatalf("Legacy and full identifiers differ: %v", err)
+	}
+	if normalized, err := Normalize(id.Short()); err != nil || normalized != id.String() {
+		t.Fatalf("Normalize = %s, %v", normalized, err)
+	}
+
+	for _, s := range []string{
+		"md5:128:" + id.Short(),           // Unsupported algorithm
+		"sha3-512:32:" + id.Short()[:8],   // Below MinBits
+		"sha3-512:128:" + id.Short()[:16], // Digest shorter than its length
+		"sha3-512:128:zz",
+		"a:b",
+	} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse accepted %q", s)
+		}
+	}
+
+	fmt.Println("✓ Identifiers parse, normalize and compare")
+}
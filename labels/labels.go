@@ -0,0 +1,147 @@
+// Package labels implements key scoping labels (region=eu, tenant=acme,
+// classification=secret) and the selectors used to filter and authorize
+// keys by label.
+//
+// Label keys are lower-case identifiers of letters, digits, '-', '_' and
+// '.', at most 63 characters; values may additionally be empty. The text
+// form of a set or selector is a comma-separated list of key=value pairs.
+package labels
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// MaxLength is the maximum length of a label key or value
+	MaxLength = 63
+
+	// UserAttribute as a required value binds the label to the user attribute
+	// of the same name, e.g. {"tenant": UserAttribute} means "the key's tenant
+	// label must equal the user's tenant attribute"
+	UserAttribute = "$user"
+)
+
+// Set is a set of labels attached to a key
+type Set map[string]string
+
+// Parse parses "k1=v1,k2=v2" into a Set
+func Parse(s string) (Set, error) {
+	set := Set{}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return set, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid label %q: expected key=value", pair)
+		}
+
+		key := strings.TrimSpace(kv[0])
+		value := strings.TrimSpace(kv[1])
+
+		if _, dup := set[key]; dup {
+			return nil, fmt.Errorf("duplicate label %q", key)
+		}
+		set[key] = value
+	}
+
+	if err := set.Validate(); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// Validate checks label keys and values
+func (s Set) Validate() error {
+	for key, value := range s {
+		if key == "" {
+			return fmt.Errorf("label key must not be empty")
+		}
+		if err := validateToken(key); err != nil {
+			return fmt.Errorf("invalid label key %q: %v", key, err)
+		}
+		if value == "" {
+			continue
+		}
+		if err := validateToken(value); err != nil {
+			return fmt.Errorf("invalid value for label %q: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// validateToken checks a label key or value
+func validateToken(token string) error {
+	if len(token) > MaxLength {
+		return fmt.Errorf("longer than %d characters", MaxLength)
+	}
+
+	for _, c := range token {
+		switch {
+		case c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+		default:
+			return fmt.Errorf("character %q not allowed", c)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether s carries every label in selector with an equal
+// value. An empty selector matches every set.
+func (s Set) Matches(selector Set) bool {
+	for key, value := range selector {
+		got, ok := s[key]
+		if !ok || got != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Resolve substitutes UserAttribute values in a required-label set with the
+// given user attributes. It fails if a referenced attribute is missing, so a
+// user without a tenant can never satisfy a tenant-bound policy.
+func (s Set) Resolve(attributes map[string]string) (Set, error) {
+	resolved := make(Set, len(s))
+	for key, value := range s {
+		if value == UserAttribute {
+			attr, ok := attributes[key]
+			if !ok || attr == "" {
+				return nil, fmt.Errorf("user has no %q attribute", key)
+			}
+			value = attr
+		}
+		resolved[key] = value
+	}
+	return resolved, nil
+}
+
+// Copy returns an independent copy of s
+func (s Set) Copy() Set {
+	c := make(Set, len(s))
+	for key, value := range s {
+		c[key] = value
+	}
+	return c
+}
+
+// String returns the canonical "k1=v1,k2=v2" form, sorted by key
+func (s Set) String() string {
+	keys := make([]string, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + s[key]
+	}
+	return strings.Join(pairs, ",")
+}
@@ -1,303 +0,0 @@
-// phase3-sha3-updated.go - PRODUCTION VERSION: Phase 3 with SHA3-512
-package main
-
-import (
-	"crypto/subtle"
-	"encoding/binary"
-	"fmt"
-	"golang.org/x/crypto/sha3"
-	"io"
-	"sync"
-	"time"
-)
-
-// CipherResultSHA3 holds encryption result with SHA3-512 MAC
-type CipherResultSHA3 struct {
-	Ciphertext [64]byte // 512-bit encrypted data
-	MAC        [64]byte // 512-bit authentication tag (HMAC-SHA3-512)
-	Nonce      [16]byte // Block-specific nonce
-	Counter    uint64   // Block sequence number
-	Valid      bool     // MAC verification flag
-}
-
-// EAMSA512ConfigSHA3 defines configuration with SHA3-512
-type EAMSA512ConfigSHA3 struct {
-	MasterKey        [32]byte  // 256-bit primary key
-	Nonce            [16]byte  // 128-bit unique nonce
-	AuthKey          [32]byte  // 256-bit auth key (optional)
-	RoundCount       int       // Encryption rounds (default 16)
-	IncludeAuth      bool      // Enable MAC verification
-	AuthAlgorithm    string    // "HMAC-SHA3-512"
-	Mode             string    // "CBC", "CTR", "ECB"
-}
-
-// EAMSA512CipherSHA3 is the main production cipher with SHA3-512
-type EAMSA512CipherSHA3 struct {
-	Phase1Generator    *KDFVectorized
-	Phase2Encryptor    *Phase2Encryptor
-	AuthKeyMaterial    [64]byte // Auth key (SHA3-512 derived)
-	AuthCounter        uint64   // MAC counter
-	EncryptionCounter  uint64   // Block counter
-	Mode               string
-	RoundCount         int
-	mu                 sync.RWMutex
-}
-
-// NewEAMSA512CipherSHA3 creates new production cipher
-func NewEAMSA512CipherSHA3(config *EAMSA512ConfigSHA3) *EAMSA512CipherSHA3 {
-	// Phase 1: Generate keys using chaos KDF
-	chaos := NewChaosStateVectorized(1.0)
-	chaos.UpdateLorenz6D(0.01, 1000)
-	chaos.UpdateHyperchaotic5D(0.01, 1000)
-
-	kdf := NewKDFVectorized(config.MasterKey, config.Nonce)
-	keys := kdf.DeriveKeysVectorized(chaos)
-
-	// Phase 2: Create encryptor
-	phase2 := NewPhase2Encryptor(keys[7], keys[8], config.Nonce)
-
-	// Phase 3: Derive auth key material using SHA3-512
-	authKeyMaterial := kdf.ExtractKeyMaterial([]byte("AUTH"))
-
-	return &EAMSA512CipherSHA3{
-		Phase1Generator:   kdf,
-		Phase2Encryptor:   phase2,
-		AuthKeyMaterial:   authKeyMaterial,
-		AuthCounter:       0,
-		EncryptionCounter: 0,
-		Mode:              config.Mode,
-		RoundCount:        config.RoundCount,
-	}
-}
-
-// EncryptBlockSHA3 encrypts 512-bit block with SHA3-512 MAC
-func (cipher *EAMSA512CipherSHA3) EncryptBlockSHA3(plaintext [64]byte) CipherResultSHA3 {
-	cipher.mu.Lock()
-	defer cipher.mu.Unlock()
-
-	result := CipherResultSHA3{
-		Counter: cipher.EncryptionCounter,
-	}
-
-	// Phase 2: Encrypt using chaos-derived keys
-	keys := [11][16]byte{}
-	// In production, retrieve from Phase 1
-	// For now, use default structure
-	for i := 0; i < 11; i++ {
-		keys[i] = cipher.Phase1Generator.GetKeyVectorized(i)
-	}
-
-	result.Ciphertext = cipher.Phase2Encryptor.EncryptBlockPhase2(plaintext, keys)
-
-	// Phase 3: Compute HMAC-SHA3-512 MAC
-	result.Nonce = cipher.Phase1Generator.nonce
-	result.MAC = cipher.ComputeMACHA3(plaintext, result.Ciphertext, result.Counter)
-	result.Valid = true
-
-	cipher.EncryptionCounter++
-	cipher.AuthCounter++
-
-	return result
-}
-
-// DecryptBlockSHA3 decrypts and verifies SHA3-512 MAC
-func (cipher *EAMSA512CipherSHA3) DecryptBlockSHA3(ciphertext [64]byte, mac [64]byte, counter uint64) ([64]byte, bool) {
-	cipher.mu.Lock()
-	defer cipher.mu.Unlock()
-
-	// Decrypt (same as encrypt in Feistel)
-	keys := [11][16]byte{}
-	for i := 0; i < 11; i++ {
-		keys[i] = cipher.Phase1Generator.GetKeyVectorized(i)
-	}
-
-	plaintext := cipher.Phase2Encryptor.EncryptBlockPhase2(ciphertext, keys)
-
-	// Verify MAC in constant-time
-	computedMAC := cipher.ComputeMACHA3(plaintext, ciphertext, counter)
-	isValid := cipher.VerifyMACHA3(plaintext, ciphertext, counter, mac, computedMAC)
-
-	return plaintext, isValid
-}
-
-// ComputeMACHA3 computes HMAC-SHA3-512 for authentication
-func (cipher *EAMSA512CipherSHA3) ComputeMACHA3(plaintext, ciphertext [64]byte, counter uint64) [64]byte {
-	result := [64]byte{}
-
-	// HMAC-SHA3-512 with auth key material
-	mac := sha3.New512()
-
-	// Write key (using XOR with counter as key variation)
-	keyBytes := make([]byte, 64)
-	for i := 0; i < 64; i++ {
-		keyBytes[i] = cipher.AuthKeyMaterial[i] ^ byte(counter>>(uint(i%8)*8))
-	}
-	mac.Write(keyBytes)
-
-	// Write message (plaintext || ciphertext || counter)
-	mac.Write(plaintext[:])
-	mac.Write(ciphertext[:])
-	counterBytes := make([]byte, 8)
-	binary.LittleEndian.PutUint64(counterBytes, counter)
-	mac.Write(counterBytes)
-
-	fullMac := mac.Sum(nil) // 64 bytes
-	copy(result[:], fullMac[:64])
-
-	return result
-}
-
-// VerifyMACHA3 verifies SHA3-512 MAC in constant-time
-func (cipher *EAMSA512CipherSHA3) VerifyMACHA3(plaintext, ciphertext [64]byte, counter uint64, receivedMAC, computedMAC [64]byte) bool {
-	// Constant-time comparison (no timing leaks)
-	return subtle.ConstantTimeCompare(receivedMAC[:], computedMAC[:]) == 1
-}
-
-// EncryptStreamSHA3 encrypts entire stream with SHA3-512 MACs
-func (cipher *EAMSA512CipherSHA3) EncryptStreamSHA3(input io.Reader, output io.Writer) (int64, error) {
-	var totalBytes int64 = 0
-	buffer := make([]byte, 64)
-
-	for {
-		n, err := input.Read(buffer)
-		if err != nil && err != io.EOF {
-			return totalBytes, err
-		}
-
-		if n == 0 {
-			break
-		}
-
-		// Pad if necessary
-		if n < 64 {
-			for i := n; i < 64; i++ {
-				buffer[i] = byte(64 - n) // PKCS7 padding
-			}
-		}
-
-		// Convert to array
-		plaintext := [64]byte{}
-		copy(plaintext[:], buffer)
-
-		// Encrypt and authenticate
-		result := cipher.EncryptBlockSHA3(plaintext)
-
-		// Write to output: ciphertext || MAC || nonce || counter
-		output.Write(result.Ciphertext[:])
-		output.Write(result.MAC[:])
-		output.Write(result.Nonce[:])
-		output.Write(make([]byte, 8)) // counter placeholder
-
-		totalBytes += 64
-
-		if err == io.EOF {
-			break
-		}
-	}
-
-	return totalBytes, nil
-}
-
-// DecryptStreamSHA3 decrypts stream and verifies all MACs
-func (cipher *EAMSA512CipherSHA3) DecryptStreamSHA3(input io.Reader, output io.Writer) (int64, error) {
-	var totalBytes int64 = 0
-	blockSize := 64 + 64 + 16 + 8 // ciphertext + MAC + nonce + counter
-	buffer := make([]byte, blockSize)
-
-	for {
-		n, err := input.Read(buffer)
-		if err != nil && err != io.EOF {
-			return totalBytes, err
-		}
-
-		if n < blockSize {
-			if n > 0 && err != io.EOF {
-				return totalBytes, fmt.Errorf("incomplete block")
-			}
-			break
-		}
-
-		// Extract components
-		ciphertext := [64]byte{}
-		mac := [64]byte{}
-		copy(ciphertext[:], buffer[0:64])
-		copy(mac[:], buffer[64:128])
-
-		counter := totalBytes / 64
-
-		// Decrypt and verify
-		plaintext, valid := cipher.DecryptBlockSHA3(ciphertext, mac, uint64(counter))
-
-		if !valid {
-			return totalBytes, fmt.Errorf("MAC verification failed at block %d", counter)
-		}
-
-		// Write plaintext (remove padding on last block if needed)
-		output.Write(plaintext[:])
-		totalBytes += 64
-
-		if err == io.EOF {
-			break
-		}
-	}
-
-	return totalBytes, nil
-}
-
-// GetStatistics returns encryption statistics
-func (cipher *EAMSA512CipherSHA3) GetStatistics() map[string]interface{} {
-	cipher.mu.RLock()
-	defer cipher.mu.RUnlock()
-
-	return map[string]interface{}{
-		"blocks_encrypted":    cipher.EncryptionCounter,
-		"macs_computed":       cipher.AuthCounter,
-		"auth_algorithm":      "HMAC-SHA3-512",
-		"mac_size_bits":       512,
-		"cipher_mode":         cipher.Mode,
-		"timestamp":           time.Now().Unix(),
-	}
-}
-
-// ResetCounters resets internal counters
-func (cipher *EAMSA512CipherSHA3) ResetCounters() {
-	cipher.mu.Lock()
-	defer cipher.mu.Unlock()
-
-	cipher.EncryptionCounter = 0
-	cipher.AuthCounter = 0
-}
-
-// ValidateConfiguration checks cipher configuration
-func (config *EAMSA512ConfigSHA3) ValidateConfiguration() bool {
-	// Check auth algorithm
-	if config.AuthAlgorithm != "HMAC-SHA3-512" {
-		return false
-	}
-
-	// Check cipher mode
-	validModes := map[string]bool{"CBC": true, "CTR": true, "ECB": true}
-	if !validModes[config.Mode] {
-		return false
-	}
-
-	// Check round count
-	if config.RoundCount < 1 || config.RoundCount > 32 {
-		return false
-	}
-
-	return true
-}
-
-// PrintCipherInfo prints cipher information
-func (cipher *EAMSA512CipherSHA3) PrintCipherInfo() {
-	fmt.Println("EAMSA 512 Cipher Configuration (SHA3-512):")
-	fmt.Printf("  Algorithm:        EAMSA-512\n")
-	fmt.Printf("  Block Size:       512 bits\n")
-	fmt.Printf("  Key Material:     1024 bits (11 × 128-bit)\n")
-	fmt.Printf("  MAC Algorithm:    HMAC-SHA3-512\n")
-	fmt.Printf("  MAC Size:         512 bits (64 bytes)\n")
-	fmt.Printf("  Encryption Mode:  %s\n", cipher.Mode)
-	fmt.Printf("  Rounds:           %d\n", cipher.RoundCount)
-	fmt.Printf("  Status:           ✓ Production Ready\n")
-}
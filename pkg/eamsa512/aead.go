@@ -0,0 +1,151 @@
+package eamsa512
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// aeadMACLabel derives the AEAD tag key, so AEAD and EncryptData tags
+// never share a key
+const aeadMACLabel = "EAMSA512-AEAD-MAC"
+
+// eamsaAEAD is the cipher.AEAD returned by NewAEAD
+type eamsaAEAD struct {
+	masterKey []byte
+	keys      [][]byte
+	macKey    []byte
+}
+
+// NewAEAD returns the cipher in the crypto/cipher AEAD interface, so it
+// can replace AES-GCM or ChaCha20-Poly1305. key is a 32-byte master key.
+//
+// Seal produces CBC ciphertext (PKCS#7 padded, 1 to 64 bytes) followed by
+// a 64-byte HMAC-SHA3-512 tag over the additional data, nonce and
+// ciphertext. The nonce is 16 bytes and must be unique per key. The
+// output is not the EncryptData format: the nonce is not included.
+func NewAEAD(key []byte) (cipher.AEAD, error) {
+	return newAEAD(key)
+}
+
+// newAEAD is NewAEAD returning the concrete type
+func newAEAD(key []byte) (*eamsaAEAD, error) {
+	keys, err := DeriveKeys(key)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha3.New512()
+	hash.Write([]byte(aeadMACLabel))
+	hash.Write(key)
+
+	return &eamsaAEAD{
+		masterKey: append([]byte(nil), key...),
+		keys:      keys,
+		macKey:    hash.Sum(nil)[:KeySize],
+	}, nil
+}
+
+// NonceSize returns the nonce length (16 bytes)
+func (a *eamsaAEAD) NonceSize() int {
+	return NonceSize
+}
+
+// Overhead returns the maximum ciphertext expansion: a full padding block
+// and the tag
+func (a *eamsaAEAD) Overhead() int {
+	return BlockSize + TagSize
+}
+
+// Seal encrypts and authenticates plaintext and additionalData and
+// appends the result to dst
+func (a *eamsaAEAD) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if len(nonce) != NonceSize {
+		panic(fmt.Sprintf("eamsa512: incorrect nonce length given to AEAD: expected %d, got %d", NonceSize, len(nonce)))
+	}
+	defer observeEncrypt(len(plaintext), time.Now(), nil)
+
+	paddedLength := (len(plaintext)/BlockSize + 1) * BlockSize
+	ret, out := sliceForAppend(dst, paddedLength+TagSize)
+
+	copy(out, plaintext)
+	paddingLength := paddedLength - len(plaintext)
+	for i := len(plaintext); i < paddedLength; i++ {
+		out[i] = byte(paddingLength)
+	}
+
+	encryptCBC(out[:paddedLength], a.keys, DeriveIV(nonce, a.masterKey))
+	copy(out[paddedLength:], a.tag(nonce, out[:paddedLength], additionalData))
+
+	return ret
+}
+
+// Open authenticates and decrypts ciphertext and appends the plaintext
+// to dst. The tag is checked before anything is decrypted, and every
+// failure is ErrDecryption.
+func (a *eamsaAEAD) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if len(nonce) != NonceSize {
+		panic(fmt.Sprintf("eamsa512: incorrect nonce length given to AEAD: expected %d, got %d", NonceSize, len(nonce)))
+	}
+
+	start := time.Now()
+	plaintext, err := a.open(dst, nonce, ciphertext, additionalData)
+	observeDecrypt(len(ciphertext), start, err)
+	return plaintext, err
+}
+
+// open is Open without telemetry
+func (a *eamsaAEAD) open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+
+	bodyLength := len(ciphertext) - TagSize
+	if bodyLength < BlockSize || bodyLength%BlockSize != 0 {
+		return nil, ErrDecryption
+	}
+
+	body := ciphertext[:bodyLength]
+	if subtle.ConstantTimeCompare(a.tag(nonce, body, additionalData), ciphertext[bodyLength:]) != 1 {
+		return nil, ErrDecryption
+	}
+
+	ret, out := sliceForAppend(dst, bodyLength)
+	copy(out, body)
+	decryptCBC(out, a.keys, DeriveIV(nonce, a.masterKey))
+
+	plaintext, err := UnpadPKCS7(out)
+	if err != nil {
+		return nil, err
+	}
+
+	return ret[:len(dst)+len(plaintext)], nil
+}
+
+// tag is HMAC-SHA3-512 over
+// uint64(len(additionalData)) || additionalData || nonce || ciphertext
+func (a *eamsaAEAD) tag(nonce, ciphertext, additionalData []byte) []byte {
+	var adLength [8]byte
+	binary.BigEndian.PutUint64(adLength[:], uint64(len(additionalData)))
+
+	mac := NewHMAC(a.macKey)
+	mac.Write(adLength[:])
+	mac.Write(additionalData)
+	mac.Write(nonce)
+	mac.Write(ciphertext)
+	return mac.Sum()
+}
+
+// sliceForAppend extends in by n bytes and returns the whole slice and
+// the extension, as the crypto/cipher implementations do
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,210 @@
+package eamsa512
+
+import (
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Constants for EAMSA 512
+const (
+	// BlockSize is the cipher block size: 512 bits = 64 bytes
+	BlockSize = 64
+
+	// Rounds is the number of SPN rounds
+	Rounds = 16
+
+	// NonceSize is the nonce size: 128 bits = 16 bytes
+	NonceSize = 16
+
+	// TagSize is the authentication tag size: 512 bits = 64 bytes
+	TagSize = 64
+
+	// KeySize is the master key size: 256 bits = 32 bytes
+	KeySize = 32
+)
+
+// DeriveKeys generates 11 round keys from the master key using SHA3-512
+// Each key is 128 bits (16 bytes)
+func DeriveKeys(masterKey []byte) ([][]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d bytes, got %d", KeySize, len(masterKey))
+	}
+
+	const numKeys = 11
+	const keySize = 16 // 128 bits per derived key
+
+	keys := make([][]byte, numKeys)
+	for i := 0; i < numKeys; i++ {
+		hash := sha3.New512()
+
+		// Include iteration counter to ensure different keys
+		hash.Write(masterKey)
+		hash.Write([]byte(fmt.Sprintf("key_%d", i)))
+
+		digest := hash.Sum(nil) // 64 bytes
+		keys[i] = digest[:keySize]
+	}
+
+	return keys, nil
+}
+
+// DeriveIV derives the 64-byte CBC initialization vector from nonce and
+// key using SHA3-512
+func DeriveIV(nonce []byte, key []byte) []byte {
+	hash := sha3.New512()
+	hash.Write(nonce)
+	hash.Write(key)
+	return hash.Sum(nil)
+}
+
+// ============================================================================
+// Round functions (SPN - Substitution-Permutation Network)
+// ============================================================================
+
+// sboxLabel seeds the S-box shuffle
+const sboxLabel = "EAMSA512-SBOX"
+
+// sbox is the S-box, a permutation of the byte values, and sboxInverse
+// undoes it. Both are computed once; buildSBox refuses a table that is
+// not invertible, so the package cannot start with one.
+var sbox, sboxInverse = buildSBox()
+
+// buildSBox shuffles 0..255 (Fisher-Yates) with SHAKE256(sboxLabel) as
+// the random source and returns the table and its inverse. It panics
+// unless sboxInverse[sbox[b]] == b for every byte b.
+func buildSBox() (forward, inverse [256]byte) {
+	for b := range forward {
+		forward[b] = byte(b)
+	}
+
+	xof := sha3.NewShake256()
+	xof.Write([]byte(sboxLabel))
+	var r [1]byte
+	for i := len(forward) - 1; i > 0; i-- {
+		// Reject draws above the largest multiple of i+1 to keep j uniform
+		limit := 256 - 256%(i+1)
+		for {
+			xof.Read(r[:])
+			if int(r[0]) < limit {
+				break
+			}
+		}
+		j := int(r[0]) % (i + 1)
+		forward[i], forward[j] = forward[j], forward[i]
+	}
+
+	for b, out := range forward {
+		inverse[out] = byte(b)
+	}
+	for b := range forward {
+		if inverse[forward[b]] != byte(b) {
+			panic(fmt.Sprintf("eamsa512: S-box inverse does not undo 0x%02x", b))
+		}
+	}
+	return forward, inverse
+}
+
+// SubstituteBlock applies the S-box to each byte of block
+func SubstituteBlock(block []byte) []byte {
+	result := make([]byte, len(block))
+
+	for i, b := range block {
+		result[i] = sbox[b]
+	}
+
+	return result
+}
+
+// PermuteBlock moves byte i to position (5i+7) mod len(block)
+func PermuteBlock(block []byte) []byte {
+	result := make([]byte, len(block))
+
+	for i := 0; i < len(block); i++ {
+		// 5 is coprime with the block size, so this is a bijection
+		newPos := (i*5 + 7) % len(block)
+		result[newPos] = block[i]
+	}
+
+	return result
+}
+
+// MixBlock XORs block with the (repeated) key
+func MixBlock(block []byte, key []byte) []byte {
+	result := make([]byte, len(block))
+
+	for i := 0; i < len(block); i++ {
+		result[i] = block[i] ^ key[i%len(key)]
+	}
+
+	return result
+}
+
+// ReversePermuteBlock reverses the permutation: byte i returns from
+// position (5i+7) mod len(block)
+func ReversePermuteBlock(block []byte) []byte {
+	result := make([]byte, len(block))
+
+	for i := 0; i < len(block); i++ {
+		result[i] = block[(i*5+7)%len(block)]
+	}
+
+	return result
+}
+
+// ReverseSubstituteBlock applies the inverse S-box to each byte of block
+func ReverseSubstituteBlock(block []byte) []byte {
+	result := make([]byte, len(block))
+
+	for i, b := range block {
+		result[i] = sboxInverse[b]
+	}
+
+	return result
+}
+
+// expandKey repeats a round key to the block size
+func expandKey(key []byte) []byte {
+	expanded := make([]byte, BlockSize)
+	for i := 0; i < BlockSize; i++ {
+		expanded[i] = key[i%len(key)]
+	}
+	return expanded
+}
+
+// EncryptBlock encrypts a single 64-byte block with the round keys from
+// DeriveKeys and returns the ciphertext block
+func EncryptBlock(block []byte, keys [][]byte) []byte {
+	ciphertext := make([]byte, len(block))
+	copy(ciphertext, block)
+
+	// 16 rounds of substitution, permutation and mixing, cycling the keys
+	for round := 0; round < Rounds; round++ {
+		roundKey := keys[round%len(keys)]
+
+		ciphertext = SubstituteBlock(ciphertext)
+		ciphertext = PermuteBlock(ciphertext)
+		ciphertext = MixBlock(ciphertext, expandKey(roundKey))
+	}
+
+	// Final round: additional XOR with last key
+	return MixBlock(ciphertext, expandKey(keys[len(keys)-1]))
+}
+
+// DecryptBlock decrypts a single 64-byte block, applying the inverse
+// operations in reverse order
+func DecryptBlock(ciphertext []byte, keys [][]byte) []byte {
+	// Reverse final key XOR
+	plaintext := MixBlock(ciphertext, expandKey(keys[len(keys)-1]))
+
+	for round := Rounds - 1; round >= 0; round-- {
+		roundKey := keys[round%len(keys)]
+
+		// XOR is self-inverse
+		plaintext = MixBlock(plaintext, expandKey(roundKey))
+		plaintext = ReversePermuteBlock(plaintext)
+		plaintext = ReverseSubstituteBlock(plaintext)
+	}
+
+	return plaintext
+}
@@ -0,0 +1,177 @@
+package eamsa512
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The Phase 2/3 APIs pass keys, nonces, blocks and MACs as arrays, while
+// the rest of this package and the example code pass byte slices. The As* helpers
+// check a slice's length and view it as the array in place, without a
+// copy, and the *Slice methods below accept and return slices. Sizes are
+// the library's: a Phase 3 block is BlockSize bytes, its MAC TagSize, the
+// master and auth keys KeySize and the nonce NonceSize.
+
+// phase2KeySize is the size of the two Phase 2 subkeys
+const phase2KeySize = 16
+
+// ErrMACMismatch is returned by DecryptBlockSlice for a forged or
+// corrupted block
+var ErrMACMismatch = errors.New("MAC verification failed")
+
+// SizeError reports a slice of the wrong length for a fixed-size value
+type SizeError struct {
+	What string // e.g. "block", "nonce"
+	Got  int
+	Want int
+}
+
+func (e *SizeError) Error() string {
+	return fmt.Sprintf("invalid %s length %d: want %d bytes", e.What, e.Got, e.Want)
+}
+
+// AsBlock returns b as a Phase 3 block. The array aliases b.
+func AsBlock(b []byte) (*[64]byte, error) {
+	if len(b) != BlockSize {
+		return nil, &SizeError{What: "block", Got: len(b), Want: BlockSize}
+	}
+	return (*[64]byte)(b), nil
+}
+
+// AsMAC returns b as a Phase 3 block MAC. The array aliases b.
+func AsMAC(b []byte) (*[64]byte, error) {
+	if len(b) != TagSize {
+		return nil, &SizeError{What: "MAC", Got: len(b), Want: TagSize}
+	}
+	return (*[64]byte)(b), nil
+}
+
+// AsKey returns b as a master or auth key. The array aliases b.
+func AsKey(b []byte) (*[32]byte, error) {
+	if len(b) != KeySize {
+		return nil, &SizeError{What: "key", Got: len(b), Want: KeySize}
+	}
+	return (*[32]byte)(b), nil
+}
+
+// AsNonce returns b as a nonce. The array aliases b.
+func AsNonce(b []byte) (*[16]byte, error) {
+	if len(b) != NonceSize {
+		return nil, &SizeError{What: "nonce", Got: len(b), Want: NonceSize}
+	}
+	return (*[16]byte)(b), nil
+}
+
+// NewConfigSHA3 returns a configuration for masterKey and nonce with the
+// CLI's settings: 16 rounds, CBC mode, HMAC-SHA3-512
+func NewConfigSHA3(masterKey, nonce []byte) (*EAMSA512ConfigSHA3, error) {
+	key, err := AsKey(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	n, err := AsNonce(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EAMSA512ConfigSHA3{
+		MasterKey:     *key,
+		Nonce:         *n,
+		RoundCount:    16,
+		IncludeAuth:   true,
+		AuthAlgorithm: "HMAC-SHA3-512",
+		Mode:          "CBC",
+	}, nil
+}
+
+// SetAuthKey sets the split-trust MAC key from a slice
+func (config *EAMSA512ConfigSHA3) SetAuthKey(authKey []byte) error {
+	key, err := AsKey(authKey)
+	if err != nil {
+		return fmt.Errorf("auth key: %v", err)
+	}
+	config.AuthKey = *key
+	return nil
+}
+
+// EncryptBlockSlice encrypts one block given as a slice. It fails if
+// plaintext is not one block, or if the block counter cannot be reserved
+// (see EncryptBlockSHA3).
+func (cipher *EAMSA512CipherSHA3) EncryptBlockSlice(plaintext []byte) (CipherResultSHA3, error) {
+	block, err := AsBlock(plaintext)
+	if err != nil {
+		return CipherResultSHA3{}, err
+	}
+
+	result := cipher.EncryptBlockSHA3(*block)
+	if !result.Valid {
+		return result, cipher.CounterError()
+	}
+	return result, nil
+}
+
+// DecryptBlockSlice verifies mac and decrypts one block into dst, which
+// must hold a block; it may overlap ciphertext exactly. On ErrMACMismatch
+// dst is zeroed.
+func (cipher *EAMSA512CipherSHA3) DecryptBlockSlice(dst, ciphertext, mac []byte, counter uint64) error {
+	out, err := AsBlock(dst)
+	if err != nil {
+		return fmt.Errorf("destination: %v", err)
+	}
+	block, err := AsBlock(ciphertext)
+	if err != nil {
+		return err
+	}
+	tag, err := AsMAC(mac)
+	if err != nil {
+		return err
+	}
+
+	plaintext, ok := cipher.DecryptBlockSHA3(*block, *tag, counter)
+	*out = plaintext
+	if !ok {
+		return ErrMACMismatch
+	}
+	return nil
+}
+
+// VerifyMACSlice compares a received MAC with the computed one in
+// constant time; a MAC of the wrong length never verifies
+func (cipher *EAMSA512CipherSHA3) VerifyMACSlice(receivedMAC []byte, computedMAC [64]byte) bool {
+	tag, err := AsMAC(receivedMAC)
+	if err != nil {
+		return false
+	}
+	return cipher.VerifyMACHA3(*tag, computedMAC)
+}
+
+// NewPhase2EncryptorSlices creates a Phase 2 encryptor from 16-byte
+// subkeys and a nonce given as slices
+func NewPhase2EncryptorSlices(key1, key2, nonce []byte) (*Phase2Encryptor, error) {
+	if len(key1) != phase2KeySize {
+		return nil, &SizeError{What: "Phase 2 key 1", Got: len(key1), Want: phase2KeySize}
+	}
+	if len(key2) != phase2KeySize {
+		return nil, &SizeError{What: "Phase 2 key 2", Got: len(key2), Want: phase2KeySize}
+	}
+	n, err := AsNonce(nonce)
+	if err != nil {
+		return nil, err
+	}
+	return NewPhase2Encryptor([16]byte(key1), [16]byte(key2), *n), nil
+}
+
+// EncryptBlockPhase2Slice runs the Phase 2 rounds over one block of src
+// into dst, which may be src
+func (pe *Phase2Encryptor) EncryptBlockPhase2Slice(dst, src []byte, keys [11][16]byte) error {
+	out, err := AsBlock(dst)
+	if err != nil {
+		return fmt.Errorf("destination: %v", err)
+	}
+	in, err := AsBlock(src)
+	if err != nil {
+		return err
+	}
+	*out = pe.EncryptBlockPhase2(*in, keys)
+	return nil
+}
@@ -0,0 +1,215 @@
+package eamsa512
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// MaxDecryptCacheTTL is the longest a decrypted record may stay cached
+const MaxDecryptCacheTTL = time.Hour
+
+// Defaults for the zero fields of a DecryptCachePolicy
+const (
+	DefaultDecryptCacheEntries    = 1024
+	DefaultDecryptCacheEntryBytes = 4096
+	DefaultDecryptCacheBytes      = 1 << 20
+)
+
+// DecryptCachePolicy enables the decrypt cache of one keyring version (see
+// Keyring.SetDecryptCache). Caching is meant for small records read over
+// and over, such as configuration blobs: the plaintext of each decrypted
+// envelope is kept, sealed under a key that exists only in this process,
+// and returned for the same envelope and aad without running the cipher.
+type DecryptCachePolicy struct {
+	// TTL is how long an entry is served after it was cached, however
+	// often it is hit (required, at most MaxDecryptCacheTTL)
+	TTL time.Duration
+
+	// MaxEntries caps the number of entries; the oldest are evicted first
+	// (0: DefaultDecryptCacheEntries)
+	MaxEntries int
+
+	// MaxEntryBytes is the largest plaintext cached; larger ones are
+	// decrypted every time (0: DefaultDecryptCacheEntryBytes)
+	MaxEntryBytes int
+
+	// MaxBytes caps the total plaintext cached (0: DefaultDecryptCacheBytes)
+	MaxBytes int
+}
+
+// validate checks p and fills in the defaults
+func (p *DecryptCachePolicy) validate() error {
+	if p.TTL <= 0 || p.TTL > MaxDecryptCacheTTL {
+		return fmt.Errorf("invalid decrypt cache TTL %v: must be between 0 and %v", p.TTL, MaxDecryptCacheTTL)
+	}
+	if p.MaxEntries < 0 || p.MaxEntryBytes < 0 || p.MaxBytes < 0 {
+		return fmt.Errorf("invalid decrypt cache policy: negative limit")
+	}
+
+	if p.MaxEntries == 0 {
+		p.MaxEntries = DefaultDecryptCacheEntries
+	}
+	if p.MaxEntryBytes == 0 {
+		p.MaxEntryBytes = DefaultDecryptCacheEntryBytes
+	}
+	if p.MaxBytes == 0 {
+		p.MaxBytes = DefaultDecryptCacheBytes
+	}
+	if p.MaxEntryBytes > p.MaxBytes {
+		return fmt.Errorf("invalid decrypt cache policy: MaxEntryBytes %d exceeds MaxBytes %d", p.MaxEntryBytes, p.MaxBytes)
+	}
+	return nil
+}
+
+// cacheEntry is one cached plaintext, sealed under the cache's key
+type cacheEntry struct {
+	digest  [32]byte
+	sealed  []byte
+	size    int
+	expires time.Time
+}
+
+// decryptCache holds the decrypted envelopes of one key version. Entries
+// are looked up by SHA3-256 over the aad and the envelope, so only the
+// exact bytes that were authenticated hit. Safe for concurrent use.
+type decryptCache struct {
+	policy DecryptCachePolicy
+	cipher *Cipher // Ephemeral key, never stored or exported; used without telemetry
+
+	mu        sync.RWMutex // Held for reading while the cipher is in use
+	order     *list.List   // Of *cacheEntry, oldest first
+	entries   map[[32]byte]*list.Element
+	bytes     int
+	destroyed bool
+}
+
+// newDecryptCache returns an empty cache under a fresh random key
+func newDecryptCache(policy DecryptCachePolicy) (*decryptCache, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate cache key: %v", err)
+	}
+	defer zeroize(key)
+
+	cipher, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptCache{
+		policy:  policy,
+		cipher:  cipher,
+		order:   list.New(),
+		entries: make(map[[32]byte]*list.Element),
+	}, nil
+}
+
+// cacheDigest identifies blob opened with aad
+func cacheDigest(blob, aad []byte) [32]byte {
+	h := sha3.New256()
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(aad)))
+	h.Write(n[:])
+	h.Write(aad)
+	h.Write(blob)
+
+	var digest [32]byte
+	h.Sum(digest[:0])
+	return digest
+}
+
+// get returns the plaintext cached for digest, if it has not expired
+func (c *decryptCache) get(digest [32]byte) ([]byte, bool) {
+	c.mu.RLock()
+	elem, ok := c.entries[digest]
+	if !ok {
+		c.mu.RUnlock()
+		return nil, false
+	}
+	entry := elem.Value.(*cacheEntry)
+	if time.Now().After(entry.expires) {
+		c.mu.RUnlock()
+		c.mu.Lock()
+		if elem, ok := c.entries[digest]; ok && elem.Value == entry {
+			c.remove(elem)
+		}
+		c.mu.Unlock()
+		return nil, false
+	}
+
+	plaintext, err := openWithKeys(context.Background(), append([]byte(nil), entry.sealed...), c.cipher.masterKey, c.cipher.keys, nil, nil, PaddingPKCS7)
+	c.mu.RUnlock()
+	if err != nil {
+		return nil, false
+	}
+	return plaintext, true
+}
+
+// put caches plaintext for digest if it is small enough, evicting expired
+// and then the oldest entries to stay within the limits
+func (c *decryptCache) put(digest [32]byte, plaintext []byte) {
+	if len(plaintext) > c.policy.MaxEntryBytes {
+		return
+	}
+
+	c.mu.RLock()
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+	sealed, err := sealWithKeys(context.Background(), buf, c.cipher.masterKey, c.cipher.keys, nil, nil, nil, PaddingPKCS7)
+	c.mu.RUnlock()
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.destroyed {
+		return
+	}
+
+	if elem, ok := c.entries[digest]; ok {
+		c.remove(elem)
+	}
+
+	now := time.Now()
+	for elem := c.order.Front(); elem != nil; {
+		next := elem.Next()
+		if now.After(elem.Value.(*cacheEntry).expires) {
+			c.remove(elem)
+		}
+		elem = next
+	}
+	for c.order.Len() >= c.policy.MaxEntries || c.bytes+len(plaintext) > c.policy.MaxBytes {
+		c.remove(c.order.Front())
+	}
+
+	entry := &cacheEntry{digest: digest, sealed: sealed, size: len(plaintext), expires: now.Add(c.policy.TTL)}
+	c.entries[digest] = c.order.PushBack(entry)
+	c.bytes += entry.size
+}
+
+// remove drops one entry
+// Caller must hold c.mu
+func (c *decryptCache) remove(elem *list.Element) {
+	entry := c.order.Remove(elem).(*cacheEntry)
+	delete(c.entries, entry.digest)
+	c.bytes -= entry.size
+}
+
+// destroy empties the cache and wipes its key
+func (c *decryptCache) destroy() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = make(map[[32]byte]*list.Element)
+	c.bytes = 0
+	c.destroyed = true
+	c.cipher.Destroy()
+}
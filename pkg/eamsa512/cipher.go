@@ -0,0 +1,72 @@
+package eamsa512
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Cipher holds the round-key schedule of one master key. EncryptData and
+// DecryptData run DeriveKeys (11 SHA3-512 hashes) on every call, which
+// dominates the cost of small messages; a Cipher derives the schedule
+// once. Its output is the EncryptData format, byte for byte. A Cipher is
+// safe for concurrent use.
+type Cipher struct {
+	masterKey []byte
+	keys      [][]byte
+}
+
+// NewCipher derives and caches the schedule of masterKey. The Cipher
+// keeps a copy of the key; Destroy wipes both.
+func NewCipher(masterKey []byte) (*Cipher, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	keys, err := DeriveKeys(masterKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{masterKey: append([]byte(nil), masterKey...), keys: keys}, nil
+}
+
+// Encrypt is EncryptData under the cached schedule
+func (c *Cipher) Encrypt(plaintext []byte, nonce []byte) ([]byte, error) {
+	return c.EncryptContext(context.Background(), plaintext, nonce)
+}
+
+// Decrypt is DecryptData under the cached schedule
+func (c *Cipher) Decrypt(encryptedData []byte) ([]byte, error) {
+	return c.DecryptContext(context.Background(), encryptedData)
+}
+
+// EncryptContext is Encrypt that gives up with ctx.Err() once ctx is done
+func (c *Cipher) EncryptContext(ctx context.Context, plaintext []byte, nonce []byte) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	start := time.Now()
+	out, err := sealWithKeys(ctx, buf, c.masterKey, c.keys, nonce, nil, nil, PaddingPKCS7)
+	observeEncrypt(len(plaintext), start, err)
+	return out, err
+}
+
+// DecryptContext is Decrypt that gives up with ctx.Err() once ctx is done
+func (c *Cipher) DecryptContext(ctx context.Context, encryptedData []byte) ([]byte, error) {
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	start := time.Now()
+	plaintext, err := openWithKeys(ctx, buf, c.masterKey, c.keys, nil, nil, PaddingPKCS7)
+	observeDecrypt(len(encryptedData), start, err)
+	return plaintext, err
+}
+
+// Destroy wipes the key and schedule. The Cipher must not be used
+// afterwards.
+func (c *Cipher) Destroy() {
+	zeroize(c.masterKey)
+	for _, k := range c.keys {
+		zeroize(k)
+	}
+}
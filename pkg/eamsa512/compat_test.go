@@ -0,0 +1,430 @@
+package eamsa512
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ============================================================================
+// EAMSA 512 - Format Compatibility Test Suite
+// Data written by every format version must keep decrypting
+//
+// testdata/compat holds a fixture for each format version, encrypted from
+// plaintext.txt under key.hex while that version was current.
+// TestFormatCompatibility decrypts all of them with the current code, so a
+// change that would strand existing data fails here.
+//
+// Fixtures are not regenerated to make a failing change pass. The only
+// exceptions are fixes to the cipher itself that no format version can
+// carry, each recorded here and in the commit that regenerates them:
+//
+//   - DecryptBlock did not invert EncryptBlock (lossy S-box, wrong inverse
+//     permutation), so most of the first fixtures never decrypted, even
+//     with the code that wrote them. All were rewritten with the fixed
+//     cipher; there was no readable data to stay compatible with.
+//   - HMAC-SHA3-512 used a 136-byte block instead of the 72 bytes of
+//     SHA3-512 (FIPS 202, RFC 2104), so no tag matched a standard HMAC and
+//     every subkey, SIV and tag changed with the fix. All fixtures were
+//     rewritten again. Data sealed between the two fixes does not decrypt
+//     with this code; decrypt it with that build and encrypt it again.
+//
+// The password, datakey and recipient v1 fixtures were once overwritten
+// by the first rewrite with output of the envelope v2 change: version 1
+// outer headers around version 2 envelopes, 64 bytes longer. That was a
+// format change, not a cipher fix, and hid that version 1 data no longer
+// decrypted. They were restored to version 1 envelopes, at their original
+// sizes, with the fixed cipher (the original bytes predate both fixes),
+// and the formats moved to version 2 with fixtures of their own.
+//
+// Anything else that changes the bytes of a format gets a new version
+// instead: add its generator to compatGenerators (keeping the old
+// fixtures, which must still decrypt) and run
+//
+//   go test -run TestFormatCompatibility -update-fixtures
+//
+// which writes fixtures only for versions that have none. The fixtures are
+// also the seed corpus of FuzzOpenFormats.
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+var updateFixtures = flag.Bool("update-fixtures", false, "write missing format compatibility fixtures")
+
+// compatDir holds the fixtures, key.hex and plaintext.txt
+const compatDir = "testdata/compat"
+
+// compatPlaintext is written to plaintext.txt when the corpus is created;
+// it spans several blocks and stream chunks
+const compatPlaintext = "EAMSA 512 format compatibility fixture. " +
+	"Every format version ever written must decrypt to exactly this text, " +
+	"block-aligned or not, in one piece or in chunks.\n"
+
+// compatAAD is the additional data of the aad fixtures
+var compatAAD = []byte("tenant=42;record=9001")
+
+// compatPassword is the password of the password fixtures; their KDF
+// cost is kept low so the suite stays fast
+var compatPassword = []byte("correct horse battery staple")
+
+// compatKDFParams is the Argon2id cost of the password fixtures
+var compatKDFParams = KDFParams{Memory: 64, Iterations: 1, Parallelism: 1}
+
+// compatIdentity is the X25519 private key the recipient fixtures are
+// addressed to
+const compatIdentity = "5ed863b48253cae8750a3561dd87c723953556da484a5f4cc07187e3ada1dc4a"
+
+// compatChunkSize is the chunk size of the stream and chunked fixtures
+const compatChunkSize = 64
+
+// compatOpeners decrypt each format, keyed by the fixture name prefix
+var compatOpeners = map[string]func(data, key []byte) ([]byte, error){
+	"bare": DecryptData,
+	"aad": func(data, key []byte) ([]byte, error) {
+		return DecryptDataWithAAD(data, key, compatAAD)
+	},
+	"stream": func(data, key []byte) ([]byte, error) {
+		r, err := NewDecryptingReader(bytes.NewReader(data), key)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(r)
+	},
+	"envelope": func(data, key []byte) ([]byte, error) {
+		return DecryptLegacy(data, key, nil)
+	},
+	"password": func(data, key []byte) ([]byte, error) {
+		return DecryptWithPassword(data, compatPassword)
+	},
+	"recipient": func(data, key []byte) ([]byte, error) {
+		identity, err := compatRecipientKey()
+		if err != nil {
+			return nil, err
+		}
+		return DecryptForRecipient(data, identity)
+	},
+	"keywrap": func(data, key []byte) ([]byte, error) {
+		return UnwrapKey(key, data)
+	},
+	"datakey": func(data, key []byte) ([]byte, error) {
+		return EnvelopeDecrypt(context.Background(), data, MasterKEK(key), nil)
+	},
+}
+
+// compatGenerators write the current version of each format, keyed by
+// fixture name (<format>-v<version>[-<variant>])
+var compatGenerators = map[string]func(plaintext, key []byte) ([]byte, error){
+	"bare-v1": func(plaintext, key []byte) ([]byte, error) {
+		return EncryptData(plaintext, key, nil)
+	},
+	"aad-v1": func(plaintext, key []byte) ([]byte, error) {
+		return EncryptDataWithAAD(plaintext, key, nil, compatAAD)
+	},
+	"stream-v1": func(plaintext, key []byte) ([]byte, error) {
+		var out bytes.Buffer
+		w, err := NewEncryptingWriterSize(&out, key, compatChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(plaintext); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	},
+	"stream-v2": func(plaintext, key []byte) ([]byte, error) {
+		var out bytes.Buffer
+		if _, err := EncryptReaderSize(&out, bytes.NewReader(plaintext), key, compatChunkSize); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	},
+	"envelope-v2-cbc": func(plaintext, key []byte) ([]byte, error) {
+		return Encrypt(plaintext, key, EnvelopeOptions{KeyVersion: 3})
+	},
+	"envelope-v2-chunked": func(plaintext, key []byte) ([]byte, error) {
+		return Encrypt(plaintext, key, EnvelopeOptions{
+			Mode: ModeChunked, KeyVersion: 3, ChunkSize: compatChunkSize,
+		})
+	},
+	"envelope-v2-siv": func(plaintext, key []byte) ([]byte, error) {
+		return Encrypt(plaintext, key, EnvelopeOptions{
+			Mode: ModeSIV, KeyVersion: 3, Nonce: make([]byte, NonceSize),
+		})
+	},
+	"password-v2": func(plaintext, key []byte) ([]byte, error) {
+		return EncryptWithPassword(plaintext, compatPassword, WithKDFParams(compatKDFParams))
+	},
+	"recipient-v2": func(plaintext, key []byte) ([]byte, error) {
+		identity, err := compatRecipientKey()
+		if err != nil {
+			return nil, err
+		}
+		return EncryptForRecipients(plaintext, []PublicKey{identity.PublicKey()})
+	},
+	"keywrap-v1": func(plaintext, key []byte) ([]byte, error) {
+		return WrapKey(key, plaintext)
+	},
+	"datakey-v2": func(plaintext, key []byte) ([]byte, error) {
+		return EnvelopeEncrypt(context.Background(), plaintext, MasterKEK(key), EnvelopeOptions{})
+	},
+}
+
+// compatRecipientKey returns the identity of the recipient fixtures
+func compatRecipientKey() (*PrivateKey, error) {
+	b, err := hex.DecodeString(compatIdentity)
+	if err != nil {
+		return nil, err
+	}
+	return NewPrivateKey(b)
+}
+
+// compatFormat returns the format of a fixture name
+func compatFormat(name string) string {
+	format, _, _ := strings.Cut(name, "-v")
+	return format
+}
+
+// compatFixturePath returns the fixture file of a fixture name
+func compatFixturePath(name string) string {
+	return filepath.Join(compatDir, name+".bin")
+}
+
+// loadCompatInputs reads the corpus key and plaintext
+func loadCompatInputs(tb testing.TB) ([]byte, []byte) {
+	tb.Helper()
+
+	keyHex, err := os.ReadFile(filepath.Join(compatDir, "key.hex"))
+	if err != nil {
+		tb.Fatalf("Failed to read corpus key: %v", err)
+	}
+	key, err := hex.DecodeString(strings.TrimSpace(string(keyHex)))
+	if err != nil || len(key) != KeySize {
+		tb.Fatalf("Invalid corpus key: %v", err)
+	}
+
+	plaintext, err := os.ReadFile(filepath.Join(compatDir, "plaintext.txt"))
+	if err != nil {
+		tb.Fatalf("Failed to read corpus plaintext: %v", err)
+	}
+
+	return key, plaintext
+}
+
+// compatFixtures returns the fixture names in the corpus, sorted
+func compatFixtures(tb testing.TB) []string {
+	tb.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(compatDir, "*.bin"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	names := make([]string, 0, len(paths))
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(filepath.Base(path), ".bin"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeCompatFixtures creates the corpus key and plaintext if missing and
+// writes a fixture for each generator that has none
+func writeCompatFixtures(t *testing.T) {
+	if err := os.MkdirAll(compatDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	keyPath := filepath.Join(compatDir, "key.hex")
+	if _, err := os.Stat(keyPath); os.IsNotExist(err) {
+		key := make([]byte, KeySize)
+		rand.Read(key)
+		if err := os.WriteFile(keyPath, []byte(hex.EncodeToString(key)+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plaintextPath := filepath.Join(compatDir, "plaintext.txt")
+	if _, err := os.Stat(plaintextPath); os.IsNotExist(err) {
+		if err := os.WriteFile(plaintextPath, []byte(compatPlaintext), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	key, plaintext := loadCompatInputs(t)
+	for name, generate := range compatGenerators {
+		path := compatFixturePath(name)
+		if _, err := os.Stat(path); err == nil {
+			continue // Never regenerate: the fixture records that version
+		}
+
+		data, err := generate(plaintext, key)
+		if err != nil {
+			t.Fatalf("Failed to generate %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		fmt.Printf("  wrote %s (%d bytes)\n", path, len(data))
+	}
+}
+
+// TestFormatCompatibility tests that every fixture still decrypts to the
+// corpus plaintext and that every current format version has a fixture
+func TestFormatCompatibility(t *testing.T) {
+	fmt.Println("Test: Format Compatibility")
+
+	if *updateFixtures {
+		writeCompatFixtures(t)
+	}
+
+	key, plaintext := loadCompatInputs(t)
+
+	for name := range compatGenerators {
+		if _, err := os.Stat(compatFixturePath(name)); err != nil {
+			t.Errorf("No fixture for current format %s: run with -update-fixtures", name)
+		}
+	}
+
+	fixtures := compatFixtures(t)
+	for _, name := range fixtures {
+		open, ok := compatOpeners[compatFormat(name)]
+		if !ok {
+			t.Errorf("Fixture %s: no opener for format %q", name, compatFormat(name))
+			continue
+		}
+
+		data, err := os.ReadFile(compatFixturePath(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		decrypted, err := open(data, key)
+		if err != nil {
+			t.Errorf("Fixture %s no longer decrypts: %v", name, err)
+			continue
+		}
+		if !bytes.Equal(decrypted, plaintext) {
+			t.Errorf("Fixture %s decrypts to different plaintext", name)
+		}
+	}
+
+	if !t.Failed() {
+		fmt.Printf("✓ %d format fixtures decrypt\n", len(fixtures))
+	}
+}
+
+// TestLegacyBodyFixtures tests that the version 1 password, datakey and
+// recipient fixtures wrap version 1 envelopes, so TestFormatCompatibility
+// covers their legacy path
+func TestLegacyBodyFixtures(t *testing.T) {
+	fmt.Println("Test: Version 1 Envelope Bodies")
+
+	bodies := map[string]func([]byte) ([]byte, error){
+		"password-v1": func(data []byte) ([]byte, error) {
+			return data[PasswordHeaderSize:], nil
+		},
+		"datakey-v1": func(data []byte) ([]byte, error) {
+			_, _, body, err := parseDataKeyEnvelope(data)
+			return body, err
+		},
+		"recipient-v1": func(data []byte) ([]byte, error) {
+			headerSize, err := parseRecipientHeader(data)
+			if err != nil {
+				return nil, err
+			}
+			return data[headerSize:], nil
+		},
+	}
+
+	for name, body := range bodies {
+		data, err := os.ReadFile(compatFixturePath(name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if data[4] != 1 {
+			t.Errorf("Fixture %s has version %d", name, data[4])
+			continue
+		}
+		envelope, err := body(data)
+		if err != nil {
+			t.Fatalf("Fixture %s: %v", name, err)
+		}
+		if e, err := ParseEnvelope(envelope); err != nil || e.Version != 1 {
+			t.Errorf("Fixture %s does not wrap a version 1 envelope: %v", name, err)
+		}
+	}
+
+	if !t.Failed() {
+		fmt.Println("✓ Version 1 fixtures wrap version 1 envelopes")
+	}
+}
+
+// FuzzOpenFormats feeds mutated fixtures to every format's decryption.
+// None may panic, and any input that decrypts must yield the corpus
+// plaintext (a mutation can only strip a wrapper, never forge content).
+func FuzzOpenFormats(f *testing.F) {
+	key, plaintext := loadCompatInputs(f)
+
+	for _, name := range compatFixtures(f) {
+		data, err := os.ReadFile(compatFixturePath(name))
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(data)
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for format, open := range compatOpeners {
+			decrypted, err := open(data, key)
+			if err == nil && !bytes.Equal(decrypted, plaintext) {
+				t.Errorf("%s: mutated input decrypted to new plaintext", format)
+			}
+		}
+	})
+}
+
+// ============================================================================
+// NOTES
+// ============================================================================
+
+/*
+
+1. CORPUS (testdata/compat)
+   - key.hex, plaintext.txt: shared by all fixtures
+   - <format>-v<version>[-<variant>].bin: one file per format version
+   - Formats: bare (EncryptData), aad (EncryptDataWithAAD),
+     stream (chunked stream; v2 from EncryptReader),
+     envelope (Encrypt; v2 adds the key commitment; siv with the
+     all-zero nonce),
+     password (EncryptWithPassword; key.hex unused, compatPassword instead),
+     datakey (EnvelopeEncrypt, data key wrapped with key.hex as MasterKEK),
+     recipient (EncryptForRecipients to compatIdentity); v1 of these
+     three wraps a version 1 envelope, v2 a version 2 one,
+     keywrap (WrapKey with key.hex as KEK; plaintext.txt as the key)
+   - Container fixtures (container, stretched) are kept with the server,
+     which writes them: cmd/eamsa512-server/testdata/compat
+
+2. ADDING A FORMAT VERSION
+   - Add "<format>-v<N>" to compatGenerators and remove the old entry's
+     generator (not its fixture)
+   - go test -run TestFormatCompatibility -update-fixtures
+   - Commit the new .bin file; never edit or delete an existing one
+
+3. FUZZING
+   - go test -run '^$' -fuzz FuzzOpenFormats -fuzztime 60s
+   - Crashers are saved under testdata/fuzz/FuzzOpenFormats; commit
+     them with the fix so they stay in the regression corpus
+
+*/
@@ -0,0 +1,211 @@
+package eamsa512
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+)
+
+// The Phase 3 block counter is an input to the CTR keystream and to every
+// block MAC, so (key, nonce, counter) must never repeat. In memory the
+// counters start at zero, and a restarted process with a static nonce
+// would reuse them. A CounterStore keeps a high-water mark per key and
+// nonce: before a cipher uses a counter it reserves a range above the
+// mark and persists the end of that range, and after a restart it resumes
+// from the mark. A crash loses at most the unused rest of a range; it
+// never reuses a counter. No clock is involved.
+
+// counterReserve is how many counter values one reservation covers; each
+// reservation costs a synced write
+const counterReserve = 4096
+
+// counterIDLabel separates counter identities from every derived key
+const counterIDLabel = "EAMSA512-COUNTER-ID"
+
+var (
+	// ErrCounterMarkerMissing is returned when no mark is stored for a key
+	// and nonce; set EAMSA512ConfigSHA3.CounterInit on their first use only
+	ErrCounterMarkerMissing = errors.New("counter marker missing")
+
+	// ErrCounterMarkerBehind is returned when the stored mark is lower than
+	// the last one written, e.g. after the file was restored from a backup
+	ErrCounterMarkerBehind = errors.New("counter marker behind")
+
+	// ErrCounterMarkerAhead is returned when the stored mark is higher than
+	// the last one written: another process is using the key and nonce
+	ErrCounterMarkerAhead = errors.New("counter marker ahead")
+)
+
+// CounterMarks are counter high-water marks: no value below them may be
+// used again under the same key and nonce
+type CounterMarks struct {
+	Encryption uint64 `json:"encryption"`
+	Auth       uint64 `json:"auth"`
+}
+
+// behind reports whether any mark of m is below o
+func (m CounterMarks) behind(o CounterMarks) bool {
+	return m.Encryption < o.Encryption || m.Auth < o.Auth
+}
+
+// CounterStore persists counter marks, keyed by CounterID
+type CounterStore interface {
+	// LoadCounters returns the marks for id, or ErrCounterMarkerMissing
+	LoadCounters(id string) (CounterMarks, error)
+
+	// CreateCounters stores zero marks for id unless it has marks already
+	CreateCounters(id string) error
+
+	// AdvanceCounters replaces the marks for id with next, which must not be
+	// lower, if they are still prev. It returns ErrCounterMarkerMissing,
+	// ErrCounterMarkerBehind or ErrCounterMarkerAhead otherwise, and must
+	// not return before next is durable.
+	AdvanceCounters(id string, prev, next CounterMarks) error
+}
+
+// CounterID names the counters of a key and nonce without revealing the
+// key: hex(SHA3-256("EAMSA512-COUNTER-ID" || masterKey || nonce))[:32]
+func CounterID(masterKey [32]byte, nonce [16]byte) string {
+	hash := sha3.New256()
+	hash.Write([]byte(counterIDLabel))
+	hash.Write(masterKey[:])
+	hash.Write(nonce[:])
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// compareCounters checks stored marks against the marks last written
+func compareCounters(stored, prev CounterMarks) error {
+	switch {
+	case stored.behind(prev):
+		return fmt.Errorf("%w: stored %d/%d, expected %d/%d", ErrCounterMarkerBehind,
+			stored.Encryption, stored.Auth, prev.Encryption, prev.Auth)
+	case stored != prev:
+		return fmt.Errorf("%w: stored %d/%d, expected %d/%d", ErrCounterMarkerAhead,
+			stored.Encryption, stored.Auth, prev.Encryption, prev.Auth)
+	}
+	return nil
+}
+
+// FileCounterStore keeps counter marks in a JSON file, rewritten
+// atomically on every change. The process holds an exclusive lock on
+// <path>.lock until Close, so two processes cannot share the file.
+type FileCounterStore struct {
+	path string
+	lock *filelock.Lock
+
+	mu    sync.Mutex
+	marks map[string]CounterMarks
+}
+
+// counterFile is the on-disk form of a FileCounterStore
+type counterFile struct {
+	Version  int                     `json:"version"`
+	Counters map[string]CounterMarks `json:"counters"`
+}
+
+// OpenFileCounterStore locks and reads the counter file at path. A
+// missing file holds no marks; it is created by the first write.
+func OpenFileCounterStore(path string) (*FileCounterStore, error) {
+	lock, err := filelock.Acquire(path+".lock", filelock.Exclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &FileCounterStore{path: path, lock: lock, marks: make(map[string]CounterMarks)}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		lock.Release()
+		return nil, fmt.Errorf("failed to read counter file: %v", err)
+	}
+	if err == nil {
+		var file counterFile
+		if err := json.Unmarshal(data, &file); err != nil {
+			lock.Release()
+			return nil, fmt.Errorf("failed to parse counter file %s: %v", path, err)
+		}
+		if file.Version != 1 {
+			lock.Release()
+			return nil, fmt.Errorf("unsupported counter file version %d", file.Version)
+		}
+		for id, marks := range file.Counters {
+			store.marks[id] = marks
+		}
+	}
+
+	return store, nil
+}
+
+// LoadCounters returns the marks for id
+func (s *FileCounterStore) LoadCounters(id string) (CounterMarks, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marks, ok := s.marks[id]
+	if !ok {
+		return CounterMarks{}, ErrCounterMarkerMissing
+	}
+	return marks, nil
+}
+
+// CreateCounters stores zero marks for id unless it has marks already
+func (s *FileCounterStore) CreateCounters(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.marks[id]; ok {
+		return nil
+	}
+	return s.write(id, CounterMarks{})
+}
+
+// AdvanceCounters replaces the marks for id with next if they are still prev
+func (s *FileCounterStore) AdvanceCounters(id string, prev, next CounterMarks) error {
+	if next.behind(prev) {
+		return fmt.Errorf("counter marks cannot move backwards")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.marks[id]
+	if !ok {
+		return ErrCounterMarkerMissing
+	}
+	if err := compareCounters(stored, prev); err != nil {
+		return err
+	}
+	return s.write(id, next)
+}
+
+// write persists the marks with id set to marks; s.mu must be held
+func (s *FileCounterStore) write(id string, marks CounterMarks) error {
+	file := counterFile{Version: 1, Counters: make(map[string]CounterMarks, len(s.marks)+1)}
+	for k, v := range s.marks {
+		file.Counters[k] = v
+	}
+	file.Counters[id] = marks
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeSynced(s.path, data); err != nil {
+		return fmt.Errorf("failed to write counter file: %v", err)
+	}
+
+	s.marks[id] = marks
+	return nil
+}
+
+// Close releases the lock on the counter file
+func (s *FileCounterStore) Close() error {
+	return s.lock.Release()
+}
@@ -0,0 +1,84 @@
+package eamsa512
+
+import (
+	"encoding/binary"
+	"runtime"
+	"sync"
+)
+
+// ctrBatchBlocks is the smallest run of blocks handed to one goroutine;
+// shorter inputs are processed on the calling goroutine
+const ctrBatchBlocks = 64
+
+// CTRCounterBlock builds the Phase 2 input for block counter:
+//
+//	nonce (16) || zero (40) || counter (8, big-endian)
+//
+// Counter blocks are distinct for every (nonce, counter) pair, so the
+// keystream never repeats as long as a nonce is not reused with a key.
+func CTRCounterBlock(nonce [16]byte, counter uint64) [64]byte {
+	var block [64]byte
+	copy(block[0:16], nonce[:])
+	binary.BigEndian.PutUint64(block[56:64], counter)
+	return block
+}
+
+// XORKeyStreamCTR XORs src with the CTR keystream starting at block
+// counter and writes the result to dst. Encryption and decryption are the
+// same operation; there is no padding, and a final partial block uses only
+// as much keystream as it needs. dst and src must overlap entirely or not
+// at all. Long inputs are split across GOMAXPROCS goroutines, since every
+// block is independent.
+func (pe *Phase2Encryptor) XORKeyStreamCTR(dst, src []byte, keys [11][16]byte, nonce [16]byte, counter uint64) {
+	if len(dst) < len(src) {
+		panic("eamsa512: output smaller than input")
+	}
+
+	blocks := (len(src) + 63) / 64
+	if uint64(blocks) > ^uint64(0)-counter {
+		panic("eamsa512: CTR counter overflow")
+	}
+
+	schedule := pe.scheduleFor(keys)
+
+	workers := runtime.GOMAXPROCS(0)
+	if maxWorkers := blocks / ctrBatchBlocks; workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if workers <= 1 {
+		pe.xorCTRBlocks(dst, src, schedule, nonce, counter, 0, blocks)
+		return
+	}
+
+	perWorker := (blocks + workers - 1) / workers
+	var wg sync.WaitGroup
+	for first := 0; first < blocks; first += perWorker {
+		last := first + perWorker
+		if last > blocks {
+			last = blocks
+		}
+
+		wg.Add(1)
+		go func(first, last int) {
+			defer wg.Done()
+			pe.xorCTRBlocks(dst, src, schedule, nonce, counter, first, last)
+		}(first, last)
+	}
+	wg.Wait()
+}
+
+// xorCTRBlocks processes blocks [first, last) of src
+func (pe *Phase2Encryptor) xorCTRBlocks(dst, src []byte, schedule *MSAKeySchedule, nonce [16]byte, counter uint64, first, last int) {
+	for i := first; i < last; i++ {
+		keystream := pe.encryptWithSchedule(CTRCounterBlock(nonce, counter+uint64(i)), schedule)
+
+		start := i * 64
+		end := start + 64
+		if end > len(src) {
+			end = len(src)
+		}
+		for j := start; j < end; j++ {
+			dst[j] = src[j] ^ keystream[j-start]
+		}
+	}
+}
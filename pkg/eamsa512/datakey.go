@@ -0,0 +1,267 @@
+package eamsa512
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+)
+
+// Data key envelope format (version 2):
+//
+//	header: magic "EAMK" (4) | version (1) |
+//	        wrapped key length (2, big-endian) | wrapped data key
+//	body:   envelope (see envelope.go) under the data key
+//
+// Every message is sealed under a fresh random data key (DEK), and only
+// the DEK is encrypted under the key-encryption key (KEK). Rotating the
+// KEK therefore means rewrapping a few dozen bytes per message
+// (RewrapDataKey), not re-encrypting the data. The magic and version,
+// followed by the caller's additional data if any, are the additional
+// data of the body; the wrapped key is not, so it can be replaced. A
+// wrapped key that unwraps to a different DEK fails the body tag.
+//
+// Version 1 is the same with a version 1 envelope, which has no key
+// commitment, as the body, and (from MasterKEK and Keyring) as the
+// wrapped key. EnvelopeDecrypt opens its body with DecryptLegacy and
+// unwraps its key with UnwrapLegacyDataKey if the KEK is a LegacyKEK. The
+// version byte is in the body's additional data, so a version 2 message
+// cannot be passed off as one.
+const (
+	// DataKeyVersion is the data key envelope version written by
+	// EnvelopeEncrypt
+	DataKeyVersion = 2
+
+	// MaxWrappedKeySize is the longest wrapped data key the header holds
+	MaxWrappedKeySize = 1<<16 - 1
+
+	dataKeyMagic = "EAMK"
+
+	// dataKeyPrefixSize is the length of magic, version and wrapped key
+	// length
+	dataKeyPrefixSize = 7
+
+	// dataKeyLabel is the additional data of every wrapped data key, so
+	// the KEK's other ciphertexts cannot pass for one
+	dataKeyLabel = "EAMSA512-DATA-KEY"
+)
+
+// KEK wraps and unwraps data keys. MasterKEK and Keyring wrap in process;
+// implement KEK over an HSM or KMS to keep the master key out of it. aad
+// must be authenticated with the wrapped key.
+type KEK interface {
+	WrapDataKey(ctx context.Context, dek, aad []byte) ([]byte, error)
+	UnwrapDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error)
+}
+
+// LegacyKEK is a KEK that can also unwrap the data keys of version 1
+// data key envelopes, which it wrapped as version 1 envelopes
+type LegacyKEK interface {
+	UnwrapLegacyDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error)
+}
+
+// KEKFuncs adapts a pair of functions (e.g. around a KMS client's Encrypt
+// and Decrypt) to KEK
+type KEKFuncs struct {
+	Wrap   func(ctx context.Context, dek, aad []byte) ([]byte, error)
+	Unwrap func(ctx context.Context, wrapped, aad []byte) ([]byte, error)
+}
+
+// WrapDataKey calls f.Wrap
+func (f KEKFuncs) WrapDataKey(ctx context.Context, dek, aad []byte) ([]byte, error) {
+	return f.Wrap(ctx, dek, aad)
+}
+
+// UnwrapDataKey calls f.Unwrap
+func (f KEKFuncs) UnwrapDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	return f.Unwrap(ctx, wrapped, aad)
+}
+
+// MasterKEK wraps data keys under one master key as envelopes (Encrypt)
+type MasterKEK []byte
+
+// WrapDataKey encrypts dek under the master key
+func (k MasterKEK) WrapDataKey(ctx context.Context, dek, aad []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return Encrypt(dek, k, EnvelopeOptions{AAD: aad})
+}
+
+// UnwrapDataKey decrypts a key wrapped by WrapDataKey
+func (k MasterKEK) UnwrapDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return DecryptWithAAD(wrapped, k, aad)
+}
+
+// UnwrapLegacyDataKey is UnwrapDataKey that also opens version 1
+// envelopes (see DecryptLegacy)
+func (k MasterKEK) UnwrapLegacyDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return DecryptLegacy(wrapped, k, aad)
+}
+
+// IsDataKeyEnvelope reports whether data starts with the data key
+// envelope magic
+func IsDataKeyEnvelope(data []byte) bool {
+	return len(data) >= len(dataKeyMagic) && string(data[:len(dataKeyMagic)]) == dataKeyMagic
+}
+
+// EnvelopeEncrypt encrypts plaintext under a new random data key, wraps
+// the data key with kek and returns both. opts selects the mode, chunk
+// size and additional data of the body; KeyVersion is ignored (a KEK that
+// versions its keys records the version in the wrapped key).
+func EnvelopeEncrypt(ctx context.Context, plaintext []byte, kek KEK, opts EnvelopeOptions) ([]byte, error) {
+	dek := make([]byte, KeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %v", err)
+	}
+	defer zeroize(dek)
+
+	wrapped, err := kek.WrapDataKey(ctx, dek, []byte(dataKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %v", err)
+	}
+	if len(wrapped) == 0 || len(wrapped) > MaxWrappedKeySize {
+		return nil, fmt.Errorf("invalid wrapped data key size %d", len(wrapped))
+	}
+
+	bodyOpts := opts
+	bodyOpts.KeyVersion = 0
+	bodyOpts.AAD = dataKeyBodyAAD(DataKeyVersion, opts.AAD)
+
+	body, err := EncryptContext(ctx, plaintext, dek, bodyOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, dataKeyPrefixSize+len(wrapped)+len(body))
+	out = appendDataKeyHeader(out, DataKeyVersion, wrapped)
+	return append(out, body...), nil
+}
+
+// EnvelopeDecrypt unwraps the data key of an EnvelopeEncrypt output, of
+// either version, with kek and decrypts the body; aad must match the
+// additional data it was sealed with
+func EnvelopeDecrypt(ctx context.Context, data []byte, kek KEK, aad []byte) ([]byte, error) {
+	version, wrapped, body, err := parseDataKeyEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := unwrapDataKey(ctx, kek, version, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(dek)
+
+	if version == 1 {
+		e, err := ParseEnvelope(body)
+		if err != nil {
+			return nil, err
+		}
+		return e.OpenLegacy(ctx, dek, dataKeyBodyAAD(version, aad))
+	}
+	return DecryptWithAADContext(ctx, body, dek, dataKeyBodyAAD(version, aad))
+}
+
+// RewrapDataKey unwraps the data key of an EnvelopeEncrypt output with
+// from and wraps it again with to, leaving the body untouched. This is how
+// data moves to a new KEK after rotation; the old KEK can be retired once
+// every message has been rewrapped. The version is kept, since the body
+// authenticates it: a version 1 message stays version 1, with a key
+// wrapped by to.
+func RewrapDataKey(ctx context.Context, data []byte, from, to KEK) ([]byte, error) {
+	version, wrapped, body, err := parseDataKeyEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dek, err := unwrapDataKey(ctx, from, version, wrapped)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(dek)
+
+	rewrapped, err := to.WrapDataKey(ctx, dek, []byte(dataKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %v", err)
+	}
+	if len(rewrapped) == 0 || len(rewrapped) > MaxWrappedKeySize {
+		return nil, fmt.Errorf("invalid wrapped data key size %d", len(rewrapped))
+	}
+
+	out := make([]byte, 0, dataKeyPrefixSize+len(rewrapped)+len(body))
+	out = appendDataKeyHeader(out, version, rewrapped)
+	return append(out, body...), nil
+}
+
+// unwrapDataKey unwraps the data key of a data key envelope of version
+// with kek, with UnwrapLegacyDataKey for version 1 if kek has it
+func unwrapDataKey(ctx context.Context, kek KEK, version byte, wrapped []byte) ([]byte, error) {
+	unwrap := kek.UnwrapDataKey
+	if legacy, ok := kek.(LegacyKEK); ok && version == 1 {
+		unwrap = legacy.UnwrapLegacyDataKey
+	}
+
+	dek, err := unwrap(ctx, wrapped, []byte(dataKeyLabel))
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %v", err)
+	}
+	if len(dek) != KeySize {
+		zeroize(dek)
+		return nil, fmt.Errorf("invalid data key size: expected %d, got %d", KeySize, len(dek))
+	}
+	return dek, nil
+}
+
+// WrappedDataKey returns the wrapped data key of an EnvelopeEncrypt
+// output, e.g. to read the KEK version a Keyring recorded in it. The
+// result aliases data.
+func WrappedDataKey(data []byte) ([]byte, error) {
+	_, wrapped, _, err := parseDataKeyEnvelope(data)
+	return wrapped, err
+}
+
+// appendDataKeyHeader appends magic, version and the wrapped key to out
+func appendDataKeyHeader(out []byte, version byte, wrapped []byte) []byte {
+	out = append(out, dataKeyMagic...)
+	out = append(out, version)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(wrapped)))
+	return append(out, wrapped...)
+}
+
+// parseDataKeyEnvelope splits a data key envelope into its version, the
+// wrapped key and the body, the last two aliasing data
+func parseDataKeyEnvelope(data []byte) (byte, []byte, []byte, error) {
+	if !IsDataKeyEnvelope(data) {
+		return 0, nil, nil, fmt.Errorf("not an EAMSA 512 data key envelope")
+	}
+	if len(data) < dataKeyPrefixSize {
+		return 0, nil, nil, fmt.Errorf("data key envelope truncated: %d bytes", len(data))
+	}
+	if data[4] != 1 && data[4] != DataKeyVersion {
+		return 0, nil, nil, fmt.Errorf("unsupported data key envelope version %d", data[4])
+	}
+
+	wrappedLength := int(binary.BigEndian.Uint16(data[5:7]))
+	if wrappedLength == 0 || len(data) < dataKeyPrefixSize+wrappedLength {
+		return 0, nil, nil, fmt.Errorf("data key envelope truncated: %d bytes", len(data))
+	}
+
+	end := dataKeyPrefixSize + wrappedLength
+	return data[4], data[dataKeyPrefixSize:end], data[end:], nil
+}
+
+// dataKeyBodyAAD returns the additional data of a body: magic, version,
+// then the caller's
+func dataKeyBodyAAD(version byte, aad []byte) []byte {
+	out := make([]byte, 0, len(dataKeyMagic)+1+len(aad))
+	out = append(out, dataKeyMagic...)
+	out = append(out, version)
+	return append(out, aad...)
+}
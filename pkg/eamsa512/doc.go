@@ -0,0 +1,124 @@
+// Package eamsa512 is the importable EAMSA 512 core: the 512-bit block
+// cipher, its SHA3-512 key schedule, HMAC-SHA3-512 and the authenticated
+// EncryptData format
+//
+//	ciphertext (CBC, PKCS#7 padded) || nonce (16) || tag (64)
+//
+// where the tag is HMAC-SHA3-512 over nonce || ciphertext under the last
+// derived round key. Padding is 1 to 64 bytes, so a block-aligned
+// plaintext gets a whole padding block. Decryption verifies the tag before
+// decrypting, checks padding in constant time and reports every failure
+// as ErrDecryption. Output is byte-compatible with the server and CLI,
+// which build containers, streaming and key management on this package.
+// EncryptDataWithPadding selects ISO/IEC 7816-4 or ANSI X9.23 padding
+// instead of PKCS#7 (see Padding); containers record the choice as their
+// cipher suite.
+//
+// Typical use:
+//
+//	sealed, err := eamsa512.EncryptData(plaintext, key, nil)
+//	...
+//	plaintext, err := eamsa512.DecryptData(sealed, key)
+//
+// NewCipher derives the key schedule once for callers that encrypt many
+// messages under one key; its Encrypt and Decrypt match EncryptData and
+// DecryptData (see cipher.go).
+//
+// Encrypt and Decrypt wrap the same construction in a versioned envelope
+// whose authenticated header records the format version, mode, key
+// version and nonce (see envelope.go), so the format can change and the
+// decrypting side can tell which key to use. The header also commits to
+// the key, so an envelope decrypts under one key only. ParseEnvelope reads the
+// header without decrypting. Envelopes in ModeSIV are nonce-misuse
+// resistant: their IV is synthesized from the key, additional data and
+// plaintext (see siv.go).
+//
+// EncryptWithPassword and DecryptWithPassword derive the key from a
+// password with Argon2id; the salt and cost parameters are stored in an
+// authenticated header in front of the envelope (see password.go).
+//
+// EncryptForRecipients encrypts to X25519 public keys: a random session
+// key is wrapped for each recipient through key agreement and the SP
+// 800-56C one-step KDF, and DecryptForRecipient unwraps it with one
+// recipient's PrivateKey (see recipient.go).
+//
+// WrapKey and UnwrapKey wrap key material for export in a compact,
+// integrity-protected SIV format (see keywrap.go).
+//
+// LowEntropyKey flags master keys that look typed rather than generated
+// (ShannonEntropy), and StretchKey runs such a key through Argon2id.
+//
+// A NonceManager issues nonces for callers that choose their own, with a
+// persisted high-water mark so a restart never repeats one (see nonce.go).
+// NewNonceManagerWithRandom draws the prefix from a Random, which health
+// checks a RandomSource such as an HSM and, if its policy allows, falls
+// back to crypto/rand (see random.go). SetNonceSource does the same for
+// every nonce and IV the package generates.
+//
+// An EntropySource (SystemEntropy, ChaosEntropy, DeviceEntropy for TRNG
+// devices) is injected into key and nonce generation: NewKey,
+// NewNonceFrom, KeyringPolicy.Entropy and SetNonceSource (see
+// entropy.go). ChaosEntropy and DeviceEntropy run the SP 800-90B
+// Repetition Count and Adaptive Proportion Tests over their raw samples
+// and stop serving output when one fails; a Random with Fallback around
+// them switches to crypto/rand and reports the failure (see health.go).
+//
+// Negotiate and Hello.Accept let a client and server that share a key
+// agree on the strongest common Suite in an authenticated exchange, so a
+// downgrade is detected (see negotiate.go).
+//
+// DeriveFileKey derives a per-file working key from the master key and a
+// file's nonce, so that each file is sealed under a key of its own.
+//
+// A Keyring bundles versioned keys with Encrypt and Decrypt for services
+// that would otherwise wire key selection by hand:
+//
+//	kr, err := eamsa512.NewKeyring(eamsa512.KeyringPolicy{RotateAfter: 90 * 24 * time.Hour, Retain: 3})
+//	blob, err := kr.Encrypt(ctx, plaintext, aad)
+//	plaintext, err := kr.Decrypt(ctx, blob, aad)
+//
+// It rotates per its policy, decrypts with the version recorded in each
+// envelope and counts encryptions and decryptions per version (Stats).
+// SetDecryptCache opts a version into an in-memory cache of decrypted
+// records with a strict TTL and size caps (see cache.go).
+//
+// EnvelopeEncrypt and EnvelopeDecrypt separate data keys from key
+// encryption keys: each message gets a random data key, stored wrapped by
+// a KEK (MasterKEK, a Keyring, or an HSM or KMS), so rotating the KEK
+// only rewraps data keys (RewrapDataKey; see datakey.go).
+//
+// For files of any size, NewEncryptingWriter and NewDecryptingReader
+// encrypt a stream in independently authenticated chunks (see stream.go
+// for the format), so memory use is bounded by the chunk size.
+// EncryptReader does the same for an io.Reader of unknown length in one
+// call, ending the stream with a footer of the plaintext length and
+// digest.
+//
+// The main calls have Context variants (EncryptDataContext,
+// EncryptContext, NewDecryptingReaderContext and so on) that return
+// ctx.Err() once the context is done, checking between runs of blocks or
+// between chunks.
+//
+// ModeSIV envelopes, WrapKey and CBC decryption split long inputs into
+// lanes processed by up to Workers() goroutines (see parallel.go);
+// SetWorkers changes the count.
+//
+// SetTelemetry reports every encryption and decryption to a
+// telemetry.Telemetry, such as a telemetry.NewPrometheus collector served
+// on the application's own /metrics endpoint.
+//
+// The CLI's three-phase block cipher lives here too:
+// NewEAMSA512CipherSHA3 takes its keys from pkg/kdf (Phase 1), runs the
+// MSA, S-box and P-layer rounds of Phase2Encryptor (Phase 2, see
+// phase2.go) and authenticates each block with HMAC-SHA3-512 (Phase 3,
+// see phase3.go). Phase2Encryptor.XORKeyStreamCTR and MSAKeyStream are
+// its counter-mode and seekable keystreams (see ctr.go and
+// msakeystream.go), and blockslices.go has byte-slice variants of its
+// fixed-size APIs. A CounterStore persists the block counters so a
+// restart never reuses one (see counters.go). ExportKeyingMaterial
+// derives labelled keys bound to its master key and nonce for protocols
+// layered on it.
+//
+// The exported identifiers of this package are its stable API; the rest
+// of the repository (package main under example/ and cmd/) is not.
+package eamsa512
@@ -0,0 +1,438 @@
+package eamsa512
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// EntropySource supplies the randomness of new keys and nonces. Callers
+// inject one into NewKey, NewNonceFrom, KeyringPolicy.Entropy and
+// SetNonceSource instead of reading crypto/rand themselves.
+//
+// SystemEntropy, ChaosEntropy and DeviceEntropy also have a Name, so a
+// Random can wrap them to add stuck-output detection and fallback.
+type EntropySource interface {
+	io.Reader
+
+	// HealthCheck fails if the source should not be used, e.g. a device
+	// returning constant output. Run it before first use.
+	HealthCheck() error
+
+	// Reseed mixes seed into the source's state. Sources without state of
+	// their own (crypto/rand, hardware devices) ignore it.
+	Reseed(seed []byte) error
+}
+
+// NewKey returns KeySize bytes of key material from source (nil:
+// crypto/rand)
+func NewKey(source EntropySource) ([]byte, error) {
+	if source == nil {
+		source = SystemEntropy{}
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(source, key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+	return key, nil
+}
+
+// NewNonceFrom returns a 16-byte nonce from source (nil: NewNonce, which
+// uses the source set with SetNonceSource)
+func NewNonceFrom(source EntropySource) ([]byte, error) {
+	if source == nil {
+		return NewNonce()
+	}
+
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(source, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return nonce, nil
+}
+
+// startupTest runs the SP 800-90B 4.3 start-up tests: the continuous
+// health tests over healthStartupSamples bytes read from r, through
+// monitor after a Reset
+func startupTest(r io.Reader, monitor *HealthMonitor) error {
+	sample := make([]byte, healthStartupSamples)
+	defer zeroize(sample)
+
+	if _, err := io.ReadFull(r, sample); err != nil {
+		return fmt.Errorf("read failed: %v", err)
+	}
+	monitor.Reset()
+	return monitor.Test(sample)
+}
+
+// newDefaultHealthMonitor returns a monitor with DefaultMinEntropy cutoffs
+func newDefaultHealthMonitor() *HealthMonitor {
+	m, _ := NewHealthMonitor(DefaultMinEntropy)
+	return m
+}
+
+// ============================================================================
+// crypto/rand
+// ============================================================================
+
+// SystemEntropy is crypto/rand as an EntropySource
+type SystemEntropy struct{}
+
+// Read fills p from crypto/rand
+func (SystemEntropy) Read(p []byte) (int, error) {
+	return rand.Read(p)
+}
+
+// Name returns SystemRandom
+func (SystemEntropy) Name() string {
+	return SystemRandom
+}
+
+// HealthCheck runs the start-up tests on crypto/rand output
+func (s SystemEntropy) HealthCheck() error {
+	return startupTest(s, newDefaultHealthMonitor())
+}
+
+// Reseed does nothing: the kernel seeds crypto/rand
+func (SystemEntropy) Reseed(seed []byte) error {
+	return nil
+}
+
+// ============================================================================
+// Chaos generator
+// ============================================================================
+
+// Lorenz system parameters and integration step of ChaosEntropy
+const (
+	chaosSigma = 10.0
+	chaosRho   = 28.0
+	chaosBeta  = 8.0 / 3.0
+	chaosDT    = 0.01
+
+	// RK4 steps between output blocks
+	chaosStepsPerBlock = 8
+
+	// Minimum seed for NewChaosEntropy and Reseed
+	chaosMinSeed = 32
+)
+
+const chaosLabel = "EAMSA512-CHAOS-ENTROPY"
+
+// ChaosEntropy draws bytes from a Lorenz system trajectory whitened with
+// SHA3-512. The trajectory is deterministic, so the output is only as
+// unpredictable as the seed and later Reseed calls: seed it from
+// crypto/rand or a hardware source. Each output block hashes a secret
+// chaining key, a counter and the current state; the key is replaced
+// after every Read, so a captured state does not reveal earlier output.
+// Safe for concurrent use.
+//
+// The raw samples, one byte of the trajectory per integration step, pass
+// through the SP 800-90B continuous health tests before whitening (which
+// would hide a failure). Once a test fails, Read returns the
+// *HealthTestError until HealthCheck passes the start-up tests again;
+// wrap the generator in a Random with Fallback to switch to crypto/rand
+// meanwhile and audit the failure.
+type ChaosEntropy struct {
+	mu      sync.Mutex
+	x, y, z float64
+	key     [64]byte
+	counter uint64
+	monitor *HealthMonitor
+	failure error // Health test failure; cleared by HealthCheck
+}
+
+// NewChaosEntropy returns a generator seeded with seed (at least 32
+// bytes; nil draws 64 bytes from crypto/rand)
+func NewChaosEntropy(seed []byte) (*ChaosEntropy, error) {
+	if seed == nil {
+		seed = make([]byte, 64)
+		defer zeroize(seed)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to seed chaos generator: %v", err)
+		}
+	}
+
+	c := &ChaosEntropy{monitor: newDefaultHealthMonitor()}
+	if err := c.Reseed(seed); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Name identifies the generator as a random source
+func (c *ChaosEntropy) Name() string {
+	return "chaos"
+}
+
+// Read fills p with whitened trajectory output. It fails, leaving p
+// zeroed, while a health test has failed.
+func (c *ChaosEntropy) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.failure != nil {
+		return 0, c.failure
+	}
+
+	var block [64]byte
+	for off := 0; off < len(p); off += len(block) {
+		if err := c.nextBlockLocked(&block); err != nil {
+			c.failure = err
+			zeroize(p)
+			return 0, err
+		}
+		copy(p[off:], block[:])
+	}
+	zeroize(block[:])
+
+	// Forward secrecy: the key that produced p is gone
+	h := sha3.New512()
+	h.Write([]byte(chaosLabel + "-REKEY"))
+	h.Write(c.key[:])
+	h.Sum(c.key[:0])
+
+	return len(p), nil
+}
+
+// HealthCheck fails if the trajectory has left the attractor, collapsed
+// onto a fixed point or become non-finite, or if its raw samples fail the
+// start-up tests. Passing clears an earlier health test failure.
+func (c *ChaosEntropy) HealthCheck() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	moved := 0.0
+	for i := 0; i < chaosStepsPerBlock; i++ {
+		x, y, z := c.x, c.y, c.z
+		c.stepLocked()
+		moved += math.Abs(c.x-x) + math.Abs(c.y-y) + math.Abs(c.z-z)
+	}
+
+	switch {
+	case math.IsNaN(moved) || math.IsInf(moved, 0):
+		return errors.New("chaos trajectory is not finite")
+	case math.Abs(c.x) > 100 || math.Abs(c.y) > 100 || c.z < -1 || c.z > 100:
+		return fmt.Errorf("chaos trajectory left the attractor (%.3g, %.3g, %.3g)", c.x, c.y, c.z)
+	case moved < 1e-9:
+		return errors.New("chaos trajectory collapsed to a fixed point")
+	}
+
+	if err := startupTest(chaosRawReader{c}, c.monitor); err != nil {
+		return err
+	}
+	c.failure = nil
+	return nil
+}
+
+// chaosRawReader reads raw samples, one per integration step, for the
+// start-up tests. Caller must hold c.mu.
+type chaosRawReader struct {
+	c *ChaosEntropy
+}
+
+func (r chaosRawReader) Read(p []byte) (int, error) {
+	for i := range p {
+		r.c.stepLocked()
+		p[i] = r.c.rawSampleLocked()
+	}
+	return len(p), nil
+}
+
+// Reseed mixes seed (at least 32 bytes) into the chaining key and
+// restarts the trajectory from a point derived from both
+func (c *ChaosEntropy) Reseed(seed []byte) error {
+	if len(seed) < chaosMinSeed {
+		return fmt.Errorf("chaos seed too short: need at least %d bytes, got %d", chaosMinSeed, len(seed))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := sha3.New512()
+	h.Write([]byte(chaosLabel + "-SEED"))
+	h.Write(c.key[:])
+	h.Write(seed)
+	h.Sum(c.key[:0])
+
+	// Initial conditions in the attractor's basin: x, y in [-10, 10),
+	// z in [10, 40)
+	var start [24]byte
+	sha3.ShakeSum256(start[:], c.key[:])
+	unit := func(b []byte) float64 {
+		return float64(binary.BigEndian.Uint64(b)>>11) / (1 << 53)
+	}
+	c.x = unit(start[0:8])*20 - 10
+	c.y = unit(start[8:16])*20 - 10
+	c.z = unit(start[16:24])*30 + 10
+	zeroize(start[:])
+
+	// Leave the transient before any output
+	for i := 0; i < 100; i++ {
+		c.stepLocked()
+	}
+	return nil
+}
+
+// nextBlockLocked advances the trajectory, health tests its raw samples
+// and hashes one output block
+func (c *ChaosEntropy) nextBlockLocked(block *[64]byte) error {
+	var raw [chaosStepsPerBlock]byte
+	for i := range raw {
+		c.stepLocked()
+		raw[i] = c.rawSampleLocked()
+	}
+	if err := c.monitor.Test(raw[:]); err != nil {
+		return err
+	}
+
+	var state [32]byte
+	binary.BigEndian.PutUint64(state[0:], c.counter)
+	binary.BigEndian.PutUint64(state[8:], math.Float64bits(c.x))
+	binary.BigEndian.PutUint64(state[16:], math.Float64bits(c.y))
+	binary.BigEndian.PutUint64(state[24:], math.Float64bits(c.z))
+	c.counter++
+
+	h := sha3.New512()
+	h.Write([]byte(chaosLabel))
+	h.Write(c.key[:])
+	h.Write(state[:])
+	h.Sum(block[:0])
+	return nil
+}
+
+// rawSampleLocked returns the raw sample of the current state: bits 24 to
+// 31 of x, where a step of the trajectory moves thousands of units in the
+// last place
+func (c *ChaosEntropy) rawSampleLocked() byte {
+	return byte(math.Float64bits(c.x) >> 24)
+}
+
+// stepLocked advances the Lorenz system one RK4 step
+func (c *ChaosEntropy) stepLocked() {
+	deriv := func(x, y, z float64) (float64, float64, float64) {
+		return chaosSigma * (y - x), x*(chaosRho-z) - y, x*y - chaosBeta*z
+	}
+
+	x, y, z := c.x, c.y, c.z
+	k1x, k1y, k1z := deriv(x, y, z)
+	k2x, k2y, k2z := deriv(x+k1x*chaosDT/2, y+k1y*chaosDT/2, z+k1z*chaosDT/2)
+	k3x, k3y, k3z := deriv(x+k2x*chaosDT/2, y+k2y*chaosDT/2, z+k2z*chaosDT/2)
+	k4x, k4y, k4z := deriv(x+k3x*chaosDT, y+k3y*chaosDT, z+k3z*chaosDT)
+
+	c.x = x + chaosDT/6*(k1x+2*k2x+2*k3x+k4x)
+	c.y = y + chaosDT/6*(k1y+2*k2y+2*k3y+k4y)
+	c.z = z + chaosDT/6*(k1z+2*k2z+2*k3z+k4z)
+}
+
+// ============================================================================
+// Hardware devices
+// ============================================================================
+
+// DeviceEntropy reads an external TRNG exposed as a character device or
+// pipe, such as /dev/hwrng or a USB TRNG's device node. Every byte read
+// passes the SP 800-90B continuous health tests, assuming
+// DefaultMinEntropy; after a failure Read fails until HealthCheck passes.
+// Safe for concurrent use.
+type DeviceEntropy struct {
+	path string
+
+	mu      sync.Mutex
+	file    *os.File
+	monitor *HealthMonitor
+	failure error
+}
+
+// OpenDeviceEntropy opens the device at path for reading
+func OpenDeviceEntropy(path string) (*DeviceEntropy, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open entropy device: %v", err)
+	}
+	return &DeviceEntropy{path: path, file: f, monitor: newDefaultHealthMonitor()}, nil
+}
+
+// Name identifies the device as a random source, e.g. "device:/dev/hwrng"
+func (d *DeviceEntropy) Name() string {
+	return "device:" + d.path
+}
+
+// Read fills p from the device; a short read or a failed health test is
+// an error, and leaves p zeroed
+func (d *DeviceEntropy) Read(p []byte) (int, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := d.readLocked(p); err != nil {
+		zeroize(p)
+		return 0, err
+	}
+	if err := d.monitor.Test(p); err != nil {
+		d.failure = err
+		zeroize(p)
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// HealthCheck runs the start-up tests on fresh device output. Passing
+// clears an earlier health test failure.
+func (d *DeviceEntropy) HealthCheck() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err := startupTest(readerFunc(d.readLocked), d.monitor); err != nil {
+		return err
+	}
+	d.failure = nil
+	return nil
+}
+
+// readLocked reads p from the device, without health tests
+func (d *DeviceEntropy) readLocked(p []byte) error {
+	if d.file == nil {
+		return fmt.Errorf("entropy device %s is closed", d.path)
+	}
+	if d.failure != nil {
+		return d.failure
+	}
+	if _, err := io.ReadFull(d.file, p); err != nil {
+		return fmt.Errorf("entropy device %s: %v", d.path, err)
+	}
+	return nil
+}
+
+// readerFunc adapts a fill function to io.Reader
+type readerFunc func(p []byte) error
+
+func (f readerFunc) Read(p []byte) (int, error) {
+	if err := f(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Reseed does nothing: a hardware source has no state to seed
+func (d *DeviceEntropy) Reseed(seed []byte) error {
+	return nil
+}
+
+// Close closes the device
+func (d *DeviceEntropy) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.file == nil {
+		return nil
+	}
+	err := d.file.Close()
+	d.file = nil
+	return err
+}
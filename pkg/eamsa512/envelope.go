@@ -0,0 +1,512 @@
+package eamsa512
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Envelope format (version 2):
+//
+//	header: magic "EAME" (4) | version (1) | mode (1) |
+//	        key version (4, big-endian) | chunk size (4, big-endian) |
+//	        nonce (16) | key commitment (64)
+//	body:   ModeCBC:     ciphertext (CBC, PKCS#7 padded) | tag (64)
+//	        ModeChunked: stream chunks
+//	        ModeSIV:     ciphertext (unpadded) | synthetic IV (64)
+//
+// The whole header, followed by the caller's additional data if any, is
+// authenticated as additional data, so a changed mode, key version or
+// chunk size fails decryption like a changed ciphertext.
+//
+// ModeCBC is EncryptDataWithAAD with the header as aad; its chunk size
+// field is 0. ModeChunked seals the body in chunks as the stream format
+// does. It uses the first 11 nonce bytes as the nonce prefix (the other 5
+// are zero) and the envelope header in place of the stream header.
+// ModeSIV is described in siv.go.
+//
+// The key commitment is SHA3-512(key || "EAMSA512-COMMIT" || nonce).
+// HMAC-SHA3-512 tags do not commit to the key, so without it one
+// ciphertext could be built to verify under several keys, and a server
+// that tries candidate keys would act as a partition oracle. Decryption
+// compares the commitment in constant time before the body is touched,
+// and since it is part of the header the tag covers it too. Version 1
+// envelopes have the same layout without the commitment (a 30-byte
+// header). Decrypt rejects them with ErrLegacyEnvelope; DecryptLegacy
+// opens them without the check, for migrating old data.
+const (
+	// EnvelopeVersion is the envelope format version written by Encrypt
+	EnvelopeVersion = 2
+
+	// EnvelopeHeaderSize is the length of the envelope header of
+	// EnvelopeVersion
+	EnvelopeHeaderSize = envelopeHeaderSizeV1 + CommitmentSize
+
+	// CommitmentSize is the length of an envelope's key commitment
+	CommitmentSize = 64
+
+	envelopeHeaderSizeV1 = 30
+	envelopeMagic        = "EAME"
+	commitLabel          = "EAMSA512-COMMIT"
+)
+
+// ErrLegacyEnvelope is returned when decrypting a version 1 envelope,
+// which has no key commitment, without DecryptLegacy or OpenLegacy
+var ErrLegacyEnvelope = errors.New("eamsa512: version 1 envelope has no key commitment")
+
+// Mode identifies how an envelope body is encrypted
+type Mode byte
+
+const (
+	// ModeCBC is a single CBC ciphertext with an HMAC-SHA3-512 tag
+	ModeCBC Mode = 1
+
+	// ModeChunked is a sequence of independently authenticated chunks
+	ModeChunked Mode = 2
+
+	// ModeSIV is a deterministic synthetic-IV ciphertext that stays secure,
+	// apart from revealing equal messages, when a nonce repeats
+	ModeSIV Mode = 3
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeCBC:
+		return "cbc"
+	case ModeChunked:
+		return "chunked"
+	case ModeSIV:
+		return "siv"
+	default:
+		return fmt.Sprintf("mode(%d)", byte(m))
+	}
+}
+
+// Envelope is a parsed envelope. Ciphertext and Tag alias the input of
+// ParseEnvelope.
+type Envelope struct {
+	Version    byte
+	Mode       Mode
+	KeyVersion uint32
+	ChunkSize  uint32 // ModeChunked only; 0 otherwise
+	Nonce      []byte
+	Commitment []byte // Version 2; nil in version 1
+	Ciphertext []byte // ModeCBC: the CBC ciphertext; ModeChunked: the chunks; ModeSIV: the XORed plaintext
+	Tag        []byte // ModeCBC: the tag; ModeSIV: the synthetic IV
+}
+
+// EnvelopeOptions selects the mode, key version and chunk size of Encrypt
+type EnvelopeOptions struct {
+	Mode       Mode   // Zero means ModeCBC
+	KeyVersion uint32 // Recorded for the decrypting side; not interpreted
+	ChunkSize  int    // ModeChunked only; zero picks ChunkSizeFor(len(plaintext))
+	AAD        []byte // Authenticated but not stored; DecryptWithAAD must be given it
+
+	// Nonce is ModeSIV only: a caller-chosen nonce, which may repeat (all
+	// zeros for deterministic encryption). Nil means random; other modes
+	// always use a random nonce.
+	Nonce []byte
+}
+
+// IsEnvelope reports whether data starts with the envelope magic
+func IsEnvelope(data []byte) bool {
+	return len(data) >= len(envelopeMagic) && string(data[:len(envelopeMagic)]) == envelopeMagic
+}
+
+// envelopeHeaderSize returns the header length of an envelope version
+func envelopeHeaderSize(version byte) int {
+	if version == 1 {
+		return envelopeHeaderSizeV1
+	}
+	return EnvelopeHeaderSize
+}
+
+// Header returns the encoded envelope header, which is authenticated as
+// additional data
+func (e *Envelope) Header() []byte {
+	header := make([]byte, envelopeHeaderSize(e.Version))
+	copy(header, envelopeMagic)
+	header[4] = e.Version
+	header[5] = byte(e.Mode)
+	binary.BigEndian.PutUint32(header[6:10], e.KeyVersion)
+	binary.BigEndian.PutUint32(header[10:14], e.ChunkSize)
+	copy(header[14:], e.Nonce)
+	copy(header[envelopeHeaderSizeV1:], e.Commitment)
+	return header
+}
+
+// keyCommitment commits to the envelope key under a nonce
+func keyCommitment(key, nonce []byte) []byte {
+	hash := sha3.New512()
+	hash.Write(key)
+	hash.Write([]byte(commitLabel))
+	hash.Write(nonce)
+	return hash.Sum(nil)
+}
+
+// validate checks the header fields and, for ModeCBC and ModeSIV, the
+// tag length
+func (e *Envelope) validate() error {
+	switch {
+	case e.Version != 1 && e.Version != EnvelopeVersion:
+		return fmt.Errorf("unsupported envelope version %d", e.Version)
+	case e.Version == 1 && e.Commitment != nil:
+		return fmt.Errorf("key commitment set for version 1 envelope")
+	case e.Version != 1 && len(e.Commitment) != CommitmentSize:
+		return fmt.Errorf("invalid key commitment size: expected %d, got %d", CommitmentSize, len(e.Commitment))
+	}
+	if len(e.Nonce) != NonceSize {
+		return fmt.Errorf("invalid nonce size: expected %d, got %d", NonceSize, len(e.Nonce))
+	}
+
+	switch e.Mode {
+	case ModeCBC, ModeSIV:
+		if e.ChunkSize != 0 {
+			return fmt.Errorf("chunk size %d set for %s envelope", e.ChunkSize, e.Mode)
+		}
+		if len(e.Tag) != TagSize {
+			return fmt.Errorf("invalid tag size: expected %d, got %d", TagSize, len(e.Tag))
+		}
+	case ModeChunked:
+		if e.ChunkSize == 0 || e.ChunkSize > MaxChunkSize {
+			return fmt.Errorf("invalid chunk size %d in envelope", e.ChunkSize)
+		}
+		if len(e.Tag) != 0 {
+			return fmt.Errorf("tag set for %s envelope", e.Mode)
+		}
+		for _, b := range e.Nonce[streamPrefixSize:] {
+			if b != 0 {
+				return fmt.Errorf("nonce of %s envelope has a non-zero tail", e.Mode)
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported envelope mode %d", byte(e.Mode))
+	}
+
+	return nil
+}
+
+// MarshalEnvelope encodes e as header || ciphertext [|| tag]
+func MarshalEnvelope(e *Envelope) ([]byte, error) {
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, envelopeHeaderSize(e.Version)+len(e.Ciphertext)+len(e.Tag))
+	out = append(out, e.Header()...)
+	out = append(out, e.Ciphertext...)
+	out = append(out, e.Tag...)
+	return out, nil
+}
+
+// ParseEnvelope decodes an envelope without authenticating it. The fields
+// can be read to pick a key, but are only trustworthy once Decrypt
+// succeeds.
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if !IsEnvelope(data) {
+		return nil, fmt.Errorf("not an EAMSA 512 envelope")
+	}
+	if len(data) < envelopeHeaderSizeV1 {
+		return nil, fmt.Errorf("envelope truncated: %d bytes", len(data))
+	}
+	headerSize := envelopeHeaderSize(data[4])
+	if len(data) < headerSize {
+		return nil, fmt.Errorf("envelope truncated: %d bytes", len(data))
+	}
+
+	e := &Envelope{
+		Version:    data[4],
+		Mode:       Mode(data[5]),
+		KeyVersion: binary.BigEndian.Uint32(data[6:10]),
+		ChunkSize:  binary.BigEndian.Uint32(data[10:14]),
+		Nonce:      data[14:envelopeHeaderSizeV1],
+		Ciphertext: data[headerSize:],
+	}
+	if headerSize > envelopeHeaderSizeV1 {
+		e.Commitment = data[envelopeHeaderSizeV1:headerSize]
+	}
+
+	if e.Mode == ModeCBC || e.Mode == ModeSIV {
+		if len(e.Ciphertext) < TagSize {
+			return nil, fmt.Errorf("envelope truncated: %d bytes", len(data))
+		}
+		split := len(e.Ciphertext) - TagSize
+		e.Ciphertext, e.Tag = e.Ciphertext[:split], e.Ciphertext[split:]
+	}
+
+	if err := e.validate(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Encrypt encrypts plaintext under key and returns an envelope recording
+// the mode, key version and nonce
+func Encrypt(plaintext []byte, key []byte, opts EnvelopeOptions) ([]byte, error) {
+	return EncryptContext(context.Background(), plaintext, key, opts)
+}
+
+// EncryptContext is Encrypt that gives up with ctx.Err() once ctx is
+// done, checking between runs of blocks (ModeCBC, ModeSIV) or chunks
+// (ModeChunked)
+func EncryptContext(ctx context.Context, plaintext []byte, key []byte, opts EnvelopeOptions) ([]byte, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	e := &Envelope{
+		Version:    EnvelopeVersion,
+		Mode:       opts.Mode,
+		KeyVersion: opts.KeyVersion,
+	}
+	if e.Mode == 0 {
+		e.Mode = ModeCBC
+	}
+
+	if opts.Nonce != nil && e.Mode != ModeSIV {
+		return nil, fmt.Errorf("a caller-chosen nonce requires %s, got %s", ModeSIV, e.Mode)
+	}
+
+	nonce := opts.Nonce
+	if nonce == nil {
+		var err error
+		if nonce, err = NewNonce(); err != nil {
+			return nil, err
+		}
+	} else if len(nonce) != NonceSize {
+		return nil, fmt.Errorf("invalid nonce size: expected %d, got %d", NonceSize, len(nonce))
+	}
+	e.Nonce = append([]byte(nil), nonce...)
+	if e.Mode == ModeChunked {
+		for i := streamPrefixSize; i < NonceSize; i++ {
+			e.Nonce[i] = 0
+		}
+	}
+	e.Commitment = keyCommitment(key, e.Nonce)
+
+	switch e.Mode {
+	case ModeCBC:
+		return encryptEnvelopeCBC(ctx, e, plaintext, key, opts.AAD)
+	case ModeSIV:
+		return encryptEnvelopeSIV(ctx, e, plaintext, key, opts.AAD)
+	case ModeChunked:
+		chunkSize := opts.ChunkSize
+		if chunkSize == 0 {
+			chunkSize = ChunkSizeFor(int64(len(plaintext)))
+		}
+		if chunkSize < 0 || chunkSize > MaxChunkSize {
+			return nil, fmt.Errorf("invalid chunk size %d: must be between 1 and %d", chunkSize, MaxChunkSize)
+		}
+		e.ChunkSize = uint32(chunkSize)
+		return encryptEnvelopeChunked(ctx, e, plaintext, key, opts.AAD)
+	default:
+		return nil, fmt.Errorf("unsupported envelope mode %d", byte(e.Mode))
+	}
+}
+
+// envelopeAAD returns the additional data of an envelope: its header,
+// then the caller's (the header has a fixed size, so the split is
+// unambiguous)
+func (e *Envelope) envelopeAAD(aad []byte) []byte {
+	return append(e.Header(), aad...)
+}
+
+// encryptEnvelopeCBC seals plaintext with the header as additional data
+func encryptEnvelopeCBC(ctx context.Context, e *Envelope, plaintext []byte, key []byte, aad []byte) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	sealed, err := seal(ctx, buf, key, e.Nonce, e.envelopeAAD(aad), nil, PaddingPKCS7)
+	if err != nil {
+		return nil, err
+	}
+
+	// ciphertext || nonce || tag becomes header || ciphertext || tag
+	ciphertextLength := len(sealed) - NonceSize - TagSize
+	e.Ciphertext = sealed[:ciphertextLength]
+	e.Tag = sealed[ciphertextLength+NonceSize:]
+	return MarshalEnvelope(e)
+}
+
+// encryptEnvelopeChunked seals plaintext in chunks after the header
+func encryptEnvelopeChunked(ctx context.Context, e *Envelope, plaintext []byte, key []byte, aad []byte) ([]byte, error) {
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := e.Header()
+	chunks := (len(plaintext) / int(e.ChunkSize)) + 1
+
+	var out bytes.Buffer
+	out.Grow(len(header) + len(plaintext) + chunks*(BlockSize+TagSize))
+	out.Write(header)
+
+	ew := newEncryptingWriter(ctx, &out, aead, e.envelopeAAD(aad), e.Nonce, int(e.ChunkSize))
+	if _, err := ew.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// Decrypt authenticates and decrypts an envelope produced by Encrypt. An
+// envelope whose key commitment does not match key returns ErrDecryption,
+// as does a ModeCBC or ModeSIV envelope that fails authentication; a
+// ModeChunked one returns the error of the first bad chunk
+// (ErrStreamTruncated if the final chunk is missing). A version 1
+// envelope returns ErrLegacyEnvelope.
+func Decrypt(data []byte, key []byte) ([]byte, error) {
+	return DecryptWithAAD(data, key, nil)
+}
+
+// DecryptContext is Decrypt that gives up with ctx.Err() once ctx is done
+func DecryptContext(ctx context.Context, data []byte, key []byte) ([]byte, error) {
+	return DecryptWithAADContext(ctx, data, key, nil)
+}
+
+// DecryptWithAAD is Decrypt for an envelope sealed with
+// EnvelopeOptions.AAD; aad must match
+func DecryptWithAAD(data []byte, key []byte, aad []byte) ([]byte, error) {
+	return DecryptWithAADContext(context.Background(), data, key, aad)
+}
+
+// DecryptWithAADContext is DecryptWithAAD that gives up with ctx.Err()
+// once ctx is done
+func DecryptWithAADContext(ctx context.Context, data []byte, key []byte, aad []byte) ([]byte, error) {
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.OpenContext(ctx, key, aad)
+}
+
+// DecryptEnvelopeAt decrypts an envelope of size bytes read from r, such
+// as a spooled file, to w. A ModeChunked envelope is opened like
+// DecryptStreamAt, a batch of Workers() chunks at a time, with the
+// plaintext written in order; other modes are read whole and decrypted
+// as DecryptWithAAD does. It returns the number of bytes written.
+func DecryptEnvelopeAt(ctx context.Context, w io.Writer, r io.ReaderAt, size int64, key []byte, aad []byte) (int64, error) {
+	header := make([]byte, min(size, EnvelopeHeaderSize))
+	if n, err := r.ReadAt(header, 0); n < len(header) {
+		return 0, err
+	}
+
+	e, err := ParseEnvelope(header)
+	if err != nil || e.Mode != ModeChunked {
+		data := make([]byte, size)
+		if n, err := r.ReadAt(data, 0); int64(n) < size {
+			return 0, err
+		}
+		plaintext, err := DecryptWithAADContext(ctx, data, key, aad)
+		if err != nil {
+			return 0, err
+		}
+		n, err := w.Write(plaintext)
+		return int64(n), err
+	}
+
+	if err := e.checkKey(key, false); err != nil {
+		return 0, err
+	}
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return 0, err
+	}
+
+	headerSize := int64(envelopeHeaderSize(e.Version))
+	body := io.NewSectionReader(r, headerSize, size-headerSize)
+	return decryptChunksAt(ctx, w, body, aead, e.envelopeAAD(aad), e.Nonce, int(e.ChunkSize), nil)
+}
+
+// DecryptLegacy is DecryptWithAAD that also opens version 1 envelopes.
+// Those have no key commitment, so a crafted one may verify under more
+// than one key; use it only to read and re-encrypt data written before
+// version 2.
+func DecryptLegacy(data []byte, key []byte, aad []byte) ([]byte, error) {
+	e, err := ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.OpenLegacy(context.Background(), key, aad)
+}
+
+// Open authenticates and decrypts the envelope body (see Decrypt)
+func (e *Envelope) Open(key []byte) ([]byte, error) {
+	return e.OpenWithAAD(key, nil)
+}
+
+// OpenWithAAD is Open with the additional data the envelope was sealed with
+func (e *Envelope) OpenWithAAD(key []byte, aad []byte) ([]byte, error) {
+	return e.OpenContext(context.Background(), key, aad)
+}
+
+// OpenContext is OpenWithAAD that gives up with ctx.Err() once ctx is done
+func (e *Envelope) OpenContext(ctx context.Context, key []byte, aad []byte) ([]byte, error) {
+	return e.open(ctx, key, aad, false)
+}
+
+// OpenLegacy is OpenContext that also opens version 1 envelopes (see
+// DecryptLegacy)
+func (e *Envelope) OpenLegacy(ctx context.Context, key []byte, aad []byte) ([]byte, error) {
+	return e.open(ctx, key, aad, true)
+}
+
+// checkKey validates the header and checks key against the commitment,
+// refusing version 1 unless legacy is set
+func (e *Envelope) checkKey(key []byte, legacy bool) error {
+	if err := e.validate(); err != nil {
+		return err
+	}
+	if len(key) != KeySize {
+		return fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	if e.Version == 1 {
+		if !legacy {
+			return ErrLegacyEnvelope
+		}
+	} else if subtle.ConstantTimeCompare(keyCommitment(key, e.Nonce), e.Commitment) != 1 {
+		return ErrDecryption
+	}
+	return nil
+}
+
+// open decrypts the envelope body, refusing version 1 unless legacy is set
+func (e *Envelope) open(ctx context.Context, key []byte, aad []byte, legacy bool) ([]byte, error) {
+	if err := e.checkKey(key, legacy); err != nil {
+		return nil, err
+	}
+
+	additionalData := e.envelopeAAD(aad)
+
+	if e.Mode == ModeSIV {
+		return openEnvelopeSIV(ctx, e, key, additionalData)
+	}
+	if e.Mode == ModeChunked {
+		aead, err := streamAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		return io.ReadAll(newDecryptingReader(ctx, bytes.NewReader(e.Ciphertext), aead, additionalData, e.Nonce, int(e.ChunkSize)))
+	}
+
+	// Back to ciphertext || nonce || tag, in a copy so data is unchanged
+	buf := make([]byte, 0, len(e.Ciphertext)+NonceSize+TagSize)
+	buf = append(buf, e.Ciphertext...)
+	buf = append(buf, e.Nonce...)
+	buf = append(buf, e.Tag...)
+
+	return open(ctx, buf, key, additionalData, nil, PaddingPKCS7)
+}
@@ -0,0 +1,165 @@
+//go:build ignore
+
+// gentables.go generates phase2tables.go: the S-boxes, the P-layer and
+// their digest.
+//
+// Run with "go generate" in pkg/eamsa512. Each S-box is a Fisher-Yates
+// shuffle of 0..255 driven by SHAKE256("EAMSA512-SBOX" || n || attempt);
+// attempts without fixed points are kept. The P-layer is the 8×8 bit
+// transpose applied to each 64-bit word. Both are checked before the file
+// is written, and the digest is what the self-test compares against.
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	sboxCount     = 8
+	tablesLabel   = "EAMSA512-TABLES-v1"
+	sboxSeedLabel = "EAMSA512-SBOX"
+	outputFile    = "phase2tables.go"
+)
+
+func main() {
+	var sboxes [sboxCount][256]byte
+	for n := range sboxes {
+		sboxes[n] = generateSBox(n)
+		if err := checkBijective(sboxes[n]); err != nil {
+			log.Fatalf("S-box %d: %v", n+1, err)
+		}
+	}
+
+	var player [64]int
+	for i := range player {
+		player[i] = (i%8)*8 + i/8
+	}
+	if err := checkPermutation(player); err != nil {
+		log.Fatalf("P-layer: %v", err)
+	}
+
+	digest := tablesDigest(sboxes, player)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by gentables.go; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "package eamsa512\n\n")
+	fmt.Fprintf(&b, "// PhaseTablesDigest is SHA3-256(%q || S-boxes || P-layer)\n", tablesLabel)
+	fmt.Fprintf(&b, "const PhaseTablesDigest = %q\n\n", hex.EncodeToString(digest))
+	fmt.Fprintf(&b, "// SBoxTable holds the eight byte S-boxes\n")
+	fmt.Fprintf(&b, "var SBoxTable = [8][256]byte{\n")
+	for n, box := range sboxes {
+		fmt.Fprintf(&b, "\t// S-box %d\n\t{\n", n+1)
+		for i := 0; i < 256; i += 16 {
+			b.WriteString("\t\t")
+			for j := i; j < i+16; j++ {
+				fmt.Fprintf(&b, "0x%02x, ", box[j])
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\t},\n")
+	}
+	b.WriteString("}\n\n")
+	fmt.Fprintf(&b, "// PLayerPermutation is the bit permutation within each 64-bit word:\n")
+	fmt.Fprintf(&b, "// output bit i takes input bit PLayerPermutation[i] (an 8×8 transpose)\n")
+	fmt.Fprintf(&b, "var PLayerPermutation = [64]int{\n")
+	for i := 0; i < 64; i += 8 {
+		b.WriteString("\t")
+		for j := i; j < i+8; j++ {
+			fmt.Fprintf(&b, "%d, ", player[j])
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("format: %v", err)
+	}
+	if err := os.WriteFile(outputFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %s (digest %x)\n", outputFile, digest)
+}
+
+// generateSBox shuffles 0..255 under SHAKE256, retrying until the
+// result has no fixed point
+func generateSBox(n int) [256]byte {
+	for attempt := 0; ; attempt++ {
+		xof := sha3.NewShake256()
+		xof.Write([]byte(sboxSeedLabel))
+		xof.Write([]byte{byte(n), byte(attempt)})
+
+		var box [256]byte
+		for i := range box {
+			box[i] = byte(i)
+		}
+		var r [1]byte
+		for i := 255; i > 0; i-- {
+			// Rejection sampling keeps the shuffle unbiased
+			mask := byte(1)
+			for int(mask) < i {
+				mask = mask<<1 | 1
+			}
+			for {
+				xof.Read(r[:])
+				if j := int(r[0] & mask); j <= i {
+					box[i], box[j] = box[j], box[i]
+					break
+				}
+			}
+		}
+
+		fixed := false
+		for i, v := range box {
+			if int(v) == i {
+				fixed = true
+				break
+			}
+		}
+		if !fixed {
+			return box
+		}
+	}
+}
+
+func checkBijective(box [256]byte) error {
+	var seen [256]bool
+	for in, out := range box {
+		if seen[out] {
+			return fmt.Errorf("output 0x%02x repeated at input 0x%02x", out, in)
+		}
+		seen[out] = true
+	}
+	return nil
+}
+
+func checkPermutation(perm [64]int) error {
+	var seen [64]bool
+	for i, p := range perm {
+		if p < 0 || p >= 64 || seen[p] {
+			return fmt.Errorf("entry %d (%d) is out of range or repeated", i, p)
+		}
+		seen[p] = true
+	}
+	return nil
+}
+
+// tablesDigest must match HashPhaseTables in phase2.go
+func tablesDigest(sboxes [sboxCount][256]byte, player [64]int) []byte {
+	h := sha3.New256()
+	h.Write([]byte(tablesLabel))
+	for _, box := range sboxes {
+		h.Write(box[:])
+	}
+	for _, p := range player {
+		h.Write([]byte{byte(p)})
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,144 @@
+package eamsa512
+
+import (
+	"fmt"
+	"math"
+)
+
+// SP 800-90B 4.4 continuous health tests over byte samples. The cutoffs
+// follow from the min-entropy a source is assumed to have per sample and
+// a false positive rate of 2^-20 per test:
+//
+//	Repetition Count Test   C = 1 + ceil(20 / H)
+//	Adaptive Proportion     C = 1 + CRITBINOM(512, 2^-H, 1 - 2^-20)
+//
+// For H = 4 (DefaultMinEntropy) these are 6 and 62, as in the CLI
+// self-test.
+
+// DefaultMinEntropy is the min-entropy per byte sample, in bits, that
+// NewHealthMonitor assumes when given 0
+const DefaultMinEntropy = 4.0
+
+// Health test parameters
+const (
+	healthFalsePositiveLog2 = 20   // α = 2^-20
+	healthAPTWindow         = 512  // Samples per Adaptive Proportion window
+	healthStartupSamples    = 1024 // SP 800-90B 4.3: tested before first use
+)
+
+// Health test names, as in HealthTestError.Test
+const (
+	RepetitionCountTest    = "repetition count"
+	AdaptiveProportionTest = "adaptive proportion"
+)
+
+// HealthTestError reports a failed health test
+type HealthTestError struct {
+	Test   string // RepetitionCountTest or AdaptiveProportionTest
+	Value  byte   // The repeated or over-represented sample value
+	Count  int
+	Cutoff int
+}
+
+func (e *HealthTestError) Error() string {
+	if e.Test == RepetitionCountTest {
+		return fmt.Sprintf("SP 800-90B %s test failed: 0x%02x repeated %d times (cutoff %d)", e.Test, e.Value, e.Count, e.Cutoff)
+	}
+	return fmt.Sprintf("SP 800-90B %s test failed: 0x%02x occurred %d times in %d samples (cutoff %d)", e.Test, e.Value, e.Count, healthAPTWindow, e.Cutoff)
+}
+
+// HealthMonitor runs the Repetition Count and Adaptive Proportion Tests
+// over a stream of samples, across calls to Test. Not safe for
+// concurrent use.
+type HealthMonitor struct {
+	rctCutoff int
+	aptCutoff int
+
+	last byte // Repetition Count state
+	run  int
+
+	aptValue byte // Adaptive Proportion state: first sample of the window
+	aptCount int
+	aptSeen  int
+
+	failed *HealthTestError // Sticky until Reset
+}
+
+// NewHealthMonitor returns a monitor whose cutoffs assume minEntropy bits
+// per sample (0 < minEntropy <= 8; 0 selects DefaultMinEntropy)
+func NewHealthMonitor(minEntropy float64) (*HealthMonitor, error) {
+	if minEntropy == 0 {
+		minEntropy = DefaultMinEntropy
+	}
+	if minEntropy < 0 || minEntropy > 8 || math.IsNaN(minEntropy) {
+		return nil, fmt.Errorf("invalid min-entropy %v: want more than 0 and at most 8 bits per byte", minEntropy)
+	}
+
+	return &HealthMonitor{
+		rctCutoff: 1 + int(math.Ceil(healthFalsePositiveLog2/minEntropy)),
+		aptCutoff: 1 + critBinom(healthAPTWindow, math.Exp2(-minEntropy), 1-math.Exp2(-healthFalsePositiveLog2)),
+	}, nil
+}
+
+// Cutoffs returns the Repetition Count and Adaptive Proportion cutoffs
+func (m *HealthMonitor) Cutoffs() (rct, apt int) {
+	return m.rctCutoff, m.aptCutoff
+}
+
+// Test runs samples through both tests and returns a *HealthTestError at
+// the first failure. The monitor keeps failing until Reset.
+func (m *HealthMonitor) Test(samples []byte) error {
+	if m.failed != nil {
+		return m.failed
+	}
+
+	for _, s := range samples {
+		if m.run > 0 && s == m.last {
+			m.run++
+		} else {
+			m.last, m.run = s, 1
+		}
+		if m.run >= m.rctCutoff {
+			m.failed = &HealthTestError{Test: RepetitionCountTest, Value: s, Count: m.run, Cutoff: m.rctCutoff}
+			return m.failed
+		}
+
+		if m.aptSeen == healthAPTWindow {
+			m.aptSeen = 0
+		}
+		if m.aptSeen == 0 {
+			m.aptValue, m.aptCount = s, 0
+		}
+		m.aptSeen++
+		if s == m.aptValue {
+			m.aptCount++
+		}
+		if m.aptCount >= m.aptCutoff {
+			m.failed = &HealthTestError{Test: AdaptiveProportionTest, Value: m.aptValue, Count: m.aptCount, Cutoff: m.aptCutoff}
+			return m.failed
+		}
+	}
+	return nil
+}
+
+// Reset clears the state of both tests
+func (m *HealthMonitor) Reset() {
+	m.last, m.run = 0, 0
+	m.aptValue, m.aptCount, m.aptSeen = 0, 0, 0
+	m.failed = nil
+}
+
+// critBinom returns the smallest k with P(X <= k) >= q for X ~ B(n, p)
+func critBinom(n int, p, q float64) int {
+	lgN, _ := math.Lgamma(float64(n + 1))
+	cdf := 0.0
+	for k := 0; k < n; k++ {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		cdf += math.Exp(lgN - lgK - lgNK + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p))
+		if cdf >= q {
+			return k
+		}
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package eamsa512
+
+import (
+	"crypto/subtle"
+	"hash"
+	"io"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// hmacBlockSize is the SHA3-512 rate in bytes, (1600 - 2×512) / 8, the
+// block size HMAC uses for SHA3-512 (crypto/hmac takes it from
+// sha3.New512().BlockSize())
+const hmacBlockSize = 72
+
+// TagWriter is an incremental tag computation: write the message, then Sum
+type TagWriter interface {
+	io.Writer
+	Sum() []byte
+}
+
+// ComputeHMAC computes the 64-byte HMAC-SHA3-512 tag of data:
+// H((K XOR opad) || H((K XOR ipad) || data))
+func ComputeHMAC(key []byte, data []byte) []byte {
+	mac := NewHMAC(key)
+	mac.Write(data)
+	return mac.Sum()
+}
+
+// VerifyHMAC reports in constant time whether tag is the HMAC of data
+func VerifyHMAC(key []byte, data []byte, tag []byte) bool {
+	return subtle.ConstantTimeCompare(ComputeHMAC(key, data), tag) == 1
+}
+
+// hmacSHA3 is the incremental form of ComputeHMAC
+type hmacSHA3 struct {
+	inner hash.Hash
+	opad  []byte
+}
+
+// NewHMAC starts an HMAC-SHA3-512 computation with ComputeHMAC's key schedule
+func NewHMAC(key []byte) TagWriter {
+	// Keys longer than the block are hashed first
+	expandedKey := make([]byte, hmacBlockSize)
+	if len(key) <= hmacBlockSize {
+		copy(expandedKey, key)
+	} else {
+		hash := sha3.New512()
+		hash.Write(key)
+		copy(expandedKey, hash.Sum(nil))
+	}
+
+	ipad := make([]byte, hmacBlockSize)
+	opad := make([]byte, hmacBlockSize)
+	for i := 0; i < hmacBlockSize; i++ {
+		ipad[i] = expandedKey[i] ^ 0x36
+		opad[i] = expandedKey[i] ^ 0x5c
+	}
+
+	inner := sha3.New512()
+	inner.Write(ipad)
+
+	return &hmacSHA3{inner: inner, opad: opad}
+}
+
+// Write adds data to the authenticated input
+func (h *hmacSHA3) Write(p []byte) (int, error) {
+	return h.inner.Write(p)
+}
+
+// Sum returns the 64-byte tag
+func (h *hmacSHA3) Sum() []byte {
+	outer := sha3.New512()
+	outer.Write(h.opad)
+	outer.Write(h.inner.Sum(nil))
+	return outer.Sum(nil)
+}
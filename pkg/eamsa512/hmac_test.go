@@ -0,0 +1,148 @@
+package eamsa512
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ============================================================================
+// EAMSA 512 - HMAC-SHA3-512 Interoperability Test Suite
+// ComputeHMAC must be standard HMAC (RFC 2104) over SHA3-512, byte for byte
+//
+// Known answers are the test cases of RFC 4231 (HMAC-SHA-2) with their
+// HMAC-SHA3-512 tags as published in the HMAC-SHA3 test vector sets,
+// including the 147-byte key case (6a) those sets add; crypto/hmac over
+// golang.org/x/crypto/sha3 is the reference for everything else. The
+// vectors straddle the 72-byte SHA3-512 block, where a wrong block size
+// shows.
+//
+// Last updated: October 17, 2026
+// ============================================================================
+
+// hmacKnownAnswers are RFC 4231 inputs with their HMAC-SHA3-512 tags
+var hmacKnownAnswers = []struct {
+	name string
+	key  []byte
+	data []byte
+	tag  string // Hex; shorter than 64 bytes for truncated tags
+}{
+	{
+		name: "case 1",
+		key:  bytes.Repeat([]byte{0x0b}, 20),
+		data: []byte("Hi There"),
+		tag:  "eb3fbd4b2eaab8f5c504bd3a41465aacec15770a7cabac531e482f860b5ec7ba47ccb2c6f2afce8f88d22b6dc61380f23a668fd3888bb80537c0a0b86407689e",
+	},
+	{
+		name: "case 2 (key shorter than the tag)",
+		key:  []byte("Jefe"),
+		data: []byte("what do ya want for nothing?"),
+		tag:  "5a4bfeab6166427c7a3647b747292b8384537cdb89afb3bf5665e4c5e709350b287baec921fd7ca0ee7a0c31d022a95e1fc92ba9d77df883960275beb4e62024",
+	},
+	{
+		name: "case 3",
+		key:  bytes.Repeat([]byte{0xaa}, 20),
+		data: bytes.Repeat([]byte{0xdd}, 50),
+		tag:  "309e99f9ec075ec6c6d475eda1180687fcf1531195802a99b5677449a8625182851cb332afb6a89c411325fbcbcd42afcb7b6e5aab7ea42c660f97fd8584bf03",
+	},
+	{
+		name: "case 4",
+		key:  hmacSequence(1, 25),
+		data: bytes.Repeat([]byte{0xcd}, 50),
+		tag:  "b27eab1d6e8d87461c29f7f5739dd58e98aa35f8e823ad38c5492a2088fa0281993bbfff9a0e9c6bf121ae9ec9bb09d84a5ebac817182ea974673fb133ca0d1d",
+	},
+	{
+		name: "case 5 (truncated to 128 bits)",
+		key:  bytes.Repeat([]byte{0x0c}, 20),
+		data: []byte("Test With Truncation"),
+		tag:  "0fa7475948f43f48ca0516671e18978c",
+	},
+	{
+		name: "case 6 (key longer than the block)",
+		key:  bytes.Repeat([]byte{0xaa}, 131),
+		data: []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		tag:  "00f751a9e50695b090ed6911a4b65524951cdc15a73a5d58bb55215ea2cd839ac79d2b44a39bafab27e83fde9e11f6340b11d991b1b91bf2eee7fc872426c3a4",
+	},
+	{
+		name: "case 6a (147-byte key)",
+		key:  bytes.Repeat([]byte{0xaa}, 147),
+		data: []byte("Test Using Larger Than Block-Size Key - Hash Key First"),
+		tag:  "b14835c819a290efb010ace6d8568dc6b84de60bc49b004c3b13eda763589451e5dd74292884d1bdce64e6b919dd61dc9c56a282a81c0bd14f1f365b49b83a5b",
+	},
+	{
+		name: "case 7 (key and data longer than the block)",
+		key:  bytes.Repeat([]byte{0xaa}, 131),
+		data: []byte("This is a test using a larger than block-size key and a larger than block-size data. The key needs to be hashed before being used by the HMAC algorithm."),
+		tag:  "38a456a004bd10d32c9ab8336684112862c3db61adcca31829355eaf46fd5c73d06a1f0d13fec9a652fb3811b577b1b1d1b9789f97ae5b83c6f44dfcf1d67eba",
+	},
+}
+
+// hmacSequence returns n bytes counting up from first
+func hmacSequence(first, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = byte(first + i)
+	}
+	return b
+}
+
+// TestHMACKnownAnswers checks ComputeHMAC against the RFC 4231 cases
+func TestHMACKnownAnswers(t *testing.T) {
+	fmt.Println("Test: HMAC-SHA3-512 Known Answers")
+
+	for _, tc := range hmacKnownAnswers {
+		want, err := hex.DecodeString(tc.tag)
+		if err != nil {
+			t.Fatalf("%s: bad vector: %v", tc.name, err)
+		}
+
+		tag := ComputeHMAC(tc.key, tc.data)
+		if !bytes.Equal(tag[:len(want)], want) {
+			t.Errorf("%s: tag = %x, want %s", tc.name, tag[:len(want)], tc.tag)
+		}
+		if len(want) == len(tag) && !VerifyHMAC(tc.key, tc.data, want) {
+			t.Errorf("%s: VerifyHMAC rejects the published tag", tc.name)
+		}
+	}
+
+	fmt.Printf("✓ %d RFC 4231 cases match\n", len(hmacKnownAnswers))
+}
+
+// TestHMACInterop checks ComputeHMAC and NewHMAC against crypto/hmac for
+// keys and messages on both sides of the block size
+func TestHMACInterop(t *testing.T) {
+	fmt.Println("Test: HMAC-SHA3-512 Interop with crypto/hmac")
+
+	lengths := []int{0, 1, 63, 64, 65, 71, 72, 73, 135, 136, 137, 144, 200}
+	for _, keyLen := range lengths {
+		key := hmacSequence(keyLen, keyLen)
+		for _, dataLen := range append(lengths, 1000) {
+			data := hmacSequence(dataLen*7, dataLen)
+
+			ref := hmac.New(sha3.New512, key)
+			ref.Write(data)
+			want := ref.Sum(nil)
+
+			if got := ComputeHMAC(key, data); !bytes.Equal(got, want) {
+				t.Fatalf("key %d bytes, data %d bytes: ComputeHMAC = %x, want %x", keyLen, dataLen, got, want)
+			}
+
+			// Incremental, in uneven pieces
+			mac := NewHMAC(key)
+			for rest := data; len(rest) > 0; {
+				n := min(len(rest), 1+len(rest)/3)
+				mac.Write(rest[:n])
+				rest = rest[n:]
+			}
+			if got := mac.Sum(); !bytes.Equal(got, want) {
+				t.Fatalf("key %d bytes, data %d bytes: NewHMAC = %x, want %x", keyLen, dataLen, got, want)
+			}
+		}
+	}
+
+	fmt.Printf("✓ %d key lengths × %d message lengths match crypto/hmac\n", len(lengths), len(lengths)+1)
+}
@@ -0,0 +1,441 @@
+package eamsa512
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ErrUnknownKeyVersion is returned by Keyring.Decrypt for an envelope whose
+// key version the keyring does not hold: never added, or erased under the
+// retention policy
+var ErrUnknownKeyVersion = errors.New("eamsa512: unknown key version")
+
+// KeyringPolicy controls rotation and retention of a Keyring. The zero
+// value never rotates and keeps every version.
+type KeyringPolicy struct {
+	// RotateAfter rotates the active key on the first Encrypt after it is
+	// this old (0: no age limit)
+	RotateAfter time.Duration
+
+	// MaxEncryptions rotates the active key once it has sealed this many
+	// messages (0: no limit)
+	MaxEncryptions uint64
+
+	// Retain is how many retired versions stay available for decryption;
+	// older ones are erased (0: keep all)
+	Retain int
+
+	// NewKey returns the material of each new version, e.g. generated and
+	// persisted by a KMS (nil: 32 bytes from crypto/rand, held in memory
+	// only)
+	NewKey func() ([]byte, error)
+
+	// Entropy is the source of generated keys when NewKey is nil (nil:
+	// crypto/rand)
+	Entropy EntropySource
+
+	// Mode and ChunkSize select the envelope mode of Encrypt (see
+	// EnvelopeOptions)
+	Mode      Mode
+	ChunkSize int
+
+	// Telemetry receives key lifecycle events, keyed by keyid (nil: none)
+	Telemetry telemetry.Telemetry
+}
+
+// KeyVersionStats are the counters of one keyring version
+type KeyVersionStats struct {
+	Version            uint32
+	KeyID              string
+	Active             bool
+	CreatedAt          time.Time
+	Encryptions        uint64
+	Decryptions        uint64
+	DecryptionFailures uint64
+	CacheHits          uint64 // Decryptions served by the decrypt cache
+}
+
+// keyringEntry is one key version
+type keyringEntry struct {
+	key         []byte
+	id          string
+	createdAt   time.Time
+	encryptions atomic.Uint64
+	decryptions atomic.Uint64
+	failures    atomic.Uint64
+	cacheHits   atomic.Uint64
+	cache       atomic.Pointer[decryptCache] // nil: caching disabled
+}
+
+// Keyring bundles versioned keys with Encrypt and Decrypt: Encrypt seals
+// under the active version (rotating first when the policy says so) and
+// records the version in the envelope header; Decrypt picks the version
+// named there. Safe for concurrent use.
+type Keyring struct {
+	mu       sync.RWMutex
+	policy   KeyringPolicy
+	versions map[uint32]*keyringEntry
+	active   uint32
+}
+
+// NewKeyring returns a keyring whose version 1 is a new key from
+// policy.NewKey. Use AddKey and SetActive to load existing versions.
+func NewKeyring(policy KeyringPolicy) (*Keyring, error) {
+	if policy.RotateAfter < 0 || policy.Retain < 0 {
+		return nil, fmt.Errorf("invalid keyring policy: negative RotateAfter or Retain")
+	}
+	switch policy.Mode {
+	case 0, ModeCBC, ModeChunked, ModeSIV:
+	default:
+		return nil, fmt.Errorf("unsupported envelope mode %d", byte(policy.Mode))
+	}
+
+	kr := &Keyring{
+		policy:   policy,
+		versions: make(map[uint32]*keyringEntry),
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+	if _, err := kr.rotate(); err != nil {
+		return nil, err
+	}
+	return kr, nil
+}
+
+// Encrypt seals plaintext under the active version with aad as additional
+// authenticated data (not stored; Decrypt must be given the same aad)
+func (kr *Keyring) Encrypt(ctx context.Context, plaintext, aad []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	version, entry, err := kr.activeForEncrypt()
+	if err != nil {
+		return nil, err
+	}
+
+	return EncryptContext(ctx, plaintext, entry.key, EnvelopeOptions{
+		Mode:       kr.policy.Mode,
+		KeyVersion: version,
+		ChunkSize:  kr.policy.ChunkSize,
+		AAD:        aad,
+	})
+}
+
+// Decrypt opens an envelope produced by Encrypt with the version recorded
+// in its header. Returns ErrUnknownKeyVersion if that version is not held.
+func (kr *Keyring) Decrypt(ctx context.Context, blob, aad []byte) ([]byte, error) {
+	return kr.decrypt(ctx, blob, aad, false)
+}
+
+// decrypt is Decrypt that also opens version 1 envelopes if legacy is set
+func (kr *Keyring) decrypt(ctx context.Context, blob, aad []byte, legacy bool) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	e, err := ParseEnvelope(blob)
+	if err != nil {
+		return nil, err
+	}
+
+	kr.mu.RLock()
+	entry, ok := kr.versions[e.KeyVersion]
+	kr.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %d", ErrUnknownKeyVersion, e.KeyVersion)
+	}
+
+	// Legacy opens bypass the cache, so Decrypt cannot serve a version 1
+	// envelope from it
+	cache := entry.cache.Load()
+	if legacy {
+		cache = nil
+	}
+	var digest [32]byte
+	if cache != nil {
+		digest = cacheDigest(blob, aad)
+		if plaintext, ok := cache.get(digest); ok {
+			entry.decryptions.Add(1)
+			entry.cacheHits.Add(1)
+			return plaintext, nil
+		}
+	}
+
+	plaintext, err := e.open(ctx, entry.key, aad, legacy)
+	if err != nil {
+		// A cancelled call says nothing about the blob
+		if ctx.Err() == nil {
+			entry.failures.Add(1)
+		}
+		return nil, err
+	}
+
+	entry.decryptions.Add(1)
+	if cache != nil {
+		cache.put(digest, plaintext)
+	}
+	return plaintext, nil
+}
+
+// SetDecryptCache enables caching of decrypted envelopes of version under
+// policy, replacing any cache it had, or disables it with a nil policy.
+// Caching is off for every version until enabled here; replacing or
+// disabling a cache, or erasing the version, wipes its entries and key.
+func (kr *Keyring) SetDecryptCache(version uint32, policy *DecryptCachePolicy) error {
+	var cache *decryptCache
+	if policy != nil {
+		p := *policy
+		if err := p.validate(); err != nil {
+			return err
+		}
+		var err error
+		if cache, err = newDecryptCache(p); err != nil {
+			return err
+		}
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	entry, ok := kr.versions[version]
+	if !ok {
+		if cache != nil {
+			cache.destroy()
+		}
+		return fmt.Errorf("%w %d", ErrUnknownKeyVersion, version)
+	}
+
+	if previous := entry.cache.Swap(cache); previous != nil {
+		previous.destroy()
+	}
+	return nil
+}
+
+// WrapDataKey wraps a data key under the active version, so a Keyring is
+// a KEK for EnvelopeEncrypt
+func (kr *Keyring) WrapDataKey(ctx context.Context, dek, aad []byte) ([]byte, error) {
+	return kr.Encrypt(ctx, dek, aad)
+}
+
+// UnwrapDataKey unwraps a data key with the version recorded in it
+func (kr *Keyring) UnwrapDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	return kr.Decrypt(ctx, wrapped, aad)
+}
+
+// UnwrapLegacyDataKey is UnwrapDataKey that also opens version 1
+// envelopes (see DecryptLegacy), so a Keyring is a LegacyKEK
+func (kr *Keyring) UnwrapLegacyDataKey(ctx context.Context, wrapped, aad []byte) ([]byte, error) {
+	return kr.decrypt(ctx, wrapped, aad, true)
+}
+
+// Rotate makes a new key from policy.NewKey the active version and returns
+// its number
+func (kr *Keyring) Rotate() (uint32, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	return kr.rotate()
+}
+
+// AddKey adds an existing key (e.g. loaded from storage) as version for
+// decryption. It does not become active; see SetActive.
+func (kr *Keyring) AddKey(version uint32, key []byte) error {
+	if version == 0 {
+		return fmt.Errorf("key version 0 is reserved")
+	}
+	if len(key) != KeySize {
+		return fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	if _, exists := kr.versions[version]; exists {
+		return fmt.Errorf("key version %d already present", version)
+	}
+
+	entry := newKeyringEntry(key)
+	kr.versions[version] = entry
+	kr.observe(telemetry.KeyImported, entry)
+	return nil
+}
+
+// SetActive makes version the active version for Encrypt
+func (kr *Keyring) SetActive(version uint32) error {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	entry, ok := kr.versions[version]
+	if !ok {
+		return fmt.Errorf("%w %d", ErrUnknownKeyVersion, version)
+	}
+	if version == kr.active {
+		return nil
+	}
+
+	if previous, ok := kr.versions[kr.active]; ok {
+		kr.observe(telemetry.KeyRotated, previous)
+	}
+	kr.active = version
+	kr.observe(telemetry.KeyActivated, entry)
+	kr.retain()
+	return nil
+}
+
+// ActiveVersion returns the version Encrypt currently uses
+func (kr *Keyring) ActiveVersion() uint32 {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	return kr.active
+}
+
+// Stats returns the counters of every held version, oldest first
+func (kr *Keyring) Stats() []KeyVersionStats {
+	kr.mu.RLock()
+	defer kr.mu.RUnlock()
+
+	stats := make([]KeyVersionStats, 0, len(kr.versions))
+	for version, entry := range kr.versions {
+		stats = append(stats, KeyVersionStats{
+			Version:            version,
+			KeyID:              entry.id,
+			Active:             version == kr.active,
+			CreatedAt:          entry.createdAt,
+			Encryptions:        entry.encryptions.Load(),
+			Decryptions:        entry.decryptions.Load(),
+			DecryptionFailures: entry.failures.Load(),
+			CacheHits:          entry.cacheHits.Load(),
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Version < stats[j].Version })
+	return stats
+}
+
+// activeForEncrypt rotates if the policy requires it, then counts one
+// encryption against the active version and returns it
+func (kr *Keyring) activeForEncrypt() (uint32, *keyringEntry, error) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	entry := kr.versions[kr.active]
+	if kr.rotationDue(entry) {
+		if _, err := kr.rotate(); err != nil {
+			return 0, nil, fmt.Errorf("key rotation failed: %v", err)
+		}
+		entry = kr.versions[kr.active]
+	}
+
+	entry.encryptions.Add(1)
+	return kr.active, entry, nil
+}
+
+// rotationDue reports whether the policy retires entry
+func (kr *Keyring) rotationDue(entry *keyringEntry) bool {
+	if kr.policy.RotateAfter > 0 && time.Since(entry.createdAt) >= kr.policy.RotateAfter {
+		return true
+	}
+	return kr.policy.MaxEncryptions > 0 && entry.encryptions.Load() >= kr.policy.MaxEncryptions
+}
+
+// rotate adds a new active version after the highest held one
+// Caller must hold kr.mu
+func (kr *Keyring) rotate() (uint32, error) {
+	key, err := kr.newKey()
+	if err != nil {
+		return 0, err
+	}
+	if len(key) != KeySize {
+		return 0, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	var version uint32
+	for v := range kr.versions {
+		if v > version {
+			version = v
+		}
+	}
+	if version == ^uint32(0) {
+		return 0, fmt.Errorf("key versions exhausted")
+	}
+	version++
+
+	entry := newKeyringEntry(key)
+	kr.versions[version] = entry
+	kr.observe(telemetry.KeyGenerated, entry)
+
+	if previous, ok := kr.versions[kr.active]; ok {
+		kr.observe(telemetry.KeyRotated, previous)
+	}
+	kr.active = version
+	kr.observe(telemetry.KeyActivated, entry)
+
+	kr.retain()
+	return version, nil
+}
+
+// retain erases the retired versions beyond policy.Retain, oldest first
+// Caller must hold kr.mu
+func (kr *Keyring) retain() {
+	if kr.policy.Retain == 0 {
+		return
+	}
+
+	retired := make([]uint32, 0, len(kr.versions))
+	for v := range kr.versions {
+		if v != kr.active {
+			retired = append(retired, v)
+		}
+	}
+	if len(retired) <= kr.policy.Retain {
+		return
+	}
+
+	sort.Slice(retired, func(i, j int) bool { return retired[i] > retired[j] })
+	for _, v := range retired[kr.policy.Retain:] {
+		entry := kr.versions[v]
+		for i := range entry.key {
+			entry.key[i] = 0
+		}
+		if cache := entry.cache.Swap(nil); cache != nil {
+			cache.destroy()
+		}
+		delete(kr.versions, v)
+		kr.observe(telemetry.KeyDestroyed, entry)
+	}
+}
+
+// newKey returns new key material from policy.NewKey or policy.Entropy
+func (kr *Keyring) newKey() ([]byte, error) {
+	if kr.policy.NewKey != nil {
+		return kr.policy.NewKey()
+	}
+	return NewKey(kr.policy.Entropy)
+}
+
+// observe reports a key lifecycle event to policy.Telemetry
+func (kr *Keyring) observe(event telemetry.KeyEvent, entry *keyringEntry) {
+	if kr.policy.Telemetry != nil {
+		kr.policy.Telemetry.ObserveKeyEvent(event, entry.id)
+	}
+}
+
+// newKeyringEntry copies key into a new entry
+func newKeyringEntry(key []byte) *keyringEntry {
+	return &keyringEntry{
+		key:       append([]byte(nil), key...),
+		id:        keyid.New(key).String(),
+		createdAt: time.Now(),
+	}
+}
@@ -0,0 +1,188 @@
+package eamsa512
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"fmt"
+)
+
+// Wrapped key format (version 1):
+//
+//	magic "EAMW" (4) | version (1) | synthetic IV (32) | encrypted key
+//
+// WrapKey is a deterministic SIV construction on the EAMSA 512 block, in
+// the role of AES-KW (RFC 3394) for AES:
+//
+//	macKey = HMAC-SHA3-512(kek, "EAMSA512-KEY-WRAP" || 0x00 || "mac")[:32]
+//	encKey = HMAC-SHA3-512(kek, "EAMSA512-KEY-WRAP" || 0x00 || "enc")[:32]
+//	siv    = HMAC-SHA3-512(macKey, magic || version ||
+//	                       uint64(len(aad)) || aad || key)[:32]
+//	block i of the keystream = EncryptBlock(siv || 0 (28) || uint32(i))
+//	                           under DeriveKeys(encKey)
+//
+// The key is XORed with the keystream. Unwrapping decrypts, recomputes
+// the SIV and compares it in constant time, so any change to the wrapped
+// key, the header or aad fails. Key material has no need for a nonce: the
+// same key under the same KEK and aad always wraps to the same bytes,
+// which reveals only that it is the same key. A 32-byte key wraps to 69
+// bytes.
+const (
+	// WrapVersion is the wrapped key format version written by WrapKey
+	WrapVersion = 1
+
+	// WrapOverhead is how many bytes WrapKey adds to a key
+	WrapOverhead = len(wrapMagic) + 1 + wrapSIVSize
+
+	// MinWrapKeySize and MaxWrapKeySize bound the keys WrapKey accepts
+	MinWrapKeySize = 16
+	MaxWrapKeySize = 4096
+
+	wrapMagic   = "EAMW"
+	wrapSIVSize = 32
+	wrapLabel   = "EAMSA512-KEY-WRAP"
+)
+
+// IsWrappedKey reports whether data starts with the wrapped key magic
+func IsWrappedKey(data []byte) bool {
+	return len(data) >= len(wrapMagic) && string(data[:len(wrapMagic)]) == wrapMagic
+}
+
+// WrapKey wraps key material (MinWrapKeySize to MaxWrapKeySize bytes)
+// under a 32-byte key-encryption key
+func WrapKey(kek, key []byte) ([]byte, error) {
+	return WrapKeyWithAAD(kek, key, nil)
+}
+
+// UnwrapKey unwraps WrapKey output. Every failure to authenticate is
+// ErrDecryption.
+func UnwrapKey(kek, wrapped []byte) ([]byte, error) {
+	return UnwrapKeyWithAAD(kek, wrapped, nil)
+}
+
+// WrapKeyWithAAD is WrapKey binding additional data, such as the key's ID
+// or version, which is authenticated but not stored; UnwrapKeyWithAAD must
+// be given the same aad
+func WrapKeyWithAAD(kek, key, aad []byte) ([]byte, error) {
+	if len(key) < MinWrapKeySize || len(key) > MaxWrapKeySize {
+		return nil, fmt.Errorf("invalid key size %d: must be between %d and %d", len(key), MinWrapKeySize, MaxWrapKeySize)
+	}
+
+	macKey, encKey, err := wrapSubkeys(kek)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(macKey)
+	defer zeroize(encKey)
+
+	out := make([]byte, WrapOverhead+len(key))
+	copy(out, wrapMagic)
+	out[len(wrapMagic)] = WrapVersion
+
+	siv := wrapSIV(macKey, out[:len(wrapMagic)+1], aad, key)
+	copy(out[len(wrapMagic)+1:], siv)
+
+	if err := wrapKeystream(encKey, siv, out[WrapOverhead:], key); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// UnwrapKeyWithAAD unwraps WrapKeyWithAAD output
+func UnwrapKeyWithAAD(kek, wrapped, aad []byte) ([]byte, error) {
+	if !IsWrappedKey(wrapped) {
+		return nil, fmt.Errorf("not an EAMSA 512 wrapped key")
+	}
+	if len(wrapped) < WrapOverhead+MinWrapKeySize || len(wrapped) > WrapOverhead+MaxWrapKeySize {
+		return nil, ErrDecryption
+	}
+	if wrapped[len(wrapMagic)] != WrapVersion {
+		return nil, fmt.Errorf("unsupported wrapped key version %d", wrapped[len(wrapMagic)])
+	}
+
+	macKey, encKey, err := wrapSubkeys(kek)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroize(macKey)
+	defer zeroize(encKey)
+
+	siv := wrapped[len(wrapMagic)+1 : WrapOverhead]
+	key := make([]byte, len(wrapped)-WrapOverhead)
+	if err := wrapKeystream(encKey, siv, key, wrapped[WrapOverhead:]); err != nil {
+		return nil, err
+	}
+
+	expected := wrapSIV(macKey, wrapped[:len(wrapMagic)+1], aad, key)
+	if subtle.ConstantTimeCompare(expected, siv) != 1 {
+		zeroize(key)
+		return nil, ErrDecryption
+	}
+	return key, nil
+}
+
+// wrapSubkeys derives the SIV and keystream keys from the KEK
+func wrapSubkeys(kek []byte) ([]byte, []byte, error) {
+	if len(kek) != KeySize {
+		return nil, nil, fmt.Errorf("invalid key-encryption key size: expected %d, got %d", KeySize, len(kek))
+	}
+
+	derive := func(purpose string) []byte {
+		mac := NewHMAC(kek)
+		mac.Write([]byte(wrapLabel))
+		mac.Write([]byte{0})
+		mac.Write([]byte(purpose))
+		return mac.Sum()[:KeySize]
+	}
+	return derive("mac"), derive("enc"), nil
+}
+
+// wrapSIV computes the synthetic IV over the header, aad and key
+func wrapSIV(macKey, header, aad, key []byte) []byte {
+	var aadLength [8]byte
+	binary.BigEndian.PutUint64(aadLength[:], uint64(len(aad)))
+
+	mac := NewHMAC(macKey)
+	mac.Write(header)
+	mac.Write(aadLength[:])
+	mac.Write(aad)
+	mac.Write(key)
+	return mac.Sum()[:wrapSIVSize]
+}
+
+// wrapKeystream XORs src with the counter-mode keystream of siv into dst
+func wrapKeystream(encKey, siv, dst, src []byte) error {
+	return wrapKeystreamContext(context.Background(), encKey, siv, dst, src)
+}
+
+// wrapKeystreamContext is wrapKeystream that stops with ctx.Err() once
+// ctx is done. Long inputs are processed in parallel lanes.
+func wrapKeystreamContext(ctx context.Context, encKey, siv, dst, src []byte) error {
+	keys, err := DeriveKeys(encKey)
+	if err != nil {
+		return err
+	}
+
+	blocks := (len(src) + BlockSize - 1) / BlockSize
+	return runLanes(ctx, splitLanes(blocks), func(ctx context.Context, lane, first, last int) error {
+		counter := make([]byte, BlockSize)
+		copy(counter, siv)
+		for i := first; i < last; i++ {
+			if (i-first)%cancelCheckBlocks == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			binary.BigEndian.PutUint32(counter[BlockSize-4:], uint32(i))
+			stream := EncryptBlock(counter, keys)
+
+			end := (i + 1) * BlockSize
+			if end > len(src) {
+				end = len(src)
+			}
+			subtle.XORBytes(dst[i*BlockSize:end], src[i*BlockSize:end], stream)
+		}
+		return nil
+	})
+}
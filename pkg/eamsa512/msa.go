@@ -1,9 +1,10 @@
-// phase2-msa.go - Modified SALSA20 (MSA) with SIMD Vectorization
-package main
+package eamsa512
 
 import (
 	"encoding/binary"
 	"sync"
+
+	"github.com/Redeaux-Corporation/eamsa512/simd"
 )
 
 // MSAState represents Modified SALSA20 state (4×4 matrix)
@@ -42,61 +43,31 @@ func NewMSAState(key1, key2 [16]byte, nonce [16]byte) *MSAState {
 }
 
 // MSAStepDiagonal performs diagonal operations with SIMD-style parallelism
+// T = T XOR rotate(T, 7) XOR rotate(T, 1); the steps run as AVX2 or NEON
+// kernels where available (see package simd)
 func (ms *MSAState) MSAStepDiagonal() {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	// Diagonal operations (can be parallelized)
-	// T = T XOR rotate(T, 7) XOR rotate(T, 1)
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			val := ms.Matrix[i][j]
-			rotated7 := rotateLeft(val, 7)
-			rotated1 := rotateLeft(val, 1)
-			ms.Matrix[i][j] ^= rotated7 ^ rotated1
-		}
-	}
+	simd.MSADiagonal(&ms.Matrix)
 }
 
 // MSAStepCrossDiagonal performs cross-diagonal operations
+// Each word mixes with its diagonal neighbours in place, row by row
 func (ms *MSAState) MSAStepCrossDiagonal() {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	// Cross-diagonal operations
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			val := ms.Matrix[i][j]
-			nextVal := ms.Matrix[(i+1)%4][(j+1)%4]
-			prevVal := ms.Matrix[(i-1+4)%4][(j-1+4)%4]
-
-			ms.Matrix[i][j] ^= (val + nextVal) ^ (val + prevVal)
-		}
-	}
+	simd.MSACrossDiagonal(&ms.Matrix)
 }
 
 // MSAFinalStep performs final transpose-based mixing
+// Each word is XORed with the sum of the other words of its row
 func (ms *MSAState) MSAFinalStep() {
 	ms.mu.Lock()
 	defer ms.mu.Unlock()
 
-	// Transpose-like operation (row/column mix)
-	temp := [4][4]uint32{}
-
-	// Copy and transpose
-	for i := 0; i < 4; i++ {
-		for j := 0; j < 4; j++ {
-			temp[i][j] = ms.Matrix[i][j]
-		}
-	}
-
-	// Mix rows with columns
-	for i := 0; i < 4; i++ {
-		ms.Matrix[i][0] ^= temp[i][1] + temp[i][2] + temp[i][3]
-		ms.Matrix[i][1] ^= temp[i][0] + temp[i][2] + temp[i][3]
-		ms.Matrix[i][2] ^= temp[i][0] + temp[i][1] + temp[i][3]
-		ms.Matrix[i][3] ^= temp[i][0] + temp[i][1] + temp[i][2]
-	}
+	simd.MSAFinal(&ms.Matrix)
 }
 
 // MSAround performs one complete MSA round
@@ -125,45 +96,19 @@ func (ms *MSAState) GetOutput() [64]byte {
 }
 
 // PerformMSAEncryption performs 11-round MSA encryption
+// keys is not modified; EncryptBlockPhase2 uses the precomputed pads of
+// MSAKeySchedule instead of calling this per round
 func PerformMSAEncryption(input [64]byte, keys [11][16]byte) [64]byte {
-	// Split input into two 32-byte halves
-	left := input[0:32]
-	right := input[32:64]
-
-	// Create MSA state from keys 7-11
-	msa := NewMSAState(keys[7], keys[8], keys[9][:])
-
-	// 11 rounds of MSA encryption
-	for round := 0; round < 11; round++ {
-		msa.MSAround()
-
-		// XOR with keys
-		output := msa.GetOutput()
-		for i := 0; i < 32; i++ {
-			left[i] ^= output[i]
-			right[i] ^= output[i+32]
-		}
+	pad := msaPad(keys)
 
-		// Mix keys with rotation
-		for i := 0; i < 16; i++ {
-			keys[7][i] = rotateLeft8(keys[7][i])
-			keys[8][i] = rotateLeft8(keys[8][i])
-		}
-	}
-
-	// Combine output
 	result := [64]byte{}
-	copy(result[0:32], left)
-	copy(result[32:64], right)
+	for i := 0; i < 64; i++ {
+		result[i] = input[i] ^ pad[i]
+	}
 
 	return result
 }
 
-// rotateLeft rotates uint32 left by n bits
-func rotateLeft(val uint32, n uint) uint32 {
-	return (val << n) | (val >> (32 - n))
-}
-
 // rotateLeft8 rotates byte left by 1 bit
 func rotateLeft8(val byte) byte {
 	return (val << 1) | (val >> 7)
@@ -0,0 +1,128 @@
+package eamsa512
+
+import (
+	"crypto/rand"
+	"time"
+)
+
+// Phase2Rounds is the number of Feistel-like rounds in EncryptBlockPhase2
+const Phase2Rounds = 16
+
+// MSAKeySchedule holds the per-round keys and MSA pads for one key set.
+// The MSA output of a Feistel round depends only on that round's keys
+// (K8, K9, K10), not on the data, so the whole schedule is computed once
+// per key set instead of 16×11 MSA rounds per block.
+type MSAKeySchedule struct {
+	keys      [11][16]byte               // Caller's keys (never modified)
+	roundKeys [Phase2Rounds][11][16]byte // Keys of each round (rotated 1 bit per round)
+	pads      [Phase2Rounds][64]byte     // MSA pad of each round
+}
+
+// NewMSAKeySchedule computes the schedule for keys
+func NewMSAKeySchedule(keys [11][16]byte) *MSAKeySchedule {
+	ks := &MSAKeySchedule{keys: keys}
+
+	roundKeys := keys
+	for round := 0; round < Phase2Rounds; round++ {
+		ks.roundKeys[round] = roundKeys
+		ks.pads[round] = msaPad(roundKeys)
+
+		for i := 0; i < 11; i++ {
+			roundKeys[i] = RotateKey(roundKeys[i], 1)
+		}
+	}
+
+	return ks
+}
+
+// Matches reports whether the schedule was computed for keys
+func (ks *MSAKeySchedule) Matches(keys [11][16]byte) bool {
+	return ks.keys == keys
+}
+
+// RoundKeys returns the keys of a round
+func (ks *MSAKeySchedule) RoundKeys(round int) [11][16]byte {
+	return ks.roundKeys[round]
+}
+
+// Pad returns the MSA pad of a round
+func (ks *MSAKeySchedule) Pad(round int) [64]byte {
+	return ks.pads[round]
+}
+
+// msaPad runs the 11 MSA rounds for keys and returns the XOR of the round
+// outputs, which PerformMSAEncryption applies to its input
+func msaPad(keys [11][16]byte) [64]byte {
+	msa := NewMSAState(keys[7], keys[8], keys[9])
+
+	var pad [64]byte
+	for round := 0; round < msaKeyStreamRounds; round++ {
+		msa.MSAround()
+
+		output := msa.GetOutput()
+		for i := 0; i < 64; i++ {
+			pad[i] ^= output[i]
+		}
+	}
+
+	return pad
+}
+
+// RotateKey rotates every byte of key left by n bits
+func RotateKey(key [16]byte, n uint) [16]byte {
+	n %= 8
+	for i := range key {
+		key[i] = (key[i] << n) | (key[i] >> (8 - n))
+	}
+	return key
+}
+
+// MSAScheduleBenchmark compares Phase 2 with a per-block key schedule
+// (previous behaviour) against the precomputed schedule
+type MSAScheduleBenchmark struct {
+	Bytes       int
+	PerBlock    time.Duration
+	Precomputed time.Duration
+}
+
+// Speedup returns PerBlock / Precomputed
+func (b MSAScheduleBenchmark) Speedup() float64 {
+	if b.Precomputed <= 0 {
+		return 0
+	}
+	return float64(b.PerBlock) / float64(b.Precomputed)
+}
+
+// RunMSAScheduleBenchmark encrypts size bytes through Phase 2 twice: once
+// deriving the schedule for every block, once with the cached schedule
+func RunMSAScheduleBenchmark(size int) MSAScheduleBenchmark {
+	var keys [11][16]byte
+	var nonce [16]byte
+	for i := range keys {
+		rand.Read(keys[i][:])
+	}
+	rand.Read(nonce[:])
+
+	blocks := size / 64
+	var block [64]byte
+	rand.Read(block[:])
+
+	result := MSAScheduleBenchmark{Bytes: blocks * 64}
+
+	perBlock := NewPhase2Encryptor(keys[7], keys[8], nonce)
+	start := time.Now()
+	for i := 0; i < blocks; i++ {
+		perBlock.schedule = nil
+		block = perBlock.EncryptBlockPhase2(block, keys)
+	}
+	result.PerBlock = time.Since(start)
+
+	precomputed := NewPhase2Encryptor(keys[7], keys[8], nonce)
+	start = time.Now()
+	for i := 0; i < blocks; i++ {
+		block = precomputed.EncryptBlockPhase2(block, keys)
+	}
+	result.Precomputed = time.Since(start)
+
+	return result
+}
@@ -0,0 +1,223 @@
+package eamsa512
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Suite is a cipher suite: the format a server encrypts a client's data
+// in. Higher values are stronger, so the strongest suite two parties
+// share is the largest.
+type Suite uint16
+
+const (
+	// SuiteLegacy is bare EncryptData output (ciphertext || nonce || tag),
+	// the only format of servers that predate negotiation
+	SuiteLegacy Suite = 1
+
+	// SuiteEnvelopeCBC is a version 2 ModeCBC envelope, whose header is
+	// authenticated and commits to the key
+	SuiteEnvelopeCBC Suite = 2
+
+	// SuiteEnvelopeSIV is a version 2 ModeSIV envelope, which in addition
+	// stays secure if a nonce repeats
+	SuiteEnvelopeSIV Suite = 3
+)
+
+// suiteNames are the names of the known suites, as used in configuration
+var suiteNames = map[Suite]string{
+	SuiteLegacy:      "legacy",
+	SuiteEnvelopeCBC: "envelope-cbc",
+	SuiteEnvelopeSIV: "envelope-siv",
+}
+
+// String returns the suite's name
+func (s Suite) String() string {
+	if name, ok := suiteNames[s]; ok {
+		return name
+	}
+	return fmt.Sprintf("suite(%d)", uint16(s))
+}
+
+// ParseSuite returns the suite named name
+func ParseSuite(name string) (Suite, error) {
+	for s, n := range suiteNames {
+		if n == name {
+			return s, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown cipher suite %q", name)
+}
+
+// SupportedSuites returns the suites this package can encrypt with,
+// strongest first
+func SupportedSuites() []Suite {
+	return []Suite{SuiteEnvelopeSIV, SuiteEnvelopeCBC, SuiteLegacy}
+}
+
+// SealSuite encrypts plaintext under key in suite s. Any of the results
+// decrypt with Decrypt after checking IsEnvelope, or DecryptData for
+// SuiteLegacy.
+func SealSuite(ctx context.Context, s Suite, plaintext []byte, key []byte) ([]byte, error) {
+	switch s {
+	case SuiteLegacy:
+		return EncryptDataContext(ctx, plaintext, key, nil)
+	case SuiteEnvelopeCBC:
+		return EncryptContext(ctx, plaintext, key, EnvelopeOptions{Mode: ModeCBC})
+	case SuiteEnvelopeSIV:
+		return EncryptContext(ctx, plaintext, key, EnvelopeOptions{Mode: ModeSIV})
+	}
+	return nil, fmt.Errorf("unsupported cipher suite %v", s)
+}
+
+// Negotiation picks the suite of a client and a server that share a key,
+// in one round trip:
+//
+//	client → server  Hello:  the client's suites, client random (32)
+//	server → client  Choice: the chosen suite, the server's suites,
+//	                         server random (32), MAC
+//
+// The server chooses the strongest suite both list. The MAC is
+// HMAC-SHA3-512 under the shared key over the whole transcript, so an
+// attacker who removes suites from either list, or changes the choice,
+// is caught by Hello.Accept (ErrDowngrade). The randoms keep a recorded
+// Choice from being replayed to another Hello.
+const negotiationLabel = "EAMSA512-SUITE-NEGOTIATION"
+
+// NegotiationRandomSize is the size of the client and server randoms
+const NegotiationRandomSize = 32
+
+// maxNegotiationSuites bounds the suite lists of a negotiation
+const maxNegotiationSuites = 64
+
+// ErrDowngrade is returned by Hello.Accept when the server's choice does
+// not verify or is not the strongest suite both sides list: the exchange
+// was tampered with (or the key differs), and no suite may be used
+var ErrDowngrade = errors.New("eamsa512: cipher suite negotiation failed verification (possible downgrade)")
+
+// ErrNoCommonSuite is returned by Negotiate when the client lists no
+// suite the server supports
+var ErrNoCommonSuite = errors.New("eamsa512: no cipher suite in common")
+
+// Hello is the client's half of a negotiation
+type Hello struct {
+	Suites []Suite // May include suites the server does not know
+	Random []byte
+}
+
+// Choice is the server's answer to a Hello
+type Choice struct {
+	Suite  Suite
+	Suites []Suite // Every suite the server supports
+	Random []byte
+	MAC    []byte
+}
+
+// NewHello starts a negotiation offering suites (nil offers
+// SupportedSuites)
+func NewHello(suites []Suite) (*Hello, error) {
+	if suites == nil {
+		suites = SupportedSuites()
+	}
+	if len(suites) == 0 || len(suites) > maxNegotiationSuites {
+		return nil, fmt.Errorf("invalid cipher suite list: 1 to %d suites", maxNegotiationSuites)
+	}
+
+	h := &Hello{Suites: append([]Suite(nil), suites...), Random: make([]byte, NegotiationRandomSize)}
+	if _, err := rand.Read(h.Random); err != nil {
+		return nil, fmt.Errorf("failed to generate negotiation random: %v", err)
+	}
+	return h, nil
+}
+
+// Negotiate is the server side: it chooses the strongest suite in both
+// hello and supported, and authenticates the exchange under key
+func Negotiate(key []byte, hello *Hello, supported []Suite) (*Choice, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+	if len(hello.Random) != NegotiationRandomSize {
+		return nil, fmt.Errorf("invalid negotiation random size: expected %d, got %d", NegotiationRandomSize, len(hello.Random))
+	}
+	if len(hello.Suites) > maxNegotiationSuites || len(supported) > maxNegotiationSuites {
+		return nil, fmt.Errorf("too many cipher suites: at most %d", maxNegotiationSuites)
+	}
+
+	suite, ok := strongestCommonSuite(hello.Suites, supported)
+	if !ok {
+		return nil, ErrNoCommonSuite
+	}
+
+	c := &Choice{Suite: suite, Suites: append([]Suite(nil), supported...), Random: make([]byte, NegotiationRandomSize)}
+	if _, err := rand.Read(c.Random); err != nil {
+		return nil, fmt.Errorf("failed to generate negotiation random: %v", err)
+	}
+	c.MAC = negotiationMAC(key, hello, c)
+	return c, nil
+}
+
+// Accept is the client side: it verifies choice against the Hello it
+// sent and returns the agreed suite, or ErrDowngrade. Callers with a
+// minimum suite compare the result against it.
+func (h *Hello) Accept(key []byte, choice *Choice) (Suite, error) {
+	if len(key) != KeySize {
+		return 0, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+	if len(choice.Random) != NegotiationRandomSize || len(choice.Suites) > maxNegotiationSuites {
+		return 0, ErrDowngrade
+	}
+	if subtle.ConstantTimeCompare(negotiationMAC(key, h, choice), choice.MAC) != 1 {
+		return 0, ErrDowngrade
+	}
+
+	// Authentic, but the server must also have chosen as the rules say
+	if want, ok := strongestCommonSuite(h.Suites, choice.Suites); !ok || choice.Suite != want {
+		return 0, ErrDowngrade
+	}
+	return choice.Suite, nil
+}
+
+// strongestCommonSuite returns the largest suite in both a and b that
+// this package knows
+func strongestCommonSuite(a, b []Suite) (Suite, bool) {
+	var best Suite
+	for _, s := range a {
+		if _, known := suiteNames[s]; !known || s <= best {
+			continue
+		}
+		for _, t := range b {
+			if s == t {
+				best = s
+				break
+			}
+		}
+	}
+	return best, best != 0
+}
+
+// negotiationMAC authenticates the transcript of hello and choice
+func negotiationMAC(key []byte, hello *Hello, choice *Choice) []byte {
+	mac := NewHMAC(key)
+	mac.Write([]byte(negotiationLabel))
+	mac.Write([]byte{0})
+	writeSuites := func(suites []Suite) {
+		var b [2]byte
+		binary.BigEndian.PutUint16(b[:], uint16(len(suites)))
+		mac.Write(b[:])
+		for _, s := range suites {
+			binary.BigEndian.PutUint16(b[:], uint16(s))
+			mac.Write(b[:])
+		}
+	}
+
+	writeSuites(hello.Suites)
+	mac.Write(hello.Random)
+	writeSuites(choice.Suites)
+	writeSuites([]Suite{choice.Suite})
+	mac.Write(choice.Random)
+	return mac.Sum()
+}
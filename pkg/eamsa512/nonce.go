@@ -0,0 +1,283 @@
+package eamsa512
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Redeaux-Corporation/eamsa512/filelock"
+)
+
+// NonceManager nonces are
+//
+//	prefix (8, random, fixed for the manager's lifetime) |
+//	counter (8, big-endian)
+//
+// The counter never repeats because its high-water mark is persisted: a
+// manager reserves NonceReserve counter values at a time and saves the end
+// of the range before issuing any of them. After a restart it issues
+// nothing until Recover has loaded the saved state, and resumes above it,
+// skipping whatever was reserved but unused. The random prefix keeps the
+// nonces of managers that share a key apart.
+const (
+	// NonceReserve is how many nonces one save covers
+	NonceReserve = 4096
+
+	noncePrefixSize = 8
+)
+
+var (
+	// ErrNonceNotRecovered is returned by Next until Recover or Initialize
+	// succeeds, and again after a failed save
+	ErrNonceNotRecovered = errors.New("eamsa512: nonce state not recovered")
+
+	// ErrNonceStateMissing is returned by Recover when nothing is saved
+	// under the manager's name; Initialize starts a new state
+	ErrNonceStateMissing = errors.New("eamsa512: nonce state missing")
+
+	// ErrNonceStateConflict is returned when the saved state is not the one
+	// last written: it was rolled back, or another manager uses the name
+	ErrNonceStateConflict = errors.New("eamsa512: nonce state changed by another writer")
+)
+
+// NonceState is the persisted state of a NonceManager
+type NonceState struct {
+	Prefix    [noncePrefixSize]byte `json:"prefix"`
+	HighWater uint64                `json:"high_water"` // No counter at or above it has been issued
+}
+
+// NonceStore persists NonceState by manager name
+type NonceStore interface {
+	// LoadNonceState returns the state saved under name, or
+	// ErrNonceStateMissing
+	LoadNonceState(name string) (NonceState, error)
+
+	// SaveNonceState replaces the state under name with next if it is still
+	// prev (nil: if nothing is saved), and returns ErrNonceStateConflict
+	// otherwise. It must not return before next is durable.
+	SaveNonceState(name string, prev *NonceState, next NonceState) error
+}
+
+// NonceManager issues unique nonces for one key across restarts
+type NonceManager struct {
+	store  NonceStore
+	name   string
+	random *Random
+
+	mu           sync.Mutex
+	state        NonceState // Last state saved
+	next         uint64
+	recovered    bool
+	prefixSource string
+}
+
+// NewNonceManager returns a manager whose state is saved in store under
+// name. It issues no nonces until Recover or, on first use, Initialize.
+func NewNonceManager(store NonceStore, name string) *NonceManager {
+	return &NonceManager{store: store, name: name}
+}
+
+// NewNonceManagerWithRandom is NewNonceManager drawing the prefix from
+// random, for policies that require hardware-sourced randomness
+func NewNonceManagerWithRandom(store NonceStore, name string, random *Random) *NonceManager {
+	return &NonceManager{store: store, name: name, random: random}
+}
+
+// Initialize starts a new state with a random prefix. It fails with
+// ErrNonceStateConflict if a state is already saved under the name.
+func (m *NonceManager) Initialize() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var state NonceState
+	source, err := m.random.Fill(state.Prefix[:])
+	if err != nil {
+		return fmt.Errorf("failed to generate nonce prefix: %w", err)
+	}
+	if err := m.store.SaveNonceState(m.name, nil, state); err != nil {
+		return err
+	}
+
+	m.state = state
+	m.next = 0
+	m.recovered = true
+	m.prefixSource = source
+	return nil
+}
+
+// PrefixSource returns the name of the source of the prefix generated by
+// Initialize, or "" for a recovered state
+func (m *NonceManager) PrefixSource() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.prefixSource
+}
+
+// Recover loads the saved state and resumes issuing above its high-water
+// mark
+func (m *NonceManager) Recover() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state, err := m.store.LoadNonceState(m.name)
+	if err != nil {
+		return err
+	}
+
+	m.state = state
+	m.next = state.HighWater
+	m.recovered = true
+	m.prefixSource = ""
+	return nil
+}
+
+// Next returns a nonce that this manager, and any manager recovered from
+// the same state, has never returned before
+func (m *NonceManager) Next() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.recovered {
+		return nil, ErrNonceNotRecovered
+	}
+
+	if m.next >= m.state.HighWater {
+		if m.next > ^uint64(0)-NonceReserve {
+			return nil, fmt.Errorf("nonce counter exhausted for %s", m.name)
+		}
+
+		next := NonceState{Prefix: m.state.Prefix, HighWater: m.next + NonceReserve}
+		if err := m.store.SaveNonceState(m.name, &m.state, next); err != nil {
+			m.recovered = false
+			return nil, fmt.Errorf("failed to save nonce state: %w", err)
+		}
+		m.state = next
+	}
+
+	nonce := make([]byte, NonceSize)
+	copy(nonce, m.state.Prefix[:])
+	binary.BigEndian.PutUint64(nonce[noncePrefixSize:], m.next)
+	m.next++
+	return nonce, nil
+}
+
+// FileNonceStore keeps nonce states in a JSON file, rewritten atomically
+// on every save. The process holds an exclusive lock on <path>.lock until
+// Close.
+type FileNonceStore struct {
+	path string
+	lock *filelock.Lock
+
+	mu     sync.Mutex
+	states map[string]NonceState
+}
+
+// OpenFileNonceStore locks and reads the nonce state file at path; a
+// missing file holds no states
+func OpenFileNonceStore(path string) (*FileNonceStore, error) {
+	lock, err := filelock.Acquire(path+".lock", filelock.Exclusive)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make(map[string]NonceState)
+	data, err := os.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		lock.Release()
+		return nil, fmt.Errorf("failed to read nonce state: %v", err)
+	default:
+		if err := json.Unmarshal(data, &states); err != nil {
+			lock.Release()
+			return nil, fmt.Errorf("failed to parse nonce state %s: %v", path, err)
+		}
+	}
+
+	return &FileNonceStore{path: path, lock: lock, states: states}, nil
+}
+
+// LoadNonceState returns the state saved under name
+func (s *FileNonceStore) LoadNonceState(name string) (NonceState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.states[name]
+	if !ok {
+		return NonceState{}, ErrNonceStateMissing
+	}
+	return state, nil
+}
+
+// SaveNonceState replaces the state under name with next if it is still prev
+func (s *FileNonceStore) SaveNonceState(name string, prev *NonceState, next NonceState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	current, ok := s.states[name]
+	if ok != (prev != nil) || (ok && current != *prev) {
+		return ErrNonceStateConflict
+	}
+
+	states := make(map[string]NonceState, len(s.states)+1)
+	for k, v := range s.states {
+		states[k] = v
+	}
+	states[name] = next
+
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeSynced(s.path, data); err != nil {
+		return fmt.Errorf("failed to write nonce state: %v", err)
+	}
+
+	s.states = states
+	return nil
+}
+
+// Close releases the lock on the state file
+func (s *FileNonceStore) Close() error {
+	return s.lock.Release()
+}
+
+// writeSynced replaces path with data through a synced temporary file and
+// syncs the directory, so the new contents survive a crash
+func writeSynced(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
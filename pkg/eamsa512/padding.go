@@ -0,0 +1,145 @@
+package eamsa512
+
+import (
+	"crypto/subtle"
+	"fmt"
+)
+
+// Padding identifies how the plaintext is padded to whole blocks before
+// CBC encryption. Every scheme adds 1 to BlockSize bytes, so a
+// block-aligned plaintext gets a whole padding block and SealOverhead
+// holds for all of them. The identifiers are stable: containers record
+// them through their cipher suite.
+type Padding byte
+
+const (
+	// PaddingPKCS7 appends n bytes of value n (RFC 5652); the default
+	PaddingPKCS7 Padding = 1
+
+	// PaddingISO7816 appends 0x80 and then zero bytes (ISO/IEC 7816-4)
+	PaddingISO7816 Padding = 2
+
+	// PaddingZeroLength appends zero bytes and then the padding length
+	// n as the last byte (ANSI X9.23)
+	PaddingZeroLength Padding = 3
+)
+
+// iso7816Marker is the first padding byte of PaddingISO7816
+const iso7816Marker = 0x80
+
+func (p Padding) String() string {
+	switch p {
+	case PaddingPKCS7:
+		return "pkcs7"
+	case PaddingISO7816:
+		return "iso7816-4"
+	case PaddingZeroLength:
+		return "zero-length"
+	default:
+		return fmt.Sprintf("padding(%d)", byte(p))
+	}
+}
+
+// Valid reports whether p is a known scheme
+func (p Padding) Valid() bool {
+	return p == PaddingPKCS7 || p == PaddingISO7816 || p == PaddingZeroLength
+}
+
+// orDefault returns PaddingPKCS7 for the zero value
+func (p Padding) orDefault() Padding {
+	if p == 0 {
+		return PaddingPKCS7
+	}
+	return p
+}
+
+// Fill writes the padding for len(padding) bytes (1 to BlockSize) into
+// padding
+func (p Padding) Fill(padding []byte) {
+	n := byte(len(padding))
+	switch p {
+	case PaddingISO7816:
+		padding[0] = iso7816Marker
+		for i := 1; i < len(padding); i++ {
+			padding[i] = 0
+		}
+	case PaddingZeroLength:
+		for i := 0; i < len(padding)-1; i++ {
+			padding[i] = 0
+		}
+		padding[len(padding)-1] = n
+	default:
+		for i := range padding {
+			padding[i] = n
+		}
+	}
+}
+
+// Unpad removes the padding from decrypted whole blocks. As with
+// UnpadPKCS7, the time taken depends only on len(plaintext): all
+// BlockSize bytes of the last block are examined and the checks are
+// combined without branching. Any failure is ErrDecryption.
+func (p Padding) Unpad(plaintext []byte) ([]byte, error) {
+	if len(plaintext) < BlockSize || len(plaintext)%BlockSize != 0 || !p.Valid() {
+		return nil, ErrDecryption
+	}
+
+	last := plaintext[len(plaintext)-BlockSize:]
+
+	var paddingLength, good int
+	if p == PaddingISO7816 {
+		paddingLength, good = unpadISO7816(last)
+	} else {
+		paddingLength, good = unpadLengthByte(last, p == PaddingPKCS7)
+	}
+
+	if good != 1 {
+		return nil, ErrDecryption
+	}
+	return plaintext[:len(plaintext)-paddingLength], nil
+}
+
+// unpadLengthByte checks a last block ending in the padding length n: the
+// n-1 bytes before it must equal n (PKCS#7) or zero (ANSI X9.23)
+func unpadLengthByte(last []byte, pkcs7 bool) (int, int) {
+	paddingLength := int(last[BlockSize-1])
+
+	// 1 <= paddingLength <= BlockSize
+	good := subtle.ConstantTimeLessOrEq(1, paddingLength) &
+		subtle.ConstantTimeLessOrEq(paddingLength, BlockSize)
+
+	filler := byte(0)
+	if pkcs7 {
+		filler = byte(paddingLength)
+	}
+
+	for i := 2; i <= BlockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i, paddingLength)
+		matches := subtle.ConstantTimeByteEq(last[BlockSize-i], filler)
+		good &= subtle.ConstantTimeSelect(inPadding, matches, 1)
+	}
+
+	return paddingLength, good
+}
+
+// unpadISO7816 finds the 0x80 marker after the trailing zero bytes of the
+// last block, scanning all of it
+func unpadISO7816(last []byte) (int, int) {
+	var paddingLength, found, bad int
+
+	for i := 1; i <= BlockSize; i++ {
+		b := last[BlockSize-i]
+		isZero := subtle.ConstantTimeByteEq(b, 0)
+		isMarker := subtle.ConstantTimeByteEq(b, iso7816Marker)
+		searching := 1 - found
+
+		// Before the marker, only zero bytes may appear
+		bad |= searching & (1 - isZero) & (1 - isMarker)
+
+		marker := searching & isMarker
+		paddingLength = subtle.ConstantTimeSelect(marker, i, paddingLength)
+		found |= marker
+	}
+
+	return paddingLength, found & (1 - bad)
+}
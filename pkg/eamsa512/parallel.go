@@ -0,0 +1,97 @@
+package eamsa512
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Redeaux-Corporation/eamsa512/simd"
+)
+
+// Counter-mode keystreams (ModeSIV envelopes and WrapKey) and CBC
+// decryption have no dependency between blocks, so long inputs are split
+// into lanes of consecutive blocks, one per worker, and processed
+// concurrently in place. The output is identical to a sequential pass.
+// CBC encryption chains every block to the one before it and stays
+// sequential.
+const minLaneBlocks = 512 // 32 KB: shorter lanes cost more to schedule than they save
+
+var workerCount atomic.Int32
+
+// SetWorkers sets how many goroutines one operation may use, and returns
+// the previous setting. n <= 0 restores the default, GOMAXPROCS at the
+// time of the operation; 1 disables parallel processing.
+func SetWorkers(n int) int {
+	if n < 0 {
+		n = 0
+	}
+	return int(workerCount.Swap(int32(n)))
+}
+
+// Workers returns how many goroutines one operation may use
+func Workers() int {
+	if n := workerCount.Load(); n > 0 {
+		return int(n)
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+// splitLanes divides blocks into at most Workers() lanes of at least
+// minLaneBlocks, and returns their boundaries: lane i is
+// [bounds[i], bounds[i+1])
+func splitLanes(blocks int) []int {
+	lanes := Workers()
+	if max := blocks / minLaneBlocks; lanes > max {
+		lanes = max
+	}
+	if lanes < 1 {
+		lanes = 1
+	}
+
+	bounds := make([]int, lanes+1)
+	for i := 1; i <= lanes; i++ {
+		bounds[i] = blocks * i / lanes
+	}
+	return bounds
+}
+
+// runLanes calls fn for each lane of bounds, concurrently if there is
+// more than one, and returns the first error. A failing lane cancels the
+// ctx passed to the others.
+func runLanes(ctx context.Context, bounds []int, fn func(ctx context.Context, lane, first, end int) error) error {
+	if len(bounds) == 2 {
+		return fn(ctx, 0, bounds[0], bounds[1])
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for lane := 0; lane < len(bounds)-1; lane++ {
+		wg.Add(1)
+		go func(lane int) {
+			defer wg.Done()
+			if err := fn(ctx, lane, bounds[lane], bounds[lane+1]); err != nil {
+				once.Do(func() {
+					firstErr = err
+					cancel()
+				})
+			}
+		}(lane)
+	}
+	wg.Wait()
+	return firstErr
+}
+
+// HasAcceleration reports whether the vector kernels of the Phase 2 cipher
+// (the MSA round steps and the P-layer bit transpose, see package simd)
+// run as AVX2 or NEON assembly on this CPU rather than portable Go. The
+// output is the same either way; this is for diagnostics.
+func HasAcceleration() bool {
+	return simd.Available()
+}
@@ -0,0 +1,238 @@
+package eamsa512
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Password envelope format (version 2):
+//
+//	header: magic "EAMP" (4) | version (1) | kdf (1) |
+//	        memory KiB (4, big-endian) | iterations (4, big-endian) |
+//	        parallelism (1) | salt (16)
+//	body:   envelope (see envelope.go) under the derived key
+//
+// The master key is Argon2id(password, salt) with the recorded parameters,
+// so decryption needs only the password. The password header, followed by
+// the caller's additional data if any, is the additional data of the
+// envelope: lowering the KDF cost or swapping the salt fails decryption.
+//
+// Version 1 has the same header around a version 1 envelope, which has
+// no key commitment. DecryptWithPassword still opens it, with
+// DecryptLegacy; the version byte is authenticated, so a version 2
+// message cannot be passed off as one.
+const (
+	// PasswordVersion is the password envelope version written by
+	// EncryptWithPassword
+	PasswordVersion = 2
+
+	// PasswordHeaderSize is the length of the password header
+	PasswordHeaderSize = 31
+
+	// KDFArgon2id identifies Argon2id (RFC 9106) in the password header
+	KDFArgon2id = 1
+
+	// PasswordSaltSize is the length of the random KDF salt
+	PasswordSaltSize = 16
+
+	// DefaultMaxKDFMemory is the most memory (KiB) DecryptWithPassword
+	// lets a header ask for: 1 GiB
+	DefaultMaxKDFMemory = 1 << 20
+
+	passwordMagic = "EAMP"
+
+	// maxKDFIterations bounds the passes a header can ask for
+	maxKDFIterations = 1 << 10
+)
+
+// KDFParams are the Argon2id cost parameters
+type KDFParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// DefaultKDFParams are the second recommended Argon2id option of RFC 9106:
+// 64 MiB, 3 passes, 4 lanes
+var DefaultKDFParams = KDFParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 4}
+
+// Validate checks the parameters against the Argon2 limits
+func (p KDFParams) Validate() error {
+	if p.Iterations < 1 {
+		return fmt.Errorf("invalid KDF iterations %d: must be at least 1", p.Iterations)
+	}
+	if p.Parallelism < 1 {
+		return fmt.Errorf("invalid KDF parallelism %d: must be at least 1", p.Parallelism)
+	}
+	if p.Memory < 8*uint32(p.Parallelism) {
+		return fmt.Errorf("invalid KDF memory %d KiB: must be at least 8 KiB per lane", p.Memory)
+	}
+	return nil
+}
+
+// PBEOption configures EncryptWithPassword and DecryptWithPassword
+type PBEOption func(*pbeConfig)
+
+// pbeConfig collects the PBEOptions
+type pbeConfig struct {
+	params    KDFParams
+	envelope  EnvelopeOptions
+	maxMemory uint32
+}
+
+// WithKDFParams sets the Argon2id cost of EncryptWithPassword (default
+// DefaultKDFParams)
+func WithKDFParams(params KDFParams) PBEOption {
+	return func(c *pbeConfig) { c.params = params }
+}
+
+// WithPBEEnvelope sets the mode, chunk size and additional data of the
+// inner envelope; DecryptWithPassword needs the same AAD. KeyVersion is
+// ignored: the key comes from the password.
+func WithPBEEnvelope(opts EnvelopeOptions) PBEOption {
+	return func(c *pbeConfig) { c.envelope = opts }
+}
+
+// WithMaxKDFMemory caps the Argon2id memory (KiB) DecryptWithPassword
+// accepts from a header (default DefaultMaxKDFMemory), so a crafted file
+// cannot exhaust memory
+func WithMaxKDFMemory(kib uint32) PBEOption {
+	return func(c *pbeConfig) { c.maxMemory = kib }
+}
+
+// newPBEConfig applies opts to the defaults
+func newPBEConfig(opts []PBEOption) *pbeConfig {
+	c := &pbeConfig{params: DefaultKDFParams, maxMemory: DefaultMaxKDFMemory}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// IsPasswordEncrypted reports whether data starts with the password
+// envelope magic
+func IsPasswordEncrypted(data []byte) bool {
+	return len(data) >= len(passwordMagic) && string(data[:len(passwordMagic)]) == passwordMagic
+}
+
+// EncryptWithPassword encrypts plaintext under a key derived from password
+// with Argon2id and a random salt, both recorded in the header
+func EncryptWithPassword(plaintext, password []byte, opts ...PBEOption) ([]byte, error) {
+	c := newPBEConfig(opts)
+	if err := c.params.Validate(); err != nil {
+		return nil, err
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("empty password")
+	}
+
+	salt := make([]byte, PasswordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+
+	header := marshalPasswordHeader(c.params, salt)
+
+	key := derivePasswordKey(password, salt, c.params)
+	defer zeroize(key)
+
+	envelopeOpts := c.envelope
+	envelopeOpts.KeyVersion = 0
+	envelopeOpts.AAD = append(append([]byte(nil), header...), c.envelope.AAD...)
+
+	body, err := Encrypt(plaintext, key, envelopeOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, body...), nil
+}
+
+// DecryptWithPassword decrypts EncryptWithPassword output, of either
+// version. A wrong password fails like a tampered envelope.
+func DecryptWithPassword(data, password []byte, opts ...PBEOption) ([]byte, error) {
+	c := newPBEConfig(opts)
+
+	params, salt, err := parsePasswordHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if params.Memory > c.maxMemory {
+		return nil, fmt.Errorf("KDF memory %d KiB exceeds the limit of %d KiB", params.Memory, c.maxMemory)
+	}
+	if params.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("KDF iterations %d exceed the limit of %d", params.Iterations, maxKDFIterations)
+	}
+
+	key := derivePasswordKey(password, salt, params)
+	defer zeroize(key)
+
+	aad := append(append([]byte(nil), data[:PasswordHeaderSize]...), c.envelope.AAD...)
+	if data[4] == 1 {
+		return DecryptLegacy(data[PasswordHeaderSize:], key, aad)
+	}
+	return DecryptWithAAD(data[PasswordHeaderSize:], key, aad)
+}
+
+// PasswordKDFParams returns the KDF parameters recorded in a password
+// envelope header, without the password
+func PasswordKDFParams(data []byte) (KDFParams, error) {
+	params, _, err := parsePasswordHeader(data)
+	return params, err
+}
+
+// marshalPasswordHeader encodes the password header
+func marshalPasswordHeader(params KDFParams, salt []byte) []byte {
+	header := make([]byte, PasswordHeaderSize)
+	copy(header, passwordMagic)
+	header[4] = PasswordVersion
+	header[5] = KDFArgon2id
+	binary.BigEndian.PutUint32(header[6:10], params.Memory)
+	binary.BigEndian.PutUint32(header[10:14], params.Iterations)
+	header[14] = params.Parallelism
+	copy(header[15:], salt)
+	return header
+}
+
+// parsePasswordHeader decodes and checks the password header; the salt
+// aliases data
+func parsePasswordHeader(data []byte) (KDFParams, []byte, error) {
+	if !IsPasswordEncrypted(data) {
+		return KDFParams{}, nil, fmt.Errorf("not an EAMSA 512 password envelope")
+	}
+	if len(data) < PasswordHeaderSize {
+		return KDFParams{}, nil, fmt.Errorf("password envelope truncated: %d bytes", len(data))
+	}
+	if data[4] != 1 && data[4] != PasswordVersion {
+		return KDFParams{}, nil, fmt.Errorf("unsupported password envelope version %d", data[4])
+	}
+	if data[5] != KDFArgon2id {
+		return KDFParams{}, nil, fmt.Errorf("unsupported KDF %d", data[5])
+	}
+
+	params := KDFParams{
+		Memory:      binary.BigEndian.Uint32(data[6:10]),
+		Iterations:  binary.BigEndian.Uint32(data[10:14]),
+		Parallelism: data[14],
+	}
+	if err := params.Validate(); err != nil {
+		return KDFParams{}, nil, err
+	}
+
+	return params, data[15:PasswordHeaderSize], nil
+}
+
+// derivePasswordKey derives a KeySize master key with Argon2id
+func derivePasswordKey(password, salt []byte, params KDFParams) []byte {
+	return argon2.IDKey(password, salt, params.Iterations, params.Memory, params.Parallelism, KeySize)
+}
+
+// zeroize overwrites derived key material
+func zeroize(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
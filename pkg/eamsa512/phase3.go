@@ -0,0 +1,629 @@
+package eamsa512
+
+import (
+	"crypto/hmac"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+	"github.com/Redeaux-Corporation/eamsa512/format"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/kdf"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// CipherResultSHA3 holds encryption result with SHA3-512 MAC
+type CipherResultSHA3 struct {
+	Ciphertext [64]byte // 512-bit encrypted data
+	MAC        [64]byte // 512-bit authentication tag (HMAC-SHA3-512)
+	Nonce      [16]byte // Block-specific nonce
+	Counter    uint64   // Block sequence number
+	Valid      bool     // MAC verification flag
+}
+
+// EAMSA512ConfigSHA3 defines configuration with SHA3-512
+type EAMSA512ConfigSHA3 struct {
+	MasterKey        [32]byte  // 256-bit primary key
+	Nonce            [16]byte  // 128-bit unique nonce
+	AuthKey          [32]byte  // 256-bit split-trust MAC key (optional, zero: derived from MasterKey)
+	RoundCount       int       // Encryption rounds (default 16)
+	IncludeAuth      bool      // Enable MAC verification
+	AuthAlgorithm    string    // "HMAC-SHA3-512"
+	Mode             string    // "CBC", "CTR"; "ECB" only with AllowInsecureModes
+	AllowInsecureModes bool    // Accept ECB (known-answer tests only; audited)
+	Telemetry        telemetry.Telemetry // Operation observer (nil: no-op)
+	Counters         CounterStore        // Persisted counter marks (nil: counters start at zero)
+	CounterInit      bool                // Create missing counter marks (first use of the key and nonce only)
+}
+
+// insecureModes are accepted only with AllowInsecureModes: ECB leaks
+// equal plaintext blocks as equal ciphertext blocks
+var insecureModes = map[string]bool{"ECB": true}
+
+// EAMSA512CipherSHA3 is the main production cipher with SHA3-512
+type EAMSA512CipherSHA3 struct {
+	Phase2Encryptor    *Phase2Encryptor
+	AuthKeyMaterial    [64]byte // Auth key (SHA3-512 derived)
+	AuthCounter        uint64   // MAC counter
+	EncryptionCounter  uint64   // Block counter
+	Mode               string
+	RoundCount         int
+	SplitTrust         bool     // MAC key is AuthKey, not derived from MasterKey
+	keys               [11][16]byte // Phase 1 keys (pkg/kdf)
+	masterKey          [32]byte     // Session secret, for ExportKeyingMaterial
+	nonce              [16]byte
+	telemetry          telemetry.Telemetry
+	counters           CounterStore
+	counterID          string
+	counterMarks       CounterMarks // Last marks written to counters
+	counterErr         error        // Set once counters cannot be trusted; encryption stops
+	stats              CipherStats  // Updated without mu
+	mu                 sync.RWMutex
+}
+
+// NewEAMSA512CipherSHA3 creates new production cipher. An insecure mode
+// (ECB) is refused unless config.AllowInsecureModes is set.
+func NewEAMSA512CipherSHA3(config *EAMSA512ConfigSHA3) (*EAMSA512CipherSHA3, error) {
+	if insecureModes[config.Mode] {
+		if !config.AllowInsecureModes {
+			log.Printf("[AUDIT] INSECURE_MODE_REFUSED - mode=%s - AllowInsecureModes not set\n", config.Mode)
+			return nil, fmt.Errorf("mode %s is insecure: set AllowInsecureModes to use it", config.Mode)
+		}
+		log.Printf("[AUDIT] INSECURE_MODE_SELECTED - mode=%s allowed=%t - WARNING\n",
+			config.Mode, config.AllowInsecureModes)
+	}
+
+	// Phase 1: Derive the 11 keys with the SP 800-56A KDF
+	keys, err := kdf.New().DeriveKeys(config.MasterKey, config.Nonce, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Phase 2: Create encryptor
+	phase2 := NewPhase2Encryptor(keys[7], keys[8], config.Nonce)
+
+	// Phase 3: Derive auth key material using SHA3-512; a separately held
+	// AuthKey (split trust) replaces the master-key-derived material
+	authKeyMaterial := deriveBlockAuthKey(config.MasterKey, config.Nonce)
+	splitTrust := config.AuthKey != [32]byte{}
+	if splitTrust {
+		authKeyMaterial = deriveSplitAuthKey(config.AuthKey)
+	}
+
+	cipher := &EAMSA512CipherSHA3{
+		Phase2Encryptor:   phase2,
+		AuthKeyMaterial:   authKeyMaterial,
+		AuthCounter:       0,
+		EncryptionCounter: 0,
+		Mode:              config.Mode,
+		RoundCount:        config.RoundCount,
+		SplitTrust:        splitTrust,
+		keys:              keys,
+		masterKey:         config.MasterKey,
+		nonce:             config.Nonce,
+		telemetry:         telemetry.OrNop(config.Telemetry),
+	}
+
+	if config.Counters != nil {
+		cipher.restoreCounters(config.Counters, CounterID(config.MasterKey, config.Nonce), config.CounterInit)
+	}
+
+	return cipher, nil
+}
+
+// restoreCounters resumes the counters from their persisted marks. If the
+// marks are missing (and init is not set) or cannot be read, encryption is
+// refused; see CounterError.
+func (cipher *EAMSA512CipherSHA3) restoreCounters(store CounterStore, id string, init bool) {
+	cipher.counters = store
+	cipher.counterID = id
+
+	marks, err := store.LoadCounters(id)
+	if errors.Is(err, ErrCounterMarkerMissing) && init {
+		if err = store.CreateCounters(id); err == nil {
+			marks, err = store.LoadCounters(id)
+		}
+	}
+	if err != nil {
+		cipher.failCounters(fmt.Errorf("failed to restore counters: %w", err))
+		return
+	}
+
+	cipher.EncryptionCounter = marks.Encryption
+	cipher.AuthCounter = marks.Auth
+	cipher.counterMarks = marks
+}
+
+// reserveCounters makes sure the next counter values are covered by a
+// persisted mark, reserving a new range when the current one is used up;
+// cipher.mu must be held
+func (cipher *EAMSA512CipherSHA3) reserveCounters() error {
+	if cipher.counters == nil {
+		return nil
+	}
+	if cipher.counterErr != nil {
+		return cipher.counterErr
+	}
+	if cipher.EncryptionCounter < cipher.counterMarks.Encryption && cipher.AuthCounter < cipher.counterMarks.Auth {
+		return nil
+	}
+
+	next := CounterMarks{
+		Encryption: cipher.EncryptionCounter + counterReserve,
+		Auth:       cipher.AuthCounter + counterReserve,
+	}
+	if err := cipher.counters.AdvanceCounters(cipher.counterID, cipher.counterMarks, next); err != nil {
+		cipher.failCounters(fmt.Errorf("failed to reserve counters: %w", err))
+		return cipher.counterErr
+	}
+	cipher.counterMarks = next
+	return nil
+}
+
+// failCounters stops encryption after a counter persistence failure
+func (cipher *EAMSA512CipherSHA3) failCounters(err error) {
+	cipher.counterErr = err
+	log.Printf("[AUDIT] COUNTER_PERSISTENCE_FAILED - counter_id=%s error=%q - encryption refused\n",
+		cipher.counterID, err)
+}
+
+// CounterError returns why encryption is refused, or nil. It is sticky:
+// a cipher whose counters cannot be trusted stays refused until it is
+// recreated from a consistent counter store.
+func (cipher *EAMSA512CipherSHA3) CounterError() error {
+	cipher.mu.RLock()
+	defer cipher.mu.RUnlock()
+
+	return cipher.counterErr
+}
+
+// blockAuthLabel separates the block MAC key from the Phase 1 keys, which
+// hash the same master key and nonce
+const blockAuthLabel = "EAMSA512-BLOCK-AUTH"
+
+// deriveBlockAuthKey derives MAC key material from the master key:
+// SHA3-512("EAMSA512-BLOCK-AUTH" || masterKey || nonce)
+func deriveBlockAuthKey(masterKey [32]byte, nonce [16]byte) [64]byte {
+	hash := sha3.New512()
+	hash.Write([]byte(blockAuthLabel))
+	hash.Write(masterKey[:])
+	hash.Write(nonce[:])
+
+	var material [64]byte
+	copy(material[:], hash.Sum(nil))
+	return material
+}
+
+// deriveSplitAuthKey derives MAC key material from a split-trust auth key:
+// SHA3-512("EAMSA512-SPLIT-MAC" || authKey). The label keeps it distinct
+// from every master-key-derived subkey.
+func deriveSplitAuthKey(authKey [32]byte) [64]byte {
+	hash := sha3.New512()
+	hash.Write([]byte(format.SplitMACLabel))
+	hash.Write(authKey[:])
+
+	var material [64]byte
+	copy(material[:], hash.Sum(nil))
+	return material
+}
+
+// EncryptBlockSHA3 encrypts 512-bit block with SHA3-512 MAC. With a
+// counter store, a block whose counter cannot be reserved is not
+// encrypted: the result has Valid false and CounterError says why.
+func (cipher *EAMSA512CipherSHA3) EncryptBlockSHA3(plaintext [64]byte) CipherResultSHA3 {
+	start := time.Now()
+	cipher.mu.Lock()
+	defer cipher.mu.Unlock()
+
+	if err := cipher.reserveCounters(); err != nil {
+		cipher.telemetry.ObserveEncrypt(64, time.Since(start), err)
+		return CipherResultSHA3{}
+	}
+
+	result := CipherResultSHA3{
+		Counter: cipher.EncryptionCounter,
+	}
+
+	// Phase 2: Encrypt using the Phase 1 keys
+	if cipher.isCTR() {
+		cipher.Phase2Encryptor.XORKeyStreamCTR(result.Ciphertext[:], plaintext[:], cipher.keys, cipher.nonce, result.Counter)
+	} else {
+		result.Ciphertext = cipher.Phase2Encryptor.EncryptBlockPhase2(plaintext, cipher.keys)
+	}
+
+	// Phase 3: Compute HMAC-SHA3-512 MAC over the ciphertext
+	result.Nonce = cipher.nonce
+	result.MAC = cipher.ComputeMACHA3(result.Ciphertext[:], result.Nonce, result.Counter)
+	result.Valid = true
+
+	cipher.EncryptionCounter++
+	cipher.AuthCounter++
+	cipher.stats.recordEncrypt(64)
+
+	cipher.telemetry.ObserveEncrypt(64, time.Since(start), nil)
+
+	return result
+}
+
+// DecryptBlockSHA3 verifies the SHA3-512 MAC and, only if it matches,
+// decrypts. A forged block never reaches the block cipher, so failures
+// reveal nothing about the plaintext; the zero block is returned.
+func (cipher *EAMSA512CipherSHA3) DecryptBlockSHA3(ciphertext [64]byte, mac [64]byte, counter uint64) ([64]byte, bool) {
+	start := time.Now()
+	cipher.mu.Lock()
+	defer cipher.mu.Unlock()
+
+	// Verify MAC in constant-time before decrypting
+	computedMAC := cipher.ComputeMACHA3(ciphertext[:], cipher.nonce, counter)
+	if !cipher.VerifyMACHA3(mac, computedMAC) {
+		cipher.stats.recordDecrypt(64, false)
+		cipher.telemetry.ObserveDecrypt(64, time.Since(start), fmt.Errorf("MAC verification failed"))
+		return [64]byte{}, false
+	}
+
+	// Decrypt: CTR applies the same keystream, block mode runs the rounds
+	// in reverse
+	var plaintext [64]byte
+	if cipher.isCTR() {
+		cipher.Phase2Encryptor.XORKeyStreamCTR(plaintext[:], ciphertext[:], cipher.keys, cipher.nonce, counter)
+	} else {
+		plaintext = cipher.Phase2Encryptor.DecryptBlockPhase2(ciphertext, cipher.keys)
+	}
+
+	cipher.stats.recordDecrypt(64, true)
+	cipher.telemetry.ObserveDecrypt(64, time.Since(start), nil)
+
+	return plaintext, true
+}
+
+// ExportKeyingMaterial derives length bytes bound to this session (its
+// master key and nonce) for a protocol layered on it, in the manner of RFC
+// 5705. Each use passes its own label; the cipher's keys are never
+// exported (see kdf.Concat.ExportKeyingMaterial).
+func (cipher *EAMSA512CipherSHA3) ExportKeyingMaterial(label string, length int) ([]byte, error) {
+	return kdf.New().ExportKeyingMaterial(cipher.masterKey, cipher.nonce, label, length)
+}
+
+// blockMACLabel starts every block MAC input; it names the layout, so
+// tags from the earlier plaintext-mixing MAC never verify
+const blockMACLabel = "EAMSA512-BLOCK-ETM-1"
+
+// ComputeMACHA3 computes the encrypt-then-MAC tag of a block:
+// HMAC-SHA3-512 under the auth key material over the header
+//
+//	label || mode length (1) || mode || nonce (16) || counter (8, big-endian) ||
+//	ciphertext length (8, big-endian)
+//
+// followed by the ciphertext. The plaintext is not an input, so the tag is
+// checked before anything is decrypted. ciphertext is what is transmitted:
+// 64 bytes, or fewer for the final block of a CTR stream.
+func (cipher *EAMSA512CipherSHA3) ComputeMACHA3(ciphertext []byte, nonce [16]byte, counter uint64) [64]byte {
+	mac := hmac.New(sha3.New512, cipher.AuthKeyMaterial[:])
+
+	mac.Write([]byte(blockMACLabel))
+	mac.Write([]byte{byte(len(cipher.Mode))})
+	mac.Write([]byte(cipher.Mode))
+	mac.Write(nonce[:])
+
+	var lengths [16]byte
+	binary.BigEndian.PutUint64(lengths[0:8], counter)
+	binary.BigEndian.PutUint64(lengths[8:16], uint64(len(ciphertext)))
+	mac.Write(lengths[:])
+
+	mac.Write(ciphertext)
+
+	result := [64]byte{}
+	copy(result[:], mac.Sum(nil))
+	return result
+}
+
+// VerifyMACHA3 compares a received MAC with the computed one in
+// constant time
+func (cipher *EAMSA512CipherSHA3) VerifyMACHA3(receivedMAC, computedMAC [64]byte) bool {
+	// Constant-time comparison (no timing leaks)
+	return subtle.ConstantTimeCompare(receivedMAC[:], computedMAC[:]) == 1
+}
+
+// isCTR reports whether the cipher runs in counter mode
+func (cipher *EAMSA512CipherSHA3) isCTR() bool {
+	return cipher.Mode == "CTR"
+}
+
+// EncryptStreamSHA3 encrypts entire stream with SHA3-512 MACs
+// It works one 64-byte block at a time and does not detect truncation; use
+// eamsa512.NewEncryptingWriter for large files
+func (cipher *EAMSA512CipherSHA3) EncryptStreamSHA3(input io.Reader, output io.Writer) (int64, error) {
+	if cipher.isCTR() {
+		return cipher.encryptStreamCTR(input, output)
+	}
+
+	var totalBytes int64 = 0
+	buffer := make([]byte, 64)
+
+	for {
+		n, err := input.Read(buffer)
+		if err != nil && err != io.EOF {
+			return totalBytes, err
+		}
+
+		if n == 0 {
+			break
+		}
+
+		// Pad if necessary
+		if n < 64 {
+			for i := n; i < 64; i++ {
+				buffer[i] = byte(64 - n) // PKCS7 padding
+			}
+		}
+
+		// Convert to array
+		plaintext := [64]byte{}
+		copy(plaintext[:], buffer)
+
+		// Encrypt and authenticate
+		result := cipher.EncryptBlockSHA3(plaintext)
+		if !result.Valid {
+			return totalBytes, cipher.CounterError()
+		}
+
+		// Write to output: ciphertext || MAC || nonce || counter
+		output.Write(result.Ciphertext[:])
+		output.Write(result.MAC[:])
+		output.Write(result.Nonce[:])
+		output.Write(make([]byte, 8)) // counter placeholder
+
+		totalBytes += 64
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return totalBytes, nil
+}
+
+// DecryptStreamSHA3 decrypts stream and verifies all MACs
+func (cipher *EAMSA512CipherSHA3) DecryptStreamSHA3(input io.Reader, output io.Writer) (int64, error) {
+	if cipher.isCTR() {
+		return cipher.decryptStreamCTR(input, output)
+	}
+
+	var totalBytes int64 = 0
+	blockSize := 64 + 64 + 16 + 8 // ciphertext + MAC + nonce + counter
+	buffer := make([]byte, blockSize)
+
+	for {
+		n, err := input.Read(buffer)
+		if err != nil && err != io.EOF {
+			return totalBytes, err
+		}
+
+		if n < blockSize {
+			if n > 0 && err != io.EOF {
+				return totalBytes, fmt.Errorf("incomplete block")
+			}
+			break
+		}
+
+		// Extract components
+		ciphertext := [64]byte{}
+		mac := [64]byte{}
+		copy(ciphertext[:], buffer[0:64])
+		copy(mac[:], buffer[64:128])
+
+		counter := totalBytes / 64
+
+		// Decrypt and verify
+		plaintext, valid := cipher.DecryptBlockSHA3(ciphertext, mac, uint64(counter))
+
+		if !valid {
+			return totalBytes, fmt.Errorf("MAC verification failed at block %d", counter)
+		}
+
+		// Write plaintext (remove padding on last block if needed)
+		output.Write(plaintext[:])
+		totalBytes += 64
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return totalBytes, nil
+}
+
+// ctrRecordOverhead is the MAC, nonce and counter following each block's
+// ciphertext in a stream record
+const ctrRecordOverhead = 64 + 16 + 8
+
+// encryptStreamCTR is EncryptStreamSHA3 in CTR mode. Records are
+// ciphertext || MAC || nonce || counter (big-endian) as in CBC mode, but
+// there is no padding: the final record holds only as many ciphertext
+// bytes as there were plaintext bytes left.
+func (cipher *EAMSA512CipherSHA3) encryptStreamCTR(input io.Reader, output io.Writer) (int64, error) {
+	var totalBytes int64 = 0
+	buffer := make([]byte, 64)
+	counterBytes := make([]byte, 8)
+
+	for {
+		n, err := io.ReadFull(input, buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return totalBytes, err
+		}
+
+		plaintext := [64]byte{}
+		copy(plaintext[:], buffer[:n])
+
+		result := cipher.EncryptBlockSHA3(plaintext)
+		if !result.Valid {
+			return totalBytes, cipher.CounterError()
+		}
+
+		// The keystream past n is not sent; the MAC covers the n bytes that
+		// are, and their length, so a record cannot be shortened
+		if n < 64 {
+			result.MAC = cipher.ComputeMACHA3(result.Ciphertext[:n], result.Nonce, result.Counter)
+			cipher.stats.macsComputed.Add(1)
+		}
+
+		binary.BigEndian.PutUint64(counterBytes, result.Counter)
+
+		output.Write(result.Ciphertext[:n])
+		output.Write(result.MAC[:])
+		output.Write(result.Nonce[:])
+		if _, err := output.Write(counterBytes); err != nil {
+			return totalBytes, err
+		}
+
+		totalBytes += int64(n)
+
+		if n < 64 {
+			break
+		}
+	}
+
+	return totalBytes, nil
+}
+
+// decryptStreamCTR decrypts a stream written by encryptStreamCTR. Record
+// counters must be consecutive, so records cannot be reordered or replayed.
+func (cipher *EAMSA512CipherSHA3) decryptStreamCTR(input io.Reader, output io.Writer) (int64, error) {
+	var totalBytes int64 = 0
+	buffer := make([]byte, 64+ctrRecordOverhead)
+
+	var expected uint64
+	for record := 0; ; record++ {
+		m, err := io.ReadFull(input, buffer)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return totalBytes, err
+		}
+
+		n := m - ctrRecordOverhead
+		if n <= 0 {
+			return totalBytes, fmt.Errorf("incomplete block")
+		}
+
+		counter := binary.BigEndian.Uint64(buffer[n+80 : n+88])
+		if record > 0 && counter != expected {
+			return totalBytes, fmt.Errorf("unexpected block counter %d at block %d", counter, record)
+		}
+		expected = counter + 1
+
+		ciphertext := [64]byte{}
+		mac := [64]byte{}
+		copy(ciphertext[:], buffer[:n])
+		copy(mac[:], buffer[n:n+64])
+
+		var plaintext [64]byte
+		var valid bool
+		if n == 64 {
+			plaintext, valid = cipher.DecryptBlockSHA3(ciphertext, mac, counter)
+		} else {
+			plaintext, valid = cipher.decryptPartialCTR(ciphertext, n, mac, counter)
+		}
+
+		if !valid {
+			return totalBytes, fmt.Errorf("MAC verification failed at block %d", record)
+		}
+
+		if _, err := output.Write(plaintext[:n]); err != nil {
+			return totalBytes, err
+		}
+		totalBytes += int64(n)
+
+		if n < 64 {
+			break
+		}
+	}
+
+	return totalBytes, nil
+}
+
+// decryptPartialCTR verifies the first n bytes of ciphertext, the final
+// block of a CTR stream, and only then decrypts them
+func (cipher *EAMSA512CipherSHA3) decryptPartialCTR(ciphertext [64]byte, n int, mac [64]byte, counter uint64) ([64]byte, bool) {
+	start := time.Now()
+
+	computedMAC := cipher.ComputeMACHA3(ciphertext[:n], cipher.nonce, counter)
+	if !cipher.VerifyMACHA3(mac, computedMAC) {
+		cipher.stats.recordDecrypt(n, false)
+		cipher.telemetry.ObserveDecrypt(n, time.Since(start), fmt.Errorf("MAC verification failed"))
+		return [64]byte{}, false
+	}
+
+	var plaintext [64]byte
+	cipher.Phase2Encryptor.XORKeyStreamCTR(plaintext[:n], ciphertext[:n], cipher.keys, cipher.nonce, counter)
+
+	cipher.stats.recordDecrypt(n, true)
+	cipher.telemetry.ObserveDecrypt(n, time.Since(start), nil)
+
+	return plaintext, true
+}
+
+// Snapshot returns the cipher's operation counts. It takes no lock, so
+// metrics exporters can call it as often as they scrape.
+func (cipher *EAMSA512CipherSHA3) Snapshot() StatsSnapshot {
+	return cipher.stats.Snapshot()
+}
+
+// ResetCounters resets internal counters. With a counter store it does
+// nothing: the counters feed the keystream and MACs, and rewinding them
+// would reuse values already spent under this key and nonce.
+func (cipher *EAMSA512CipherSHA3) ResetCounters() {
+	cipher.mu.Lock()
+	defer cipher.mu.Unlock()
+
+	if cipher.counters != nil {
+		log.Printf("[AUDIT] COUNTER_RESET_REFUSED - counter_id=%s - counters are persisted\n", cipher.counterID)
+		return
+	}
+
+	cipher.EncryptionCounter = 0
+	cipher.AuthCounter = 0
+}
+
+// ValidateConfiguration checks cipher configuration and returns a
+// *confschema.ValidationError listing every invalid field
+func (config *EAMSA512ConfigSHA3) ValidateConfiguration() error {
+	v := confschema.NewValidator("EAMSA512ConfigSHA3")
+
+	v.OneOf("AuthAlgorithm", config.AuthAlgorithm, "HMAC-SHA3-512")
+
+	// Insecure modes need an explicit opt-in
+	if config.AllowInsecureModes {
+		v.OneOf("Mode", config.Mode, "CBC", "CTR", "ECB")
+	} else {
+		v.OneOf("Mode", config.Mode, "CBC", "CTR")
+	}
+
+	v.Range("RoundCount", config.RoundCount, 1, 32)
+
+	return v.Err()
+}
+
+// PrintCipherInfo prints cipher information
+func (cipher *EAMSA512CipherSHA3) PrintCipherInfo() {
+	fmt.Println("EAMSA 512 Cipher Configuration (SHA3-512):")
+	fmt.Printf("  Algorithm:        EAMSA-512\n")
+	fmt.Printf("  Block Size:       512 bits\n")
+	fmt.Printf("  Key Material:     1024 bits (11 × 128-bit)\n")
+	fmt.Printf("  MAC Algorithm:    HMAC-SHA3-512\n")
+	fmt.Printf("  MAC Size:         512 bits (64 bytes)\n")
+	fmt.Printf("  Split Trust:      %v\n", cipher.SplitTrust)
+	fmt.Printf("  Encryption Mode:  %s\n", cipher.Mode)
+	fmt.Printf("  Rounds:           %d\n", cipher.RoundCount)
+	fmt.Printf("  Status:           ✓ Production Ready\n")
+}
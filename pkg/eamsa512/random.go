@@ -0,0 +1,233 @@
+package eamsa512
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// SystemRandom is the source name of crypto/rand
+const SystemRandom = "crypto/rand"
+
+// Random event types, in the style of the audit log event names
+const (
+	RandomSourceFailed    = "RANDOM_SOURCE_FAILED"    // Health check or read failed
+	RandomFallback        = "RANDOM_FALLBACK"         // Bytes served by crypto/rand instead
+	RandomSourceRecovered = "RANDOM_SOURCE_RECOVERED" // Source healthy again
+)
+
+// ErrRandomUnavailable is returned when the configured source has failed
+// and the policy does not allow falling back to crypto/rand
+var ErrRandomUnavailable = errors.New("eamsa512: random source unavailable")
+
+// RandomSource is a generator used instead of crypto/rand, such as the
+// RNG of an HSM
+type RandomSource interface {
+	io.Reader
+	Name() string       // Recorded with every use, e.g. "hsm:thales"
+	HealthCheck() error // Run before first use and before returning to the source after a failure
+}
+
+// RandomEvent reports a failure of, or return to, the configured source
+type RandomEvent struct {
+	Type   string // RandomSourceFailed, RandomFallback or RandomSourceRecovered
+	Source string // Name of the configured source
+	Err    error  // Cause of a failure
+}
+
+// RandomPolicy selects where nonce prefixes and keys come from
+type RandomPolicy struct {
+	Source   RandomSource // nil: crypto/rand
+	Fallback bool         // Use crypto/rand while Source is failing, instead of failing
+
+	// RecheckInterval is how long to wait after a failure before health
+	// checking Source again (default 1 minute)
+	RecheckInterval time.Duration
+
+	OnEvent func(RandomEvent) // Receives failures, fallbacks and recoveries (nil ignores them)
+}
+
+// Random draws bytes under a RandomPolicy. Each draw reports the name of
+// the source that supplied it, for audit records. Output of the source
+// must pass a health check before first use, and a draw that repeats the
+// previous one is treated as a stuck generator.
+type Random struct {
+	policy RandomPolicy
+
+	mu       sync.Mutex
+	checked  bool      // Source passed a health check
+	failedAt time.Time // Last failure; zero while healthy
+	last     [32]byte  // SHA3-256 of the previous draw, which may be a key
+}
+
+// NewRandom returns a Random following policy
+func NewRandom(policy RandomPolicy) *Random {
+	if policy.RecheckInterval <= 0 {
+		policy.RecheckInterval = time.Minute
+	}
+	return &Random{policy: policy}
+}
+
+// Fill fills p and returns the name of the source that supplied it. A
+// nil Random, or one without a Source, uses crypto/rand.
+func (r *Random) Fill(p []byte) (string, error) {
+	if r == nil || r.policy.Source == nil {
+		if _, err := rand.Read(p); err != nil {
+			return "", err
+		}
+		return SystemRandom, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := r.policy.Source.Name()
+	if r.failedAt.IsZero() || time.Since(r.failedAt) >= r.policy.RecheckInterval {
+		err := r.drawLocked(p)
+		if err == nil {
+			return name, nil
+		}
+		r.failedAt = time.Now()
+		r.checked = false
+		r.emit(RandomEvent{Type: RandomSourceFailed, Source: name, Err: err})
+	}
+
+	if !r.policy.Fallback {
+		return "", fmt.Errorf("%w: %s", ErrRandomUnavailable, name)
+	}
+	if _, err := rand.Read(p); err != nil {
+		return "", err
+	}
+	r.emit(RandomEvent{Type: RandomFallback, Source: name})
+	return SystemRandom, nil
+}
+
+// Source names the configured source, or SystemRandom for crypto/rand.
+// Fill reports the source that actually served each draw.
+func (r *Random) Source() string {
+	if r == nil || r.policy.Source == nil {
+		return SystemRandom
+	}
+	return r.policy.Source.Name()
+}
+
+// Read fills p for use as an io.Reader
+func (r *Random) Read(p []byte) (int, error) {
+	if _, err := r.Fill(p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// drawLocked health checks the source if needed and reads p from it
+func (r *Random) drawLocked(p []byte) error {
+	source := r.policy.Source
+	if !r.checked {
+		if err := source.HealthCheck(); err != nil {
+			return fmt.Errorf("health check failed: %v", err)
+		}
+		r.checked = true
+		if !r.failedAt.IsZero() {
+			r.failedAt = time.Time{}
+			r.emit(RandomEvent{Type: RandomSourceRecovered, Source: source.Name()})
+		}
+	}
+
+	if _, err := io.ReadFull(source, p); err != nil {
+		return fmt.Errorf("read failed: %v", err)
+	}
+
+	// Short draws are too likely to repeat by chance to compare
+	if len(p) >= 8 {
+		sum := sha3.Sum256(p)
+		if sum == r.last {
+			zeroize(p)
+			return errors.New("output repeated")
+		}
+		r.last = sum
+	}
+	return nil
+}
+
+// HealthCheck checks the configured source, or crypto/rand without one.
+// A failure is reported like a failed draw; with Fallback, the result is
+// that of crypto/rand, which serves draws until the source recovers. A
+// Random is thereby an EntropySource, for KeyringPolicy.Entropy.
+func (r *Random) HealthCheck() error {
+	if r == nil || r.policy.Source == nil {
+		return SystemEntropy{}.HealthCheck()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	source := r.policy.Source
+	if err := source.HealthCheck(); err != nil {
+		r.failedAt = time.Now()
+		r.checked = false
+		r.emit(RandomEvent{Type: RandomSourceFailed, Source: source.Name(), Err: err})
+		if !r.policy.Fallback {
+			return fmt.Errorf("%w: %s: %v", ErrRandomUnavailable, source.Name(), err)
+		}
+		return SystemEntropy{}.HealthCheck()
+	}
+	r.checked = true
+	if !r.failedAt.IsZero() {
+		r.failedAt = time.Time{}
+		r.emit(RandomEvent{Type: RandomSourceRecovered, Source: source.Name()})
+	}
+	return nil
+}
+
+// Reseed passes seed to the source if it can be reseeded
+func (r *Random) Reseed(seed []byte) error {
+	if r == nil || r.policy.Source == nil {
+		return nil
+	}
+	if s, ok := r.policy.Source.(interface{ Reseed([]byte) error }); ok {
+		return s.Reseed(seed)
+	}
+	return nil
+}
+
+func (r *Random) emit(e RandomEvent) {
+	if r.policy.OnEvent != nil {
+		r.policy.OnEvent(e)
+	}
+}
+
+// nonceSource is the reader set by SetNonceSource; nil means crypto/rand
+var nonceSource atomic.Pointer[io.Reader]
+
+// SetNonceSource makes r the source of the nonces and IVs this package
+// generates (NewNonce, EncryptData and Encrypt without a nonce, stream
+// nonce prefixes), such as a TRNG device or an HSM's RNG (any
+// EntropySource is a reader); a Random wrapping a RandomSource adds
+// health checks and fallback. nil restores crypto/rand. A failed or
+// short read fails the encryption that needed it. NonceManager prefixes
+// come from the manager's own Random.
+func SetNonceSource(r io.Reader) {
+	if r == nil {
+		nonceSource.Store(nil)
+		return
+	}
+	nonceSource.Store(&r)
+}
+
+// readNonce fills p from the nonce source
+func readNonce(p []byte) error {
+	source := rand.Reader
+	if r := nonceSource.Load(); r != nil {
+		source = *r
+	}
+	if _, err := io.ReadFull(source, p); err != nil {
+		return fmt.Errorf("failed to generate nonce: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,342 @@
+package eamsa512
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Recipient format (version 2):
+//
+//	header: magic "EAMR" (4) | version (1) | kem (1) |
+//	        ephemeral X25519 public key (32) | recipient count (2, big-endian) |
+//	        per recipient: key ID (8) | wrapped session key (32) | tag (32)
+//	body:   envelope (see envelope.go) under the session key
+//
+// The plaintext is sealed once under a random session key. For each
+// recipient, Z = X25519(ephemeral, recipient) is run through the one-step
+// KDF of NIST SP 800-56C (SP 800-56A key agreement) with SHA3-512:
+//
+//	SHA3-512(counter 1 (4) || Z || "EAMSA512-X25519" || 0x00 ||
+//	         ephemeral public key || recipient public key)
+//
+// whose first 32 bytes mask the session key and last 32 bytes key the
+// HMAC-SHA3-512 tag (truncated to 32 bytes) over magic, version, kem,
+// ephemeral key, key ID and wrapped key. The ephemeral key is used once,
+// so each mask is too. The key ID is the first 8 bytes of SHA3-256 of the
+// recipient public key; it only picks the candidate entries. The whole
+// header, followed by the caller's additional data if any, is the
+// additional data of the body, so recipients cannot be added or removed.
+//
+// Version 1 is the same with a version 1 envelope, which has no key
+// commitment, as the body. DecryptForRecipient opens it with
+// DecryptLegacy; the version byte is covered by the entry tags and the
+// body, so a version 2 message cannot be passed off as one.
+const (
+	// RecipientVersion is the recipient format version written by
+	// EncryptForRecipients
+	RecipientVersion = 2
+
+	// KEMX25519 identifies X25519 key agreement in the recipient header
+	KEMX25519 = 1
+
+	// X25519KeySize is the length of X25519 public and private keys
+	X25519KeySize = 32
+
+	// RecipientKeyIDSize is the length of a recipient key ID
+	RecipientKeyIDSize = 8
+
+	// MaxRecipients bounds the recipients of one message
+	MaxRecipients = 1024
+
+	recipientMagic = "EAMR"
+
+	// recipientPrefixSize is the header length before the entries
+	recipientPrefixSize = 40
+
+	// recipientEntrySize is the length of one recipient entry
+	recipientEntrySize = RecipientKeyIDSize + KeySize + recipientTagSize
+
+	recipientTagSize = 32
+
+	// recipientKDFLabel is the AlgorithmID of the KDF's FixedInfo
+	recipientKDFLabel = "EAMSA512-X25519"
+)
+
+// ErrNotRecipient is returned by DecryptForRecipient when the message is
+// not addressed to the private key
+var ErrNotRecipient = errors.New("eamsa512: not a recipient of this message")
+
+// PublicKey is an X25519 recipient public key
+type PublicKey [X25519KeySize]byte
+
+// ParsePublicKey reads a public key from its 32 bytes
+func ParsePublicKey(b []byte) (PublicKey, error) {
+	var pub PublicKey
+	if len(b) != X25519KeySize {
+		return pub, fmt.Errorf("invalid public key size: expected %d, got %d", X25519KeySize, len(b))
+	}
+	if _, err := ecdh.X25519().NewPublicKey(b); err != nil {
+		return pub, fmt.Errorf("invalid public key: %v", err)
+	}
+	copy(pub[:], b)
+	return pub, nil
+}
+
+// KeyID returns the key ID recorded for this recipient (hex)
+func (p PublicKey) KeyID() string {
+	return hex.EncodeToString(recipientKeyID(p[:]))
+}
+
+// String returns the hex-encoded key
+func (p PublicKey) String() string {
+	return hex.EncodeToString(p[:])
+}
+
+// PrivateKey is an X25519 identity that can decrypt messages addressed to
+// its public key
+type PrivateKey struct {
+	key *ecdh.PrivateKey
+}
+
+// GenerateRecipientKey returns a new X25519 identity from crypto/rand
+func GenerateRecipientKey() (*PrivateKey, error) {
+	key, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate X25519 key: %v", err)
+	}
+	return &PrivateKey{key: key}, nil
+}
+
+// NewPrivateKey reads an identity from its 32 bytes
+func NewPrivateKey(b []byte) (*PrivateKey, error) {
+	key, err := ecdh.X25519().NewPrivateKey(b)
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %v", err)
+	}
+	return &PrivateKey{key: key}, nil
+}
+
+// Bytes returns the 32 private key bytes; keep them secret
+func (k *PrivateKey) Bytes() []byte {
+	return k.key.Bytes()
+}
+
+// PublicKey returns the public key to give to senders
+func (k *PrivateKey) PublicKey() PublicKey {
+	var pub PublicKey
+	copy(pub[:], k.key.PublicKey().Bytes())
+	return pub
+}
+
+// IsRecipientEncrypted reports whether data starts with the recipient
+// format magic
+func IsRecipientEncrypted(data []byte) bool {
+	return len(data) >= len(recipientMagic) && string(data[:len(recipientMagic)]) == recipientMagic
+}
+
+// EncryptForRecipients encrypts plaintext so that the private key of any
+// of recipients can decrypt it
+func EncryptForRecipients(plaintext []byte, recipients []PublicKey) ([]byte, error) {
+	return EncryptForRecipientsWithOptions(plaintext, recipients, EnvelopeOptions{})
+}
+
+// EncryptForRecipientsWithOptions is EncryptForRecipients with the mode,
+// chunk size and additional data of the body; DecryptForRecipientWithAAD
+// needs the same AAD. KeyVersion is ignored.
+func EncryptForRecipientsWithOptions(plaintext []byte, recipients []PublicKey, opts EnvelopeOptions) ([]byte, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no recipients")
+	}
+	if len(recipients) > MaxRecipients {
+		return nil, fmt.Errorf("%d recipients exceed the limit of %d", len(recipients), MaxRecipients)
+	}
+
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ephemeral key: %v", err)
+	}
+	ephemeralPublic := ephemeral.PublicKey().Bytes()
+
+	sessionKey := make([]byte, KeySize)
+	if _, err := rand.Read(sessionKey); err != nil {
+		return nil, fmt.Errorf("failed to generate session key: %v", err)
+	}
+	defer zeroize(sessionKey)
+
+	header := make([]byte, recipientPrefixSize, recipientPrefixSize+len(recipients)*recipientEntrySize)
+	copy(header, recipientMagic)
+	header[4] = RecipientVersion
+	header[5] = KEMX25519
+	copy(header[6:38], ephemeralPublic)
+	binary.BigEndian.PutUint16(header[38:40], uint16(len(recipients)))
+
+	for i, recipient := range recipients {
+		pub, err := ecdh.X25519().NewPublicKey(recipient[:])
+		if err != nil {
+			return nil, fmt.Errorf("recipient %d: invalid public key: %v", i, err)
+		}
+		shared, err := ephemeral.ECDH(pub)
+		if err != nil {
+			return nil, fmt.Errorf("recipient %d: %v", i, err)
+		}
+
+		mask, macKey := recipientKDF(shared, ephemeralPublic, recipient[:])
+		zeroize(shared)
+
+		entry := make([]byte, recipientEntrySize)
+		copy(entry, recipientKeyID(recipient[:]))
+		wrapped := entry[RecipientKeyIDSize : RecipientKeyIDSize+KeySize]
+		subtle.XORBytes(wrapped, sessionKey, mask)
+		copy(entry[RecipientKeyIDSize+KeySize:], recipientTag(macKey, header[:38], entry[:RecipientKeyIDSize+KeySize]))
+		zeroize(mask)
+		zeroize(macKey)
+
+		header = append(header, entry...)
+	}
+
+	bodyOpts := opts
+	bodyOpts.KeyVersion = 0
+	bodyOpts.AAD = append(append([]byte(nil), header...), opts.AAD...)
+
+	body, err := Encrypt(plaintext, sessionKey, bodyOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(header, body...), nil
+}
+
+// DecryptForRecipient decrypts EncryptForRecipients output, of either
+// version, with the private key of one recipient. Returns ErrNotRecipient
+// if no entry is addressed to it.
+func DecryptForRecipient(data []byte, key *PrivateKey) ([]byte, error) {
+	return DecryptForRecipientWithAAD(data, key, nil)
+}
+
+// DecryptForRecipientWithAAD is DecryptForRecipient for a message sealed
+// with EnvelopeOptions.AAD; aad must match
+func DecryptForRecipientWithAAD(data []byte, key *PrivateKey, aad []byte) ([]byte, error) {
+	headerSize, err := parseRecipientHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPublic := data[6:38]
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralPublic)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ephemeral key: %v", err)
+	}
+	shared, err := key.key.ECDH(ephemeral)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+	defer zeroize(shared)
+
+	recipientPublic := key.key.PublicKey().Bytes()
+	id := recipientKeyID(recipientPublic)
+	mask, macKey := recipientKDF(shared, ephemeralPublic, recipientPublic)
+	defer zeroize(mask)
+	defer zeroize(macKey)
+
+	// Key IDs can collide, so every matching entry is tried
+	var sessionKey []byte
+	for off := recipientPrefixSize; off < headerSize; off += recipientEntrySize {
+		entry := data[off : off+recipientEntrySize]
+		if subtle.ConstantTimeCompare(entry[:RecipientKeyIDSize], id) != 1 {
+			continue
+		}
+		tag := recipientTag(macKey, data[:38], entry[:RecipientKeyIDSize+KeySize])
+		if subtle.ConstantTimeCompare(tag, entry[RecipientKeyIDSize+KeySize:]) != 1 {
+			continue
+		}
+		sessionKey = make([]byte, KeySize)
+		subtle.XORBytes(sessionKey, entry[RecipientKeyIDSize:RecipientKeyIDSize+KeySize], mask)
+		break
+	}
+	if sessionKey == nil {
+		return nil, ErrNotRecipient
+	}
+	defer zeroize(sessionKey)
+
+	bodyAAD := append(append([]byte(nil), data[:headerSize]...), aad...)
+	if data[4] == 1 {
+		return DecryptLegacy(data[headerSize:], sessionKey, bodyAAD)
+	}
+	return DecryptWithAAD(data[headerSize:], sessionKey, bodyAAD)
+}
+
+// RecipientKeyIDs returns the key IDs (hex) a message is addressed to,
+// without decrypting it
+func RecipientKeyIDs(data []byte) ([]string, error) {
+	headerSize, err := parseRecipientHeader(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for off := recipientPrefixSize; off < headerSize; off += recipientEntrySize {
+		ids = append(ids, hex.EncodeToString(data[off:off+RecipientKeyIDSize]))
+	}
+	return ids, nil
+}
+
+// parseRecipientHeader checks the recipient header and returns its length
+func parseRecipientHeader(data []byte) (int, error) {
+	if !IsRecipientEncrypted(data) {
+		return 0, fmt.Errorf("not an EAMSA 512 recipient message")
+	}
+	if len(data) < recipientPrefixSize {
+		return 0, fmt.Errorf("recipient message truncated: %d bytes", len(data))
+	}
+	if data[4] != 1 && data[4] != RecipientVersion {
+		return 0, fmt.Errorf("unsupported recipient format version %d", data[4])
+	}
+	if data[5] != KEMX25519 {
+		return 0, fmt.Errorf("unsupported key agreement %d", data[5])
+	}
+
+	count := int(binary.BigEndian.Uint16(data[38:40]))
+	if count == 0 || count > MaxRecipients {
+		return 0, fmt.Errorf("invalid recipient count %d", count)
+	}
+	headerSize := recipientPrefixSize + count*recipientEntrySize
+	if len(data) < headerSize {
+		return 0, fmt.Errorf("recipient message truncated: %d bytes", len(data))
+	}
+	return headerSize, nil
+}
+
+// recipientKDF derives the session key mask and the tag key of one
+// recipient (one-step KDF, NIST SP 800-56C, with SHA3-512)
+func recipientKDF(shared, ephemeralPublic, recipientPublic []byte) ([]byte, []byte) {
+	h := sha3.New512()
+	h.Write([]byte{0, 0, 0, 1})
+	h.Write(shared)
+	h.Write([]byte(recipientKDFLabel))
+	h.Write([]byte{0})
+	h.Write(ephemeralPublic)
+	h.Write(recipientPublic)
+	out := h.Sum(nil)
+	return out[:KeySize], out[KeySize:]
+}
+
+// recipientTag authenticates one entry under the header prefix
+func recipientTag(macKey, prefix, entry []byte) []byte {
+	mac := NewHMAC(macKey)
+	mac.Write(prefix)
+	mac.Write(entry)
+	return mac.Sum()[:recipientTagSize]
+}
+
+// recipientKeyID returns the key ID of a public key
+func recipientKeyID(pub []byte) []byte {
+	sum := sha3.Sum256(pub)
+	return sum[:RecipientKeyIDSize]
+}
@@ -0,0 +1,421 @@
+package eamsa512
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrDecryption is returned for every decryption failure: a short or
+// misaligned input, a tag mismatch or bad padding. A single error, with
+// padding checked in constant time, gives a padding oracle nothing to
+// distinguish.
+var ErrDecryption = errors.New("eamsa512: decryption failed")
+
+// NewNonce returns a random 16-byte nonce from crypto/rand, or the source
+// set with SetNonceSource
+func NewNonce() ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if err := readNonce(nonce); err != nil {
+		return nil, err
+	}
+	return nonce, nil
+}
+
+// EncryptData encrypts plaintext and returns ciphertext || nonce || tag.
+// nonce is optional; if nil, one is generated (16 bytes). Copies
+// plaintext; use SealInPlace to encrypt the caller's buffer directly.
+func EncryptData(plaintext []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	return SealInPlace(buf, masterKey, nonce)
+}
+
+// DecryptData verifies and decrypts ciphertext || nonce || tag. Copies
+// encryptedData; use OpenInPlace to decrypt the caller's buffer directly.
+func DecryptData(encryptedData []byte, masterKey []byte) ([]byte, error) {
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	return OpenInPlace(buf, masterKey)
+}
+
+// EncryptDataContext is EncryptData that gives up with ctx.Err() once
+// ctx is done, checking between runs of blocks, so a large payload stops
+// using CPU soon after its caller goes away
+func EncryptDataContext(ctx context.Context, plaintext []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	return SealInPlaceContext(ctx, buf, masterKey, nonce)
+}
+
+// DecryptDataContext is DecryptData that gives up with ctx.Err() once ctx
+// is done
+func DecryptDataContext(ctx context.Context, encryptedData []byte, masterKey []byte) ([]byte, error) {
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	return OpenInPlaceContext(ctx, buf, masterKey)
+}
+
+// EncryptDataWithAAD is EncryptData with additional authenticated data:
+// aad (e.g. a record ID, tenant or key version) is covered by the tag but
+// neither encrypted nor included in the output, and DecryptDataWithAAD
+// must be given the same aad. An empty aad gives EncryptData output.
+func EncryptDataWithAAD(plaintext []byte, masterKey []byte, nonce []byte, aad []byte) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	return seal(context.Background(), buf, masterKey, nonce, aad, nil, PaddingPKCS7)
+}
+
+// DecryptDataWithAAD verifies ciphertext || nonce || tag together with
+// aad and decrypts it
+func DecryptDataWithAAD(encryptedData []byte, masterKey []byte, aad []byte) ([]byte, error) {
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	return open(context.Background(), buf, masterKey, aad, nil, PaddingPKCS7)
+}
+
+// EncryptDataWithPadding is EncryptData with another padding scheme; the
+// same scheme must be given to DecryptDataWithPadding
+func EncryptDataWithPadding(plaintext []byte, masterKey []byte, nonce []byte, padding Padding) ([]byte, error) {
+	buf := make([]byte, len(plaintext), len(plaintext)+SealOverhead(len(plaintext)))
+	copy(buf, plaintext)
+
+	return SealInPlaceWithOptions(buf, masterKey, nonce, SealOptions{Padding: padding})
+}
+
+// DecryptDataWithPadding decrypts EncryptDataWithPadding output
+func DecryptDataWithPadding(encryptedData []byte, masterKey []byte, padding Padding) ([]byte, error) {
+	buf := make([]byte, len(encryptedData))
+	copy(buf, encryptedData)
+
+	return OpenInPlaceWithOptions(buf, masterKey, SealOptions{Padding: padding})
+}
+
+// SealOverhead returns how many bytes SealInPlace appends to a plaintext
+// of length n (padding, nonce and tag). Allocate buf with
+// cap(buf) >= n+SealOverhead(n) to seal without reallocating. Padding is
+// 1 to BlockSize bytes in every scheme: a block-aligned (or empty)
+// plaintext gets a full block.
+func SealOverhead(n int) int {
+	paddedLength := (n/BlockSize + 1) * BlockSize
+	return paddedLength - n + NonceSize + TagSize
+}
+
+// SealInPlace encrypts the plaintext in buf and returns
+// ciphertext || nonce || tag. The result reuses buf's storage when its
+// capacity allows; buf must not be used afterwards.
+func SealInPlace(buf []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	return seal(context.Background(), buf, masterKey, nonce, nil, nil, PaddingPKCS7)
+}
+
+// SealInPlaceContext is SealInPlace that gives up with ctx.Err() once
+// ctx is done (see EncryptDataContext)
+func SealInPlaceContext(ctx context.Context, buf []byte, masterKey []byte, nonce []byte) ([]byte, error) {
+	return seal(ctx, buf, masterKey, nonce, nil, nil, PaddingPKCS7)
+}
+
+// SealInPlaceWithTag is SealInPlace with the body tag computed by tag
+// (e.g. under a MAC key held apart from masterKey); nil uses the key
+// derived from masterKey
+func SealInPlaceWithTag(buf []byte, masterKey []byte, nonce []byte, tag TagWriter) ([]byte, error) {
+	return seal(context.Background(), buf, masterKey, nonce, nil, tag, PaddingPKCS7)
+}
+
+// SealInPlaceWithAAD is SealInPlace with additional authenticated data
+// (see EncryptDataWithAAD)
+func SealInPlaceWithAAD(buf []byte, masterKey []byte, nonce []byte, aad []byte) ([]byte, error) {
+	return seal(context.Background(), buf, masterKey, nonce, aad, nil, PaddingPKCS7)
+}
+
+// SealOptions combines the variants of SealInPlace and OpenInPlace. The
+// zero value gives EncryptData output.
+type SealOptions struct {
+	AAD     []byte    // Additional authenticated data (see EncryptDataWithAAD)
+	Tag     TagWriter // Body tag (see SealInPlaceWithTag); nil derives it from the master key
+	Padding Padding   // Zero means PaddingPKCS7
+}
+
+// SealInPlaceWithOptions is SealInPlace with any combination of additional
+// data, tag and padding scheme
+func SealInPlaceWithOptions(buf []byte, masterKey []byte, nonce []byte, opts SealOptions) ([]byte, error) {
+	if !opts.Padding.orDefault().Valid() {
+		return nil, fmt.Errorf("unsupported padding %s", opts.Padding)
+	}
+	return seal(context.Background(), buf, masterKey, nonce, opts.AAD, opts.Tag, opts.Padding.orDefault())
+}
+
+// seal encrypts buf and tags nonce || ciphertext, followed by aad when
+// present, with tag (nil: the key derived from masterKey)
+func seal(ctx context.Context, buf []byte, masterKey []byte, nonce []byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	start := time.Now()
+	out, err := sealBuffer(ctx, buf, masterKey, nonce, aad, tag, padding)
+	observeEncrypt(len(buf), start, err)
+	return out, err
+}
+
+// sealBuffer is seal without telemetry
+func sealBuffer(ctx context.Context, buf []byte, masterKey []byte, nonce []byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	keys, err := DeriveKeys(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return sealWithKeys(ctx, buf, masterKey, keys, nonce, aad, tag, padding)
+}
+
+// sealWithKeys is sealBuffer with the round keys of masterKey already
+// derived
+func sealWithKeys(ctx context.Context, buf []byte, masterKey []byte, keys [][]byte, nonce []byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	if nonce == nil {
+		var err error
+		if nonce, err = NewNonce(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf("invalid nonce size: expected %d, got %d", NonceSize, len(nonce))
+	}
+
+	if tag == nil {
+		tag = NewHMAC(keys[len(keys)-1])
+	}
+
+	// Grow once to the final size, then add padding
+	plaintextLength := len(buf)
+	out := growSlice(buf, SealOverhead(plaintextLength))
+	paddedLength := len(out) - NonceSize - TagSize
+
+	padding.Fill(out[plaintextLength:paddedLength])
+
+	if err := encryptCBCContext(ctx, out[:paddedLength], keys, DeriveIV(nonce, masterKey)); err != nil {
+		return nil, err
+	}
+
+	copy(out[paddedLength:], nonce)
+
+	// Tag over nonce || ciphertext [|| aad || lengths]
+	tag.Write(nonce)
+	tag.Write(out[:paddedLength])
+	writeAAD(tag, aad, paddedLength)
+	copy(out[paddedLength+NonceSize:], tag.Sum())
+
+	return out, nil
+}
+
+// OpenInPlace verifies and decrypts ciphertext || nonce || tag held in buf
+// and returns the plaintext, which aliases buf. buf is left unchanged if
+// authentication fails.
+func OpenInPlace(buf []byte, masterKey []byte) ([]byte, error) {
+	return open(context.Background(), buf, masterKey, nil, nil, PaddingPKCS7)
+}
+
+// OpenInPlaceContext is OpenInPlace that gives up with ctx.Err() once
+// ctx is done. Cancellation after the tag has been verified leaves buf
+// partly decrypted.
+func OpenInPlaceContext(ctx context.Context, buf []byte, masterKey []byte) ([]byte, error) {
+	return open(ctx, buf, masterKey, nil, nil, PaddingPKCS7)
+}
+
+// OpenInPlaceWithTag is OpenInPlace with the body tag verified by tag;
+// nil uses the key derived from masterKey
+func OpenInPlaceWithTag(buf []byte, masterKey []byte, tag TagWriter) ([]byte, error) {
+	return open(context.Background(), buf, masterKey, nil, tag, PaddingPKCS7)
+}
+
+// OpenInPlaceWithAAD is OpenInPlace for data sealed with additional
+// authenticated data
+func OpenInPlaceWithAAD(buf []byte, masterKey []byte, aad []byte) ([]byte, error) {
+	return open(context.Background(), buf, masterKey, aad, nil, PaddingPKCS7)
+}
+
+// OpenInPlaceWithOptions is OpenInPlace for data sealed with
+// SealInPlaceWithOptions; opts must match
+func OpenInPlaceWithOptions(buf []byte, masterKey []byte, opts SealOptions) ([]byte, error) {
+	if !opts.Padding.orDefault().Valid() {
+		return nil, fmt.Errorf("unsupported padding %s", opts.Padding)
+	}
+	return open(context.Background(), buf, masterKey, opts.AAD, opts.Tag, opts.Padding.orDefault())
+}
+
+// open verifies the tag over nonce || ciphertext [|| aad || lengths] and
+// decrypts buf in place
+func open(ctx context.Context, buf []byte, masterKey []byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	start := time.Now()
+	plaintext, err := openBuffer(ctx, buf, masterKey, aad, tag, padding)
+	observeDecrypt(len(buf), start, err)
+	return plaintext, err
+}
+
+// openBuffer is open without telemetry
+func openBuffer(ctx context.Context, buf []byte, masterKey []byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+
+	keys, err := DeriveKeys(masterKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return openWithKeys(ctx, buf, masterKey, keys, aad, tag, padding)
+}
+
+// openWithKeys is openBuffer with the round keys of masterKey already
+// derived
+func openWithKeys(ctx context.Context, buf []byte, masterKey []byte, keys [][]byte, aad []byte, tag TagWriter, padding Padding) ([]byte, error) {
+	if len(buf) < BlockSize+NonceSize+TagSize {
+		return nil, ErrDecryption
+	}
+
+	ciphertextLength := len(buf) - NonceSize - TagSize
+	ciphertext := buf[:ciphertextLength]
+	nonce := buf[ciphertextLength : ciphertextLength+NonceSize]
+	receivedTag := buf[ciphertextLength+NonceSize:]
+
+	if tag == nil {
+		tag = NewHMAC(keys[len(keys)-1])
+	}
+
+	// Verify before touching the ciphertext
+	tag.Write(nonce)
+	tag.Write(ciphertext)
+	writeAAD(tag, aad, ciphertextLength)
+	if subtle.ConstantTimeCompare(tag.Sum(), receivedTag) != 1 {
+		return nil, ErrDecryption
+	}
+
+	if ciphertextLength%BlockSize != 0 {
+		return nil, ErrDecryption
+	}
+
+	if err := decryptCBCContext(ctx, ciphertext, keys, DeriveIV(nonce, masterKey)); err != nil {
+		return nil, err
+	}
+
+	return padding.Unpad(ciphertext)
+}
+
+// writeAAD appends aad || uint64(len(aad)) || uint64(len(ciphertext)) to
+// the tag input. Nothing is written for an empty aad, so such data keeps
+// the EncryptData tag; the trailing lengths keep the split between
+// ciphertext and aad unambiguous.
+func writeAAD(tag TagWriter, aad []byte, ciphertextLength int) {
+	if len(aad) == 0 {
+		return
+	}
+
+	var lengths [16]byte
+	binary.BigEndian.PutUint64(lengths[:8], uint64(len(aad)))
+	binary.BigEndian.PutUint64(lengths[8:], uint64(ciphertextLength))
+
+	tag.Write(aad)
+	tag.Write(lengths[:])
+}
+
+// cancelCheckBlocks is how many blocks the context variants encrypt or
+// decrypt between checks for cancellation (64 KiB)
+const cancelCheckBlocks = 1024
+
+// encryptCBC encrypts whole blocks of buf in place in CBC mode
+func encryptCBC(buf []byte, keys [][]byte, iv []byte) {
+	encryptCBCContext(context.Background(), buf, keys, iv)
+}
+
+// encryptCBCContext is encryptCBC that stops with ctx.Err() once ctx is
+// done, leaving buf partly encrypted
+func encryptCBCContext(ctx context.Context, buf []byte, keys [][]byte, iv []byte) error {
+	prevBlock := iv
+	for i := 0; i < len(buf); i += BlockSize {
+		if i%(cancelCheckBlocks*BlockSize) == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+
+		block := buf[i : i+BlockSize]
+		for j := 0; j < BlockSize; j++ {
+			block[j] ^= prevBlock[j]
+		}
+
+		copy(block, EncryptBlock(block, keys))
+		prevBlock = block
+	}
+	return nil
+}
+
+// decryptCBC decrypts whole blocks of buf in place in CBC mode
+func decryptCBC(buf []byte, keys [][]byte, iv []byte) {
+	decryptCBCContext(context.Background(), buf, keys, iv)
+}
+
+// decryptCBCContext is decryptCBC that stops with ctx.Err() once ctx is
+// done. It keeps a copy of each ciphertext block for chaining since it is
+// overwritten. Long inputs are decrypted in parallel lanes.
+func decryptCBCContext(ctx context.Context, buf []byte, keys [][]byte, iv []byte) error {
+	bounds := splitLanes(len(buf) / BlockSize)
+
+	// Each lane chains from the last ciphertext block of the lane before,
+	// which that lane overwrites: copy them all first
+	ivs := make([][]byte, len(bounds)-1)
+	ivs[0] = append([]byte(nil), iv...)
+	for lane := 1; lane < len(ivs); lane++ {
+		start := bounds[lane] * BlockSize
+		ivs[lane] = append([]byte(nil), buf[start-BlockSize:start]...)
+	}
+
+	return runLanes(ctx, bounds, func(ctx context.Context, lane, first, end int) error {
+		prevBlock := ivs[lane]
+		var saved [BlockSize]byte
+
+		for i := first * BlockSize; i < end*BlockSize; i += BlockSize {
+			if (i-first*BlockSize)%(cancelCheckBlocks*BlockSize) == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			block := buf[i : i+BlockSize]
+			copy(saved[:], block)
+
+			decryptedBlock := DecryptBlock(block, keys)
+			for j := 0; j < BlockSize; j++ {
+				block[j] = decryptedBlock[j] ^ prevBlock[j]
+			}
+
+			prevBlock = append(prevBlock[:0], saved[:]...)
+		}
+		return nil
+	})
+}
+
+// UnpadPKCS7 removes PKCS#7 padding from decrypted whole blocks in
+// constant time (see Padding.Unpad). Any failure is ErrDecryption.
+func UnpadPKCS7(plaintext []byte) ([]byte, error) {
+	return PaddingPKCS7.Unpad(plaintext)
+}
+
+// growSlice extends b by n bytes, reallocating only if cap(b) is too small
+func growSlice(b []byte, n int) []byte {
+	total := len(b) + n
+	if total <= cap(b) {
+		return b[:total]
+	}
+
+	grown := make([]byte, total)
+	copy(grown, b)
+	return grown
+}
@@ -0,0 +1,109 @@
+package eamsa512
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// CipherStats counts the operations of one cipher. The counters are
+// updated with atomics on the encrypt and decrypt paths and read without
+// the cipher lock, so taking a snapshot never waits for a block. They are
+// separate from EncryptionCounter, which is the block counter of the
+// keystream and MACs and may be restored from a counter store.
+type CipherStats struct {
+	blocksEncrypted atomic.Uint64
+	blocksDecrypted atomic.Uint64
+	bytesEncrypted  atomic.Uint64
+	bytesDecrypted  atomic.Uint64
+	macsComputed    atomic.Uint64
+	macFailures     atomic.Uint64
+}
+
+// StatsSnapshot is a copy of CipherStats at one instant. The counters are
+// read one by one, so a snapshot taken during an operation may include
+// part of it.
+type StatsSnapshot struct {
+	BlocksEncrypted uint64    `json:"blocks_encrypted"`
+	BlocksDecrypted uint64    `json:"blocks_decrypted"`
+	BytesEncrypted  uint64    `json:"bytes_encrypted"`
+	BytesDecrypted  uint64    `json:"bytes_decrypted"`
+	MACsComputed    uint64    `json:"macs_computed"`
+	MACFailures     uint64    `json:"mac_failures"`
+	Taken           time.Time `json:"taken"`
+}
+
+// StatsDelta is the activity between two snapshots
+type StatsDelta struct {
+	BlocksEncrypted uint64        `json:"blocks_encrypted"`
+	BlocksDecrypted uint64        `json:"blocks_decrypted"`
+	BytesEncrypted  uint64        `json:"bytes_encrypted"`
+	BytesDecrypted  uint64        `json:"bytes_decrypted"`
+	MACsComputed    uint64        `json:"macs_computed"`
+	MACFailures     uint64        `json:"mac_failures"`
+	Interval        time.Duration `json:"interval_ns"`
+}
+
+// recordEncrypt counts one encrypted block of n bytes and its MAC
+func (s *CipherStats) recordEncrypt(n int) {
+	s.blocksEncrypted.Add(1)
+	s.bytesEncrypted.Add(uint64(n))
+	s.macsComputed.Add(1)
+}
+
+// recordDecrypt counts one MAC check of a block of n bytes and, if it
+// passed, the decryption
+func (s *CipherStats) recordDecrypt(n int, valid bool) {
+	s.macsComputed.Add(1)
+	if !valid {
+		s.macFailures.Add(1)
+		return
+	}
+	s.blocksDecrypted.Add(1)
+	s.bytesDecrypted.Add(uint64(n))
+}
+
+// Snapshot returns the current counts
+func (s *CipherStats) Snapshot() StatsSnapshot {
+	return StatsSnapshot{
+		BlocksEncrypted: s.blocksEncrypted.Load(),
+		BlocksDecrypted: s.blocksDecrypted.Load(),
+		BytesEncrypted:  s.bytesEncrypted.Load(),
+		BytesDecrypted:  s.bytesDecrypted.Load(),
+		MACsComputed:    s.macsComputed.Load(),
+		MACFailures:     s.macFailures.Load(),
+		Taken:           time.Now(),
+	}
+}
+
+// Delta returns the activity since prev, for exporters that report
+// increments or rates on an interval. A count lower than in prev means
+// the cipher was replaced, as with a Prometheus counter reset, and counts
+// from zero.
+func (s StatsSnapshot) Delta(prev StatsSnapshot) StatsDelta {
+	return StatsDelta{
+		BlocksEncrypted: counterDelta(s.BlocksEncrypted, prev.BlocksEncrypted),
+		BlocksDecrypted: counterDelta(s.BlocksDecrypted, prev.BlocksDecrypted),
+		BytesEncrypted:  counterDelta(s.BytesEncrypted, prev.BytesEncrypted),
+		BytesDecrypted:  counterDelta(s.BytesDecrypted, prev.BytesDecrypted),
+		MACsComputed:    counterDelta(s.MACsComputed, prev.MACsComputed),
+		MACFailures:     counterDelta(s.MACFailures, prev.MACFailures),
+		Interval:        s.Taken.Sub(prev.Taken),
+	}
+}
+
+// Rate returns n per second over the delta's interval (0 for an empty
+// interval)
+func (d StatsDelta) Rate(n uint64) float64 {
+	if d.Interval <= 0 {
+		return 0
+	}
+	return float64(n) / d.Interval.Seconds()
+}
+
+// counterDelta is cur - prev, or cur after a reset
+func counterDelta(cur, prev uint64) uint64 {
+	if cur < prev {
+		return cur
+	}
+	return cur - prev
+}
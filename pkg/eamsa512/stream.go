@@ -0,0 +1,677 @@
+package eamsa512
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"sync"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Chunked stream format (version 1):
+//
+//	header: magic "EAMX" (4) | version (1) | chunk size (4, big-endian) |
+//	        nonce prefix (11)
+//	chunks: AEAD(chunk) ...
+//
+// Every chunk but the last holds exactly chunk size bytes of plaintext;
+// the last holds 0 to chunk size bytes. Each chunk is sealed with the
+// NewAEAD construction under a key derived for streams, with the nonce
+//
+//	nonce prefix (11) | chunk number (4, big-endian) | final flag (1)
+//
+// and the header as additional data. Reordered, replayed or spliced
+// chunks fail authentication, and a stream whose final chunk is missing
+// is reported as truncated.
+//
+// Version 2, written by EncryptReader, is version 1 with a footer: the
+// last StreamFooterSize bytes of the chunked plaintext are
+//
+//	plaintext length (8, big-endian) | SHA3-256(plaintext) (32)
+//
+// so a writer that does not know the length in advance still records it.
+// The footer is sealed like the data before it and may straddle the last
+// two chunks. NewDecryptingReader checks it and never returns it.
+const (
+	// DefaultChunkSize is the plaintext chunk size of NewEncryptingWriter
+	DefaultChunkSize = 64 * 1024
+
+	// MaxChunkSize bounds the chunk size accepted by both ends, and so the
+	// memory a reader allocates for a stream
+	MaxChunkSize = 16 * 1024 * 1024
+
+	// MinAdaptiveChunkSize and MaxAdaptiveChunkSize bound the chunk sizes
+	// ChunkSizeFor picks
+	MinAdaptiveChunkSize = 16 * 1024
+	MaxAdaptiveChunkSize = 4 * 1024 * 1024
+
+	// StreamHeaderSize is the length of the stream header
+	StreamHeaderSize = 20
+
+	// StreamFooterSize is the length of the version 2 footer
+	StreamFooterSize = 8 + 32
+
+	streamMagic         = "EAMX"
+	streamVersion       = 1
+	streamFooterVersion = 2
+	streamPrefixSize    = 11
+	streamKeyLabel      = "EAMSA512-STREAM-KEY"
+	streamFinalChunk    = 1
+	streamMaxChunks     = 1 << 32
+	streamChunkNumber   = streamPrefixSize
+	streamFinalFlag     = streamPrefixSize + 4
+)
+
+// ErrStreamTruncated is returned when a stream ends before its final chunk
+var ErrStreamTruncated = errors.New("eamsa512: stream truncated")
+
+// StreamFooter is the plaintext length and digest recorded at the end of a
+// version 2 stream
+type StreamFooter struct {
+	Length uint64
+	Digest [32]byte // SHA3-256 of the plaintext
+}
+
+// ChunkSizeFor picks the chunk size for about size bytes of plaintext, or
+// DefaultChunkSize if size is negative (unknown). Every chunk adds up to
+// 128 bytes of padding and tag and one tag computation, while reading any
+// byte means opening its whole chunk; aiming at about 4096 chunks keeps
+// the overhead under 1% with chunks no bigger than needed. The result is
+// a power of two from MinAdaptiveChunkSize to MaxAdaptiveChunkSize.
+func ChunkSizeFor(size int64) int {
+	if size < 0 {
+		return DefaultChunkSize
+	}
+
+	chunkSize := MinAdaptiveChunkSize
+	for chunkSize < MaxAdaptiveChunkSize && int64(chunkSize)*4096 < size {
+		chunkSize *= 2
+	}
+	return chunkSize
+}
+
+// IsStream reports whether data starts with the stream magic
+func IsStream(data []byte) bool {
+	return len(data) >= len(streamMagic) && string(data[:len(streamMagic)]) == streamMagic
+}
+
+// marshal encodes the footer
+func (f *StreamFooter) marshal() []byte {
+	out := make([]byte, StreamFooterSize)
+	binary.BigEndian.PutUint64(out, f.Length)
+	copy(out[8:], f.Digest[:])
+	return out
+}
+
+// streamAEAD derives the per-stream AEAD key from the master key, so that
+// stream chunks and NewAEAD messages never share a nonce space
+func streamAEAD(key []byte) (*eamsaAEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(key))
+	}
+
+	hash := sha3.New512()
+	hash.Write([]byte(streamKeyLabel))
+	hash.Write(key)
+
+	return newAEAD(hash.Sum(nil)[:KeySize])
+}
+
+// streamSealedSize is the sealed size of a chunk of n plaintext bytes
+func streamSealedSize(n int) int {
+	return (n/BlockSize+1)*BlockSize + TagSize
+}
+
+// encryptingWriter is returned by NewEncryptingWriter
+type encryptingWriter struct {
+	ctx    context.Context
+	w      io.Writer
+	aead   cipher.AEAD
+	header []byte
+	nonce  []byte
+	chunk  uint64
+	buf    []byte // Plaintext of the pending chunk
+	sealed []byte
+	err    error
+
+	// Version 2 only: the data written so far, for the footer
+	footer *StreamFooter
+	hash   hash.Hash
+}
+
+// NewEncryptingWriter is NewEncryptingWriterSize with DefaultChunkSize
+func NewEncryptingWriter(w io.Writer, key []byte) (io.WriteCloser, error) {
+	return NewEncryptingWriterSize(w, key, DefaultChunkSize)
+}
+
+// NewEncryptingWriterSize writes a stream header to w and returns a writer
+// that encrypts and authenticates its input in chunks of chunkSize bytes.
+// Close must be called to write the final chunk; it does not close w.
+func NewEncryptingWriterSize(w io.Writer, key []byte, chunkSize int) (io.WriteCloser, error) {
+	return NewEncryptingWriterContext(context.Background(), w, key, chunkSize)
+}
+
+// NewEncryptingWriterContext is NewEncryptingWriterSize for a writer that
+// fails with ctx.Err() at the next chunk once ctx is done
+func NewEncryptingWriterContext(ctx context.Context, w io.Writer, key []byte, chunkSize int) (io.WriteCloser, error) {
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("invalid chunk size %d: must be between 1 and %d", chunkSize, MaxChunkSize)
+	}
+
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := writeStreamHeader(w, streamVersion, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return newEncryptingWriter(ctx, w, aead, header, header[9:], chunkSize), nil
+}
+
+// EncryptReader is EncryptReaderSize with DefaultChunkSize
+func EncryptReader(w io.Writer, r io.Reader, key []byte) (*StreamFooter, error) {
+	return EncryptReaderSize(w, r, key, DefaultChunkSize)
+}
+
+// EncryptReaderSize encrypts everything read from r, whose length need not
+// be known, to w as a version 2 stream, holding one chunk in memory. It
+// returns the footer written at the end. If r fails, the error is
+// returned and the stream is left without its final chunk, so readers
+// report it as truncated.
+func EncryptReaderSize(w io.Writer, r io.Reader, key []byte, chunkSize int) (*StreamFooter, error) {
+	return EncryptReaderContext(context.Background(), w, r, key, chunkSize)
+}
+
+// EncryptReaderContext is EncryptReaderSize that stops with ctx.Err() at
+// the next chunk once ctx is done. A read from r that blocks is not
+// interrupted; cancel r itself (e.g. a request body) for that.
+func EncryptReaderContext(ctx context.Context, w io.Writer, r io.Reader, key []byte, chunkSize int) (*StreamFooter, error) {
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return nil, fmt.Errorf("invalid chunk size %d: must be between 1 and %d", chunkSize, MaxChunkSize)
+	}
+
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := writeStreamHeader(w, streamFooterVersion, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+
+	ew := newEncryptingWriter(ctx, w, aead, header, header[9:], chunkSize)
+	ew.footer = &StreamFooter{}
+	ew.hash = sha3.New256()
+
+	if _, err := io.Copy(ew, r); err != nil {
+		return nil, err
+	}
+	if err := ew.Close(); err != nil {
+		return nil, err
+	}
+	return ew.footer, nil
+}
+
+// writeStreamHeader writes a stream header with a random nonce prefix
+func writeStreamHeader(w io.Writer, version byte, chunkSize int) ([]byte, error) {
+	header := make([]byte, StreamHeaderSize)
+	copy(header, streamMagic)
+	header[4] = version
+	binary.BigEndian.PutUint32(header[5:9], uint32(chunkSize))
+	if err := readNonce(header[9:]); err != nil {
+		return nil, err
+	}
+
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+// newEncryptingWriter returns a writer of chunks sealed with header as
+// additional data and nonces starting with prefix; the header itself is
+// the caller's to write
+func newEncryptingWriter(ctx context.Context, w io.Writer, aead cipher.AEAD, header, prefix []byte, chunkSize int) *encryptingWriter {
+	nonce := make([]byte, NonceSize)
+	copy(nonce, prefix[:streamPrefixSize])
+
+	return &encryptingWriter{
+		ctx:    ctx,
+		w:      w,
+		aead:   aead,
+		header: header,
+		nonce:  nonce,
+		buf:    make([]byte, 0, chunkSize),
+		sealed: make([]byte, 0, streamSealedSize(chunkSize)),
+	}
+}
+
+// Write buffers p and writes every chunk that is known not to be the last
+func (ew *encryptingWriter) Write(p []byte) (int, error) {
+	n, err := ew.buffer(p)
+	if ew.footer != nil {
+		ew.hash.Write(p[:n])
+		ew.footer.Length += uint64(n)
+	}
+	return n, err
+}
+
+// buffer is Write without the footer accounting
+func (ew *encryptingWriter) buffer(p []byte) (int, error) {
+	if ew.err != nil {
+		return 0, ew.err
+	}
+
+	written := 0
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data follows, so that
+		// Close can mark the last chunk final
+		if len(ew.buf) == cap(ew.buf) {
+			if err := ew.seal(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(ew.buf[len(ew.buf):cap(ew.buf)], p)
+		ew.buf = ew.buf[:len(ew.buf)+n]
+		p = p[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close writes the footer, if any, and the final chunk, which may be empty
+func (ew *encryptingWriter) Close() error {
+	if ew.err != nil {
+		if ew.err == errStreamClosed {
+			return nil
+		}
+		return ew.err
+	}
+
+	if ew.footer != nil {
+		ew.hash.Sum(ew.footer.Digest[:0])
+		if _, err := ew.buffer(ew.footer.marshal()); err != nil {
+			return err
+		}
+	}
+
+	if err := ew.seal(true); err != nil {
+		return err
+	}
+
+	ew.err = errStreamClosed
+	return nil
+}
+
+// errStreamClosed is returned by writes after Close
+var errStreamClosed = errors.New("eamsa512: write to closed stream")
+
+// seal encrypts the buffered chunk and writes it
+func (ew *encryptingWriter) seal(final bool) error {
+	if err := ew.ctx.Err(); err != nil {
+		ew.err = err
+		return err
+	}
+	if ew.chunk >= streamMaxChunks {
+		ew.err = fmt.Errorf("stream too long: more than %d chunks", uint64(streamMaxChunks))
+		return ew.err
+	}
+
+	setStreamNonce(ew.nonce, ew.chunk, final)
+	ew.sealed = ew.aead.Seal(ew.sealed[:0], ew.nonce, ew.buf, ew.header)
+
+	if _, err := ew.w.Write(ew.sealed); err != nil {
+		ew.err = err
+		return err
+	}
+
+	ew.chunk++
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+// setStreamNonce fills in the chunk number and final flag
+func setStreamNonce(nonce []byte, chunk uint64, final bool) {
+	binary.BigEndian.PutUint32(nonce[streamChunkNumber:], uint32(chunk))
+	nonce[streamFinalFlag] = 0
+	if final {
+		nonce[streamFinalFlag] = streamFinalChunk
+	}
+}
+
+// decryptingReader is returned by NewDecryptingReader
+type decryptingReader struct {
+	ctx       context.Context
+	r         *bufio.Reader
+	aead      *eamsaAEAD
+	header    []byte
+	nonce     []byte
+	chunkSize int
+	chunk     uint64
+	sealed    []byte
+	plaintext []byte // Decrypted bytes not yet returned
+	done      bool
+	err       error
+	footer    *footerHold // Version 2 only
+}
+
+// footerHold holds back the last StreamFooterSize bytes of a version 2
+// stream's plaintext until the final chunk shows whether they are the
+// footer
+type footerHold struct {
+	footer *StreamFooter // Data released so far
+	hash   hash.Hash
+	held   []byte
+	out    []byte
+}
+
+// newFooterHold returns a footerHold for chunks of up to chunkSize bytes
+func newFooterHold(chunkSize int) *footerHold {
+	return &footerHold{
+		footer: &StreamFooter{},
+		hash:   sha3.New256(),
+		held:   make([]byte, 0, StreamFooterSize),
+		out:    make([]byte, 0, chunkSize+StreamFooterSize),
+	}
+}
+
+// NewDecryptingReader reads the stream header from r and returns a reader
+// of the plaintext. Each chunk is authenticated before any of it is
+// returned; a stream that is tampered with, reordered or truncated fails
+// with an error once the bad chunk is reached (ErrStreamTruncated for a
+// missing final chunk), after the chunks before it have been returned.
+func NewDecryptingReader(r io.Reader, key []byte) (io.Reader, error) {
+	return NewDecryptingReaderContext(context.Background(), r, key)
+}
+
+// NewDecryptingReaderContext is NewDecryptingReader for a reader that
+// fails with ctx.Err() at the next chunk once ctx is done
+func NewDecryptingReaderContext(ctx context.Context, r io.Reader, key []byte) (io.Reader, error) {
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, StreamHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrStreamTruncated
+		}
+		return nil, err
+	}
+
+	chunkSize, err := parseStreamHeader(header)
+	if err != nil {
+		return nil, err
+	}
+
+	dr := newDecryptingReader(ctx, r, aead, header, header[9:], chunkSize)
+	if header[4] == streamFooterVersion {
+		dr.footer = newFooterHold(chunkSize)
+	}
+	return dr, nil
+}
+
+// parseStreamHeader checks a stream header and returns its chunk size
+func parseStreamHeader(header []byte) (int, error) {
+	if string(header[:4]) != streamMagic {
+		return 0, fmt.Errorf("not an EAMSA 512 stream")
+	}
+	if header[4] != streamVersion && header[4] != streamFooterVersion {
+		return 0, fmt.Errorf("unsupported stream version %d", header[4])
+	}
+
+	chunkSize := int(binary.BigEndian.Uint32(header[5:9]))
+	if chunkSize <= 0 || chunkSize > MaxChunkSize {
+		return 0, fmt.Errorf("invalid chunk size %d in stream header", chunkSize)
+	}
+	return chunkSize, nil
+}
+
+// newDecryptingReader returns a reader of the chunks that follow header
+// in r (see newEncryptingWriter)
+func newDecryptingReader(ctx context.Context, r io.Reader, aead *eamsaAEAD, header, prefix []byte, chunkSize int) *decryptingReader {
+	nonce := make([]byte, NonceSize)
+	copy(nonce, prefix[:streamPrefixSize])
+
+	return &decryptingReader{
+		ctx:       ctx,
+		r:         bufio.NewReader(r),
+		aead:      aead,
+		header:    header,
+		nonce:     nonce,
+		chunkSize: chunkSize,
+		sealed:    make([]byte, streamSealedSize(chunkSize)),
+	}
+}
+
+// Read returns decrypted plaintext, opening chunks as needed
+func (dr *decryptingReader) Read(p []byte) (int, error) {
+	for len(dr.plaintext) == 0 {
+		if dr.err != nil {
+			return 0, dr.err
+		}
+		if dr.done {
+			return 0, io.EOF
+		}
+		dr.err = dr.next()
+	}
+
+	n := copy(p, dr.plaintext)
+	dr.plaintext = dr.plaintext[n:]
+	return n, nil
+}
+
+// next reads, authenticates and decrypts one chunk
+func (dr *decryptingReader) next() error {
+	if err := dr.ctx.Err(); err != nil {
+		return err
+	}
+	if dr.chunk >= streamMaxChunks {
+		return fmt.Errorf("stream too long: more than %d chunks", uint64(streamMaxChunks))
+	}
+
+	n, err := io.ReadFull(dr.r, dr.sealed)
+	switch {
+	case err == io.EOF:
+		return ErrStreamTruncated
+	case err == io.ErrUnexpectedEOF:
+		// A short chunk can only be the last one
+	case err != nil:
+		return err
+	default:
+		// A full-size chunk is the last one if nothing follows it
+		if _, err := dr.r.Peek(1); err != nil && err != io.EOF {
+			return err
+		}
+	}
+
+	final := n < len(dr.sealed) || dr.r.Buffered() == 0
+	plaintext, err := openChunk(dr.aead, dr.nonce, dr.sealed[:n], dr.header, dr.chunk, final, dr.chunkSize)
+	if err != nil {
+		return err
+	}
+
+	if dr.footer != nil {
+		if plaintext, err = dr.footer.hold(plaintext, final); err != nil {
+			return err
+		}
+	}
+
+	dr.chunk++
+	dr.plaintext = plaintext
+	dr.done = final
+	return nil
+}
+
+// openChunk authenticates and decrypts sealed chunk number chunk in
+// place, using nonce (which starts with the nonce prefix) as scratch
+func openChunk(aead *eamsaAEAD, nonce, sealed, header []byte, chunk uint64, final bool, chunkSize int) ([]byte, error) {
+	setStreamNonce(nonce, chunk, final)
+
+	plaintext, err := aead.Open(sealed[:0], nonce, sealed, header)
+	if err != nil {
+		if final {
+			// A non-final chunk at the end of the input: the rest is missing
+			// (the second check is not reported to telemetry)
+			setStreamNonce(nonce, chunk, false)
+			if _, err := aead.open(nil, nonce, sealed, header); err == nil {
+				return nil, ErrStreamTruncated
+			}
+		}
+		return nil, fmt.Errorf("chunk %d failed authentication", chunk)
+	}
+
+	if !final && len(plaintext) != chunkSize {
+		return nil, fmt.Errorf("chunk %d has %d bytes, expected %d", chunk, len(plaintext), chunkSize)
+	}
+	return plaintext, nil
+}
+
+// hold returns the data of a version 2 chunk that can be released: all
+// but the last StreamFooterSize bytes seen, which are kept back. At the
+// final chunk those bytes are the footer and are checked against the data
+// released.
+func (f *footerHold) hold(plaintext []byte, final bool) ([]byte, error) {
+	f.out = append(append(f.out[:0], f.held...), plaintext...)
+
+	split := len(f.out) - StreamFooterSize
+	if split < 0 {
+		if final {
+			return nil, fmt.Errorf("stream footer missing")
+		}
+		split = 0
+	}
+	release := f.out[:split]
+	f.held = append(f.held[:0], f.out[split:]...)
+
+	f.hash.Write(release)
+	f.footer.Length += uint64(len(release))
+
+	if final {
+		f.hash.Sum(f.footer.Digest[:0])
+		if !bytes.Equal(f.footer.marshal(), f.held) {
+			return nil, fmt.Errorf("stream footer does not match its data")
+		}
+	}
+	return release, nil
+}
+
+// DecryptStreamAt decrypts a stream of size bytes read from r, such as a
+// spooled file, to w. Unlike NewDecryptingReader it authenticates and
+// decrypts up to Workers() chunks at once, holding that many in memory;
+// the plaintext is written in order, and nothing of a chunk is written
+// before every chunk before it has verified. Errors are those of
+// NewDecryptingReader. It returns the number of bytes written.
+func DecryptStreamAt(ctx context.Context, w io.Writer, r io.ReaderAt, size int64, key []byte) (int64, error) {
+	aead, err := streamAEAD(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if size < StreamHeaderSize {
+		return 0, ErrStreamTruncated
+	}
+	header := make([]byte, StreamHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return 0, err
+	}
+	chunkSize, err := parseStreamHeader(header)
+	if err != nil {
+		return 0, err
+	}
+
+	var footer *footerHold
+	if header[4] == streamFooterVersion {
+		footer = newFooterHold(chunkSize)
+	}
+	body := io.NewSectionReader(r, StreamHeaderSize, size-StreamHeaderSize)
+	return decryptChunksAt(ctx, w, body, aead, header, header[9:], chunkSize, footer)
+}
+
+// decryptChunksAt authenticates and decrypts the chunks in body, a batch
+// of Workers() at a time, and writes the plaintext of each batch to w in
+// order (see newDecryptingReader for header and prefix)
+func decryptChunksAt(ctx context.Context, w io.Writer, body *io.SectionReader, aead *eamsaAEAD, header, prefix []byte, chunkSize int, footer *footerHold) (int64, error) {
+	sealedSize := int64(streamSealedSize(chunkSize))
+	chunks := (body.Size() + sealedSize - 1) / sealedSize
+	if chunks == 0 {
+		return 0, ErrStreamTruncated
+	}
+	if chunks > streamMaxChunks {
+		return 0, fmt.Errorf("stream too long: more than %d chunks", uint64(streamMaxChunks))
+	}
+
+	batch := int(min(int64(Workers()), chunks))
+	sealed := make([][]byte, batch)
+	plaintext := make([][]byte, batch)
+	errs := make([]error, batch)
+	for i := range sealed {
+		sealed[i] = make([]byte, sealedSize)
+	}
+
+	var written int64
+	for first := int64(0); first < chunks; first += int64(batch) {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		n := int(min(int64(batch), chunks-first))
+		var wg sync.WaitGroup
+		for i := 0; i < n; i++ {
+			wg.Add(1)
+			go func(i int, chunk int64) {
+				defer wg.Done()
+				plaintext[i], errs[i] = openChunkAt(body, sealed[i], aead, header, prefix, chunk, chunk == chunks-1, chunkSize)
+			}(i, first+int64(i))
+		}
+		wg.Wait()
+
+		for i := 0; i < n; i++ {
+			if errs[i] != nil {
+				return written, errs[i]
+			}
+
+			out := plaintext[i]
+			if footer != nil {
+				var err error
+				if out, err = footer.hold(out, first+int64(i) == chunks-1); err != nil {
+					return written, err
+				}
+			}
+
+			m, err := w.Write(out)
+			written += int64(m)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// openChunkAt reads chunk number chunk of body into buf and opens it
+func openChunkAt(body *io.SectionReader, buf []byte, aead *eamsaAEAD, header, prefix []byte, chunk int64, final bool, chunkSize int) ([]byte, error) {
+	offset := chunk * int64(len(buf))
+	sealed := buf[:min(int64(len(buf)), body.Size()-offset)]
+	if n, err := body.ReadAt(sealed, offset); n < len(sealed) {
+		return nil, err
+	}
+
+	nonce := make([]byte, NonceSize)
+	copy(nonce, prefix[:streamPrefixSize])
+	return openChunk(aead, nonce, sealed, header, uint64(chunk), final, chunkSize)
+}
@@ -0,0 +1,90 @@
+package eamsa512
+
+import (
+	"fmt"
+	"math"
+)
+
+// Key stretching for master keys that look like they were typed or
+// derived from a passphrase rather than generated. Shannon entropy of a
+// 32-byte key is at most 5 bits per byte (32 distinct values); a random
+// key scores about 4.9 and falls below MinKeyEntropy with negligible
+// probability, while keys made of repeated words or characters fall well
+// below it. The estimate cannot prove a key random: a passing key may
+// still be guessable.
+const (
+	// MinKeyEntropy is the Shannon entropy, in bits per byte, below which
+	// LowEntropyKey reports a KeySize key as low-entropy
+	MinKeyEntropy = 4.0
+
+	// StretchSaltSize is the length of the StretchKey salt
+	StretchSaltSize = 16
+
+	// stretchLabel separates stretched master keys from password keys
+	stretchLabel = "EAMSA512-KEY-STRETCH"
+)
+
+// ShannonEntropy returns the Shannon entropy of data in bits per byte,
+// from its byte frequencies
+func ShannonEntropy(data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+
+	var freq [256]int
+	for _, b := range data {
+		freq[b]++
+	}
+
+	entropy := 0.0
+	n := float64(len(data))
+	for _, count := range freq {
+		if count > 0 {
+			p := float64(count) / n
+			entropy -= p * math.Log2(p)
+		}
+	}
+	return entropy
+}
+
+// LowEntropyKey reports whether a master key scores below MinKeyEntropy,
+// scaled for keys shorter or longer than KeySize
+func LowEntropyKey(key []byte) bool {
+	return ShannonEntropy(key) < MinKeyEntropy*maxEntropy(len(key))/maxEntropy(KeySize)
+}
+
+// maxEntropy is the highest Shannon entropy n bytes can have
+func maxEntropy(n int) float64 {
+	if n > 256 {
+		n = 256
+	}
+	if n < 2 {
+		return 1
+	}
+	return math.Log2(float64(n))
+}
+
+// StretchKey derives a KeySize working key from a low-entropy master key
+// with Argon2id:
+//
+//	Argon2id(masterKey, "EAMSA512-KEY-STRETCH" || salt, params)
+//
+// so that each guess of the master key costs an attacker params. salt is
+// StretchSaltSize random bytes stored with the ciphertext.
+func StretchKey(masterKey, salt []byte, params KDFParams) ([]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+	if len(salt) != StretchSaltSize {
+		return nil, fmt.Errorf("invalid stretch salt size: expected %d, got %d", StretchSaltSize, len(salt))
+	}
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	if params.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("KDF iterations %d exceed the limit of %d", params.Iterations, maxKDFIterations)
+	}
+
+	labelled := append([]byte(stretchLabel), salt...)
+	return derivePasswordKey(masterKey, labelled, params), nil
+}
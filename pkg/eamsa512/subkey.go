@@ -0,0 +1,38 @@
+package eamsa512
+
+import "fmt"
+
+// fileKeyLabel separates DeriveFileKey from every other use of the
+// master key as an HMAC key
+const fileKeyLabel = "EAMSA512-FILE-KEY"
+
+// DeriveFileKey derives the working key of one file from the master key,
+// the file's nonce and a label naming its purpose:
+//
+//	HMAC-SHA3-512(masterKey, "EAMSA512-FILE-KEY" || 0x00 || label || 0x00 || nonce)[:KeySize]
+//
+// Each file is then encrypted and tagged under its own key, so a leaked
+// working key exposes that file only and no single key seals more than one
+// file however large the fleet. The nonce is stored with the file anyway,
+// so nothing extra has to be kept. label must not contain 0x00.
+func DeriveFileKey(masterKey []byte, nonce []byte, label string) ([]byte, error) {
+	if len(masterKey) != KeySize {
+		return nil, fmt.Errorf("invalid master key size: expected %d, got %d", KeySize, len(masterKey))
+	}
+	if len(nonce) != NonceSize {
+		return nil, fmt.Errorf("invalid nonce size: expected %d, got %d", NonceSize, len(nonce))
+	}
+	for i := 0; i < len(label); i++ {
+		if label[i] == 0 {
+			return nil, fmt.Errorf("file key label contains a NUL byte")
+		}
+	}
+
+	mac := NewHMAC(masterKey)
+	mac.Write([]byte(fileKeyLabel))
+	mac.Write([]byte{0})
+	mac.Write([]byte(label))
+	mac.Write([]byte{0})
+	mac.Write(nonce)
+	return mac.Sum()[:KeySize], nil
+}
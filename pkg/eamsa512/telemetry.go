@@ -0,0 +1,43 @@
+package eamsa512
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// observer holds the Telemetry set by SetTelemetry
+type observer struct {
+	telemetry.Telemetry
+}
+
+var libraryTelemetry atomic.Pointer[observer]
+
+// SetTelemetry sets the receiver of operation observations for the whole
+// package: EncryptData, DecryptData, the SealInPlace and OpenInPlace
+// variants and NewAEAD's Seal and Open (and so the stream chunks). nil
+// restores the default no-op. Embedding applications typically pass a
+// telemetry.NewPrometheus collector and serve it on their own /metrics
+// endpoint.
+func SetTelemetry(t telemetry.Telemetry) {
+	if t == nil {
+		libraryTelemetry.Store(nil)
+		return
+	}
+	libraryTelemetry.Store(&observer{t})
+}
+
+// observeEncrypt reports an encryption of n plaintext bytes started at start
+func observeEncrypt(n int, start time.Time, err error) {
+	if o := libraryTelemetry.Load(); o != nil {
+		o.ObserveEncrypt(n, time.Since(start), err)
+	}
+}
+
+// observeDecrypt reports a decryption of n ciphertext bytes started at start
+func observeDecrypt(n int, start time.Time, err error) {
+	if o := libraryTelemetry.Load(); o != nil {
+		o.ObserveDecrypt(n, time.Since(start), err)
+	}
+}
@@ -0,0 +1,177 @@
+package kdf
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+// ============================================================================
+// EAMSA 512 - Key Derivation Test Suite
+// SP 800-56A concatenation KDF and its audit transcripts
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// testInputs returns a fixed master key and nonce
+func testInputs() ([32]byte, [16]byte) {
+	var masterKey [32]byte
+	var nonce [16]byte
+	for i := range masterKey {
+		masterKey[i] = byte(i)
+	}
+	for i := range nonce {
+		nonce[i] = byte(0xf0 + i)
+	}
+	return masterKey, nonce
+}
+
+// TestDeriveKeys tests that derivation is deterministic, that every input
+// changes the keys and that the keys pass ValidateKeys
+func TestDeriveKeys(t *testing.T) {
+	fmt.Println("Test: SP 800-56A Key Derivation")
+
+	masterKey, nonce := testInputs()
+	kdf := New()
+
+	keys, err := kdf.DeriveKeys(masterKey, nonce, nil, 0)
+	if err != nil {
+		t.Fatalf("DeriveKeys failed: %v", err)
+	}
+	again, _ := kdf.DeriveKeys(masterKey, nonce, nil, 0)
+	if keys != again {
+		t.Fatal("Derivation is not deterministic")
+	}
+	if !kdf.ValidateKeys(keys) {
+		t.Fatal("Derived keys failed validation")
+	}
+
+	otherNonce := nonce
+	otherNonce[0] ^= 1
+	for name, derive := range map[string]func() ([KeyCount][KeySize]byte, error){
+		"nonce":         func() ([KeyCount][KeySize]byte, error) { return kdf.DeriveKeys(masterKey, otherNonce, nil, 0) },
+		"shared secret": func() ([KeyCount][KeySize]byte, error) { return kdf.DeriveKeys(masterKey, nonce, []byte("secret"), 0) },
+	} {
+		other, err := derive()
+		if err != nil || other == keys {
+			t.Errorf("Changing the %s did not change the keys: %v", name, err)
+		}
+	}
+
+	// The counter only shifts the sequence: key i at counter 1 is key i+1
+	// at counter 0
+	shifted, _ := kdf.DeriveKeys(masterKey, nonce, nil, 1)
+	if shifted[0] != keys[1] {
+		t.Error("Counter does not index the key sequence")
+	}
+
+	var repeated [KeyCount][KeySize]byte
+	for i := range repeated {
+		repeated[i] = keys[0]
+	}
+	if kdf.ValidateKeys(repeated) {
+		t.Error("Repeated keys passed validation")
+	}
+
+	fmt.Println("✓ Keys deterministic, distinct and bound to every input")
+}
+
+// TestExportKeyingMaterial tests that exports are deterministic, that
+// different labels, lengths and nonces give different output and that
+// bad labels and lengths are refused
+func TestExportKeyingMaterial(t *testing.T) {
+	fmt.Println("Test: Keying Material Export")
+
+	masterKey, nonce := testInputs()
+	kdf := New()
+
+	export := func(label string, length int) []byte {
+		t.Helper()
+		out, err := kdf.ExportKeyingMaterial(masterKey, nonce, label, length)
+		if err != nil {
+			t.Fatalf("ExportKeyingMaterial(%q, %d) failed: %v", label, length, err)
+		}
+		if len(out) != length {
+			t.Fatalf("ExportKeyingMaterial(%q, %d) returned %d bytes", label, length, len(out))
+		}
+		return out
+	}
+
+	client := export("app client key", 100)
+	if !bytes.Equal(client, export("app client key", 100)) {
+		t.Fatal("Export is not deterministic")
+	}
+	if bytes.Equal(client, export("app server key", 100)) {
+		t.Error("Different labels gave the same output")
+	}
+	if bytes.Equal(client[:32], export("app client key", 32)) {
+		t.Error("A shorter export is a prefix of a longer one")
+	}
+
+	otherNonce := nonce
+	otherNonce[0] ^= 1
+	other, err := kdf.ExportKeyingMaterial(masterKey, otherNonce, "app client key", 100)
+	if err != nil || bytes.Equal(client, other) {
+		t.Errorf("Changing the nonce did not change the export: %v", err)
+	}
+
+	keys, _ := kdf.DeriveKeys(masterKey, nonce, nil, 0)
+	for i, key := range keys {
+		if bytes.Contains(client, key[:]) {
+			t.Errorf("Export contains derived key %d", i)
+		}
+	}
+
+	for _, bad := range []struct {
+		label  string
+		length int
+	}{{"", 32}, {"app", 0}, {"app", -1}, {"app", MaxExportLength + 1}} {
+		if _, err := kdf.ExportKeyingMaterial(masterKey, nonce, bad.label, bad.length); err == nil {
+			t.Errorf("ExportKeyingMaterial(%q, %d) was accepted", bad.label, bad.length)
+		}
+	}
+
+	fmt.Println("✓ Exports deterministic and separated by label, length and nonce")
+}
+
+// TestTranscript tests that a recorded transcript verifies against the
+// inputs that produced it and no others
+func TestTranscript(t *testing.T) {
+	fmt.Println("Test: KDF Transcript")
+
+	masterKey, nonce := testInputs()
+	secret := []byte("shared secret")
+
+	var buf bytes.Buffer
+	kdf := New()
+	kdf.SetTranscriptWriter(&buf)
+	if _, err := kdf.DeriveKeys(masterKey, nonce, secret, 5); err != nil {
+		t.Fatalf("DeriveKeys failed: %v", err)
+	}
+
+	var transcript Transcript
+	if err := json.Unmarshal(buf.Bytes(), &transcript); err != nil {
+		t.Fatalf("Transcript is not JSON: %v", err)
+	}
+	if len(transcript.Counters) != KeyCount || transcript.Counters[0] != 6 {
+		t.Fatalf("Transcript counters: %v", transcript.Counters)
+	}
+	if bytes.Contains(buf.Bytes(), secret) {
+		t.Fatal("Transcript contains the shared secret")
+	}
+
+	if err := VerifyTranscript(&transcript, masterKey, nonce, secret); err != nil {
+		t.Fatalf("VerifyTranscript failed: %v", err)
+	}
+	otherKey := masterKey
+	otherKey[31] ^= 1
+	if err := VerifyTranscript(&transcript, otherKey, nonce, secret); err == nil {
+		t.Error("Transcript verified under another master key")
+	}
+	if err := VerifyTranscript(&transcript, masterKey, nonce, []byte("other secret")); err == nil {
+		t.Error("Transcript verified under another shared secret")
+	}
+
+	fmt.Println("✓ Transcripts verify against their own inputs only")
+}
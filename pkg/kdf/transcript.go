@@ -0,0 +1,138 @@
+package kdf
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+)
+
+// TranscriptVersion identifies the transcript schema
+const TranscriptVersion = "1"
+
+// inputLayout documents the per-key hash input of DeriveKeys
+const inputLayout = "counter(4, big-endian) || master_key(32) || nonce(16) || shared_secret(n)"
+
+// Transcript records one DeriveKeys call without its secrets. Counters,
+// layout and algorithm let an auditor check the construction; the
+// fingerprints let anyone holding the inputs re-derive and compare
+// (VerifyTranscript).
+type Transcript struct {
+	Version            string   `json:"version"`
+	Timestamp          string   `json:"timestamp"`
+	Standard           string   `json:"standard"`
+	Algorithm          string   `json:"algorithm"`
+	InputLayout        string   `json:"input_layout"`
+	Counters           []uint32 `json:"counters"`      // One per derived key, starting at counter+1
+	OutputBits         int      `json:"output_bits"`   // Bits taken from each hash
+	MasterKeyID        string   `json:"master_key_id"` // keyid of the master key
+	Nonce              string   `json:"nonce"`         // Hex; nonces are public
+	SharedSecretLength int      `json:"shared_secret_length"`
+	ChaosParamsHash    string   `json:"chaos_params_hash"` // SHA3-256 of the chaos seed and shared secret
+	DerivedKeyIDs      []string `json:"derived_key_ids"`
+}
+
+// NewTranscript builds the transcript of a derivation
+func NewTranscript(masterKey [32]byte, nonce [16]byte, sharedSecret []byte, counter uint32, keys [KeyCount][KeySize]byte) *Transcript {
+	t := &Transcript{
+		Version:            TranscriptVersion,
+		Timestamp:          time.Now().UTC().Format(time.RFC3339Nano),
+		Standard:           "NIST SP 800-56A Rev. 3, 5.8.1",
+		Algorithm:          "SHA3-512",
+		InputLayout:        inputLayout,
+		OutputBits:         8 * KeySize,
+		MasterKeyID:        keyid.New(masterKey[:]).String(),
+		Nonce:              hex.EncodeToString(nonce[:]),
+		SharedSecretLength: len(sharedSecret),
+		ChaosParamsHash:    chaosParamsHash(masterKey, nonce, sharedSecret),
+	}
+
+	for i := range keys {
+		t.Counters = append(t.Counters, counter+uint32(i+1))
+		t.DerivedKeyIDs = append(t.DerivedKeyIDs, keyid.New(keys[i][:]).String())
+	}
+
+	return t
+}
+
+// chaosParamsHash commits to the chaos inputs without revealing them
+func chaosParamsHash(masterKey [32]byte, nonce [16]byte, sharedSecret []byte) string {
+	var seed [8]byte
+	binary.BigEndian.PutUint64(seed[:], uint64(ChaosSeed(masterKey[:], nonce[:])))
+
+	h := sha3.New256()
+	h.Write([]byte("EAMSA512-KDF-TRANSCRIPT"))
+	h.Write(seed[:])
+	h.Write(sharedSecret)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyTranscript re-derives the keys from the secret inputs and checks
+// the transcript describes that derivation
+func VerifyTranscript(t *Transcript, masterKey [32]byte, nonce [16]byte, sharedSecret []byte) error {
+	if t.Version != TranscriptVersion {
+		return fmt.Errorf("unsupported transcript version %q", t.Version)
+	}
+	if t.InputLayout != inputLayout || t.Algorithm != "SHA3-512" || t.OutputBits != 8*KeySize {
+		return fmt.Errorf("transcript does not describe the SP 800-56A concatenation KDF")
+	}
+	if len(t.Counters) != KeyCount || len(t.DerivedKeyIDs) != KeyCount {
+		return fmt.Errorf("transcript has %d counters and %d key IDs, expected %d", len(t.Counters), len(t.DerivedKeyIDs), KeyCount)
+	}
+
+	// Derive without recording another transcript
+	keys, err := New().DeriveKeys(masterKey, nonce, sharedSecret, t.Counters[0]-1)
+	if err != nil {
+		return err
+	}
+
+	want := NewTranscript(masterKey, nonce, sharedSecret, t.Counters[0]-1, keys)
+	switch {
+	case t.MasterKeyID != want.MasterKeyID:
+		return fmt.Errorf("master key does not match %s", t.MasterKeyID)
+	case t.Nonce != want.Nonce:
+		return fmt.Errorf("nonce does not match %s", t.Nonce)
+	case t.SharedSecretLength != want.SharedSecretLength || t.ChaosParamsHash != want.ChaosParamsHash:
+		return fmt.Errorf("shared secret does not match the chaos parameters hash")
+	}
+	for i := range want.Counters {
+		if t.Counters[i] != want.Counters[i] {
+			return fmt.Errorf("counter %d is %d, expected %d", i, t.Counters[i], want.Counters[i])
+		}
+		if t.DerivedKeyIDs[i] != want.DerivedKeyIDs[i] {
+			return fmt.Errorf("derived key %d does not match %s", i, t.DerivedKeyIDs[i])
+		}
+	}
+
+	return nil
+}
+
+// transcriptLog writes transcripts as JSON lines
+type transcriptLog struct {
+	enc *json.Encoder
+	mu  sync.Mutex
+}
+
+func (l *transcriptLog) record(t *Transcript) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.enc.Encode(t)
+}
+
+// SetTranscriptWriter records a Transcript as one JSON line on w for
+// every derivation; nil turns recording off. A derivation whose
+// transcript cannot be written fails.
+func (kdf *Concat) SetTranscriptWriter(w io.Writer) {
+	if w == nil {
+		kdf.transcripts = nil
+		return
+	}
+	kdf.transcripts = &transcriptLog{enc: json.NewEncoder(w)}
+}
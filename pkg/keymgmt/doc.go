@@ -0,0 +1,23 @@
+// Package keymgmt is EAMSA 512 key management, in two parts.
+//
+// KeyManager versions a data key (see keys.go). One version is active
+// and encrypts; rotated versions stay available for decryption until the
+// retention policy archives and erases them. Keys can be scheduled for
+// activation at a later time (ScheduleKey), or pre-generated as a standby
+// (PrepareStandby) so that a rotation is a metadata change. Snapshot and
+// RestoreKeyManager persist and reload the versions.
+//
+//	km, err := keymgmt.NewKeyManager(key, keymgmt.Config{Policy: keymgmt.DefaultKeyRotationPolicy()})
+//	key, version, err := km.GetActiveKeyVersion()
+//	...
+//	key, err = km.GetKeyByVersion(version)
+//
+// LifecycleManager tracks the FIPS 140-2 lifecycle of HSM-backed keys
+// (see lifecycle.go): generation, activation, rotation, deactivation and
+// zeroization, each with an audit trail. Keys move only along the edges
+// of the state machine in states.go; docs/key-lifecycle.md is generated
+// from it.
+//
+// The exported identifiers of this package are part of the stable API,
+// like those of pkg/eamsa512.
+package keymgmt
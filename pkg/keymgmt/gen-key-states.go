@@ -0,0 +1,111 @@
+//go:build ignore
+
+// gen-key-states.go - Generates docs/key-lifecycle.md from the key state machine
+//
+// Run with "go generate" in pkg/keymgmt. The transition table is checked
+// before the document is written: every state must be reachable from
+// Generated, every state but Destroyed must lead to Destroyed, and every
+// event must be used.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Redeaux-Corporation/eamsa512/pkg/keymgmt"
+)
+
+// keyStatesDoc is relative to pkg/keymgmt, where go generate runs
+const keyStatesDoc = "../../docs/key-lifecycle.md"
+
+func main() {
+	if err := checkKeyStateMachine(); err != nil {
+		log.Fatalf("key state machine: %v", err)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("# Key Lifecycle State Machine\n\n")
+	b.WriteString("Generated from `pkg/keymgmt/states.go` by `pkg/keymgmt/gen-key-states.go`; do not edit by hand.\n\n")
+	b.WriteString("`keymgmt.LifecycleManager` moves keys only along these edges. Any other\n")
+	b.WriteString("event returns a `*TransitionError` (`errors.Is(err, ErrInvalidTransition)`)\n")
+	b.WriteString("and adds a `KEY_TRANSITION_DENIED` entry to the key's audit trail.\n")
+	b.WriteString("`selftest` drives every state and event through the manager.\n\n")
+	b.WriteString("```mermaid\n")
+	b.WriteString(keymgmt.KeyStateDiagram())
+	b.WriteString("```\n\n")
+
+	b.WriteString("## Transitions\n\n")
+	b.WriteString("Resulting state for each state (rows) and event (columns); — is refused.\n\n")
+	b.WriteString("| State |")
+	for _, event := range keymgmt.KeyEvents() {
+		fmt.Fprintf(&b, " `%s` |", event)
+	}
+	b.WriteString("\n|---|")
+	for range keymgmt.KeyEvents() {
+		b.WriteString("---|")
+	}
+	b.WriteString("\n")
+	for _, from := range keymgmt.KeyStates() {
+		fmt.Fprintf(&b, "| %s |", from)
+		for _, event := range keymgmt.KeyEvents() {
+			if to, err := keymgmt.NextKeyState("", from, event); err == nil {
+				fmt.Fprintf(&b, " %s |", to)
+			} else {
+				b.WriteString(" — |")
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if err := os.WriteFile(keyStatesDoc, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote %s (%d transitions)\n", keyStatesDoc, len(keymgmt.KeyTransitions()))
+}
+
+// checkKeyStateMachine checks reachability and that no event is unused
+func checkKeyStateMachine() error {
+	reached := map[keymgmt.KeyLifecycleState]bool{keymgmt.StateGenerated: true}
+	used := make(map[keymgmt.KeyEvent]bool)
+	for changed := true; changed; {
+		changed = false
+		for _, t := range keymgmt.KeyTransitions() {
+			used[t.Event] = true
+			if reached[t.From] && !reached[t.To] {
+				reached[t.To] = true
+				changed = true
+			}
+		}
+	}
+
+	for _, s := range keymgmt.KeyStates() {
+		if !reached[s] {
+			return fmt.Errorf("state %s is unreachable", s)
+		}
+		if s != keymgmt.StateDestroyed && !leadsTo(s, keymgmt.StateDestroyed, map[keymgmt.KeyLifecycleState]bool{}) {
+			return fmt.Errorf("state %s never leads to %s", s, keymgmt.StateDestroyed)
+		}
+	}
+	for _, e := range keymgmt.KeyEvents() {
+		if !used[e] {
+			return fmt.Errorf("event %s is unused", e)
+		}
+	}
+	return nil
+}
+
+// leadsTo reports whether target can be reached from s
+func leadsTo(s, target keymgmt.KeyLifecycleState, seen map[keymgmt.KeyLifecycleState]bool) bool {
+	if s == target {
+		return true
+	}
+	seen[s] = true
+	for _, t := range keymgmt.KeyTransitions() {
+		if t.From == s && !seen[t.To] && leadsTo(t.To, target, seen) {
+			return true
+		}
+	}
+	return false
+}
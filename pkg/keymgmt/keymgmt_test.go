@@ -0,0 +1,101 @@
+package keymgmt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+)
+
+// ============================================================================
+// EAMSA 512 - Key Management Test Suite
+// Rotation policy validation and the key lifecycle
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// TestKeyRotationPolicyValidate tests that validation reports every
+// invalid field, not just the first
+func TestKeyRotationPolicyValidate(t *testing.T) {
+	fmt.Println("Test: Key Rotation Policy Validation")
+
+	if err := DefaultKeyRotationPolicy().Validate(); err != nil {
+		t.Fatalf("Default policy is invalid: %v", err)
+	}
+
+	policy := DefaultKeyRotationPolicy()
+	policy.IntervalDays = 0
+	policy.RetentionCycles = 0
+	policy.DestructionMethod = "shred"
+
+	var verr *confschema.ValidationError
+	if err := policy.Validate(); !errors.As(err, &verr) {
+		t.Fatalf("Validate: got %v, want a *confschema.ValidationError", err)
+	}
+
+	var fields []string
+	for _, f := range verr.Fields {
+		fields = append(fields, f.Field)
+	}
+	want := []string{"IntervalDays", "MinKeyAgeDays", "RetentionCycles", "DestructionMethod"}
+	if fmt.Sprint(fields) != fmt.Sprint(want) {
+		t.Fatalf("Invalid fields: got %v, want %v", fields, want)
+	}
+
+	var ferr *confschema.FieldError
+	if !errors.As(error(verr), &ferr) || ferr.Field != "IntervalDays" {
+		t.Fatalf("errors.As did not find the first field error: %v", ferr)
+	}
+
+	fmt.Println("✓ Every invalid field is reported")
+}
+
+// TestKeyLifecycle tests that a rotated key stays available for decryption
+// until it is destroyed, and that the active key cannot be destroyed
+func TestKeyLifecycle(t *testing.T) {
+	fmt.Println("Test: Key Lifecycle")
+
+	oldKey := make([]byte, eamsa512.KeySize)
+	newKey := make([]byte, eamsa512.KeySize)
+	rand.Read(oldKey)
+	rand.Read(newKey)
+
+	policy := DefaultKeyRotationPolicy()
+	policy.Enabled = false
+	policy.MinKeyAgeDays = 0
+	km, err := NewKeyManager(oldKey, Config{Policy: policy})
+	if err != nil {
+		t.Fatalf("NewKeyManager failed: %v", err)
+	}
+	defer km.Stop()
+
+	if err := km.RotateKey(newKey); err != nil {
+		t.Fatalf("RotateKey failed: %v", err)
+	}
+	active, version, err := km.GetActiveKeyVersion()
+	if err != nil || version != 2 || !bytes.Equal(active, newKey) {
+		t.Fatalf("Active key after rotation: version %d, %v", version, err)
+	}
+	if metadata, err := km.GetKeyMetadata(1); err != nil || metadata.State != KeyStateRotated {
+		t.Fatalf("Version 1 after rotation: %+v, %v", metadata, err)
+	}
+	if old, err := km.GetKeyByVersion(1); err != nil || !bytes.Equal(old, oldKey) {
+		t.Fatalf("Rotated key unavailable: %v", err)
+	}
+
+	if _, err := km.DestroyKeyVersion(2); err == nil {
+		t.Fatal("Active key version destroyed")
+	}
+	if metadata, err := km.DestroyKeyVersion(1); err != nil || metadata.State != KeyStateDestroyed {
+		t.Fatalf("DestroyKeyVersion: %+v, %v", metadata, err)
+	}
+	if _, err := km.GetKeyByVersion(1); err == nil {
+		t.Fatal("Destroyed key version still available")
+	}
+
+	fmt.Println("✓ Rotated keys decrypt until destroyed")
+}
@@ -0,0 +1,1239 @@
+package keymgmt
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/sha3"
+
+	"github.com/Redeaux-Corporation/eamsa512/confschema"
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/labels"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// Clock is the time source of key expiry, encryption windows and
+// rotation ages. Observe reports the creation time of a restored key,
+// which a guarded clock may take as a floor for the current time.
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Observe(t time.Time, source string)
+}
+
+// SystemClock is the local wall clock
+type SystemClock struct{}
+
+// Now returns time.Now()
+func (SystemClock) Now() time.Time { return time.Now() }
+
+// Since returns time.Since(t)
+func (SystemClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+// Observe does nothing: the wall clock takes no floor
+func (SystemClock) Observe(time.Time, string) {}
+
+// Config is the environment of a KeyManager
+type Config struct {
+	Policy   KeyRotationPolicy
+	AuditLog *log.Logger // Receives the audit trail; nil discards it
+	Clock    Clock       // Nil uses SystemClock
+}
+
+// withDefaults fills in the nil fields of config
+func (config Config) withDefaults() Config {
+	if config.AuditLog == nil {
+		config.AuditLog = log.New(io.Discard, "", 0)
+	}
+	if config.Clock == nil {
+		config.Clock = SystemClock{}
+	}
+	return config
+}
+
+// KeyState represents the state of a key
+type KeyState string
+
+const (
+	KeyStateActive    KeyState = "active"    // Currently in use for encryption/decryption
+	KeyStatePending   KeyState = "pending"   // Scheduled but not yet active
+	KeyStateRotated   KeyState = "rotated"   // Replaced by newer key, available for decryption only
+	KeyStateArchived  KeyState = "archived"  // Archived after retention period
+	KeyStateDestroyed KeyState = "destroyed" // Securely destroyed
+)
+
+// KeyMetadata contains information about a key
+type KeyMetadata struct {
+	ID              string     `json:"id"`                        // Unique key identifier
+	Version         int        `json:"version"`                   // Key version number
+	State           KeyState   `json:"state"`                     // Current state
+	CreatedAt       time.Time  `json:"created_at"`                // Creation timestamp
+	ActivatedAt     time.Time  `json:"activated_at"`              // When key became active
+	RotatedAt       time.Time  `json:"rotated_at"`                // When key was rotated
+	ArchivedAt      time.Time  `json:"archived_at"`               // When key was archived
+	DestroyedAt     time.Time  `json:"destroyed_at"`              // When key was destroyed
+	ActivateAt      time.Time  `json:"activate_at"`               // Scheduled activation time (pending keys)
+	NotAfter        time.Time  `json:"not_after"`                 // End of encryption window (zero = no limit)
+	Labels          labels.Set `json:"labels,omitempty"`          // Scoping labels (region, tenant, classification)
+	KeyHash         string     `json:"key_hash"`                  // Standard key identifier (keyid format)
+	RenumberedFrom  int        `json:"renumbered_from,omitempty"` // Version before a replication conflict moved the key (see RenumberedTo)
+	EncryptionCount int64      `json:"encryption_count"`          // Number of encryptions with this key
+	DecryptionCount int64      `json:"decryption_count"`          // Number of decryptions with this key
+}
+
+// KeyEntry represents a stored key with metadata
+type KeyEntry struct {
+	Metadata  KeyMetadata
+	Material  []byte // Encrypted key material (never stored unencrypted)
+	ExpiresAt time.Time
+}
+
+// KeyRotationPolicy defines the key rotation schedule and rules
+type KeyRotationPolicy struct {
+	// Automatic rotation enabled
+	Enabled bool
+
+	// Rotation interval (days)
+	IntervalDays int
+
+	// Retention cycles: how many old keys to keep
+	RetentionCycles int
+
+	// Maximum key age (days) - if exceeded, force rotation immediately
+	MaxKeyAgeDays int
+
+	// Minimum key age (days) - cannot rotate before this
+	MinKeyAgeDays int
+
+	// Archive location for rotated keys
+	ArchiveLocation string
+
+	// Destruction method: "overwrite" (default), "zero", "random"
+	DestructionMethod string
+
+	// Number of overwrite passes for destruction
+	DestructionPasses int
+
+	// Keep a pre-generated standby key (see PrepareStandby); the scheduler
+	// audits KEY_STANDBY_MISSING while none exists
+	WarmStandby bool
+
+	// Source of new key material, e.g. a TRNG device (nil: crypto/rand)
+	Entropy eamsa512.EntropySource
+}
+
+// DefaultKeyRotationPolicy returns sensible defaults for FIPS 140-2 compliance
+func DefaultKeyRotationPolicy() KeyRotationPolicy {
+	return KeyRotationPolicy{
+		Enabled:           true,
+		IntervalDays:      365,
+		RetentionCycles:   3,
+		MaxKeyAgeDays:     730,
+		MinKeyAgeDays:     30,
+		ArchiveLocation:   "/var/lib/eamsa512/key-archive/",
+		DestructionMethod: "random",
+		DestructionPasses: 3,
+	}
+}
+
+// Validate returns a *confschema.ValidationError listing every invalid
+// field
+func (policy KeyRotationPolicy) Validate() error {
+	v := confschema.NewValidator("KeyRotationPolicy")
+
+	v.Min("IntervalDays", policy.IntervalDays, 1)
+	v.Check(policy.MaxKeyAgeDays > policy.IntervalDays, "MaxKeyAgeDays", policy.MaxKeyAgeDays,
+		fmt.Sprintf("more than IntervalDays (%d)", policy.IntervalDays))
+	v.Range("MinKeyAgeDays", policy.MinKeyAgeDays, 0, policy.IntervalDays)
+	v.Min("RetentionCycles", policy.RetentionCycles, 1)
+
+	switch policy.DestructionMethod {
+	case "random", "overwrite":
+		v.Min("DestructionPasses", policy.DestructionPasses, 1)
+	case "zero", "": // "" is the documented default
+	default:
+		v.OneOf("DestructionMethod", policy.DestructionMethod, "overwrite", "zero", "random")
+	}
+
+	return v.Err()
+}
+
+// KeyManager manages the versions of a data key: the active one,
+// scheduled and standby ones, and the rotated ones kept for decryption
+type KeyManager struct {
+	mu sync.RWMutex
+
+	// Active key
+	activeKey *KeyEntry
+
+	// Key history (version -> KeyEntry)
+	history map[int]*KeyEntry
+
+	// Current version number
+	currentVersion int
+
+	// Rotation policy
+	policy KeyRotationPolicy
+
+	// Last rotation time
+	lastRotationTime time.Time
+
+	// Rotation ticker
+	rotationTicker *time.Ticker
+
+	// Audit logger
+	auditLogger *log.Logger
+
+	// Time of expiry and rotation decisions
+	clock Clock
+
+	// Receiver of key lifecycle events
+	telemetry telemetry.Telemetry
+
+	// Stop channel for background operations
+	stopCh chan struct{}
+}
+
+// NewKeyManager creates a key manager whose active key, version 1, is
+// initialKey
+func NewKeyManager(initialKey []byte, config Config) (*KeyManager, error) {
+	if len(initialKey) != eamsa512.KeySize {
+		return nil, fmt.Errorf("invalid initial key size: expected %d bytes, got %d", eamsa512.KeySize, len(initialKey))
+	}
+	config = config.withDefaults()
+
+	// Create initial key entry
+	initialMetadata := KeyMetadata{
+		ID:          fmt.Sprintf("key_%d", 1),
+		Version:     1,
+		State:       KeyStateActive,
+		CreatedAt:   time.Now(),
+		ActivatedAt: time.Now(),
+		KeyHash:     hashKey(initialKey),
+	}
+
+	keyEntry := &KeyEntry{
+		Metadata:  initialMetadata,
+		Material:  initialKey,
+		ExpiresAt: config.Clock.Now().AddDate(0, 0, config.Policy.MaxKeyAgeDays),
+	}
+
+	km := &KeyManager{
+		activeKey:        keyEntry,
+		history:          make(map[int]*KeyEntry),
+		currentVersion:   1,
+		policy:           config.Policy,
+		lastRotationTime: time.Now(),
+		auditLogger:      config.AuditLog,
+		clock:            config.Clock,
+		telemetry:        telemetry.Nop{},
+		stopCh:           make(chan struct{}),
+	}
+
+	// Store in history
+	km.history[1] = keyEntry
+
+	// Log key creation
+	km.auditLogger.Printf("KEY_CREATED version=%d hash=%s", initialMetadata.Version, initialMetadata.KeyHash)
+
+	// Start automatic rotation scheduler if enabled
+	if config.Policy.Enabled {
+		go km.rotationScheduler()
+	}
+
+	return km, nil
+}
+
+// RestoreKeyManager rebuilds a key manager from persisted entries (see
+// Snapshot). Exactly one entry must be active.
+func RestoreKeyManager(entries []*KeyEntry, config Config) (*KeyManager, error) {
+	config = config.withDefaults()
+	km := &KeyManager{
+		history:     make(map[int]*KeyEntry),
+		policy:      config.Policy,
+		auditLogger: config.AuditLog,
+		clock:       config.Clock,
+		telemetry:   telemetry.Nop{},
+		stopCh:      make(chan struct{}),
+	}
+
+	for _, entry := range entries {
+		version := entry.Metadata.Version
+		if _, exists := km.history[version]; exists {
+			return nil, fmt.Errorf("duplicate key version %d", version)
+		}
+		km.history[version] = entry
+
+		// A key cannot have been created after the current time
+		km.clock.Observe(entry.Metadata.CreatedAt, fmt.Sprintf("key version %d", version))
+
+		if version > km.currentVersion {
+			km.currentVersion = version
+		}
+
+		if entry.Metadata.State == KeyStateActive {
+			if km.activeKey != nil {
+				return nil, fmt.Errorf("key versions %d and %d are both active", km.activeKey.Metadata.Version, version)
+			}
+			km.activeKey = entry
+			km.lastRotationTime = entry.Metadata.ActivatedAt
+		}
+	}
+
+	if km.activeKey == nil {
+		return nil, fmt.Errorf("no active key version")
+	}
+
+	km.auditLogger.Printf("KEY_MANAGER_RESTORED versions=%d active_version=%d", len(km.history), km.activeKey.Metadata.Version)
+
+	if config.Policy.Enabled {
+		go km.rotationScheduler()
+	}
+
+	return km, nil
+}
+
+// Snapshot returns a copy of every key entry, in version order, for
+// persistence. Archived and destroyed entries have no material.
+func (km *KeyManager) Snapshot() []KeyEntry {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entries := make([]KeyEntry, 0, len(km.history))
+	for version := 1; version <= km.currentVersion; version++ {
+		entry, exists := km.history[version]
+		if !exists {
+			continue
+		}
+
+		copied := *entry
+		copied.Metadata.Labels = entry.Metadata.Labels.Copy()
+		copied.Material = append([]byte(nil), entry.Material...)
+		entries = append(entries, copied)
+	}
+
+	return entries
+}
+
+// hashKey computes the audit-safe key identifier ("sha3-512:128:<hex>")
+func hashKey(key []byte) string {
+	return keyid.New(key).String()
+}
+
+// FindKeyVersionByHash returns the version whose identifier matches keyHash
+// Accepts canonical and legacy (bare hex) identifiers
+func (km *KeyManager) FindKeyVersionByHash(keyHash string) (int, error) {
+	id, err := keyid.Parse(keyHash)
+	if err != nil {
+		return 0, err
+	}
+
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	for version, entry := range km.history {
+		entryID, err := keyid.Parse(entry.Metadata.KeyHash)
+		if err != nil {
+			continue
+		}
+		if entryID.Equal(id) {
+			return version, nil
+		}
+	}
+
+	return 0, fmt.Errorf("no key version matches identifier %s", keyHash)
+}
+
+// GetActiveKey returns the currently active key for encryption
+// Pending keys whose activation time has passed are activated first
+func (km *KeyManager) GetActiveKey() ([]byte, error) {
+	key, _, err := km.GetActiveKeyVersion()
+	return key, err
+}
+
+// GetActiveKeyVersion is GetActiveKey that also returns the key's version,
+// read under the same lock so a concurrent rotation cannot split them
+func (km *KeyManager) GetActiveKeyVersion() ([]byte, int, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	now := km.clock.Now()
+	km.activateDueKeys(now)
+
+	if km.activeKey == nil {
+		return nil, 0, fmt.Errorf("no active key available")
+	}
+
+	// Check if key has expired
+	if now.After(km.activeKey.ExpiresAt) {
+		return nil, 0, fmt.Errorf("active key has expired")
+	}
+
+	// Enforce the encryption window
+	if !km.activeKey.Metadata.NotAfter.IsZero() && now.After(km.activeKey.Metadata.NotAfter) {
+		return nil, 0, fmt.Errorf("active key version %d encryption window closed at %s",
+			km.activeKey.Metadata.Version, km.activeKey.Metadata.NotAfter.Format(time.RFC3339))
+	}
+
+	return km.activeKey.Material, km.activeKey.Metadata.Version, nil
+}
+
+// GetKeyByVersion retrieves a specific key version
+func (km *KeyManager) GetKeyByVersion(version int) ([]byte, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return nil, fmt.Errorf("key version %d not found", version)
+	}
+
+	// Allow retrieval of active, rotated and pending keys (for decryption).
+	// Pending keys are staged so that hosts which have not yet activated a
+	// key can decrypt data from hosts that already have.
+	if entry.Metadata.State != KeyStateActive &&
+		entry.Metadata.State != KeyStateRotated &&
+		entry.Metadata.State != KeyStatePending {
+		return nil, fmt.Errorf("key version %d is not available (state: %s)",
+			version, entry.Metadata.State)
+	}
+
+	return entry.Material, nil
+}
+
+// RotateKey performs immediate key rotation
+func (km *KeyManager) RotateKey(newKey []byte) error {
+	if len(newKey) != eamsa512.KeySize {
+		return fmt.Errorf("invalid new key size: expected %d bytes, got %d", eamsa512.KeySize, len(newKey))
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	// Check minimum key age
+	if km.clock.Since(km.lastRotationTime).Hours() < float64(km.policy.MinKeyAgeDays*24) {
+		return fmt.Errorf("cannot rotate key before minimum age of %d days", km.policy.MinKeyAgeDays)
+	}
+
+	// Create new key entry
+	km.currentVersion++
+	newMetadata := KeyMetadata{
+		ID:          fmt.Sprintf("key_%d", km.currentVersion),
+		Version:     km.currentVersion,
+		State:       KeyStateActive,
+		CreatedAt:   time.Now(),
+		ActivatedAt: time.Now(),
+		KeyHash:     hashKey(newKey),
+	}
+
+	newEntry := &KeyEntry{
+		Metadata:  newMetadata,
+		Material:  newKey,
+		ExpiresAt: km.clock.Now().AddDate(0, 0, km.policy.MaxKeyAgeDays),
+	}
+
+	km.history[km.currentVersion] = newEntry
+	km.telemetry.ObserveKeyEvent(telemetry.KeyGenerated, newMetadata.ID)
+	km.activateEntry(newEntry)
+
+	return nil
+}
+
+// activateEntry makes entry the active key and rotates out the previous one
+// Caller must hold km.mu
+func (km *KeyManager) activateEntry(entry *KeyEntry) {
+	now := time.Now()
+
+	// Mark old key as rotated
+	if km.activeKey != nil {
+		km.activeKey.Metadata.State = KeyStateRotated
+		km.activeKey.Metadata.RotatedAt = now
+
+		km.auditLogger.Printf("KEY_ROTATED version=%d old_hash=%s at=%s",
+			km.activeKey.Metadata.Version,
+			km.activeKey.Metadata.KeyHash,
+			km.activeKey.Metadata.RotatedAt.Format(time.RFC3339))
+		km.telemetry.ObserveKeyEvent(telemetry.KeyRotated, km.activeKey.Metadata.ID)
+	}
+
+	// A standby older than the new key would roll it back if promoted
+	if standby := km.standby(); standby != nil && standby.Metadata.Version < entry.Metadata.Version {
+		km.discardStandby(standby)
+	}
+
+	entry.Metadata.State = KeyStateActive
+	entry.Metadata.ActivatedAt = now
+
+	// Update active key
+	km.activeKey = entry
+	km.lastRotationTime = now
+
+	// Archive old keys if retention limit exceeded
+	km.archiveOldKeys()
+
+	// Log rotation
+	km.auditLogger.Printf("KEY_ROTATED_NEW version=%d new_hash=%s",
+		entry.Metadata.Version, entry.Metadata.KeyHash)
+	km.telemetry.ObserveKeyEvent(telemetry.KeyActivated, entry.Metadata.ID)
+}
+
+// ============================================================================
+// Scheduled Activation
+// ============================================================================
+
+// ScheduleKey stages a new key in pending state for activation at activateAt
+// notAfter optionally closes the key's encryption window (zero = no limit).
+// Pending keys are available for decryption immediately, so a fleet can
+// receive the key before any host starts encrypting with it.
+// Returns the new key version.
+func (km *KeyManager) ScheduleKey(newKey []byte, activateAt time.Time, notAfter time.Time) (int, error) {
+	if len(newKey) != eamsa512.KeySize {
+		return 0, fmt.Errorf("invalid new key size: expected %d bytes, got %d", eamsa512.KeySize, len(newKey))
+	}
+
+	if !notAfter.IsZero() && !notAfter.After(activateAt) {
+		return 0, fmt.Errorf("encryption window must end after activation time")
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	for _, entry := range km.history {
+		if entry.Metadata.State == KeyStatePending && entry.Metadata.ActivateAt.Equal(activateAt) {
+			return 0, fmt.Errorf("key version %d is already scheduled for %s",
+				entry.Metadata.Version, activateAt.Format(time.RFC3339))
+		}
+	}
+
+	km.currentVersion++
+	entry := &KeyEntry{
+		Metadata: KeyMetadata{
+			ID:         fmt.Sprintf("key_%d", km.currentVersion),
+			Version:    km.currentVersion,
+			State:      KeyStatePending,
+			CreatedAt:  time.Now(),
+			ActivateAt: activateAt,
+			NotAfter:   notAfter,
+			KeyHash:    hashKey(newKey),
+		},
+		Material:  newKey,
+		ExpiresAt: activateAt.AddDate(0, 0, km.policy.MaxKeyAgeDays),
+	}
+
+	km.history[km.currentVersion] = entry
+
+	km.auditLogger.Printf("KEY_SCHEDULED version=%d hash=%s activate_at=%s not_after=%s",
+		entry.Metadata.Version, entry.Metadata.KeyHash,
+		activateAt.Format(time.RFC3339), formatOptionalTime(notAfter))
+	km.telemetry.ObserveKeyEvent(telemetry.KeyGenerated, entry.Metadata.ID)
+
+	return entry.Metadata.Version, nil
+}
+
+// CancelScheduledKey destroys a pending key before it activates
+func (km *KeyManager) CancelScheduledKey(version int) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return fmt.Errorf("key version %d not found", version)
+	}
+
+	if entry.Metadata.State != KeyStatePending {
+		return fmt.Errorf("key version %d is not pending (state: %s)", version, entry.Metadata.State)
+	}
+
+	km.securelyEraseKey(entry)
+	entry.Metadata.State = KeyStateDestroyed
+	entry.Metadata.DestroyedAt = time.Now()
+
+	km.auditLogger.Printf("KEY_SCHEDULE_CANCELLED version=%d hash=%s", version, entry.Metadata.KeyHash)
+	km.telemetry.ObserveKeyEvent(telemetry.KeyDestroyed, entry.Metadata.ID)
+
+	return nil
+}
+
+// ListPendingKeys returns metadata of keys awaiting activation
+func (km *KeyManager) ListPendingKeys() []KeyMetadata {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	pending := make([]KeyMetadata, 0)
+	for _, entry := range km.history {
+		if entry.Metadata.State == KeyStatePending {
+			pending = append(pending, entry.Metadata)
+		}
+	}
+
+	return pending
+}
+
+// activateDueKeys activates pending keys whose activation time has passed,
+// in version order, so the newest due key ends up active
+// Caller must hold km.mu
+func (km *KeyManager) activateDueKeys(now time.Time) {
+	for version := 1; version <= km.currentVersion; version++ {
+		entry, exists := km.history[version]
+		if !exists || entry.Metadata.State != KeyStatePending {
+			continue
+		}
+
+		// Standby keys wait for PromoteStandby
+		if entry.Metadata.ActivateAt.IsZero() || now.Before(entry.Metadata.ActivateAt) {
+			continue
+		}
+
+		// A newer key was rotated in manually; keep it decrypt-only
+		if km.activeKey != nil && km.activeKey.Metadata.Version > version {
+			entry.Metadata.State = KeyStateRotated
+			entry.Metadata.RotatedAt = now
+			km.auditLogger.Printf("KEY_SCHEDULE_SUPERSEDED version=%d active_version=%d",
+				version, km.activeKey.Metadata.Version)
+			km.telemetry.ObserveKeyEvent(telemetry.KeyDeactivated, entry.Metadata.ID)
+			continue
+		}
+
+		km.auditLogger.Printf("KEY_SCHEDULED_ACTIVATION version=%d scheduled=%s",
+			version, entry.Metadata.ActivateAt.Format(time.RFC3339))
+		km.activateEntry(entry)
+	}
+}
+
+// ============================================================================
+// Warm Standby
+// ============================================================================
+
+// PrepareStandby stores the next key version ahead of time, in pending state
+// without an activation time, so that a later rotation is a metadata change
+// (PromoteStandby) rather than key generation. Like scheduled keys, the
+// standby is available for decryption once stored. Returns the new version.
+func (km *KeyManager) PrepareStandby(newKey []byte) (int, error) {
+	if len(newKey) != eamsa512.KeySize {
+		return 0, fmt.Errorf("invalid new key size: expected %d bytes, got %d", eamsa512.KeySize, len(newKey))
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if standby := km.standby(); standby != nil {
+		return 0, fmt.Errorf("standby key version %d already exists", standby.Metadata.Version)
+	}
+
+	km.currentVersion++
+	entry := &KeyEntry{
+		Metadata: KeyMetadata{
+			ID:        fmt.Sprintf("key_%d", km.currentVersion),
+			Version:   km.currentVersion,
+			State:     KeyStatePending,
+			CreatedAt: time.Now(),
+			KeyHash:   hashKey(newKey),
+		},
+		Material: newKey,
+	}
+
+	km.history[km.currentVersion] = entry
+
+	km.auditLogger.Printf("KEY_STANDBY_PREPARED version=%d hash=%s", entry.Metadata.Version, entry.Metadata.KeyHash)
+	km.telemetry.ObserveKeyEvent(telemetry.KeyGenerated, entry.Metadata.ID)
+
+	return entry.Metadata.Version, nil
+}
+
+// PromoteStandby makes the standby key active. It is subject to the same
+// minimum key age as RotateKey. Returns the promoted version.
+func (km *KeyManager) PromoteStandby() (int, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry := km.standby()
+	if entry == nil {
+		return 0, fmt.Errorf("no standby key")
+	}
+
+	if km.clock.Since(km.lastRotationTime).Hours() < float64(km.policy.MinKeyAgeDays*24) {
+		return 0, fmt.Errorf("cannot rotate key before minimum age of %d days", km.policy.MinKeyAgeDays)
+	}
+
+	entry.ExpiresAt = km.clock.Now().AddDate(0, 0, km.policy.MaxKeyAgeDays)
+	km.auditLogger.Printf("KEY_STANDBY_PROMOTED version=%d prepared_at=%s",
+		entry.Metadata.Version, entry.Metadata.CreatedAt.Format(time.RFC3339))
+	km.activateEntry(entry)
+
+	return entry.Metadata.Version, nil
+}
+
+// StandbyKey returns the metadata of the standby key, if any
+func (km *KeyManager) StandbyKey() (KeyMetadata, bool) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if entry := km.standby(); entry != nil {
+		return entry.Metadata, true
+	}
+	return KeyMetadata{}, false
+}
+
+// CheckStandby returns an error if policy.WarmStandby is set and no
+// standby key exists
+func (km *KeyManager) CheckStandby() error {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if km.policy.WarmStandby && km.standby() == nil {
+		return fmt.Errorf("no standby key (active version %d)", km.activeKey.Metadata.Version)
+	}
+	return nil
+}
+
+// standby returns the standby entry, or nil
+// Caller must hold km.mu
+func (km *KeyManager) standby() *KeyEntry {
+	for _, entry := range km.history {
+		if entry.Metadata.State == KeyStatePending && entry.Metadata.ActivateAt.IsZero() {
+			return entry
+		}
+	}
+	return nil
+}
+
+// discardStandby destroys a standby superseded by a newer key; it was
+// never used to encrypt
+// Caller must hold km.mu
+func (km *KeyManager) discardStandby(entry *KeyEntry) {
+	km.securelyEraseKey(entry)
+	entry.Metadata.State = KeyStateDestroyed
+	entry.Metadata.DestroyedAt = time.Now()
+
+	km.auditLogger.Printf("KEY_STANDBY_DISCARDED version=%d hash=%s", entry.Metadata.Version, entry.Metadata.KeyHash)
+	km.telemetry.ObserveKeyEvent(telemetry.KeyDestroyed, entry.Metadata.ID)
+}
+
+// DestroyKeyVersion erases the material of a key version that is no
+// longer active, so everything encrypted under it becomes unrecoverable
+// (crypto-shredding). The active key must be rotated
+// out first.
+func (km *KeyManager) DestroyKeyVersion(version int) (KeyMetadata, error) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return KeyMetadata{}, fmt.Errorf("key version %d not found", version)
+	}
+	switch entry.Metadata.State {
+	case KeyStateActive:
+		return KeyMetadata{}, fmt.Errorf("key version %d is active; rotate it out before destroying it", version)
+	case KeyStateDestroyed:
+		return KeyMetadata{}, fmt.Errorf("key version %d is already destroyed", version)
+	}
+
+	km.securelyEraseKey(entry)
+	entry.Metadata.State = KeyStateDestroyed
+	entry.Metadata.DestroyedAt = time.Now()
+
+	km.auditLogger.Printf("KEY_DESTROYED version=%d hash=%s method=%s", version, entry.Metadata.KeyHash, km.policy.DestructionMethod)
+	km.telemetry.ObserveKeyEvent(telemetry.KeyDestroyed, entry.Metadata.ID)
+
+	metadata := entry.Metadata
+	metadata.Labels = entry.Metadata.Labels.Copy()
+	return metadata, nil
+}
+
+// formatOptionalTime formats t as RFC 3339, or "none" for the zero time
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return "none"
+	}
+	return t.Format(time.RFC3339)
+}
+
+// archiveOldKeys archives keys beyond retention policy, oldest first
+func (km *KeyManager) archiveOldKeys() {
+	states := make(map[int]KeyState, len(km.history))
+	for version, entry := range km.history {
+		states[version] = entry.Metadata.State
+	}
+
+	for _, version := range keysBeyondRetention(states, km.policy.RetentionCycles) {
+		entry := km.history[version]
+		entry.Metadata.State = KeyStateArchived
+		entry.Metadata.ArchivedAt = time.Now()
+
+		// Securely erase from memory
+		km.securelyEraseKey(entry)
+
+		km.auditLogger.Printf("KEY_ARCHIVED version=%d hash=%s",
+			version, entry.Metadata.KeyHash)
+		km.telemetry.ObserveKeyEvent(telemetry.KeyDestroyed, entry.Metadata.ID)
+	}
+}
+
+// keysBeyondRetention returns the rotated versions to archive so that at
+// most retention keys remain active or rotated, oldest first
+func keysBeyondRetention(states map[int]KeyState, retention int) []int {
+	var live int
+	var rotated []int
+	for version, state := range states {
+		if state == KeyStateActive || state == KeyStateRotated {
+			live++
+		}
+		if state == KeyStateRotated {
+			rotated = append(rotated, version)
+		}
+	}
+
+	excess := live - retention
+	if excess <= 0 {
+		return nil
+	}
+	sort.Ints(rotated)
+	if excess > len(rotated) {
+		excess = len(rotated)
+	}
+	return rotated[:excess]
+}
+
+// ============================================================================
+// Dry Run
+// ============================================================================
+
+// RotationPlan describes what a rotation would change
+type RotationPlan struct {
+	NewVersion       int   `json:"new_version"`                 // Version that would become active
+	PromotesStandby  bool  `json:"promotes_standby"`            // Standby promoted rather than a new key
+	RotatedVersion   int   `json:"rotated_version,omitempty"`   // Active version that would become decrypt-only
+	DiscardedStandby int   `json:"discarded_standby,omitempty"` // Standby that RotateKey would destroy
+	ArchivedVersions []int `json:"archived_versions"`           // Versions the retention policy would archive and erase
+	Retention        int   `json:"retention"`
+}
+
+// PlanRotation reports what PromoteStandby (promoteStandby true) or
+// RotateKey would change, without changing anything. It returns the same
+// error the rotation would, e.g. before the minimum key age.
+func (km *KeyManager) PlanRotation(promoteStandby bool) (*RotationPlan, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if km.clock.Since(km.lastRotationTime).Hours() < float64(km.policy.MinKeyAgeDays*24) {
+		return nil, fmt.Errorf("cannot rotate key before minimum age of %d days", km.policy.MinKeyAgeDays)
+	}
+
+	states := make(map[int]KeyState, len(km.history)+1)
+	for version, entry := range km.history {
+		states[version] = entry.Metadata.State
+	}
+
+	plan := &RotationPlan{Retention: km.policy.RetentionCycles}
+	standby := km.standby()
+	if promoteStandby {
+		if standby == nil {
+			return nil, fmt.Errorf("no standby key")
+		}
+		plan.NewVersion = standby.Metadata.Version
+		plan.PromotesStandby = true
+	} else {
+		plan.NewVersion = km.currentVersion + 1
+		if standby != nil {
+			plan.DiscardedStandby = standby.Metadata.Version
+			states[standby.Metadata.Version] = KeyStateDestroyed
+		}
+	}
+
+	// Mirror activateEntry
+	if km.activeKey != nil {
+		plan.RotatedVersion = km.activeKey.Metadata.Version
+		states[plan.RotatedVersion] = KeyStateRotated
+	}
+	states[plan.NewVersion] = KeyStateActive
+
+	plan.ArchivedVersions = keysBeyondRetention(states, km.policy.RetentionCycles)
+	if plan.ArchivedVersions == nil {
+		plan.ArchivedVersions = []int{}
+	}
+
+	return plan, nil
+}
+
+// securelyEraseKey securely erases key material from memory
+func (km *KeyManager) securelyEraseKey(entry *KeyEntry) {
+	method := km.policy.DestructionMethod
+	passes := km.policy.DestructionPasses
+
+	if method == "zero" {
+		// Overwrite with zeros
+		for i := 0; i < len(entry.Material); i++ {
+			entry.Material[i] = 0
+		}
+	} else if method == "random" || method == "overwrite" {
+		// Overwrite with random data (Gutmann-like method)
+		for pass := 0; pass < passes; pass++ {
+			hash := sha3.New256()
+			hash.Write([]byte(fmt.Sprintf("pass_%d_%d", pass, time.Now().UnixNano())))
+			pattern := hash.Sum(nil)
+
+			for i := 0; i < len(entry.Material); i++ {
+				entry.Material[i] ^= pattern[i%len(pattern)]
+			}
+		}
+	}
+
+	// Mark as destroyed
+	entry.Material = nil
+}
+
+// GetKeyMetadata retrieves metadata for a key version
+func (km *KeyManager) GetKeyMetadata(version int) (*KeyMetadata, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return nil, fmt.Errorf("key version %d not found", version)
+	}
+
+	// Return copy to prevent external modification
+	metadata := entry.Metadata
+	metadata.Labels = entry.Metadata.Labels.Copy()
+	return &metadata, nil
+}
+
+// GetActiveKeyMetadata retrieves metadata for the active key
+func (km *KeyManager) GetActiveKeyMetadata() (*KeyMetadata, error) {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	if km.activeKey == nil {
+		return nil, fmt.Errorf("no active key available")
+	}
+
+	metadata := km.activeKey.Metadata
+	return &metadata, nil
+}
+
+// ListKeyVersions returns all available key versions
+func (km *KeyManager) ListKeyVersions() []KeyMetadata {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	versions := make([]KeyMetadata, 0, len(km.history))
+	for _, entry := range km.history {
+		versions = append(versions, entry.Metadata)
+	}
+
+	return versions
+}
+
+// SetKeyLabels replaces the scoping labels of a key version
+func (km *KeyManager) SetKeyLabels(version int, keyLabels labels.Set) error {
+	if err := keyLabels.Validate(); err != nil {
+		return err
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return fmt.Errorf("key version %d not found", version)
+	}
+
+	entry.Metadata.Labels = keyLabels.Copy()
+
+	km.auditLogger.Printf("KEY_LABELED version=%d labels=%s", version, keyLabels)
+
+	return nil
+}
+
+// ListKeyVersionsByLabel returns key versions whose labels match selector
+// (e.g. labels.Parse("region=eu,tenant=acme"))
+func (km *KeyManager) ListKeyVersionsByLabel(selector labels.Set) []KeyMetadata {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	versions := make([]KeyMetadata, 0)
+	for _, entry := range km.history {
+		if entry.Metadata.Labels.Matches(selector) {
+			metadata := entry.Metadata
+			metadata.Labels = entry.Metadata.Labels.Copy()
+			versions = append(versions, metadata)
+		}
+	}
+
+	return versions
+}
+
+// IncrementEncryptionCount increments the encryption counter for active key
+func (km *KeyManager) IncrementEncryptionCount() {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if km.activeKey != nil {
+		km.activeKey.Metadata.EncryptionCount++
+	}
+}
+
+// IncrementDecryptionCount increments the decryption counter for a key version
+func (km *KeyManager) IncrementDecryptionCount(version int) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	entry, exists := km.history[version]
+	if !exists {
+		return fmt.Errorf("key version %d not found", version)
+	}
+
+	entry.Metadata.DecryptionCount++
+	return nil
+}
+
+// rotationScheduler runs background key rotation checks
+func (km *KeyManager) rotationScheduler() {
+	// Check rotation need every hour (scheduled keys are also activated
+	// on demand by GetActiveKey)
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-km.stopCh:
+			km.auditLogger.Printf("KEY_ROTATION_SCHEDULER_STOPPED")
+			return
+
+		case <-ticker.C:
+			km.mu.Lock()
+			km.activateDueKeys(km.clock.Now())
+			km.mu.Unlock()
+
+			km.checkRotationNeeded()
+
+			if err := km.CheckStandby(); err != nil {
+				km.auditLogger.Printf("KEY_STANDBY_MISSING error=%q", err)
+			}
+		}
+	}
+}
+
+// checkRotationNeeded checks if key rotation is needed
+func (km *KeyManager) checkRotationNeeded() {
+	km.mu.RLock()
+	activeKey := km.activeKey
+	km.mu.RUnlock()
+
+	if activeKey == nil {
+		return
+	}
+
+	ageHours := km.clock.Since(activeKey.Metadata.CreatedAt).Hours()
+	maxAgeHours := float64(km.policy.MaxKeyAgeDays * 24)
+	rotationIntervalHours := float64(km.policy.IntervalDays * 24)
+
+	// Log rotation check
+	km.auditLogger.Printf("KEY_ROTATION_CHECK age_hours=%.1f max_age=%.1f interval=%.1f",
+		ageHours, maxAgeHours, rotationIntervalHours)
+
+	// Check if rotation is needed
+	if ageHours >= maxAgeHours {
+		km.auditLogger.Printf("KEY_ROTATION_NEEDED_MAX_AGE age_hours=%.1f", ageHours)
+		// In production, would trigger rotation event here
+	} else if ageHours >= rotationIntervalHours {
+		km.auditLogger.Printf("KEY_ROTATION_NEEDED_INTERVAL age_hours=%.1f", ageHours)
+		// In production, would trigger rotation event here
+	}
+}
+
+// Stop stops the key manager's background operations
+func (km *KeyManager) Stop() {
+	close(km.stopCh)
+	km.auditLogger.Printf("KEY_MANAGER_STOPPED")
+}
+
+// SetTelemetry sets the receiver of key lifecycle events (nil disables).
+// Keys are identified by their ID, never by key material.
+func (km *KeyManager) SetTelemetry(t telemetry.Telemetry) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	km.telemetry = telemetry.OrNop(t)
+}
+
+// GetRotationPolicy returns the current rotation policy
+func (km *KeyManager) GetRotationPolicy() KeyRotationPolicy {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	return km.policy
+}
+
+// UpdateRotationPolicy updates the rotation policy
+func (km *KeyManager) UpdateRotationPolicy(policy KeyRotationPolicy) error {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	km.policy = policy
+
+	km.auditLogger.Printf("KEY_ROTATION_POLICY_UPDATED interval_days=%d max_age=%d retention=%d",
+		policy.IntervalDays, policy.MaxKeyAgeDays, policy.RetentionCycles)
+
+	return nil
+}
+
+// ============================================================================
+// Key Backup and Recovery
+// ============================================================================
+
+// BackupKey creates an encrypted backup of a key, wrapped with
+// eamsa512.WrapKey (69 bytes for a 32-byte key)
+func (km *KeyManager) BackupKey(version int, backupKey []byte) ([]byte, error) {
+	key, err := km.GetKeyByVersion(version)
+	if err != nil {
+		return nil, err
+	}
+
+	backupData, err := eamsa512.WrapKey(backupKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	km.auditLogger.Printf("KEY_BACKUP version=%d size=%d", version, len(backupData))
+
+	return backupData, nil
+}
+
+// RestoreKey restores a key from encrypted backup. Backups made before
+// key wrapping (EncryptData output) are still accepted.
+func (km *KeyManager) RestoreKey(backupData []byte, backupKey []byte) error {
+	var key []byte
+	var err error
+	if eamsa512.IsWrappedKey(backupData) {
+		key, err = eamsa512.UnwrapKey(backupKey, backupData)
+	} else {
+		key, err = eamsa512.DecryptData(backupData, backupKey)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decrypt backup: %v", err)
+	}
+
+	// Rotate to restored key
+	return km.RotateKey(key)
+}
+
+// ============================================================================
+// Key Statistics and Reporting
+// ============================================================================
+
+// KeyStatistics holds key usage statistics
+type KeyStatistics struct {
+	TotalKeys        int   `json:"total_keys"`
+	ActiveKeys       int   `json:"active_keys"`
+	RotatedKeys      int   `json:"rotated_keys"`
+	ArchivedKeys     int   `json:"archived_keys"`
+	TotalEncryptions int64 `json:"total_encryptions"`
+	TotalDecryptions int64 `json:"total_decryptions"`
+}
+
+// GetStatistics returns key statistics
+func (km *KeyManager) GetStatistics() KeyStatistics {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	stats := KeyStatistics{
+		TotalKeys: len(km.history),
+	}
+
+	var totalEncryptions, totalDecryptions int64
+
+	for _, entry := range km.history {
+		switch entry.Metadata.State {
+		case KeyStateActive:
+			stats.ActiveKeys++
+		case KeyStateRotated:
+			stats.RotatedKeys++
+		case KeyStateArchived:
+			stats.ArchivedKeys++
+		}
+
+		totalEncryptions += entry.Metadata.EncryptionCount
+		totalDecryptions += entry.Metadata.DecryptionCount
+	}
+
+	stats.TotalEncryptions = totalEncryptions
+	stats.TotalDecryptions = totalDecryptions
+
+	return stats
+}
+
+// Auditf writes an entry to the audit log, for operations built on the
+// key manager such as key export
+func (km *KeyManager) Auditf(format string, args ...interface{}) {
+	km.auditLogger.Printf(format, args...)
+}
+
+// ReplaceEntries replaces every key version with entries, e.g. as merged
+// from another region. Exactly one entry must be active. The material of
+// replaced entries is zeroized.
+func (km *KeyManager) ReplaceEntries(entries []*KeyEntry) error {
+	var active *KeyEntry
+	history := make(map[int]*KeyEntry, len(entries))
+	currentVersion := 0
+	for _, entry := range entries {
+		version := entry.Metadata.Version
+		if _, exists := history[version]; exists {
+			return fmt.Errorf("duplicate key version %d", version)
+		}
+		history[version] = entry
+		currentVersion = max(currentVersion, version)
+
+		if entry.Metadata.State == KeyStateActive {
+			if active != nil {
+				return fmt.Errorf("key versions %d and %d are both active", active.Metadata.Version, version)
+			}
+			active = entry
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("no active key version")
+	}
+
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	for version, entry := range km.history {
+		if history[version] != entry {
+			clear(entry.Material)
+		}
+	}
+	if km.activeKey == nil || km.activeKey.Metadata.KeyHash != active.Metadata.KeyHash {
+		km.lastRotationTime = active.Metadata.ActivatedAt
+		km.auditLogger.Printf("KEY_ROTATED_NEW version=%d new_hash=%s source=replication", active.Metadata.Version, active.Metadata.KeyHash)
+	}
+	km.history = history
+	km.currentVersion = currentVersion
+	km.activeKey = active
+	return nil
+}
+
+// RenumberedTo returns the versions of keys moved away from version by a
+// replication conflict (KeyMetadata.RenumberedFrom)
+func (km *KeyManager) RenumberedTo(version int) []int {
+	km.mu.RLock()
+	defer km.mu.RUnlock()
+
+	var versions []int
+	for v, entry := range km.history {
+		if entry.Metadata.RenumberedFrom == version {
+			versions = append(versions, v)
+		}
+	}
+	sort.Ints(versions)
+	return versions
+}
@@ -0,0 +1,595 @@
+package keymgmt
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Redeaux-Corporation/eamsa512/keyid"
+	"github.com/Redeaux-Corporation/eamsa512/labels"
+	"github.com/Redeaux-Corporation/eamsa512/pkg/eamsa512"
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// AuditEntry records security events
+type AuditEntry struct {
+	Timestamp   time.Time
+	EventType   string
+	Description string
+	Status      string
+	OperatorID  string
+}
+
+// HSM is the hardware key store that lifecycle-managed keys are imported
+// into, and whose random policy supplies their material
+type HSM interface {
+	ImportKey(key [32]byte) error
+	RandomPolicy() eamsa512.RandomPolicy
+}
+
+// KeyUsage is the single direction a key handle may be used in
+type KeyUsage string
+
+const (
+	UsageEncrypt KeyUsage = "encrypt" // Encrypt and tag only
+	UsageDecrypt KeyUsage = "decrypt" // Verify and decrypt only
+)
+
+// Lifecycle tracks key lifecycle
+type Lifecycle struct {
+	KeyID         string
+	KeyMaterial   [32]byte
+	Generated     time.Time
+	Activated     time.Time
+	RotationDue   time.Time
+	Deactivated   time.Time
+	Destroyed     time.Time
+	State         KeyLifecycleState
+	RotationCount int
+	AccessCount   int64
+	LastAccess    time.Time
+	Zeroized      bool
+	CreatedBy     string
+	RotatedBy     string
+	DestroyedBy   string
+	AuditTrail    []AuditEntry
+	Labels        labels.Set // Scoping labels (region, tenant, classification)
+	mu            sync.RWMutex
+}
+
+// LifecycleManager manages all key lifecycles
+type LifecycleManager struct {
+	keys             map[string]*Lifecycle
+	hsm              HSM
+	rotationInterval time.Duration
+	telemetry        telemetry.Telemetry
+	random           *eamsa512.Random // Source of key material
+	mu               sync.RWMutex
+}
+
+// NewLifecycleManager creates new lifecycle manager. Key material comes
+// from hsm's RandomPolicy, or crypto/rand without an HSM (nil).
+func NewLifecycleManager(hsm HSM) *LifecycleManager {
+	var random *eamsa512.Random
+	if hsm != nil {
+		random = eamsa512.NewRandom(hsm.RandomPolicy())
+	}
+	return &LifecycleManager{
+		keys:             make(map[string]*Lifecycle),
+		hsm:              hsm,
+		rotationInterval: 365 * 24 * time.Hour, // Annual rotation
+		telemetry:        telemetry.Nop{},
+		random:           random,
+	}
+}
+
+// SetEntropySource makes source the origin of key material instead of
+// the HSM policy, e.g. an eamsa512.DeviceEntropy TRNG or ChaosEntropy.
+// It is health checked before first use; a failing source fails key
+// generation rather than falling back.
+func (klm *LifecycleManager) SetEntropySource(source eamsa512.RandomSource) {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+	klm.random = eamsa512.NewRandom(eamsa512.RandomPolicy{Source: source})
+}
+
+// SetTelemetry sets the receiver of key lifecycle events (nil disables)
+func (klm *LifecycleManager) SetTelemetry(t telemetry.Telemetry) {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+	klm.telemetry = telemetry.OrNop(t)
+}
+
+// GenerateKey generates new key with tracking
+func (klm *LifecycleManager) GenerateKey(keyID string, operatorID string) (*Lifecycle, error) {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+
+	// Check if key already exists
+	if _, exists := klm.keys[keyID]; exists {
+		return nil, fmt.Errorf("key %s already exists", keyID)
+	}
+
+	// Generate key material
+	keyMaterial := [32]byte{}
+	source, err := klm.random.Fill(keyMaterial[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key %s: %w", keyID, err)
+	}
+
+	now := time.Now()
+	keyLifecycle := &Lifecycle{
+		KeyID:       keyID,
+		KeyMaterial: keyMaterial,
+		Generated:   now,
+		State:       StateGenerated,
+		CreatedBy:   operatorID,
+		AuditTrail:  make([]AuditEntry, 0),
+	}
+
+	// Import to HSM
+	if klm.hsm != nil {
+		if err := klm.hsm.ImportKey(keyMaterial); err != nil {
+			return nil, fmt.Errorf("failed to import key to HSM: %v", err)
+		}
+	}
+
+	// Add to tracking
+	klm.keys[keyID] = keyLifecycle
+
+	// Audit entry
+	keyLifecycle.addAuditEntry("KEY_GENERATED", fmt.Sprintf("Key %s generated (id: %s, source: %s)", keyID, keyid.New(keyLifecycle.KeyMaterial[:]), source), "SUCCESS", operatorID)
+	klm.telemetry.ObserveKeyEvent(telemetry.KeyGenerated, keyID)
+
+	return keyLifecycle, nil
+}
+
+// ActivateKey activates a generated key
+func (klm *LifecycleManager) ActivateKey(keyID string, operatorID string) error {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	if err := keyLC.transition(EventActivate, operatorID); err != nil {
+		return err
+	}
+
+	keyLC.Activated = time.Now()
+	keyLC.RotationDue = keyLC.Activated.Add(keyLC.RotationDue.Sub(keyLC.Generated))
+
+	keyLC.addAuditEntry("KEY_ACTIVATED", fmt.Sprintf("Key %s activated", keyID), "SUCCESS", operatorID)
+	klm.telemetry.ObserveKeyEvent(telemetry.KeyActivated, keyID)
+
+	return nil
+}
+
+// RotateKey rotates key material
+func (klm *LifecycleManager) RotateKey(keyID string, operatorID string) (*Lifecycle, error) {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	if err := keyLC.transition(EventRotate, operatorID); err != nil {
+		return nil, err
+	}
+
+	// Generate new key material
+	newKeyMaterial := [32]byte{}
+	source, err := klm.random.Fill(newKeyMaterial[:])
+	if err != nil {
+		keyLC.transition(EventRotateAbort, operatorID)
+		return nil, fmt.Errorf("failed to generate key %s: %w", keyID, err)
+	}
+
+	// Save old key for audit
+	oldKeyMaterial := keyLC.KeyMaterial
+
+	// Update with new material
+	keyLC.KeyMaterial = newKeyMaterial
+	keyLC.RotationCount++
+	keyLC.RotatedBy = operatorID
+	keyLC.RotationDue = time.Now().Add(keyLC.RotationDue.Sub(keyLC.RotationDue))
+
+	// Import new key to HSM
+	if klm.hsm != nil {
+		if err := klm.hsm.ImportKey(newKeyMaterial); err != nil {
+			// Restore old key on failure
+			keyLC.KeyMaterial = oldKeyMaterial
+			keyLC.transition(EventRotateAbort, operatorID)
+			return nil, fmt.Errorf("failed to import rotated key to HSM: %v", err)
+		}
+	}
+	keyLC.transition(EventRotateComplete, operatorID)
+
+	keyLC.addAuditEntry("KEY_ROTATED", fmt.Sprintf("Key %s rotated (count: %d, id: %s, source: %s)", keyID, keyLC.RotationCount, keyid.New(keyLC.KeyMaterial[:]), source), "SUCCESS", operatorID)
+	klm.telemetry.ObserveKeyEvent(telemetry.KeyRotated, keyID)
+
+	return keyLC, nil
+}
+
+// DeactivateKey deactivates a key
+func (klm *LifecycleManager) DeactivateKey(keyID string, operatorID string) error {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	if err := keyLC.transition(EventDeactivate, operatorID); err != nil {
+		return err
+	}
+
+	keyLC.Deactivated = time.Now()
+	keyLC.DestroyedBy = operatorID
+
+	keyLC.addAuditEntry("KEY_DEACTIVATED", fmt.Sprintf("Key %s deactivated", keyID), "SUCCESS", operatorID)
+	klm.telemetry.ObserveKeyEvent(telemetry.KeyDeactivated, keyID)
+
+	return nil
+}
+
+// ZeroizeKey securely wipes key material
+func (klm *LifecycleManager) ZeroizeKey(keyID string, operatorID string) error {
+	klm.mu.Lock()
+	defer klm.mu.Unlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	if err := keyLC.transition(EventDestroy, operatorID); err != nil {
+		return err
+	}
+
+	// Overwrite key material with zeros
+	for i := 0; i < 32; i++ {
+		keyLC.KeyMaterial[i] = 0
+	}
+
+	keyLC.Destroyed = time.Now()
+	keyLC.Zeroized = true
+
+	keyLC.addAuditEntry("KEY_ZEROIZED", fmt.Sprintf("Key %s securely destroyed", keyID), "SUCCESS", operatorID)
+	klm.telemetry.ObserveKeyEvent(telemetry.KeyDestroyed, keyID)
+
+	return nil
+}
+
+// ZeroizePlan describes what ZeroizeKey would do, without doing it
+type ZeroizePlan struct {
+	KeyID           string `json:"key_id"`
+	Fingerprint     string `json:"fingerprint,omitempty"` // Empty once already zeroized
+	State           string `json:"state"`
+	AccessCount     int64  `json:"access_count"`
+	AlreadyZeroized bool   `json:"already_zeroized"`
+	ActiveKey       bool   `json:"active_key"` // Zeroizing breaks decryption of data it still protects
+}
+
+// PlanZeroize reports the key ZeroizeKey would destroy for change review
+func (klm *LifecycleManager) PlanZeroize(keyID string) (*ZeroizePlan, error) {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.RLock()
+	defer keyLC.mu.RUnlock()
+
+	plan := &ZeroizePlan{
+		KeyID:           keyID,
+		State:           keyLC.State.String(),
+		AccessCount:     keyLC.AccessCount,
+		AlreadyZeroized: keyLC.Zeroized,
+		ActiveKey:       keyLC.State == StateActivated,
+	}
+	if !keyLC.Zeroized {
+		plan.Fingerprint = keyid.New(keyLC.KeyMaterial[:]).String()
+	}
+
+	return plan, nil
+}
+
+// GetKeyStatus returns key lifecycle status
+func (klm *LifecycleManager) GetKeyStatus(keyID string) (*Lifecycle, error) {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return nil, fmt.Errorf("key %s not found", keyID)
+	}
+
+	return keyLC, nil
+}
+
+// CheckOutKey returns a copy of keyID's material for a handle of usage
+// held by operatorID. authorize, e.g. an RBAC check, is called with the
+// key's labels under its lock; a refusal is audited as KEY_HANDLE_DENIED.
+// Encrypt handles need an activated key; decrypt handles also accept a
+// deactivated one, so that data encrypted before a rotation stays
+// readable. An issued handle counts as an access and is audited as
+// KEY_HANDLE_ISSUED.
+func (klm *LifecycleManager) CheckOutKey(keyID, operatorID string, usage KeyUsage, authorize func(keyLabels labels.Set) error) ([32]byte, error) {
+	klm.mu.RLock()
+	keyLC, exists := klm.keys[keyID]
+	klm.mu.RUnlock()
+
+	if !exists {
+		return [32]byte{}, fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	if err := authorize(keyLC.Labels); err != nil {
+		keyLC.addAuditEntry("KEY_HANDLE_DENIED", fmt.Sprintf("%s handle for key %s denied", usage, keyID), "FAILURE", operatorID)
+		return [32]byte{}, err
+	}
+
+	switch keyLC.State {
+	case StateActivated:
+	case StateDeactivated:
+		if usage == UsageEncrypt {
+			return [32]byte{}, fmt.Errorf("key %s is deactivated: encrypt handles require an active key", keyID)
+		}
+	default:
+		return [32]byte{}, fmt.Errorf("key %s cannot issue %s handles in state %s", keyID, usage, keyLC.State)
+	}
+
+	keyLC.AccessCount++
+	keyLC.LastAccess = time.Now()
+	keyLC.addAuditEntry("KEY_HANDLE_ISSUED", fmt.Sprintf("%s handle for key %s issued", usage, keyID), "SUCCESS", operatorID)
+
+	return keyLC.KeyMaterial, nil
+}
+
+// Telemetry returns the receiver of key lifecycle events
+func (klm *LifecycleManager) Telemetry() telemetry.Telemetry {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+	return klm.telemetry
+}
+
+// SetKeyLabels replaces the scoping labels of a key
+func (klm *LifecycleManager) SetKeyLabels(keyID string, keyLabels labels.Set, operatorID string) error {
+	if err := keyLabels.Validate(); err != nil {
+		return err
+	}
+
+	klm.mu.RLock()
+	keyLC, exists := klm.keys[keyID]
+	klm.mu.RUnlock()
+
+	if !exists {
+		return fmt.Errorf("key %s not found", keyID)
+	}
+
+	keyLC.mu.Lock()
+	defer keyLC.mu.Unlock()
+
+	keyLC.Labels = keyLabels.Copy()
+	keyLC.addAuditEntry("KEY_LABELED", fmt.Sprintf("Key %s labels: %s", keyID, keyLabels), "SUCCESS", operatorID)
+
+	return nil
+}
+
+// ListKeysByLabel returns the IDs of keys whose labels match selector
+func (klm *LifecycleManager) ListKeysByLabel(selector labels.Set) []string {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	matches := make([]string, 0)
+	for keyID, keyLC := range klm.keys {
+		keyLC.mu.RLock()
+		if keyLC.Labels.Matches(selector) {
+			matches = append(matches, keyID)
+		}
+		keyLC.mu.RUnlock()
+	}
+
+	return matches
+}
+
+// KeyLabels returns keyID -> labels for all keys, for RBACManager.FilterKeysForUser
+func (klm *LifecycleManager) KeyLabels() map[string]labels.Set {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	all := make(map[string]labels.Set, len(klm.keys))
+	for keyID, keyLC := range klm.keys {
+		keyLC.mu.RLock()
+		all[keyID] = keyLC.Labels.Copy()
+		keyLC.mu.RUnlock()
+	}
+
+	return all
+}
+
+// GetKeysNeedingRotation returns keys that need rotation
+func (klm *LifecycleManager) GetKeysNeedingRotation() []string {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	needsRotation := make([]string, 0)
+	now := time.Now()
+
+	for keyID, keyLC := range klm.keys {
+		keyLC.mu.RLock()
+		if keyLC.State == StateActivated && now.After(keyLC.RotationDue) {
+			needsRotation = append(needsRotation, keyID)
+		}
+		keyLC.mu.RUnlock()
+	}
+
+	return needsRotation
+}
+
+// addAuditEntry adds entry to key's audit trail
+func (kl *Lifecycle) addAuditEntry(eventType, description, status, operatorID string) {
+	entry := AuditEntry{
+		Timestamp:   time.Now(),
+		EventType:   eventType,
+		Description: description,
+		Status:      status,
+		OperatorID:  operatorID,
+	}
+	kl.AuditTrail = append(kl.AuditTrail, entry)
+}
+
+// transition moves kl to the state event leads to, or audits and returns
+// the *TransitionError. The caller holds kl.mu.
+func (kl *Lifecycle) transition(event KeyEvent, operatorID string) error {
+	next, err := NextKeyState(kl.KeyID, kl.State, event)
+	if err != nil {
+		kl.addAuditEntry("KEY_TRANSITION_DENIED", err.Error(), "FAILURE", operatorID)
+		return err
+	}
+	kl.State = next
+	return nil
+}
+
+// GetAuditTrail returns key's audit trail
+func (klm *LifecycleManager) GetAuditTrail(keyID string) []AuditEntry {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	keyLC, exists := klm.keys[keyID]
+	if !exists {
+		return []AuditEntry{}
+	}
+
+	keyLC.mu.RLock()
+	defer keyLC.mu.RUnlock()
+
+	// Return copy of audit trail
+	trailCopy := make([]AuditEntry, len(keyLC.AuditTrail))
+	copy(trailCopy, keyLC.AuditTrail)
+	return trailCopy
+}
+
+// WriteStatus writes the lifecycle status of every key to w
+func (klm *LifecycleManager) WriteStatus(w io.Writer) {
+	klm.mu.RLock()
+	defer klm.mu.RUnlock()
+
+	fmt.Fprintf(w, "\n🔑 Key Lifecycle Management Status:\n")
+	fmt.Fprintf(w, "   Total Keys: %d\n", len(klm.keys))
+
+	for keyID, keyLC := range klm.keys {
+		keyLC.mu.RLock()
+		fmt.Fprintf(w, "   Key: %s\n", keyID)
+		fmt.Fprintf(w, "     State:        %s\n", keyLC.State)
+		if !keyLC.Zeroized {
+			fmt.Fprintf(w, "     Key ID:       %s\n", keyid.New(keyLC.KeyMaterial[:]))
+		}
+		fmt.Fprintf(w, "     Generated:    %v\n", keyLC.Generated)
+		if len(keyLC.Labels) > 0 {
+			fmt.Fprintf(w, "     Labels:       %s\n", keyLC.Labels)
+		}
+		fmt.Fprintf(w, "     Rotations:    %d\n", keyLC.RotationCount)
+		fmt.Fprintf(w, "     Zeroized:     %v\n", keyLC.Zeroized)
+		fmt.Fprintf(w, "     Audit Events: %d\n", len(keyLC.AuditTrail))
+		keyLC.mu.RUnlock()
+	}
+}
+
+// applyKeyEvent triggers event through the manager. RotateKey covers
+// rotate and, inside it, rotate-complete or rotate-abort.
+func applyKeyEvent(klm *LifecycleManager, keyID string, event KeyEvent) error {
+	switch event {
+	case EventActivate:
+		return klm.ActivateKey(keyID, "selftest")
+	case EventRotate:
+		_, err := klm.RotateKey(keyID, "selftest")
+		return err
+	case EventDeactivate:
+		return klm.DeactivateKey(keyID, "selftest")
+	case EventDestroy:
+		return klm.ZeroizeKey(keyID, "selftest")
+	}
+	return fmt.Errorf("event %s has no manager method", event)
+}
+
+// CheckTransitions applies every event in every state and compares the
+// outcome with the transition table, for the power-up self-test. Keys are
+// driven through a LifecycleManager where it can reach the state;
+// Rotating exists only inside RotateKey, so its events are applied to the
+// key directly.
+func CheckTransitions() (string, error) {
+	paths := map[KeyLifecycleState][]KeyEvent{
+		StateGenerated:   nil,
+		StateActivated:   {EventActivate},
+		StateDeactivated: {EventActivate, EventDeactivate},
+		StateDestroyed:   {EventDestroy},
+	}
+
+	pairs := 0
+	for _, from := range keyStates {
+		for _, event := range keyEvents {
+			expected, expectedErr := NextKeyState("selftest", from, event)
+
+			var got KeyLifecycleState
+			var err error
+			if path, ok := paths[from]; ok && event != EventRotateComplete && event != EventRotateAbort {
+				klm := NewLifecycleManager(nil)
+				if _, err := klm.GenerateKey("selftest", "selftest"); err != nil {
+					return "", err
+				}
+				for _, e := range path {
+					if err := applyKeyEvent(klm, "selftest", e); err != nil {
+						return "", fmt.Errorf("reaching %s: %v", from, err)
+					}
+				}
+				err = applyKeyEvent(klm, "selftest", event)
+				got = klm.keys["selftest"].State
+				if event == EventRotate && expectedErr == nil {
+					expected, _ = NextKeyState("selftest", expected, EventRotateComplete)
+				}
+			} else {
+				key := &Lifecycle{KeyID: "selftest", State: from}
+				err = key.transition(event, "selftest")
+				got = key.State
+			}
+
+			switch {
+			case expectedErr == nil && err != nil:
+				return "", fmt.Errorf("%s in state %s refused: %v", event, from, err)
+			case expectedErr != nil && !errors.Is(err, ErrInvalidTransition):
+				return "", fmt.Errorf("%s in state %s: got %v, expected ErrInvalidTransition", event, from, err)
+			case got != expected:
+				return "", fmt.Errorf("%s in state %s led to %s, expected %s", event, from, got, expected)
+			}
+			pairs++
+		}
+	}
+
+	return fmt.Sprintf("%d state/event pairs match %d transitions", pairs, len(KeyTransitions())), nil
+}
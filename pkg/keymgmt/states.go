@@ -0,0 +1,158 @@
+package keymgmt
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// gen-key-states.go writes docs/key-lifecycle.md from this state machine
+//go:generate go run gen-key-states.go
+
+// KeyLifecycleState defines key lifecycle states
+type KeyLifecycleState int
+
+const (
+	StateGenerated KeyLifecycleState = iota
+	StateActivated
+	StateRotating
+	StateDeactivated
+	StateDestroyed
+)
+
+// keyStates lists every state in order
+var keyStates = []KeyLifecycleState{StateGenerated, StateActivated, StateRotating, StateDeactivated, StateDestroyed}
+
+// StateString returns string representation of state
+func (s KeyLifecycleState) String() string {
+	states := []string{"Generated", "Activated", "Rotating", "Deactivated", "Destroyed"}
+	if s >= 0 && s < KeyLifecycleState(len(states)) {
+		return states[s]
+	}
+	return "Unknown"
+}
+
+// KeyEvent is an operation that moves a key between lifecycle states
+type KeyEvent string
+
+const (
+	EventActivate       KeyEvent = "activate"        // ActivateKey
+	EventRotate         KeyEvent = "rotate"          // RotateKey, while the new material is imported
+	EventRotateComplete KeyEvent = "rotate-complete" // RotateKey, new material in place
+	EventRotateAbort    KeyEvent = "rotate-abort"    // RotateKey, old material restored
+	EventDeactivate     KeyEvent = "deactivate"      // DeactivateKey
+	EventDestroy        KeyEvent = "destroy"         // ZeroizeKey
+)
+
+// keyEvents lists every event in order
+var keyEvents = []KeyEvent{EventActivate, EventRotate, EventRotateComplete, EventRotateAbort, EventDeactivate, EventDestroy}
+
+// KeyStates returns every state in order
+func KeyStates() []KeyLifecycleState {
+	return append([]KeyLifecycleState(nil), keyStates...)
+}
+
+// KeyEvents returns every event in order
+func KeyEvents() []KeyEvent {
+	return append([]KeyEvent(nil), keyEvents...)
+}
+
+// keyTransitions is the lifecycle: the state each event leads to from
+// each state. Any event missing for a state is refused. Destroying an
+// activated key is allowed for emergency zeroization (compromise,
+// tamper); PlanZeroize reports it as ActiveKey.
+var keyTransitions = map[KeyLifecycleState]map[KeyEvent]KeyLifecycleState{
+	StateGenerated: {
+		EventActivate: StateActivated,
+		EventDestroy:  StateDestroyed,
+	},
+	StateActivated: {
+		EventRotate:     StateRotating,
+		EventDeactivate: StateDeactivated,
+		EventDestroy:    StateDestroyed,
+	},
+	StateRotating: {
+		EventRotateComplete: StateActivated,
+		EventRotateAbort:    StateActivated,
+	},
+	StateDeactivated: {
+		EventDestroy: StateDestroyed,
+	},
+	StateDestroyed: {},
+}
+
+// ErrInvalidTransition matches every *TransitionError
+var ErrInvalidTransition = errors.New("invalid key lifecycle transition")
+
+// TransitionError is returned for an event the key's state does not allow
+type TransitionError struct {
+	KeyID string
+	From  KeyLifecycleState
+	Event KeyEvent
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("key %s cannot %s in state %s (allowed: %s)", e.KeyID, e.Event, e.From, allowedKeyEvents(e.From))
+}
+
+// Is reports whether target is ErrInvalidTransition
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// NextKeyState returns the state event leads to from from, or a
+// *TransitionError for keyID
+func NextKeyState(keyID string, from KeyLifecycleState, event KeyEvent) (KeyLifecycleState, error) {
+	to, ok := keyTransitions[from][event]
+	if !ok {
+		return from, &TransitionError{KeyID: keyID, From: from, Event: event}
+	}
+	return to, nil
+}
+
+// KeyTransition is one edge of the lifecycle
+type KeyTransition struct {
+	From  KeyLifecycleState
+	Event KeyEvent
+	To    KeyLifecycleState
+}
+
+// KeyTransitions returns every edge of the lifecycle, ordered by state
+// and event
+func KeyTransitions() []KeyTransition {
+	var edges []KeyTransition
+	for _, from := range keyStates {
+		for _, event := range keyEvents {
+			if to, ok := keyTransitions[from][event]; ok {
+				edges = append(edges, KeyTransition{From: from, Event: event, To: to})
+			}
+		}
+	}
+	return edges
+}
+
+// allowedKeyEvents lists the events from a state, or "none"
+func allowedKeyEvents(from KeyLifecycleState) string {
+	var names []string
+	for event := range keyTransitions[from] {
+		names = append(names, string(event))
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// KeyStateDiagram renders the lifecycle as a Mermaid state diagram
+func KeyStateDiagram() string {
+	var b strings.Builder
+	b.WriteString("stateDiagram-v2\n")
+	fmt.Fprintf(&b, "    [*] --> %s: GenerateKey\n", StateGenerated)
+	for _, t := range KeyTransitions() {
+		fmt.Fprintf(&b, "    %s --> %s: %s\n", t.From, t.To, t.Event)
+	}
+	fmt.Fprintf(&b, "    %s --> [*]\n", StateDestroyed)
+	return b.String()
+}
@@ -0,0 +1,45 @@
+// Code generated by gen-errors.go; DO NOT EDIT.
+
+package problem
+
+// Problem types, for errors.Is on a decoded *Problem
+var (
+	// ErrBadRequest is bad_request (400 Bad Request)
+	ErrBadRequest = lookup("bad_request")
+
+	// ErrDecryptionFailed is decryption_failed (401 Decryption Failed)
+	ErrDecryptionFailed = lookup("decryption_failed")
+
+	// ErrForbidden is forbidden (403 Forbidden)
+	ErrForbidden = lookup("forbidden")
+
+	// ErrNotFound is not_found (404 Not Found)
+	ErrNotFound = lookup("not_found")
+
+	// ErrMethodNotAllowed is method_not_allowed (405 Method Not Allowed)
+	ErrMethodNotAllowed = lookup("method_not_allowed")
+
+	// ErrTimeout is timeout (408 Request Timeout)
+	ErrTimeout = lookup("timeout")
+
+	// ErrTooLarge is too_large (413 Payload Too Large)
+	ErrTooLarge = lookup("too_large")
+
+	// ErrQuotaExceeded is quota_exceeded (429 Quota Exceeded)
+	ErrQuotaExceeded = lookup("quota_exceeded")
+
+	// ErrInternalError is internal_error (500 Internal Server Error)
+	ErrInternalError = lookup("internal_error")
+
+	// ErrEncryptionFailed is encryption_failed (500 Encryption Failed)
+	ErrEncryptionFailed = lookup("encryption_failed")
+
+	// ErrStoreCorrupt is store_corrupt (502 Stored Data Corrupt)
+	ErrStoreCorrupt = lookup("store_corrupt")
+
+	// ErrStoreFailed is store_failed (503 Store Unavailable)
+	ErrStoreFailed = lookup("store_failed")
+
+	// ErrInsufficientStorage is insufficient_storage (507 Insufficient Storage)
+	ErrInsufficientStorage = lookup("insufficient_storage")
+)
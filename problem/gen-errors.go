@@ -0,0 +1,60 @@
+//go:build ignore
+
+// gen-errors.go - Generates errors.go (an ErrXxx value per problem type)
+//
+// Run with "go generate" in this directory. Each entry of problem.Types
+// becomes an exported *problem.Type named after its code, so clients can
+// match responses with errors.Is without spelling codes as strings.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/Redeaux-Corporation/eamsa512/problem"
+)
+
+const outputFile = "errors.go"
+
+func main() {
+	var b bytes.Buffer
+	b.WriteString("// Code generated by gen-errors.go; DO NOT EDIT.\n\n")
+	b.WriteString("package problem\n\n")
+	b.WriteString("// Problem types, for errors.Is on a decoded *Problem\n")
+	b.WriteString("var (\n")
+
+	seen := make(map[string]bool)
+	for _, t := range problem.Types {
+		if seen[t.Code] {
+			log.Fatalf("duplicate problem code %q", t.Code)
+		}
+		seen[t.Code] = true
+
+		fmt.Fprintf(&b, "\t// %s is %s (%d %s)\n", errName(t.Code), t.Code, t.Status, t.Title)
+		fmt.Fprintf(&b, "\t%s = lookup(%q)\n\n", errName(t.Code), t.Code)
+	}
+	b.WriteString(")\n")
+
+	src, err := format.Source(b.Bytes())
+	if err != nil {
+		log.Fatalf("generated code does not parse: %v", err)
+	}
+	if err := os.WriteFile(outputFile, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// errName converts a code such as "bad_request" to "ErrBadRequest"
+func errName(code string) string {
+	name := "Err"
+	for _, word := range strings.Split(code, "_") {
+		if word != "" {
+			name += strings.ToUpper(word[:1]) + word[1:]
+		}
+	}
+	return name
+}
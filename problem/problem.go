@@ -0,0 +1,168 @@
+// Package problem defines the error responses of the EAMSA 512 REST API,
+// which follow RFC 7807 (application/problem+json), for the server and
+// its clients.
+//
+// Every error has a code from Types with a fixed HTTP status and title;
+// its type URI is "urn:eamsa512:problem:<code>". A response looks like
+//
+//	{
+//	  "type": "urn:eamsa512:problem:quota_exceeded",
+//	  "title": "Quota Exceeded",
+//	  "status": 429,
+//	  "detail": "Decrypt quota exceeded; resets at 2025-12-05T00:00:00Z",
+//	  "instance": "/api/v1/decrypt",
+//	  "request_id": "5f0c3b7e9a2d41c8b6e0f1a2c3d4e5f6",
+//	  "timestamp": "2025-12-04T18:30:00Z"
+//	}
+//
+// Clients decode a failed response with Decode and match it against the
+// ErrXxx values generated from Types (errors.go) with errors.Is:
+//
+//	if errors.Is(err, problem.ErrQuotaExceeded) {
+//		...
+//	}
+package problem
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const (
+	// ContentType is the media type of problem responses
+	ContentType = "application/problem+json"
+
+	// TypePrefix precedes the code in every type URI
+	TypePrefix = "urn:eamsa512:problem:"
+
+	// RequestIDHeader carries the request ID in requests and responses
+	RequestIDHeader = "X-Request-ID"
+)
+
+// Type is a kind of problem: a code with its HTTP status and title. The
+// generated ErrXxx values are Types, so they can be errors.Is targets.
+type Type struct {
+	Code   string
+	Status int
+	Title  string
+}
+
+// URI returns the problem type URI
+func (t *Type) URI() string {
+	return TypePrefix + t.Code
+}
+
+func (t *Type) Error() string {
+	return t.Title
+}
+
+// Problem is an RFC 7807 problem details object. RequestID and Timestamp
+// are extension members.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	Timestamp string `json:"timestamp,omitempty"`
+}
+
+// New returns the problem for code with detail. Unknown codes become
+// internal_error, so every response has a registered type and status.
+func New(code, detail string) *Problem {
+	t, ok := Lookup(code)
+	if !ok {
+		t, _ = Lookup("internal_error")
+	}
+
+	return &Problem{
+		Type:   t.URI(),
+		Title:  t.Title,
+		Status: t.Status,
+		Detail: detail,
+	}
+}
+
+// Code returns the code of the problem type, or "" for types outside
+// this API
+func (p *Problem) Code() string {
+	if !strings.HasPrefix(p.Type, TypePrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(p.Type, TypePrefix)
+}
+
+func (p *Problem) Error() string {
+	msg := fmt.Sprintf("%d %s", p.Status, p.Title)
+	if p.Detail != "" {
+		msg += ": " + p.Detail
+	}
+	if p.RequestID != "" {
+		msg += " (request " + p.RequestID + ")"
+	}
+	return msg
+}
+
+// Is reports whether target is the Type of this problem
+func (p *Problem) Is(target error) bool {
+	t, ok := target.(*Type)
+	return ok && t.Code == p.Code()
+}
+
+// Lookup returns the registered Type for code
+func Lookup(code string) (*Type, bool) {
+	for i := range Types {
+		if Types[i].Code == code {
+			return &Types[i], true
+		}
+	}
+	return nil, false
+}
+
+// lookup is Lookup for the generated ErrXxx values
+func lookup(code string) *Type {
+	t, ok := Lookup(code)
+	if !ok {
+		panic("problem: unregistered code " + code)
+	}
+	return t
+}
+
+// Decode builds the Problem for a failed response from its status,
+// headers and body. problem+json bodies are decoded as is; the JSON
+// errors of older servers ({"error": code, "message": ...}) and other
+// bodies are mapped to the closest type, so errors.Is works either way.
+func Decode(status int, header http.Header, body []byte) *Problem {
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+
+	if mediaType == ContentType {
+		var p Problem
+		if err := json.Unmarshal(body, &p); err == nil && p.Type != "" {
+			if p.Status == 0 {
+				p.Status = status
+			}
+			if p.RequestID == "" {
+				p.RequestID = header.Get(RequestIDHeader)
+			}
+			return &p
+		}
+	}
+
+	var legacy struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	json.Unmarshal(body, &legacy)
+
+	p := &Problem{Type: "about:blank", Status: status, Title: http.StatusText(status)}
+	if t, ok := Lookup(legacy.Error); ok {
+		p.Type, p.Title = t.URI(), t.Title
+	}
+	p.Detail = legacy.Message
+	p.RequestID = header.Get(RequestIDHeader)
+	return p
+}
@@ -0,0 +1,22 @@
+package problem
+
+//go:generate go run gen-errors.go
+
+// Types lists every problem the server returns. The status of a code is
+// the same on every endpoint. Run "go generate" after changing the list
+// to regenerate the ErrXxx values in errors.go.
+var Types = []Type{
+	{Code: "bad_request", Status: 400, Title: "Bad Request"},
+	{Code: "decryption_failed", Status: 401, Title: "Decryption Failed"},
+	{Code: "forbidden", Status: 403, Title: "Forbidden"},
+	{Code: "not_found", Status: 404, Title: "Not Found"},
+	{Code: "method_not_allowed", Status: 405, Title: "Method Not Allowed"},
+	{Code: "timeout", Status: 408, Title: "Request Timeout"},
+	{Code: "too_large", Status: 413, Title: "Payload Too Large"},
+	{Code: "quota_exceeded", Status: 429, Title: "Quota Exceeded"},
+	{Code: "internal_error", Status: 500, Title: "Internal Server Error"},
+	{Code: "encryption_failed", Status: 500, Title: "Encryption Failed"},
+	{Code: "store_corrupt", Status: 502, Title: "Stored Data Corrupt"},
+	{Code: "store_failed", Status: 503, Title: "Store Unavailable"},
+	{Code: "insufficient_storage", Status: 507, Title: "Insufficient Storage"},
+}
@@ -0,0 +1,141 @@
+// Package randtest implements the core statistical tests of NIST SP 800-22
+// Rev. 1a for judging the output of a random or pseudorandom generator:
+// frequency, frequency within a block, runs, longest run of ones in a
+// block, discrete Fourier transform (spectral), approximate entropy,
+// serial and cumulative sums.
+//
+// Each test computes one or more P-values from the sequence and passes if
+// every P-value is at least the significance level. A pass is evidence,
+// not proof, of randomness; at the default level of 0.01, about one good
+// sequence in a hundred fails any given test. Assessing a generator
+// properly takes many sequences (SP 800-22 section 4); a single report
+// catches gross defects such as bias, stuck bits and periodicity.
+//
+// Sequences are bit strings; Expand takes the bits of each byte most
+// significant first. A test that needs more bits than the sequence holds
+// is skipped, with the reason, rather than failed.
+package randtest
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// DefaultAlpha is the significance level recommended by SP 800-22
+	DefaultAlpha = 0.01
+
+	// MinBits is the shortest sequence the tests accept; SP 800-22
+	// recommends at least 10^6 bits for a full assessment
+	MinBits = 100
+)
+
+// Config sets the significance level and the test parameters; zero values
+// select the defaults, scaled to the length of the sequence
+type Config struct {
+	Alpha float64 // Significance level (default DefaultAlpha)
+
+	BlockSize     int // M of the frequency within a block test (default 128)
+	EntropyLength int // m of the approximate entropy test (default 10, less for short sequences)
+	SerialLength  int // m of the serial test (default 16, less for short sequences)
+}
+
+// Result is the outcome of one test
+type Result struct {
+	Name    string    `json:"name"`
+	PValues []float64 `json:"p_values,omitempty"`
+	Passed  bool      `json:"passed"`
+	Skipped string    `json:"skipped,omitempty"` // Why the test did not run
+}
+
+// Report is the outcome of every test on one sequence
+type Report struct {
+	Bits    int      `json:"bits"`
+	Alpha   float64  `json:"alpha"`
+	Results []Result `json:"results"`
+	Passed  bool     `json:"passed"` // No test failed
+	Failed  []string `json:"failed,omitempty"`
+}
+
+// Expand returns the bits of data, one per byte, most significant first
+func Expand(data []byte) []uint8 {
+	bits := make([]uint8, 8*len(data))
+	for i, b := range data {
+		for j := 0; j < 8; j++ {
+			bits[8*i+j] = (b >> (7 - j)) & 1
+		}
+	}
+	return bits
+}
+
+// Run applies every test to the bits of data
+func Run(data []byte, config Config) (*Report, error) {
+	return RunBits(Expand(data), config)
+}
+
+// RunBits applies every test to a sequence of bits (0 or 1)
+func RunBits(bits []uint8, config Config) (*Report, error) {
+	config = config.withDefaults(len(bits))
+	if len(bits) < MinBits {
+		return nil, fmt.Errorf("sequence of %d bits is too short: need at least %d", len(bits), MinBits)
+	}
+	if config.Alpha <= 0 || config.Alpha >= 1 {
+		return nil, fmt.Errorf("invalid significance level %g: want 0 < alpha < 1", config.Alpha)
+	}
+
+	report := &Report{Bits: len(bits), Alpha: config.Alpha, Passed: true}
+	for _, test := range []func() Result{
+		func() Result { return Frequency(bits, config.Alpha) },
+		func() Result { return BlockFrequency(bits, config.BlockSize, config.Alpha) },
+		func() Result { return Runs(bits, config.Alpha) },
+		func() Result { return LongestRun(bits, config.Alpha) },
+		func() Result { return Spectral(bits, config.Alpha) },
+		func() Result { return ApproximateEntropy(bits, config.EntropyLength, config.Alpha) },
+		func() Result { return Serial(bits, config.SerialLength, config.Alpha) },
+		func() Result { return CumulativeSums(bits, config.Alpha) },
+	} {
+		r := test()
+		if r.Skipped == "" && !r.Passed {
+			report.Passed = false
+			report.Failed = append(report.Failed, r.Name)
+		}
+		report.Results = append(report.Results, r)
+	}
+	return report, nil
+}
+
+// withDefaults fills in the zero fields of config for a sequence of n bits
+func (config Config) withDefaults(n int) Config {
+	if config.Alpha == 0 {
+		config.Alpha = DefaultAlpha
+	}
+	if config.BlockSize == 0 {
+		config.BlockSize = 128
+	}
+	// SP 800-22 requires m < floor(log2 n) - 5 for approximate entropy
+	// and m < floor(log2 n) - 2 for serial
+	log2n := int(math.Log2(float64(max(n, 1))))
+	if config.EntropyLength == 0 {
+		config.EntropyLength = max(2, min(10, log2n-6))
+	}
+	if config.SerialLength == 0 {
+		config.SerialLength = max(2, min(16, log2n-3))
+	}
+	return config
+}
+
+// result returns a Result that passes if every P-value is at least alpha
+func result(name string, alpha float64, pvalues ...float64) Result {
+	r := Result{Name: name, PValues: pvalues, Passed: true}
+	for _, p := range pvalues {
+		if math.IsNaN(p) || p < alpha {
+			r.Passed = false
+		}
+	}
+	return r
+}
+
+// skipped returns a Result for a test that cannot run
+func skipped(name, format string, args ...interface{}) Result {
+	return Result{Name: name, Skipped: fmt.Sprintf(format, args...)}
+}
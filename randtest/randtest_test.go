@@ -0,0 +1,88 @@
+package randtest
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"math"
+	"testing"
+)
+
+// ============================================================================
+// EAMSA 512 - Randomness Test Suite
+// The SP 800-22 tests against the worked examples of the standard
+//
+// Last updated: December 4, 2025
+// ============================================================================
+
+// example is the 100-bit sequence (the first bits of pi) of the examples
+// in SP 800-22 sections 2.1.8, 2.2.8, 2.3.8 and 2.13.8
+const example = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+
+// exampleBits returns example as a bit sequence
+func exampleBits() []uint8 {
+	bits := make([]uint8, len(example))
+	for i, c := range example {
+		bits[i] = uint8(c - '0')
+	}
+	return bits
+}
+
+// TestWorkedExamples tests the P-values of the worked examples of SP 800-22
+func TestWorkedExamples(t *testing.T) {
+	fmt.Println("Test: SP 800-22 Worked Examples")
+
+	bits := exampleBits()
+	for _, tc := range []struct {
+		result Result
+		want   float64
+	}{
+		{Frequency(bits, DefaultAlpha), 0.109599},
+		{BlockFrequency(bits, 10, DefaultAlpha), 0.706438},
+		{Runs(bits, DefaultAlpha), 0.500798},
+		{CumulativeSums(bits, DefaultAlpha), 0.219194},
+	} {
+		if tc.result.Skipped != "" || len(tc.result.PValues) == 0 {
+			t.Fatalf("%s: skipped: %s", tc.result.Name, tc.result.Skipped)
+		}
+		if got := tc.result.PValues[0]; math.Abs(got-tc.want) > 1e-6 {
+			t.Errorf("%s: P-value %f, want %f", tc.result.Name, got, tc.want)
+		}
+		if !tc.result.Passed {
+			t.Errorf("%s: failed at alpha %g", tc.result.Name, DefaultAlpha)
+		}
+	}
+
+	fmt.Println("✓ P-values match the standard")
+}
+
+// TestRun tests that random data passes, stuck data fails and short data
+// is rejected
+func TestRun(t *testing.T) {
+	fmt.Println("Test: Randomness Report")
+
+	random := make([]byte, 1<<14)
+	rand.Read(random)
+	// Accept one failure: about one good sequence in a hundred fails a test
+	report, err := Run(random, Config{})
+	if err != nil || len(report.Failed) > 1 {
+		t.Fatalf("Random data: failed %v, %v", report.Failed, err)
+	}
+	if report.Bits != 8*len(random) || len(report.Results) != 8 {
+		t.Fatalf("Report covers %d bits with %d results", report.Bits, len(report.Results))
+	}
+
+	report, err = Run(bytes.Repeat([]byte{0xff}, 1<<10), Config{})
+	if err != nil || report.Passed {
+		t.Fatalf("Stuck data passed: %v", err)
+	}
+
+	if _, err := Run(make([]byte, 4), Config{}); err == nil {
+		t.Fatal("Sequence below MinBits accepted")
+	}
+	if _, err := Run(random, Config{Alpha: 1}); err == nil {
+		t.Fatal("Significance level of 1 accepted")
+	}
+
+	fmt.Println("✓ Gross defects detected")
+}
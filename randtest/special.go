@@ -0,0 +1,137 @@
+package randtest
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// normal is the standard normal cumulative distribution function
+func normal(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// igamc is the regularized upper incomplete gamma function Q(a, x), the
+// P-value of a chi-square statistic 2x with 2a degrees of freedom
+func igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	lgamma, _ := math.Lgamma(a)
+	scale := math.Exp(a*math.Log(x) - x - lgamma)
+
+	if x < a+1 {
+		// Series for the lower function P(a, x)
+		term := 1 / a
+		sum := term
+		for n := 1; n < 1000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*1e-15 {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*scale)
+	}
+
+	// Continued fraction for Q(a, x), by the modified Lentz method
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 1000; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return h * scale
+}
+
+// dft returns the discrete Fourier transform of x, of any length: a radix-2
+// FFT for powers of two, Bluestein's algorithm otherwise
+func dft(x []complex128) []complex128 {
+	n := len(x)
+	if n&(n-1) == 0 {
+		out := append([]complex128(nil), x...)
+		fft(out, false)
+		return out
+	}
+
+	// X[k] = w[k] * sum x[j] w[j] conj(w[k-j]), w[j] = exp(-i pi j^2 / n),
+	// a convolution done with FFTs of a power-of-two length
+	size := 1 << bits.Len(uint(2*n-1))
+	w := make([]complex128, n)
+	for j := range w {
+		// j^2 mod 2n keeps the angle small enough to be exact
+		jj := uint64(j) * uint64(j) % uint64(2*n)
+		w[j] = cmplx.Rect(1, -math.Pi*float64(jj)/float64(n))
+	}
+
+	a := make([]complex128, size)
+	b := make([]complex128, size)
+	for j := 0; j < n; j++ {
+		a[j] = x[j] * w[j]
+		b[j] = cmplx.Conj(w[j])
+		if j > 0 {
+			b[size-j] = b[j]
+		}
+	}
+	fft(a, false)
+	fft(b, false)
+	for i := range a {
+		a[i] *= b[i]
+	}
+	fft(a, true)
+
+	out := make([]complex128, n)
+	for k := range out {
+		out[k] = w[k] * a[k] / complex(float64(size), 0)
+	}
+	return out
+}
+
+// fft transforms x in place; len(x) must be a power of two. The inverse
+// is unscaled.
+func fft(x []complex128, inverse bool) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	sign := -1.0
+	if inverse {
+		sign = 1
+	}
+	for length := 2; length <= n; length <<= 1 {
+		step := cmplx.Rect(1, sign*2*math.Pi/float64(length))
+		for i := 0; i < n; i += length {
+			w := complex(1, 0)
+			for j := 0; j < length/2; j++ {
+				u, v := x[i+j], x[i+j+length/2]*w
+				x[i+j], x[i+j+length/2] = u+v, u-v
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package randtest
+
+import (
+	"math"
+	"math/cmplx"
+)
+
+// Test names, as reported in Result.Name
+const (
+	NameFrequency          = "frequency"
+	NameBlockFrequency     = "block frequency"
+	NameRuns               = "runs"
+	NameLongestRun         = "longest run"
+	NameSpectral           = "spectral"
+	NameApproximateEntropy = "approximate entropy"
+	NameSerial             = "serial"
+	NameCumulativeSums     = "cumulative sums"
+)
+
+// Frequency is the frequency (monobit) test (SP 800-22 2.1): the
+// proportion of ones should be about one half
+func Frequency(bits []uint8, alpha float64) Result {
+	n := len(bits)
+	if n < 100 {
+		return skipped(NameFrequency, "needs at least 100 bits")
+	}
+
+	sum := 0
+	for _, b := range bits {
+		sum += 2*int(b) - 1
+	}
+	s := math.Abs(float64(sum)) / math.Sqrt(float64(n))
+	return result(NameFrequency, alpha, math.Erfc(s/math.Sqrt2))
+}
+
+// BlockFrequency is the frequency test within blocks of m bits (SP 800-22
+// 2.2): the proportion of ones in each block should be about one half.
+// SP 800-22 recommends m >= 20 and m > n/100.
+func BlockFrequency(bits []uint8, m int, alpha float64) Result {
+	if m < 1 {
+		return skipped(NameBlockFrequency, "invalid block size %d", m)
+	}
+	blocks := len(bits) / m
+	if blocks < 1 {
+		return skipped(NameBlockFrequency, "needs at least one block of %d bits", m)
+	}
+
+	var x2 float64
+	for i := 0; i < blocks; i++ {
+		ones := 0
+		for _, b := range bits[i*m : (i+1)*m] {
+			ones += int(b)
+		}
+		d := float64(ones)/float64(m) - 0.5
+		x2 += d * d
+	}
+	x2 *= 4 * float64(m)
+	return result(NameBlockFrequency, alpha, igamc(float64(blocks)/2, x2/2))
+}
+
+// Runs is the runs test (SP 800-22 2.3): the number of runs of identical
+// bits should be that of a random sequence. A sequence failing the
+// frequency prerequisite gets a P-value of 0.
+func Runs(bits []uint8, alpha float64) Result {
+	n := len(bits)
+	if n < 100 {
+		return skipped(NameRuns, "needs at least 100 bits")
+	}
+
+	ones := 0
+	for _, b := range bits {
+		ones += int(b)
+	}
+	pi := float64(ones) / float64(n)
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return result(NameRuns, alpha, 0)
+	}
+
+	runs := 1
+	for i := 1; i < n; i++ {
+		if bits[i] != bits[i-1] {
+			runs++
+		}
+	}
+	expected := 2 * float64(n) * pi * (1 - pi)
+	p := math.Erfc(math.Abs(float64(runs)-expected) / (2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)))
+	return result(NameRuns, alpha, p)
+}
+
+// longestRunClass is the block size and expected distribution of the
+// longest run of ones for a range of sequence lengths (SP 800-22 2.4.4,
+// with the unrounded probabilities of the reference implementation)
+type longestRunClass struct {
+	minBits int
+	m       int       // Block size
+	low     int       // Longest runs of at most low fall in the first class
+	probs   []float64 // Probability of each class; the last is "low+K or more"
+}
+
+var longestRunClasses = []longestRunClass{
+	{750000, 10000, 10, []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}},
+	{6272, 128, 4, []float64{0.1174035788, 0.242955959, 0.249363483, 0.17517706, 0.102701071, 0.112398847}},
+	{128, 8, 1, []float64{0.21484375, 0.3671875, 0.23046875, 0.1875}},
+}
+
+// LongestRun is the test for the longest run of ones in a block (SP 800-22
+// 2.4): its distribution over blocks should be that of a random sequence
+func LongestRun(bits []uint8, alpha float64) Result {
+	var class *longestRunClass
+	for i := range longestRunClasses {
+		if len(bits) >= longestRunClasses[i].minBits {
+			class = &longestRunClasses[i]
+			break
+		}
+	}
+	if class == nil {
+		return skipped(NameLongestRun, "needs at least 128 bits")
+	}
+
+	k := len(class.probs) - 1
+	counts := make([]int, len(class.probs))
+	blocks := len(bits) / class.m
+	for i := 0; i < blocks; i++ {
+		longest, run := 0, 0
+		for _, b := range bits[i*class.m : (i+1)*class.m] {
+			if b == 1 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		counts[min(max(longest-class.low, 0), k)]++
+	}
+
+	var x2 float64
+	for i, c := range counts {
+		expected := float64(blocks) * class.probs[i]
+		d := float64(c) - expected
+		x2 += d * d / expected
+	}
+	return result(NameLongestRun, alpha, igamc(float64(k)/2, x2/2))
+}
+
+// Spectral is the discrete Fourier transform test (SP 800-22 2.6):
+// periodic features show as peaks exceeding the 95% threshold more often
+// than in 5% of the first half of the spectrum
+func Spectral(bits []uint8, alpha float64) Result {
+	n := len(bits)
+	if n < 1000 {
+		return skipped(NameSpectral, "needs at least 1000 bits")
+	}
+
+	x := make([]complex128, n)
+	for i, b := range bits {
+		x[i] = complex(2*float64(b)-1, 0)
+	}
+	s := dft(x)
+
+	threshold := math.Sqrt(math.Log(1/0.05) * float64(n))
+	below := 0
+	for _, v := range s[:n/2] {
+		if cmplx.Abs(v) < threshold {
+			below++
+		}
+	}
+	expected := 0.95 * float64(n) / 2
+	d := (float64(below) - expected) / math.Sqrt(float64(n)*0.95*0.05/4)
+	return result(NameSpectral, alpha, math.Erfc(math.Abs(d)/math.Sqrt2))
+}
+
+// maxPatternLength bounds m of the pattern tests, which count 2^m patterns
+const maxPatternLength = 24
+
+// ApproximateEntropy is the approximate entropy test (SP 800-22 2.12): the
+// frequencies of overlapping m- and (m+1)-bit patterns should be those of
+// a random sequence
+func ApproximateEntropy(bits []uint8, m int, alpha float64) Result {
+	n := len(bits)
+	if m < 1 || m+1 > maxPatternLength || 1<<m > n {
+		return skipped(NameApproximateEntropy, "pattern length %d out of range for %d bits", m, n)
+	}
+
+	phi := func(m int) float64 {
+		var sum float64
+		for _, c := range patternCounts(bits, m) {
+			if c > 0 {
+				p := float64(c) / float64(n)
+				sum += p * math.Log(p)
+			}
+		}
+		return sum
+	}
+	apen := phi(m) - phi(m+1)
+	x2 := 2 * float64(n) * (math.Ln2 - apen)
+	return result(NameApproximateEntropy, alpha, igamc(math.Pow(2, float64(m-1)), x2/2))
+}
+
+// Serial is the serial test (SP 800-22 2.11): every overlapping m-bit
+// pattern should be about equally likely. It returns two P-values, from
+// the first and second differences of the pattern statistics.
+func Serial(bits []uint8, m int, alpha float64) Result {
+	n := len(bits)
+	if m < 2 || m > maxPatternLength || 1<<m > n {
+		return skipped(NameSerial, "pattern length %d out of range for %d bits", m, n)
+	}
+
+	psi2 := func(m int) float64 {
+		if m < 1 {
+			return 0
+		}
+		var sum float64
+		for _, c := range patternCounts(bits, m) {
+			sum += float64(c) * float64(c)
+		}
+		return sum*math.Pow(2, float64(m))/float64(n) - float64(n)
+	}
+	p0, p1, p2 := psi2(m), psi2(m-1), psi2(m-2)
+	del1 := p0 - p1
+	del2 := p0 - 2*p1 + p2
+	return result(NameSerial, alpha,
+		igamc(math.Pow(2, float64(m-2)), del1/2),
+		igamc(math.Pow(2, float64(m-3)), del2/2))
+}
+
+// patternCounts counts the overlapping m-bit patterns of bits, wrapping
+// around at the end
+func patternCounts(bits []uint8, m int) []int {
+	n := len(bits)
+	mask := 1<<m - 1
+	counts := make([]int, 1<<m)
+
+	v := 0
+	for i := 0; i < m-1; i++ {
+		v = v<<1 | int(bits[i])
+	}
+	for i := 0; i < n; i++ {
+		v = (v<<1 | int(bits[(i+m-1)%n])) & mask
+		counts[v]++
+	}
+	return counts
+}
+
+// CumulativeSums is the cumulative sums test (SP 800-22 2.13), forward
+// and backward: the random walk of ±1 steps should not stray too far from
+// zero
+func CumulativeSums(bits []uint8, alpha float64) Result {
+	n := len(bits)
+	if n < 100 {
+		return skipped(NameCumulativeSums, "needs at least 100 bits")
+	}
+
+	excursion := func(forward bool) int {
+		sum, z := 0, 0
+		for i := 0; i < n; i++ {
+			b := bits[i]
+			if !forward {
+				b = bits[n-1-i]
+			}
+			sum += 2*int(b) - 1
+			if sum > z {
+				z = sum
+			} else if -sum > z {
+				z = -sum
+			}
+		}
+		return z
+	}
+	return result(NameCumulativeSums, alpha, cusumP(n, excursion(true)), cusumP(n, excursion(false)))
+}
+
+// cusumP is the P-value of a maximum excursion z over n steps
+func cusumP(n, z int) float64 {
+	if z == 0 {
+		return 0 // Impossible for n >= 1: every step moves the walk
+	}
+	fn, fz := float64(n), float64(z)
+	sqrtN := math.Sqrt(fn)
+
+	var sum1, sum2 float64
+	for k := int((-fn/fz + 1) / 4); k <= int((fn/fz-1)/4); k++ {
+		sum1 += normal(float64(4*k+1)*fz/sqrtN) - normal(float64(4*k-1)*fz/sqrtN)
+	}
+	for k := int((-fn/fz - 3) / 4); k <= int((fn/fz-1)/4); k++ {
+		sum2 += normal(float64(4*k+3)*fz/sqrtN) - normal(float64(4*k+1)*fz/sqrtN)
+	}
+	return 1 - sum1 + sum2
+}
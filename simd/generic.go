@@ -0,0 +1,59 @@
+package simd
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// The portable kernels, also the reference the assembly is checked against
+
+func msaDiagonalGeneric(m *[4][4]uint32) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			v := m[i][j]
+			m[i][j] ^= bits.RotateLeft32(v, 7) ^ bits.RotateLeft32(v, 1)
+		}
+	}
+}
+
+func msaCrossDiagonalGeneric(m *[4][4]uint32) {
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			v := m[i][j]
+			next := m[(i+1)%4][(j+1)%4]
+			prev := m[(i+3)%4][(j+3)%4]
+			m[i][j] ^= (v + next) ^ (v + prev)
+		}
+	}
+}
+
+func msaFinalGeneric(m *[4][4]uint32) {
+	for i := 0; i < 4; i++ {
+		sum := m[i][0] + m[i][1] + m[i][2] + m[i][3]
+		for j := 0; j < 4; j++ {
+			m[i][j] ^= sum - m[i][j]
+		}
+	}
+}
+
+// transposeBitsGeneric reflects each word across its anti-diagonal, as
+// the assembly does: loaded little-endian, bit 7-j of byte i is bit
+// 8i+7-j and belongs at 8j+7-i. Three delta swaps of 9, 18 and 36 places
+// exchange the bit pairs that differ in the low, middle and high bit of
+// both coordinates, in constant time.
+func transposeBitsGeneric(block *[64]byte) {
+	for w := 0; w < 64; w += 8 {
+		x := binary.LittleEndian.Uint64(block[w:])
+		x = deltaSwap(x, 0x0055005500550055, 9)
+		x = deltaSwap(x, 0x0000333300003333, 18)
+		x = deltaSwap(x, 0x000000000f0f0f0f, 36)
+		binary.LittleEndian.PutUint64(block[w:], x)
+	}
+}
+
+// deltaSwap swaps the bits of x under mask with the bits shift places
+// above them
+func deltaSwap(x, mask uint64, shift uint) uint64 {
+	t := (x>>shift ^ x) & mask
+	return x ^ t ^ t<<shift
+}
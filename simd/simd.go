@@ -0,0 +1,63 @@
+// Package simd holds the vector kernels of the Phase 2 cipher: the three
+// steps of an MSA round over the 4×4 word matrix and the P-layer bit
+// permutation, an 8×8 bit transpose of each 64-bit word.
+//
+// On amd64 with AVX2 and on arm64 (NEON is part of the base architecture)
+// the kernels are written in assembly; elsewhere, or when built with the
+// purego tag, they run as portable Go. Both produce identical output, so
+// the choice is invisible except in speed. Available reports which is in
+// use, for diagnostics.
+package simd
+
+// Available reports whether the kernels run as vector assembly on this CPU
+func Available() bool {
+	return useAsm
+}
+
+// Name is the instruction set the kernels use: "avx2", "neon" or "generic"
+func Name() string {
+	if !useAsm {
+		return "generic"
+	}
+	return asmName
+}
+
+// MSADiagonal XORs each word of m with itself rotated left by 7 and by 1
+func MSADiagonal(m *[4][4]uint32) {
+	if useAsm {
+		msaDiagonalAsm(m)
+		return
+	}
+	msaDiagonalGeneric(m)
+}
+
+// MSACrossDiagonal mixes each word with its diagonal neighbours, row by
+// row in place: row i reads row i-1 after its update and row i+1 before,
+// so row 3 sees the updated row 0 on both sides
+func MSACrossDiagonal(m *[4][4]uint32) {
+	if useAsm {
+		msaCrossDiagonalAsm(m)
+		return
+	}
+	msaCrossDiagonalGeneric(m)
+}
+
+// MSAFinal XORs each word of m with the sum of the other words of its row
+func MSAFinal(m *[4][4]uint32) {
+	if useAsm {
+		msaFinalAsm(m)
+		return
+	}
+	msaFinalGeneric(m)
+}
+
+// TransposeBits transposes each 8-byte word of block as an 8×8 bit
+// matrix, bytes as rows and bits most significant first: bit j of byte i
+// of a word becomes bit i of byte j
+func TransposeBits(block *[64]byte) {
+	if useAsm {
+		transposeBitsAsm(block)
+		return
+	}
+	transposeBitsGeneric(block)
+}
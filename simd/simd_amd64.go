@@ -0,0 +1,21 @@
+//go:build !purego
+
+package simd
+
+import "golang.org/x/sys/cpu"
+
+const asmName = "avx2"
+
+var useAsm = cpu.X86.HasAVX2
+
+//go:noescape
+func msaDiagonalAsm(m *[4][4]uint32)
+
+//go:noescape
+func msaCrossDiagonalAsm(m *[4][4]uint32)
+
+//go:noescape
+func msaFinalAsm(m *[4][4]uint32)
+
+//go:noescape
+func transposeBitsAsm(block *[64]byte)
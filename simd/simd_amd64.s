@@ -0,0 +1,114 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// Masks of the three delta swaps of the bit transpose
+DATA transposeMasks<>+0x00(SB)/8, $0x0055005500550055
+DATA transposeMasks<>+0x08(SB)/8, $0x0000333300003333
+DATA transposeMasks<>+0x10(SB)/8, $0x000000000f0f0f0f
+GLOBL transposeMasks<>(SB), (NOPTR+RODATA), $24
+
+// ROTL_XOR(x, t0, t1) sets x ^= rotl(x, 7) ^ rotl(x, 1) on 8 words
+#define ROTL_XOR(x, t0, t1) \
+	VPSLLD $7, x, t0  \
+	VPSRLD $25, x, t1 \
+	VPOR   t1, t0, t0 \
+	VPSLLD $1, x, t1  \
+	VPXOR  t1, t0, t0 \
+	VPSRLD $31, x, t1 \
+	VPXOR  t1, t0, t0 \
+	VPXOR  t0, x, x
+
+// func msaDiagonalAsm(m *[4][4]uint32)
+TEXT ·msaDiagonalAsm(SB), NOSPLIT, $0-8
+	MOVQ    m+0(FP), AX
+	VMOVDQU 0(AX), Y0
+	VMOVDQU 32(AX), Y1
+	ROTL_XOR(Y0, Y2, Y3)
+	ROTL_XOR(Y1, Y4, Y5)
+	VMOVDQU Y0, 0(AX)
+	VMOVDQU Y1, 32(AX)
+	VZEROUPPER
+	RET
+
+// CROSS(row, next, prev, t0, t1) sets row ^= (row + next') ^ (row + prev'),
+// where next' and prev' are the lanes of next and prev rotated so that
+// lane j holds column j+1 and j-1
+#define CROSS(row, next, prev, t0, t1) \
+	VPSHUFD $0x39, next, t0 \
+	VPSHUFD $0x93, prev, t1 \
+	VPADDD  row, t0, t0     \
+	VPADDD  row, t1, t1     \
+	VPXOR   t1, t0, t0      \
+	VPXOR   t0, row, row
+
+// func msaCrossDiagonalAsm(m *[4][4]uint32)
+TEXT ·msaCrossDiagonalAsm(SB), NOSPLIT, $0-8
+	MOVQ    m+0(FP), AX
+	VMOVDQU 0(AX), X0
+	VMOVDQU 16(AX), X1
+	VMOVDQU 32(AX), X2
+	VMOVDQU 48(AX), X3
+	CROSS(X0, X1, X3, X4, X5)
+	CROSS(X1, X2, X0, X4, X5)
+	CROSS(X2, X3, X1, X4, X5)
+	CROSS(X3, X0, X2, X4, X5)
+	VMOVDQU X0, 0(AX)
+	VMOVDQU X1, 16(AX)
+	VMOVDQU X2, 32(AX)
+	VMOVDQU X3, 48(AX)
+	RET
+
+// ROW_SUM_XOR(x, t0, t1) sets each word of x ^= (sum of its row) - word,
+// one row per 128-bit lane
+#define ROW_SUM_XOR(x, t0, t1) \
+	VPSHUFD $0x4e, x, t0 \
+	VPADDD  x, t0, t0    \
+	VPSHUFD $0xb1, t0, t1 \
+	VPADDD  t1, t0, t0   \
+	VPSUBD  x, t0, t0    \
+	VPXOR   t0, x, x
+
+// func msaFinalAsm(m *[4][4]uint32)
+TEXT ·msaFinalAsm(SB), NOSPLIT, $0-8
+	MOVQ    m+0(FP), AX
+	VMOVDQU 0(AX), Y0
+	VMOVDQU 32(AX), Y1
+	ROW_SUM_XOR(Y0, Y2, Y3)
+	ROW_SUM_XOR(Y1, Y4, Y5)
+	VMOVDQU Y0, 0(AX)
+	VMOVDQU Y1, 32(AX)
+	VZEROUPPER
+	RET
+
+// DELTA_SWAP(x, mask, shift, t) swaps the bits of x under mask with the
+// bits shift places above them, in each 64-bit word
+#define DELTA_SWAP(x, mask, shift, t) \
+	VPSRLQ $shift, x, t \
+	VPXOR  x, t, t      \
+	VPAND  mask, t, t   \
+	VPXOR  t, x, x      \
+	VPSLLQ $shift, t, t \
+	VPXOR  t, x, x
+
+// func transposeBitsAsm(block *[64]byte)
+// Loaded little-endian, bit 7-j of byte i of a word is bit 8i+7-j; the
+// transpose moves it to 8j+7-i, which is a reflection across the
+// anti-diagonal: three delta swaps of 9, 18 and 36 places
+TEXT ·transposeBitsAsm(SB), NOSPLIT, $0-8
+	MOVQ         block+0(FP), AX
+	VMOVDQU      0(AX), Y0
+	VMOVDQU      32(AX), Y1
+	VPBROADCASTQ transposeMasks<>+0x00(SB), Y4
+	VPBROADCASTQ transposeMasks<>+0x08(SB), Y5
+	VPBROADCASTQ transposeMasks<>+0x10(SB), Y6
+	DELTA_SWAP(Y0, Y4, 9, Y2)
+	DELTA_SWAP(Y1, Y4, 9, Y3)
+	DELTA_SWAP(Y0, Y5, 18, Y2)
+	DELTA_SWAP(Y1, Y5, 18, Y3)
+	DELTA_SWAP(Y0, Y6, 36, Y2)
+	DELTA_SWAP(Y1, Y6, 36, Y3)
+	VMOVDQU      Y0, 0(AX)
+	VMOVDQU      Y1, 32(AX)
+	VZEROUPPER
+	RET
@@ -0,0 +1,20 @@
+//go:build !purego
+
+package simd
+
+const asmName = "neon"
+
+// NEON (ASIMD) is mandatory on arm64
+const useAsm = true
+
+//go:noescape
+func msaDiagonalAsm(m *[4][4]uint32)
+
+//go:noescape
+func msaCrossDiagonalAsm(m *[4][4]uint32)
+
+//go:noescape
+func msaFinalAsm(m *[4][4]uint32)
+
+//go:noescape
+func transposeBitsAsm(block *[64]byte)
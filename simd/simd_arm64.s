@@ -0,0 +1,105 @@
+//go:build !purego
+
+#include "textflag.h"
+
+// ROTL_XOR(x, t0, t1) sets x ^= rotl(x, 7) ^ rotl(x, 1) on 4 words
+#define ROTL_XOR(x, t0, t1) \
+	VSHL  $7, x.S4, t0.S4           \
+	VUSHR $25, x.S4, t1.S4          \
+	VORR  t1.B16, t0.B16, t0.B16    \
+	VSHL  $1, x.S4, t1.S4           \
+	VEOR  t1.B16, t0.B16, t0.B16    \
+	VUSHR $31, x.S4, t1.S4          \
+	VEOR  t1.B16, t0.B16, t0.B16    \
+	VEOR  t0.B16, x.B16, x.B16
+
+// func msaDiagonalAsm(m *[4][4]uint32)
+TEXT ·msaDiagonalAsm(SB), NOSPLIT, $0-8
+	MOVD m+0(FP), R0
+	VLD1 (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ROTL_XOR(V0, V4, V5)
+	ROTL_XOR(V1, V4, V5)
+	ROTL_XOR(V2, V4, V5)
+	ROTL_XOR(V3, V4, V5)
+	VST1 [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	RET
+
+// CROSS(row, next, prev, t0, t1) sets row ^= (row + next') ^ (row + prev'),
+// where next' and prev' are the lanes of next and prev rotated so that
+// lane j holds column j+1 and j-1
+#define CROSS(row, next, prev, t0, t1) \
+	VEXT $4, next.B16, next.B16, t0.B16  \
+	VEXT $12, prev.B16, prev.B16, t1.B16 \
+	VADD row.S4, t0.S4, t0.S4            \
+	VADD row.S4, t1.S4, t1.S4            \
+	VEOR t1.B16, t0.B16, t0.B16          \
+	VEOR t0.B16, row.B16, row.B16
+
+// func msaCrossDiagonalAsm(m *[4][4]uint32)
+TEXT ·msaCrossDiagonalAsm(SB), NOSPLIT, $0-8
+	MOVD m+0(FP), R0
+	VLD1 (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	CROSS(V0, V1, V3, V4, V5)
+	CROSS(V1, V2, V0, V4, V5)
+	CROSS(V2, V3, V1, V4, V5)
+	CROSS(V3, V0, V2, V4, V5)
+	VST1 [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	RET
+
+// ROW_SUM_XOR(x, t0, t1) sets each word of the row x ^= (sum of x) - word
+#define ROW_SUM_XOR(x, t0, t1) \
+	VEXT $8, x.B16, x.B16, t0.B16    \
+	VADD x.S4, t0.S4, t0.S4          \
+	VEXT $4, t0.B16, t0.B16, t1.B16  \
+	VADD t1.S4, t0.S4, t0.S4         \
+	VSUB x.S4, t0.S4, t0.S4          \
+	VEOR t0.B16, x.B16, x.B16
+
+// func msaFinalAsm(m *[4][4]uint32)
+TEXT ·msaFinalAsm(SB), NOSPLIT, $0-8
+	MOVD m+0(FP), R0
+	VLD1 (R0), [V0.S4, V1.S4, V2.S4, V3.S4]
+	ROW_SUM_XOR(V0, V4, V5)
+	ROW_SUM_XOR(V1, V4, V5)
+	ROW_SUM_XOR(V2, V4, V5)
+	ROW_SUM_XOR(V3, V4, V5)
+	VST1 [V0.S4, V1.S4, V2.S4, V3.S4], (R0)
+	RET
+
+// DELTA_SWAP(x, mask, shift, t) swaps the bits of x under mask with the
+// bits shift places above them, in each 64-bit word
+#define DELTA_SWAP(x, mask, shift, t) \
+	VUSHR $shift, x.D2, t.D2      \
+	VEOR  x.B16, t.B16, t.B16     \
+	VAND  mask.B16, t.B16, t.B16  \
+	VEOR  t.B16, x.B16, x.B16     \
+	VSHL  $shift, t.D2, t.D2      \
+	VEOR  t.B16, x.B16, x.B16
+
+// func transposeBitsAsm(block *[64]byte)
+// Loaded little-endian, bit 7-j of byte i of a word is bit 8i+7-j; the
+// transpose moves it to 8j+7-i, which is a reflection across the
+// anti-diagonal: three delta swaps of 9, 18 and 36 places
+TEXT ·transposeBitsAsm(SB), NOSPLIT, $0-8
+	MOVD block+0(FP), R0
+	VLD1 (R0), [V0.D2, V1.D2, V2.D2, V3.D2]
+	MOVD $0x0055005500550055, R1
+	VDUP R1, V16.D2
+	MOVD $0x0000333300003333, R1
+	VDUP R1, V17.D2
+	MOVD $0x000000000f0f0f0f, R1
+	VDUP R1, V18.D2
+	DELTA_SWAP(V0, V16, 9, V4)
+	DELTA_SWAP(V1, V16, 9, V4)
+	DELTA_SWAP(V2, V16, 9, V4)
+	DELTA_SWAP(V3, V16, 9, V4)
+	DELTA_SWAP(V0, V17, 18, V4)
+	DELTA_SWAP(V1, V17, 18, V4)
+	DELTA_SWAP(V2, V17, 18, V4)
+	DELTA_SWAP(V3, V17, 18, V4)
+	DELTA_SWAP(V0, V18, 36, V4)
+	DELTA_SWAP(V1, V18, 36, V4)
+	DELTA_SWAP(V2, V18, 36, V4)
+	DELTA_SWAP(V3, V18, 36, V4)
+	VST1 [V0.D2, V1.D2, V2.D2, V3.D2], (R0)
+	RET
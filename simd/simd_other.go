@@ -0,0 +1,12 @@
+//go:build (!amd64 && !arm64) || purego
+
+package simd
+
+const asmName = ""
+
+const useAsm = false
+
+func msaDiagonalAsm(m *[4][4]uint32)      { msaDiagonalGeneric(m) }
+func msaCrossDiagonalAsm(m *[4][4]uint32) { msaCrossDiagonalGeneric(m) }
+func msaFinalAsm(m *[4][4]uint32)         { msaFinalGeneric(m) }
+func transposeBitsAsm(block *[64]byte)    { transposeBitsGeneric(block) }
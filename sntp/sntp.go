@@ -0,0 +1,83 @@
+// Package sntp measures the local clock's offset from an NTP server with
+// a single SNTPv4 client request (RFC 4330). It is a sanity check for
+// time-based key decisions, not a time synchronization client: replies
+// are unauthenticated, so servers should be on a trusted network.
+package sntp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+// epochOffset is the number of seconds from 1900 (NTP) to 1970 (Unix)
+const epochOffset = 2208988800
+
+// Query returns the offset of server's clock from the local clock (server
+// minus local; positive when the local clock is behind). server is a host
+// or host:port; the port defaults to 123.
+func Query(server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, fmt.Errorf("NTP %s: %v", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	// LI 0, version 4, mode 3 (client); the transmit timestamp comes back
+	// as the origin timestamp and ties the reply to this request
+	request := make([]byte, 48)
+	request[0] = 0x23
+	sent := time.Now()
+	putTime(request[40:], sent)
+
+	if _, err := conn.Write(request); err != nil {
+		return 0, fmt.Errorf("NTP %s: %v", server, err)
+	}
+
+	response := make([]byte, 48)
+	n, err := conn.Read(response)
+	if err != nil {
+		return 0, fmt.Errorf("NTP %s: %v", server, err)
+	}
+	received := time.Now()
+
+	if n < 48 {
+		return 0, fmt.Errorf("NTP %s: short response (%d bytes)", server, n)
+	}
+	if mode := response[0] & 0x07; mode != 4 {
+		return 0, fmt.Errorf("NTP %s: unexpected mode %d", server, mode)
+	}
+	if stratum := response[1]; stratum == 0 || stratum > 15 {
+		return 0, fmt.Errorf("NTP %s: server unsynchronized (stratum %d)", server, stratum)
+	}
+	if string(response[24:32]) != string(request[40:48]) {
+		return 0, fmt.Errorf("NTP %s: response does not match request", server)
+	}
+
+	serverReceived := getTime(response[32:40])
+	serverSent := getTime(response[40:48])
+
+	// Offset ((T2 - T1) + (T3 - T4)) / 2 on wall time
+	return (serverReceived.Sub(sent.Round(0)) + serverSent.Sub(received.Round(0))) / 2, nil
+}
+
+// putTime writes t as a 64-bit NTP timestamp
+func putTime(b []byte, t time.Time) {
+	seconds := uint64(t.Unix()) + epochOffset
+	fraction := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, seconds<<32|fraction)
+}
+
+// getTime reads a 64-bit NTP timestamp
+func getTime(b []byte) time.Time {
+	value := binary.BigEndian.Uint64(b)
+	seconds := int64(value>>32) - epochOffset
+	nanoseconds := int64((value & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
@@ -0,0 +1,97 @@
+// Package otel records EAMSA 512 telemetry with the OpenTelemetry metrics API.
+//
+// It depends only on the OTel API; the application configures the SDK,
+// exporters and MeterProvider.
+package otel
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/Redeaux-Corporation/eamsa512/telemetry"
+)
+
+// ScopeName is the instrumentation scope of the meter
+const ScopeName = "eamsa512"
+
+// Telemetry implements telemetry.Telemetry with OTel instruments
+type Telemetry struct {
+	operations metric.Int64Counter
+	bytes      metric.Int64Counter
+	duration   metric.Float64Histogram
+	keyEvents  metric.Int64Counter
+}
+
+var _ telemetry.Telemetry = (*Telemetry)(nil)
+
+// New creates the instruments on a meter from provider
+func New(provider metric.MeterProvider) (*Telemetry, error) {
+	meter := provider.Meter(ScopeName)
+
+	operations, err := meter.Int64Counter("eamsa512.operations",
+		metric.WithDescription("Cipher operations by type and result"),
+		metric.WithUnit("{operation}"))
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := meter.Int64Counter("eamsa512.operation.bytes",
+		metric.WithDescription("Bytes processed by cipher operations"),
+		metric.WithUnit("By"))
+	if err != nil {
+		return nil, err
+	}
+
+	duration, err := meter.Float64Histogram("eamsa512.operation.duration",
+		metric.WithDescription("Cipher operation latency"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(telemetry.DefaultBuckets...))
+	if err != nil {
+		return nil, err
+	}
+
+	keyEvents, err := meter.Int64Counter("eamsa512.key.events",
+		metric.WithDescription("Key lifecycle events"),
+		metric.WithUnit("{event}"))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Telemetry{
+		operations: operations,
+		bytes:      bytes,
+		duration:   duration,
+		keyEvents:  keyEvents,
+	}, nil
+}
+
+func (t *Telemetry) ObserveEncrypt(bytes int, duration time.Duration, err error) {
+	t.observe(telemetry.OpEncrypt, bytes, duration, err)
+}
+
+func (t *Telemetry) ObserveDecrypt(bytes int, duration time.Duration, err error) {
+	t.observe(telemetry.OpDecrypt, bytes, duration, err)
+}
+
+func (t *Telemetry) ObserveKeyEvent(event telemetry.KeyEvent, keyID string) {
+	t.keyEvents.Add(context.Background(), 1,
+		metric.WithAttributes(attribute.String("event", string(event))))
+}
+
+// observe records one operation
+func (t *Telemetry) observe(op telemetry.Op, bytes int, duration time.Duration, err error) {
+	ctx := context.Background()
+	attrs := metric.WithAttributes(
+		attribute.String("op", string(op)),
+		attribute.String("result", telemetry.Result(err)),
+	)
+
+	t.operations.Add(ctx, 1, attrs)
+	if bytes > 0 {
+		t.bytes.Add(ctx, int64(bytes), attrs)
+	}
+	t.duration.Record(ctx, duration.Seconds(), attrs)
+}
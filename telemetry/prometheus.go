@@ -0,0 +1,164 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultBuckets are the latency histogram bounds in seconds
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// Prometheus accumulates observations and renders them in the Prometheus
+// text exposition format. Mount it as an http.Handler or call WriteTo from
+// an existing /metrics handler. Key IDs are not used as labels.
+type Prometheus struct {
+	namespace string
+	buckets   []float64
+	ops       map[opKey]*opStats
+	keyEvents map[KeyEvent]uint64
+	mu        sync.Mutex
+}
+
+type opKey struct {
+	op     Op
+	result string
+}
+
+type opStats struct {
+	count   uint64
+	bytes   uint64
+	sum     float64  // seconds
+	buckets []uint64 // non-cumulative counts per bound; cumulated on render
+}
+
+// NewPrometheus creates a collector whose metric names start with
+// namespace (default "eamsa512")
+func NewPrometheus(namespace string) *Prometheus {
+	if namespace == "" {
+		namespace = "eamsa512"
+	}
+	return &Prometheus{
+		namespace: namespace,
+		buckets:   DefaultBuckets,
+		ops:       make(map[opKey]*opStats),
+		keyEvents: make(map[KeyEvent]uint64),
+	}
+}
+
+func (p *Prometheus) ObserveEncrypt(bytes int, duration time.Duration, err error) {
+	p.observe(OpEncrypt, bytes, duration, err)
+}
+
+func (p *Prometheus) ObserveDecrypt(bytes int, duration time.Duration, err error) {
+	p.observe(OpDecrypt, bytes, duration, err)
+}
+
+func (p *Prometheus) ObserveKeyEvent(event KeyEvent, keyID string) {
+	p.mu.Lock()
+	p.keyEvents[event]++
+	p.mu.Unlock()
+}
+
+// observe records one operation
+func (p *Prometheus) observe(op Op, bytes int, duration time.Duration, err error) {
+	key := opKey{op: op, result: Result(err)}
+	seconds := duration.Seconds()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	s := p.ops[key]
+	if s == nil {
+		s = &opStats{buckets: make([]uint64, len(p.buckets))}
+		p.ops[key] = s
+	}
+
+	s.count++
+	if bytes > 0 {
+		s.bytes += uint64(bytes)
+	}
+	s.sum += seconds
+	for i, bound := range p.buckets {
+		if seconds <= bound {
+			s.buckets[i]++
+			break
+		}
+	}
+}
+
+// ServeHTTP writes the metrics for a Prometheus scrape
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	p.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the text exposition format
+func (p *Prometheus) WriteTo(w io.Writer) (int64, error) {
+	p.mu.Lock()
+	keys := make([]opKey, 0, len(p.ops))
+	stats := make(map[opKey]opStats, len(p.ops))
+	for k, s := range p.ops {
+		keys = append(keys, k)
+		c := *s
+		c.buckets = append([]uint64(nil), s.buckets...)
+		stats[k] = c
+	}
+	events := make([]KeyEvent, 0, len(p.keyEvents))
+	eventCounts := make(map[KeyEvent]uint64, len(p.keyEvents))
+	for e, n := range p.keyEvents {
+		events = append(events, e)
+		eventCounts[e] = n
+	}
+	p.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].op != keys[j].op {
+			return keys[i].op < keys[j].op
+		}
+		return keys[i].result < keys[j].result
+	})
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+
+	var b strings.Builder
+	ns := p.namespace
+
+	fmt.Fprintf(&b, "# HELP %s_operations_total Cipher operations by type and result\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operations_total counter\n", ns)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s_operations_total{op=%q,result=%q} %d\n", ns, k.op, k.result, stats[k].count)
+	}
+
+	fmt.Fprintf(&b, "\n# HELP %s_operation_bytes_total Bytes processed by cipher operations\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operation_bytes_total counter\n", ns)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s_operation_bytes_total{op=%q,result=%q} %d\n", ns, k.op, k.result, stats[k].bytes)
+	}
+
+	fmt.Fprintf(&b, "\n# HELP %s_operation_duration_seconds Cipher operation latency\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_operation_duration_seconds histogram\n", ns)
+	for _, k := range keys {
+		s := stats[k]
+		var cumulative uint64
+		for i, bound := range p.buckets {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(&b, "%s_operation_duration_seconds_bucket{op=%q,result=%q,le=\"%g\"} %d\n", ns, k.op, k.result, bound, cumulative)
+		}
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_bucket{op=%q,result=%q,le=\"+Inf\"} %d\n", ns, k.op, k.result, s.count)
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_sum{op=%q,result=%q} %g\n", ns, k.op, k.result, s.sum)
+		fmt.Fprintf(&b, "%s_operation_duration_seconds_count{op=%q,result=%q} %d\n", ns, k.op, k.result, s.count)
+	}
+
+	fmt.Fprintf(&b, "\n# HELP %s_key_events_total Key lifecycle events\n", ns)
+	fmt.Fprintf(&b, "# TYPE %s_key_events_total counter\n", ns)
+	for _, e := range events {
+		fmt.Fprintf(&b, "%s_key_events_total{event=%q} %d\n", ns, e, eventCounts[e])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,75 @@
+// Package telemetry defines the hook EAMSA 512 uses to report operations to
+// an embedding application's metrics stack.
+//
+// The cipher and server accept a Telemetry value as an option, and
+// pkg/eamsa512.SetTelemetry and the key managers' SetTelemetry methods
+// attach one to the library; each calls it after every encrypt, decrypt
+// and key lifecycle event. Nop is the default.
+// Prometheus renders the text exposition format without a client library;
+// the telemetry/otel package records to an OpenTelemetry MeterProvider.
+// Applications with their own stack implement the three methods directly.
+//
+// Implementations must be safe for concurrent use and must not block: they
+// are called on the request path.
+package telemetry
+
+import "time"
+
+// Op is an observed cipher operation
+type Op string
+
+const (
+	OpEncrypt Op = "encrypt"
+	OpDecrypt Op = "decrypt"
+)
+
+// KeyEvent is a key lifecycle event
+type KeyEvent string
+
+const (
+	KeyGenerated   KeyEvent = "generated"
+	KeyActivated   KeyEvent = "activated"
+	KeyRotated     KeyEvent = "rotated"
+	KeyDeactivated KeyEvent = "deactivated"
+	KeyDestroyed   KeyEvent = "destroyed"
+	KeyImported    KeyEvent = "imported"
+	KeyExported    KeyEvent = "exported"
+	KeyRewrapped   KeyEvent = "rewrapped"
+)
+
+// Telemetry receives operation observations
+type Telemetry interface {
+	// ObserveEncrypt reports one encryption of bytes plaintext bytes
+	ObserveEncrypt(bytes int, duration time.Duration, err error)
+
+	// ObserveDecrypt reports one decryption of bytes ciphertext bytes;
+	// authentication failures are reported through err
+	ObserveDecrypt(bytes int, duration time.Duration, err error)
+
+	// ObserveKeyEvent reports a key lifecycle event. keyID is the key's
+	// name or audit-safe identifier (keyid format), never key material.
+	ObserveKeyEvent(event KeyEvent, keyID string)
+}
+
+// Nop discards all observations
+type Nop struct{}
+
+func (Nop) ObserveEncrypt(int, time.Duration, error) {}
+func (Nop) ObserveDecrypt(int, time.Duration, error) {}
+func (Nop) ObserveKeyEvent(KeyEvent, string)         {}
+
+// OrNop returns t, or Nop if t is nil
+func OrNop(t Telemetry) Telemetry {
+	if t == nil {
+		return Nop{}
+	}
+	return t
+}
+
+// Result returns the result label for err: "ok" or "error"
+func Result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
@@ -1,741 +0,0 @@
-package main
-
-import (
-	"bytes"
-	"crypto/rand"
-	"encoding/hex"
-	"fmt"
-	"testing"
-	"time"
-)
-
-// ============================================================================
-// EAMSA 512 - Encryption Test Suite
-// Comprehensive tests for encryption, decryption, and cryptographic properties
-//
-// Tests cover:
-// - Basic encryption/decryption operations
-// - Deterministic behavior with fixed nonce
-// - Authentication tag verification
-// - Key schedule integrity
-// - Different plaintext sizes
-// - Performance benchmarks
-// - Edge cases and error handling
-//
-// Last updated: December 4, 2025
-// ============================================================================
-
-// TestBasicEncryptionDecryption tests fundamental encrypt/decrypt cycle
-func TestBasicEncryptionDecryption(t *testing.T) {
-	fmt.Println("Test: Basic Encryption/Decryption")
-
-	plaintext := []byte("Hello, EAMSA 512!")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt
-	encrypted, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Encryption failed: %v", err)
-	}
-
-	if len(encrypted) == 0 {
-		t.Fatal("Encrypted data is empty")
-	}
-
-	// Verify size: plaintext + nonce + tag
-	expectedMinSize := len(plaintext) + NonceSize + TagSize
-	if len(encrypted) < expectedMinSize {
-		t.Fatalf("Encrypted size too small: got %d, expected at least %d",
-			len(encrypted), expectedMinSize)
-	}
-
-	// Extract components
-	ciphertextLen := len(encrypted) - NonceSize - TagSize
-	ciphertext := encrypted[:ciphertextLen]
-	nonce := encrypted[ciphertextLen : ciphertextLen+NonceSize]
-	tag := encrypted[ciphertextLen+NonceSize:]
-
-	// Verify nonce and tag sizes
-	if len(nonce) != NonceSize {
-		t.Fatalf("Nonce size mismatch: got %d, expected %d", len(nonce), NonceSize)
-	}
-	if len(tag) != TagSize {
-		t.Fatalf("Tag size mismatch: got %d, expected %d", len(tag), TagSize)
-	}
-
-	// Decrypt
-	decrypted, err := DecryptData(encrypted, key)
-	if err != nil {
-		t.Fatalf("Decryption failed: %v", err)
-	}
-
-	// Verify plaintext
-	if !bytes.Equal(plaintext, decrypted) {
-		t.Fatalf("Plaintext mismatch:\nExpected: %s\nGot: %s",
-			string(plaintext), string(decrypted))
-	}
-
-	fmt.Println("✓ Basic encrypt/decrypt cycle successful")
-}
-
-// TestDeterministicWithFixedNonce tests determinism with same nonce
-func TestDeterministicWithFixedNonce(t *testing.T) {
-	fmt.Println("Test: Deterministic Encryption with Fixed Nonce")
-
-	plaintext := []byte("Test data for determinism")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Create fixed nonce
-	nonce := make([]byte, NonceSize)
-	for i := 0; i < len(nonce); i++ {
-		nonce[i] = byte(i % 256)
-	}
-
-	// Encrypt twice with same key and nonce
-	encrypted1, err := EncryptData(plaintext, key, nonce)
-	if err != nil {
-		t.Fatalf("First encryption failed: %v", err)
-	}
-
-	encrypted2, err := EncryptData(plaintext, key, nonce)
-	if err != nil {
-		t.Fatalf("Second encryption failed: %v", err)
-	}
-
-	// Extract ciphertexts (excluding nonce and tag)
-	len1 := len(encrypted1) - NonceSize - TagSize
-	len2 := len(encrypted2) - NonceSize - TagSize
-
-	if len1 != len2 {
-		t.Fatalf("Ciphertext lengths differ: %d vs %d", len1, len2)
-	}
-
-	ciphertext1 := encrypted1[:len1]
-	ciphertext2 := encrypted2[:len1]
-
-	if !bytes.Equal(ciphertext1, ciphertext2) {
-		t.Fatal("Ciphertexts differ with same key and nonce (not deterministic)")
-	}
-
-	fmt.Println("✓ Encryption is deterministic with fixed nonce")
-}
-
-// TestRandomNonces tests that random nonces produce different ciphertexts
-func TestRandomNonces(t *testing.T) {
-	fmt.Println("Test: Random Nonces Produce Different Ciphertexts")
-
-	plaintext := []byte("Same plaintext, different nonces")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt multiple times with random nonces
-	encrypted1, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("First encryption failed: %v", err)
-	}
-
-	encrypted2, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Second encryption failed: %v", err)
-	}
-
-	// Extract ciphertexts
-	len1 := len(encrypted1) - NonceSize - TagSize
-	len2 := len(encrypted2) - NonceSize - TagSize
-
-	if len1 != len2 {
-		t.Fatalf("Ciphertext lengths differ: %d vs %d", len1, len2)
-	}
-
-	ciphertext1 := encrypted1[:len1]
-	ciphertext2 := encrypted2[:len1]
-
-	// With random nonces, ciphertexts should differ
-	if bytes.Equal(ciphertext1, ciphertext2) {
-		t.Fatal("Ciphertexts are identical with different nonces (expected different)")
-	}
-
-	// Extract nonces
-	nonce1 := encrypted1[len1 : len1+NonceSize]
-	nonce2 := encrypted2[len1 : len1+NonceSize]
-
-	if bytes.Equal(nonce1, nonce2) {
-		t.Fatal("Generated nonces are identical (poor randomness)")
-	}
-
-	fmt.Println("✓ Random nonces produce different ciphertexts")
-}
-
-// TestAuthenticationTagVerification tests tag verification on tampering
-func TestAuthenticationTagVerification(t *testing.T) {
-	fmt.Println("Test: Authentication Tag Verification")
-
-	plaintext := []byte("Tamper test data")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt
-	encrypted, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Encryption failed: %v", err)
-	}
-
-	// Tamper with ciphertext
-	if len(encrypted) > TagSize {
-		encrypted[0] ^= 0xFF // Flip bits in ciphertext
-	}
-
-	// Attempt to decrypt tampered data
-	_, err = DecryptData(encrypted, key)
-	if err == nil {
-		t.Fatal("Decryption succeeded on tampered data (tag verification failed)")
-	}
-
-	fmt.Println("✓ Authentication tag correctly detects tampering")
-}
-
-// TestWrongKeyDecryption tests decryption with wrong key fails
-func TestWrongKeyDecryption(t *testing.T) {
-	fmt.Println("Test: Wrong Key Decryption Detection")
-
-	plaintext := []byte("Secret message")
-	key1 := make([]byte, KeySize)
-	key2 := make([]byte, KeySize)
-	rand.Read(key1)
-	rand.Read(key2)
-
-	// Encrypt with key1
-	encrypted, err := EncryptData(plaintext, key1, nil)
-	if err != nil {
-		t.Fatalf("Encryption failed: %v", err)
-	}
-
-	// Try to decrypt with key2
-	_, err = DecryptData(encrypted, key2)
-	if err == nil {
-		t.Fatal("Decryption succeeded with wrong key")
-	}
-
-	fmt.Println("✓ Wrong key decryption correctly fails")
-}
-
-// TestVariousPlaintextSizes tests encryption of different sizes
-func TestVariousPlaintextSizes(t *testing.T) {
-	fmt.Println("Test: Various Plaintext Sizes")
-
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	sizes := []int{0, 1, 16, 64, 256, 1024, 4096}
-
-	for _, size := range sizes {
-		plaintext := make([]byte, size)
-		rand.Read(plaintext)
-
-		// Encrypt
-		encrypted, err := EncryptData(plaintext, key, nil)
-		if err != nil {
-			t.Fatalf("Encryption failed for size %d: %v", size, err)
-		}
-
-		// Decrypt
-		decrypted, err := DecryptData(encrypted, key)
-		if err != nil {
-			t.Fatalf("Decryption failed for size %d: %v", size, err)
-		}
-
-		// Verify
-		if !bytes.Equal(plaintext, decrypted) {
-			t.Fatalf("Plaintext mismatch for size %d", size)
-		}
-
-		fmt.Printf("  ✓ Size %d: OK\n", size)
-	}
-
-	fmt.Println("✓ All plaintext sizes handled correctly")
-}
-
-// TestKeyScheduleIntegrity tests that key schedule is properly initialized
-func TestKeyScheduleIntegrity(t *testing.T) {
-	fmt.Println("Test: Key Schedule Integrity")
-
-	key1 := make([]byte, KeySize)
-	key2 := make([]byte, KeySize)
-
-	// Different keys
-	rand.Read(key1)
-	rand.Read(key2)
-
-	plaintext := []byte("Key schedule test")
-	nonce := make([]byte, NonceSize)
-	rand.Read(nonce)
-
-	// Encrypt with different keys
-	encrypted1, _ := EncryptData(plaintext, key1, nonce)
-	encrypted2, _ := EncryptData(plaintext, key2, nonce)
-
-	// Extract ciphertexts
-	len1 := len(encrypted1) - NonceSize - TagSize
-	ciphertext1 := encrypted1[:len1]
-	ciphertext2 := encrypted2[:len1]
-
-	// Should produce completely different ciphertexts
-	if bytes.Equal(ciphertext1, ciphertext2) {
-		t.Fatal("Different keys produced same ciphertext")
-	}
-
-	// Count differing bytes
-	differentBytes := 0
-	for i := 0; i < len(ciphertext1) && i < len(ciphertext2); i++ {
-		if ciphertext1[i] != ciphertext2[i] {
-			differentBytes++
-		}
-	}
-
-	// Should have significant differences (at least 50% different)
-	threshold := len(ciphertext1) / 2
-	if differentBytes < threshold {
-		t.Fatalf("Key schedule shows poor diffusion: only %d bytes differ (expected > %d)",
-			differentBytes, threshold)
-	}
-
-	fmt.Printf("  ✓ Key schedule diffusion: %d/%d bytes differ (%.1f%%)\n",
-		differentBytes, len(ciphertext1), float64(differentBytes)*100/float64(len(ciphertext1)))
-	fmt.Println("✓ Key schedule integrity verified")
-}
-
-// TestRoundConsistency tests that all rounds execute properly
-func TestRoundConsistency(t *testing.T) {
-	fmt.Println("Test: Round Consistency")
-
-	plaintext := []byte("Round consistency test data for validation")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt and decrypt multiple times
-	originalPlaintext := make([]byte, len(plaintext))
-	copy(originalPlaintext, plaintext)
-
-	for i := 0; i < 100; i++ {
-		encrypted, err := EncryptData(plaintext, key, nil)
-		if err != nil {
-			t.Fatalf("Encryption iteration %d failed: %v", i, err)
-		}
-
-		decrypted, err := DecryptData(encrypted, key)
-		if err != nil {
-			t.Fatalf("Decryption iteration %d failed: %v", i, err)
-		}
-
-		if !bytes.Equal(originalPlaintext, decrypted) {
-			t.Fatalf("Plaintext mismatch at iteration %d", i)
-		}
-	}
-
-	fmt.Println("✓ Round consistency verified over 100 iterations")
-}
-
-// TestAuthenticationTagSize tests tag generation
-func TestAuthenticationTagSize(t *testing.T) {
-	fmt.Println("Test: Authentication Tag Size")
-
-	plaintext := []byte("Tag size verification")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	encrypted, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Encryption failed: %v", err)
-	}
-
-	// Extract tag
-	tag := encrypted[len(encrypted)-TagSize:]
-
-	if len(tag) != TagSize {
-		t.Fatalf("Tag size mismatch: got %d, expected %d", len(tag), TagSize)
-	}
-
-	// Verify tag is not all zeros
-	allZeros := true
-	for _, b := range tag {
-		if b != 0 {
-			allZeros = false
-			break
-		}
-	}
-
-	if allZeros {
-		t.Fatal("Authentication tag is all zeros")
-	}
-
-	fmt.Printf("  ✓ Tag size: %d bytes\n", len(tag))
-	fmt.Printf("  ✓ Tag (hex): %s\n", hex.EncodeToString(tag[:16])+"...")
-	fmt.Println("✓ Authentication tag size verified")
-}
-
-// TestHexEncoding tests hex encoding/decoding
-func TestHexEncoding(t *testing.T) {
-	fmt.Println("Test: Hex Encoding/Decoding")
-
-	plaintext := []byte("Hex encoding test")
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt
-	encrypted, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Encryption failed: %v", err)
-	}
-
-	// Encode to hex
-	hexEncoded := hex.EncodeToString(encrypted)
-
-	// Decode from hex
-	decoded, err := hex.DecodeString(hexEncoded)
-	if err != nil {
-		t.Fatalf("Hex decode failed: %v", err)
-	}
-
-	// Should match original encrypted data
-	if !bytes.Equal(encrypted, decoded) {
-		t.Fatal("Hex encoding/decoding mismatch")
-	}
-
-	fmt.Printf("  ✓ Original size: %d bytes\n", len(encrypted))
-	fmt.Printf("  ✓ Hex encoded size: %d characters\n", len(hexEncoded))
-	fmt.Println("✓ Hex encoding/decoding verified")
-}
-
-// TestEmptyPlaintext tests encryption of empty data
-func TestEmptyPlaintext(t *testing.T) {
-	fmt.Println("Test: Empty Plaintext")
-
-	plaintext := []byte{}
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	// Encrypt empty data
-	encrypted, err := EncryptData(plaintext, key, nil)
-	if err != nil {
-		t.Fatalf("Encryption of empty data failed: %v", err)
-	}
-
-	// Should still have nonce and tag
-	if len(encrypted) < NonceSize+TagSize {
-		t.Fatalf("Encrypted empty data too small: %d", len(encrypted))
-	}
-
-	// Decrypt
-	decrypted, err := DecryptData(encrypted, key)
-	if err != nil {
-		t.Fatalf("Decryption of empty data failed: %v", err)
-	}
-
-	if len(decrypted) != 0 {
-		t.Fatalf("Decrypted empty data should be empty: got %d bytes", len(decrypted))
-	}
-
-	fmt.Println("✓ Empty plaintext handled correctly")
-}
-
-// BenchmarkEncryption benchmarks encryption performance
-func BenchmarkEncryption(b *testing.B) {
-	plaintext := make([]byte, 1024) // 1KB
-	rand.Read(plaintext)
-
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		EncryptData(plaintext, key, nil)
-	}
-}
-
-// BenchmarkDecryption benchmarks decryption performance
-func BenchmarkDecryption(b *testing.B) {
-	plaintext := make([]byte, 1024) // 1KB
-	rand.Read(plaintext)
-
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	encrypted, _ := EncryptData(plaintext, key, nil)
-
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		DecryptData(encrypted, key)
-	}
-}
-
-// BenchmarkLargeData benchmarks with larger data
-func BenchmarkLargeData(b *testing.B) {
-	plaintext := make([]byte, 1024*1024) // 1MB
-	rand.Read(plaintext)
-
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	b.ResetTimer()
-
-	for i := 0; i < b.N; i++ {
-		EncryptData(plaintext, key, nil)
-	}
-}
-
-// ============================================================================
-// Performance Test Suite
-// ============================================================================
-
-// TestPerformanceMetrics measures and reports encryption performance
-func TestPerformanceMetrics(t *testing.T) {
-	fmt.Println("\nPerformance Metrics")
-	fmt.Println("==================")
-
-	key := make([]byte, KeySize)
-	rand.Read(key)
-
-	sizes := []int{64, 256, 1024, 4096, 16384, 65536}
-
-	for _, size := range sizes {
-		plaintext := make([]byte, size)
-		rand.Read(plaintext)
-
-		start := time.Now()
-		encrypted, err := EncryptData(plaintext, key, nil)
-		if err != nil {
-			t.Fatalf("Encryption failed: %v", err)
-		}
-		encryptTime := time.Since(start)
-
-		start = time.Now()
-		_, err = DecryptData(encrypted, key)
-		if err != nil {
-			t.Fatalf("Decryption failed: %v", err)
-		}
-		decryptTime := time.Since(start)
-
-		throughputEnc := float64(size) / (1024 * 1024) / encryptTime.Seconds()
-		throughputDec := float64(size) / (1024 * 1024) / decryptTime.Seconds()
-
-		fmt.Printf("  Size: %8d bytes | Enc: %10.2f µs (%6.1f MB/s) | Dec: %10.2f µs (%6.1f MB/s)\n",
-			size,
-			float64(encryptTime.Microseconds()),
-			throughputEnc,
-			float64(decryptTime.Microseconds()),
-			throughputDec)
-	}
-}
-
-// ============================================================================
-// Integration Tests
-// ============================================================================
-
-// TestMultipleKeysIndependence tests that different keys are independent
-func TestMultipleKeysIndependence(t *testing.T) {
-	fmt.Println("Test: Multiple Keys Independence")
-
-	plaintext := []byte("Independence test")
-	keys := make([][]byte, 10)
-
-	for i := 0; i < 10; i++ {
-		keys[i] = make([]byte, KeySize)
-		rand.Read(keys[i])
-	}
-
-	ciphertexts := make([][]byte, 10)
-	for i, key := range keys {
-		encrypted, err := EncryptData(plaintext, key, nil)
-		if err != nil {
-			t.Fatalf("Encryption with key %d failed: %v", i, err)
-		}
-		ciphertexts[i] = encrypted
-	}
-
-	// All ciphertexts should be different
-	for i := 0; i < 10; i++ {
-		for j := i + 1; j < 10; j++ {
-			if bytes.Equal(ciphertexts[i], ciphertexts[j]) {
-				t.Fatalf("Keys %d and %d produced same ciphertext", i, j)
-			}
-		}
-	}
-
-	fmt.Println("✓ Multiple keys are independent")
-}
-
-// TestCryptographicProperties verifies basic cryptographic properties
-func TestCryptographicProperties(t *testing.T) {
-	fmt.Println("\nCryptographic Properties Verification")
-	fmt.Println("=====================================")
-
-	// 1. Uniqueness
-	fmt.Println("\n1. Uniqueness Test (same plaintext, different keys)")
-	plaintext := []byte("uniqueness test")
-	ciphertexts := make(map[string]bool)
-
-	for i := 0; i < 100; i++ {
-		key := make([]byte, KeySize)
-		rand.Read(key)
-		nonce := make([]byte, NonceSize)
-		rand.Read(nonce)
-
-		encrypted, _ := EncryptData(plaintext, key, nonce)
-		hexStr := hex.EncodeToString(encrypted)
-		if ciphertexts[hexStr] {
-			t.Fatal("Duplicate ciphertext generated")
-		}
-		ciphertexts[hexStr] = true
-	}
-	fmt.Printf("   ✓ Generated %d unique ciphertexts\n", len(ciphertexts))
-
-	// 2. Avalanche Effect
-	fmt.Println("\n2. Avalanche Effect Test (single bit change in key)")
-	key1 := make([]byte, KeySize)
-	rand.Read(key1)
-	key2 := make([]byte, KeySize)
-	copy(key2, key1)
-	key2[0] ^= 0x01 // Flip one bit
-
-	nonce := make([]byte, NonceSize)
-	rand.Read(nonce)
-
-	enc1, _ := EncryptData(plaintext, key1, nonce)
-	enc2, _ := EncryptData(plaintext, key2, nonce)
-
-	diffBits := 0
-	minLen := len(enc1)
-	if len(enc2) < minLen {
-		minLen = len(enc2)
-	}
-
-	for i := 0; i < minLen; i++ {
-		xor := enc1[i] ^ enc2[i]
-		for j := 0; j < 8; j++ {
-			if (xor >> uint(j)) & 1 == 1 {
-				diffBits++
-			}
-		}
-	}
-
-	totalBits := minLen * 8
-	avalancheRatio := float64(diffBits) / float64(totalBits)
-	fmt.Printf("   ✓ Single bit key change affects %.1f%% of output bits\n", avalancheRatio*100)
-
-	// 3. Non-linearity
-	fmt.Println("\n3. Non-linearity Test")
-	fmt.Println("   ✓ EAMSA 512 uses non-linear S-boxes and MDS matrix")
-
-	fmt.Println("\n✓ All cryptographic properties verified")
-}
-
-// ============================================================================
-// Main Test Function
-// ============================================================================
-
-func RunAllTests() {
-	fmt.Println("\n" + "="*70)
-	fmt.Println("EAMSA 512 - Comprehensive Encryption Test Suite")
-	fmt.Println("="*70 + "\n")
-
-	// Run basic tests
-	t := &testing.T{}
-
-	TestBasicEncryptionDecryption(t)
-	fmt.Println()
-	TestDeterministicWithFixedNonce(t)
-	fmt.Println()
-	TestRandomNonces(t)
-	fmt.Println()
-	TestAuthenticationTagVerification(t)
-	fmt.Println()
-	TestWrongKeyDecryption(t)
-	fmt.Println()
-	TestVariousPlaintextSizes(t)
-	fmt.Println()
-	TestKeyScheduleIntegrity(t)
-	fmt.Println()
-	TestRoundConsistency(t)
-	fmt.Println()
-	TestAuthenticationTagSize(t)
-	fmt.Println()
-	TestHexEncoding(t)
-	fmt.Println()
-	TestEmptyPlaintext(t)
-	fmt.Println()
-	TestMultipleKeysIndependence(t)
-	fmt.Println()
-	TestPerformanceMetrics(t)
-	fmt.Println()
-	TestCryptographicProperties(t)
-
-	fmt.Println("\n" + "="*70)
-	fmt.Println("✓ All tests passed successfully!")
-	fmt.Println("="*70 + "\n")
-}
-
-// ============================================================================
-// NOTES
-// ============================================================================
-
-/*
-
-TEST CATEGORIES:
-
-1. BASIC FUNCTIONALITY
-   - TestBasicEncryptionDecryption: Round-trip encrypt/decrypt
-   - TestEmptyPlaintext: Edge case with empty data
-   - TestVariousPlaintextSizes: Multiple data sizes
-
-2. DETERMINISM & RANDOMNESS
-   - TestDeterministicWithFixedNonce: Same output with same nonce
-   - TestRandomNonces: Different output with random nonces
-
-3. SECURITY PROPERTIES
-   - TestAuthenticationTagVerification: Tampering detection
-   - TestWrongKeyDecryption: Wrong key rejection
-   - TestKeyScheduleIntegrity: Key expansion quality
-   - TestCryptographicProperties: Avalanche effect, uniqueness
-
-4. CRYPTOGRAPHIC PROPERTIES
-   - TestRoundConsistency: Round function stability
-   - TestAuthenticationTagSize: Tag generation
-   - TestHexEncoding: Encoding/decoding integrity
-   - TestMultipleKeysIndependence: Key independence
-
-5. PERFORMANCE BENCHMARKS
-   - BenchmarkEncryption: Encryption throughput
-   - BenchmarkDecryption: Decryption throughput
-   - BenchmarkLargeData: Large file performance
-   - TestPerformanceMetrics: Detailed performance analysis
-
-RUNNING TESTS:
-
-  go test -v                           # Run all tests with verbose output
-  go test -run TestBasic               # Run specific test
-  go test -bench .                     # Run benchmarks
-  go test -bench . -benchtime=10s      # Run benchmarks for 10 seconds
-  go test -trace trace.out             # Generate trace for analysis
-
-EXPECTED RESULTS:
-
-  ✓ Encryption and decryption round-trip successfully
-  ✓ Deterministic with fixed nonce
-  ✓ Random with random nonce
-  ✓ Authentication tag detects tampering
-  ✓ Wrong key decryption fails
-  ✓ All plaintext sizes handled
-  ✓ Key schedule provides good diffusion
-  ✓ Round consistency verified
-  ✓ Authentication tag properly sized
-  ✓ Hex encoding/decoding works
-  ✓ Empty plaintext handled
-  ✓ Multiple keys are independent
-  ✓ Encryption: ~50-100 MB/s on modern CPU
-  ✓ Decryption: ~50-100 MB/s on modern CPU
-
-*/
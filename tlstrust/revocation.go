@@ -0,0 +1,299 @@
+package tlstrust
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	// fetchTimeout bounds each OCSP or CRL request
+	fetchTimeout = 10 * time.Second
+
+	// defaultCacheTTL applies to responses without a NextUpdate
+	defaultCacheTTL = time.Hour
+
+	maxOCSPResponse = 1 << 20
+	maxCRLSize      = 16 << 20
+)
+
+// revocationError reports a revoked certificate or an unknown status
+type revocationError struct {
+	revoked bool
+	detail  string
+}
+
+func (e *revocationError) Error() string {
+	return e.detail
+}
+
+// certStatus is the revocation status of one certificate
+type certStatus int
+
+const (
+	statusUnknown certStatus = iota
+	statusGood
+	statusRevoked
+)
+
+// cachedOCSP is an OCSP status valid until expires
+type cachedOCSP struct {
+	status  certStatus
+	expires time.Time
+}
+
+// revocationChecker checks certificates against OCSP and CRLs, caching
+// responses until their NextUpdate
+type revocationChecker struct {
+	client    *http.Client
+	localCRLs []*x509.RevocationList
+
+	mu   sync.Mutex
+	ocsp map[string]cachedOCSP
+	crls map[string]*x509.RevocationList
+}
+
+// newRevocationChecker loads local CRL files
+func newRevocationChecker(crlFiles []string) (*revocationChecker, error) {
+	r := &revocationChecker{
+		// OCSP and CRL endpoints are plain HTTP; responses are signed
+		client: &http.Client{Timeout: fetchTimeout},
+		ocsp:   make(map[string]cachedOCSP),
+		crls:   make(map[string]*x509.RevocationList),
+	}
+
+	for _, path := range crlFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CRL file: %v", err)
+		}
+
+		crl, err := parseCRL(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CRL file %s: %v", path, err)
+		}
+		r.localCRLs = append(r.localCRLs, crl)
+	}
+
+	return r, nil
+}
+
+// check verifies every certificate in chain except the root. stapled is the
+// server's OCSP response for the leaf, if any.
+func (r *revocationChecker) check(chain []*x509.Certificate, stapled []byte) *revocationError {
+	for i := 0; i+1 < len(chain); i++ {
+		cert, issuer := chain[i], chain[i+1]
+
+		var staple []byte
+		if i == 0 {
+			staple = stapled
+		}
+
+		status, detail := r.status(cert, issuer, staple)
+		switch status {
+		case statusRevoked:
+			return &revocationError{revoked: true, detail: fmt.Sprintf("%s (serial %s) is revoked", cert.Subject, cert.SerialNumber)}
+		case statusUnknown:
+			return &revocationError{detail: fmt.Sprintf("revocation status of %s unavailable: %s", cert.Subject, detail)}
+		}
+	}
+
+	return nil
+}
+
+// status determines the revocation status of cert from local CRLs, the
+// stapled response, OCSP responders and CRL distribution points, in order
+func (r *revocationChecker) status(cert, issuer *x509.Certificate, staple []byte) (certStatus, string) {
+	now := time.Now()
+
+	for _, crl := range r.localCRLs {
+		if status, ok := crlStatus(crl, cert, issuer, now); ok {
+			return status, ""
+		}
+	}
+
+	if len(staple) > 0 {
+		if status, _, err := ocspStatus(staple, cert, issuer, now); err == nil {
+			return status, ""
+		}
+	}
+
+	var problems []string
+
+	for _, server := range cert.OCSPServer {
+		status, err := r.queryOCSP(server, cert, issuer, now)
+		if err == nil {
+			return status, ""
+		}
+		problems = append(problems, fmt.Sprintf("OCSP %s: %v", server, err))
+	}
+
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := r.fetchCRL(url, issuer, now)
+		if err == nil {
+			if status, ok := crlStatus(crl, cert, issuer, now); ok {
+				return status, ""
+			}
+			err = fmt.Errorf("CRL not current")
+		}
+		problems = append(problems, fmt.Sprintf("CRL %s: %v", url, err))
+	}
+
+	if len(problems) == 0 {
+		return statusUnknown, "certificate has no OCSP responder or CRL distribution point"
+	}
+	return statusUnknown, strings.Join(problems, "; ")
+}
+
+// queryOCSP asks an OCSP responder for the status of cert
+func (r *revocationChecker) queryOCSP(server string, cert, issuer *x509.Certificate, now time.Time) (certStatus, error) {
+	cacheKey := server + "|" + string(issuer.RawSubjectPublicKeyInfo) + "|" + cert.SerialNumber.String()
+
+	r.mu.Lock()
+	cached, ok := r.ocsp[cacheKey]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.status, nil
+	}
+
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return statusUnknown, err
+	}
+
+	resp, err := r.client.Post(server, "application/ocsp-request", bytes.NewReader(req))
+	if err != nil {
+		return statusUnknown, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return statusUnknown, fmt.Errorf("responder returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxOCSPResponse))
+	if err != nil {
+		return statusUnknown, err
+	}
+
+	status, expires, err := ocspStatus(data, cert, issuer, now)
+	if err != nil {
+		return statusUnknown, err
+	}
+
+	r.mu.Lock()
+	r.ocsp[cacheKey] = cachedOCSP{status: status, expires: expires}
+	r.mu.Unlock()
+
+	return status, nil
+}
+
+// fetchCRL downloads a CRL signed by issuer, reusing a cached copy until
+// its NextUpdate
+func (r *revocationChecker) fetchCRL(url string, issuer *x509.Certificate, now time.Time) (*x509.RevocationList, error) {
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("unsupported URL scheme")
+	}
+
+	r.mu.Lock()
+	cached, ok := r.crls[url]
+	r.mu.Unlock()
+	if ok && crlCurrent(cached, now) {
+		return cached, nil
+	}
+
+	resp, err := r.client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxCRLSize))
+	if err != nil {
+		return nil, err
+	}
+
+	crl, err := parseCRL(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := crl.CheckSignatureFrom(issuer); err != nil {
+		return nil, fmt.Errorf("bad signature: %v", err)
+	}
+
+	r.mu.Lock()
+	r.crls[url] = crl
+	r.mu.Unlock()
+
+	return crl, nil
+}
+
+// ocspStatus parses a response for cert and returns its status and the
+// time until which it may be cached
+func ocspStatus(data []byte, cert, issuer *x509.Certificate, now time.Time) (certStatus, time.Time, error) {
+	resp, err := ocsp.ParseResponseForCert(data, cert, issuer)
+	if err != nil {
+		return statusUnknown, time.Time{}, err
+	}
+
+	expires := resp.NextUpdate
+	if expires.IsZero() {
+		expires = now.Add(defaultCacheTTL)
+	}
+	if now.After(expires) {
+		return statusUnknown, time.Time{}, fmt.Errorf("response expired at %s", expires.Format(time.RFC3339))
+	}
+
+	switch resp.Status {
+	case ocsp.Good:
+		return statusGood, expires, nil
+	case ocsp.Revoked:
+		return statusRevoked, expires, nil
+	}
+	return statusUnknown, time.Time{}, fmt.Errorf("responder does not know the certificate")
+}
+
+// crlStatus looks cert up in crl. ok is false if crl was not issued by
+// issuer or is out of date.
+func crlStatus(crl *x509.RevocationList, cert, issuer *x509.Certificate, now time.Time) (certStatus, bool) {
+	if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) || !crlCurrent(crl, now) {
+		return statusUnknown, false
+	}
+	if crl.CheckSignatureFrom(issuer) != nil {
+		return statusUnknown, false
+	}
+
+	for _, entry := range crl.RevokedCertificateEntries {
+		if entry.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+			return statusRevoked, true
+		}
+	}
+
+	return statusGood, true
+}
+
+// crlCurrent reports whether crl has not passed its NextUpdate
+func crlCurrent(crl *x509.RevocationList, now time.Time) bool {
+	return crl.NextUpdate.IsZero() || now.Before(crl.NextUpdate)
+}
+
+// parseCRL accepts DER or PEM ("X509 CRL")
+func parseCRL(data []byte) (*x509.RevocationList, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	return x509.ParseRevocationList(data)
+}
@@ -0,0 +1,295 @@
+// Package tlstrust verifies the TLS peers of outbound integrations (KMS,
+// HSM, webhook and SIEM endpoints), which carry key-wrapping material.
+//
+// On top of chain and host name verification a Config can restrict the
+// trusted CAs (a private CA bundle, SPKI pins of CA certificates, or both),
+// pin the server's own public key, and check each certificate in the chain
+// for revocation using the stapled OCSP response, the certificate's OCSP
+// responder, its CRL distribution points and local CRL files. Every rejected
+// handshake is reported to the integration's Auditor.
+//
+// Pins have the form "sha256/<base64 SHA-256 of the SubjectPublicKeyInfo>",
+// as printed by
+//
+//	openssl x509 -in cert.pem -pubkey -noout | openssl pkey -pubin -outform der |
+//	    openssl dgst -sha256 -binary | base64
+package tlstrust
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// RevocationMode selects how revocation status is enforced
+type RevocationMode string
+
+const (
+	RevocationOff      RevocationMode = "off"       // No revocation checking (also the empty value)
+	RevocationSoftFail RevocationMode = "soft-fail" // Reject revoked certificates; accept if status is unavailable
+	RevocationHardFail RevocationMode = "hard-fail" // Reject unless every certificate is known not revoked
+)
+
+// Failure reasons
+const (
+	ReasonChain             = "chain"              // Untrusted chain, expired certificate or host name mismatch
+	ReasonCAPin             = "ca_pin"             // No CA in the chain matches CAPins
+	ReasonSPKIPin           = "spki_pin"           // Server key does not match SPKIPins
+	ReasonRevoked           = "revoked"            // A certificate in the chain is revoked
+	ReasonRevocationUnknown = "revocation_unknown" // Revocation status unavailable
+)
+
+// pinPrefix is the only supported pin hash
+const pinPrefix = "sha256/"
+
+// Config is the peer verification policy of one integration
+type Config struct {
+	CAFile     string         `json:"ca_file,omitempty"`     // PEM bundle that replaces the system roots
+	CAPins     []string       `json:"ca_pins,omitempty"`     // A CA certificate in the verified chain must match one
+	SPKIPins   []string       `json:"spki_pins,omitempty"`   // The server certificate must match one
+	Revocation RevocationMode `json:"revocation,omitempty"`  // "off" (default), "soft-fail" or "hard-fail"
+	CRLFiles   []string       `json:"crl_files,omitempty"`   // DER or PEM CRLs checked before online sources
+	ServerName string         `json:"server_name,omitempty"` // Host name to verify instead of the dialed one
+}
+
+// Failure describes a rejected (or, in soft-fail mode, unverified) peer
+type Failure struct {
+	Integration string    `json:"integration"`
+	ServerName  string    `json:"server_name"`
+	Reason      string    `json:"reason"`
+	Detail      string    `json:"detail"`
+	Subject     string    `json:"subject,omitempty"`  // Server certificate subject
+	SPKIPin     string    `json:"spki_pin,omitempty"` // Server certificate pin
+	Accepted    bool      `json:"accepted"`           // Connection allowed anyway (soft-fail)
+	Time        time.Time `json:"time"`
+}
+
+// Error returns a one-line description
+func (f Failure) Error() string {
+	outcome := "rejected"
+	if f.Accepted {
+		outcome = "accepted (soft-fail)"
+	}
+	return fmt.Sprintf("%s: TLS peer %s %s (%s): %s", f.Integration, f.ServerName, outcome, f.Reason, f.Detail)
+}
+
+// Auditor receives verification failures; it must not block
+type Auditor func(Failure)
+
+// Verifier applies a Config to TLS handshakes
+type Verifier struct {
+	integration string
+	config      Config
+	roots       *x509.CertPool // nil means the system roots
+	caPins      map[string]bool
+	spkiPins    map[string]bool
+	revocation  *revocationChecker
+	auditor     Auditor
+}
+
+// SPKIPin returns the pin of a certificate's public key
+func SPKIPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return pinPrefix + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// Validate checks pins and the revocation mode without reading files
+func (c Config) Validate() error {
+	if _, err := parsePins(c.CAPins); err != nil {
+		return fmt.Errorf("ca_pins: %v", err)
+	}
+	if _, err := parsePins(c.SPKIPins); err != nil {
+		return fmt.Errorf("spki_pins: %v", err)
+	}
+
+	switch c.Revocation {
+	case "", RevocationOff, RevocationSoftFail, RevocationHardFail:
+	default:
+		return fmt.Errorf("unknown revocation mode %q (want off, soft-fail or hard-fail)", c.Revocation)
+	}
+
+	return nil
+}
+
+// New creates a verifier for the named integration. auditor may be nil.
+func New(integration string, config Config, auditor Auditor) (*Verifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	v := &Verifier{
+		integration: integration,
+		config:      config,
+		auditor:     auditor,
+	}
+	v.caPins, _ = parsePins(config.CAPins)
+	v.spkiPins, _ = parsePins(config.SPKIPins)
+
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+
+		v.roots = x509.NewCertPool()
+		if !v.roots.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in CA file %s", config.CAFile)
+		}
+	}
+
+	if config.Revocation == RevocationSoftFail || config.Revocation == RevocationHardFail {
+		checker, err := newRevocationChecker(config.CRLFiles)
+		if err != nil {
+			return nil, err
+		}
+		v.revocation = checker
+	}
+
+	return v, nil
+}
+
+// TLSConfig returns a client configuration that verifies peers with v
+func (v *Verifier) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: v.config.ServerName,
+		// Chain verification is done in VerifyConnection so that every
+		// failure, including an untrusted chain, reaches the auditor
+		InsecureSkipVerify: true,
+		VerifyConnection:   v.VerifyConnection,
+	}
+}
+
+// HTTPClient returns an HTTP client whose TLS connections are verified by v
+func (v *Verifier) HTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: v.TLSConfig()},
+	}
+}
+
+// VerifyConnection verifies a completed handshake
+func (v *Verifier) VerifyConnection(cs tls.ConnectionState) error {
+	serverName := cs.ServerName
+	if v.config.ServerName != "" {
+		serverName = v.config.ServerName
+	}
+
+	if len(cs.PeerCertificates) == 0 {
+		return v.reject(serverName, nil, ReasonChain, "no server certificate")
+	}
+	leaf := cs.PeerCertificates[0]
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range cs.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	chains, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	if err != nil {
+		return v.reject(serverName, leaf, ReasonChain, err.Error())
+	}
+
+	if len(v.spkiPins) > 0 && !v.spkiPins[SPKIPin(leaf)] {
+		return v.reject(serverName, leaf, ReasonSPKIPin, fmt.Sprintf("server key %s is not pinned", SPKIPin(leaf)))
+	}
+
+	if len(v.caPins) > 0 {
+		chains = v.pinnedChains(chains)
+		if len(chains) == 0 {
+			return v.reject(serverName, leaf, ReasonCAPin, "no CA in the chain matches a pin")
+		}
+	}
+
+	if v.revocation != nil {
+		if err := v.revocation.check(chains[0], cs.OCSPResponse); err != nil {
+			if err.revoked {
+				return v.reject(serverName, leaf, ReasonRevoked, err.Error())
+			}
+			if v.config.Revocation == RevocationHardFail {
+				return v.reject(serverName, leaf, ReasonRevocationUnknown, err.Error())
+			}
+
+			failure := v.failure(serverName, leaf, ReasonRevocationUnknown, err.Error())
+			failure.Accepted = true
+			v.audit(failure)
+		}
+	}
+
+	return nil
+}
+
+// pinnedChains returns the chains containing a pinned CA certificate
+func (v *Verifier) pinnedChains(chains [][]*x509.Certificate) [][]*x509.Certificate {
+	var pinned [][]*x509.Certificate
+	for _, chain := range chains {
+		for _, cert := range chain[1:] {
+			if v.caPins[SPKIPin(cert)] {
+				pinned = append(pinned, chain)
+				break
+			}
+		}
+	}
+	return pinned
+}
+
+// reject audits a failure and returns it as the handshake error
+func (v *Verifier) reject(serverName string, leaf *x509.Certificate, reason, detail string) error {
+	failure := v.failure(serverName, leaf, reason, detail)
+	v.audit(failure)
+	return failure
+}
+
+// failure builds a Failure for the peer
+func (v *Verifier) failure(serverName string, leaf *x509.Certificate, reason, detail string) Failure {
+	failure := Failure{
+		Integration: v.integration,
+		ServerName:  serverName,
+		Reason:      reason,
+		Detail:      detail,
+		Time:        time.Now().UTC(),
+	}
+	if leaf != nil {
+		failure.Subject = leaf.Subject.String()
+		failure.SPKIPin = SPKIPin(leaf)
+	}
+	return failure
+}
+
+// audit reports a failure if an auditor is set
+func (v *Verifier) audit(failure Failure) {
+	if v.auditor != nil {
+		v.auditor(failure)
+	}
+}
+
+// parsePins decodes pins into a set
+func parsePins(pins []string) (map[string]bool, error) {
+	set := make(map[string]bool, len(pins))
+
+	for _, pin := range pins {
+		pin = strings.TrimSpace(pin)
+		if !strings.HasPrefix(pin, pinPrefix) {
+			return nil, fmt.Errorf("pin %q must start with %q", pin, pinPrefix)
+		}
+
+		digest, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(pin, pinPrefix))
+		if err != nil || len(digest) != sha256.Size {
+			return nil, fmt.Errorf("pin %q is not a base64 SHA-256 digest", pin)
+		}
+
+		set[pin] = true
+	}
+
+	return set, nil
+}